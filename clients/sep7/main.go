@@ -0,0 +1,138 @@
+// Package sep7 builds, parses, signs and verifies SEP-7 URIs, which are used
+// to hand transactions and payment requests from one application to another
+// using the web+stellar: URI scheme.
+//
+// See https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0007.md
+package sep7
+
+import (
+	"github.com/metriqorg/go/clients/stellartoml"
+)
+
+// Scheme is the prefix of every SEP-7 URI.
+const Scheme = "web+stellar:"
+
+// MsgMaxLength is the maximum number of characters allowed in the msg
+// parameter.
+const MsgMaxLength = 300
+
+// callbackPrefix is the prefix required for the value of the callback
+// parameter.
+const callbackPrefix = "url:"
+
+// signaturePayloadPrefix is prepended to the URI before it is signed. It is
+// made of 35 zero bytes followed by the byte 4, followed by the SEP-7 marker.
+var signaturePayloadPrefix = append(
+	append(make([]byte, 35), 4),
+	[]byte("stellar.sep.7 - URI Scheme")...,
+)
+
+// Operation is the operation requested by a SEP-7 URI.
+type Operation string
+
+const (
+	// OperationTx requests that a transaction be signed and submitted.
+	OperationTx Operation = "tx"
+	// OperationPay requests a payment to a destination.
+	OperationPay Operation = "pay"
+)
+
+// Memo types supported by the pay operation.
+const (
+	MemoTypeText   = "MEMO_TEXT"
+	MemoTypeID     = "MEMO_ID"
+	MemoTypeHash   = "MEMO_HASH"
+	MemoTypeReturn = "MEMO_RETURN"
+)
+
+// TransactionRequest holds the parameters of a tx operation.
+type TransactionRequest struct {
+	// XDR is the base64 encoded transaction envelope. It is required.
+	XDR string
+	// Replace is a Txrep list of fields the wallet should replace.
+	Replace string
+	// Callback is the URL the signed transaction is posted to instead of
+	// being submitted to the network.
+	Callback string
+	// Pubkey is the account that should sign the transaction.
+	Pubkey string
+	// Chain is a previous SEP-7 URI which triggered this request.
+	Chain string
+	// Msg is shown to the user, at most MsgMaxLength characters.
+	Msg string
+	// NetworkPassphrase is the passphrase of the network the transaction
+	// must be signed for. The public network is assumed when empty.
+	NetworkPassphrase string
+	// OriginDomain is the domain which issued the request. When set, the
+	// URI must be signed with the URI_REQUEST_SIGNING_KEY of its
+	// stellar.toml.
+	OriginDomain string
+}
+
+// PaymentRequest holds the parameters of a pay operation.
+type PaymentRequest struct {
+	// Destination is the account or federation address to pay. It is
+	// required.
+	Destination string
+	// Amount is the amount to pay. When empty the wallet asks the user.
+	Amount string
+	// AssetCode and AssetIssuer identify the asset to pay. The native asset
+	// is used when both are empty.
+	AssetCode   string
+	AssetIssuer string
+	// Memo and MemoType are attached to the payment. Hash and return memos
+	// are base64 encoded.
+	Memo     string
+	MemoType string
+	// Callback is the URL the signed transaction is posted to instead of
+	// being submitted to the network.
+	Callback string
+	// Msg is shown to the user, at most MsgMaxLength characters.
+	Msg string
+	// NetworkPassphrase is the passphrase of the network the payment must be
+	// made on. The public network is assumed when empty.
+	NetworkPassphrase string
+	// OriginDomain is the domain which issued the request. When set, the
+	// URI must be signed with the URI_REQUEST_SIGNING_KEY of its
+	// stellar.toml.
+	OriginDomain string
+}
+
+// URI is a parsed SEP-7 URI. Exactly one of Transaction or Payment is set,
+// according to Operation.
+type URI struct {
+	Operation   Operation
+	Transaction *TransactionRequest
+	Payment     *PaymentRequest
+	// Signature is the base64 encoded signature of the URI, if any.
+	Signature string
+
+	// raw is the URI as it was parsed, which is needed to verify the
+	// signature since re-encoding could change the order of the parameters.
+	raw string
+}
+
+// String returns the URI as it was parsed.
+func (u *URI) String() string {
+	return u.raw
+}
+
+// OriginDomain returns the origin_domain parameter of the URI.
+func (u *URI) OriginDomain() string {
+	if u.Transaction != nil {
+		return u.Transaction.OriginDomain
+	}
+	if u.Payment != nil {
+		return u.Payment.OriginDomain
+	}
+	return ""
+}
+
+// Client verifies SEP-7 URI signatures using the URI_REQUEST_SIGNING_KEY
+// published in the stellar.toml of the origin domain.
+type Client struct {
+	StellarTomlResolver stellartoml.ClientInterface
+}
+
+// DefaultClient is a Client using the default stellar.toml client.
+var DefaultClient = &Client{StellarTomlResolver: stellartoml.DefaultClient}
@@ -0,0 +1,96 @@
+package sep7
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/errors"
+)
+
+const signatureParam = "signature="
+
+// Sign signs an unsigned SEP-7 URI with the given keypair, which should be
+// the URI_REQUEST_SIGNING_KEY of the origin domain, and returns the URI with
+// the signature parameter appended.
+func Sign(uri string, kp *keypair.Full) (string, error) {
+	parsed, err := parse(uri, false)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Signature != "" {
+		return "", errors.New("uri is already signed")
+	}
+
+	sig, err := kp.Sign(signaturePayload(uri))
+	if err != nil {
+		return "", errors.Wrap(err, "could not sign uri")
+	}
+	return uri + "&" + signatureParam + escape(base64.StdEncoding.EncodeToString(sig)), nil
+}
+
+// Verify verifies that the URI was signed by signingKey.
+func (u *URI) Verify(signingKey string) error {
+	if u.Signature == "" {
+		return errors.New("uri is not signed")
+	}
+	kp, err := keypair.ParseAddress(signingKey)
+	if err != nil {
+		return errors.Wrap(err, "signing key is invalid")
+	}
+
+	// The signature only covers the URI before it, so nothing can follow it.
+	idx := strings.LastIndex(u.raw, "&"+signatureParam)
+	if idx < 0 {
+		return errors.New("signature must be the last parameter of the uri")
+	}
+	value, err := url.QueryUnescape(u.raw[idx+len("&"+signatureParam):])
+	if err != nil || value != u.Signature {
+		return errors.New("signature must be the last parameter of the uri")
+	}
+	sig, err := base64.StdEncoding.DecodeString(u.Signature)
+	if err != nil {
+		return errors.Wrap(err, "signature is not valid base64")
+	}
+	if err = kp.Verify(signaturePayload(u.raw[:idx]), sig); err != nil {
+		return errors.New("signature is not valid")
+	}
+	return nil
+}
+
+// Verify parses a SEP-7 URI and verifies that it was signed with the
+// URI_REQUEST_SIGNING_KEY of its origin domain. It returns the parsed URI.
+func (c *Client) Verify(uri string) (*URI, error) {
+	parsed, err := Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	domain := parsed.OriginDomain()
+	if domain == "" {
+		return nil, errors.New("uri has no origin_domain")
+	}
+
+	toml, err := c.StellarTomlResolver.GetStellarToml(domain)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch stellar.toml of %s", domain)
+	}
+	if toml.UriRequestSigningKey == "" {
+		return nil, errors.Errorf("stellar.toml of %s has no URI_REQUEST_SIGNING_KEY", domain)
+	}
+	if err = parsed.Verify(toml.UriRequestSigningKey); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// Verify verifies a SEP-7 URI using the DefaultClient.
+func Verify(uri string) (*URI, error) {
+	return DefaultClient.Verify(uri)
+}
+
+func signaturePayload(uri string) []byte {
+	payload := make([]byte, 0, len(signaturePayloadPrefix)+len(uri))
+	payload = append(payload, signaturePayloadPrefix...)
+	return append(payload, uri...)
+}
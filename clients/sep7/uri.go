@@ -0,0 +1,275 @@
+package sep7
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/metriqorg/go/amount"
+	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+)
+
+// NewTransactionRequest returns a TransactionRequest for the given
+// transaction. The other parameters of the request can be set on the
+// returned value before calling URI.
+func NewTransactionRequest(tx *txnbuild.Transaction) (TransactionRequest, error) {
+	if tx == nil {
+		return TransactionRequest{}, errors.New("transaction is missing")
+	}
+	xdr, err := tx.Base64()
+	if err != nil {
+		return TransactionRequest{}, errors.Wrap(err, "could not encode transaction")
+	}
+	return TransactionRequest{XDR: xdr}, nil
+}
+
+// NewPaymentRequest returns a PaymentRequest for the given payment operation
+// and optional memo.
+func NewPaymentRequest(payment txnbuild.Payment, memo txnbuild.Memo) (PaymentRequest, error) {
+	r := PaymentRequest{
+		Destination: payment.Destination,
+		Amount:      payment.Amount,
+	}
+	if payment.Asset != nil && !payment.Asset.IsNative() {
+		r.AssetCode = payment.Asset.GetCode()
+		r.AssetIssuer = payment.Asset.GetIssuer()
+	}
+
+	switch m := memo.(type) {
+	case nil:
+	case txnbuild.MemoText:
+		r.Memo, r.MemoType = string(m), MemoTypeText
+	case txnbuild.MemoID:
+		r.Memo, r.MemoType = strconv.FormatUint(uint64(m), 10), MemoTypeID
+	case txnbuild.MemoHash:
+		r.Memo, r.MemoType = base64.StdEncoding.EncodeToString(m[:]), MemoTypeHash
+	case txnbuild.MemoReturn:
+		r.Memo, r.MemoType = base64.StdEncoding.EncodeToString(m[:]), MemoTypeReturn
+	default:
+		return PaymentRequest{}, errors.Errorf("unsupported memo type %T", memo)
+	}
+
+	return r, r.Validate()
+}
+
+// Transaction decodes the transaction of the request.
+func (r TransactionRequest) Transaction() (*txnbuild.GenericTransaction, error) {
+	return txnbuild.TransactionFromXDR(r.XDR)
+}
+
+// Validate returns an error if the request is not a valid tx request.
+func (r TransactionRequest) Validate() error {
+	if r.XDR == "" {
+		return errors.New("xdr is required")
+	}
+	if _, err := r.Transaction(); err != nil {
+		return errors.Wrap(err, "xdr is not a valid transaction envelope")
+	}
+	if r.Pubkey != "" && !strkey.IsValidEd25519PublicKey(r.Pubkey) {
+		return errors.New("pubkey is not a valid account")
+	}
+	return validateCommon(r.Callback, r.Msg, r.OriginDomain)
+}
+
+// URI returns the unsigned SEP-7 URI of the request.
+func (r TransactionRequest) URI() (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+	q := query{}
+	q.add("xdr", r.XDR)
+	q.add("replace", r.Replace)
+	q.addCallback(r.Callback)
+	q.add("pubkey", r.Pubkey)
+	q.add("chain", r.Chain)
+	q.add("msg", r.Msg)
+	q.add("network_passphrase", r.NetworkPassphrase)
+	q.add("origin_domain", r.OriginDomain)
+	return q.uri(OperationTx), nil
+}
+
+// Validate returns an error if the request is not a valid pay request.
+func (r PaymentRequest) Validate() error {
+	if r.Destination == "" {
+		return errors.New("destination is required")
+	}
+	if r.Amount != "" {
+		if _, err := amount.ParseInt64(r.Amount); err != nil {
+			return errors.Wrap(err, "amount is invalid")
+		}
+	}
+	if (r.AssetCode == "") != (r.AssetIssuer == "") {
+		return errors.New("asset_code and asset_issuer must be provided together")
+	}
+	if r.AssetIssuer != "" && !strkey.IsValidEd25519PublicKey(r.AssetIssuer) {
+		return errors.New("asset_issuer is not a valid account")
+	}
+
+	switch r.MemoType {
+	case "":
+		if r.Memo != "" {
+			return errors.New("memo_type is required when memo is provided")
+		}
+	case MemoTypeText:
+		if _, err := txnbuild.MemoText(r.Memo).ToXDR(); err != nil {
+			return errors.Wrap(err, "memo is invalid")
+		}
+	case MemoTypeID:
+		if _, err := strconv.ParseUint(r.Memo, 10, 64); err != nil {
+			return errors.New("memo is not a valid id")
+		}
+	case MemoTypeHash, MemoTypeReturn:
+		b, err := base64.StdEncoding.DecodeString(r.Memo)
+		if err != nil || len(b) != 32 {
+			return errors.New("memo is not a base64 encoded 32 byte hash")
+		}
+	default:
+		return errors.Errorf("memo_type %s is not supported", r.MemoType)
+	}
+
+	return validateCommon(r.Callback, r.Msg, r.OriginDomain)
+}
+
+// URI returns the unsigned SEP-7 URI of the request.
+func (r PaymentRequest) URI() (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+	q := query{}
+	q.add("destination", r.Destination)
+	q.add("amount", r.Amount)
+	q.add("asset_code", r.AssetCode)
+	q.add("asset_issuer", r.AssetIssuer)
+	q.add("memo", r.Memo)
+	q.add("memo_type", r.MemoType)
+	q.addCallback(r.Callback)
+	q.add("msg", r.Msg)
+	q.add("network_passphrase", r.NetworkPassphrase)
+	q.add("origin_domain", r.OriginDomain)
+	return q.uri(OperationPay), nil
+}
+
+func validateCommon(callback, msg, originDomain string) error {
+	if callback != "" {
+		u, err := url.Parse(callback)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("callback is not a valid url")
+		}
+	}
+	if utf8.RuneCountInString(msg) > MsgMaxLength {
+		return errors.Errorf("msg cannot be longer than %d characters", MsgMaxLength)
+	}
+	if originDomain != "" && (strings.ContainsAny(originDomain, ":/ ") || !strings.Contains(originDomain, ".")) {
+		return errors.New("origin_domain is not a fully qualified domain name")
+	}
+	return nil
+}
+
+// query builds the query string of a URI keeping the order in which the
+// parameters are added, which matters for signatures.
+type query []string
+
+func (q *query) add(key, value string) {
+	if value == "" {
+		return
+	}
+	*q = append(*q, key+"="+escape(value))
+}
+
+func (q *query) addCallback(callback string) {
+	if callback == "" {
+		return
+	}
+	q.add("callback", callbackPrefix+callback)
+}
+
+func (q query) uri(op Operation) string {
+	return Scheme + string(op) + "?" + strings.Join(q, "&")
+}
+
+// escape percent-encodes a parameter value. Spaces are encoded as %20
+// rather than + so that the URI is readable by every implementation.
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// Parse parses and validates a SEP-7 URI. The signature is not verified, use
+// Verify or Client.Verify for that.
+func Parse(uri string) (*URI, error) {
+	return parse(uri, true)
+}
+
+// parse parses a SEP-7 URI. Signing a URI requires parsing it before the
+// signature is added, in which case requireSignature is false.
+func parse(uri string, requireSignature bool) (*URI, error) {
+	if !strings.HasPrefix(uri, Scheme) {
+		return nil, errors.Errorf("uri does not start with %s", Scheme)
+	}
+	rest := strings.TrimPrefix(uri, Scheme)
+	op, rawQuery, _ := strings.Cut(rest, "?")
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse uri parameters")
+	}
+	for key, v := range values {
+		if len(v) > 1 {
+			return nil, errors.Errorf("parameter %s is repeated", key)
+		}
+	}
+
+	callback := values.Get("callback")
+	if callback != "" {
+		if !strings.HasPrefix(callback, callbackPrefix) {
+			return nil, errors.Errorf("callback must start with %s", callbackPrefix)
+		}
+		callback = strings.TrimPrefix(callback, callbackPrefix)
+	}
+
+	result := &URI{
+		Operation: Operation(op),
+		Signature: values.Get("signature"),
+		raw:       uri,
+	}
+	switch result.Operation {
+	case OperationTx:
+		result.Transaction = &TransactionRequest{
+			XDR:               values.Get("xdr"),
+			Replace:           values.Get("replace"),
+			Callback:          callback,
+			Pubkey:            values.Get("pubkey"),
+			Chain:             values.Get("chain"),
+			Msg:               values.Get("msg"),
+			NetworkPassphrase: values.Get("network_passphrase"),
+			OriginDomain:      values.Get("origin_domain"),
+		}
+		err = result.Transaction.Validate()
+	case OperationPay:
+		result.Payment = &PaymentRequest{
+			Destination:       values.Get("destination"),
+			Amount:            values.Get("amount"),
+			AssetCode:         values.Get("asset_code"),
+			AssetIssuer:       values.Get("asset_issuer"),
+			Memo:              values.Get("memo"),
+			MemoType:          values.Get("memo_type"),
+			Callback:          callback,
+			Msg:               values.Get("msg"),
+			NetworkPassphrase: values.Get("network_passphrase"),
+			OriginDomain:      values.Get("origin_domain"),
+		}
+		err = result.Payment.Validate()
+	default:
+		return nil, errors.Errorf("operation %q is not supported", op)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if requireSignature && result.OriginDomain() != "" && result.Signature == "" {
+		return nil, errors.New("uri with an origin_domain must be signed")
+	}
+	return result, nil
+}
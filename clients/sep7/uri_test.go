@@ -0,0 +1,177 @@
+package sep7
+
+import (
+	"testing"
+
+	"github.com/metriqorg/go/clients/stellartoml"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDestination = "GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO"
+	testIssuer      = "GDQNY3PBOJOKYZSRMK2S7LHHGWZIUISD4QORETLMXEWXBI7KFZZMKTL3"
+)
+
+func newTestTransaction(t *testing.T) *txnbuild.Transaction {
+	source := txnbuild.NewSimpleAccount(testIssuer, 1)
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &source,
+		Operations: []txnbuild.Operation{
+			&txnbuild.Payment{Destination: testDestination, Amount: "10", Asset: txnbuild.NativeAsset{}},
+		},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestTransactionRequestRoundTrip(t *testing.T) {
+	tx := newTestTransaction(t)
+	req, err := NewTransactionRequest(tx)
+	require.NoError(t, err)
+	req.Callback = "https://example.com/callback?id=1"
+	req.Msg = "order 1234 & more"
+	req.NetworkPassphrase = network.TestNetworkPassphrase
+
+	uri, err := req.URI()
+	require.NoError(t, err)
+	assert.Contains(t, uri, "web+stellar:tx?xdr=")
+	assert.Contains(t, uri, "&callback=url%3Ahttps%3A%2F%2Fexample.com%2Fcallback%3Fid%3D1")
+	assert.Contains(t, uri, "&msg=order%201234%20%26%20more")
+
+	parsed, err := Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, OperationTx, parsed.Operation)
+	assert.Nil(t, parsed.Payment)
+	assert.Equal(t, req, *parsed.Transaction)
+
+	gtx, err := parsed.Transaction.Transaction()
+	require.NoError(t, err)
+	parsedTx, ok := gtx.Transaction()
+	require.True(t, ok)
+	assert.Equal(t, tx.SourceAccount(), parsedTx.SourceAccount())
+}
+
+func TestPaymentRequestRoundTrip(t *testing.T) {
+	req, err := NewPaymentRequest(
+		txnbuild.Payment{
+			Destination: testDestination,
+			Amount:      "120.123456",
+			Asset:       txnbuild.CreditAsset{Code: "USD", Issuer: testIssuer},
+		},
+		txnbuild.MemoID(42),
+	)
+	require.NoError(t, err)
+
+	uri, err := req.URI()
+	require.NoError(t, err)
+	assert.Equal(t,
+		"web+stellar:pay?destination="+testDestination+"&amount=120.123456&asset_code=USD&asset_issuer="+testIssuer+"&memo=42&memo_type=MEMO_ID",
+		uri,
+	)
+
+	parsed, err := Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, OperationPay, parsed.Operation)
+	assert.Equal(t, req, *parsed.Payment)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		uri string
+		err string
+	}{
+		{"https://example.com", "uri does not start with web+stellar:"},
+		{"web+stellar:foo?bar=1", `operation "foo" is not supported`},
+		{"web+stellar:pay?amount=1", "destination is required"},
+		{"web+stellar:pay?destination=" + testDestination + "&destination=" + testDestination, "parameter destination is repeated"},
+		{"web+stellar:pay?destination=" + testDestination + "&asset_code=USD", "asset_code and asset_issuer must be provided together"},
+		{"web+stellar:pay?destination=" + testDestination + "&memo=x&memo_type=MEMO_HASH", "memo is not a base64 encoded 32 byte hash"},
+		{"web+stellar:pay?destination=" + testDestination + "&callback=https://example.com", "callback must start with url:"},
+		{"web+stellar:pay?destination=" + testDestination + "&origin_domain=example.com", "uri with an origin_domain must be signed"},
+	} {
+		_, err := Parse(tc.uri)
+		assert.EqualError(t, err, tc.err, tc.uri)
+	}
+
+	_, err := Parse("web+stellar:tx?xdr=AAAA")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "xdr is not a valid transaction envelope")
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer := keypair.MustRandom()
+	req := PaymentRequest{
+		Destination:  testDestination,
+		Amount:       "5",
+		Msg:          "pay me",
+		OriginDomain: "example.com",
+	}
+	uri, err := req.URI()
+	require.NoError(t, err)
+
+	signed, err := Sign(uri, signer)
+	require.NoError(t, err)
+	assert.Contains(t, signed, uri+"&signature=")
+
+	_, err = Sign(signed, signer)
+	assert.EqualError(t, err, "uri is already signed")
+
+	parsed, err := Parse(signed)
+	require.NoError(t, err)
+	assert.NoError(t, parsed.Verify(signer.Address()))
+	assert.EqualError(t, parsed.Verify(keypair.MustRandom().Address()), "signature is not valid")
+
+	tampered, err := Parse(signed[:len(Scheme)+len("pay?")] + "destination=" + testIssuer + signed[len(Scheme)+len("pay?destination=")+len(testDestination):])
+	require.NoError(t, err)
+	assert.EqualError(t, tampered.Verify(signer.Address()), "signature is not valid")
+
+	// parameters appended after the signature are not covered by it
+	appended, err := Parse(signed + "&callback=url%3Ahttps%3A%2F%2Fevil.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "https://evil.example.com", appended.Payment.Callback)
+	assert.EqualError(t, appended.Verify(signer.Address()), "signature must be the last parameter of the uri")
+}
+
+func TestClientVerify(t *testing.T) {
+	signer := keypair.MustRandom()
+	req, err := NewTransactionRequest(newTestTransaction(t))
+	require.NoError(t, err)
+	req.OriginDomain = "example.com"
+	uri, err := req.URI()
+	require.NoError(t, err)
+	signed, err := Sign(uri, signer)
+	require.NoError(t, err)
+
+	tomlClient := &stellartoml.MockClient{}
+	client := &Client{StellarTomlResolver: tomlClient}
+
+	tomlClient.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{UriRequestSigningKey: signer.Address()}, nil).Once()
+	parsed, err := client.Verify(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", parsed.OriginDomain())
+
+	tomlClient.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{UriRequestSigningKey: keypair.MustRandom().Address()}, nil).Once()
+	_, err = client.Verify(signed)
+	assert.EqualError(t, err, "signature is not valid")
+
+	tomlClient.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{}, nil).Once()
+	_, err = client.Verify(signed)
+	assert.EqualError(t, err, "stellar.toml of example.com has no URI_REQUEST_SIGNING_KEY")
+
+	tomlClient.On("GetStellarToml", "example.com").
+		Return((*stellartoml.Response)(nil), errors.New("not found")).Once()
+	_, err = client.Verify(signed)
+	assert.EqualError(t, err, "could not fetch stellar.toml of example.com: not found")
+
+	tomlClient.AssertExpectations(t)
+}
@@ -87,6 +87,8 @@ type Response struct {
 	TransferServer0024            string      `toml:"TRANSFER_SERVER_0024"`
 	KycServer                     string      `toml:"KYC_SERVER"`
 	WebAuthEndpoint               string      `toml:"WEB_AUTH_ENDPOINT"`
+	WebAuthForContractsEndpoint   string      `toml:"WEB_AUTH_FOR_CONTRACTS_ENDPOINT"`
+	WebAuthContractID             string      `toml:"WEB_AUTH_CONTRACT_ID"`
 	SigningKey                    string      `toml:"SIGNING_KEY"`
 	OrbitRUrl                    string      `toml:"ORBITR_URL"`
 	Accounts                      []string    `toml:"ACCOUNTS"`
@@ -0,0 +1,97 @@
+package webauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// Error is an error response of a web authentication server.
+type Error struct {
+	StatusCode int
+	Message    string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("web auth server error (%d): %s", e.StatusCode, e.Message)
+}
+
+// challengeResponse is the response of the challenge endpoint of both SEP-10
+// and SEP-45 servers.
+type challengeResponse struct {
+	Transaction          string `json:"transaction"`
+	AuthorizationEntries string `json:"authorization_entries"`
+	NetworkPassphrase    string `json:"network_passphrase"`
+}
+
+type tokenResponse struct {
+	Token string `json:"token"`
+}
+
+func (c *Client) getJSON(endpoint string, query url.Values, dest interface{}) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return errors.Wrap(err, "parsing endpoint")
+	}
+	q := u.Query()
+	for k, v := range query {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "building request")
+	}
+	return c.do(req, dest)
+}
+
+func (c *Client) postJSON(endpoint string, body interface{}, dest interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "encoding request")
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "building request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, dest)
+}
+
+func (c *Client) do(req *http.Request, dest interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "sending request to %s", req.URL)
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, ResponseMaxSize)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		serverErr := &Error{StatusCode: resp.StatusCode}
+		if json.NewDecoder(body).Decode(serverErr) != nil || serverErr.Message == "" {
+			serverErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return serverErr
+	}
+	if err = json.NewDecoder(body).Decode(dest); err != nil {
+		return errors.Wrapf(err, "decoding response from %s", req.URL)
+	}
+	return nil
+}
+
+// webAuthDomain returns the domain hosting the endpoint, which servers
+// include in challenges.
+func webAuthDomain(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", errors.Errorf("endpoint %q is not a valid url", endpoint)
+	}
+	return u.Host, nil
+}
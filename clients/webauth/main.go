@@ -0,0 +1,121 @@
+// Package webauth performs the SEP-10 and SEP-45 web authentication flows
+// against anchors and other services, and caches the returned JWTs until they
+// expire.
+//
+// The web authentication endpoint and signing key of a service are looked up
+// in the stellar.toml of its home domain. Stellar accounts (G... and M...
+// addresses) authenticate using SEP-10 challenge transactions, contract
+// accounts (C... addresses) authenticate using SEP-45 authorization entries.
+//
+// See https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0010.md
+// and https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0045.md
+package webauth
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/metriqorg/go/clients/stellartoml"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/support/clock"
+)
+
+// DefaultExpiryLeeway is the leeway used when the client has no ExpiryLeeway.
+const DefaultExpiryLeeway = 30 * time.Second
+
+// ResponseMaxSize is the maximum size of a response from a web
+// authentication server.
+const ResponseMaxSize = 100 * 1024
+
+// DefaultPublicNetClient is a default client for the public network.
+var DefaultPublicNetClient = &Client{
+	StellarTOML:       stellartoml.DefaultClient,
+	HTTP:              http.DefaultClient,
+	NetworkPassphrase: network.PublicNetworkPassphrase,
+}
+
+// DefaultTestNetClient is a default client for the test network.
+var DefaultTestNetClient = &Client{
+	StellarTOML:       stellartoml.DefaultClient,
+	HTTP:              http.DefaultClient,
+	NetworkPassphrase: network.TestNetworkPassphrase,
+}
+
+// DefaultClient is the client used by the package level functions. It
+// authenticates on the public network.
+var DefaultClient = DefaultPublicNetClient
+
+// HTTP represents the http client used to send requests to web
+// authentication servers.
+type HTTP interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// StellarTOML represents a client that can resolve a home domain to its
+// stellar.toml file.
+type StellarTOML interface {
+	GetStellarToml(domain string) (*stellartoml.Response, error)
+}
+
+// Client authenticates with web authentication servers. A Client caches the
+// tokens it receives and is safe for concurrent use.
+type Client struct {
+	StellarTOML StellarTOML
+	HTTP        HTTP
+	// NetworkPassphrase is the passphrase of the network challenges must be
+	// issued for. Challenges for another network are rejected.
+	NetworkPassphrase string
+	// ExpiryLeeway is how long before their expiry cached tokens are
+	// considered expired. DefaultExpiryLeeway is used when zero.
+	ExpiryLeeway time.Duration
+	Clock        *clock.Clock
+
+	mu     sync.Mutex
+	tokens map[tokenKey]Token
+}
+
+// Request describes who to authenticate with which service.
+type Request struct {
+	// HomeDomain is the domain hosting the stellar.toml of the service.
+	HomeDomain string
+	// Account is the account to authenticate. It is a Stellar account (G...
+	// or M...) for SEP-10 and a contract (C...) for SEP-45. It defaults to
+	// the address of the first signer.
+	Account string
+	// Memo is the optional SEP-10 memo identifying a user of a shared
+	// account.
+	Memo *uint64
+	// Signers sign the challenge. For SEP-10 they must meet the threshold
+	// the server requires of Account. For SEP-45 they sign the authorization
+	// entry of the contract using the ed25519 signature format.
+	Signers []*keypair.Full
+	// SignatureExpirationLedger is the ledger after which the signature of
+	// the SEP-45 authorization entry of the contract expires. It is required
+	// for SEP-45.
+	SignatureExpirationLedger uint32
+}
+
+func (r Request) account() string {
+	if r.Account == "" && len(r.Signers) > 0 {
+		return r.Signers[0].Address()
+	}
+	return r.Account
+}
+
+// Authenticate returns a token for the request using the DefaultClient.
+func Authenticate(req Request) (Token, error) {
+	return DefaultClient.Authenticate(req)
+}
+
+// Refresh authenticates using the DefaultClient, ignoring cached tokens.
+func Refresh(req Request) (Token, error) {
+	return DefaultClient.Refresh(req)
+}
+
+// Transport returns a RoundTripper that authenticates requests using the
+// DefaultClient.
+func Transport(req Request, base http.RoundTripper) http.RoundTripper {
+	return DefaultClient.Transport(req, base)
+}
@@ -0,0 +1,76 @@
+package webauth
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+)
+
+// authenticateAccount performs the SEP-10 flow for a Stellar account.
+func (c *Client) authenticateAccount(req Request) (Token, error) {
+	account := req.account()
+	toml, err := c.StellarTOML.GetStellarToml(req.HomeDomain)
+	if err != nil {
+		return Token{}, errors.Wrapf(err, "getting stellar.toml of %s", req.HomeDomain)
+	}
+	if toml.WebAuthEndpoint == "" {
+		return Token{}, errors.Errorf("stellar.toml of %s has no WEB_AUTH_ENDPOINT", req.HomeDomain)
+	}
+	if toml.SigningKey == "" {
+		return Token{}, errors.Errorf("stellar.toml of %s has no SIGNING_KEY", req.HomeDomain)
+	}
+	domain, err := webAuthDomain(toml.WebAuthEndpoint)
+	if err != nil {
+		return Token{}, err
+	}
+
+	query := url.Values{}
+	query.Set("account", account)
+	query.Set("home_domain", req.HomeDomain)
+	if req.Memo != nil {
+		query.Set("memo", strconv.FormatUint(*req.Memo, 10))
+	}
+	challenge := challengeResponse{}
+	if err = c.getJSON(toml.WebAuthEndpoint, query, &challenge); err != nil {
+		return Token{}, errors.Wrap(err, "getting challenge")
+	}
+	if challenge.NetworkPassphrase != "" && challenge.NetworkPassphrase != c.NetworkPassphrase {
+		return Token{}, errors.Errorf("challenge is for network %q", challenge.NetworkPassphrase)
+	}
+
+	// ReadChallengeTx verifies the challenge is signed by the signing key of
+	// the stellar.toml, and that it is for the home domain and endpoint.
+	tx, clientAccountID, _, memo, err := txnbuild.ReadChallengeTx(
+		challenge.Transaction,
+		toml.SigningKey,
+		c.NetworkPassphrase,
+		domain,
+		[]string{req.HomeDomain},
+	)
+	if err != nil {
+		return Token{}, errors.Wrap(err, "reading challenge")
+	}
+	if clientAccountID != account {
+		return Token{}, errors.Errorf("challenge is for account %s", clientAccountID)
+	}
+	if (memo == nil) != (req.Memo == nil) || (memo != nil && uint64(*memo) != *req.Memo) {
+		return Token{}, errors.New("challenge memo does not match the request")
+	}
+
+	tx, err = tx.Sign(c.NetworkPassphrase, req.Signers...)
+	if err != nil {
+		return Token{}, errors.Wrap(err, "signing challenge")
+	}
+	signed, err := tx.Base64()
+	if err != nil {
+		return Token{}, errors.Wrap(err, "encoding challenge")
+	}
+
+	resp := tokenResponse{}
+	if err = c.postJSON(toml.WebAuthEndpoint, map[string]string{"transaction": signed}, &resp); err != nil {
+		return Token{}, errors.Wrap(err, "getting token")
+	}
+	return parseToken(resp.Token, req.HomeDomain)
+}
@@ -0,0 +1,334 @@
+package webauth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+	xdr3 "github.com/stellar/go-xdr/xdr3"
+)
+
+// WebAuthVerifyFunction is the function of the web auth contract that SEP-45
+// authorization entries invoke.
+const WebAuthVerifyFunction = "web_auth_verify"
+
+// authenticateContract performs the SEP-45 flow for a contract account.
+func (c *Client) authenticateContract(req Request) (Token, error) {
+	if req.SignatureExpirationLedger == 0 {
+		return Token{}, errors.New("signature expiration ledger is required to authenticate a contract")
+	}
+	toml, err := c.StellarTOML.GetStellarToml(req.HomeDomain)
+	if err != nil {
+		return Token{}, errors.Wrapf(err, "getting stellar.toml of %s", req.HomeDomain)
+	}
+	if toml.WebAuthForContractsEndpoint == "" {
+		return Token{}, errors.Errorf("stellar.toml of %s has no WEB_AUTH_FOR_CONTRACTS_ENDPOINT", req.HomeDomain)
+	}
+	if toml.WebAuthContractID == "" {
+		return Token{}, errors.Errorf("stellar.toml of %s has no WEB_AUTH_CONTRACT_ID", req.HomeDomain)
+	}
+	if toml.SigningKey == "" {
+		return Token{}, errors.Errorf("stellar.toml of %s has no SIGNING_KEY", req.HomeDomain)
+	}
+	domain, err := webAuthDomain(toml.WebAuthForContractsEndpoint)
+	if err != nil {
+		return Token{}, err
+	}
+
+	query := url.Values{}
+	query.Set("account", req.Account)
+	query.Set("home_domain", req.HomeDomain)
+	challenge := challengeResponse{}
+	if err = c.getJSON(toml.WebAuthForContractsEndpoint, query, &challenge); err != nil {
+		return Token{}, errors.Wrap(err, "getting challenge")
+	}
+	if challenge.NetworkPassphrase != "" && challenge.NetworkPassphrase != c.NetworkPassphrase {
+		return Token{}, errors.Errorf("challenge is for network %q", challenge.NetworkPassphrase)
+	}
+
+	entries, err := DecodeAuthorizationEntries(challenge.AuthorizationEntries)
+	if err != nil {
+		return Token{}, err
+	}
+	err = c.verifyAuthorizationEntries(entries, challengeArgs{
+		"account":                 req.Account,
+		"home_domain":             req.HomeDomain,
+		"web_auth_domain":         domain,
+		"web_auth_domain_account": toml.SigningKey,
+	}, toml.WebAuthContractID, toml.SigningKey)
+	if err != nil {
+		return Token{}, errors.Wrap(err, "verifying challenge")
+	}
+
+	signed := false
+	for i := range entries {
+		creds := entries[i].Credentials.Address
+		if creds == nil || !addressIs(creds.Address, req.Account) {
+			continue
+		}
+		creds.SignatureExpirationLedger = xdr.Uint32(req.SignatureExpirationLedger)
+		payload, err := authorizationPayload(entries[i], c.NetworkPassphrase)
+		if err != nil {
+			return Token{}, err
+		}
+		sig, err := signatureScVal(payload, req.Signers)
+		if err != nil {
+			return Token{}, err
+		}
+		creds.Signature = sig
+		signed = true
+	}
+	if !signed {
+		return Token{}, errors.New("challenge has no authorization entry for the account")
+	}
+
+	encoded, err := EncodeAuthorizationEntries(entries)
+	if err != nil {
+		return Token{}, err
+	}
+	resp := tokenResponse{}
+	if err = c.postJSON(toml.WebAuthForContractsEndpoint, map[string]string{"authorization_entries": encoded}, &resp); err != nil {
+		return Token{}, errors.Wrap(err, "getting token")
+	}
+	return parseToken(resp.Token, req.HomeDomain)
+}
+
+// challengeArgs are the arguments of the web_auth_verify invocation that the
+// client checks.
+type challengeArgs map[string]string
+
+// verifyAuthorizationEntries checks that every entry only invokes
+// web_auth_verify on the web auth contract with the expected arguments, and
+// that the entry of the server is signed by its signing key.
+func (c *Client) verifyAuthorizationEntries(entries []xdr.SorobanAuthorizationEntry, expected challengeArgs, contractID, signingKey string) error {
+	if len(entries) == 0 {
+		return errors.New("challenge has no authorization entries")
+	}
+	serverSigned := false
+	for _, entry := range entries {
+		fn, ok := entry.RootInvocation.Function.GetContractFn()
+		if !ok {
+			return errors.New("authorization entry does not invoke a contract function")
+		}
+		if !addressIs(fn.ContractAddress, contractID) {
+			return errors.New("authorization entry does not invoke the web auth contract")
+		}
+		if string(fn.FunctionName) != WebAuthVerifyFunction {
+			return errors.Errorf("authorization entry invokes %s", fn.FunctionName)
+		}
+		if len(entry.RootInvocation.SubInvocations) > 0 {
+			return errors.New("authorization entry has sub-invocations")
+		}
+		args, err := invocationArgs(fn.Args)
+		if err != nil {
+			return err
+		}
+		for k, v := range expected {
+			if args[k] != v {
+				return errors.Errorf("argument %s is %q, expected %q", k, args[k], v)
+			}
+		}
+		if args["nonce"] == "" {
+			return errors.New("argument nonce is missing")
+		}
+
+		creds := entry.Credentials.Address
+		if creds != nil && addressIs(creds.Address, signingKey) {
+			payload, err := authorizationPayload(entry, c.NetworkPassphrase)
+			if err != nil {
+				return err
+			}
+			if err = verifySignatureScVal(payload, creds.Signature, signingKey); err != nil {
+				return err
+			}
+			serverSigned = true
+		}
+	}
+	if !serverSigned {
+		return errors.New("challenge is not signed by the server")
+	}
+	return nil
+}
+
+// invocationArgs returns the single map argument of web_auth_verify.
+func invocationArgs(args xdr.ScVec) (map[string]string, error) {
+	if len(args) != 1 {
+		return nil, errors.New("web_auth_verify must have a single argument")
+	}
+	m, ok := args[0].GetMap()
+	if !ok || m == nil {
+		return nil, errors.New("web_auth_verify argument is not a map")
+	}
+	result := map[string]string{}
+	for _, e := range *m {
+		key, ok := scValString(e.Key)
+		if !ok {
+			return nil, errors.New("web_auth_verify argument has a key that is not a string")
+		}
+		val, ok := scValString(e.Val)
+		if !ok {
+			return nil, errors.Errorf("web_auth_verify argument %s is not a string", key)
+		}
+		result[key] = val
+	}
+	return result, nil
+}
+
+func scValString(v xdr.ScVal) (string, bool) {
+	if s, ok := v.GetStr(); ok {
+		return string(s), true
+	}
+	if s, ok := v.GetSym(); ok {
+		return string(s), true
+	}
+	return "", false
+}
+
+// addressIs returns true if the address is the account or contract address.
+func addressIs(address xdr.ScAddress, expected string) bool {
+	s, err := address.String()
+	return err == nil && s == expected
+}
+
+// authorizationPayload returns the hash signed by the credentials of an
+// authorization entry.
+func authorizationPayload(entry xdr.SorobanAuthorizationEntry, networkPassphrase string) ([32]byte, error) {
+	creds := entry.Credentials.Address
+	if creds == nil {
+		return [32]byte{}, errors.New("authorization entry has no address credentials")
+	}
+	preimage := xdr.HashIdPreimage{
+		Type: xdr.EnvelopeTypeEnvelopeTypeSorobanAuthorization,
+		SorobanAuthorization: &xdr.HashIdPreimageSorobanAuthorization{
+			NetworkId:                 network.ID(networkPassphrase),
+			Nonce:                     creds.Nonce,
+			SignatureExpirationLedger: creds.SignatureExpirationLedger,
+			Invocation:                entry.RootInvocation,
+		},
+	}
+	b, err := preimage.MarshalBinary()
+	if err != nil {
+		return [32]byte{}, errors.Wrap(err, "encoding authorization preimage")
+	}
+	return sha256.Sum256(b), nil
+}
+
+// signatureScVal returns the signature of the payload in the format used by
+// Stellar accounts, a vector of maps holding the public_key and signature.
+func signatureScVal(payload [32]byte, signers []*keypair.Full) (xdr.ScVal, error) {
+	sigs := xdr.ScVec{}
+	for _, kp := range signers {
+		sig, err := kp.Sign(payload[:])
+		if err != nil {
+			return xdr.ScVal{}, errors.Wrapf(err, "signing with %s", kp.Address())
+		}
+		publicKey, err := strkey.Decode(strkey.VersionByteAccountID, kp.Address())
+		if err != nil {
+			return xdr.ScVal{}, err
+		}
+		m := &xdr.ScMap{
+			{Key: symbol("public_key"), Val: scBytes(publicKey)},
+			{Key: symbol("signature"), Val: scBytes(sig)},
+		}
+		sigs = append(sigs, xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &m})
+	}
+	vec := &sigs
+	return xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &vec}, nil
+}
+
+// verifySignatureScVal verifies that a signature in the format used by
+// Stellar accounts contains a valid signature of the payload by signer.
+func verifySignatureScVal(payload [32]byte, sig xdr.ScVal, signer string) error {
+	kp, err := keypair.ParseAddress(signer)
+	if err != nil {
+		return errors.Wrap(err, "parsing signing key")
+	}
+	publicKey, err := strkey.Decode(strkey.VersionByteAccountID, signer)
+	if err != nil {
+		return errors.Wrap(err, "decoding signing key")
+	}
+	vec, ok := sig.GetVec()
+	if !ok || vec == nil {
+		return errors.New("server signature is not a vector")
+	}
+	for _, v := range *vec {
+		m, ok := v.GetMap()
+		if !ok || m == nil {
+			continue
+		}
+		var key, signature []byte
+		for _, e := range *m {
+			name, _ := scValString(e.Key)
+			b, ok := e.Val.GetBytes()
+			if !ok {
+				continue
+			}
+			switch name {
+			case "public_key":
+				key = b
+			case "signature":
+				signature = b
+			}
+		}
+		if bytes.Equal(key, publicKey) && kp.Verify(payload[:], signature) == nil {
+			return nil
+		}
+	}
+	return errors.New("server signature is not valid")
+}
+
+func symbol(s string) xdr.ScVal {
+	sym := xdr.ScSymbol(s)
+	return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}
+}
+
+func scBytes(b []byte) xdr.ScVal {
+	bs := xdr.ScBytes(b)
+	return xdr.ScVal{Type: xdr.ScValTypeScvBytes, Bytes: &bs}
+}
+
+// DecodeAuthorizationEntries decodes base64 encoded SorobanAuthorizationEntries,
+// a variable length array of authorization entries.
+func DecodeAuthorizationEntries(encoded string) ([]xdr.SorobanAuthorizationEntry, error) {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding authorization entries")
+	}
+	d := xdr3.NewDecoder(bytes.NewReader(b))
+	n, _, err := d.DecodeUint()
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding authorization entries")
+	}
+	if n > 20 {
+		return nil, errors.Errorf("too many authorization entries: %d", n)
+	}
+	entries := make([]xdr.SorobanAuthorizationEntry, n)
+	for i := range entries {
+		if _, err = entries[i].DecodeFrom(d); err != nil {
+			return nil, errors.Wrapf(err, "decoding authorization entry %d", i)
+		}
+	}
+	return entries, nil
+}
+
+// EncodeAuthorizationEntries encodes authorization entries as base64 encoded
+// SorobanAuthorizationEntries.
+func EncodeAuthorizationEntries(entries []xdr.SorobanAuthorizationEntry) (string, error) {
+	var b bytes.Buffer
+	e := xdr3.NewEncoder(&b)
+	if _, err := e.EncodeUint(uint32(len(entries))); err != nil {
+		return "", errors.Wrap(err, "encoding authorization entries")
+	}
+	for i, entry := range entries {
+		if err := entry.EncodeTo(e); err != nil {
+			return "", errors.Wrapf(err, "encoding authorization entry %d", i)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(b.Bytes()), nil
+}
@@ -0,0 +1,150 @@
+package webauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/clients/stellartoml"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContractAddress(t *testing.T, id byte) (string, xdr.ScAddress) {
+	hash := xdr.Hash{id}
+	address := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &hash}
+	s, err := strkey.Encode(strkey.VersionByteContract, hash[:])
+	require.NoError(t, err)
+	return s, address
+}
+
+func newTestAuthorizationEntry(t *testing.T, creds xdr.ScAddress, webAuthContract xdr.ScAddress, args map[string]string) xdr.SorobanAuthorizationEntry {
+	m := xdr.ScMap{}
+	for _, k := range []string{"account", "home_domain", "nonce", "web_auth_domain", "web_auth_domain_account"} {
+		str := xdr.ScString(args[k])
+		m = append(m, xdr.ScMapEntry{Key: symbol(k), Val: xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &str}})
+	}
+	mp := &m
+	return xdr.SorobanAuthorizationEntry{
+		Credentials: xdr.SorobanCredentials{
+			Type:    xdr.SorobanCredentialsTypeSorobanCredentialsAddress,
+			Address: &xdr.SorobanAddressCredentials{Address: creds, Nonce: 7, Signature: xdr.ScVal{Type: xdr.ScValTypeScvVoid}},
+		},
+		RootInvocation: xdr.SorobanAuthorizedInvocation{
+			Function: xdr.SorobanAuthorizedFunction{
+				Type: xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeContractFn,
+				ContractFn: &xdr.InvokeContractArgs{
+					ContractAddress: webAuthContract,
+					FunctionName:    WebAuthVerifyFunction,
+					Args:            xdr.ScVec{{Type: xdr.ScValTypeScvMap, Map: &mp}},
+				},
+			},
+		},
+	}
+}
+
+func TestAuthenticate_sep45(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	clientSigner := keypair.MustRandom()
+	contractID, contractAddress := newTestContractAddress(t, 1)
+	webAuthContractID, webAuthContract := newTestContractAddress(t, 2)
+	serverAccount := xdr.MustAddress(serverKey.Address())
+	serverAddress := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeAccount, AccountId: &serverAccount}
+
+	tamper := false
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, _ := url.Parse(server.URL)
+		switch r.Method {
+		case http.MethodGet:
+			args := map[string]string{
+				"account":                 r.URL.Query().Get("account"),
+				"home_domain":             r.URL.Query().Get("home_domain"),
+				"nonce":                   "abc",
+				"web_auth_domain":         u.Host,
+				"web_auth_domain_account": serverKey.Address(),
+			}
+			if tamper {
+				args["home_domain"] = "evil.com"
+			}
+			serverEntry := newTestAuthorizationEntry(t, serverAddress, webAuthContract, args)
+			serverEntry.Credentials.Address.SignatureExpirationLedger = 100
+			payload, err := authorizationPayload(serverEntry, network.TestNetworkPassphrase)
+			require.NoError(t, err)
+			serverEntry.Credentials.Address.Signature, err = signatureScVal(payload, []*keypair.Full{serverKey})
+			require.NoError(t, err)
+
+			encoded, err := EncodeAuthorizationEntries([]xdr.SorobanAuthorizationEntry{
+				serverEntry,
+				newTestAuthorizationEntry(t, contractAddress, webAuthContract, args),
+			})
+			require.NoError(t, err)
+			json.NewEncoder(w).Encode(map[string]string{
+				"authorization_entries": encoded,
+				"network_passphrase":    network.TestNetworkPassphrase,
+			})
+		case http.MethodPost:
+			req := map[string]string{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			entries, err := DecodeAuthorizationEntries(req["authorization_entries"])
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+			clientCreds := entries[1].Credentials.Address
+			assert.Equal(t, xdr.Uint32(500), clientCreds.SignatureExpirationLedger)
+			payload, err := authorizationPayload(entries[1], network.TestNetworkPassphrase)
+			require.NoError(t, err)
+			require.NoError(t, verifySignatureScVal(payload, clientCreds.Signature, clientSigner.Address()))
+			json.NewEncoder(w).Encode(map[string]string{"token": newTestJWT(contractID, time.Now().Add(time.Hour), 1)})
+		}
+	}))
+	defer server.Close()
+
+	toml := &stellartoml.MockClient{}
+	toml.On("GetStellarToml", "example.com").Return(&stellartoml.Response{
+		WebAuthForContractsEndpoint: server.URL + "/sep45/auth",
+		WebAuthContractID:           webAuthContractID,
+		SigningKey:                  serverKey.Address(),
+	}, nil)
+	client := &Client{StellarTOML: toml, HTTP: http.DefaultClient, NetworkPassphrase: network.TestNetworkPassphrase}
+	req := Request{
+		HomeDomain:                "example.com",
+		Account:                   contractID,
+		Signers:                   []*keypair.Full{clientSigner},
+		SignatureExpirationLedger: 500,
+	}
+
+	token, err := client.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, contractID, token.Account)
+
+	tamper = true
+	_, err = client.Refresh(req)
+	assert.EqualError(t, err, `verifying challenge: argument home_domain is "evil.com", expected "example.com"`)
+
+	req.SignatureExpirationLedger = 0
+	_, err = client.Refresh(req)
+	assert.EqualError(t, err, "signature expiration ledger is required to authenticate a contract")
+}
+
+func TestAuthorizationEntriesRoundTrip(t *testing.T) {
+	_, contract := newTestContractAddress(t, 1)
+	entries := []xdr.SorobanAuthorizationEntry{
+		newTestAuthorizationEntry(t, contract, contract, map[string]string{"nonce": "1"}),
+		newTestAuthorizationEntry(t, contract, contract, map[string]string{"nonce": "2"}),
+	}
+	encoded, err := EncodeAuthorizationEntries(entries)
+	require.NoError(t, err)
+	decoded, err := DecodeAuthorizationEntries(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, entries, decoded)
+
+	_, err = DecodeAuthorizationEntries("AAAA")
+	assert.Error(t, err)
+}
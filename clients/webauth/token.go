@@ -0,0 +1,141 @@
+package webauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// Token is a JWT issued by a web authentication server.
+type Token struct {
+	// JWT is the encoded token to send in the Authorization header.
+	JWT        string
+	Account    string
+	HomeDomain string
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+}
+
+// Expired returns true if the token expires before now plus leeway.
+func (t Token) Expired(now time.Time, leeway time.Duration) bool {
+	return !now.Add(leeway).Before(t.ExpiresAt)
+}
+
+// parseToken reads the claims of a JWT. The signature is not verified, the
+// client has no way to verify it and only needs to know when the token
+// expires.
+func parseToken(jwt, homeDomain string) (Token, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return Token{}, errors.New("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Token{}, errors.Wrap(err, "decoding token claims")
+	}
+	claims := struct {
+		Sub string `json:"sub"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}{}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return Token{}, errors.Wrap(err, "unmarshaling token claims")
+	}
+	if claims.Exp == 0 {
+		return Token{}, errors.New("token has no expiration")
+	}
+	return Token{
+		JWT:        jwt,
+		Account:    claims.Sub,
+		HomeDomain: homeDomain,
+		IssuedAt:   time.Unix(claims.Iat, 0).UTC(),
+		ExpiresAt:  time.Unix(claims.Exp, 0).UTC(),
+	}, nil
+}
+
+type tokenKey struct {
+	homeDomain string
+	account    string
+	memo       uint64
+	hasMemo    bool
+}
+
+func newTokenKey(req Request) tokenKey {
+	k := tokenKey{homeDomain: req.HomeDomain, account: req.account()}
+	if req.Memo != nil {
+		k.memo, k.hasMemo = *req.Memo, true
+	}
+	return k
+}
+
+// Authenticate returns a cached token for the request if it has not expired,
+// otherwise it authenticates with the service and caches the new token.
+func (c *Client) Authenticate(req Request) (Token, error) {
+	key := newTokenKey(req)
+	c.mu.Lock()
+	t, ok := c.tokens[key]
+	c.mu.Unlock()
+	if ok && !t.Expired(c.Clock.Now(), c.expiryLeeway()) {
+		return t, nil
+	}
+	return c.Refresh(req)
+}
+
+// Refresh authenticates with the service, ignoring any cached token, and
+// caches the new token.
+func (c *Client) Refresh(req Request) (Token, error) {
+	account := req.account()
+	if account == "" {
+		return Token{}, errors.New("account or a signer is required")
+	}
+	if req.HomeDomain == "" {
+		return Token{}, errors.New("home domain is required")
+	}
+	if len(req.Signers) == 0 {
+		return Token{}, errors.New("at least one signer is required")
+	}
+
+	var (
+		t   Token
+		err error
+	)
+	if isContract(account) {
+		t, err = c.authenticateContract(req)
+	} else {
+		t, err = c.authenticateAccount(req)
+	}
+	if err != nil {
+		return Token{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokens == nil {
+		c.tokens = map[tokenKey]Token{}
+	}
+	c.tokens[newTokenKey(req)] = t
+	return t, nil
+}
+
+// Forget removes the cached token of the request.
+func (c *Client) Forget(req Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tokens, newTokenKey(req))
+}
+
+func (c *Client) expiryLeeway() time.Duration {
+	if c.ExpiryLeeway == 0 {
+		return DefaultExpiryLeeway
+	}
+	return c.ExpiryLeeway
+}
+
+func isContract(address string) bool {
+	_, err := strkey.Decode(strkey.VersionByteContract, address)
+	return err == nil
+}
@@ -0,0 +1,63 @@
+package webauth
+
+import (
+	"net/http"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// Transport returns a RoundTripper that adds the token of the request as a
+// bearer token to every request it sends using base, for calling SEP-6, SEP-12,
+// SEP-24, SEP-31 and other services that accept web authentication tokens.
+// http.DefaultTransport is used when base is nil.
+func (c *Client) Transport(req Request, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{client: c, req: req, base: base}
+}
+
+type transport struct {
+	client *Client
+	req    Request
+	base   http.RoundTripper
+}
+
+// RoundTrip sends the request with a bearer token. If the service responds
+// with 401 Unauthorized, for example because the token was revoked, the token
+// is refreshed and the request is sent once more when its body can be
+// replayed.
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token, err := t.client.Authenticate(t.req)
+	if err != nil {
+		return nil, errors.Wrap(err, "authenticating")
+	}
+	resp, err := t.base.RoundTrip(withToken(r, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if r.Body != nil && r.GetBody == nil {
+		return resp, nil
+	}
+
+	token, err = t.client.Refresh(t.req)
+	if err != nil {
+		return resp, nil
+	}
+	retry := withToken(r, token)
+	if r.Body != nil {
+		if retry.Body, err = r.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// withToken returns a copy of r with the Authorization header set, since a
+// RoundTripper must not modify the request it is given.
+func withToken(r *http.Request, token Token) *http.Request {
+	clone := r.Clone(r.Context())
+	clone.Header.Set("Authorization", "Bearer "+token.JWT)
+	return clone
+}
@@ -0,0 +1,196 @@
+package webauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/clients/stellartoml"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/support/clock"
+	"github.com/metriqorg/go/support/clock/clocktest"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJWT(sub string, exp time.Time, id int) string {
+	enc := base64.RawURLEncoding
+	claims := fmt.Sprintf(`{"jti":"%d","sub":%q,"iat":%d,"exp":%d}`, id, sub, exp.Add(-time.Hour).Unix(), exp.Unix())
+	return enc.EncodeToString([]byte(`{"alg":"ES256"}`)) + "." + enc.EncodeToString([]byte(claims)) + ".c2ln"
+}
+
+// newSEP10Server returns a SEP-10 server that issues tokens expiring at exp
+// and counts the tokens it issued.
+func newSEP10Server(t *testing.T, serverKey *keypair.Full, homeDomain string, exp time.Time, issued *int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, _ := url.Parse(server.URL)
+		switch r.Method {
+		case http.MethodGet:
+			tx, err := txnbuild.BuildChallengeTx(serverKey.Seed(), r.URL.Query().Get("account"), u.Host,
+				r.URL.Query().Get("home_domain"), network.TestNetworkPassphrase, time.Minute, nil)
+			require.NoError(t, err)
+			challenge, err := tx.Base64()
+			require.NoError(t, err)
+			json.NewEncoder(w).Encode(map[string]string{
+				"transaction":        challenge,
+				"network_passphrase": network.TestNetworkPassphrase,
+			})
+		case http.MethodPost:
+			req := map[string]string{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			_, account, _, _, err := txnbuild.ReadChallengeTx(req["transaction"], serverKey.Address(),
+				network.TestNetworkPassphrase, u.Host, []string{homeDomain})
+			require.NoError(t, err)
+			_, err = txnbuild.VerifyChallengeTxSigners(req["transaction"], serverKey.Address(),
+				network.TestNetworkPassphrase, u.Host, []string{homeDomain}, account)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			*issued++
+			json.NewEncoder(w).Encode(map[string]string{"token": newTestJWT(account, exp, *issued)})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuthenticate_sep10(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	account := keypair.MustRandom()
+	now := time.Date(2023, 11, 14, 22, 0, 0, 0, time.UTC)
+	issued := 0
+	server := newSEP10Server(t, serverKey, "example.com", now.Add(time.Hour), &issued)
+
+	toml := &stellartoml.MockClient{}
+	toml.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{WebAuthEndpoint: server.URL + "/auth", SigningKey: serverKey.Address()}, nil)
+
+	source := clocktest.FixedSource(now)
+	client := &Client{
+		StellarTOML:       toml,
+		HTTP:              http.DefaultClient,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Clock:             &clock.Clock{Source: &source},
+	}
+	req := Request{HomeDomain: "example.com", Signers: []*keypair.Full{account}}
+
+	token, err := client.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, account.Address(), token.Account)
+	assert.Equal(t, "example.com", token.HomeDomain)
+	assert.Equal(t, now.Add(time.Hour), token.ExpiresAt)
+	assert.Equal(t, 1, issued)
+
+	cached, err := client.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, token, cached)
+	assert.Equal(t, 1, issued)
+
+	// Tokens are refreshed within the expiry leeway.
+	source = clocktest.FixedSource(now.Add(time.Hour - 10*time.Second))
+	_, err = client.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, issued)
+
+	client.Forget(req)
+	_, err = client.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, 3, issued)
+
+	_, err = client.Authenticate(Request{HomeDomain: "example.com", Account: keypair.MustRandom().Address(), Signers: []*keypair.Full{account}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "getting token: web auth server error (400): transaction not signed by")
+}
+
+func TestAuthenticate_sep10VerifiesChallenge(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	issued := 0
+	server := newSEP10Server(t, serverKey, "example.com", time.Now().Add(time.Hour), &issued)
+
+	toml := &stellartoml.MockClient{}
+	client := &Client{StellarTOML: toml, HTTP: http.DefaultClient, NetworkPassphrase: network.TestNetworkPassphrase}
+	req := Request{HomeDomain: "example.com", Signers: []*keypair.Full{keypair.MustRandom()}}
+
+	toml.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{WebAuthEndpoint: server.URL, SigningKey: keypair.MustRandom().Address()}, nil).
+		Once()
+	_, err := client.Authenticate(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reading challenge")
+
+	toml.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{WebAuthEndpoint: server.URL}, nil).
+		Once()
+	_, err = client.Authenticate(req)
+	assert.EqualError(t, err, "stellar.toml of example.com has no SIGNING_KEY")
+
+	client.NetworkPassphrase = network.PublicNetworkPassphrase
+	toml.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{WebAuthEndpoint: server.URL, SigningKey: serverKey.Address()}, nil).
+		Once()
+	_, err = client.Authenticate(req)
+	assert.EqualError(t, err, `challenge is for network "Test Lantah Network ; 2023"`)
+
+	assert.Equal(t, 0, issued)
+	toml.AssertExpectations(t)
+}
+
+func TestTransport(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	issued := 0
+	authServer := newSEP10Server(t, serverKey, "example.com", time.Now().Add(time.Hour), &issued)
+
+	toml := &stellartoml.MockClient{}
+	toml.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{WebAuthEndpoint: authServer.URL, SigningKey: serverKey.Address()}, nil)
+	client := &Client{StellarTOML: toml, HTTP: http.DefaultClient, NetworkPassphrase: network.TestNetworkPassphrase}
+
+	rejected := ""
+	calls := 0
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		auth := r.Header.Get("Authorization")
+		if auth == rejected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rejected = auth
+		w.Write([]byte("ok"))
+	}))
+	defer service.Close()
+
+	httpClient := &http.Client{Transport: client.Transport(Request{HomeDomain: "example.com", Signers: []*keypair.Full{keypair.MustRandom()}}, nil)}
+	resp, err := httpClient.Get(service.URL + "/info")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, issued)
+
+	// The service rejects the token it saw before, the transport refreshes
+	// the token and retries.
+	resp, err = httpClient.Get(service.URL + "/info")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, issued)
+	assert.Equal(t, 3, calls)
+}
+
+func TestParseToken(t *testing.T) {
+	_, err := parseToken("abc", "example.com")
+	assert.EqualError(t, err, "token is not a JWT")
+
+	enc := base64.RawURLEncoding
+	_, err = parseToken("e30."+enc.EncodeToString([]byte(`{"sub":"x"}`))+".c2ln", "example.com")
+	assert.EqualError(t, err, "token has no expiration")
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"testing"
+
+	dbpkg "github.com/metriqorg/go/exp/services/multisig/internal/db"
+	"github.com/metriqorg/go/exp/services/multisig/internal/db/dbtest"
+	"github.com/metriqorg/go/support/log"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBCommand_Migrate_upDown(t *testing.T) {
+	db := dbtest.OpenWithoutMigrations(t)
+	log := log.New()
+
+	dbCommand := DBCommand{
+		Logger:      log,
+		DatabaseURL: db.DSN,
+	}
+
+	// Migrate Up
+	{
+		logsGet := log.StartTest(logrus.InfoLevel)
+
+		dbCommand.Migrate(&cobra.Command{}, []string{"up"})
+
+		session, err := dbpkg.Open(db.DSN)
+		require.NoError(t, err)
+		ids := []string{}
+		err = session.Select(&ids, `SELECT id FROM gorp_migrations`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"20261019000000-create-proposals.sql"}, ids)
+
+		logs := logsGet()
+		messages := []string{}
+		for _, l := range logs {
+			messages = append(messages, l.Message)
+		}
+		wantMessages := []string{
+			"Migrations to apply up: 20261019000000-create-proposals.sql",
+			"Successfully applied 1 migrations up.",
+		}
+		assert.Equal(t, wantMessages, messages)
+	}
+
+	// Migrate Down
+	{
+		logsGet := log.StartTest(logrus.InfoLevel)
+
+		dbCommand.Migrate(&cobra.Command{}, []string{"down"})
+
+		session, err := dbpkg.Open(db.DSN)
+		require.NoError(t, err)
+		ids := []string{}
+		err = session.Select(&ids, `SELECT id FROM gorp_migrations`)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+
+		logs := logsGet()
+		messages := []string{}
+		for _, l := range logs {
+			messages = append(messages, l.Message)
+		}
+		wantMessages := []string{
+			"Migrations to apply down: 20261019000000-create-proposals.sql",
+			"Successfully applied 1 migrations down.",
+		}
+		assert.Equal(t, wantMessages, messages)
+	}
+}
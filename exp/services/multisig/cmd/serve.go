@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"go/types"
+
+	"github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/exp/services/multisig/internal/serve"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/support/config"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/spf13/cobra"
+)
+
+type ServeCommand struct {
+	Logger *supportlog.Entry
+}
+
+func (c *ServeCommand) Command() *cobra.Command {
+	opts := serve.Options{
+		Logger: c.Logger,
+	}
+	configOpts := config.ConfigOptions{
+		{
+			Name:        "port",
+			Usage:       "Port to listen and serve on",
+			OptType:     types.Int,
+			ConfigKey:   &opts.Port,
+			FlagDefault: 8000,
+			Required:    true,
+		},
+		{
+			Name:      "db-url",
+			Usage:     "Database URL proposals are stored in (proposals are kept in memory if not set)",
+			OptType:   types.String,
+			ConfigKey: &opts.DatabaseURL,
+			Required:  false,
+		},
+		{
+			Name:        "orbitr-url",
+			Usage:       "OrbitR URL used for looking up account signers and submitting transactions",
+			OptType:     types.String,
+			ConfigKey:   &opts.OrbitRURL,
+			FlagDefault: orbitrclient.DefaultTestNetClient.OrbitRURL,
+			Required:    true,
+		},
+		{
+			Name:        "network-passphrase",
+			Usage:       "Network passphrase of the Lantah Network transactions should be signed for",
+			OptType:     types.String,
+			ConfigKey:   &opts.NetworkPassphrase,
+			FlagDefault: network.TestNetworkPassphrase,
+			Required:    true,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the Multisig Coordination server",
+		Run: func(_ *cobra.Command, _ []string) {
+			configOpts.Require()
+			configOpts.SetValues()
+			c.Run(opts)
+		},
+	}
+	configOpts.Init(cmd)
+	return cmd
+}
+
+func (c *ServeCommand) Run(opts serve.Options) {
+	serve.Serve(opts)
+}
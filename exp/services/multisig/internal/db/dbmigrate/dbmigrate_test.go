@@ -0,0 +1,59 @@
+package dbmigrate
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	assetfs "github.com/elazarl/go-bindata-assetfs"
+	dbpkg "github.com/metriqorg/go/exp/services/multisig/internal/db"
+	"github.com/metriqorg/go/exp/services/multisig/internal/db/dbtest"
+	supportHttp "github.com/metriqorg/go/support/http"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/shurcooL/httpfs/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedAssets(t *testing.T) {
+	localAssets := http.FileSystem(filter.Keep(http.Dir("."), func(path string, fi os.FileInfo) bool {
+		return fi.IsDir() || strings.HasSuffix(path, ".sql")
+	}))
+	generatedAssets := &assetfs.AssetFS{Asset: Asset, AssetDir: AssetDir, AssetInfo: AssetInfo}
+
+	if !supportHttp.EqualFileSystems(localAssets, generatedAssets, "/") {
+		t.Fatalf("generated migrations does not match local migrations")
+	}
+}
+
+func TestMigrate_upDownAll(t *testing.T) {
+	db := dbtest.OpenWithoutMigrations(t)
+	session, err := dbpkg.Open(db.DSN)
+	require.NoError(t, err)
+
+	migrations, err := PlanMigration(session, migrate.Up, 0)
+	require.NoError(t, err)
+	wantIDs := []string{
+		"20261019000000-create-proposals.sql",
+	}
+	assert.Equal(t, wantIDs, migrations)
+
+	n, err := Migrate(session, migrate.Up, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	ids := []string{}
+	err = session.Select(&ids, `SELECT id FROM gorp_migrations`)
+	require.NoError(t, err)
+	assert.Equal(t, wantIDs, ids)
+
+	n, err = Migrate(session, migrate.Down, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	ids = []string{}
+	err = session.Select(&ids, `SELECT id FROM gorp_migrations`)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
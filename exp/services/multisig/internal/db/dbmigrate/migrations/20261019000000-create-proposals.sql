@@ -0,0 +1,23 @@
+-- +migrate Up
+
+CREATE TABLE proposals (
+    hash text NOT NULL PRIMARY KEY,
+    envelope text NOT NULL,
+    -- The signers and thresholds of the source accounts, the checks the
+    -- network performs and the collected signatures are only ever read and
+    -- written together with the proposal.
+    requirements jsonb NOT NULL,
+    checks jsonb NOT NULL,
+    signatures jsonb NOT NULL,
+    status text NOT NULL,
+    submit_error text NOT NULL DEFAULT '',
+    ledger integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX proposals_status_idx ON proposals (status);
+
+-- +migrate Down
+
+DROP TABLE proposals;
@@ -0,0 +1,174 @@
+package proposal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// DBStore is a Store that keeps proposals in a Postgres database, so pending
+// proposals and their signatures survive restarts.
+type DBStore struct {
+	DB *sqlx.DB
+}
+
+type dbProposal struct {
+	Hash         string    `db:"hash"`
+	Envelope     string    `db:"envelope"`
+	Requirements []byte    `db:"requirements"`
+	Checks       []byte    `db:"checks"`
+	Signatures   []byte    `db:"signatures"`
+	Status       string    `db:"status"`
+	SubmitError  string    `db:"submit_error"`
+	Ledger       int32     `db:"ledger"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+func (s *DBStore) Add(p Proposal) error {
+	r, err := toDBProposal(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.NamedExec(`
+		INSERT INTO proposals (hash, envelope, requirements, checks, signatures, status, submit_error, ledger, created_at, updated_at)
+		VALUES (:hash, :envelope, :requirements, :checks, :signatures, :status, :submit_error, :ledger, :created_at, :updated_at)
+	`, r)
+	if err != nil {
+		// 23505 is the PostgreSQL error for Unique Violation.
+		// See https://www.postgresql.org/docs/9.2/errcodes-appendix.html.
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *DBStore) Get(hash string) (Proposal, error) {
+	return getProposal(s.DB, hash, "")
+}
+
+// Update locks the row of the proposal until fn's result is saved, so
+// concurrent updates of the same proposal, including from other instances of
+// the service, are applied one at a time.
+func (s *DBStore) Update(hash string, fn func(p *Proposal) error) (Proposal, error) {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return Proposal{}, err
+	}
+	defer tx.Rollback()
+
+	p, err := getProposal(tx, hash, "FOR UPDATE")
+	if err != nil {
+		return Proposal{}, err
+	}
+	if err = fn(&p); err != nil {
+		return Proposal{}, err
+	}
+
+	r, err := toDBProposal(p)
+	if err != nil {
+		return Proposal{}, err
+	}
+	_, err = tx.NamedExec(`
+		UPDATE proposals
+		SET envelope = :envelope,
+			requirements = :requirements,
+			checks = :checks,
+			signatures = :signatures,
+			status = :status,
+			submit_error = :submit_error,
+			ledger = :ledger,
+			updated_at = :updated_at
+		WHERE hash = :hash
+	`, r)
+	if err != nil {
+		return Proposal{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Proposal{}, err
+	}
+	return p, nil
+}
+
+func getProposal(q sqlx.Queryer, hash, lock string) (Proposal, error) {
+	r := dbProposal{}
+	err := sqlx.Get(q, &r, `
+		SELECT hash, envelope, requirements, checks, signatures, status, submit_error, ledger, created_at, updated_at
+		FROM proposals
+		WHERE hash = $1
+	`+lock, hash)
+	if err == sql.ErrNoRows {
+		return Proposal{}, ErrNotFound
+	} else if err != nil {
+		return Proposal{}, err
+	}
+	return r.proposal()
+}
+
+func toDBProposal(p Proposal) (dbProposal, error) {
+	r := dbProposal{
+		Hash:        p.Hash,
+		Envelope:    p.Envelope,
+		Status:      string(p.Status),
+		SubmitError: p.SubmitError,
+		Ledger:      p.Ledger,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+	var err error
+	if r.Requirements, err = marshalJSONArray(p.Requirements); err != nil {
+		return dbProposal{}, errors.Wrap(err, "encoding requirements")
+	}
+	if r.Checks, err = marshalJSONArray(p.Checks); err != nil {
+		return dbProposal{}, errors.Wrap(err, "encoding checks")
+	}
+	if r.Signatures, err = marshalJSONArray(p.Signatures); err != nil {
+		return dbProposal{}, errors.Wrap(err, "encoding signatures")
+	}
+	return r, nil
+}
+
+func (r dbProposal) proposal() (Proposal, error) {
+	p := Proposal{
+		Hash:        r.Hash,
+		Envelope:    r.Envelope,
+		Status:      Status(r.Status),
+		SubmitError: r.SubmitError,
+		Ledger:      r.Ledger,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+	if err := json.Unmarshal(r.Requirements, &p.Requirements); err != nil {
+		return Proposal{}, errors.Wrap(err, "decoding requirements")
+	}
+	if err := json.Unmarshal(r.Checks, &p.Checks); err != nil {
+		return Proposal{}, errors.Wrap(err, "decoding checks")
+	}
+	if err := json.Unmarshal(r.Signatures, &p.Signatures); err != nil {
+		return Proposal{}, errors.Wrap(err, "decoding signatures")
+	}
+	return p, nil
+}
+
+// marshalJSONArray encodes a nil slice as an empty JSON array rather than
+// null.
+func marshalJSONArray(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(b) == "null" {
+		return []byte("[]"), nil
+	}
+	return b, nil
+}
+
+var _ Store = &DBStore{}
@@ -0,0 +1,89 @@
+package proposal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/exp/services/multisig/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBStore(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+	defer session.Close()
+	s := &DBStore{DB: session}
+
+	_, err := s.Get("abc")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = s.Update("abc", func(p *Proposal) error { return nil })
+	assert.Equal(t, ErrNotFound, err)
+
+	createdAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Add(Proposal{
+		Hash:     "abc",
+		Envelope: "AAAA",
+		Requirements: []Requirement{
+			{Account: "G1", Level: "medium", Thresholds: Thresholds{Low: 1, Medium: 2, High: 3}},
+		},
+		Status:    StatusPending,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}))
+	assert.Equal(t, ErrAlreadyExists, s.Add(Proposal{Hash: "abc"}))
+
+	p, err := s.Update("abc", func(p *Proposal) error {
+		p.Signatures = append(p.Signatures, Signature{Signer: "G1", Signature: "c2ln", AddedAt: createdAt})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, p.Signatures, 1)
+
+	stored, err := s.Get("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "AAAA", stored.Envelope)
+	assert.Equal(t, StatusPending, stored.Status)
+	require.Len(t, stored.Requirements, 1)
+	assert.Equal(t, Thresholds{Low: 1, Medium: 2, High: 3}, stored.Requirements[0].Thresholds)
+	assert.Equal(t, []Signature{{Signer: "G1", Signature: "c2ln", AddedAt: createdAt}}, stored.Signatures)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+
+	_, err = s.Update("abc", func(p *Proposal) error {
+		p.Status = StatusReady
+		return ErrSubmitted
+	})
+	assert.Equal(t, ErrSubmitted, err)
+	stored, err = s.Get("abc")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, stored.Status)
+}
+
+func TestDBStore_concurrentUpdates(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+	defer session.Close()
+	s := &DBStore{DB: session}
+
+	require.NoError(t, s.Add(Proposal{Hash: "abc", Status: StatusPending}))
+
+	// Updates of the same proposal are serialized, so no signature is lost.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Update("abc", func(p *Proposal) error {
+				p.Signatures = append(p.Signatures, Signature{Signer: "G1"})
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	stored, err := s.Get("abc")
+	require.NoError(t, err)
+	assert.Len(t, stored.Signatures, 10)
+}
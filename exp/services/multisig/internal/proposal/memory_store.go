@@ -0,0 +1,59 @@
+package proposal
+
+import (
+	"sync"
+)
+
+// MemoryStore is a Store that keeps proposals in memory. Proposals are lost
+// when the process exits.
+type MemoryStore struct {
+	mu        sync.Mutex
+	proposals map[string]Proposal
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{proposals: map[string]Proposal{}}
+}
+
+func (s *MemoryStore) Add(p Proposal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.proposals[p.Hash]; ok {
+		return ErrAlreadyExists
+	}
+	s.proposals[p.Hash] = copyProposal(p)
+	return nil
+}
+
+func (s *MemoryStore) Get(hash string) (Proposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[hash]
+	if !ok {
+		return Proposal{}, ErrNotFound
+	}
+	return copyProposal(p), nil
+}
+
+func (s *MemoryStore) Update(hash string, fn func(p *Proposal) error) (Proposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proposals[hash]
+	if !ok {
+		return Proposal{}, ErrNotFound
+	}
+	p = copyProposal(p)
+	if err := fn(&p); err != nil {
+		return Proposal{}, err
+	}
+	s.proposals[hash] = copyProposal(p)
+	return p, nil
+}
+
+// copyProposal copies the slices of a proposal so that callers cannot modify
+// the stored proposal. Requirements are never modified after a proposal is
+// created and are shared.
+func copyProposal(p Proposal) Proposal {
+	p.Signatures = append([]Signature{}, p.Signatures...)
+	return p
+}
@@ -0,0 +1,41 @@
+package proposal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+
+	_, err := s.Get("abc")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = s.Update("abc", func(p *Proposal) error { return nil })
+	assert.Equal(t, ErrNotFound, err)
+
+	require.NoError(t, s.Add(Proposal{Hash: "abc", Status: StatusPending}))
+	assert.Equal(t, ErrAlreadyExists, s.Add(Proposal{Hash: "abc"}))
+
+	p, err := s.Update("abc", func(p *Proposal) error {
+		p.Signatures = append(p.Signatures, Signature{Signer: "G1"})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, p.Signatures, 1)
+
+	p.Signatures[0].Signer = "modified"
+	stored, err := s.Get("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "G1", stored.Signatures[0].Signer)
+
+	_, err = s.Update("abc", func(p *Proposal) error {
+		p.Status = StatusReady
+		return ErrSubmitted
+	})
+	assert.Equal(t, ErrSubmitted, err)
+	stored, err = s.Get("abc")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, stored.Status)
+}
@@ -0,0 +1,243 @@
+package proposal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/metriqorg/go/xdr"
+)
+
+// Status is the state of a proposal.
+type Status string
+
+const (
+	// StatusPending is the status of a proposal that is waiting for more
+	// signatures.
+	StatusPending Status = "pending"
+	// StatusReady is the status of a proposal that has collected enough
+	// signatures but has not been accepted by the network yet.
+	StatusReady Status = "ready"
+	// StatusSubmitted is the status of a proposal that has been accepted by
+	// the network.
+	StatusSubmitted Status = "submitted"
+)
+
+var (
+	ErrNotSigner        = errors.New("signer is not a signer of any source account")
+	ErrInvalidSignature = errors.New("signature is not valid for the transaction")
+	ErrSubmitted        = errors.New("transaction has already been submitted")
+	// ErrUnreachableThreshold is returned when the signers of a source
+	// account that can sign a proposal cannot meet its threshold.
+	ErrUnreachableThreshold = errors.New("signers cannot meet the threshold")
+)
+
+// Proposal is a transaction that is collecting signatures from the signers
+// of its source accounts.
+type Proposal struct {
+	// Hash is the hex encoded hash of the transaction.
+	Hash string
+	// Envelope is the base64 encoded transaction envelope without any
+	// signatures.
+	Envelope     string
+	Requirements []Requirement
+	Checks       []Check
+	Signatures   []Signature
+	Status       Status
+	// SubmitError is the error returned by the last failed submission.
+	SubmitError string
+	// Ledger is the ledger the transaction was included in once submitted.
+	Ledger    int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Signature is a signature collected for a proposal.
+type Signature struct {
+	Signer string
+	// Signature is the base64 encoded signature of the transaction hash.
+	Signature string
+	AddedAt   time.Time
+}
+
+// Requirement returns the requirement of the given account.
+func (p Proposal) Requirement(account string) (Requirement, bool) {
+	for _, r := range p.Requirements {
+		if r.Account == account {
+			return r, true
+		}
+	}
+	return Requirement{}, false
+}
+
+// Signers returns the set of signers that have signed the proposal.
+func (p Proposal) Signers() map[string]bool {
+	signers := make(map[string]bool, len(p.Signatures))
+	for _, s := range p.Signatures {
+		signers[s.Signer] = true
+	}
+	return signers
+}
+
+// Met returns true if the collected signatures meet the thresholds of every
+// check the network performs on the transaction.
+func (p Proposal) Met() bool {
+	_, ok := p.usedSignatures()
+	return ok
+}
+
+// AddSignature verifies and adds the base64 encoded signature of signer to
+// the proposal. It returns false if the signer had already signed.
+func (p *Proposal) AddSignature(signer, signature string, now time.Time) (bool, error) {
+	if p.Status == StatusSubmitted {
+		return false, ErrSubmitted
+	}
+	isSigner := false
+	for _, r := range p.Requirements {
+		if r.Signers[signer] > 0 {
+			isSigner = true
+			break
+		}
+	}
+	if !isSigner {
+		return false, ErrNotSigner
+	}
+	if err := p.verify(signer, signature); err != nil {
+		return false, err
+	}
+	if p.Signers()[signer] {
+		return false, nil
+	}
+
+	p.Signatures = append(p.Signatures, Signature{
+		Signer:    signer,
+		Signature: signature,
+		AddedAt:   now,
+	})
+	p.UpdatedAt = now
+	if p.Met() {
+		p.Status = StatusReady
+	}
+	return true, nil
+}
+
+func (p Proposal) verify(signer, signature string) error {
+	kp, err := keypair.ParseAddress(signer)
+	if err != nil {
+		return ErrNotSigner
+	}
+	hash, err := hex.DecodeString(p.Hash)
+	if err != nil {
+		return errors.Wrap(err, "decoding transaction hash")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if kp.Verify(hash, sig) != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SignedEnvelope returns the base64 encoded transaction envelope with the
+// collected signatures attached. Signatures the network would not use to meet
+// the thresholds are left out because the network rejects transactions with
+// unused signatures.
+func (p Proposal) SignedEnvelope() (string, error) {
+	used, ok := p.usedSignatures()
+	if !ok {
+		return "", errors.New("signatures do not meet the thresholds")
+	}
+
+	parsed, err := txnbuild.TransactionFromXDR(p.Envelope)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing transaction envelope")
+	}
+	tx, ok := parsed.Transaction()
+	if !ok {
+		return "", errors.New("envelope is not a transaction")
+	}
+
+	decorated := make([]xdr.DecoratedSignature, 0, len(used))
+	for _, s := range p.Signatures {
+		if !used[s.Signer] {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(s.Signature)
+		if err != nil {
+			return "", errors.Wrapf(err, "decoding signature of %s", s.Signer)
+		}
+		kp, err := keypair.ParseAddress(s.Signer)
+		if err != nil {
+			return "", errors.Wrapf(err, "parsing signer %s", s.Signer)
+		}
+		decorated = append(decorated, xdr.NewDecoratedSignature(sig, kp.Hint()))
+	}
+	tx, err = tx.AddSignatureDecorated(decorated...)
+	if err != nil {
+		return "", errors.Wrap(err, "adding signatures")
+	}
+	return tx.Base64()
+}
+
+// usedSignatures replays the signature checks of the network in the order it
+// performs them and returns the signers whose signatures are consumed, and
+// whether every check passes. The network stops counting signatures for a
+// check as soon as its threshold is reached, so a signature collected from a
+// signer may end up unused.
+func (p Proposal) usedSignatures() (map[string]bool, bool) {
+	signed := p.Signers()
+	used := map[string]bool{}
+	for _, c := range p.Checks {
+		r, ok := p.Requirement(c.Account)
+		if !ok {
+			return used, false
+		}
+		needed := r.Thresholds.Weight(c.Level)
+		total := int32(0)
+		met := false
+		for _, signer := range r.signerOrder() {
+			if !signed[signer] {
+				continue
+			}
+			used[signer] = true
+			total += r.Signers[signer]
+			if total >= needed {
+				met = true
+				break
+			}
+		}
+		if !met {
+			return used, false
+		}
+	}
+	return used, true
+}
+
+// signerOrder returns the ed25519 signers of the account in the order the
+// network checks them: the master key first, then the other signers ordered
+// by their raw key.
+func (r Requirement) signerOrder() []string {
+	signers := make([]string, 0, len(r.Signers))
+	for s := range r.Signers {
+		if s != r.Account {
+			signers = append(signers, s)
+		}
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		a, _ := strkey.Decode(strkey.VersionByteAccountID, signers[i])
+		b, _ := strkey.Decode(strkey.VersionByteAccountID, signers[j])
+		return bytes.Compare(a, b) < 0
+	})
+	if r.Signers[r.Account] > 0 {
+		signers = append([]string{r.Account}, signers...)
+	}
+	return signers
+}
@@ -0,0 +1,214 @@
+package proposal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAccount(account *keypair.Full, thresholds orbitr.AccountThresholds, masterWeight int32, signers ...*keypair.Full) orbitr.Account {
+	a := orbitr.Account{
+		AccountID:  account.Address(),
+		Thresholds: thresholds,
+		Signers: []orbitr.Signer{
+			{Key: account.Address(), Weight: masterWeight, Type: "ed25519_public_key"},
+		},
+	}
+	for _, s := range signers {
+		a.Signers = append(a.Signers, orbitr.Signer{Key: s.Address(), Weight: 1, Type: "ed25519_public_key"})
+	}
+	return a
+}
+
+func newTestTransaction(t *testing.T, source *keypair.Full, ops ...txnbuild.Operation) *txnbuild.Transaction {
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address(), Sequence: 1},
+		Operations:    ops,
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func sign(t *testing.T, p *Proposal, kp *keypair.Full) error {
+	tx, err := txnbuild.TransactionFromXDR(p.Envelope)
+	require.NoError(t, err)
+	simple, _ := tx.Transaction()
+	hash, err := simple.Hash(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	sig, err := kp.SignBase64(hash[:])
+	require.NoError(t, err)
+	_, err = p.AddSignature(kp.Address(), sig, time.Now())
+	return err
+}
+
+func TestNew_requirements(t *testing.T) {
+	treasury := keypair.MustRandom()
+	other := keypair.MustRandom()
+	signers := []*keypair.Full{keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom()}
+
+	client := &orbitrclient.MockClient{}
+	client.On("AccountDetail", orbitrclient.AccountRequest{AccountID: treasury.Address()}).
+		Return(newTestAccount(treasury, orbitr.AccountThresholds{LowThreshold: 1, MedThreshold: 2, HighThreshold: 3}, 0, signers...), nil).
+		Once()
+	client.On("AccountDetail", orbitrclient.AccountRequest{AccountID: other.Address()}).
+		Return(newTestAccount(other, orbitr.AccountThresholds{}, 1), nil).
+		Once()
+
+	tx := newTestTransaction(t, treasury,
+		&txnbuild.Payment{Destination: other.Address(), Amount: "10", Asset: txnbuild.NativeAsset{}},
+		&txnbuild.BumpSequence{BumpTo: 10, SourceAccount: other.Address()},
+		&txnbuild.SetOptions{HomeDomain: txnbuild.NewHomeDomain("example.com")},
+	)
+
+	p, err := New(tx, network.TestNetworkPassphrase, client, time.Now())
+	require.NoError(t, err)
+	client.AssertExpectations(t)
+
+	assert.Equal(t, StatusPending, p.Status)
+	assert.Equal(t, []Check{
+		{Account: treasury.Address(), Level: ThresholdLevelLow},
+		{Account: treasury.Address(), Level: ThresholdLevelMedium},
+		{Account: other.Address(), Level: ThresholdLevelLow},
+		{Account: treasury.Address(), Level: ThresholdLevelMedium},
+	}, p.Checks)
+	require.Len(t, p.Requirements, 2)
+	assert.Equal(t, ThresholdLevelMedium, p.Requirements[0].Level)
+	assert.Equal(t, int32(2), p.Requirements[0].Threshold())
+	assert.Len(t, p.Requirements[0].Signers, 3)
+	assert.Equal(t, ThresholdLevelLow, p.Requirements[1].Level)
+	assert.Equal(t, int32(1), p.Requirements[1].Threshold())
+
+	require.NoError(t, sign(t, &p, signers[0]))
+	require.NoError(t, sign(t, &p, other))
+	assert.False(t, p.Met())
+	_, err = p.SignedEnvelope()
+	assert.EqualError(t, err, "signatures do not meet the thresholds")
+
+	require.NoError(t, sign(t, &p, signers[1]))
+	assert.True(t, p.Met())
+	assert.Equal(t, StatusReady, p.Status)
+
+	envelope, err := p.SignedEnvelope()
+	require.NoError(t, err)
+	signed, err := txnbuild.TransactionFromXDR(envelope)
+	require.NoError(t, err)
+	signedTx, _ := signed.Transaction()
+	assert.Len(t, signedTx.Signatures(), 3)
+}
+
+func TestNew_existingSignatures(t *testing.T) {
+	account := keypair.MustRandom()
+	client := &orbitrclient.MockClient{}
+	client.On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(newTestAccount(account, orbitr.AccountThresholds{}, 1), nil)
+
+	tx := newTestTransaction(t, account, &txnbuild.BumpSequence{BumpTo: 10})
+	tx, err := tx.Sign(network.TestNetworkPassphrase, account)
+	require.NoError(t, err)
+
+	p, err := New(tx, network.TestNetworkPassphrase, client, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, StatusReady, p.Status)
+	require.Len(t, p.Signatures, 1)
+	assert.Equal(t, account.Address(), p.Signatures[0].Signer)
+
+	unsigned, err := tx.ClearSignatures()
+	require.NoError(t, err)
+	envelope, err := unsigned.Base64()
+	require.NoError(t, err)
+	assert.Equal(t, envelope, p.Envelope)
+
+	tx, err = tx.Sign(network.TestNetworkPassphrase, keypair.MustRandom())
+	require.NoError(t, err)
+	_, err = New(tx, network.TestNetworkPassphrase, client, time.Now())
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestNew_unreachableThreshold(t *testing.T) {
+	account := keypair.MustRandom()
+	client := &orbitrclient.MockClient{}
+	client.On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(newTestAccount(account, orbitr.AccountThresholds{HighThreshold: 3}, 1, keypair.MustRandom()), nil)
+
+	tx := newTestTransaction(t, account, &txnbuild.AccountMerge{Destination: keypair.MustRandom().Address()})
+	_, err := New(tx, network.TestNetworkPassphrase, client, time.Now())
+	assert.Equal(t, ErrUnreachableThreshold, errors.Cause(err))
+	assert.EqualError(t, err, "account "+account.Address()+" high threshold: signers cannot meet the threshold")
+}
+
+func TestAddSignature(t *testing.T) {
+	account := keypair.MustRandom()
+	signer := keypair.MustRandom()
+	client := &orbitrclient.MockClient{}
+	client.On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(newTestAccount(account, orbitr.AccountThresholds{MedThreshold: 2}, 1, signer), nil)
+
+	tx := newTestTransaction(t, account, &txnbuild.ManageData{Name: "a", Value: []byte("b")})
+	p, err := New(tx, network.TestNetworkPassphrase, client, time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, ErrNotSigner, sign(t, &p, keypair.MustRandom()))
+	_, err = p.AddSignature(signer.Address(), "bm90IGEgc2lnbmF0dXJl", time.Now())
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	require.NoError(t, sign(t, &p, signer))
+	require.NoError(t, sign(t, &p, signer))
+	assert.Len(t, p.Signatures, 1)
+	assert.Equal(t, StatusPending, p.Status)
+
+	require.NoError(t, sign(t, &p, account))
+	assert.Equal(t, StatusReady, p.Status)
+
+	p.Status = StatusSubmitted
+	assert.Equal(t, ErrSubmitted, sign(t, &p, signer))
+}
+
+func TestSignedEnvelope_leavesOutUnusedSignatures(t *testing.T) {
+	account := keypair.MustRandom()
+	signers := []*keypair.Full{keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom()}
+	client := &orbitrclient.MockClient{}
+	client.On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(newTestAccount(account, orbitr.AccountThresholds{LowThreshold: 3, MedThreshold: 3, HighThreshold: 3}, 0, signers...), nil)
+
+	tx := newTestTransaction(t, account, &txnbuild.ManageData{Name: "a", Value: []byte("b")})
+	p, err := New(tx, network.TestNetworkPassphrase, client, time.Now())
+	require.NoError(t, err)
+
+	// Four of five signers sign a 3-of-5 transaction, one signature is not
+	// needed by the network.
+	for _, s := range signers[:4] {
+		require.NoError(t, sign(t, &p, s))
+	}
+	assert.Len(t, p.Signatures, 4)
+
+	used, ok := p.usedSignatures()
+	require.True(t, ok)
+	assert.Len(t, used, 3)
+
+	envelope, err := p.SignedEnvelope()
+	require.NoError(t, err)
+	signed, err := txnbuild.TransactionFromXDR(envelope)
+	require.NoError(t, err)
+	signedTx, _ := signed.Transaction()
+	assert.Len(t, signedTx.Signatures(), 3)
+}
+
+func TestOperationThresholdLevel(t *testing.T) {
+	weight := txnbuild.Threshold(1)
+	assert.Equal(t, ThresholdLevelLow, OperationThresholdLevel(&txnbuild.AllowTrust{}))
+	assert.Equal(t, ThresholdLevelLow, OperationThresholdLevel(&txnbuild.ClaimClaimableBalance{}))
+	assert.Equal(t, ThresholdLevelMedium, OperationThresholdLevel(&txnbuild.Payment{}))
+	assert.Equal(t, ThresholdLevelMedium, OperationThresholdLevel(&txnbuild.SetOptions{HomeDomain: txnbuild.NewHomeDomain("example.com")}))
+	assert.Equal(t, ThresholdLevelHigh, OperationThresholdLevel(&txnbuild.SetOptions{MasterWeight: &weight}))
+	assert.Equal(t, ThresholdLevelHigh, OperationThresholdLevel(&txnbuild.AccountMerge{}))
+}
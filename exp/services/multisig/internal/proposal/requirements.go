@@ -0,0 +1,252 @@
+package proposal
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/metriqorg/go/xdr"
+)
+
+// ThresholdLevel is the threshold an operation must meet on its source
+// account.
+type ThresholdLevel string
+
+const (
+	ThresholdLevelLow    ThresholdLevel = "low"
+	ThresholdLevelMedium ThresholdLevel = "medium"
+	ThresholdLevelHigh   ThresholdLevel = "high"
+)
+
+var thresholdLevelRank = map[ThresholdLevel]int{
+	ThresholdLevelLow:    0,
+	ThresholdLevelMedium: 1,
+	ThresholdLevelHigh:   2,
+}
+
+// Thresholds are the thresholds of an account.
+type Thresholds struct {
+	Low    int32
+	Medium int32
+	High   int32
+}
+
+// Weight returns the weight needed to meet the threshold level. The network
+// requires at least one signature even when a threshold is zero, so the
+// returned weight is never less than one.
+func (t Thresholds) Weight(level ThresholdLevel) int32 {
+	w := t.Low
+	switch level {
+	case ThresholdLevelMedium:
+		w = t.Medium
+	case ThresholdLevelHigh:
+		w = t.High
+	}
+	if w < 1 {
+		w = 1
+	}
+	return w
+}
+
+// Requirement is the signing requirement of one source account of a
+// transaction.
+type Requirement struct {
+	Account string
+	// Level is the highest threshold level the transaction needs from the
+	// account.
+	Level      ThresholdLevel
+	Thresholds Thresholds
+	// Signers are the ed25519 signers of the account with a non-zero weight.
+	// Other signer types cannot attach signatures to a proposal.
+	Signers txnbuild.SignerSummary
+}
+
+// Threshold returns the weight needed from the account.
+func (r Requirement) Threshold() int32 {
+	return r.Thresholds.Weight(r.Level)
+}
+
+// Weight returns the weight of the given signers on the account.
+func (r Requirement) Weight(signers map[string]bool) int32 {
+	w := int32(0)
+	for s := range signers {
+		w += r.Signers[s]
+	}
+	return w
+}
+
+// Check is a signature check the network performs on a source account when
+// validating the transaction.
+type Check struct {
+	Account string
+	Level   ThresholdLevel
+}
+
+// New returns a proposal for the transaction. The signers and thresholds of
+// every source account are looked up using the OrbitR client. Signatures
+// already attached to the transaction are verified and collected.
+func New(tx *txnbuild.Transaction, networkPassphrase string, client orbitrclient.ClientInterface, now time.Time) (Proposal, error) {
+	hash, err := tx.HashHex(networkPassphrase)
+	if err != nil {
+		return Proposal{}, errors.Wrap(err, "hashing transaction")
+	}
+
+	checks, err := transactionChecks(tx)
+	if err != nil {
+		return Proposal{}, err
+	}
+
+	requirements := []Requirement{}
+	index := map[string]int{}
+	for _, c := range checks {
+		if i, ok := index[c.Account]; ok {
+			if thresholdLevelRank[c.Level] > thresholdLevelRank[requirements[i].Level] {
+				requirements[i].Level = c.Level
+			}
+			continue
+		}
+		account, err := client.AccountDetail(orbitrclient.AccountRequest{AccountID: c.Account})
+		if err != nil {
+			return Proposal{}, errors.Wrapf(err, "getting account %s", c.Account)
+		}
+		index[c.Account] = len(requirements)
+		requirements = append(requirements, newRequirement(c, account))
+	}
+
+	for _, r := range requirements {
+		total := int32(0)
+		for _, w := range r.Signers {
+			total += w
+		}
+		if total < r.Threshold() {
+			return Proposal{}, errors.Wrapf(ErrUnreachableThreshold, "account %s %s threshold", r.Account, r.Level)
+		}
+	}
+
+	unsigned, err := tx.ClearSignatures()
+	if err != nil {
+		return Proposal{}, errors.Wrap(err, "clearing signatures")
+	}
+	envelope, err := unsigned.Base64()
+	if err != nil {
+		return Proposal{}, errors.Wrap(err, "encoding transaction")
+	}
+
+	p := Proposal{
+		Hash:         hash,
+		Envelope:     envelope,
+		Requirements: requirements,
+		Checks:       checks,
+		Signatures:   []Signature{},
+		Status:       StatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	for _, sig := range tx.Signatures() {
+		signer, ok := p.signerWithHint(sig)
+		if !ok {
+			return Proposal{}, ErrInvalidSignature
+		}
+		_, err = p.AddSignature(signer, base64.StdEncoding.EncodeToString(sig.Signature), now)
+		if err != nil {
+			return Proposal{}, err
+		}
+	}
+	return p, nil
+}
+
+func newRequirement(c Check, account orbitr.Account) Requirement {
+	signers := txnbuild.SignerSummary{}
+	for _, s := range account.Signers {
+		if s.Weight > 0 && s.Type == "ed25519_public_key" {
+			signers[s.Key] = s.Weight
+		}
+	}
+	return Requirement{
+		Account: c.Account,
+		Level:   c.Level,
+		Thresholds: Thresholds{
+			Low:    int32(account.Thresholds.LowThreshold),
+			Medium: int32(account.Thresholds.MedThreshold),
+			High:   int32(account.Thresholds.HighThreshold),
+		},
+		Signers: signers,
+	}
+}
+
+// signerWithHint returns the signer of any source account whose hint matches
+// the decorated signature and whose key verifies it.
+func (p Proposal) signerWithHint(sig xdr.DecoratedSignature) (string, bool) {
+	for _, r := range p.Requirements {
+		for s := range r.Signers {
+			kp, err := keypair.ParseAddress(s)
+			if err != nil || kp.Hint() != sig.Hint {
+				continue
+			}
+			if p.verify(s, base64.StdEncoding.EncodeToString(sig.Signature)) == nil {
+				return s, true
+			}
+		}
+	}
+	return "", false
+}
+
+// transactionChecks returns the signature checks of the transaction in the
+// order the network performs them: the transaction source account at the low
+// threshold, then the source account of each operation at the threshold of
+// the operation.
+func transactionChecks(tx *txnbuild.Transaction) ([]Check, error) {
+	source, err := accountID(tx.SourceAccount().AccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing transaction source account")
+	}
+	checks := []Check{{Account: source, Level: ThresholdLevelLow}}
+	for i, op := range tx.Operations() {
+		opSource := source
+		if op.GetSourceAccount() != "" {
+			opSource, err = accountID(op.GetSourceAccount())
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing source account of operation %d", i)
+			}
+		}
+		checks = append(checks, Check{Account: opSource, Level: OperationThresholdLevel(op)})
+	}
+	return checks, nil
+}
+
+// OperationThresholdLevel returns the threshold level the operation needs on
+// its source account.
+func OperationThresholdLevel(op txnbuild.Operation) ThresholdLevel {
+	switch o := op.(type) {
+	case *txnbuild.AllowTrust,
+		*txnbuild.BumpSequence,
+		*txnbuild.SetTrustLineFlags,
+		*txnbuild.ClaimClaimableBalance,
+		*txnbuild.Inflation,
+		*txnbuild.BumpFootprintExpiration,
+		*txnbuild.RestoreFootprint:
+		return ThresholdLevelLow
+	case *txnbuild.AccountMerge:
+		return ThresholdLevelHigh
+	case *txnbuild.SetOptions:
+		if o.MasterWeight != nil || o.LowThreshold != nil || o.MediumThreshold != nil ||
+			o.HighThreshold != nil || o.Signer != nil {
+			return ThresholdLevelHigh
+		}
+	}
+	return ThresholdLevelMedium
+}
+
+// accountID returns the G address of a possibly muxed account address.
+func accountID(address string) (string, error) {
+	muxed, err := xdr.AddressToMuxedAccount(address)
+	if err != nil {
+		return "", err
+	}
+	id := muxed.ToAccountId()
+	return id.Address(), nil
+}
@@ -0,0 +1,14 @@
+package proposal
+
+import "errors"
+
+type Store interface {
+	Add(p Proposal) error
+	Get(hash string) (Proposal, error)
+	// Update applies fn to the proposal with the given hash and saves the
+	// result. Updates of the same proposal are applied one at a time.
+	Update(hash string, fn func(p *Proposal) error) (Proposal, error)
+}
+
+var ErrNotFound = errors.New("proposal not found")
+var ErrAlreadyExists = errors.New("proposal already exists")
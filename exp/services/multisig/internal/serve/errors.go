@@ -0,0 +1,60 @@
+package serve
+
+import (
+	"net/http"
+
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+var serverError = errorResponse{
+	Status: http.StatusInternalServerError,
+	Error:  "An error occurred while processing this request.",
+}
+var notFound = errorResponse{
+	Status: http.StatusNotFound,
+	Error:  "The resource at the url requested was not found.",
+}
+var methodNotAllowed = errorResponse{
+	Status: http.StatusMethodNotAllowed,
+	Error:  "The method is not allowed for resource at the url requested.",
+}
+var badRequest = errorResponse{
+	Status: http.StatusBadRequest,
+	Error:  "The request was invalid in some way.",
+}
+var conflict = errorResponse{
+	Status: http.StatusConflict,
+	Error:  "The request could not be completed because the resource already exists.",
+}
+var alreadySubmitted = errorResponse{
+	Status: http.StatusConflict,
+	Error:  "The transaction has already been submitted.",
+}
+var notReady = errorResponse{
+	Status: http.StatusConflict,
+	Error:  "The transaction does not have enough signatures to be submitted.",
+}
+
+type errorResponse struct {
+	Status int    `json:"-"`
+	Error  string `json:"error"`
+}
+
+func (e errorResponse) Render(w http.ResponseWriter) {
+	httpjson.RenderStatus(w, e.Status, e, httpjson.JSON)
+}
+
+func badRequestError(err error) errorResponse {
+	return errorResponse{
+		Status: http.StatusBadRequest,
+		Error:  err.Error(),
+	}
+}
+
+type errorHandler struct {
+	Error errorResponse
+}
+
+func (h errorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Error.Render(w)
+}
@@ -0,0 +1,119 @@
+package serve
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/exp/services/multisig/internal/db"
+	"github.com/metriqorg/go/exp/services/multisig/internal/proposal"
+	"github.com/metriqorg/go/support/errors"
+	supporthttp "github.com/metriqorg/go/support/http"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/health"
+)
+
+type Options struct {
+	Logger            *supportlog.Entry
+	Port              int
+	DatabaseURL       string
+	OrbitRURL         string
+	NetworkPassphrase string
+}
+
+func Serve(opts Options) {
+	handler, err := handler(opts)
+	if err != nil {
+		opts.Logger.Fatalf("Error: %v", err)
+		return
+	}
+
+	addr := fmt.Sprintf(":%d", opts.Port)
+	supporthttp.Run(supporthttp.Config{
+		ListenAddr: addr,
+		Handler:    handler,
+		OnStarting: func() {
+			opts.Logger.Info("Starting Multisig Coordination Server")
+			opts.Logger.Infof("Listening on %s", addr)
+		},
+	})
+}
+
+func handler(opts Options) (http.Handler, error) {
+	orbitrTimeout := orbitrclient.OrbitRTimeout
+	httpClient := &http.Client{
+		Timeout: orbitrTimeout,
+	}
+	orbitrClient := &orbitrclient.Client{
+		OrbitRURL: opts.OrbitRURL,
+		HTTP:      httpClient,
+	}
+	orbitrClient.SetOrbitRTimeout(orbitrTimeout)
+
+	store, err := proposalStore(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return handlerWithDeps(opts, orbitrClient, store), nil
+}
+
+// proposalStore returns a store keeping proposals in the database, or in
+// memory if no database is configured.
+func proposalStore(opts Options) (proposal.Store, error) {
+	if opts.DatabaseURL == "" {
+		opts.Logger.Warn("No database configured, proposals are kept in memory and are lost when the server restarts")
+		return proposal.NewMemoryStore(), nil
+	}
+
+	db, err := db.Open(opts.DatabaseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing database url")
+	}
+	err = db.Ping()
+	if err != nil {
+		opts.Logger.Warn("Error pinging to Database: ", err)
+	}
+	return &proposal.DBStore{DB: db}, nil
+}
+
+func handlerWithDeps(opts Options, orbitrClient orbitrclient.ClientInterface, store proposal.Store) http.Handler {
+	sub := submitter{
+		Logger:        opts.Logger,
+		OrbitRClient:  orbitrClient,
+		ProposalStore: store,
+	}
+
+	mux := supporthttp.NewAPIMux(opts.Logger)
+
+	mux.NotFound(errorHandler{Error: notFound}.ServeHTTP)
+	mux.MethodNotAllowed(errorHandler{Error: methodNotAllowed}.ServeHTTP)
+
+	mux.Get("/health", health.PassHandler{}.ServeHTTP)
+	mux.Post("/transactions", transactionPostHandler{
+		Logger:            opts.Logger,
+		NetworkPassphrase: opts.NetworkPassphrase,
+		OrbitRClient:      orbitrClient,
+		ProposalStore:     store,
+		Submitter:         sub,
+	}.ServeHTTP)
+	mux.Get("/transactions/{hash}", transactionGetHandler{
+		Logger:            opts.Logger,
+		NetworkPassphrase: opts.NetworkPassphrase,
+		ProposalStore:     store,
+	}.ServeHTTP)
+	mux.Post("/transactions/{hash}/signatures", transactionSignHandler{
+		Logger:            opts.Logger,
+		NetworkPassphrase: opts.NetworkPassphrase,
+		ProposalStore:     store,
+		Submitter:         sub,
+	}.ServeHTTP)
+	mux.Post("/transactions/{hash}/submit", transactionSubmitHandler{
+		Logger:            opts.Logger,
+		NetworkPassphrase: opts.NetworkPassphrase,
+		ProposalStore:     store,
+		Submitter:         sub,
+	}.ServeHTTP)
+
+	return mux
+}
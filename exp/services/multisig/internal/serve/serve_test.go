@@ -0,0 +1,166 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/exp/services/multisig/internal/proposal"
+	"github.com/metriqorg/go/exp/services/multisig/multisigclient"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/support/errors"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/problem"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, orbitrClient orbitrclient.ClientInterface) *multisigclient.Client {
+	h := handlerWithDeps(Options{
+		Logger:            supportlog.DefaultLogger,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}, orbitrClient, proposal.NewMemoryStore())
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+	return &multisigclient.Client{URL: server.URL}
+}
+
+func TestServe_threeOfFive(t *testing.T) {
+	treasury := keypair.MustRandom()
+	signers := []*keypair.Full{}
+	account := orbitr.Account{
+		AccountID:  treasury.Address(),
+		Thresholds: orbitr.AccountThresholds{LowThreshold: 3, MedThreshold: 3, HighThreshold: 3},
+		Signers:    []orbitr.Signer{{Key: treasury.Address(), Weight: 0, Type: "ed25519_public_key"}},
+	}
+	for i := 0; i < 5; i++ {
+		kp := keypair.MustRandom()
+		signers = append(signers, kp)
+		account.Signers = append(account.Signers, orbitr.Signer{Key: kp.Address(), Weight: 1, Type: "ed25519_public_key"})
+	}
+
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.On("AccountDetail", orbitrclient.AccountRequest{AccountID: treasury.Address()}).
+		Return(account, nil).
+		Once()
+	client := newTestServer(t, orbitrClient)
+
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: treasury.Address(), Sequence: 1},
+		Operations: []txnbuild.Operation{
+			&txnbuild.Payment{Destination: keypair.MustRandom().Address(), Amount: "100", Asset: txnbuild.NativeAsset{}},
+		},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, signers[0])
+	require.NoError(t, err)
+	hash, err := tx.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+
+	proposed, err := client.Propose(tx)
+	require.NoError(t, err)
+	assert.Equal(t, hash, proposed.Hash)
+	assert.Equal(t, multisigclient.StatusPending, proposed.Status)
+	require.Len(t, proposed.Accounts, 1)
+	assert.Equal(t, "medium", proposed.Accounts[0].ThresholdLevel)
+	assert.Equal(t, int32(3), proposed.Accounts[0].Threshold)
+	assert.Equal(t, int32(1), proposed.Accounts[0].Weight)
+	assert.Len(t, proposed.Accounts[0].Signers, 5)
+	assert.Empty(t, proposed.SignedEnvelopeXDR)
+
+	_, err = client.Propose(tx)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, err.(*multisigclient.Error).StatusCode)
+
+	_, err = client.Sign(hash, network.TestNetworkPassphrase, keypair.MustRandom())
+	require.Error(t, err)
+	assert.Equal(t, &multisigclient.Error{
+		StatusCode: http.StatusBadRequest,
+		Message:    "signer is not a signer of any source account",
+	}, err)
+
+	_, err = client.Sign(hash, network.PublicNetworkPassphrase, signers[1])
+	assert.EqualError(t, err, "transaction returned by the server does not match the hash")
+
+	signed, err := client.Sign(hash, network.TestNetworkPassphrase, signers[1])
+	require.NoError(t, err)
+	assert.Equal(t, multisigclient.StatusPending, signed.Status)
+	assert.Equal(t, int32(2), signed.Accounts[0].Weight)
+
+	_, err = client.Submit(hash)
+	assert.Equal(t, http.StatusConflict, err.(*multisigclient.Error).StatusCode)
+
+	orbitrClient.On("SubmitTransactionXDR", mock.AnythingOfType("string")).
+		Return(orbitr.Transaction{}, errors.New("timeout")).
+		Once()
+	signed, err = client.Sign(hash, network.TestNetworkPassphrase, signers[2], signers[3])
+	require.NoError(t, err)
+	assert.Equal(t, multisigclient.StatusReady, signed.Status)
+	assert.Equal(t, "timeout", signed.SubmitError)
+	assert.Len(t, signed.Signatures, 4)
+
+	// Only three of the four signatures are needed by the network.
+	submitted, err := txnbuild.TransactionFromXDR(signed.SignedEnvelopeXDR)
+	require.NoError(t, err)
+	submittedTx, _ := submitted.Transaction()
+	assert.Len(t, submittedTx.Signatures(), 3)
+
+	orbitrClient.On("SubmitTransactionXDR", signed.SignedEnvelopeXDR).
+		Return(orbitr.Transaction{Ledger: 42}, nil).
+		Once()
+	signed, err = client.Submit(hash)
+	require.NoError(t, err)
+	assert.Equal(t, multisigclient.StatusSubmitted, signed.Status)
+	assert.Equal(t, int32(42), signed.Ledger)
+	assert.Empty(t, signed.SubmitError)
+
+	_, err = client.Sign(hash, network.TestNetworkPassphrase, signers[4])
+	assert.Equal(t, &multisigclient.Error{
+		StatusCode: http.StatusConflict,
+		Message:    "The transaction has already been submitted.",
+	}, err)
+
+	orbitrClient.AssertExpectations(t)
+}
+
+func TestServe_invalidTransaction(t *testing.T) {
+	account := keypair.MustRandom()
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(orbitr.Account{}, &orbitrclient.Error{Problem: problem.P{Type: "https://metriq.network/orbitr-errors/not_found"}}).
+		Once()
+	client := newTestServer(t, orbitrClient)
+
+	_, err := client.Transaction(strings.Repeat("0", 64))
+	assert.Equal(t, http.StatusNotFound, err.(*multisigclient.Error).StatusCode)
+
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: account.Address(), Sequence: 1},
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 2}},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimebounds(0, 1)},
+	})
+	require.NoError(t, err)
+	_, err = client.Propose(tx)
+	assert.Equal(t, http.StatusBadRequest, err.(*multisigclient.Error).StatusCode)
+
+	tx, err = txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: account.Address(), Sequence: 1},
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 2}},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	_, err = client.Propose(tx)
+	assert.Equal(t, http.StatusBadRequest, err.(*multisigclient.Error).StatusCode)
+
+	orbitrClient.AssertExpectations(t)
+}
@@ -0,0 +1,55 @@
+package serve
+
+import (
+	"context"
+	"time"
+
+	"github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/exp/services/multisig/internal/proposal"
+	supportlog "github.com/metriqorg/go/support/log"
+)
+
+// submitter submits proposals that have collected enough signatures.
+type submitter struct {
+	Logger        *supportlog.Entry
+	OrbitRClient  orbitrclient.ClientInterface
+	ProposalStore proposal.Store
+}
+
+// Submit submits the proposal if it is ready and records the outcome. A
+// failed submission leaves the proposal ready so that it can be retried.
+func (s submitter) Submit(ctx context.Context, p proposal.Proposal) (proposal.Proposal, error) {
+	if p.Status != proposal.StatusReady {
+		return p, nil
+	}
+	l := s.Logger.Ctx(ctx).WithField("transaction_hash", p.Hash)
+
+	envelope, err := p.SignedEnvelope()
+	if err != nil {
+		return p, err
+	}
+
+	l.Info("Submitting transaction.")
+	tx, submitErr := s.OrbitRClient.SubmitTransactionXDR(envelope)
+	if submitErr != nil {
+		l.WithField("error", submitErr.Error()).Info("Submitting transaction failed.")
+	} else {
+		l.WithField("ledger", tx.Ledger).Info("Transaction submitted.")
+	}
+
+	return s.ProposalStore.Update(p.Hash, func(p *proposal.Proposal) error {
+		if p.Status == proposal.StatusSubmitted {
+			// Submitted concurrently by another request.
+			return nil
+		}
+		p.UpdatedAt = time.Now().UTC()
+		if submitErr != nil {
+			p.SubmitError = submitErr.Error()
+			return nil
+		}
+		p.Status = proposal.StatusSubmitted
+		p.SubmitError = ""
+		p.Ledger = tx.Ledger
+		return nil
+	})
+}
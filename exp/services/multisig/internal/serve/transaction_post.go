@@ -0,0 +1,125 @@
+package serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/exp/services/multisig/internal/proposal"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/http/httpdecode"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+	"github.com/metriqorg/go/txnbuild"
+)
+
+type transactionPostHandler struct {
+	Logger            *supportlog.Entry
+	NetworkPassphrase string
+	OrbitRClient      orbitrclient.ClientInterface
+	ProposalStore     proposal.Store
+	Submitter         submitter
+}
+
+type transactionPostRequest struct {
+	Transaction string `json:"transaction" form:"transaction"`
+}
+
+func (h transactionPostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := transactionPostRequest{}
+	err := httpdecode.Decode(r, &req)
+	if err != nil {
+		badRequest.Render(w)
+		return
+	}
+
+	l := h.Logger.Ctx(ctx)
+
+	parsed, err := txnbuild.TransactionFromXDR(req.Transaction)
+	if err != nil {
+		l.WithField("transaction", req.Transaction).
+			Info("Parsing transaction failed.")
+		badRequest.Render(w)
+		return
+	}
+	tx, ok := parsed.Transaction()
+	if !ok {
+		l.Info("Transaction is not a simple transaction.")
+		badRequest.Render(w)
+		return
+	}
+	maxTime := tx.Timebounds().MaxTime
+	if maxTime != 0 && maxTime < time.Now().Unix() {
+		l.Info("Transaction has expired.")
+		badRequest.Render(w)
+		return
+	}
+
+	hash, err := tx.HashHex(h.NetworkPassphrase)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	l = l.WithField("transaction_hash", hash)
+
+	_, err = h.ProposalStore.Get(hash)
+	if err == nil {
+		l.Info("Transaction has already been proposed.")
+		conflict.Render(w)
+		return
+	} else if err != proposal.ErrNotFound {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	p, err := proposal.New(tx, h.NetworkPassphrase, h.OrbitRClient, time.Now().UTC())
+	if orbitrclient.IsNotFoundError(err) {
+		l.Info("Source account of transaction not found.")
+		badRequestError(err).Render(w)
+		return
+	} else if err == proposal.ErrNotSigner || err == proposal.ErrInvalidSignature {
+		l.Info("Transaction has signatures that are not valid.")
+		badRequestError(err).Render(w)
+		return
+	} else if errors.Cause(err) == proposal.ErrUnreachableThreshold {
+		l.Info(err)
+		badRequestError(err).Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	err = h.ProposalStore.Add(p)
+	if err == proposal.ErrAlreadyExists {
+		l.Info("Transaction has already been proposed.")
+		conflict.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	l.Infof("Transaction proposed with %d source account(s).", len(p.Requirements))
+
+	p, err = h.Submitter.Submit(ctx, p)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	resp, err := newTransactionResponse(p, h.NetworkPassphrase)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	httpjson.RenderStatus(w, http.StatusCreated, resp, httpjson.JSON)
+}
@@ -0,0 +1,97 @@
+package serve
+
+import (
+	"sort"
+	"time"
+
+	"github.com/metriqorg/go/exp/services/multisig/internal/proposal"
+)
+
+type transactionResponse struct {
+	Hash              string                         `json:"hash"`
+	NetworkPassphrase string                         `json:"network_passphrase"`
+	Status            proposal.Status                `json:"status"`
+	EnvelopeXDR       string                         `json:"envelope_xdr"`
+	SignedEnvelopeXDR string                         `json:"signed_envelope_xdr,omitempty"`
+	Accounts          []transactionResponseAccount   `json:"accounts"`
+	Signatures        []transactionResponseSignature `json:"signatures"`
+	SubmitError       string                         `json:"submit_error,omitempty"`
+	Ledger            int32                          `json:"ledger,omitempty"`
+	CreatedAt         time.Time                      `json:"created_at"`
+	UpdatedAt         time.Time                      `json:"updated_at"`
+}
+
+type transactionResponseAccount struct {
+	Account        string                      `json:"account"`
+	ThresholdLevel proposal.ThresholdLevel     `json:"threshold_level"`
+	Threshold      int32                       `json:"threshold"`
+	Weight         int32                       `json:"weight"`
+	Signers        []transactionResponseSigner `json:"signers"`
+}
+
+type transactionResponseSigner struct {
+	Key    string `json:"key"`
+	Weight int32  `json:"weight"`
+	Signed bool   `json:"signed"`
+}
+
+type transactionResponseSignature struct {
+	Signer    string    `json:"signer"`
+	Signature string    `json:"signature"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+func newTransactionResponse(p proposal.Proposal, networkPassphrase string) (transactionResponse, error) {
+	resp := transactionResponse{
+		Hash:              p.Hash,
+		NetworkPassphrase: networkPassphrase,
+		Status:            p.Status,
+		EnvelopeXDR:       p.Envelope,
+		Accounts:          []transactionResponseAccount{},
+		Signatures:        []transactionResponseSignature{},
+		SubmitError:       p.SubmitError,
+		Ledger:            p.Ledger,
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
+	}
+
+	if p.Met() {
+		signed, err := p.SignedEnvelope()
+		if err != nil {
+			return transactionResponse{}, err
+		}
+		resp.SignedEnvelopeXDR = signed
+	}
+
+	signed := p.Signers()
+	for _, r := range p.Requirements {
+		account := transactionResponseAccount{
+			Account:        r.Account,
+			ThresholdLevel: r.Level,
+			Threshold:      r.Threshold(),
+			Weight:         r.Weight(signed),
+			Signers:        []transactionResponseSigner{},
+		}
+		for key, weight := range r.Signers {
+			account.Signers = append(account.Signers, transactionResponseSigner{
+				Key:    key,
+				Weight: weight,
+				Signed: signed[key],
+			})
+		}
+		sort.Slice(account.Signers, func(i, j int) bool {
+			return account.Signers[i].Key < account.Signers[j].Key
+		})
+		resp.Accounts = append(resp.Accounts, account)
+	}
+
+	for _, s := range p.Signatures {
+		resp.Signatures = append(resp.Signatures, transactionResponseSignature{
+			Signer:    s.Signer,
+			Signature: s.Signature,
+			AddedAt:   s.AddedAt,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/metriqorg/go/exp/services/multisig/internal/proposal"
+	"github.com/metriqorg/go/support/http/httpdecode"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+type transactionSignHandler struct {
+	Logger            *supportlog.Entry
+	NetworkPassphrase string
+	ProposalStore     proposal.Store
+	Submitter         submitter
+}
+
+type transactionSignRequest struct {
+	Hash       string                            `path:"hash"`
+	Signatures []transactionSignRequestSignature `json:"signatures"`
+}
+
+type transactionSignRequestSignature struct {
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+func (h transactionSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := transactionSignRequest{}
+	err := httpdecode.Decode(r, &req)
+	if err != nil || req.Hash == "" || len(req.Signatures) == 0 {
+		badRequest.Render(w)
+		return
+	}
+
+	l := h.Logger.Ctx(ctx).
+		WithField("transaction_hash", req.Hash)
+
+	l.Infof("Request to add %d signature(s).", len(req.Signatures))
+
+	added := 0
+	p, err := h.ProposalStore.Update(req.Hash, func(p *proposal.Proposal) error {
+		now := time.Now().UTC()
+		for _, s := range req.Signatures {
+			ok, err := p.AddSignature(s.Signer, s.Signature, now)
+			if err != nil {
+				return err
+			}
+			if ok {
+				added++
+			}
+		}
+		return nil
+	})
+	switch err {
+	case nil:
+	case proposal.ErrNotFound:
+		l.Info("Transaction not found.")
+		notFound.Render(w)
+		return
+	case proposal.ErrSubmitted:
+		l.Info("Transaction has already been submitted.")
+		alreadySubmitted.Render(w)
+		return
+	case proposal.ErrNotSigner, proposal.ErrInvalidSignature:
+		l.Info(err)
+		badRequestError(err).Render(w)
+		return
+	default:
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	l.Infof("Added %d signature(s), transaction is %s.", added, p.Status)
+
+	p, err = h.Submitter.Submit(ctx, p)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	resp, err := newTransactionResponse(p, h.NetworkPassphrase)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	httpjson.Render(w, resp, httpjson.JSON)
+}
@@ -0,0 +1,72 @@
+package serve
+
+import (
+	"net/http"
+
+	"github.com/metriqorg/go/exp/services/multisig/internal/proposal"
+	"github.com/metriqorg/go/support/http/httpdecode"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+// transactionSubmitHandler retries the submission of a transaction that has
+// collected enough signatures but failed to be submitted.
+type transactionSubmitHandler struct {
+	Logger            *supportlog.Entry
+	NetworkPassphrase string
+	ProposalStore     proposal.Store
+	Submitter         submitter
+}
+
+type transactionSubmitRequest struct {
+	Hash string `path:"hash"`
+}
+
+func (h transactionSubmitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := transactionSubmitRequest{}
+	err := httpdecode.Decode(r, &req)
+	if err != nil || req.Hash == "" {
+		badRequest.Render(w)
+		return
+	}
+
+	l := h.Logger.Ctx(ctx).
+		WithField("transaction_hash", req.Hash)
+
+	p, err := h.ProposalStore.Get(req.Hash)
+	if err == proposal.ErrNotFound {
+		l.Info("Transaction not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	switch p.Status {
+	case proposal.StatusSubmitted:
+		alreadySubmitted.Render(w)
+		return
+	case proposal.StatusPending:
+		notReady.Render(w)
+		return
+	}
+
+	p, err = h.Submitter.Submit(ctx, p)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	resp, err := newTransactionResponse(p, h.NetworkPassphrase)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	httpjson.Render(w, resp, httpjson.JSON)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/metriqorg/go/exp/services/multisig/cmd"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	logger := supportlog.New()
+	logger.SetLevel(logrus.TraceLevel)
+
+	rootCmd := &cobra.Command{
+		Use:   "multisig [command]",
+		Short: "Multisig Coordination Server",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	rootCmd.AddCommand((&cmd.ServeCommand{Logger: logger}).Command())
+	rootCmd.AddCommand((&cmd.DBCommand{Logger: logger}).Command())
+
+	err := rootCmd.Execute()
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
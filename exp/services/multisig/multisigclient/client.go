@@ -0,0 +1,202 @@
+// Package multisigclient is a client for the multisig coordination server. A
+// proposer uploads a transaction with Propose, co-signers inspect and sign it
+// with Transaction and Sign, and the server submits it to the network once the
+// thresholds of all its source accounts are met.
+package multisigclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+)
+
+// Transaction statuses.
+const (
+	StatusPending   = "pending"
+	StatusReady     = "ready"
+	StatusSubmitted = "submitted"
+)
+
+// HTTP is the HTTP client used to send requests to the server.
+type HTTP interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client is a client for a multisig coordination server.
+type Client struct {
+	// URL is the base URL of the server.
+	URL  string
+	HTTP HTTP
+}
+
+// Transaction is a transaction collecting signatures on the server.
+type Transaction struct {
+	Hash              string      `json:"hash"`
+	NetworkPassphrase string      `json:"network_passphrase"`
+	Status            string      `json:"status"`
+	EnvelopeXDR       string      `json:"envelope_xdr"`
+	SignedEnvelopeXDR string      `json:"signed_envelope_xdr,omitempty"`
+	Accounts          []Account   `json:"accounts"`
+	Signatures        []Signature `json:"signatures"`
+	SubmitError       string      `json:"submit_error,omitempty"`
+	Ledger            int32       `json:"ledger,omitempty"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// Account is the signing requirement of a source account of a transaction.
+type Account struct {
+	Account        string   `json:"account"`
+	ThresholdLevel string   `json:"threshold_level"`
+	Threshold      int32    `json:"threshold"`
+	Weight         int32    `json:"weight"`
+	Signers        []Signer `json:"signers"`
+}
+
+// Signer is a signer of a source account.
+type Signer struct {
+	Key    string `json:"key"`
+	Weight int32  `json:"weight"`
+	Signed bool   `json:"signed"`
+}
+
+// Signature is a base64 encoded signature of a transaction hash.
+type Signature struct {
+	Signer    string    `json:"signer"`
+	Signature string    `json:"signature"`
+	AddedAt   time.Time `json:"added_at,omitempty"`
+}
+
+// Transaction decodes the unsigned transaction envelope.
+func (t Transaction) Transaction() (*txnbuild.Transaction, error) {
+	parsed, err := txnbuild.TransactionFromXDR(t.EnvelopeXDR)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing transaction envelope")
+	}
+	tx, ok := parsed.Transaction()
+	if !ok {
+		return nil, errors.New("envelope is not a transaction")
+	}
+	return tx, nil
+}
+
+// Error is an error response of the server.
+type Error struct {
+	StatusCode int
+	Message    string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("multisig server error (%d): %s", e.StatusCode, e.Message)
+}
+
+// Propose uploads a transaction. Signatures already attached to the
+// transaction are collected.
+func (c *Client) Propose(tx *txnbuild.Transaction) (Transaction, error) {
+	envelope, err := tx.Base64()
+	if err != nil {
+		return Transaction{}, errors.Wrap(err, "encoding transaction")
+	}
+	body := map[string]string{"transaction": envelope}
+	return c.do(http.MethodPost, "transactions", body)
+}
+
+// Transaction returns the transaction with the given hash.
+func (c *Client) Transaction(hash string) (Transaction, error) {
+	return c.do(http.MethodGet, "transactions/"+url.PathEscape(hash), nil)
+}
+
+// AddSignatures attaches signatures to the transaction with the given hash.
+func (c *Client) AddSignatures(hash string, signatures ...Signature) (Transaction, error) {
+	body := map[string][]Signature{"signatures": signatures}
+	return c.do(http.MethodPost, "transactions/"+url.PathEscape(hash)+"/signatures", body)
+}
+
+// Sign fetches the transaction with the given hash, signs it with the signers
+// and attaches the signatures. The transaction hash is computed locally using
+// networkPassphrase so that a signer never signs a transaction other than the
+// one it asked for.
+func (c *Client) Sign(hash, networkPassphrase string, signers ...*keypair.Full) (Transaction, error) {
+	t, err := c.Transaction(hash)
+	if err != nil {
+		return Transaction{}, err
+	}
+	tx, err := t.Transaction()
+	if err != nil {
+		return Transaction{}, err
+	}
+	txHash, err := tx.Hash(networkPassphrase)
+	if err != nil {
+		return Transaction{}, errors.Wrap(err, "hashing transaction")
+	}
+	if fmt.Sprintf("%x", txHash) != hash {
+		return Transaction{}, errors.New("transaction returned by the server does not match the hash")
+	}
+
+	signatures := make([]Signature, 0, len(signers))
+	for _, kp := range signers {
+		sig, err := kp.SignBase64(txHash[:])
+		if err != nil {
+			return Transaction{}, errors.Wrapf(err, "signing with %s", kp.Address())
+		}
+		signatures = append(signatures, Signature{Signer: kp.Address(), Signature: sig})
+	}
+	return c.AddSignatures(hash, signatures...)
+}
+
+// Submit retries the submission of a transaction that has enough signatures
+// but failed to be submitted.
+func (c *Client) Submit(hash string) (Transaction, error) {
+	return c.do(http.MethodPost, "transactions/"+url.PathEscape(hash)+"/submit", nil)
+}
+
+func (c *Client) do(method, path string, body interface{}) (Transaction, error) {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return Transaction{}, errors.Wrap(err, "encoding request")
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.URL, "/")+"/"+path, &reqBody)
+	if err != nil {
+		return Transaction{}, errors.Wrap(err, "building request")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http().Do(req)
+	if err != nil {
+		return Transaction{}, errors.Wrap(err, "sending request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		serverErr := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(serverErr); err != nil {
+			serverErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return Transaction{}, serverErr
+	}
+
+	t := Transaction{}
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return Transaction{}, errors.Wrap(err, "decoding response")
+	}
+	return t, nil
+}
+
+func (c *Client) http() HTTP {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
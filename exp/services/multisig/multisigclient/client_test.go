@@ -0,0 +1,196 @@
+package multisigclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Method      string
+	Path        string
+	ContentType string
+	Body        string
+}
+
+// newTestServer returns a server that records the requests it receives and
+// responds to them with the transaction.
+func newTestServer(t *testing.T, resp Transaction) (*httptest.Server, *[]request) {
+	requests := []request{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests = append(requests, request{
+			Method:      r.Method,
+			Path:        r.URL.EscapedPath(),
+			ContentType: r.Header.Get("Content-Type"),
+			Body:        string(body),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestTransaction(t *testing.T, source *keypair.Full) *txnbuild.Transaction {
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address(), Sequence: 1},
+		Operations: []txnbuild.Operation{
+			&txnbuild.Payment{Destination: keypair.MustRandom().Address(), Amount: "100", Asset: txnbuild.NativeAsset{}},
+		},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func TestClient_Propose(t *testing.T) {
+	tx := newTestTransaction(t, keypair.MustRandom())
+	envelope, err := tx.Base64()
+	require.NoError(t, err)
+
+	server, requests := newTestServer(t, Transaction{Hash: "abc", Status: StatusPending, EnvelopeXDR: envelope})
+	client := &Client{URL: server.URL + "/"}
+
+	resp, err := client.Propose(tx)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.Hash)
+	assert.Equal(t, StatusPending, resp.Status)
+
+	require.Len(t, *requests, 1)
+	assert.Equal(t, http.MethodPost, (*requests)[0].Method)
+	assert.Equal(t, "/transactions", (*requests)[0].Path)
+	assert.Equal(t, "application/json", (*requests)[0].ContentType)
+	assert.JSONEq(t, `{"transaction": "`+envelope+`"}`, (*requests)[0].Body)
+}
+
+func TestClient_Transaction(t *testing.T) {
+	server, requests := newTestServer(t, Transaction{
+		Hash:     "abc",
+		Status:   StatusPending,
+		Accounts: []Account{{Account: "G1", ThresholdLevel: "medium", Threshold: 2, Weight: 1}},
+	})
+	client := &Client{URL: server.URL}
+
+	resp, err := client.Transaction("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.Hash)
+	assert.Equal(t, []Account{{Account: "G1", ThresholdLevel: "medium", Threshold: 2, Weight: 1}}, resp.Accounts)
+
+	require.Len(t, *requests, 1)
+	assert.Equal(t, http.MethodGet, (*requests)[0].Method)
+	assert.Equal(t, "/transactions/abc", (*requests)[0].Path)
+	assert.Equal(t, "", (*requests)[0].ContentType)
+	assert.Equal(t, "", (*requests)[0].Body)
+}
+
+func TestClient_AddSignatures(t *testing.T) {
+	server, requests := newTestServer(t, Transaction{Hash: "abc", Status: StatusReady})
+	client := &Client{URL: server.URL}
+
+	resp, err := client.AddSignatures("abc", Signature{Signer: "G1", Signature: "c2ln"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusReady, resp.Status)
+
+	require.Len(t, *requests, 1)
+	assert.Equal(t, http.MethodPost, (*requests)[0].Method)
+	assert.Equal(t, "/transactions/abc/signatures", (*requests)[0].Path)
+	assert.JSONEq(t, `{"signatures": [{"signer": "G1", "signature": "c2ln", "added_at": "0001-01-01T00:00:00Z"}]}`, (*requests)[0].Body)
+}
+
+func TestClient_Sign(t *testing.T) {
+	signer := keypair.MustRandom()
+	tx := newTestTransaction(t, signer)
+	envelope, err := tx.Base64()
+	require.NoError(t, err)
+	hash, err := tx.Hash(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	hashHex, err := tx.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+
+	server, requests := newTestServer(t, Transaction{Hash: hashHex, Status: StatusPending, EnvelopeXDR: envelope})
+	client := &Client{URL: server.URL}
+
+	_, err = client.Sign(hashHex, network.TestNetworkPassphrase, signer)
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 2)
+	assert.Equal(t, http.MethodGet, (*requests)[0].Method)
+	assert.Equal(t, "/transactions/"+hashHex, (*requests)[0].Path)
+	assert.Equal(t, http.MethodPost, (*requests)[1].Method)
+	assert.Equal(t, "/transactions/"+hashHex+"/signatures", (*requests)[1].Path)
+
+	body := struct {
+		Signatures []Signature `json:"signatures"`
+	}{}
+	require.NoError(t, json.Unmarshal([]byte((*requests)[1].Body), &body))
+	require.Len(t, body.Signatures, 1)
+	assert.Equal(t, signer.Address(), body.Signatures[0].Signer)
+	sig, err := base64.StdEncoding.DecodeString(body.Signatures[0].Signature)
+	require.NoError(t, err)
+	assert.NoError(t, signer.Verify(hash[:], sig))
+}
+
+func TestClient_Sign_hashMismatch(t *testing.T) {
+	signer := keypair.MustRandom()
+	envelope, err := newTestTransaction(t, signer).Base64()
+	require.NoError(t, err)
+	otherHash, err := newTestTransaction(t, keypair.MustRandom()).HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+
+	// The server returns a transaction other than the one asked for.
+	server, requests := newTestServer(t, Transaction{Hash: otherHash, EnvelopeXDR: envelope})
+	client := &Client{URL: server.URL}
+
+	_, err = client.Sign(otherHash, network.TestNetworkPassphrase, signer)
+	assert.EqualError(t, err, "transaction returned by the server does not match the hash")
+	assert.Len(t, *requests, 1)
+}
+
+func TestClient_Submit(t *testing.T) {
+	server, requests := newTestServer(t, Transaction{Hash: "abc", Status: StatusSubmitted, Ledger: 123})
+	client := &Client{URL: server.URL}
+
+	resp, err := client.Submit("abc")
+	require.NoError(t, err)
+	assert.Equal(t, StatusSubmitted, resp.Status)
+	assert.Equal(t, int32(123), resp.Ledger)
+
+	require.Len(t, *requests, 1)
+	assert.Equal(t, http.MethodPost, (*requests)[0].Method)
+	assert.Equal(t, "/transactions/abc/submit", (*requests)[0].Path)
+	assert.Equal(t, "", (*requests)[0].Body)
+}
+
+func TestClient_errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/transactions/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "The resource at the url requested was not found."}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`not json`))
+		}
+	}))
+	defer server.Close()
+	client := &Client{URL: server.URL}
+
+	_, err := client.Transaction("missing")
+	assert.Equal(t, &Error{StatusCode: http.StatusNotFound, Message: "The resource at the url requested was not found."}, err)
+	assert.EqualError(t, err, "multisig server error (404): The resource at the url requested was not found.")
+
+	_, err = client.Submit("abc")
+	assert.Equal(t, &Error{StatusCode: http.StatusBadGateway, Message: "Bad Gateway"}, err)
+}
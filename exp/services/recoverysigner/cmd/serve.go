@@ -53,10 +53,17 @@ func (c *ServeCommand) Command() *cobra.Command {
 		},
 		{
 			Name:      "signing-key",
-			Usage:     "Stellar signing key(s) used for signing transactions comma separated (first key is preferred signer) (will be deprecated with per-account keys in the future)",
+			Usage:     "Stellar signing key(s) used for signing transactions comma separated (first key is preferred signer) (legacy keys shared by the accounts registered before a signing key seed was configured) (required if signing-key-seed is not set)",
 			OptType:   types.String,
 			ConfigKey: &opts.SigningKeys,
-			Required:  true,
+			Required:  false,
+		},
+		{
+			Name:      "signing-key-seed",
+			Usage:     "Hex encoded secret of at least 32 bytes from which a signing key is derived for each account registered (required if signing-key is not set)",
+			OptType:   types.String,
+			ConfigKey: &opts.SigningKeySeed,
+			Required:  false,
 		},
 		{
 			Name:      "sep10-jwks",
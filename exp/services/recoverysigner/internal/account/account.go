@@ -1,8 +1,60 @@
 package account
 
+import "time"
+
 type Account struct {
 	Address    string
 	Identities []Identity
+
+	// SigningKeys are the signing keys derived for the account, the most
+	// recently added first.
+	SigningKeys []SigningKey
+	// LegacySigningKeysRetired is true when the account no longer uses the
+	// signing keys shared by all accounts, which accounts registered before
+	// per-account signing keys were introduced use until they are retired.
+	LegacySigningKeysRetired bool
+}
+
+// SigningKey is a signing key derived for a single account. Its ID is the
+// index it is derived with.
+type SigningKey struct {
+	ID      int64
+	AddedAt time.Time
+}
+
+// Policy limits the transactions that are signed for an account. The zero
+// value allows any transaction.
+type Policy struct {
+	// AllowedOperationTypes are the names of the operation types, e.g.
+	// SetOptions, that transactions may contain. Any operation type is
+	// allowed if empty.
+	AllowedOperationTypes []string
+	// MaxPaymentAmount is the max amount, in millionths of a unit of the
+	// asset sent, that each operation may send. Amounts aren't limited if
+	// zero.
+	MaxPaymentAmount int64
+	// RequireTimeBounds requires transactions to have an upper time bound,
+	// which must not be more than MaxTimeBoundsDuration in the future unless
+	// it is zero.
+	RequireTimeBounds     bool
+	MaxTimeBoundsDuration time.Duration
+	// RateLimit is the max number of transactions signed for each identity
+	// within RateLimitPeriod. Signing isn't rate limited if zero.
+	RateLimit       int
+	RateLimitPeriod time.Duration
+}
+
+// SignAttempt is a request to sign a transaction for an account, which is
+// recorded whether the transaction is signed or denied.
+type SignAttempt struct {
+	Address string
+	// AuthMethod is the auth method the request was authorized with.
+	AuthMethod      AuthMethod
+	SigningAddress  string
+	TransactionHash string
+	// DeniedReason is the reason the transaction was denied, empty if it was
+	// signed.
+	DeniedReason string
 }
 
 type Identity struct {
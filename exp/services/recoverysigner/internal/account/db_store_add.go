@@ -11,10 +11,10 @@ func (s *DBStore) Add(a Account) error {
 
 	accountID := int64(0)
 	err = tx.Get(&accountID, `
-		INSERT INTO accounts (address)
-		VALUES ($1)
+		INSERT INTO accounts (address, legacy_signing_keys_retired)
+		VALUES ($1, $2)
 		RETURNING id
-	`, a.Address)
+	`, a.Address, a.LegacySigningKeysRetired)
 	if err != nil {
 		// 23505 is the PostgreSQL error for Unique Violation.
 		// See https://www.postgresql.org/docs/9.2/errcodes-appendix.html.
@@ -24,6 +24,16 @@ func (s *DBStore) Add(a Account) error {
 		return err
 	}
 
+	if a.LegacySigningKeysRetired {
+		_, err = tx.Exec(`
+			INSERT INTO account_signing_keys (account_id)
+			VALUES ($1)
+		`, accountID)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, i := range a.Identities {
 		identityID := int64(0)
 		err = tx.Get(&identityID, `
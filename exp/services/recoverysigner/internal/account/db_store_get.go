@@ -1,5 +1,11 @@
 package account
 
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
 func (s *DBStore) Get(address string) (Account, error) {
 	accounts, err := s.getAccounts("accounts.address = $1", address)
 	if err != nil {
@@ -20,6 +26,7 @@ func (s *DBStore) getAccounts(where string, args ...interface{}) ([]Account, err
 	query := `SELECT
 			accounts.id AS account_id,
 			accounts.address AS account_address,
+			accounts.legacy_signing_keys_retired AS account_legacy_signing_keys_retired,
 			identities.id AS identity_id,
 			identities.role AS identity_role,
 			auth_methods.type_ AS auth_method_type,
@@ -41,12 +48,13 @@ func (s *DBStore) getAccounts(where string, args ...interface{}) ([]Account, err
 
 	for rows.Next() {
 		var r struct {
-			AccountID       int64   `db:"account_id"`
-			AccountAddress  string  `db:"account_address"`
-			IdentityID      *int64  `db:"identity_id"`
-			IdentityRole    *string `db:"identity_role"`
-			AuthMethodType  *string `db:"auth_method_type"`
-			AuthMethodValue *string `db:"auth_method_value"`
+			AccountID                       int64   `db:"account_id"`
+			AccountAddress                  string  `db:"account_address"`
+			AccountLegacySigningKeysRetired bool    `db:"account_legacy_signing_keys_retired"`
+			IdentityID                      *int64  `db:"identity_id"`
+			IdentityRole                    *string `db:"identity_role"`
+			AuthMethodType                  *string `db:"auth_method_type"`
+			AuthMethodValue                 *string `db:"auth_method_value"`
 		}
 		err = rows.StructScan(&r)
 		if err != nil {
@@ -55,7 +63,10 @@ func (s *DBStore) getAccounts(where string, args ...interface{}) ([]Account, err
 
 		accountIndex, ok := accountIndexByAccountID[r.AccountID]
 		if !ok {
-			a := Account{Address: r.AccountAddress}
+			a := Account{
+				Address:                  r.AccountAddress,
+				LegacySigningKeysRetired: r.AccountLegacySigningKeysRetired,
+			}
 			accounts = append(accounts, a)
 			accountIndex = len(accounts) - 1
 			accountIndexByAccountID[r.AccountID] = accountIndex
@@ -97,5 +108,42 @@ func (s *DBStore) getAccounts(where string, args ...interface{}) ([]Account, err
 		accounts[accountIndex] = a
 	}
 
-	return accounts, nil
+	if len(accounts) == 0 {
+		return accounts, nil
+	}
+
+	accountIDs := make(pq.Int64Array, 0, len(accountIndexByAccountID))
+	for accountID := range accountIndexByAccountID {
+		accountIDs = append(accountIDs, accountID)
+	}
+	signingKeyRows, err := s.DB.Queryx(`
+		SELECT account_id, id, created_at
+		FROM account_signing_keys
+		WHERE account_id = ANY($1)
+		ORDER BY id DESC
+	`, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer signingKeyRows.Close()
+
+	for signingKeyRows.Next() {
+		var r struct {
+			AccountID int64     `db:"account_id"`
+			ID        int64     `db:"id"`
+			CreatedAt time.Time `db:"created_at"`
+		}
+		err = signingKeyRows.StructScan(&r)
+		if err != nil {
+			return nil, err
+		}
+
+		accountIndex := accountIndexByAccountID[r.AccountID]
+		accounts[accountIndex].SigningKeys = append(accounts[accountIndex].SigningKeys, SigningKey{
+			ID:      r.ID,
+			AddedAt: r.CreatedAt,
+		})
+	}
+
+	return accounts, signingKeyRows.Err()
 }
@@ -0,0 +1,197 @@
+package account
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func (s *DBStore) GetPolicy(address string) (Policy, error) {
+	var r struct {
+		AccountID              int64          `db:"account_id"`
+		AllowedOperationTypes  pq.StringArray `db:"allowed_operation_types"`
+		MaxPaymentAmount       *int64         `db:"max_payment_amount"`
+		RequireTimeBounds      *bool          `db:"require_time_bounds"`
+		MaxTimeBoundsSeconds   *int64         `db:"max_time_bounds_seconds"`
+		RateLimit              *int           `db:"rate_limit"`
+		RateLimitPeriodSeconds *int64         `db:"rate_limit_period_seconds"`
+	}
+	// The LEFT JOIN results in null policy columns for accounts that have no
+	// policy.
+	err := s.DB.Get(&r, `
+		SELECT
+			accounts.id AS account_id,
+			account_policies.allowed_operation_types,
+			account_policies.max_payment_amount,
+			account_policies.require_time_bounds,
+			account_policies.max_time_bounds_seconds,
+			account_policies.rate_limit,
+			account_policies.rate_limit_period_seconds
+		FROM accounts
+		LEFT JOIN account_policies ON account_policies.account_id = accounts.id
+		WHERE accounts.address = $1
+	`, address)
+	if err == sql.ErrNoRows {
+		return Policy{}, ErrNotFound
+	}
+	if err != nil {
+		return Policy{}, err
+	}
+	if r.MaxPaymentAmount == nil {
+		return Policy{}, nil
+	}
+
+	p := Policy{
+		MaxPaymentAmount:      *r.MaxPaymentAmount,
+		RequireTimeBounds:     *r.RequireTimeBounds,
+		MaxTimeBoundsDuration: time.Duration(*r.MaxTimeBoundsSeconds) * time.Second,
+		RateLimit:             *r.RateLimit,
+		RateLimitPeriod:       time.Duration(*r.RateLimitPeriodSeconds) * time.Second,
+	}
+	if len(r.AllowedOperationTypes) > 0 {
+		p.AllowedOperationTypes = r.AllowedOperationTypes
+	}
+	return p, nil
+}
+
+func (s *DBStore) UpdatePolicy(address string, p Policy) error {
+	allowedOperationTypes := pq.StringArray(p.AllowedOperationTypes)
+	if allowedOperationTypes == nil {
+		allowedOperationTypes = pq.StringArray{}
+	}
+	result, err := s.DB.Exec(`
+		INSERT INTO account_policies (
+			account_id,
+			allowed_operation_types,
+			max_payment_amount,
+			require_time_bounds,
+			max_time_bounds_seconds,
+			rate_limit,
+			rate_limit_period_seconds
+		)
+		SELECT id, $2, $3, $4, $5, $6, $7
+		FROM accounts
+		WHERE address = $1
+		ON CONFLICT (account_id) DO UPDATE SET
+			allowed_operation_types = EXCLUDED.allowed_operation_types,
+			max_payment_amount = EXCLUDED.max_payment_amount,
+			require_time_bounds = EXCLUDED.require_time_bounds,
+			max_time_bounds_seconds = EXCLUDED.max_time_bounds_seconds,
+			rate_limit = EXCLUDED.rate_limit,
+			rate_limit_period_seconds = EXCLUDED.rate_limit_period_seconds,
+			updated_at = NOW()
+	`,
+		address,
+		allowedOperationTypes,
+		p.MaxPaymentAmount,
+		p.RequireTimeBounds,
+		int64(p.MaxTimeBoundsDuration/time.Second),
+		p.RateLimit,
+		int64(p.RateLimitPeriod/time.Second),
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *DBStore) AddSignAttempt(a SignAttempt) error {
+	return addSignAttempt(s.DB, a)
+}
+
+func (s *DBStore) AddSignedAttempt(a SignAttempt, rateLimit int, since time.Time) (int, error) {
+	if rateLimit <= 0 {
+		return 0, addSignAttempt(s.DB, a)
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the account so that concurrent sign attempts for it are counted
+	// and inserted one at a time, otherwise they could all see a count under
+	// the rate limit and all be signed.
+	var accountID int64
+	err = tx.Get(&accountID, `
+		SELECT id
+		FROM accounts
+		WHERE address = $1
+		FOR UPDATE
+	`, a.Address)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := countSignedAttempts(tx, a.Address, a.AuthMethod, since)
+	if err != nil {
+		return 0, err
+	}
+	if count >= rateLimit {
+		return count, nil
+	}
+
+	err = addSignAttempt(tx, a)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func addSignAttempt(e sqlx.Execer, a SignAttempt) error {
+	var deniedReason *string
+	if a.DeniedReason != "" {
+		deniedReason = &a.DeniedReason
+	}
+	_, err := e.Exec(`
+		INSERT INTO sign_attempts (
+			account_address,
+			auth_method_type,
+			auth_method_value,
+			signing_address,
+			transaction_hash,
+			denied_reason
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, a.Address, a.AuthMethod.Type, a.AuthMethod.Value, a.SigningAddress, a.TransactionHash, deniedReason)
+	return err
+}
+
+func (s *DBStore) CountSignedAttempts(address string, m AuthMethod, since time.Time) (int, error) {
+	return countSignedAttempts(s.DB, address, m, since)
+}
+
+func countSignedAttempts(q sqlx.Queryer, address string, m AuthMethod, since time.Time) (int, error) {
+	count := int(0)
+	err := sqlx.Get(q, &count, `
+		SELECT COUNT(*)
+		FROM sign_attempts
+		WHERE account_address = $1
+			AND auth_method_type = $2
+			AND auth_method_value = $3
+			AND created_at >= $4
+			AND denied_reason IS NULL
+	`, address, m.Type, m.Value, since)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
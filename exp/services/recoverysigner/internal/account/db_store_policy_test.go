@@ -0,0 +1,191 @@
+package account
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+
+	store := DBStore{
+		DB: session,
+	}
+
+	address := "GCLLT3VG4F6EZAHZEBKWBWV5JGVPCVIKUCGTY3QEOAIZU5IJGMWCT2TT"
+
+	_, err := store.GetPolicy(address)
+	assert.Equal(t, ErrNotFound, err)
+	err = store.UpdatePolicy(address, Policy{RateLimit: 1})
+	assert.Equal(t, ErrNotFound, err)
+
+	err = store.Add(Account{Address: address})
+	require.NoError(t, err)
+
+	// Accounts have no policy until one is set.
+	p, err := store.GetPolicy(address)
+	require.NoError(t, err)
+	assert.Equal(t, Policy{}, p)
+
+	wantPolicy := Policy{
+		AllowedOperationTypes: []string{"SetOptions", "Payment"},
+		MaxPaymentAmount:      1000000000,
+		RequireTimeBounds:     true,
+		MaxTimeBoundsDuration: 5 * time.Minute,
+		RateLimit:             3,
+		RateLimitPeriod:       24 * time.Hour,
+	}
+	err = store.UpdatePolicy(address, wantPolicy)
+	require.NoError(t, err)
+	p, err = store.GetPolicy(address)
+	require.NoError(t, err)
+	assert.Equal(t, wantPolicy, p)
+
+	// Updating the policy replaces it.
+	err = store.UpdatePolicy(address, Policy{})
+	require.NoError(t, err)
+	p, err = store.GetPolicy(address)
+	require.NoError(t, err)
+	assert.Equal(t, Policy{}, p)
+}
+
+func TestSignAttempts(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+
+	store := DBStore{
+		DB: session,
+	}
+
+	address := "GCLLT3VG4F6EZAHZEBKWBWV5JGVPCVIKUCGTY3QEOAIZU5IJGMWCT2TT"
+	email := AuthMethod{Type: AuthMethodTypeEmail, Value: "user1@example.com"}
+	phoneNumber := AuthMethod{Type: AuthMethodTypePhoneNumber, Value: "+10000000000"}
+	since := time.Now().Add(-time.Minute)
+
+	for _, a := range []SignAttempt{
+		{Address: address, AuthMethod: email, SigningAddress: "GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", TransactionHash: "a"},
+		{Address: address, AuthMethod: email, SigningAddress: "GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", TransactionHash: "b"},
+		{Address: address, AuthMethod: email, SigningAddress: "GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", TransactionHash: "c", DeniedReason: "denied"},
+		{Address: address, AuthMethod: phoneNumber, SigningAddress: "GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", TransactionHash: "d"},
+	} {
+		err := store.AddSignAttempt(a)
+		require.NoError(t, err)
+	}
+
+	// Only the signed attempts authorized with the auth method are counted.
+	count, err := store.CountSignedAttempts(address, email, since)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = store.CountSignedAttempts(address, phoneNumber, since)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = store.CountSignedAttempts(address, email, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = store.CountSignedAttempts("GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N", email, since)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	var deniedReasons []string
+	err = session.Select(&deniedReasons, `SELECT denied_reason FROM sign_attempts WHERE denied_reason IS NOT NULL`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"denied"}, deniedReasons)
+}
+
+func TestAddSignedAttempt(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+
+	store := DBStore{
+		DB: session,
+	}
+
+	address := "GCLLT3VG4F6EZAHZEBKWBWV5JGVPCVIKUCGTY3QEOAIZU5IJGMWCT2TT"
+	email := AuthMethod{Type: AuthMethodTypeEmail, Value: "user1@example.com"}
+	since := time.Now().Add(-time.Minute)
+	attempt := SignAttempt{Address: address, AuthMethod: email, SigningAddress: "GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", TransactionHash: "a"}
+
+	_, err := store.AddSignedAttempt(attempt, 2, since)
+	assert.Equal(t, ErrNotFound, err)
+
+	err = store.Add(Account{Address: address})
+	require.NoError(t, err)
+
+	count, err := store.AddSignedAttempt(attempt, 2, since)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	count, err = store.AddSignedAttempt(attempt, 2, since)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Attempts at the rate limit are not recorded.
+	count, err = store.AddSignedAttempt(attempt, 2, since)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = store.CountSignedAttempts(address, email, since)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Attempts without a rate limit are always recorded.
+	count, err = store.AddSignedAttempt(attempt, 0, since)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	count, err = store.CountSignedAttempts(address, email, since)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestAddSignedAttemptConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+
+	store := DBStore{
+		DB: session,
+	}
+
+	address := "GCLLT3VG4F6EZAHZEBKWBWV5JGVPCVIKUCGTY3QEOAIZU5IJGMWCT2TT"
+	email := AuthMethod{Type: AuthMethodTypeEmail, Value: "user1@example.com"}
+	since := time.Now().Add(-time.Minute)
+
+	err := store.Add(Account{Address: address})
+	require.NoError(t, err)
+
+	const rateLimit = 3
+	const attempts = 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		recorded int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a := SignAttempt{Address: address, AuthMethod: email, SigningAddress: "GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", TransactionHash: fmt.Sprint(i)}
+			count, err := store.AddSignedAttempt(a, rateLimit, since)
+			assert.NoError(t, err)
+			if err == nil && count < rateLimit {
+				mu.Lock()
+				recorded++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Only as many concurrent attempts as the rate limit are recorded.
+	assert.Equal(t, rateLimit, recorded)
+	count, err := store.CountSignedAttempts(address, email, since)
+	require.NoError(t, err)
+	assert.Equal(t, rateLimit, count)
+}
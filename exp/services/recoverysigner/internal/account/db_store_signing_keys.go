@@ -0,0 +1,68 @@
+package account
+
+import (
+	"database/sql"
+	"time"
+)
+
+func (s *DBStore) AddSigningKey(address string) (SigningKey, error) {
+	var r struct {
+		ID        int64     `db:"id"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	err := s.DB.Get(&r, `
+		INSERT INTO account_signing_keys (account_id)
+		SELECT id FROM accounts
+		WHERE address = $1
+		RETURNING id, created_at
+	`, address)
+	if err == sql.ErrNoRows {
+		return SigningKey{}, ErrNotFound
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{ID: r.ID, AddedAt: r.CreatedAt}, nil
+}
+
+func (s *DBStore) DeleteSigningKey(address string, id int64) error {
+	result, err := s.DB.Exec(`
+		DELETE FROM account_signing_keys
+		USING accounts
+		WHERE account_signing_keys.account_id = accounts.id
+			AND accounts.address = $1
+			AND account_signing_keys.id = $2
+	`, address, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *DBStore) RetireLegacySigningKeys(address string) error {
+	result, err := s.DB.Exec(`
+		UPDATE accounts
+		SET legacy_signing_keys_retired = TRUE, updated_at = NOW()
+		WHERE address = $1
+	`, address)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeys(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+
+	store := DBStore{
+		DB: session,
+	}
+
+	address := "GCLLT3VG4F6EZAHZEBKWBWV5JGVPCVIKUCGTY3QEOAIZU5IJGMWCT2TT"
+
+	// Accounts not using the legacy signing keys get a first signing key.
+	err := store.Add(Account{
+		Address:                  address,
+		LegacySigningKeysRetired: true,
+	})
+	require.NoError(t, err)
+
+	a, err := store.Get(address)
+	require.NoError(t, err)
+	assert.True(t, a.LegacySigningKeysRetired)
+	require.Len(t, a.SigningKeys, 1)
+	firstKey := a.SigningKeys[0]
+	assert.False(t, firstKey.AddedAt.IsZero())
+
+	// Added signing keys are listed first.
+	secondKey, err := store.AddSigningKey(address)
+	require.NoError(t, err)
+	assert.Greater(t, secondKey.ID, firstKey.ID)
+
+	a, err = store.Get(address)
+	require.NoError(t, err)
+	assert.Equal(t, []SigningKey{secondKey, firstKey}, a.SigningKeys)
+
+	err = store.DeleteSigningKey(address, firstKey.ID)
+	require.NoError(t, err)
+	err = store.DeleteSigningKey(address, firstKey.ID)
+	assert.Equal(t, ErrNotFound, err)
+
+	a, err = store.Get(address)
+	require.NoError(t, err)
+	assert.Equal(t, []SigningKey{secondKey}, a.SigningKeys)
+
+	_, err = store.AddSigningKey("GDJ6ZE3SR6XBKF2ZDGNMWXF7TKZEEQZDSBVRLZXJ2HVOFIYMYQ7IAMMU")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestRetireLegacySigningKeys(t *testing.T) {
+	db := dbtest.Open(t)
+	session := db.Open()
+
+	store := DBStore{
+		DB: session,
+	}
+
+	address := "GCLLT3VG4F6EZAHZEBKWBWV5JGVPCVIKUCGTY3QEOAIZU5IJGMWCT2TT"
+
+	// Accounts using the legacy signing keys have no signing keys of their
+	// own until they add one.
+	err := store.Add(Account{Address: address})
+	require.NoError(t, err)
+
+	a, err := store.Get(address)
+	require.NoError(t, err)
+	assert.False(t, a.LegacySigningKeysRetired)
+	assert.Empty(t, a.SigningKeys)
+
+	_, err = store.AddSigningKey(address)
+	require.NoError(t, err)
+	err = store.RetireLegacySigningKeys(address)
+	require.NoError(t, err)
+
+	a, err = store.Get(address)
+	require.NoError(t, err)
+	assert.True(t, a.LegacySigningKeysRetired)
+	assert.Len(t, a.SigningKeys, 1)
+
+	err = store.RetireLegacySigningKeys("GDJ6ZE3SR6XBKF2ZDGNMWXF7TKZEEQZDSBVRLZXJ2HVOFIYMYQ7IAMMU")
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -1,8 +1,13 @@
 package account
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type Store interface {
+	// Add adds the account. If the account doesn't use the legacy signing
+	// keys it is given its first signing key.
 	Add(a Account) error
 	Delete(address string) error
 	Get(address string) (Account, error)
@@ -11,6 +16,26 @@ type Store interface {
 	FindWithIdentityPhoneNumber(phoneNumber string) ([]Account, error)
 	FindWithIdentityEmail(email string) ([]Account, error)
 	Count() (int, error)
+	// AddSigningKey adds a new signing key to the account, which becomes its
+	// preferred signing key.
+	AddSigningKey(address string) (SigningKey, error)
+	DeleteSigningKey(address string, id int64) error
+	RetireLegacySigningKeys(address string) error
+	// GetPolicy returns the policy of the account, the zero value if it has
+	// none.
+	GetPolicy(address string) (Policy, error)
+	UpdatePolicy(address string, p Policy) error
+	AddSignAttempt(a SignAttempt) error
+	// CountSignedAttempts counts the transactions signed for the account
+	// authorized with the auth method since the given time.
+	CountSignedAttempts(address string, m AuthMethod, since time.Time) (int, error)
+	// AddSignedAttempt atomically counts the transactions signed for the
+	// account authorized with the attempt's auth method since the given time
+	// and records the signed attempt only if the count is under the rate
+	// limit. It returns the count before the attempt, so the attempt was not
+	// recorded if the count is at or over the rate limit. A rate limit of
+	// zero records the attempt without counting.
+	AddSignedAttempt(a SignAttempt, rateLimit int, since time.Time) (int, error)
 }
 
 var ErrNotFound = errors.New("account not found")
@@ -8,6 +8,8 @@
 // migrations/20200320000000-create-accounts-audit.sql (1.23kB)
 // migrations/20200320000001-create-identities-audit.sql (1.166kB)
 // migrations/20200320000002-create-auth-methods-audit.sql (1.192kB)
+// migrations/20261019000000-create-account-signing-keys.sql (2.194kB)
+// migrations/20261019000001-create-account-policies.sql (2.581kB)
 
 package dbmigrate
 
@@ -236,6 +238,46 @@ func migrations20200320000002CreateAuthMethodsAuditSql() (*asset, error) {
 	return a, nil
 }
 
+var _migrations20261019000000CreateAccountSigningKeysSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x55\xd1\x72\xe2\x36\x14\x7d\xd7\x57\x9c\x87\x9d\x59\x68\xc3\x7e\xc0\x32\x7d\x10\xd6\x85\x68\x56\x91\x18\x59\x2e\xa1\x2f\x8c\x8b\xb5\x44\x53\x62\xa8\x6c\x9a\xe6\xef\x3b\xc6\xf6\xb2\x34\x49\x61\xa7\x9d\xce\xbe\xc9\xd2\xb9\xe7\x9e\x7b\xae\xae\x35\x1a\xe1\xc7\xc7\xb0\x89\x79\xed\x91\xed\x19\x1b\x8d\xc0\xd7\xeb\xdd\xa1\xac\x2b\x44\xbf\x09\x55\xed\xa3\x2f\xf0\xab\xff\xbc\x8b\x1e\x7b\x1f\x47\x79\x7b\x8c\x2a\x6c\xca\x50\x6e\xf0\x9b\x7f\xae\xf0\xe4\xa3\x47\x28\xeb\xb8\x2b\x0e\x6b\x5f\xe0\x50\x79\xd4\x0f\xbe\xa1\xdb\xfa\x4d\xbe\x7e\x3e\x87\x57\x0f\xf9\x91\xf5\x19\xf9\x76\x8b\x8e\xb1\xc2\xa1\xac\xc3\xb6\x89\x7b\x46\x1e\x3d\xa2\xaf\x43\xf4\xc5\x07\xc6\x95\x23\x0b\xc7\x27\x8a\xbe\x80\x19\xc0\x85\x40\x62\x54\x76\xa7\xbb\x24\xab\x2e\xc9\xaa\xd1\xb4\xea\xc2\x31\x31\x46\x11\xd7\xd0\xc6\x41\x67\x4a\x41\xd0\x94\x67\xca\x61\xca\x55\x4a\x63\xf6\x2a\xfd\x2a\x3f\x14\xa1\xfe\x0f\x93\x24\x96\xb8\xa3\xf3\x2c\x67\x54\x18\x30\x7c\x39\x08\x05\x26\x72\x26\xb5\x3b\x11\x5a\x9a\x92\x25\x9d\x50\xda\xa3\x2a\x0c\x42\x31\x84\xd1\x10\xa4\xc8\x11\x12\x9e\x26\x5c\xd0\x0d\x03\x46\x23\xb8\x07\x8f\x50\x20\x54\xc8\xb7\xd5\xae\xb1\x15\xa1\x2c\xfc\x9f\xc7\x55\x97\xb9\x69\x47\x83\x28\x7c\x0c\x7f\xf8\x02\x4f\xa1\x7e\xc0\xe7\xb8\x7b\x6c\x40\x2d\xcd\xd7\xc8\xca\x37\xed\x00\x5e\x91\x37\xb7\xf2\x8e\xdb\x25\x3e\xd1\x12\x33\xd2\x64\xb9\x23\x01\xae\x16\x7c\x99\x82\xa7\x90\x82\xb4\x93\x6e\x79\xc3\x18\xb0\x8e\x3e\xaf\x7d\xb1\xca\x6b\x38\x79\x47\xa9\xe3\x77\x73\x2c\xa4\xbb\x3d\x7e\xe2\x17\xa3\xe9\xa5\x93\xda\x2c\x06\x43\x36\x3c\x79\x29\xb5\xa0\xfb\xa6\xfc\xde\xb5\x73\x3b\xfb\xdd\x50\x0c\xaf\xf1\xbf\xed\x78\xdb\x85\x66\xb5\xfa\x57\x45\xf6\x24\xdf\x5e\xe1\x29\xf6\x50\xf9\x08\x47\xf7\xee\x25\x32\x4b\xc9\x9e\x80\xbb\xfd\x69\xd1\x43\x9b\x53\x25\x3f\xbd\x5e\xeb\xd1\xc5\xaf\x47\x3f\xad\xf3\xda\x3f\xfa\xb2\x9e\xf8\x4d\x28\x7b\xb3\xa6\x99\x4e\x9c\x34\x1a\xd1\xaf\x77\xb1\x58\xbd\x6d\xdb\x60\x08\x4b\x2e\xb3\x3a\x85\xb3\x72\x36\x23\xdb\xf4\xfc\xdd\xc4\x88\xe5\x3b\x06\x4c\x68\x26\x35\x03\x00\x39\xc5\xc0\xcd\x56\x66\x8e\x9f\xf0\x5e\xea\x94\xac\x7b\x3f\x84\xbb\xa5\xf6\x18\x68\xf7\x20\xb5\x33\xff\xd4\xa6\x9f\xb9\xca\x28\xc5\xa0\x33\xe4\x06\x2f\x17\xc7\x34\x1f\x3f\xf6\xce\xdc\x40\xd3\xe2\xc3\x0f\xc3\x71\x97\xa8\xd5\xdb\x6c\xb6\x3b\xa4\xd2\x33\x71\xd9\x5c\x70\x47\xdf\xa9\xb8\x76\xe0\xff\x37\x71\x46\x89\x97\xe2\x8c\x12\x9d\x38\x2d\x20\xa7\xcd\x9a\xb4\x18\xb3\xb6\xeb\x50\x5c\xcf\x32\x3e\x23\xec\xb7\xfb\x4d\xf5\xfb\x76\xfc\xfa\x85\xa3\xb2\x38\x0d\x67\x77\x75\x2e\x5e\x37\xc6\xa7\xcd\x8b\xd0\x55\x6c\x2c\xda\x66\xc1\xd8\xfe\x57\xf8\xc6\x5f\x81\x01\x53\x63\x41\x3c\xb9\x85\x35\x0b\xd0\x3d\x25\x99\x23\xcc\xad\x49\x48\x64\x96\xae\xb9\xea\x7f\x9b\x1d\xb1\x7b\x2a\x19\x13\xd6\xcc\xaf\x2f\xe0\x2d\x7d\xe3\x96\xe7\xfa\xc1\xeb\x02\x2e\xfd\xd6\x2e\xc2\x2e\x3d\x83\xc7\xf0\xcb\xef\xe0\x1b\x34\xdf\x42\xf0\x17\x00\x00\x00\xff\xff\x03\x00\x62\x2f\xc0\xac\x92\x08\x00\x00")
+
+func migrations20261019000000CreateAccountSigningKeysSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations20261019000000CreateAccountSigningKeysSql,
+		"migrations/20261019000000-create-account-signing-keys.sql",
+	)
+}
+
+func migrations20261019000000CreateAccountSigningKeysSql() (*asset, error) {
+	bytes, err := migrations20261019000000CreateAccountSigningKeysSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/20261019000000-create-account-signing-keys.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x22, 0x90, 0xe8, 0x14, 0x6e, 0xd1, 0x1, 0xe2, 0xfd, 0x5a, 0xce, 0x13, 0x83, 0xf2, 0xc1, 0xab, 0x5, 0x1e, 0x6e, 0xbd, 0x61, 0x12, 0x59, 0xac, 0x23, 0xce, 0x8f, 0x5d, 0xad, 0x44, 0x1e, 0xbd}}
+	return a, nil
+}
+
+var _migrations20261019000001CreateAccountPoliciesSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x56\xcd\x6e\xe3\x36\x10\xbe\xeb\x29\xe6\xb0\x40\xec\xd6\x09\x7a\x5e\xa3\x07\xda\x1a\x7b\x85\x55\x28\x83\xa2\x9a\xb8\x45\x21\x70\xad\x59\x9b\xa8\x2c\x29\x22\xb5\xe9\xa2\xe8\xbb\x17\xd4\x4f\xe2\x44\x49\xbc\xe8\x02\x45\x6f\xd4\xf0\x9b\xf9\x86\xf3\xab\xcb\x4b\xf8\xf1\xa8\xf7\xb5\xb2\x04\x49\xe5\x79\x4b\x81\x4c\x22\x48\xb6\x08\x11\xd4\x6e\x57\x36\x85\x4d\xab\x32\xd7\x3b\x4d\x06\x26\x1e\x3c\x08\x75\x06\x8b\x60\x1d\x70\x09\x3c\x92\xc0\x93\x30\x84\x8d\x08\xae\x99\xd8\xc2\x47\xdc\x82\xc0\x15\x0a\xe4\x4b\x8c\x07\x0d\x03\x13\x9d\x4d\x21\xe2\xe0\x63\x88\x12\x61\xc9\xe2\x25\xf3\x71\xe6\x79\x00\xbb\x9a\x94\xa5\x2c\x55\x16\x64\x70\x8d\xb1\x64\xd7\x1b\xb8\x09\xe4\x87\xf6\x13\x7e\x8d\x38\x3e\xf2\xf8\xb8\x62\x49\xe8\x88\x6f\x26\xd3\x99\x07\xd0\x54\xd9\x39\xed\x96\x45\xe5\x79\x79\x4f\x59\x5a\x56\x54\x2b\xab\xcb\x22\xb5\x5f\x2b\x32\x20\xf1\x56\xfe\xf6\xfb\x98\xe0\xe2\xaf\xbf\x2f\x9c\xfd\xa3\xfa\x33\xad\xd4\xd7\x23\x15\x36\x55\x47\xf7\x98\xd1\xdb\x07\x95\x9f\x1c\xbe\xa6\xbb\x46\xd7\x94\x5a\x7d\xa4\xf4\x53\xd9\x14\x99\x81\x45\x14\x85\xc8\xf8\x58\x63\xc5\xc2\x18\x07\x96\x13\x8d\xd4\xd0\xae\x6c\x35\xdf\xa4\x52\x96\xd2\x5c\x1f\xb5\x85\x80\x4b\x5c\xa3\x38\x0b\x4c\x2b\xaa\x75\x99\x9d\xb7\xef\x4d\xe7\x67\x2a\x22\x55\x4d\xa6\x6d\x57\x17\xee\x74\xae\x2a\xd6\xc8\x51\x30\x89\x3e\xb0\xf0\x86\x6d\x63\x60\x31\x04\x3e\x72\x19\xc8\xed\xec\xc1\xc8\xbf\x2b\x82\x4e\xb7\x31\x54\xb7\xf9\x1c\x23\x93\x18\xc5\x23\xb0\xac\x1e\x0f\x03\xd4\xdd\x86\xc1\xc7\xf1\x3b\xdb\x48\x9c\xf6\x4a\x6c\x95\x25\x57\x0f\x0b\xda\xeb\x62\x08\xd2\x2a\xe1\x4b\x19\x44\x1c\x6a\xda\x95\x75\x96\xbe\x1c\xae\xc9\x14\x04\xca\x44\xf0\x18\xa4\x08\xd6\x2e\x65\x2c\x86\x77\x8b\xc8\xdf\xbe\xf3\x00\x16\xb8\x0e\xb8\x07\x00\x10\xac\x60\x22\xd7\x69\xb4\x81\x9f\xe1\x22\xe0\x31\x0a\x79\x31\x05\xf9\x01\xbb\x6b\x80\x4e\xe6\x12\x1f\xbd\x96\x9a\x5f\x58\x98\x60\x0c\x93\x3e\x08\x33\x18\x1f\x5a\x8a\xf7\xef\x87\x68\xcc\x80\xe3\xcd\xd5\x0f\xd3\x79\x4f\xd2\xf9\xea\x84\x9d\x04\xc3\xf8\x89\x63\xc9\xc6\x67\x12\xff\x87\x8e\x75\x63\xe6\x3f\x71\x2c\x0a\xfd\xb1\x63\x51\xe8\xf7\x8e\x71\x1f\x82\x95\x3b\x23\xf7\xe7\x5e\x97\x69\x08\x19\x5f\x27\x6c\x8d\x50\xe5\xd5\xde\xdc\xe5\xf3\x97\x0b\x0c\x8b\xec\xb1\x09\xfb\x72\x79\xb3\xbc\x3c\xb6\x92\x28\x86\x97\x46\x02\xba\x04\x41\x24\x86\xc1\x1b\xf1\xd1\xdb\x3d\x80\x55\x24\x00\xd9\xf2\x03\x88\xe8\x06\xf0\x16\x97\x89\x44\xd8\x88\x68\x89\x7e\x22\xf0\x5c\x49\x77\xfd\x21\xe8\xae\x21\x63\x0d\xd8\x12\x8c\xde\x17\x60\x6b\x55\x18\xb5\x73\xb3\xd6\xcc\xe0\x53\x69\x0f\xad\x9c\x32\x50\x45\x06\x19\x15\x9a\xb2\x2b\x90\x07\x1a\xc4\x65\x41\x06\x54\x4d\xce\x5a\xcb\x44\x99\x33\xd6\x2e\xa8\x6e\xcc\x39\xa0\x2e\xf6\x9d\x56\xef\x0e\xa8\x2c\xab\xc9\x18\xd0\x06\xfe\xa0\xca\xba\x69\x77\xa0\x1a\xec\x41\x15\xa0\x9c\xad\x9a\x3e\x53\x4d\xc5\x8e\x9c\x35\x7b\xa2\x69\xdc\xa7\xb2\x9d\xcc\x5a\x3a\x56\xd6\x40\xd9\xd8\x5c\x7f\xa1\x53\xe0\xd5\x43\x12\xda\x49\xe8\xbc\x48\x1f\xf0\x6e\x00\x7e\xe7\xe8\xfb\x9e\x0d\x78\xb2\x96\x87\x40\x3c\x19\x81\x6e\xae\xa9\xc6\x1e\xd2\x23\xd9\x43\x99\xb5\x5b\x6f\x2c\x78\x0d\xfd\x45\xe5\x0d\x8d\x0d\xf6\x79\x78\x9d\xf1\x24\xf7\xe9\x41\x99\xc3\x18\x71\x79\xd9\x26\xb1\x26\x65\xca\xa2\x0d\xf6\x89\x0e\xdc\x2b\xd3\x57\xc8\x0c\x8a\x26\xcf\x41\x7f\x06\x6d\x5b\xb1\xe3\xa6\xec\xca\x83\x1e\x90\xf6\x26\x1c\xc3\xe9\xd6\x0a\xb8\x8f\xb7\xee\x97\xe3\x59\xba\x9e\x45\x6b\x36\x0a\xc6\x53\x49\x1b\x81\xd9\xc9\x4f\xca\xf3\x75\xe0\x97\xf7\x85\xe7\xf9\x22\xda\xbc\x54\x1e\xf3\xe1\xea\x5b\xda\xf7\xa5\xee\x9c\x77\xfa\xdf\xb6\x5e\x7a\xf0\x5b\x0b\xfb\x4d\xc8\xdc\xfb\x07\x00\x00\xff\xff\x03\x00\x7b\xd0\x13\x82\x15\x0a\x00\x00")
+
+func migrations20261019000001CreateAccountPoliciesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations20261019000001CreateAccountPoliciesSql,
+		"migrations/20261019000001-create-account-policies.sql",
+	)
+}
+
+func migrations20261019000001CreateAccountPoliciesSql() (*asset, error) {
+	bytes, err := migrations20261019000001CreateAccountPoliciesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/20261019000001-create-account-policies.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x73, 0x39, 0xb, 0x72, 0x50, 0xeb, 0x1b, 0xa2, 0x11, 0x40, 0xdb, 0xb9, 0xbb, 0x32, 0xb0, 0x5d, 0x1e, 0xda, 0x11, 0xde, 0x8f, 0xe6, 0x7b, 0x5a, 0x53, 0x8e, 0x3d, 0x60, 0x54, 0x6b, 0xaf, 0x65}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -327,14 +369,16 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"migrations/20200309000000-initial-1.sql":                 migrations20200309000000Initial1Sql,
-	"migrations/20200309000001-initial-2.sql":                 migrations20200309000001Initial2Sql,
-	"migrations/20200311000000-create-accounts.sql":           migrations20200311000000CreateAccountsSql,
-	"migrations/20200311000001-create-identities.sql":         migrations20200311000001CreateIdentitiesSql,
-	"migrations/20200311000002-create-auth-methods.sql":       migrations20200311000002CreateAuthMethodsSql,
-	"migrations/20200320000000-create-accounts-audit.sql":     migrations20200320000000CreateAccountsAuditSql,
-	"migrations/20200320000001-create-identities-audit.sql":   migrations20200320000001CreateIdentitiesAuditSql,
-	"migrations/20200320000002-create-auth-methods-audit.sql": migrations20200320000002CreateAuthMethodsAuditSql,
+	"migrations/20200309000000-initial-1.sql":                   migrations20200309000000Initial1Sql,
+	"migrations/20200309000001-initial-2.sql":                   migrations20200309000001Initial2Sql,
+	"migrations/20200311000000-create-accounts.sql":             migrations20200311000000CreateAccountsSql,
+	"migrations/20200311000001-create-identities.sql":           migrations20200311000001CreateIdentitiesSql,
+	"migrations/20200311000002-create-auth-methods.sql":         migrations20200311000002CreateAuthMethodsSql,
+	"migrations/20200320000000-create-accounts-audit.sql":       migrations20200320000000CreateAccountsAuditSql,
+	"migrations/20200320000001-create-identities-audit.sql":     migrations20200320000001CreateIdentitiesAuditSql,
+	"migrations/20200320000002-create-auth-methods-audit.sql":   migrations20200320000002CreateAuthMethodsAuditSql,
+	"migrations/20261019000000-create-account-signing-keys.sql": migrations20261019000000CreateAccountSigningKeysSql,
+	"migrations/20261019000001-create-account-policies.sql":     migrations20261019000001CreateAccountPoliciesSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -381,14 +425,16 @@ type bintree struct {
 
 var _bintree = &bintree{nil, map[string]*bintree{
 	"migrations": {nil, map[string]*bintree{
-		"20200309000000-initial-1.sql":                 {migrations20200309000000Initial1Sql, map[string]*bintree{}},
-		"20200309000001-initial-2.sql":                 {migrations20200309000001Initial2Sql, map[string]*bintree{}},
-		"20200311000000-create-accounts.sql":           {migrations20200311000000CreateAccountsSql, map[string]*bintree{}},
-		"20200311000001-create-identities.sql":         {migrations20200311000001CreateIdentitiesSql, map[string]*bintree{}},
-		"20200311000002-create-auth-methods.sql":       {migrations20200311000002CreateAuthMethodsSql, map[string]*bintree{}},
-		"20200320000000-create-accounts-audit.sql":     {migrations20200320000000CreateAccountsAuditSql, map[string]*bintree{}},
-		"20200320000001-create-identities-audit.sql":   {migrations20200320000001CreateIdentitiesAuditSql, map[string]*bintree{}},
-		"20200320000002-create-auth-methods-audit.sql": {migrations20200320000002CreateAuthMethodsAuditSql, map[string]*bintree{}},
+		"20200309000000-initial-1.sql":                   {migrations20200309000000Initial1Sql, map[string]*bintree{}},
+		"20200309000001-initial-2.sql":                   {migrations20200309000001Initial2Sql, map[string]*bintree{}},
+		"20200311000000-create-accounts.sql":             {migrations20200311000000CreateAccountsSql, map[string]*bintree{}},
+		"20200311000001-create-identities.sql":           {migrations20200311000001CreateIdentitiesSql, map[string]*bintree{}},
+		"20200311000002-create-auth-methods.sql":         {migrations20200311000002CreateAuthMethodsSql, map[string]*bintree{}},
+		"20200320000000-create-accounts-audit.sql":       {migrations20200320000000CreateAccountsAuditSql, map[string]*bintree{}},
+		"20200320000001-create-identities-audit.sql":     {migrations20200320000001CreateIdentitiesAuditSql, map[string]*bintree{}},
+		"20200320000002-create-auth-methods-audit.sql":   {migrations20200320000002CreateAuthMethodsAuditSql, map[string]*bintree{}},
+		"20261019000000-create-account-signing-keys.sql": {migrations20261019000000CreateAccountSigningKeysSql, map[string]*bintree{}},
+		"20261019000001-create-account-policies.sql":     {migrations20261019000001CreateAccountPoliciesSql, map[string]*bintree{}},
 	}},
 }}
 
@@ -0,0 +1,62 @@
+-- +migrate Up
+
+-- Accounts registered before per-account signing keys were introduced use the
+-- legacy signing keys shared by all accounts until they are retired.
+ALTER TABLE accounts
+  ADD COLUMN legacy_signing_keys_retired BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE accounts_audit
+  ADD COLUMN legacy_signing_keys_retired BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE account_signing_keys (
+  account_id BIGINT NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+  -- The id is also the index the signing key is derived with from the
+  -- signing key seed.
+  id BIGINT NOT NULL PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ON account_signing_keys (account_id);
+
+CREATE TABLE account_signing_keys_audit (
+  audit_id BIGINT NOT NULL PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  audit_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  audit_user TEXT NOT NULL DEFAULT USER,
+  audit_op audit_op NOT NULL,
+  LIKE account_signing_keys
+);
+
+-- +migrate StatementBegin
+CREATE FUNCTION record_account_signing_keys_audit() RETURNS TRIGGER AS $BODY$
+  BEGIN
+    IF (TG_OP = 'INSERT') THEN
+      INSERT INTO account_signing_keys_audit VALUES (DEFAULT, DEFAULT, DEFAULT, TG_OP::audit_op, NEW.*);
+      RETURN NEW;
+    ELSIF (TG_OP = 'UPDATE') THEN
+      INSERT INTO account_signing_keys_audit VALUES (DEFAULT, DEFAULT, DEFAULT, TG_OP::audit_op, NEW.*);
+      RETURN NEW;
+    ELSIF (TG_OP = 'DELETE') THEN
+      INSERT INTO account_signing_keys_audit VALUES (DEFAULT, DEFAULT, DEFAULT, TG_OP::audit_op, OLD.*);
+      RETURN OLD;
+    END IF;
+  END;
+$BODY$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER record_account_signing_keys_audit
+AFTER INSERT OR UPDATE OR DELETE ON account_signing_keys
+  FOR EACH ROW EXECUTE PROCEDURE record_account_signing_keys_audit();
+
+-- +migrate Down
+
+DROP TRIGGER record_account_signing_keys_audit ON account_signing_keys;
+DROP FUNCTION record_account_signing_keys_audit;
+DROP TABLE account_signing_keys_audit;
+DROP TABLE account_signing_keys;
+
+ALTER TABLE accounts_audit
+  DROP COLUMN legacy_signing_keys_retired;
+
+ALTER TABLE accounts
+  DROP COLUMN legacy_signing_keys_retired;
@@ -0,0 +1,71 @@
+-- +migrate Up
+
+CREATE TABLE account_policies (
+  account_id BIGINT NOT NULL PRIMARY KEY REFERENCES accounts (id) ON DELETE CASCADE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE,
+
+  allowed_operation_types TEXT[] NOT NULL DEFAULT '{}',
+  max_payment_amount BIGINT NOT NULL DEFAULT 0,
+  require_time_bounds BOOLEAN NOT NULL DEFAULT FALSE,
+  max_time_bounds_seconds BIGINT NOT NULL DEFAULT 0,
+  rate_limit INTEGER NOT NULL DEFAULT 0,
+  rate_limit_period_seconds BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE account_policies_audit (
+  audit_id BIGINT NOT NULL PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  audit_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  audit_user TEXT NOT NULL DEFAULT USER,
+  audit_op audit_op NOT NULL,
+  LIKE account_policies
+);
+
+-- +migrate StatementBegin
+CREATE FUNCTION record_account_policies_audit() RETURNS TRIGGER AS $BODY$
+  BEGIN
+    IF (TG_OP = 'INSERT') THEN
+      INSERT INTO account_policies_audit VALUES (DEFAULT, DEFAULT, DEFAULT, TG_OP::audit_op, NEW.*);
+      RETURN NEW;
+    ELSIF (TG_OP = 'UPDATE') THEN
+      INSERT INTO account_policies_audit VALUES (DEFAULT, DEFAULT, DEFAULT, TG_OP::audit_op, NEW.*);
+      RETURN NEW;
+    ELSIF (TG_OP = 'DELETE') THEN
+      INSERT INTO account_policies_audit VALUES (DEFAULT, DEFAULT, DEFAULT, TG_OP::audit_op, OLD.*);
+      RETURN OLD;
+    END IF;
+  END;
+$BODY$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER record_account_policies_audit
+AFTER INSERT OR UPDATE OR DELETE ON account_policies
+  FOR EACH ROW EXECUTE PROCEDURE record_account_policies_audit();
+
+-- Requests to sign transactions, both signed and denied. The signed ones are
+-- counted to rate limit signing. The account address is kept rather than a
+-- reference to the account so that the attempts outlive the account.
+CREATE TABLE sign_attempts (
+  id BIGINT NOT NULL PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  account_address TEXT NOT NULL,
+  auth_method_type auth_method_type NOT NULL,
+  auth_method_value TEXT NOT NULL,
+  signing_address TEXT NOT NULL,
+  transaction_hash TEXT NOT NULL,
+  -- The reason the transaction was denied, null if it was signed.
+  denied_reason TEXT
+);
+
+CREATE INDEX ON sign_attempts (account_address, auth_method_type, auth_method_value, created_at);
+
+-- +migrate Down
+
+DROP TABLE sign_attempts;
+
+DROP TRIGGER record_account_policies_audit ON account_policies;
+DROP FUNCTION record_account_policies_audit;
+DROP TABLE account_policies_audit;
+DROP TABLE account_policies;
@@ -0,0 +1,153 @@
+// Package policy checks the transactions a recovery signer is asked to sign
+// against the signing policies of the accounts.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metriqorg/go/amount"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/metriqorg/go/xdr"
+)
+
+// DeniedError is returned when a transaction is denied by a policy.
+type DeniedError struct {
+	Reason string
+}
+
+func (e DeniedError) Error() string {
+	return "transaction denied by policy: " + e.Reason
+}
+
+func denied(format string, args ...interface{}) error {
+	return DeniedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// operationTypes are the names of the operation types that can be allowed by
+// a policy, which are the names of the XDR operation types without their
+// OperationType prefix, e.g. SetOptions.
+var operationTypes = func() map[string]bool {
+	types := map[string]bool{}
+	var t xdr.OperationType
+	for i := int32(0); i < 256; i++ {
+		if t.ValidEnum(i) {
+			types[operationType(xdr.OperationType(i))] = true
+		}
+	}
+	return types
+}()
+
+func operationType(t xdr.OperationType) string {
+	return strings.TrimPrefix(t.String(), "OperationType")
+}
+
+// Validate checks that the policy is valid.
+func Validate(p account.Policy) error {
+	for _, t := range p.AllowedOperationTypes {
+		if !operationTypes[t] {
+			return errors.Errorf("operation type %q unrecognized", t)
+		}
+	}
+	if p.MaxPaymentAmount < 0 {
+		return errors.New("max payment amount is negative")
+	}
+	if p.MaxTimeBoundsDuration < 0 {
+		return errors.New("max time bounds duration is negative")
+	}
+	if p.MaxTimeBoundsDuration > 0 && !p.RequireTimeBounds {
+		return errors.New("max time bounds duration is set but time bounds are not required")
+	}
+	if p.RateLimit < 0 {
+		return errors.New("rate limit is negative")
+	}
+	if p.RateLimit > 0 && p.RateLimitPeriod <= 0 {
+		return errors.New("rate limit is set but rate limit period is not")
+	}
+	return nil
+}
+
+// Check checks that the transaction is allowed by the policy at the given
+// time. The rate limit is checked separately with CheckRateLimit. It returns a
+// DeniedError if the transaction is denied.
+func Check(p account.Policy, tx *txnbuild.Transaction, now time.Time) error {
+	if len(p.AllowedOperationTypes) > 0 {
+		allowed := map[string]bool{}
+		for _, t := range p.AllowedOperationTypes {
+			allowed[t] = true
+		}
+		for _, op := range tx.ToXDR().Operations() {
+			t := operationType(op.Body.Type)
+			if !allowed[t] {
+				return denied("operation type %s not allowed", t)
+			}
+		}
+	}
+
+	if p.MaxPaymentAmount > 0 {
+		for _, op := range tx.Operations() {
+			err := checkPaymentAmount(op, p.MaxPaymentAmount)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.RequireTimeBounds {
+		maxTime := tx.Timebounds().MaxTime
+		if maxTime == txnbuild.TimeoutInfinite {
+			return denied("transaction has no upper time bound")
+		}
+		if p.MaxTimeBoundsDuration > 0 && time.Unix(maxTime, 0).After(now.Add(p.MaxTimeBoundsDuration)) {
+			return denied("transaction upper time bound more than %s in the future", p.MaxTimeBoundsDuration)
+		}
+	}
+
+	return nil
+}
+
+// checkPaymentAmount checks that the operation doesn't send more than the max
+// payment amount, whatever the asset sent. Account merges are denied since
+// they send the whole balance of the account.
+func checkPaymentAmount(op txnbuild.Operation, maxPaymentAmount int64) error {
+	var a string
+	switch op := op.(type) {
+	case *txnbuild.Payment:
+		a = op.Amount
+	case *txnbuild.PathPaymentStrictReceive:
+		a = op.SendMax
+	case *txnbuild.PathPaymentStrictSend:
+		a = op.SendAmount
+	case *txnbuild.CreateAccount:
+		a = op.Amount
+	case *txnbuild.CreateClaimableBalance:
+		a = op.Amount
+	case *txnbuild.AccountMerge:
+		return denied("account merge not allowed with a max payment amount")
+	default:
+		return nil
+	}
+
+	stroops, err := amount.ParseInt64(a)
+	if err != nil {
+		return denied("payment amount %q invalid", a)
+	}
+	if stroops > maxPaymentAmount {
+		return denied("payment amount %s exceeds the max payment amount %s", a, amount.StringFromInt64(maxPaymentAmount))
+	}
+	return nil
+}
+
+// CheckRateLimit checks that signing another transaction for an identity
+// which already had signedCount transactions signed within the rate limit
+// period doesn't exceed the rate limit of the policy. It returns a
+// DeniedError if it does.
+func CheckRateLimit(p account.Policy, signedCount int) error {
+	if p.RateLimit > 0 && signedCount >= p.RateLimit {
+		return denied("rate limit of %d transactions per %s exceeded", p.RateLimit, p.RateLimitPeriod)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTransaction(t *testing.T, maxTime int64, ops ...txnbuild.Operation) *txnbuild.Transaction {
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &txnbuild.SimpleAccount{AccountID: "GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4"},
+			IncrementSequenceNum: true,
+			Operations:           ops,
+			BaseFee:              txnbuild.MinBaseFee,
+			Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimebounds(0, maxTime)},
+		},
+	)
+	require.NoError(t, err)
+	return tx
+}
+
+var (
+	setOptions = &txnbuild.SetOptions{
+		Signer: &txnbuild.Signer{
+			Address: "GD7CGJSJ5OBOU5KOP2UQDH3MPY75UTEY27HVV5XPSL2X6DJ2VGTOSXEU",
+			Weight:  20,
+		},
+	}
+	payment = &txnbuild.Payment{
+		Destination: "GD7CGJSJ5OBOU5KOP2UQDH3MPY75UTEY27HVV5XPSL2X6DJ2VGTOSXEU",
+		Amount:      "10",
+		Asset:       txnbuild.NativeAsset{},
+	}
+	accountMerge = &txnbuild.AccountMerge{
+		Destination: "GD7CGJSJ5OBOU5KOP2UQDH3MPY75UTEY27HVV5XPSL2X6DJ2VGTOSXEU",
+	}
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		Policy  account.Policy
+		WantErr string
+	}{
+		{"zero", account.Policy{}, ""},
+		{"valid", account.Policy{
+			AllowedOperationTypes: []string{"SetOptions", "PathPaymentStrictReceive"},
+			MaxPaymentAmount:      100,
+			RequireTimeBounds:     true,
+			MaxTimeBoundsDuration: time.Minute,
+			RateLimit:             1,
+			RateLimitPeriod:       time.Hour,
+		}, ""},
+		{"unknownOperationType", account.Policy{AllowedOperationTypes: []string{"OperationTypeSetOptions"}}, `operation type "OperationTypeSetOptions" unrecognized`},
+		{"negativeMaxPaymentAmount", account.Policy{MaxPaymentAmount: -1}, "max payment amount is negative"},
+		{"maxTimeBoundsWithoutTimeBounds", account.Policy{MaxTimeBoundsDuration: time.Minute}, "max time bounds duration is set but time bounds are not required"},
+		{"rateLimitWithoutPeriod", account.Policy{RateLimit: 1}, "rate limit is set but rate limit period is not"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := Validate(tc.Policy)
+			if tc.WantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.WantErr)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	testCases := []struct {
+		Name       string
+		Policy     account.Policy
+		Tx         *txnbuild.Transaction
+		WantReason string
+	}{
+		{"zeroAllowsAll", account.Policy{}, newTestTransaction(t, 0, setOptions, payment, accountMerge), ""},
+		{"operationTypeAllowed", account.Policy{AllowedOperationTypes: []string{"SetOptions"}}, newTestTransaction(t, 0, setOptions), ""},
+		{"operationTypeNotAllowed", account.Policy{AllowedOperationTypes: []string{"SetOptions"}}, newTestTransaction(t, 0, setOptions, payment), "operation type Payment not allowed"},
+		{"paymentAmountAllowed", account.Policy{MaxPaymentAmount: 10000000}, newTestTransaction(t, 0, payment), ""},
+		{"paymentAmountExceeded", account.Policy{MaxPaymentAmount: 1000000}, newTestTransaction(t, 0, setOptions, payment), "payment amount 10 exceeds the max payment amount 1.000000"},
+		{"accountMergeWithMaxPaymentAmount", account.Policy{MaxPaymentAmount: 10000000}, newTestTransaction(t, 0, accountMerge), "account merge not allowed with a max payment amount"},
+		{"timeBoundsRequired", account.Policy{RequireTimeBounds: true}, newTestTransaction(t, 0, setOptions), "transaction has no upper time bound"},
+		{"timeBoundsSet", account.Policy{RequireTimeBounds: true}, newTestTransaction(t, 5000, setOptions), ""},
+		{"timeBoundsWithinMaxDuration", account.Policy{RequireTimeBounds: true, MaxTimeBoundsDuration: time.Minute}, newTestTransaction(t, 1060, setOptions), ""},
+		{"timeBoundsExceedMaxDuration", account.Policy{RequireTimeBounds: true, MaxTimeBoundsDuration: time.Minute}, newTestTransaction(t, 1061, setOptions), "transaction upper time bound more than 1m0s in the future"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := Check(tc.Policy, tc.Tx, now)
+			if tc.WantReason == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, DeniedError{Reason: tc.WantReason}, err)
+			}
+		})
+	}
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	p := account.Policy{RateLimit: 2, RateLimitPeriod: time.Hour}
+	assert.NoError(t, CheckRateLimit(p, 0))
+	assert.NoError(t, CheckRateLimit(p, 1))
+	assert.Equal(t, DeniedError{Reason: "rate limit of 2 transactions per 1h0m0s exceeded"}, CheckRateLimit(p, 2))
+
+	assert.NoError(t, CheckRateLimit(account.Policy{}, 100))
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
 	"github.com/metriqorg/go/keypair"
 	"github.com/metriqorg/go/support/http/httpdecode"
 	supportlog "github.com/metriqorg/go/support/log"
@@ -12,9 +13,10 @@ import (
 )
 
 type accountDeleteHandler struct {
-	Logger           *supportlog.Entry
-	SigningAddresses []*keypair.FromAddress
-	AccountStore     account.Store
+	Logger            *supportlog.Entry
+	SigningAddresses  []*keypair.FromAddress
+	SigningKeyDeriver *signingkey.Deriver
+	AccountStore      account.Store
 }
 
 type accountDeleteRequest struct {
@@ -56,10 +58,11 @@ func (h accountDeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	resp := accountResponse{
 		Address: acc.Address,
 	}
-	for _, signingAddress := range h.SigningAddresses {
-		resp.Signers = append(resp.Signers, accountResponseSigner{
-			Key: signingAddress.Address(),
-		})
+	resp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
 	}
 
 	// Authorized if authenticated as the account.
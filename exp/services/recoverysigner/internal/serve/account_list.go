@@ -5,15 +5,17 @@ import (
 
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
 	"github.com/metriqorg/go/keypair"
 	supportlog "github.com/metriqorg/go/support/log"
 	"github.com/metriqorg/go/support/render/httpjson"
 )
 
 type accountListHandler struct {
-	Logger           *supportlog.Entry
-	SigningAddresses []*keypair.FromAddress
-	AccountStore     account.Store
+	Logger            *supportlog.Entry
+	SigningAddresses  []*keypair.FromAddress
+	SigningKeyDeriver *signingkey.Deriver
+	AccountStore      account.Store
 }
 
 type accountListResponse struct {
@@ -51,10 +53,11 @@ func (h accountListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			accResp := accountResponse{
 				Address: acc.Address,
 			}
-			for _, signingAddress := range h.SigningAddresses {
-				accResp.Signers = append(accResp.Signers, accountResponseSigner{
-					Key: signingAddress.Address(),
-				})
+			accResp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+			if err != nil {
+				l.Error(err)
+				serverError.Render(w)
+				return
 			}
 			for _, i := range acc.Identities {
 				accRespIdentity := accountResponseIdentity{
@@ -79,10 +82,11 @@ func (h accountListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			accResp := accountResponse{
 				Address: acc.Address,
 			}
-			for _, signingAddress := range h.SigningAddresses {
-				accResp.Signers = append(accResp.Signers, accountResponseSigner{
-					Key: signingAddress.Address(),
-				})
+			accResp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+			if err != nil {
+				l.Error(err)
+				serverError.Render(w)
+				return
 			}
 			for _, i := range acc.Identities {
 				accRespIdentity := accountResponseIdentity{
@@ -115,10 +119,11 @@ func (h accountListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			accResp := accountResponse{
 				Address: acc.Address,
 			}
-			for _, signingAddress := range h.SigningAddresses {
-				accResp.Signers = append(accResp.Signers, accountResponseSigner{
-					Key: signingAddress.Address(),
-				})
+			accResp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+			if err != nil {
+				l.Error(err)
+				serverError.Render(w)
+				return
 			}
 			for _, i := range acc.Identities {
 				accRespIdentity := accountResponseIdentity{
@@ -151,10 +156,11 @@ func (h accountListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			accResp := accountResponse{
 				Address: acc.Address,
 			}
-			for _, signingAddress := range h.SigningAddresses {
-				accResp.Signers = append(accResp.Signers, accountResponseSigner{
-					Key: signingAddress.Address(),
-				})
+			accResp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+			if err != nil {
+				l.Error(err)
+				serverError.Render(w)
+				return
 			}
 			for _, i := range acc.Identities {
 				accRespIdentity := accountResponseIdentity{
@@ -0,0 +1,67 @@
+package serve
+
+import (
+	"net/http"
+
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/http/httpdecode"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+// accountPolicyGetHandler returns the policy that limits the transactions
+// signed for an account.
+type accountPolicyGetHandler struct {
+	Logger       *supportlog.Entry
+	AccountStore account.Store
+}
+
+type accountPolicyGetRequest struct {
+	Address *keypair.FromAddress `path:"address"`
+}
+
+func (h accountPolicyGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, _ := auth.FromContext(ctx)
+	if claims.Address == "" {
+		unauthorized.Render(w)
+		return
+	}
+
+	req := accountPolicyGetRequest{}
+	err := httpdecode.Decode(r, &req)
+	if err != nil || req.Address == nil {
+		badRequest.Render(w)
+		return
+	}
+
+	l := h.Logger.Ctx(ctx).
+		WithField("account", req.Address.Address())
+
+	l.Info("Request to get account policy.")
+
+	if req.Address.Address() != claims.Address {
+		l.WithField("address", claims.Address).
+			Info("Not authorized as self, authorized as other address.")
+		unauthorized.Render(w)
+		return
+	}
+
+	p, err := h.AccountStore.GetPolicy(req.Address.Address())
+	if err == account.ErrNotFound {
+		l.Info("Account not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	l.Info("Account policy found.")
+
+	httpjson.Render(w, newAccountPolicyResponse(req.Address.Address(), p), httpjson.JSON)
+}
@@ -0,0 +1,105 @@
+package serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/metriqorg/go/amount"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/policy"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/http/httpdecode"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+// accountPolicyPutHandler replaces the policy that limits the transactions
+// signed for an account. Only the account itself can change its policy, not
+// the identities that can have transactions signed for it.
+type accountPolicyPutHandler struct {
+	Logger       *supportlog.Entry
+	AccountStore account.Store
+}
+
+type accountPolicyPutRequest struct {
+	Address                *keypair.FromAddress `path:"address"`
+	AllowedOperationTypes  []string             `json:"allowed_operation_types" form:"allowed_operation_types"`
+	MaxPaymentAmount       string               `json:"max_payment_amount" form:"max_payment_amount"`
+	RequireTimeBounds      bool                 `json:"require_time_bounds" form:"require_time_bounds"`
+	MaxTimeBoundsSeconds   int64                `json:"max_time_bounds_seconds" form:"max_time_bounds_seconds"`
+	RateLimit              int                  `json:"rate_limit" form:"rate_limit"`
+	RateLimitPeriodSeconds int64                `json:"rate_limit_period_seconds" form:"rate_limit_period_seconds"`
+}
+
+// Policy returns the policy in the request, validated.
+func (r accountPolicyPutRequest) Policy() (account.Policy, error) {
+	p := account.Policy{
+		AllowedOperationTypes: r.AllowedOperationTypes,
+		RequireTimeBounds:     r.RequireTimeBounds,
+		MaxTimeBoundsDuration: time.Duration(r.MaxTimeBoundsSeconds) * time.Second,
+		RateLimit:             r.RateLimit,
+		RateLimitPeriod:       time.Duration(r.RateLimitPeriodSeconds) * time.Second,
+	}
+	if r.MaxPaymentAmount != "" {
+		var err error
+		p.MaxPaymentAmount, err = amount.ParseInt64(r.MaxPaymentAmount)
+		if err != nil {
+			return account.Policy{}, errors.Wrap(err, "parsing max payment amount")
+		}
+	}
+	return p, policy.Validate(p)
+}
+
+func (h accountPolicyPutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, _ := auth.FromContext(ctx)
+	if claims.Address == "" {
+		unauthorized.Render(w)
+		return
+	}
+
+	req := accountPolicyPutRequest{}
+	err := httpdecode.Decode(r, &req)
+	if err != nil || req.Address == nil {
+		badRequest.Render(w)
+		return
+	}
+
+	l := h.Logger.Ctx(ctx).
+		WithField("account", req.Address.Address())
+
+	l.Info("Request to update account policy.")
+
+	if req.Address.Address() != claims.Address {
+		l.WithField("address", claims.Address).
+			Info("Not authorized as self, authorized as other address.")
+		unauthorized.Render(w)
+		return
+	}
+
+	p, err := req.Policy()
+	if err != nil {
+		l.WithField("error", err).
+			Info("Request validation failed.")
+		badRequest.Render(w)
+		return
+	}
+
+	err = h.AccountStore.UpdatePolicy(req.Address.Address(), p)
+	if err == account.ErrNotFound {
+		l.Info("Account not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	l.Info("Account policy updated.")
+
+	httpjson.Render(w, newAccountPolicyResponse(req.Address.Address(), p), httpjson.JSON)
+}
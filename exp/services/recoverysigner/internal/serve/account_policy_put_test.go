@@ -0,0 +1,161 @@
+package serve
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/db/dbtest"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that an identity of the account can't change its policy.
+func TestAccountPolicyPut_authenticatedNotAuthorized(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	s.Add(account.Account{
+		Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+		Identities: []account.Identity{
+			{
+				Role: "sender",
+				AuthMethods: []account.AuthMethod{
+					{Type: account.AuthMethodTypeAddress, Value: "GCGZ3CNBE47IWAA5YIKDZL2XYYLA2UKJPS55P5EJ4VOMLK523PF3G7EM"},
+				},
+			},
+		},
+	})
+	h := accountPolicyPutHandler{
+		Logger:       supportlog.DefaultLogger,
+		AccountStore: s,
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GCGZ3CNBE47IWAA5YIKDZL2XYYLA2UKJPS55P5EJ4VOMLK523PF3G7EM"})
+	req := `{}`
+	r := httptest.NewRequest("PUT", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/policy", strings.NewReader(req))
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Put("/{address}/policy", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantBody := `{
+	"error": "The request could not be authenticated."
+}`
+
+	assert.JSONEq(t, wantBody, string(body))
+}
+
+// Test that an invalid policy is rejected.
+func TestAccountPolicyPut_invalid(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	s.Add(account.Account{
+		Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+	})
+	h := accountPolicyPutHandler{
+		Logger:       supportlog.DefaultLogger,
+		AccountStore: s,
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N"})
+	req := `{
+	"allowed_operation_types": ["Transfer"]
+}`
+	r := httptest.NewRequest("PUT", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/policy", strings.NewReader(req))
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Put("/{address}/policy", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantBody := `{
+	"error": "The request was invalid in some way."
+}`
+
+	assert.JSONEq(t, wantBody, string(body))
+}
+
+// Test that the account can replace its policy.
+func TestAccountPolicyPut_success(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	s.Add(account.Account{
+		Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+	})
+	h := accountPolicyPutHandler{
+		Logger:       supportlog.DefaultLogger,
+		AccountStore: s,
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N"})
+	req := `{
+	"allowed_operation_types": ["SetOptions", "Payment"],
+	"max_payment_amount": "100",
+	"require_time_bounds": true,
+	"max_time_bounds_seconds": 300,
+	"rate_limit": 5,
+	"rate_limit_period_seconds": 86400
+}`
+	r := httptest.NewRequest("PUT", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/policy", strings.NewReader(req))
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Put("/{address}/policy", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantBody := `{
+	"address": "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+	"allowed_operation_types": ["SetOptions", "Payment"],
+	"max_payment_amount": "100.000000",
+	"require_time_bounds": true,
+	"max_time_bounds_seconds": 300,
+	"rate_limit": 5,
+	"rate_limit_period_seconds": 86400
+}`
+	assert.JSONEq(t, wantBody, string(body))
+
+	p, err := s.GetPolicy("GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N")
+	require.NoError(t, err)
+	wantPolicy := account.Policy{
+		AllowedOperationTypes: []string{"SetOptions", "Payment"},
+		MaxPaymentAmount:      100000000,
+		RequireTimeBounds:     true,
+		MaxTimeBoundsDuration: 5 * time.Minute,
+		RateLimit:             5,
+		RateLimitPeriod:       24 * time.Hour,
+	}
+	assert.Equal(t, wantPolicy, p)
+}
@@ -0,0 +1,34 @@
+package serve
+
+import (
+	"time"
+
+	"github.com/metriqorg/go/amount"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+)
+
+type accountPolicyResponse struct {
+	Address                string   `json:"address"`
+	AllowedOperationTypes  []string `json:"allowed_operation_types"`
+	MaxPaymentAmount       string   `json:"max_payment_amount,omitempty"`
+	RequireTimeBounds      bool     `json:"require_time_bounds"`
+	MaxTimeBoundsSeconds   int64    `json:"max_time_bounds_seconds"`
+	RateLimit              int      `json:"rate_limit"`
+	RateLimitPeriodSeconds int64    `json:"rate_limit_period_seconds"`
+}
+
+func newAccountPolicyResponse(address string, p account.Policy) accountPolicyResponse {
+	resp := accountPolicyResponse{
+		Address:                address,
+		AllowedOperationTypes:  []string{},
+		RequireTimeBounds:      p.RequireTimeBounds,
+		MaxTimeBoundsSeconds:   int64(p.MaxTimeBoundsDuration / time.Second),
+		RateLimit:              p.RateLimit,
+		RateLimitPeriodSeconds: int64(p.RateLimitPeriod / time.Second),
+	}
+	resp.AllowedOperationTypes = append(resp.AllowedOperationTypes, p.AllowedOperationTypes...)
+	if p.MaxPaymentAmount > 0 {
+		resp.MaxPaymentAmount = amount.StringFromInt64(p.MaxPaymentAmount)
+	}
+	return resp
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
 	"github.com/metriqorg/go/keypair"
 	"github.com/metriqorg/go/support/errors"
 	"github.com/metriqorg/go/support/http/httpdecode"
@@ -13,9 +14,10 @@ import (
 )
 
 type accountPostHandler struct {
-	Logger           *supportlog.Entry
-	SigningAddresses []*keypair.FromAddress
-	AccountStore     account.Store
+	Logger            *supportlog.Entry
+	SigningAddresses  []*keypair.FromAddress
+	SigningKeyDeriver *signingkey.Deriver
+	AccountStore      account.Store
 }
 
 type accountPostRequest struct {
@@ -107,6 +109,9 @@ func (h accountPostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	authMethodCount := 0
 	acc := account.Account{
 		Address: req.Address.Address(),
+		// Accounts registered while a signing key seed is configured get a
+		// signing key of their own instead of the legacy signing keys.
+		LegacySigningKeysRetired: h.SigningKeyDeriver != nil,
 	}
 	for _, i := range req.Identities {
 		accIdentity := account.Identity{
@@ -138,13 +143,22 @@ func (h accountPostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	l.Info("Account registered.")
 
+	// Get the account to get the signing key generated for it.
+	acc, err = h.AccountStore.Get(acc.Address)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
 	resp := accountResponse{
 		Address: acc.Address,
 	}
-	for _, signingAddress := range h.SigningAddresses {
-		resp.Signers = append(resp.Signers, accountResponseSigner{
-			Key: signingAddress.Address(),
-		})
+	resp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
 	}
 	for _, i := range acc.Identities {
 		respIdentity := accountResponseIdentity{
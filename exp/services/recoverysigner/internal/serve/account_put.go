@@ -5,6 +5,7 @@ import (
 
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
 	"github.com/metriqorg/go/keypair"
 	"github.com/metriqorg/go/support/errors"
 	"github.com/metriqorg/go/support/http/httpdecode"
@@ -13,9 +14,10 @@ import (
 )
 
 type accountPutHandler struct {
-	Logger           *supportlog.Entry
-	SigningAddresses []*keypair.FromAddress
-	AccountStore     account.Store
+	Logger            *supportlog.Entry
+	SigningAddresses  []*keypair.FromAddress
+	SigningKeyDeriver *signingkey.Deriver
+	AccountStore      account.Store
 }
 
 type accountPutRequest struct {
@@ -168,10 +170,11 @@ func (h accountPutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	resp := accountResponse{
 		Address: accWithNewIdentiies.Address,
 	}
-	for _, signingAddress := range h.SigningAddresses {
-		resp.Signers = append(resp.Signers, accountResponseSigner{
-			Key: signingAddress.Address(),
-		})
+	resp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
 	}
 	for _, i := range accWithNewIdentiies.Identities {
 		resp.Identities = append(resp.Identities, accountResponseIdentity{
@@ -1,6 +1,13 @@
 package serve
 
-import "time"
+import (
+	"time"
+
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/errors"
+)
 
 type accountResponse struct {
 	Address    string                    `json:"address"`
@@ -17,3 +24,49 @@ type accountResponseSigner struct {
 	Key     string    `json:"key"`
 	AddedAt time.Time `json:"added_at"`
 }
+
+// accountSigners returns the signers of the account, its most recently added
+// signing key first, followed by the legacy signing keys if the account still
+// uses them.
+func accountSigners(acc account.Account, legacySigningAddresses []*keypair.FromAddress, deriver *signingkey.Deriver) ([]accountResponseSigner, error) {
+	signers := []accountResponseSigner{}
+	signingKeys, err := accountSigningKeys(acc, deriver)
+	if err != nil {
+		return nil, err
+	}
+	for i, signingKey := range signingKeys {
+		signers = append(signers, accountResponseSigner{
+			Key:     signingKey.Address(),
+			AddedAt: acc.SigningKeys[i].AddedAt,
+		})
+	}
+	if !acc.LegacySigningKeysRetired {
+		for _, signingAddress := range legacySigningAddresses {
+			signers = append(signers, accountResponseSigner{
+				Key: signingAddress.Address(),
+			})
+		}
+	}
+	return signers, nil
+}
+
+// accountSigningKeys derives the signing keys of the account, in the order of
+// acc.SigningKeys.
+func accountSigningKeys(acc account.Account, deriver *signingkey.Deriver) ([]*keypair.Full, error) {
+	if len(acc.SigningKeys) == 0 {
+		return nil, nil
+	}
+	if deriver == nil {
+		return nil, errors.New("account has signing keys of its own but no signing key seed is configured")
+	}
+
+	signingKeys := make([]*keypair.Full, 0, len(acc.SigningKeys))
+	for _, sk := range acc.SigningKeys {
+		kp, err := deriver.Derive(sk.ID)
+		if err != nil {
+			return nil, err
+		}
+		signingKeys = append(signingKeys, kp)
+	}
+	return signingKeys, nil
+}
@@ -2,9 +2,12 @@ package serve
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/policy"
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
 	"github.com/metriqorg/go/keypair"
 	"github.com/metriqorg/go/support/http/httpdecode"
 	supportlog "github.com/metriqorg/go/support/log"
@@ -15,6 +18,7 @@ import (
 type accountSignHandler struct {
 	Logger                *supportlog.Entry
 	SigningKeys           []*keypair.Full
+	SigningKeyDeriver     *signingkey.Deriver
 	NetworkPassphrase     string
 	AccountStore          account.Store
 	AllowedSourceAccounts []*keypair.FromAddress
@@ -57,19 +61,6 @@ func (h accountSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	l.Info("Request to sign transaction.")
 
-	var signingKey *keypair.Full
-	for _, sk := range h.SigningKeys {
-		if req.SigningAddress.Address() == sk.Address() {
-			signingKey = sk
-			break
-		}
-	}
-	if signingKey == nil {
-		l.Info("Signing key not found.")
-		notFound.Render(w)
-		return
-	}
-
 	// Find the account that the request is for.
 	acc, err := h.AccountStore.Get(req.Address.Address())
 	if err == account.ErrNotFound {
@@ -86,12 +77,22 @@ func (h accountSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	authorized := claims.Address == req.Address.Address()
 	l.Infof("Authorized with self: %v.", authorized)
 
+	// The auth method the request is authorized with, which the rate limit of
+	// the account's policy applies to.
+	var authorizedWith account.AuthMethod
+	if authorized {
+		authorizedWith = account.AuthMethod{Type: account.AuthMethodTypeAddress, Value: claims.Address}
+	}
+
 	// Authorized if authenticated as an identity registered with the account.
 	for _, i := range acc.Identities {
 		for _, m := range i.AuthMethods {
 			if m.Value != "" && ((m.Type == account.AuthMethodTypeAddress && m.Value == claims.Address) ||
 				(m.Type == account.AuthMethodTypePhoneNumber && m.Value == claims.PhoneNumber) ||
 				(m.Type == account.AuthMethodTypeEmail && m.Value == claims.Email)) {
+				if !authorized {
+					authorizedWith = m
+				}
 				authorized = true
 				l.Infof("Authorized with %s.", m.Type)
 				break
@@ -105,6 +106,30 @@ func (h accountSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Find the signing key among the signing keys of the account, and the
+	// legacy signing keys if the account still uses them.
+	signingKeys, err := accountSigningKeys(acc, h.SigningKeyDeriver)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	if !acc.LegacySigningKeysRetired {
+		signingKeys = append(signingKeys, h.SigningKeys...)
+	}
+	var signingKey *keypair.Full
+	for _, sk := range signingKeys {
+		if req.SigningAddress.Address() == sk.Address() {
+			signingKey = sk
+			break
+		}
+	}
+	if signingKey == nil {
+		l.Info("Signing key not found.")
+		notFound.Render(w)
+		return
+	}
+
 	// Decode the request transaction.
 	parsed, err := txnbuild.TransactionFromXDR(req.Transaction)
 	if err != nil {
@@ -130,10 +155,19 @@ func (h accountSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	l.Info("Signing transaction.")
 
+	attempt := account.SignAttempt{
+		Address:         acc.Address,
+		AuthMethod:      authorizedWith,
+		SigningAddress:  signingKey.Address(),
+		TransactionHash: hashHex,
+	}
+
 	// Check that the transaction's source account and any operations it
 	// contains references only to this account.
 	if tx.SourceAccount().AccountID != req.Address.Address() {
 		l.Info("Transaction's source account is not the account in the request.")
+		attempt.DeniedReason = "transaction source account not the account"
+		h.addDeniedSignAttempt(l, attempt)
 		badRequest.Render(w)
 		return
 	}
@@ -154,12 +188,35 @@ func (h accountSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 			if !opHasAllowedAccount {
 				l.Info("Operation's source account is not the account in the request and not any account that is configured to be allowed.")
+				attempt.DeniedReason = "operation source account not the account or an allowed source account"
+				h.addDeniedSignAttempt(l, attempt)
 				badRequest.Render(w)
 				return
 			}
 		}
 	}
 
+	// Check that the transaction is allowed by the account's policy.
+	p, err := h.AccountStore.GetPolicy(acc.Address)
+	if err == account.ErrNotFound {
+		// It can happen if another authorized user is trying to delete the account at the same time.
+		l.Info("Account not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	err = policy.Check(p, tx, time.Now())
+	if deniedErr, ok := err.(policy.DeniedError); ok {
+		l.WithField("reason", deniedErr.Reason).
+			Info("Transaction denied by the account's policy.")
+		attempt.DeniedReason = deniedErr.Reason
+		h.addDeniedSignAttempt(l, attempt)
+		forbidden.Render(w)
+		return
+	}
 	// Sign the transaction.
 	hash, err := tx.Hash(h.NetworkPassphrase)
 	if err != nil {
@@ -174,6 +231,29 @@ func (h accountSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Record the signed attempt before responding so that it counts towards
+	// the rate limit. The count and the record are atomic so that concurrent
+	// requests can't all be signed under the rate limit.
+	signedCount, err := h.AccountStore.AddSignedAttempt(attempt, p.RateLimit, time.Now().Add(-p.RateLimitPeriod))
+	if err == account.ErrNotFound {
+		l.Info("Account not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	err = policy.CheckRateLimit(p, signedCount)
+	if deniedErr, ok := err.(policy.DeniedError); ok {
+		l.WithField("reason", deniedErr.Reason).
+			Info("Transaction denied by the account's rate limit.")
+		attempt.DeniedReason = deniedErr.Reason
+		h.addDeniedSignAttempt(l, attempt)
+		tooManyRequests.Render(w)
+		return
+	}
+
 	l.Info("Transaction signed.")
 
 	resp := accountSignResponse{
@@ -182,3 +262,13 @@ func (h accountSignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	httpjson.Render(w, resp, httpjson.JSON)
 }
+
+// addDeniedSignAttempt records a denied sign attempt. Failing to record it is
+// logged but doesn't change the response since the transaction is denied
+// either way.
+func (h accountSignHandler) addDeniedSignAttempt(l *supportlog.Entry, attempt account.SignAttempt) {
+	err := h.AccountStore.AddSignAttempt(attempt)
+	if err != nil {
+		l.Error("Error recording denied sign attempt:", err)
+	}
+}
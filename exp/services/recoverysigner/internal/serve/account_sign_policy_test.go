@@ -0,0 +1,200 @@
+package serve
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/db/dbtest"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a transaction containing an operation type not allowed by the
+// account's policy is denied, and that the denied attempt is recorded.
+func TestAccountSign_policyOperationTypeNotAllowed(t *testing.T) {
+	db := dbtest.Open(t).Open()
+	s := &account.DBStore{DB: db}
+	s.Add(account.Account{
+		Address: "GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4",
+		Identities: []account.Identity{
+			{
+				Role: "sender",
+				AuthMethods: []account.AuthMethod{
+					{Type: account.AuthMethodTypeEmail, Value: "user1@example.com"},
+				},
+			},
+		},
+	})
+	err := s.UpdatePolicy("GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4", account.Policy{
+		AllowedOperationTypes: []string{"SetOptions"},
+	})
+	require.NoError(t, err)
+	h := accountSignHandler{
+		Logger:       supportlog.DefaultLogger,
+		AccountStore: s,
+		SigningKeys: []*keypair.Full{
+			keypair.MustParseFull("SBIB72S6JMTGJRC6LMKLC5XMHZ2IOHZSZH4SASTN47LECEEJ7QEB6EYK"), // GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H
+		},
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}
+
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &txnbuild.SimpleAccount{AccountID: "GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4"},
+			IncrementSequenceNum: true,
+			Operations: []txnbuild.Operation{
+				&txnbuild.Payment{
+					Destination: "GD7CGJSJ5OBOU5KOP2UQDH3MPY75UTEY27HVV5XPSL2X6DJ2VGTOSXEU",
+					Amount:      "10",
+					Asset:       txnbuild.NativeAsset{},
+				},
+			},
+			BaseFee:       txnbuild.MinBaseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimebounds(0, 1)},
+		},
+	)
+	require.NoError(t, err)
+	txEnc, err := tx.Base64()
+	require.NoError(t, err)
+	t.Log("Tx:", txEnc)
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Email: "user1@example.com"})
+	req := `{
+	"transaction": "` + txEnc + `"
+}`
+	r := httptest.NewRequest("POST", "/GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4/sign/GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", strings.NewReader(req))
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Post("/{address}/sign/{signing-address}", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantBody := `{
+	"error": "The request is not allowed by the account's policy."
+}`
+	assert.JSONEq(t, wantBody, string(body))
+
+	type signAttempt struct {
+		AuthMethodType  string  `db:"auth_method_type"`
+		AuthMethodValue string  `db:"auth_method_value"`
+		DeniedReason    *string `db:"denied_reason"`
+	}
+	attempts := []signAttempt{}
+	err = db.Select(&attempts, `SELECT auth_method_type, auth_method_value, denied_reason FROM sign_attempts`)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "email", attempts[0].AuthMethodType)
+	assert.Equal(t, "user1@example.com", attempts[0].AuthMethodValue)
+	require.NotNil(t, attempts[0].DeniedReason)
+	assert.Equal(t, "operation type Payment not allowed", *attempts[0].DeniedReason)
+}
+
+// Test that once an identity had as many transactions signed as the rate
+// limit of the account's policy allows, further transactions are denied for
+// that identity only.
+func TestAccountSign_policyRateLimit(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	s.Add(account.Account{
+		Address: "GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4",
+		Identities: []account.Identity{
+			{
+				Role: "sender",
+				AuthMethods: []account.AuthMethod{
+					{Type: account.AuthMethodTypeEmail, Value: "user1@example.com"},
+				},
+			},
+			{
+				Role: "receiver",
+				AuthMethods: []account.AuthMethod{
+					{Type: account.AuthMethodTypeEmail, Value: "user2@example.com"},
+				},
+			},
+		},
+	})
+	err := s.UpdatePolicy("GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4", account.Policy{
+		RateLimit:       1,
+		RateLimitPeriod: time.Hour,
+	})
+	require.NoError(t, err)
+	h := accountSignHandler{
+		Logger:       supportlog.DefaultLogger,
+		AccountStore: s,
+		SigningKeys: []*keypair.Full{
+			keypair.MustParseFull("SBIB72S6JMTGJRC6LMKLC5XMHZ2IOHZSZH4SASTN47LECEEJ7QEB6EYK"), // GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H
+		},
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}
+
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &txnbuild.SimpleAccount{AccountID: "GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4"},
+			IncrementSequenceNum: true,
+			Operations: []txnbuild.Operation{
+				&txnbuild.SetOptions{
+					Signer: &txnbuild.Signer{
+						Address: "GD7CGJSJ5OBOU5KOP2UQDH3MPY75UTEY27HVV5XPSL2X6DJ2VGTOSXEU",
+						Weight:  20,
+					},
+				},
+			},
+			BaseFee:       txnbuild.MinBaseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimebounds(0, 1)},
+		},
+	)
+	require.NoError(t, err)
+	txEnc, err := tx.Base64()
+	require.NoError(t, err)
+	t.Log("Tx:", txEnc)
+
+	m := chi.NewMux()
+	m.Post("/{address}/sign/{signing-address}", h.ServeHTTP)
+	sign := func(email string) *http.Response {
+		ctx := context.Background()
+		ctx = auth.NewContext(ctx, auth.Auth{Email: email})
+		req := `{
+	"transaction": "` + txEnc + `"
+}`
+		r := httptest.NewRequest("POST", "/GA6HNE7O2N2IXIOBZNZ4IPTS2P6DSAJJF5GD5PDLH5GYOZ6WMPSKCXD4/sign/GBOG4KF66M4AFRBUHOTJQJRO7BGGFCSGIICTI5BHXHKXCWV2C67QRN5H", strings.NewReader(req))
+		r = r.WithContext(ctx)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	resp := sign("user1@example.com")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = sign("user1@example.com")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	wantBody := `{
+	"error": "The request exceeds the account's rate limit."
+}`
+	assert.JSONEq(t, wantBody, string(body))
+
+	// The rate limit applies to each identity separately.
+	resp = sign("user2@example.com")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
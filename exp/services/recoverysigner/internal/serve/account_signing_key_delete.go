@@ -0,0 +1,144 @@
+package serve
+
+import (
+	"net/http"
+
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/http/httpdecode"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+// accountSigningKeyDeleteHandler deletes a signing key of an account so that
+// it can no longer sign transactions for the account. Deleting one of the
+// legacy signing keys retires all of them for the account. The last signing
+// key of an account can't be deleted.
+type accountSigningKeyDeleteHandler struct {
+	Logger            *supportlog.Entry
+	SigningAddresses  []*keypair.FromAddress
+	SigningKeyDeriver *signingkey.Deriver
+	AccountStore      account.Store
+}
+
+type accountSigningKeyDeleteRequest struct {
+	Address        *keypair.FromAddress `path:"address"`
+	SigningAddress *keypair.FromAddress `path:"signing-address"`
+}
+
+func (h accountSigningKeyDeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, _ := auth.FromContext(ctx)
+	if claims.Address == "" {
+		unauthorized.Render(w)
+		return
+	}
+
+	req := accountSigningKeyDeleteRequest{}
+	err := httpdecode.Decode(r, &req)
+	if err != nil || req.Address == nil || req.SigningAddress == nil {
+		badRequest.Render(w)
+		return
+	}
+
+	l := h.Logger.Ctx(ctx).
+		WithField("account", req.Address.Address()).
+		WithField("signingaddress", req.SigningAddress.Address())
+
+	l.Info("Request to delete signing key.")
+
+	if req.Address.Address() != claims.Address {
+		l.WithField("address", claims.Address).
+			Info("Not authorized as self, authorized as other address.")
+		unauthorized.Render(w)
+		return
+	}
+
+	acc, err := h.AccountStore.Get(req.Address.Address())
+	if err == account.ErrNotFound {
+		l.Info("Account not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	signingKeys, err := accountSigningKeys(acc, h.SigningKeyDeriver)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	signingKeyIndex := -1
+	for i, sk := range signingKeys {
+		if sk.Address() == req.SigningAddress.Address() {
+			signingKeyIndex = i
+			break
+		}
+	}
+	isLegacySigningKey := false
+	if !acc.LegacySigningKeysRetired {
+		for _, sa := range h.SigningAddresses {
+			if sa.Address() == req.SigningAddress.Address() {
+				isLegacySigningKey = true
+				break
+			}
+		}
+	}
+
+	switch {
+	case signingKeyIndex >= 0:
+		if len(signingKeys) == 1 && acc.LegacySigningKeysRetired {
+			l.Info("Last signing key of the account can't be deleted.")
+			badRequest.Render(w)
+			return
+		}
+		err = h.AccountStore.DeleteSigningKey(acc.Address, acc.SigningKeys[signingKeyIndex].ID)
+		acc.SigningKeys = append(acc.SigningKeys[:signingKeyIndex], acc.SigningKeys[signingKeyIndex+1:]...)
+	case isLegacySigningKey:
+		if len(signingKeys) == 0 {
+			l.Info("Legacy signing keys can't be retired before adding a signing key.")
+			badRequest.Render(w)
+			return
+		}
+		err = h.AccountStore.RetireLegacySigningKeys(acc.Address)
+		acc.LegacySigningKeysRetired = true
+	default:
+		l.Info("Signing key not found.")
+		notFound.Render(w)
+		return
+	}
+	if err == account.ErrNotFound {
+		// It can happen if another authorized user is trying to delete the account at the same time.
+		l.Info("Account or signing key not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	l.Info("Signing key deleted.")
+
+	resp := accountResponse{
+		Address: acc.Address,
+	}
+	resp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	for _, i := range acc.Identities {
+		resp.Identities = append(resp.Identities, accountResponseIdentity{
+			Role: i.Role,
+		})
+	}
+	httpjson.Render(w, resp, httpjson.JSON)
+}
@@ -0,0 +1,211 @@
+package serve
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/db/dbtest"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/keypair"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that when authenticated as another address, deleting a signing key
+// returns an error.
+func TestAccountSigningKeyDelete_authenticatedNotAuthorized(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	s.Add(account.Account{
+		Address:                  "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+		LegacySigningKeysRetired: true,
+	})
+	h := accountSigningKeyDeleteHandler{
+		Logger:            supportlog.DefaultLogger,
+		AccountStore:      s,
+		SigningKeyDeriver: testSigningKeyDeriver(t),
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GCGZ3CNBE47IWAA5YIKDZL2XYYLA2UKJPS55P5EJ4VOMLK523PF3G7EM"})
+	r := httptest.NewRequest("DELETE", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/signing-keys/GBAW5XGWORWVFE2XTJYDTLDHXTY2Q2MO73HYCGB3XMFMQ562Q2W2GJQX", nil)
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Delete("/{address}/signing-keys/{signing-address}", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantBody := `{
+	"error": "The request could not be authenticated."
+}`
+
+	assert.JSONEq(t, wantBody, string(body))
+}
+
+// Test that the last signing key of an account can't be deleted.
+func TestAccountSigningKeyDelete_lastSigningKey(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	err := s.Add(account.Account{
+		Address:                  "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+		LegacySigningKeysRetired: true,
+	})
+	require.NoError(t, err)
+	h := accountSigningKeyDeleteHandler{
+		Logger:            supportlog.DefaultLogger,
+		AccountStore:      s,
+		SigningKeyDeriver: testSigningKeyDeriver(t),
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N"})
+	r := httptest.NewRequest("DELETE", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/signing-keys/GBAW5XGWORWVFE2XTJYDTLDHXTY2Q2MO73HYCGB3XMFMQ562Q2W2GJQX", nil)
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Delete("/{address}/signing-keys/{signing-address}", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantBody := `{
+	"error": "The request was invalid in some way."
+}`
+
+	assert.JSONEq(t, wantBody, string(body))
+}
+
+// Test that deleting a signing key of an account leaves its other signing
+// keys.
+func TestAccountSigningKeyDelete_signingKey(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	err := s.Add(account.Account{
+		Address:                  "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+		LegacySigningKeysRetired: true,
+	})
+	require.NoError(t, err)
+	_, err = s.AddSigningKey("GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N")
+	require.NoError(t, err)
+	h := accountSigningKeyDeleteHandler{
+		Logger:            supportlog.DefaultLogger,
+		AccountStore:      s,
+		SigningKeyDeriver: testSigningKeyDeriver(t),
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N"})
+	r := httptest.NewRequest("DELETE", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/signing-keys/GBAW5XGWORWVFE2XTJYDTLDHXTY2Q2MO73HYCGB3XMFMQ562Q2W2GJQX", nil)
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Delete("/{address}/signing-keys/{signing-address}", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantSigners := []string{"GAY5PRAHJ2HIYBYCLZXTHID6SPVELOOYH2LBPH3LD4RUMXUW3DOYTLXW"}
+	assert.Equal(t, wantSigners, responseSignerKeys(t, body))
+
+	acc, err := s.Get("GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N")
+	require.NoError(t, err)
+	require.Len(t, acc.SigningKeys, 1)
+	assert.Equal(t, int64(2), acc.SigningKeys[0].ID)
+}
+
+// Test that the legacy signing keys of an account can't be retired before it
+// has a signing key of its own.
+func TestAccountSigningKeyDelete_legacySigningKeyWithoutSigningKey(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	err := s.Add(account.Account{
+		Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+	})
+	require.NoError(t, err)
+	h := accountSigningKeyDeleteHandler{
+		Logger:       supportlog.DefaultLogger,
+		AccountStore: s,
+		SigningAddresses: []*keypair.FromAddress{
+			keypair.MustParseAddress("GCAPXRXSU7P6D353YGXMP6ROJIC744HO5OZCIWTXZQK2X757YU5KCHUE"),
+		},
+		SigningKeyDeriver: testSigningKeyDeriver(t),
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N"})
+	r := httptest.NewRequest("DELETE", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/signing-keys/GCAPXRXSU7P6D353YGXMP6ROJIC744HO5OZCIWTXZQK2X757YU5KCHUE", nil)
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Delete("/{address}/signing-keys/{signing-address}", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// Test that deleting a legacy signing key of an account that has a signing
+// key of its own retires the legacy signing keys.
+func TestAccountSigningKeyDelete_legacySigningKey(t *testing.T) {
+	s := &account.DBStore{DB: dbtest.Open(t).Open()}
+	err := s.Add(account.Account{
+		Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N",
+	})
+	require.NoError(t, err)
+	_, err = s.AddSigningKey("GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N")
+	require.NoError(t, err)
+	h := accountSigningKeyDeleteHandler{
+		Logger:       supportlog.DefaultLogger,
+		AccountStore: s,
+		SigningAddresses: []*keypair.FromAddress{
+			keypair.MustParseAddress("GCAPXRXSU7P6D353YGXMP6ROJIC744HO5OZCIWTXZQK2X757YU5KCHUE"),
+			keypair.MustParseAddress("GAPE22DOMALCH42VOR4S3HN6KIZZ643G7D3GNTYF4YOWWXP6UVRAF5JS"),
+		},
+		SigningKeyDeriver: testSigningKeyDeriver(t),
+	}
+
+	ctx := context.Background()
+	ctx = auth.NewContext(ctx, auth.Auth{Address: "GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N"})
+	r := httptest.NewRequest("DELETE", "/GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N/signing-keys/GAPE22DOMALCH42VOR4S3HN6KIZZ643G7D3GNTYF4YOWWXP6UVRAF5JS", nil)
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	m := chi.NewMux()
+	m.Delete("/{address}/signing-keys/{signing-address}", h.ServeHTTP)
+	m.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	wantSigners := []string{"GBAW5XGWORWVFE2XTJYDTLDHXTY2Q2MO73HYCGB3XMFMQ562Q2W2GJQX"}
+	assert.Equal(t, wantSigners, responseSignerKeys(t, body))
+
+	acc, err := s.Get("GDIXCQJ2W2N6TAS6AYW4LW2EBV7XNRUCLNHQB37FARDEWBQXRWP47Q6N")
+	require.NoError(t, err)
+	assert.True(t, acc.LegacySigningKeysRetired)
+}
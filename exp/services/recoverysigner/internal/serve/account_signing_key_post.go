@@ -0,0 +1,106 @@
+package serve
+
+import (
+	"net/http"
+
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/http/httpdecode"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+// accountSigningKeyPostHandler rotates the signing key of an account by adding
+// a new signing key to it, which becomes its preferred signer. The previous
+// signing keys can still sign transactions until they are deleted with
+// accountSigningKeyDeleteHandler, once the account has replaced them with the
+// new signing key on the network.
+type accountSigningKeyPostHandler struct {
+	Logger            *supportlog.Entry
+	SigningAddresses  []*keypair.FromAddress
+	SigningKeyDeriver *signingkey.Deriver
+	AccountStore      account.Store
+}
+
+type accountSigningKeyPostRequest struct {
+	Address *keypair.FromAddress `path:"address"`
+}
+
+func (h accountSigningKeyPostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, _ := auth.FromContext(ctx)
+	if claims.Address == "" {
+		unauthorized.Render(w)
+		return
+	}
+
+	req := accountSigningKeyPostRequest{}
+	err := httpdecode.Decode(r, &req)
+	if err != nil || req.Address == nil {
+		badRequest.Render(w)
+		return
+	}
+
+	l := h.Logger.Ctx(ctx).
+		WithField("account", req.Address.Address())
+
+	l.Info("Request to add signing key.")
+
+	if req.Address.Address() != claims.Address {
+		l.WithField("address", claims.Address).
+			Info("Not authorized as self, authorized as other address.")
+		unauthorized.Render(w)
+		return
+	}
+
+	if h.SigningKeyDeriver == nil {
+		l.Info("Signing keys can't be added without a signing key seed.")
+		notFound.Render(w)
+		return
+	}
+
+	signingKey, err := h.AccountStore.AddSigningKey(req.Address.Address())
+	if err == account.ErrNotFound {
+		l.Info("Account not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	l.WithField("signing_key_id", signingKey.ID).
+		Info("Signing key added.")
+
+	acc, err := h.AccountStore.Get(req.Address.Address())
+	if err == account.ErrNotFound {
+		// It can happen if another authorized user is trying to delete the account at the same time.
+		l.Info("Account not found.")
+		notFound.Render(w)
+		return
+	} else if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+
+	resp := accountResponse{
+		Address: acc.Address,
+	}
+	resp.Signers, err = accountSigners(acc, h.SigningAddresses, h.SigningKeyDeriver)
+	if err != nil {
+		l.Error(err)
+		serverError.Render(w)
+		return
+	}
+	for _, i := range acc.Identities {
+		resp.Identities = append(resp.Identities, accountResponseIdentity{
+			Role: i.Role,
+		})
+	}
+	httpjson.Render(w, resp, httpjson.JSON)
+}
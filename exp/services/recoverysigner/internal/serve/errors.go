@@ -30,6 +30,14 @@ var unauthorized = errorResponse{
 	Status: http.StatusUnauthorized,
 	Error:  "The request could not be authenticated.",
 }
+var forbidden = errorResponse{
+	Status: http.StatusForbidden,
+	Error:  "The request is not allowed by the account's policy.",
+}
+var tooManyRequests = errorResponse{
+	Status: http.StatusTooManyRequests,
+	Error:  "The request exceeds the account's rate limit.",
+}
 
 type errorResponse struct {
 	Status int    `json:"-"`
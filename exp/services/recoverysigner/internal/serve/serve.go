@@ -12,6 +12,7 @@ import (
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/account"
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/db"
 	"github.com/metriqorg/go/exp/services/recoverysigner/internal/serve/auth"
+	"github.com/metriqorg/go/exp/services/recoverysigner/internal/signingkey"
 	"github.com/metriqorg/go/keypair"
 	"github.com/metriqorg/go/support/errors"
 	supporthttp "github.com/metriqorg/go/support/http"
@@ -27,6 +28,7 @@ type Options struct {
 	Port                 int
 	NetworkPassphrase    string
 	SigningKeys          string
+	SigningKeySeed       string
 	SEP10JWKS            string
 	SEP10JWTIssuer       string
 	FirebaseProjectID    string
@@ -69,6 +71,7 @@ type handlerDeps struct {
 	NetworkPassphrase     string
 	SigningKeys           []*keypair.Full
 	SigningAddresses      []*keypair.FromAddress
+	SigningKeyDeriver     *signingkey.Deriver
 	AccountStore          account.Store
 	SEP10JWKS             jose.JSONWebKeySet
 	SEP10JWTIssuer        string
@@ -78,19 +81,36 @@ type handlerDeps struct {
 }
 
 func getHandlerDeps(opts Options) (handlerDeps, error) {
-	// TODO: Replace this signing key with randomly generating a unique signing
-	// key for each account so that it is not possible to identify which
-	// accounts are recoverable via a recovery signer.
+	if opts.SigningKeys == "" && opts.SigningKeySeed == "" {
+		return handlerDeps{}, errors.New("no signing key or signing key seed configured but at least one is required")
+	}
+
+	// The signing keys are the legacy signing keys shared by all the accounts
+	// registered before a signing key seed was configured.
 	signingKeys := []*keypair.Full{}
 	signingAddresses := []*keypair.FromAddress{}
-	for i, signingKeyStr := range strings.Split(opts.SigningKeys, ",") {
-		signingKey, err := keypair.ParseFull(signingKeyStr)
+	if opts.SigningKeys != "" {
+		for i, signingKeyStr := range strings.Split(opts.SigningKeys, ",") {
+			signingKey, err := keypair.ParseFull(signingKeyStr)
+			if err != nil {
+				return handlerDeps{}, errors.Wrap(err, "parsing signing key seed")
+			}
+			signingKeys = append(signingKeys, signingKey)
+			signingAddresses = append(signingAddresses, signingKey.FromAddress())
+			opts.Logger.Info("Signing key ", i, ": ", signingKey.Address())
+		}
+	}
+
+	// Accounts registered while a signing key seed is configured get signing
+	// keys of their own derived from the seed.
+	var signingKeyDeriver *signingkey.Deriver
+	if opts.SigningKeySeed != "" {
+		var err error
+		signingKeyDeriver, err = signingkey.ParseDeriver(opts.SigningKeySeed)
 		if err != nil {
 			return handlerDeps{}, errors.Wrap(err, "parsing signing key seed")
 		}
-		signingKeys = append(signingKeys, signingKey)
-		signingAddresses = append(signingAddresses, signingKey.FromAddress())
-		opts.Logger.Info("Signing key ", i, ": ", signingKey.Address())
+		opts.Logger.Info("Signing keys are derived per account")
 	}
 
 	sep10JWKS := jose.JSONWebKeySet{}
@@ -158,6 +178,7 @@ func getHandlerDeps(opts Options) (handlerDeps, error) {
 		NetworkPassphrase:     opts.NetworkPassphrase,
 		SigningKeys:           signingKeys,
 		SigningAddresses:      signingAddresses,
+		SigningKeyDeriver:     signingKeyDeriver,
 		AccountStore:          accountStore,
 		SEP10JWKS:             sep10JWKS,
 		SEP10JWTIssuer:        opts.SEP10JWTIssuer,
@@ -180,40 +201,66 @@ func handler(deps handlerDeps) http.Handler {
 		mux.Use(auth.SEP10Middleware(deps.SEP10JWTIssuer, deps.SEP10JWKS))
 		mux.Use(auth.FirebaseMiddleware(auth.FirebaseTokenVerifierLive{AuthClient: deps.FirebaseAuthClient}))
 		mux.Get("/", accountListHandler{
-			Logger:           deps.Logger,
-			SigningAddresses: deps.SigningAddresses,
-			AccountStore:     deps.AccountStore,
+			Logger:            deps.Logger,
+			SigningAddresses:  deps.SigningAddresses,
+			SigningKeyDeriver: deps.SigningKeyDeriver,
+			AccountStore:      deps.AccountStore,
 		}.ServeHTTP)
 		mux.Route("/{address}", func(mux chi.Router) {
 			mux.Post("/", accountPostHandler{
-				Logger:           deps.Logger,
-				SigningAddresses: deps.SigningAddresses,
-				AccountStore:     deps.AccountStore,
+				Logger:            deps.Logger,
+				SigningAddresses:  deps.SigningAddresses,
+				SigningKeyDeriver: deps.SigningKeyDeriver,
+				AccountStore:      deps.AccountStore,
 			}.ServeHTTP)
 			mux.Put("/", accountPutHandler{
-				Logger:           deps.Logger,
-				SigningAddresses: deps.SigningAddresses,
-				AccountStore:     deps.AccountStore,
+				Logger:            deps.Logger,
+				SigningAddresses:  deps.SigningAddresses,
+				SigningKeyDeriver: deps.SigningKeyDeriver,
+				AccountStore:      deps.AccountStore,
 			}.ServeHTTP)
 			mux.Get("/", accountGetHandler{
-				Logger:           deps.Logger,
-				SigningAddresses: deps.SigningAddresses,
-				AccountStore:     deps.AccountStore,
+				Logger:            deps.Logger,
+				SigningAddresses:  deps.SigningAddresses,
+				SigningKeyDeriver: deps.SigningKeyDeriver,
+				AccountStore:      deps.AccountStore,
 			}.ServeHTTP)
 			mux.Delete("/", accountDeleteHandler{
-				Logger:           deps.Logger,
-				SigningAddresses: deps.SigningAddresses,
-				AccountStore:     deps.AccountStore,
+				Logger:            deps.Logger,
+				SigningAddresses:  deps.SigningAddresses,
+				SigningKeyDeriver: deps.SigningKeyDeriver,
+				AccountStore:      deps.AccountStore,
 			}.ServeHTTP)
 			signHandler := accountSignHandler{
 				Logger:                deps.Logger,
 				SigningKeys:           deps.SigningKeys,
+				SigningKeyDeriver:     deps.SigningKeyDeriver,
 				NetworkPassphrase:     deps.NetworkPassphrase,
 				AccountStore:          deps.AccountStore,
 				AllowedSourceAccounts: deps.AllowedSourceAccounts,
 			}
 			mux.Post("/sign", signHandler.ServeHTTP)
 			mux.Post("/sign/{signing-address}", signHandler.ServeHTTP)
+			mux.Get("/policy", accountPolicyGetHandler{
+				Logger:       deps.Logger,
+				AccountStore: deps.AccountStore,
+			}.ServeHTTP)
+			mux.Put("/policy", accountPolicyPutHandler{
+				Logger:       deps.Logger,
+				AccountStore: deps.AccountStore,
+			}.ServeHTTP)
+			mux.Post("/signing-keys", accountSigningKeyPostHandler{
+				Logger:            deps.Logger,
+				SigningAddresses:  deps.SigningAddresses,
+				SigningKeyDeriver: deps.SigningKeyDeriver,
+				AccountStore:      deps.AccountStore,
+			}.ServeHTTP)
+			mux.Delete("/signing-keys/{signing-address}", accountSigningKeyDeleteHandler{
+				Logger:            deps.Logger,
+				SigningAddresses:  deps.SigningAddresses,
+				SigningKeyDeriver: deps.SigningKeyDeriver,
+				AccountStore:      deps.AccountStore,
+			}.ServeHTTP)
 		})
 	})
 
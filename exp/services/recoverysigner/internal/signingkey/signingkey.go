@@ -0,0 +1,57 @@
+// Package signingkey derives the signing keys of the accounts registered with
+// the recovery signer from a master seed, so that each account has signing keys
+// of its own while only the seed needs to be stored securely.
+package signingkey
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/metriqorg/go/exp/crypto/derivation"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// MinSeedLength is the minimum length in bytes of the master seed.
+const MinSeedLength = 32
+
+// Deriver derives signing keys from a master seed. The signing key with index
+// i is the key at the path m/44'/148'/i' of the seed as described by SEP-5,
+// so the seed must not be used for anything else.
+type Deriver struct {
+	seed []byte
+}
+
+// NewDeriver returns a Deriver for the given master seed.
+func NewDeriver(seed []byte) (*Deriver, error) {
+	if len(seed) < MinSeedLength {
+		return nil, errors.Errorf("signing key seed must be at least %d bytes long", MinSeedLength)
+	}
+	return &Deriver{seed: seed}, nil
+}
+
+// ParseDeriver returns a Deriver for the hex encoded master seed.
+func ParseDeriver(seedHex string) (*Deriver, error) {
+	seed, err := hex.DecodeString(seedHex)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding signing key seed")
+	}
+	return NewDeriver(seed)
+}
+
+// Derive returns the signing key with the given index.
+func (d *Deriver) Derive(index int64) (*keypair.Full, error) {
+	if index < 0 || index > math.MaxInt32 {
+		return nil, errors.Errorf("signing key index %d out of range", index)
+	}
+	key, err := derivation.DeriveForPath(fmt.Sprintf(derivation.StellarAccountPathFormat, index), d.seed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "deriving signing key %d", index)
+	}
+	kp, err := keypair.FromRawSeed(key.RawSeed())
+	if err != nil {
+		return nil, errors.Wrapf(err, "deriving signing key %d", index)
+	}
+	return kp, nil
+}
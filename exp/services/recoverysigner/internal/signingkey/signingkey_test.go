@@ -0,0 +1,43 @@
+package signingkey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Seed of the SEP-5 test case 1, whose derived keys are listed in
+// https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0005.md#test-cases.
+const testSeedHex = "e4a5a632e70943ae7f07659df1332160937fad82587216a4c64315a0fb39497ee4a01f76ddab4cba68147977f3a147b6ad584c41808e8238a07f6cc4b582f186"
+
+func TestDeriver_Derive(t *testing.T) {
+	d, err := ParseDeriver(testSeedHex)
+	require.NoError(t, err)
+
+	kp, err := d.Derive(0)
+	require.NoError(t, err)
+	assert.Equal(t, "GDRXE2BQUC3AZNPVFSCEZ76NJ3WWL25FYFK6RGZGIEKWE4SOOHSUJUJ6", kp.Address())
+
+	kp, err = d.Derive(1)
+	require.NoError(t, err)
+	assert.Equal(t, "GBAW5XGWORWVFE2XTJYDTLDHXTY2Q2MO73HYCGB3XMFMQ562Q2W2GJQX", kp.Address())
+
+	// Keys are derived deterministically.
+	kp2, err := d.Derive(1)
+	require.NoError(t, err)
+	assert.Equal(t, kp.Seed(), kp2.Seed())
+
+	_, err = d.Derive(-1)
+	assert.EqualError(t, err, "signing key index -1 out of range")
+	_, err = d.Derive(1 << 31)
+	assert.EqualError(t, err, "signing key index 2147483648 out of range")
+}
+
+func TestParseDeriver_invalidSeed(t *testing.T) {
+	_, err := ParseDeriver("not hex")
+	assert.Error(t, err)
+
+	_, err = ParseDeriver("00112233")
+	assert.EqualError(t, err, "signing key seed must be at least 32 bytes long")
+}
@@ -49,7 +49,6 @@ func (c *ServeCommand) Command() *cobra.Command {
 			Usage:     "Stellar signing key(s) used for signing transactions comma separated (first key is used for signing, others used for verifying challenges)",
 			OptType:   types.String,
 			ConfigKey: &opts.SigningKeys,
-			Required:  true,
 		},
 		{
 			Name:      "domain",
@@ -63,7 +62,6 @@ func (c *ServeCommand) Command() *cobra.Command {
 			Usage:     "Home domain(s) of the service(s) requiring SEP-10 authentication comma separated (first domain is the default domain)",
 			OptType:   types.String,
 			ConfigKey: &opts.AuthHomeDomains,
-			Required:  true,
 		},
 		{
 			Name:           "challenge-expires-in",
@@ -79,14 +77,18 @@ func (c *ServeCommand) Command() *cobra.Command {
 			Usage:     "JSON Web Key (JWK) used for signing JWTs (if the key is an asymmetric key that has separate public and private key, the JWK must contain the private key)",
 			OptType:   types.String,
 			ConfigKey: &opts.JWK,
-			Required:  true,
 		},
 		{
 			Name:      "jwt-issuer",
 			Usage:     "The issuer to set in the JWT iss claim",
 			OptType:   types.String,
 			ConfigKey: &opts.JWTIssuer,
-			Required:  true,
+		},
+		{
+			Name:      "tenants-file",
+			Usage:     "JSON file configuring additional home domains served, each with their own signing keys and JWT settings",
+			OptType:   types.String,
+			ConfigKey: &opts.TenantsFile,
 		},
 		{
 			Name:           "jwt-expires-in",
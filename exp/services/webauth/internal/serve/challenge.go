@@ -3,10 +3,9 @@ package serve
 import (
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/clients/stellartoml"
 	"github.com/metriqorg/go/strkey"
 	supportlog "github.com/metriqorg/go/support/log"
 	"github.com/metriqorg/go/support/render/httpjson"
@@ -18,10 +17,9 @@ import (
 type challengeHandler struct {
 	Logger             *supportlog.Entry
 	NetworkPassphrase  string
-	SigningKey         *keypair.Full
 	ChallengeExpiresIn time.Duration
-	Domain             string
-	HomeDomains        []string
+	StellarTOMLClient  stellartoml.ClientInterface
+	Tenants            []tenant
 }
 
 type challengeResponse struct {
@@ -41,23 +39,10 @@ func (h challengeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	homeDomain := queryValues.Get("home_domain")
-	if homeDomain != "" {
-		// In some cases the full stop (period) character is used at the end of a FQDN.
-		homeDomain = strings.TrimSuffix(homeDomain, ".")
-		matched := false
-		for _, supportedDomain := range h.HomeDomains {
-			if homeDomain == supportedDomain {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			badRequest.Render(w)
-			return
-		}
-	} else {
-		homeDomain = h.HomeDomains[0]
+	tenant, homeDomain := tenantForHomeDomain(h.Tenants, queryValues.Get("home_domain"))
+	if tenant == nil {
+		badRequest.Render(w)
+		return
 	}
 
 	var memo *txnbuild.MemoID
@@ -72,11 +57,37 @@ func (h challengeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		memo = &memoId
 	}
 
-	tx, err := txnbuild.BuildChallengeTx(
-		h.SigningKey.Seed(),
+	// If the client has a client domain its signing key is looked up in the
+	// client domain's stellar.toml and the client domain signer must sign the
+	// challenge too.
+	var clientSigningKey string
+	clientDomain := queryValues.Get("client_domain")
+	if clientDomain != "" {
+		resp, err := h.StellarTOMLClient.GetStellarToml(clientDomain)
+		if err != nil {
+			h.Logger.Ctx(ctx).
+				WithField("clientdomain", clientDomain).
+				Info("Failed to get client domain stellar.toml: ", err)
+			badRequest.Render(w)
+			return
+		}
+		clientSigningKey = resp.SigningKey
+		if !strkey.IsValidEd25519PublicKey(clientSigningKey) {
+			h.Logger.Ctx(ctx).
+				WithField("clientdomain", clientDomain).
+				Info("Client domain stellar.toml does not contain a valid SIGNING_KEY.")
+			badRequest.Render(w)
+			return
+		}
+	}
+
+	tx, err := txnbuild.BuildChallengeTxWithClientDomain(
+		tenant.SigningKey.Seed(),
 		account,
-		h.Domain,
+		tenant.Domain,
 		homeDomain,
+		clientDomain,
+		clientSigningKey,
 		h.NetworkPassphrase,
 		h.ChallengeExpiresIn,
 		memo,
@@ -97,8 +108,9 @@ func (h challengeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	l := h.Logger.Ctx(ctx).
 		WithField("tx", hash).
 		WithField("account", account).
-		WithField("serversigner", h.SigningKey.Address()).
-		WithField("homedomain", homeDomain)
+		WithField("serversigner", tenant.SigningKey.Address()).
+		WithField("homedomain", homeDomain).
+		WithField("clientdomain", clientDomain)
 
 	l.Info("Generated challenge transaction for account.")
 
@@ -9,9 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/metriqorg/go/clients/stellartoml"
 	"github.com/metriqorg/go/keypair"
 	"github.com/metriqorg/go/network"
 	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/support/errors"
 	supportlog "github.com/metriqorg/go/support/log"
 	"github.com/metriqorg/go/txnbuild"
 	"github.com/metriqorg/go/xdr"
@@ -26,10 +28,12 @@ func TestChallenge(t *testing.T) {
 	h := challengeHandler{
 		Logger:             supportlog.DefaultLogger,
 		NetworkPassphrase:  network.TestNetworkPassphrase,
-		SigningKey:         serverKey,
 		ChallengeExpiresIn: time.Minute,
-		Domain:             "webauthdomain",
-		HomeDomains:        []string{"testdomain"},
+		Tenants: []tenant{{
+			SigningKey:  serverKey,
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain"},
+		}},
 	}
 
 	r := httptest.NewRequest("GET", "/?account="+account.Address(), nil)
@@ -82,10 +86,12 @@ func TestChallenge_anotherHomeDomain(t *testing.T) {
 	h := challengeHandler{
 		Logger:             supportlog.DefaultLogger,
 		NetworkPassphrase:  network.TestNetworkPassphrase,
-		SigningKey:         serverKey,
 		ChallengeExpiresIn: time.Minute,
-		Domain:             "webauthdomain",
-		HomeDomains:        []string{"testdomain", anotherDomain},
+		Tenants: []tenant{{
+			SigningKey:  serverKey,
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain", anotherDomain},
+		}},
 	}
 
 	r := httptest.NewRequest("GET", fmt.Sprintf("/?account=%s&home_domain=%s", account.Address(), anotherDomain), nil)
@@ -132,7 +138,9 @@ func TestChallenge_anotherHomeDomain(t *testing.T) {
 
 func TestChallenge_noAccount(t *testing.T) {
 	h := challengeHandler{
-		SigningKey: keypair.MustRandom(),
+		Tenants: []tenant{{
+			SigningKey: keypair.MustRandom(),
+		}},
 	}
 
 	r := httptest.NewRequest("GET", "/", nil)
@@ -150,7 +158,9 @@ func TestChallenge_noAccount(t *testing.T) {
 
 func TestChallenge_invalidAccount(t *testing.T) {
 	h := challengeHandler{
-		SigningKey: keypair.MustRandom(),
+		Tenants: []tenant{{
+			SigningKey: keypair.MustRandom(),
+		}},
 	}
 
 	r := httptest.NewRequest("GET", "/?account=GREATACCOUNT", nil)
@@ -171,8 +181,10 @@ func TestChallenge_invalidHomeDomain(t *testing.T) {
 	anotherDomain := "anotherdomain"
 
 	h := challengeHandler{
-		SigningKey:  keypair.MustRandom(),
-		HomeDomains: []string{"testdomain"},
+		Tenants: []tenant{{
+			SigningKey:  keypair.MustRandom(),
+			HomeDomains: []string{"testdomain"},
+		}},
 	}
 
 	r := httptest.NewRequest("GET", fmt.Sprintf("/?account=%s&home_domain=%s", account.Address(), anotherDomain), nil)
@@ -195,10 +207,12 @@ func TestChallengeWithMemo(t *testing.T) {
 	h := challengeHandler{
 		Logger:             supportlog.DefaultLogger,
 		NetworkPassphrase:  network.TestNetworkPassphrase,
-		SigningKey:         serverKey,
 		ChallengeExpiresIn: time.Minute,
-		Domain:             "webauthdomain",
-		HomeDomains:        []string{"testdomain"},
+		Tenants: []tenant{{
+			SigningKey:  serverKey,
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain"},
+		}},
 	}
 
 	r := httptest.NewRequest("GET", "/?account="+account.Address()+"&memo=1", nil)
@@ -232,10 +246,12 @@ func TestChallengeWithBadMemo(t *testing.T) {
 	h := challengeHandler{
 		Logger:             supportlog.DefaultLogger,
 		NetworkPassphrase:  network.TestNetworkPassphrase,
-		SigningKey:         serverKey,
 		ChallengeExpiresIn: time.Minute,
-		Domain:             "webauthdomain",
-		HomeDomains:        []string{"testdomain"},
+		Tenants: []tenant{{
+			SigningKey:  serverKey,
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain"},
+		}},
 	}
 
 	r := httptest.NewRequest("GET", "/?account="+account.Address()+"&memo=test", nil)
@@ -264,10 +280,12 @@ func TestChallengeWithMuxedAccount(t *testing.T) {
 	h := challengeHandler{
 		Logger:             supportlog.DefaultLogger,
 		NetworkPassphrase:  network.TestNetworkPassphrase,
-		SigningKey:         serverKey,
 		ChallengeExpiresIn: time.Minute,
-		Domain:             "webauthdomain",
-		HomeDomains:        []string{"testdomain"},
+		Tenants: []tenant{{
+			SigningKey:  serverKey,
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain"},
+		}},
 	}
 
 	r := httptest.NewRequest("GET", "/?account="+muxedAccountAddress, nil)
@@ -291,3 +309,165 @@ func TestChallengeWithMuxedAccount(t *testing.T) {
 
 	require.Equal(t, tx.Operations()[0].SourceAccount.Address(), muxedAccountAddress)
 }
+
+func TestChallenge_anotherTenant(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	tenantServerKey := keypair.MustRandom()
+	account := keypair.MustRandom()
+
+	h := challengeHandler{
+		Logger:             supportlog.DefaultLogger,
+		NetworkPassphrase:  network.TestNetworkPassphrase,
+		ChallengeExpiresIn: time.Minute,
+		Tenants: []tenant{
+			{
+				SigningKey:  serverKey,
+				Domain:      "webauthdomain",
+				HomeDomains: []string{"testdomain"},
+			},
+			{
+				SigningKey:  tenantServerKey,
+				Domain:      "tenantwebauthdomain",
+				HomeDomains: []string{"tenantdomain", "anothertenantdomain"},
+			},
+		},
+	}
+
+	r := httptest.NewRequest("GET", "/?account="+account.Address()+"&home_domain=anothertenantdomain", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	res := struct {
+		Transaction       string `json:"transaction"`
+		NetworkPassphrase string `json:"network_passphrase"`
+	}{}
+	err := json.NewDecoder(resp.Body).Decode(&res)
+	require.NoError(t, err)
+
+	var tx xdr.TransactionEnvelope
+	err = xdr.SafeUnmarshalBase64(res.Transaction, &tx)
+	require.NoError(t, err)
+
+	sourceAccount := tx.SourceAccount().ToAccountId()
+	assert.Equal(t, tenantServerKey.Address(), sourceAccount.Address())
+	assert.Len(t, tx.Operations(), 2)
+	assert.Regexp(t, "^anothertenantdomain auth", tx.Operations()[0].Body.ManageDataOp.DataName)
+	assert.Equal(t, "web_auth_domain", string(tx.Operations()[1].Body.ManageDataOp.DataName))
+	assert.Equal(t, "tenantwebauthdomain", string(*tx.Operations()[1].Body.ManageDataOp.DataValue))
+
+	hash, err := network.HashTransactionInEnvelope(tx, res.NetworkPassphrase)
+	require.NoError(t, err)
+	assert.NoError(t, tenantServerKey.FromAddress().Verify(hash[:], tx.Signatures()[0].Signature))
+}
+
+func TestChallenge_clientDomain(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	clientDomainKey := keypair.MustRandom()
+	account := keypair.MustRandom()
+
+	stellarTOMLClient := &stellartoml.MockClient{}
+	stellarTOMLClient.
+		On("GetStellarToml", "wallet.example.com").
+		Return(&stellartoml.Response{SigningKey: clientDomainKey.Address()}, nil)
+
+	h := challengeHandler{
+		Logger:             supportlog.DefaultLogger,
+		NetworkPassphrase:  network.TestNetworkPassphrase,
+		ChallengeExpiresIn: time.Minute,
+		StellarTOMLClient:  stellarTOMLClient,
+		Tenants: []tenant{{
+			SigningKey:  serverKey,
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain"},
+		}},
+	}
+
+	r := httptest.NewRequest("GET", "/?account="+account.Address()+"&client_domain=wallet.example.com", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	res := struct {
+		Transaction       string `json:"transaction"`
+		NetworkPassphrase string `json:"network_passphrase"`
+	}{}
+	err := json.NewDecoder(resp.Body).Decode(&res)
+	require.NoError(t, err)
+
+	var tx xdr.TransactionEnvelope
+	err = xdr.SafeUnmarshalBase64(res.Transaction, &tx)
+	require.NoError(t, err)
+
+	assert.Len(t, tx.Signatures(), 1)
+	require.Len(t, tx.Operations(), 3)
+	op2SourceAccount := tx.Operations()[2].SourceAccount.ToAccountId()
+	assert.Equal(t, clientDomainKey.Address(), op2SourceAccount.Address())
+	assert.Equal(t, xdr.OperationTypeManageData, tx.Operations()[2].Body.Type)
+	assert.Equal(t, "client_domain", string(tx.Operations()[2].Body.ManageDataOp.DataName))
+	assert.Equal(t, "wallet.example.com", string(*tx.Operations()[2].Body.ManageDataOp.DataValue))
+}
+
+func TestChallenge_clientDomainWithoutSigningKey(t *testing.T) {
+	account := keypair.MustRandom()
+
+	stellarTOMLClient := &stellartoml.MockClient{}
+	stellarTOMLClient.
+		On("GetStellarToml", "wallet.example.com").
+		Return(&stellartoml.Response{}, nil)
+
+	h := challengeHandler{
+		Logger:             supportlog.DefaultLogger,
+		NetworkPassphrase:  network.TestNetworkPassphrase,
+		ChallengeExpiresIn: time.Minute,
+		StellarTOMLClient:  stellarTOMLClient,
+		Tenants: []tenant{{
+			SigningKey:  keypair.MustRandom(),
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain"},
+		}},
+	}
+
+	r := httptest.NewRequest("GET", "/?account="+account.Address()+"&client_domain=wallet.example.com", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"error":"The request was invalid in some way."}`, string(body))
+}
+
+func TestChallenge_clientDomainStellarTOMLNotFound(t *testing.T) {
+	account := keypair.MustRandom()
+
+	stellarTOMLClient := &stellartoml.MockClient{}
+	stellarTOMLClient.
+		On("GetStellarToml", "wallet.example.com").
+		Return((*stellartoml.Response)(nil), errors.New("http request failed with non-200 status code"))
+
+	h := challengeHandler{
+		Logger:             supportlog.DefaultLogger,
+		NetworkPassphrase:  network.TestNetworkPassphrase,
+		ChallengeExpiresIn: time.Minute,
+		StellarTOMLClient:  stellarTOMLClient,
+		Tenants: []tenant{{
+			SigningKey:  keypair.MustRandom(),
+			Domain:      "webauthdomain",
+			HomeDomains: []string{"testdomain"},
+		}},
+	}
+
+	r := httptest.NewRequest("GET", "/?account="+account.Address()+"&client_domain=wallet.example.com", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
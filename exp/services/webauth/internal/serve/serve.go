@@ -1,19 +1,15 @@
 package serve
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/metriqorg/go/clients/orbitrclient"
-	"github.com/metriqorg/go/keypair"
-	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/clients/stellartoml"
 	supporthttp "github.com/metriqorg/go/support/http"
 	supportlog "github.com/metriqorg/go/support/log"
 	"github.com/metriqorg/go/support/render/health"
-	"gopkg.in/square/go-jose.v2"
 )
 
 type Options struct {
@@ -29,8 +25,15 @@ type Options struct {
 	JWTIssuer                   string
 	JWTExpiresIn                time.Duration
 	AllowAccountsThatDoNotExist bool
+	// TenantsFile is a JSON file configuring additional home domains, each
+	// with their own signing keys and JWT settings.
+	TenantsFile string
 }
 
+// stellarTOMLTimeout is the timeout for fetching the stellar.toml of client
+// domains.
+const stellarTOMLTimeout = 10 * time.Second
+
 func Serve(opts Options) {
 	handler, err := handler(opts)
 	if err != nil {
@@ -50,34 +53,9 @@ func Serve(opts Options) {
 }
 
 func handler(opts Options) (http.Handler, error) {
-	signingKeys := []*keypair.Full{}
-	signingKeyStrs := strings.Split(opts.SigningKeys, ",")
-	signingAddresses := make([]*keypair.FromAddress, 0, len(signingKeyStrs))
-
-	for i, signingKeyStr := range signingKeyStrs {
-		signingKey, err := keypair.ParseFull(signingKeyStr)
-		if err != nil {
-			return nil, errors.Wrap(err, "parsing signing key seed")
-		}
-		signingKeys = append(signingKeys, signingKey)
-		signingAddresses = append(signingAddresses, signingKey.FromAddress())
-		opts.Logger.Info("Signing key ", i, ": ", signingKey.Address())
-	}
-
-	homeDomains := strings.Split(opts.AuthHomeDomains, ",")
-	trimmedHomeDomains := make([]string, 0, len(homeDomains))
-	for _, homeDomain := range homeDomains {
-		// In some cases the full stop (period) character is used at the end of a FQDN.
-		trimmedHomeDomains = append(trimmedHomeDomains, strings.TrimSuffix(homeDomain, "."))
-	}
-
-	jwk := jose.JSONWebKey{}
-	err := json.Unmarshal([]byte(opts.JWK), &jwk)
+	tenants, err := tenants(opts)
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing JSON Web Key (JWK)")
-	}
-	if jwk.Algorithm == "" {
-		return nil, errors.New("algorithm (alg) field must be set")
+		return nil, err
 	}
 
 	orbitrTimeout := orbitrclient.OrbitRTimeout
@@ -90,6 +68,12 @@ func handler(opts Options) (http.Handler, error) {
 	}
 	orbitrClient.SetOrbitRTimeout(orbitrTimeout)
 
+	stellarTOMLClient := &stellartoml.Client{
+		HTTP: &http.Client{
+			Timeout: stellarTOMLTimeout,
+		},
+	}
+
 	mux := supporthttp.NewAPIMux(opts.Logger)
 
 	mux.NotFound(errorHandler{Error: notFound}.ServeHTTP)
@@ -99,22 +83,16 @@ func handler(opts Options) (http.Handler, error) {
 	mux.Get("/", challengeHandler{
 		Logger:             opts.Logger,
 		NetworkPassphrase:  opts.NetworkPassphrase,
-		SigningKey:         signingKeys[0],
 		ChallengeExpiresIn: opts.ChallengeExpiresIn,
-		Domain:             opts.Domain,
-		HomeDomains:        trimmedHomeDomains,
+		StellarTOMLClient:  stellarTOMLClient,
+		Tenants:            tenants,
 	}.ServeHTTP)
 	mux.Post("/", tokenHandler{
 		Logger:                      opts.Logger,
 		OrbitRClient:               orbitrClient,
 		NetworkPassphrase:           opts.NetworkPassphrase,
-		SigningAddresses:            signingAddresses,
-		JWK:                         jwk,
-		JWTIssuer:                   opts.JWTIssuer,
-		JWTExpiresIn:                opts.JWTExpiresIn,
 		AllowAccountsThatDoNotExist: opts.AllowAccountsThatDoNotExist,
-		Domain:                      opts.Domain,
-		HomeDomains:                 trimmedHomeDomains,
+		Tenants:                     tenants,
 	}.ServeHTTP)
 
 	return mux, nil
@@ -0,0 +1,172 @@
+package serve
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/support/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// tenant serves SEP-10 authentication for one or more home domains, with its
+// own signing keys and JWT settings.
+type tenant struct {
+	// SigningKey signs the challenges.
+	SigningKey *keypair.Full
+	// SigningAddresses are the addresses challenges are accepted signed by,
+	// including the address of the SigningKey.
+	SigningAddresses []*keypair.FromAddress
+	// Domain is the domain the tenant's authentication is hosted at, the
+	// web_auth_domain of its challenges.
+	Domain       string
+	HomeDomains  []string
+	JWK          jose.JSONWebKey
+	JWTIssuer    string
+	JWTExpiresIn time.Duration
+}
+
+// tenantForHomeDomain returns the tenant serving the home domain, or the first
+// tenant and its first home domain if the home domain is empty. It returns nil
+// if no tenant serves the home domain.
+func tenantForHomeDomain(tenants []tenant, homeDomain string) (*tenant, string) {
+	if homeDomain == "" {
+		return &tenants[0], tenants[0].HomeDomains[0]
+	}
+	// In some cases the full stop (period) character is used at the end of a FQDN.
+	homeDomain = strings.TrimSuffix(homeDomain, ".")
+	for i, t := range tenants {
+		for _, d := range t.HomeDomains {
+			if homeDomain == d {
+				return &tenants[i], homeDomain
+			}
+		}
+	}
+	return nil, ""
+}
+
+// tenantConfig is the configuration of a tenant in the tenants file, which
+// contains a JSON array of them.
+type tenantConfig struct {
+	HomeDomains []string `json:"home_domains"`
+	// Domain defaults to the domain set in the options.
+	Domain      string          `json:"domain"`
+	SigningKeys []string        `json:"signing_keys"`
+	JWK         json.RawMessage `json:"jwk"`
+	// JWTIssuer defaults to the JWT issuer set in the options.
+	JWTIssuer string `json:"jwt_issuer"`
+	// JWTExpiresIn is in seconds and defaults to the JWT expiry set in the
+	// options.
+	JWTExpiresIn int `json:"jwt_expires_in"`
+}
+
+// tenants returns the tenants configured in the options, the tenant configured
+// with the signing key, auth home domain and JWT options if they are set
+// first, followed by the tenants in the tenants file.
+func tenants(opts Options) ([]tenant, error) {
+	configs := []tenantConfig{}
+	if opts.SigningKeys != "" || opts.AuthHomeDomains != "" || opts.JWK != "" {
+		configs = append(configs, tenantConfig{
+			HomeDomains: strings.Split(opts.AuthHomeDomains, ","),
+			SigningKeys: strings.Split(opts.SigningKeys, ","),
+			JWK:         json.RawMessage(opts.JWK),
+			JWTIssuer:   opts.JWTIssuer,
+		})
+	}
+	if opts.TenantsFile != "" {
+		tenantsJSON, err := ioutil.ReadFile(opts.TenantsFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading tenants file")
+		}
+		fileConfigs := []tenantConfig{}
+		err = json.Unmarshal(tenantsJSON, &fileConfigs)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing tenants file")
+		}
+		configs = append(configs, fileConfigs...)
+	}
+	if len(configs) == 0 {
+		return nil, errors.New("no signing key, auth home domain and JWK or tenants file configured but at least one is required")
+	}
+
+	tenants := make([]tenant, 0, len(configs))
+	homeDomainsSeen := map[string]bool{}
+	for i, c := range configs {
+		t, err := c.tenant(opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "configuring tenant %d", i)
+		}
+		for _, d := range t.HomeDomains {
+			if homeDomainsSeen[d] {
+				return nil, errors.Errorf("home domain %s configured for more than one tenant", d)
+			}
+			homeDomainsSeen[d] = true
+		}
+		tenants = append(tenants, t)
+
+		opts.Logger.Info("Tenant ", i, " home domains: ", strings.Join(t.HomeDomains, ","))
+		for j, signingAddress := range t.SigningAddresses {
+			opts.Logger.Info("Tenant ", i, " signing key ", j, ": ", signingAddress.Address())
+		}
+	}
+	return tenants, nil
+}
+
+func (c tenantConfig) tenant(opts Options) (tenant, error) {
+	t := tenant{
+		Domain:       c.Domain,
+		JWTIssuer:    c.JWTIssuer,
+		JWTExpiresIn: time.Duration(c.JWTExpiresIn) * time.Second,
+	}
+	if t.Domain == "" {
+		t.Domain = opts.Domain
+	}
+	if t.JWTIssuer == "" {
+		t.JWTIssuer = opts.JWTIssuer
+	}
+	if t.JWTExpiresIn == 0 {
+		t.JWTExpiresIn = opts.JWTExpiresIn
+	}
+
+	for _, homeDomain := range c.HomeDomains {
+		// In some cases the full stop (period) character is used at the end of a FQDN.
+		homeDomain = strings.TrimSuffix(homeDomain, ".")
+		if homeDomain == "" {
+			return tenant{}, errors.New("home domain is empty")
+		}
+		t.HomeDomains = append(t.HomeDomains, homeDomain)
+	}
+	if len(t.HomeDomains) == 0 {
+		return tenant{}, errors.New("no home domains configured but at least one is required")
+	}
+
+	for _, signingKeyStr := range c.SigningKeys {
+		signingKey, err := keypair.ParseFull(signingKeyStr)
+		if err != nil {
+			return tenant{}, errors.Wrap(err, "parsing signing key seed")
+		}
+		if t.SigningKey == nil {
+			t.SigningKey = signingKey
+		}
+		t.SigningAddresses = append(t.SigningAddresses, signingKey.FromAddress())
+	}
+	if t.SigningKey == nil {
+		return tenant{}, errors.New("no signing keys configured but at least one is required")
+	}
+
+	err := json.Unmarshal(c.JWK, &t.JWK)
+	if err != nil {
+		return tenant{}, errors.Wrap(err, "parsing JSON Web Key (JWK)")
+	}
+	if t.JWK.Algorithm == "" {
+		return tenant{}, errors.New("algorithm (alg) field must be set")
+	}
+
+	if t.JWTIssuer == "" {
+		return tenant{}, errors.New("no JWT issuer configured but one is required")
+	}
+
+	return t, nil
+}
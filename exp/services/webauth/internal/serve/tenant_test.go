@@ -0,0 +1,187 @@
+package serve
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/exp/support/jwtkey"
+	"github.com/metriqorg/go/keypair"
+	supportlog "github.com/metriqorg/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func testJWK(t *testing.T) string {
+	jwtPrivateKey, err := jwtkey.GenerateKey()
+	require.NoError(t, err)
+	jwk, err := json.Marshal(jose.JSONWebKey{Key: jwtPrivateKey, Algorithm: string(jose.ES256)})
+	require.NoError(t, err)
+	return string(jwk)
+}
+
+func writeTenantsFile(t *testing.T, tenantsJSON string) string {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	err := ioutil.WriteFile(path, []byte(tenantsJSON), 0600)
+	require.NoError(t, err)
+	return path
+}
+
+func TestTenants(t *testing.T) {
+	signingKey := keypair.MustRandom()
+	otherSigningKey := keypair.MustRandom()
+	tenantSigningKey := keypair.MustRandom()
+
+	tenantsFile := writeTenantsFile(t, `[{
+		"home_domains": ["tenant.example.com", "tenant.example.org."],
+		"domain": "webauth.tenant.example.com",
+		"signing_keys": ["`+tenantSigningKey.Seed()+`"],
+		"jwk": `+testJWK(t)+`,
+		"jwt_issuer": "https://tenant.example.com",
+		"jwt_expires_in": 3600
+	}]`)
+
+	tenants, err := tenants(Options{
+		Logger:          supportlog.DefaultLogger,
+		SigningKeys:     signingKey.Seed() + "," + otherSigningKey.Seed(),
+		Domain:          "webauth.example.com",
+		AuthHomeDomains: "example.com,example.org.",
+		JWK:             testJWK(t),
+		JWTIssuer:       "https://example.com",
+		JWTExpiresIn:    time.Minute,
+		TenantsFile:     tenantsFile,
+	})
+	require.NoError(t, err)
+	require.Len(t, tenants, 2)
+
+	assert.Equal(t, signingKey.Address(), tenants[0].SigningKey.Address())
+	assert.Equal(t, []*keypair.FromAddress{signingKey.FromAddress(), otherSigningKey.FromAddress()}, tenants[0].SigningAddresses)
+	assert.Equal(t, "webauth.example.com", tenants[0].Domain)
+	assert.Equal(t, []string{"example.com", "example.org"}, tenants[0].HomeDomains)
+	assert.Equal(t, "ES256", tenants[0].JWK.Algorithm)
+	assert.Equal(t, "https://example.com", tenants[0].JWTIssuer)
+	assert.Equal(t, time.Minute, tenants[0].JWTExpiresIn)
+
+	assert.Equal(t, tenantSigningKey.Address(), tenants[1].SigningKey.Address())
+	assert.Equal(t, []*keypair.FromAddress{tenantSigningKey.FromAddress()}, tenants[1].SigningAddresses)
+	assert.Equal(t, "webauth.tenant.example.com", tenants[1].Domain)
+	assert.Equal(t, []string{"tenant.example.com", "tenant.example.org"}, tenants[1].HomeDomains)
+	assert.Equal(t, "ES256", tenants[1].JWK.Algorithm)
+	assert.Equal(t, "https://tenant.example.com", tenants[1].JWTIssuer)
+	assert.Equal(t, time.Hour, tenants[1].JWTExpiresIn)
+
+	tenant, homeDomain := tenantForHomeDomain(tenants, "")
+	assert.Equal(t, &tenants[0], tenant)
+	assert.Equal(t, "example.com", homeDomain)
+	tenant, homeDomain = tenantForHomeDomain(tenants, "tenant.example.org.")
+	assert.Equal(t, &tenants[1], tenant)
+	assert.Equal(t, "tenant.example.org", homeDomain)
+	tenant, _ = tenantForHomeDomain(tenants, "unknown.example.com")
+	assert.Nil(t, tenant)
+}
+
+func TestTenants_onlyTenantsFile(t *testing.T) {
+	tenantsFile := writeTenantsFile(t, `[{
+		"home_domains": ["tenant.example.com"],
+		"signing_keys": ["`+keypair.MustRandom().Seed()+`"],
+		"jwk": `+testJWK(t)+`,
+		"jwt_issuer": "https://tenant.example.com"
+	}]`)
+
+	tenants, err := tenants(Options{
+		Logger:       supportlog.DefaultLogger,
+		Domain:       "webauth.example.com",
+		JWTExpiresIn: time.Minute,
+		TenantsFile:  tenantsFile,
+	})
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	assert.Equal(t, "webauth.example.com", tenants[0].Domain)
+	assert.Equal(t, time.Minute, tenants[0].JWTExpiresIn)
+}
+
+func TestTenants_jwtIssuerDefault(t *testing.T) {
+	tenantsFile := writeTenantsFile(t, `[{
+		"home_domains": ["tenant.example.com"],
+		"signing_keys": ["`+keypair.MustRandom().Seed()+`"],
+		"jwk": `+testJWK(t)+`
+	}]`)
+
+	tenants, err := tenants(Options{
+		Logger:      supportlog.DefaultLogger,
+		Domain:      "webauth.example.com",
+		JWTIssuer:   "https://example.com",
+		TenantsFile: tenantsFile,
+	})
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	assert.Equal(t, "https://example.com", tenants[0].JWTIssuer)
+}
+
+func TestTenants_noJWTIssuer(t *testing.T) {
+	tenantsFile := writeTenantsFile(t, `[{
+		"home_domains": ["tenant.example.com"],
+		"signing_keys": ["`+keypair.MustRandom().Seed()+`"],
+		"jwk": `+testJWK(t)+`
+	}]`)
+
+	_, err := tenants(Options{
+		Logger:      supportlog.DefaultLogger,
+		Domain:      "webauth.example.com",
+		TenantsFile: tenantsFile,
+	})
+	assert.EqualError(t, err, "configuring tenant 0: no JWT issuer configured but one is required")
+
+	_, err = tenants(Options{
+		Logger:          supportlog.DefaultLogger,
+		SigningKeys:     keypair.MustRandom().Seed(),
+		Domain:          "webauth.example.com",
+		AuthHomeDomains: "example.com",
+		JWK:             testJWK(t),
+	})
+	assert.EqualError(t, err, "configuring tenant 0: no JWT issuer configured but one is required")
+}
+
+func TestTenants_none(t *testing.T) {
+	_, err := tenants(Options{
+		Logger: supportlog.DefaultLogger,
+		Domain: "webauth.example.com",
+	})
+	assert.EqualError(t, err, "no signing key, auth home domain and JWK or tenants file configured but at least one is required")
+}
+
+func TestTenants_homeDomainConfiguredTwice(t *testing.T) {
+	tenantsFile := writeTenantsFile(t, `[{
+		"home_domains": ["example.com"],
+		"signing_keys": ["`+keypair.MustRandom().Seed()+`"],
+		"jwk": `+testJWK(t)+`
+	}]`)
+
+	_, err := tenants(Options{
+		Logger:          supportlog.DefaultLogger,
+		SigningKeys:     keypair.MustRandom().Seed(),
+		Domain:          "webauth.example.com",
+		AuthHomeDomains: "example.com",
+		JWK:             testJWK(t),
+		JWTIssuer:       "https://example.com",
+		TenantsFile:     tenantsFile,
+	})
+	assert.EqualError(t, err, "home domain example.com configured for more than one tenant")
+}
+
+func TestTenants_noSigningKeys(t *testing.T) {
+	tenantsFile := writeTenantsFile(t, `[{
+		"home_domains": ["example.com"],
+		"jwk": `+testJWK(t)+`
+	}]`)
+
+	_, err := tenants(Options{
+		Logger:      supportlog.DefaultLogger,
+		Domain:      "webauth.example.com",
+		TenantsFile: tenantsFile,
+	})
+	assert.EqualError(t, err, "configuring tenant 0: no signing keys configured but at least one is required")
+}
@@ -21,13 +21,8 @@ type tokenHandler struct {
 	Logger                      *supportlog.Entry
 	OrbitRClient               orbitrclient.ClientInterface
 	NetworkPassphrase           string
-	SigningAddresses            []*keypair.FromAddress
-	JWK                         jose.JSONWebKey
-	JWTIssuer                   string
-	JWTExpiresIn                time.Duration
 	AllowAccountsThatDoNotExist bool
-	Domain                      string
-	HomeDomains                 []string
+	Tenants                     []tenant
 }
 
 type tokenRequest struct {
@@ -52,15 +47,20 @@ func (h tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var (
 		tx              *txnbuild.Transaction
 		clientAccountID string
+		tenant          *tenant
 		signingAddress  *keypair.FromAddress
 		homeDomain      string
 		memo            *txnbuild.MemoID
 	)
-	for _, s := range h.SigningAddresses {
-		tx, clientAccountID, homeDomain, memo, err = txnbuild.ReadChallengeTx(req.Transaction, s.Address(), h.NetworkPassphrase, h.Domain, h.HomeDomains)
-		if err == nil {
-			signingAddress = s
-			break
+tenants:
+	for i, t := range h.Tenants {
+		for _, s := range t.SigningAddresses {
+			tx, clientAccountID, homeDomain, memo, err = txnbuild.ReadChallengeTx(req.Transaction, s.Address(), h.NetworkPassphrase, t.Domain, t.HomeDomains)
+			if err == nil {
+				tenant = &h.Tenants[i]
+				signingAddress = s
+				break tenants
+			}
 		}
 	}
 	if signingAddress == nil {
@@ -79,11 +79,14 @@ func (h tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	clientDomain, _ := txnbuild.ChallengeTxClientDomain(tx)
+
 	l := h.Logger.Ctx(ctx).
 		WithField("tx", hash).
 		WithField("account", clientAccountID).
 		WithField("serversigner", signingAddress.Address()).
 		WithField("homedomain", homeDomain).
+		WithField("clientdomain", clientDomain).
 		WithField("memo", memo)
 
 	l.Info("Start verifying challenge transaction.")
@@ -116,7 +119,7 @@ func (h tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if clientAccountExists {
 		requiredThreshold := txnbuild.Threshold(clientAccount.Thresholds.HighThreshold)
 		clientSignerSummary := clientAccount.SignerSummary()
-		signersVerified, err = txnbuild.VerifyChallengeTxThreshold(req.Transaction, signingAddress.Address(), h.NetworkPassphrase, h.Domain, h.HomeDomains, requiredThreshold, clientSignerSummary)
+		signersVerified, err = txnbuild.VerifyChallengeTxThreshold(req.Transaction, signingAddress.Address(), h.NetworkPassphrase, tenant.Domain, tenant.HomeDomains, requiredThreshold, clientSignerSummary)
 		if err != nil {
 			l.
 				WithField("signersCount", len(clientSignerSummary)).
@@ -132,7 +135,7 @@ func (h tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			unauthorized.Render(w)
 			return
 		}
-		signersVerified, err = txnbuild.VerifyChallengeTxSigners(req.Transaction, signingAddress.Address(), h.NetworkPassphrase, h.Domain, h.HomeDomains, clientAccountID)
+		signersVerified, err = txnbuild.VerifyChallengeTxSigners(req.Transaction, signingAddress.Address(), h.NetworkPassphrase, tenant.Domain, tenant.HomeDomains, clientAccountID)
 		if err != nil {
 			l.Infof("Failed to verify with account master key as signer.")
 			unauthorized.Render(w)
@@ -146,7 +149,7 @@ func (h tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	jwsOptions := &jose.SignerOptions{}
 	jwsOptions.WithType("JWT")
-	jws, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(h.JWK.Algorithm), Key: h.JWK.Key}, jwsOptions)
+	jws, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(tenant.JWK.Algorithm), Key: tenant.JWK.Key}, jwsOptions)
 	if err != nil {
 		l.WithStack(err).Error(err)
 		serverError.Render(w)
@@ -165,12 +168,18 @@ func (h tokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	issuedAt := time.Unix(tx.Timebounds().MinTime, 0)
 	claims := jwt.Claims{
-		Issuer:   h.JWTIssuer,
+		Issuer:   tenant.JWTIssuer,
 		Subject:  sub,
 		IssuedAt: jwt.NewNumericDate(issuedAt),
-		Expiry:   jwt.NewNumericDate(issuedAt.Add(h.JWTExpiresIn)),
+		Expiry:   jwt.NewNumericDate(issuedAt.Add(tenant.JWTExpiresIn)),
+	}
+	builder := jwt.Signed(jws).Claims(claims)
+	if clientDomain != "" {
+		builder = builder.Claims(map[string]interface{}{
+			"client_domain": clientDomain,
+		})
 	}
-	tokenStr, err := jwt.Signed(jws).Claims(claims).CompactSerialize()
+	tokenStr, err := builder.CompactSerialize()
 	if err != nil {
 		l.WithStack(err).Error(err)
 		serverError.Render(w)
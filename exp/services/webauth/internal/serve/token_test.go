@@ -83,14 +83,16 @@ func TestToken_formInputSuccess(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := url.Values{}
@@ -126,7 +128,7 @@ func TestToken_formInputSuccess(t *testing.T) {
 	assert.Equal(t, account.Address(), claims["sub"])
 	assert.Equal(t, float64(tx.Timebounds().MinTime), claims["iat"])
 	iat := time.Unix(int64(claims["iat"].(float64)), 0)
-	assert.Equal(t, float64(iat.Add(h.JWTExpiresIn).Unix()), claims["exp"])
+	assert.Equal(t, float64(iat.Add(h.Tenants[0].JWTExpiresIn).Unix()), claims["exp"])
 }
 
 func TestToken_formInputSuccess_jwtHeaderAndPayloadAreDeterministic(t *testing.T) {
@@ -184,14 +186,16 @@ func TestToken_formInputSuccess_jwtHeaderAndPayloadAreDeterministic(t *testing.T
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := url.Values{}
@@ -294,14 +298,16 @@ func TestToken_jsonInputSuccess(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -342,7 +348,7 @@ func TestToken_jsonInputSuccess(t *testing.T) {
 	assert.Equal(t, account.Address(), claims["sub"])
 	assert.Equal(t, float64(tx.Timebounds().MinTime), claims["iat"])
 	iat := time.Unix(int64(claims["iat"].(float64)), 0)
-	assert.Equal(t, float64(iat.Add(h.JWTExpiresIn).Unix()), claims["exp"])
+	assert.Equal(t, float64(iat.Add(h.Tenants[0].JWTExpiresIn).Unix()), claims["exp"])
 }
 
 // This test ensures that when multiple server keys are configured on the
@@ -397,14 +403,16 @@ func TestToken_jsonInputValidRotatingServerSigners(t *testing.T) {
 	homeDomain := "example.com"
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  serverKeyAddresses,
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: serverKeyAddresses,
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	for i, serverKey := range serverKeys {
@@ -472,7 +480,7 @@ func TestToken_jsonInputValidRotatingServerSigners(t *testing.T) {
 			assert.Equal(t, account.Address(), claims["sub"])
 			assert.Equal(t, float64(tx.Timebounds().MinTime), claims["iat"])
 			iat := time.Unix(int64(claims["iat"].(float64)), 0)
-			assert.Equal(t, float64(iat.Add(h.JWTExpiresIn).Unix()), claims["exp"])
+			assert.Equal(t, float64(iat.Add(h.Tenants[0].JWTExpiresIn).Unix()), claims["exp"])
 		})
 	}
 }
@@ -542,14 +550,16 @@ func TestToken_jsonInputValidMultipleSigners(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -589,7 +599,7 @@ func TestToken_jsonInputValidMultipleSigners(t *testing.T) {
 	assert.Equal(t, account.Address(), claims["sub"])
 	assert.Equal(t, float64(tx.Timebounds().MinTime), claims["iat"])
 	iat := time.Unix(int64(claims["iat"].(float64)), 0)
-	assert.Equal(t, float64(iat.Add(h.JWTExpiresIn).Unix()), claims["exp"])
+	assert.Equal(t, float64(iat.Add(h.Tenants[0].JWTExpiresIn).Unix()), claims["exp"])
 }
 
 func TestToken_jsonInputNotEnoughWeight(t *testing.T) {
@@ -647,14 +657,16 @@ func TestToken_jsonInputNotEnoughWeight(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -734,14 +746,16 @@ func TestToken_jsonInputUnrecognizedSigner(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -816,15 +830,17 @@ func TestToken_jsonInputAccountNotExistSuccess(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:                      supportlog.DefaultLogger,
-		OrbitRClient:               orbitrClient,
+		OrbitRClient:                orbitrClient,
 		NetworkPassphrase:           network.TestNetworkPassphrase,
-		SigningAddresses:            []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:                         jwk,
-		JWTIssuer:                   "https://example.com",
-		JWTExpiresIn:                time.Minute,
 		AllowAccountsThatDoNotExist: true,
-		Domain:                      domain,
-		HomeDomains:                 []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -865,7 +881,7 @@ func TestToken_jsonInputAccountNotExistSuccess(t *testing.T) {
 	assert.Equal(t, account.Address(), claims["sub"])
 	assert.Equal(t, float64(tx.Timebounds().MinTime), claims["iat"])
 	iat := time.Unix(int64(claims["iat"].(float64)), 0)
-	assert.Equal(t, float64(iat.Add(h.JWTExpiresIn).Unix()), claims["exp"])
+	assert.Equal(t, float64(iat.Add(h.Tenants[0].JWTExpiresIn).Unix()), claims["exp"])
 }
 
 func TestToken_jsonInputAccountNotExistFail(t *testing.T) {
@@ -921,15 +937,17 @@ func TestToken_jsonInputAccountNotExistFail(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:                      supportlog.DefaultLogger,
-		OrbitRClient:               orbitrClient,
+		OrbitRClient:                orbitrClient,
 		NetworkPassphrase:           network.TestNetworkPassphrase,
-		SigningAddresses:            []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:                         jwk,
-		JWTIssuer:                   "https://example.com",
-		JWTExpiresIn:                time.Minute,
 		AllowAccountsThatDoNotExist: true,
-		Domain:                      domain,
-		HomeDomains:                 []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -1004,15 +1022,17 @@ func TestToken_jsonInputAccountNotExistNotAllowed(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:                      supportlog.DefaultLogger,
-		OrbitRClient:               orbitrClient,
+		OrbitRClient:                orbitrClient,
 		NetworkPassphrase:           network.TestNetworkPassphrase,
-		SigningAddresses:            []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:                         jwk,
-		JWTIssuer:                   "https://example.com",
-		JWTExpiresIn:                time.Minute,
 		AllowAccountsThatDoNotExist: false,
-		Domain:                      domain,
-		HomeDomains:                 []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -1089,15 +1109,17 @@ func TestToken_jsonInputUnrecognizedServerSigner(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:                      supportlog.DefaultLogger,
-		OrbitRClient:               orbitrClient,
+		OrbitRClient:                orbitrClient,
 		NetworkPassphrase:           network.TestNetworkPassphrase,
-		SigningAddresses:            []*keypair.FromAddress{serverKey2.FromAddress()},
-		JWK:                         jwk,
-		JWTIssuer:                   "https://example.com",
-		JWTExpiresIn:                time.Minute,
 		AllowAccountsThatDoNotExist: false,
-		Domain:                      domain,
-		HomeDomains:                 []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey2.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -1186,15 +1208,17 @@ func TestToken_jsonInputNoWebAuthDomainSuccess(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:                      supportlog.DefaultLogger,
-		OrbitRClient:               orbitrClient,
+		OrbitRClient:                orbitrClient,
 		NetworkPassphrase:           network.TestNetworkPassphrase,
-		SigningAddresses:            []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:                         jwk,
-		JWTIssuer:                   "https://example.com",
-		JWTExpiresIn:                time.Minute,
 		AllowAccountsThatDoNotExist: true,
-		Domain:                      domain,
-		HomeDomains:                 []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -1235,7 +1259,7 @@ func TestToken_jsonInputNoWebAuthDomainSuccess(t *testing.T) {
 	assert.Equal(t, account.Address(), claims["sub"])
 	assert.Equal(t, float64(txMinTimebounds), claims["iat"])
 	iat := time.Unix(int64(claims["iat"].(float64)), 0)
-	assert.Equal(t, float64(iat.Add(h.JWTExpiresIn).Unix()), claims["exp"])
+	assert.Equal(t, float64(iat.Add(h.Tenants[0].JWTExpiresIn).Unix()), claims["exp"])
 }
 
 func TestToken_jsonInputInvalidWebAuthDomainFail(t *testing.T) {
@@ -1291,15 +1315,17 @@ func TestToken_jsonInputInvalidWebAuthDomainFail(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:                      supportlog.DefaultLogger,
-		OrbitRClient:               orbitrClient,
+		OrbitRClient:                orbitrClient,
 		NetworkPassphrase:           network.TestNetworkPassphrase,
-		SigningAddresses:            []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:                         jwk,
-		JWTIssuer:                   "https://example.com",
-		JWTExpiresIn:                time.Minute,
 		AllowAccountsThatDoNotExist: true,
-		Domain:                      domain,
-		HomeDomains:                 []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -1381,14 +1407,16 @@ func TestToken_successWithIdMemo(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -1491,14 +1519,16 @@ func TestToken_successWithMuxedAccount(t *testing.T) {
 
 	h := tokenHandler{
 		Logger:            supportlog.DefaultLogger,
-		OrbitRClient:     orbitrClient,
+		OrbitRClient:      orbitrClient,
 		NetworkPassphrase: network.TestNetworkPassphrase,
-		SigningAddresses:  []*keypair.FromAddress{serverKey.FromAddress()},
-		JWK:               jwk,
-		JWTIssuer:         "https://example.com",
-		JWTExpiresIn:      time.Minute,
-		Domain:            domain,
-		HomeDomains:       []string{homeDomain},
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
 	}
 
 	body := struct {
@@ -1537,3 +1567,267 @@ func TestToken_successWithMuxedAccount(t *testing.T) {
 
 	require.Equal(t, muxedAccountAddress, claims["sub"])
 }
+
+func TestToken_anotherTenant(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	tenantServerKey := keypair.MustRandom()
+
+	jwtPrivateKey, err := jwtkey.GenerateKey()
+	require.NoError(t, err)
+	jwk := jose.JSONWebKey{Key: jwtPrivateKey, Algorithm: string(jose.ES256)}
+	tenantJWTPrivateKey, err := jwtkey.GenerateKey()
+	require.NoError(t, err)
+	tenantJWK := jose.JSONWebKey{Key: tenantJWTPrivateKey, Algorithm: string(jose.ES256)}
+
+	account := keypair.MustRandom()
+
+	tx, err := txnbuild.BuildChallengeTx(
+		tenantServerKey.Seed(),
+		account.Address(),
+		"webauth.tenant.example.com",
+		"tenant.example.com",
+		network.TestNetworkPassphrase,
+		time.Minute,
+		nil,
+	)
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, account)
+	require.NoError(t, err)
+	txSigned, err := tx.Base64()
+	require.NoError(t, err)
+
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.
+		On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(
+			orbitr.Account{
+				Thresholds: orbitr.AccountThresholds{
+					LowThreshold:  1,
+					MedThreshold:  10,
+					HighThreshold: 100,
+				},
+				Signers: []orbitr.Signer{
+					{
+						Key:    account.Address(),
+						Weight: 100,
+					},
+				}},
+			nil,
+		)
+
+	h := tokenHandler{
+		Logger:            supportlog.DefaultLogger,
+		OrbitRClient:      orbitrClient,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Tenants: []tenant{
+			{
+				SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+				JWK:              jwk,
+				JWTIssuer:        "https://example.com",
+				JWTExpiresIn:     time.Minute,
+				Domain:           "webauth.example.com",
+				HomeDomains:      []string{"example.com"},
+			},
+			{
+				SigningAddresses: []*keypair.FromAddress{tenantServerKey.FromAddress()},
+				JWK:              tenantJWK,
+				JWTIssuer:        "https://tenant.example.com",
+				JWTExpiresIn:     time.Hour,
+				Domain:           "webauth.tenant.example.com",
+				HomeDomains:      []string{"tenant.example.com"},
+			},
+		},
+	}
+
+	body := url.Values{}
+	body.Set("transaction", txSigned)
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	res := struct {
+		Token string `json:"token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	require.NoError(t, err)
+
+	token, err := jwt.Parse(res.Token, func(token *jwt.Token) (interface{}, error) {
+		return &tenantJWTPrivateKey.PublicKey, nil
+	})
+	require.NoError(t, err)
+
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, "https://tenant.example.com", claims["iss"])
+	assert.Equal(t, account.Address(), claims["sub"])
+	iat := time.Unix(int64(claims["iat"].(float64)), 0)
+	assert.Equal(t, float64(iat.Add(time.Hour).Unix()), claims["exp"])
+}
+
+func TestToken_clientDomain(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	clientDomainKey := keypair.MustRandom()
+
+	jwtPrivateKey, err := jwtkey.GenerateKey()
+	require.NoError(t, err)
+	jwk := jose.JSONWebKey{Key: jwtPrivateKey, Algorithm: string(jose.ES256)}
+
+	account := keypair.MustRandom()
+
+	domain := "webauth.example.com"
+	homeDomain := "example.com"
+	tx, err := txnbuild.BuildChallengeTxWithClientDomain(
+		serverKey.Seed(),
+		account.Address(),
+		domain,
+		homeDomain,
+		"wallet.example.com",
+		clientDomainKey.Address(),
+		network.TestNetworkPassphrase,
+		time.Minute,
+		nil,
+	)
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, account, clientDomainKey)
+	require.NoError(t, err)
+	txSigned, err := tx.Base64()
+	require.NoError(t, err)
+
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.
+		On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(
+			orbitr.Account{
+				Thresholds: orbitr.AccountThresholds{
+					LowThreshold:  1,
+					MedThreshold:  10,
+					HighThreshold: 100,
+				},
+				Signers: []orbitr.Signer{
+					{
+						Key:    account.Address(),
+						Weight: 100,
+					},
+				}},
+			nil,
+		)
+
+	h := tokenHandler{
+		Logger:            supportlog.DefaultLogger,
+		OrbitRClient:      orbitrClient,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
+	}
+
+	body := url.Values{}
+	body.Set("transaction", txSigned)
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	res := struct {
+		Token string `json:"token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	require.NoError(t, err)
+
+	token, err := jwt.Parse(res.Token, func(token *jwt.Token) (interface{}, error) {
+		return &jwtPrivateKey.PublicKey, nil
+	})
+	require.NoError(t, err)
+
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, account.Address(), claims["sub"])
+	assert.Equal(t, "wallet.example.com", claims["client_domain"])
+}
+
+func TestToken_clientDomainNotSigned(t *testing.T) {
+	serverKey := keypair.MustRandom()
+	clientDomainKey := keypair.MustRandom()
+
+	jwtPrivateKey, err := jwtkey.GenerateKey()
+	require.NoError(t, err)
+	jwk := jose.JSONWebKey{Key: jwtPrivateKey, Algorithm: string(jose.ES256)}
+
+	account := keypair.MustRandom()
+
+	domain := "webauth.example.com"
+	homeDomain := "example.com"
+	tx, err := txnbuild.BuildChallengeTxWithClientDomain(
+		serverKey.Seed(),
+		account.Address(),
+		domain,
+		homeDomain,
+		"wallet.example.com",
+		clientDomainKey.Address(),
+		network.TestNetworkPassphrase,
+		time.Minute,
+		nil,
+	)
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, account)
+	require.NoError(t, err)
+	txSigned, err := tx.Base64()
+	require.NoError(t, err)
+
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.
+		On("AccountDetail", orbitrclient.AccountRequest{AccountID: account.Address()}).
+		Return(
+			orbitr.Account{
+				Thresholds: orbitr.AccountThresholds{
+					LowThreshold:  1,
+					MedThreshold:  10,
+					HighThreshold: 100,
+				},
+				Signers: []orbitr.Signer{
+					{
+						Key:    account.Address(),
+						Weight: 100,
+					},
+				}},
+			nil,
+		)
+
+	h := tokenHandler{
+		Logger:            supportlog.DefaultLogger,
+		OrbitRClient:      orbitrClient,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Tenants: []tenant{{
+			SigningAddresses: []*keypair.FromAddress{serverKey.FromAddress()},
+			JWK:              jwk,
+			JWTIssuer:        "https://example.com",
+			JWTExpiresIn:     time.Minute,
+			Domain:           domain,
+			HomeDomains:      []string{homeDomain},
+		}},
+	}
+
+	body := url.Values{}
+	body.Set("transaction", txSigned)
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	respBodyBytes, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"error":"The request could not be authenticated."}`, string(respBodyBytes))
+}
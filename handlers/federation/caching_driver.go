@@ -0,0 +1,162 @@
+package federation
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultCacheMaxEntries is the maximum number of results cached by a
+// `CachingDriver` that doesn't configure one.
+const DefaultCacheMaxEntries = 10000
+
+type cacheEntry struct {
+	record        *Record
+	reverseRecord *ReverseRecord
+	expiresAt     time.Time
+}
+
+// LookupRecord implements `Driver` by returning the cached result of the
+// lookup if any, or performing it against `drv.Driver` and caching its result
+// otherwise.
+func (drv *CachingDriver) LookupRecord(ctx context.Context, name, domain string) (*Record, error) {
+	key := "name:" + name + "*" + domain
+	if e, ok := drv.get(key); ok {
+		return e.record, nil
+	}
+
+	rec, err := drv.Driver.LookupRecord(ctx, name, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	drv.put(key, cacheEntry{record: rec}, rec != nil)
+	return rec, nil
+}
+
+// LookupReverseRecord implements `ReverseDriver` by returning the cached
+// result of the lookup if any, or performing it against `drv.Driver` and
+// caching its result otherwise.
+func (drv *CachingDriver) LookupReverseRecord(ctx context.Context, accountID string) (*ReverseRecord, error) {
+	rd, ok := drv.Driver.(ReverseDriver)
+	if !ok {
+		return nil, ErrorResponse{
+			StatusCode: http.StatusNotImplemented,
+			Code:       "not_implemented",
+			Message:    "id type queries are not supported",
+		}
+	}
+
+	key := "id:" + accountID
+	if e, ok := drv.get(key); ok {
+		return e.reverseRecord, nil
+	}
+
+	rec, err := rd.LookupReverseRecord(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	drv.put(key, cacheEntry{reverseRecord: rec}, rec != nil)
+	return rec, nil
+}
+
+// LookupForwardingRecord implements `ForwardDriver` by returning the cached
+// result of the lookup if any, or performing it against `drv.Driver` and
+// caching its result otherwise.
+func (drv *CachingDriver) LookupForwardingRecord(query url.Values) (*Record, error) {
+	fd, ok := drv.Driver.(ForwardDriver)
+	if !ok {
+		return nil, ErrorResponse{
+			StatusCode: http.StatusNotImplemented,
+			Code:       "not_implemented",
+			Message:    "forward type queries are not supported",
+		}
+	}
+
+	// Encode sorts the query by key, so equivalent queries share a key.
+	key := "forward:" + query.Encode()
+	if e, ok := drv.get(key); ok {
+		return e.record, nil
+	}
+
+	rec, err := fd.LookupForwardingRecord(query)
+	if err != nil {
+		return nil, err
+	}
+
+	drv.put(key, cacheEntry{record: rec}, rec != nil)
+	return rec, nil
+}
+
+var _ Driver = &CachingDriver{}
+var _ ReverseDriver = &CachingDriver{}
+var _ ForwardDriver = &CachingDriver{}
+
+func (drv *CachingDriver) initCache() {
+	drv.init.Do(func() {
+		drv.entries = map[string]cacheEntry{}
+		if drv.now == nil {
+			drv.now = time.Now
+		}
+	})
+}
+
+// get returns the entry cached with the provided key if it hasn't expired.
+func (drv *CachingDriver) get(key string) (cacheEntry, bool) {
+	drv.initCache()
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+
+	e, ok := drv.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if !drv.now().Before(e.expiresAt) {
+		delete(drv.entries, key)
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// put caches the provided entry with the provided key for `drv.TTL` if a
+// record was found, or for `drv.NegativeTTL` otherwise.  If the cache is full
+// the expired entries are evicted, and if none had expired an arbitrary one
+// is.
+func (drv *CachingDriver) put(key string, e cacheEntry, found bool) {
+	ttl := drv.NegativeTTL
+	if found {
+		ttl = drv.TTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	drv.initCache()
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+
+	now := drv.now()
+	e.expiresAt = now.Add(ttl)
+
+	maxEntries := drv.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	if _, ok := drv.entries[key]; !ok && len(drv.entries) >= maxEntries {
+		for k, cached := range drv.entries {
+			if !now.Before(cached.expiresAt) {
+				delete(drv.entries, k)
+			}
+		}
+		if len(drv.entries) >= maxEntries {
+			for k := range drv.entries {
+				delete(drv.entries, k)
+				break
+			}
+		}
+	}
+
+	drv.entries[key] = e
+}
@@ -0,0 +1,222 @@
+package federation
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDriver counts the lookups performed against the wrapped
+// `StaticDriver`.
+type countingDriver struct {
+	StaticDriver
+	lookups int
+	err     error
+}
+
+func (drv *countingDriver) LookupRecord(ctx context.Context, name, domain string) (*Record, error) {
+	drv.lookups++
+	if drv.err != nil {
+		return nil, drv.err
+	}
+	return drv.StaticDriver.LookupRecord(ctx, name, domain)
+}
+
+func (drv *countingDriver) LookupReverseRecord(ctx context.Context, accountID string) (*ReverseRecord, error) {
+	drv.lookups++
+	return drv.StaticDriver.LookupReverseRecord(ctx, accountID)
+}
+
+func (drv *countingDriver) LookupForwardingRecord(query url.Values) (*Record, error) {
+	drv.lookups++
+	return drv.StaticDriver.LookupForwardingRecord(query)
+}
+
+func newCountingDriver() *countingDriver {
+	return &countingDriver{
+		StaticDriver: StaticDriver{
+			Records: []StaticRecord{
+				{
+					Name:      "scott",
+					Domain:    "stellar.org",
+					AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG",
+				},
+				{
+					ForwardType: "bank_account",
+					Fields:      map[string]string{"acct": "1234"},
+					AccountID:   "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3",
+					MemoType:    "id",
+					Memo:        "1",
+				},
+			},
+		},
+	}
+}
+
+func TestCachingDriver_ttl(t *testing.T) {
+	backend := newCountingDriver()
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	driver := &CachingDriver{
+		Driver:      backend,
+		TTL:         time.Minute,
+		NegativeTTL: 10 * time.Second,
+		now:         func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	// records found are cached for the TTL
+	for i := 0; i < 2; i++ {
+		rec, err := driver.LookupRecord(ctx, "scott", "stellar.org")
+		require.NoError(t, err)
+		assert.Equal(t, &Record{AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG"}, rec)
+	}
+	assert.Equal(t, 1, backend.lookups)
+
+	// lookups that found no record are cached for the negative TTL
+	for i := 0; i < 2; i++ {
+		rec, err := driver.LookupRecord(ctx, "jed", "stellar.org")
+		require.NoError(t, err)
+		assert.Nil(t, rec)
+	}
+	assert.Equal(t, 2, backend.lookups)
+
+	now = now.Add(10 * time.Second)
+
+	rec, err := driver.LookupRecord(ctx, "scott", "stellar.org")
+	require.NoError(t, err)
+	assert.NotNil(t, rec)
+	assert.Equal(t, 2, backend.lookups)
+
+	rec, err = driver.LookupRecord(ctx, "jed", "stellar.org")
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+	assert.Equal(t, 3, backend.lookups)
+
+	now = now.Add(50 * time.Second)
+
+	rec, err = driver.LookupRecord(ctx, "scott", "stellar.org")
+	require.NoError(t, err)
+	assert.NotNil(t, rec)
+	assert.Equal(t, 4, backend.lookups)
+}
+
+func TestCachingDriver_reverseAndForward(t *testing.T) {
+	backend := newCountingDriver()
+	driver := &CachingDriver{
+		Driver: backend,
+		TTL:    time.Minute,
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		rec, err := driver.LookupReverseRecord(ctx, "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+		require.NoError(t, err)
+		assert.Equal(t, &ReverseRecord{Name: "scott", Domain: "stellar.org"}, rec)
+	}
+	assert.Equal(t, 1, backend.lookups)
+
+	// queries with the same values in a different order share the cache
+	rec, err := driver.LookupForwardingRecord(url.Values{"forward_type": {"bank_account"}, "acct": {"1234"}})
+	require.NoError(t, err)
+	assert.Equal(t, &Record{AccountID: "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3", MemoType: "id", Memo: "1"}, rec)
+	rec, err = driver.LookupForwardingRecord(url.Values{"acct": {"1234"}, "forward_type": {"bank_account"}})
+	require.NoError(t, err)
+	assert.NotNil(t, rec)
+	assert.Equal(t, 2, backend.lookups)
+
+	// no negative TTL, lookups that found no record are not cached
+	for i := 0; i < 2; i++ {
+		rec, err := driver.LookupForwardingRecord(url.Values{"forward_type": {"bank_account"}, "acct": {"4321"}})
+		require.NoError(t, err)
+		assert.Nil(t, rec)
+	}
+	assert.Equal(t, 4, backend.lookups)
+}
+
+func TestCachingDriver_errorsNotCached(t *testing.T) {
+	backend := newCountingDriver()
+	backend.err = errors.New("database unavailable")
+	driver := &CachingDriver{
+		Driver:      backend,
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+	}
+	ctx := context.Background()
+
+	_, err := driver.LookupRecord(ctx, "scott", "stellar.org")
+	assert.EqualError(t, err, "database unavailable")
+
+	backend.err = nil
+	rec, err := driver.LookupRecord(ctx, "scott", "stellar.org")
+	require.NoError(t, err)
+	assert.NotNil(t, rec)
+	assert.Equal(t, 2, backend.lookups)
+}
+
+func TestCachingDriver_maxEntries(t *testing.T) {
+	backend := newCountingDriver()
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	driver := &CachingDriver{
+		Driver:      backend,
+		TTL:         time.Minute,
+		NegativeTTL: time.Second,
+		MaxEntries:  2,
+		now:         func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	_, err := driver.LookupRecord(ctx, "jed", "stellar.org")
+	require.NoError(t, err)
+	_, err = driver.LookupRecord(ctx, "scott", "stellar.org")
+	require.NoError(t, err)
+	assert.Len(t, driver.entries, 2)
+
+	// the expired entry is evicted to make room
+	now = now.Add(time.Second)
+	_, err = driver.LookupReverseRecord(ctx, "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+	require.NoError(t, err)
+	assert.Len(t, driver.entries, 2)
+	assert.Contains(t, driver.entries, "name:scott*stellar.org")
+	assert.Contains(t, driver.entries, "id:GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+
+	// an arbitrary entry is evicted when none has expired
+	_, err = driver.LookupForwardingRecord(url.Values{"forward_type": {"bank_account"}, "acct": {"1234"}})
+	require.NoError(t, err)
+	assert.Len(t, driver.entries, 2)
+	assert.Contains(t, driver.entries, "forward:acct=1234&forward_type=bank_account")
+}
+
+func TestCachingDriver_handler(t *testing.T) {
+	handler := &Handler{&CachingDriver{
+		Driver: ForwardTestDriver{},
+		TTL:    time.Minute,
+	}}
+	server := httptest.NewServer(t, handler)
+	defer server.Close()
+
+	server.GET("/federation").
+		WithQuery("type", "forward").
+		WithQuery("forward_type", "bank_account").
+		WithQuery("acct", "1234").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().
+		ValueEqual("account_id", "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+
+	// the wrapped driver doesn't support reverse federation
+	server.GET("/federation").
+		WithQuery("type", "id").
+		WithQuery("q", "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG").
+		Expect().
+		Status(http.StatusNotImplemented).
+		JSON().Object().
+		ValueEqual("code", "not_implemented").
+		ValueEqual("message", "id type queries are not supported")
+}
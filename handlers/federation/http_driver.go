@@ -0,0 +1,132 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/metriqorg/go/address"
+	proto "github.com/metriqorg/go/protocols/federation"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// upstreamResponseMaxSize is the maximum size of a response from the upstream
+// service of an `HTTPDriver`.
+const upstreamResponseMaxSize = 100 * 1024
+
+// LookupRecord implements `Driver` by performing a "name" federation request
+// against `drv.URL`.
+func (drv *HTTPDriver) LookupRecord(ctx context.Context, name, domain string) (*Record, error) {
+	query := url.Values{}
+	query.Set("type", "name")
+	query.Set("q", address.New(name, domain))
+
+	var resp proto.NameResponse
+	found, err := drv.get(ctx, query, &resp)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &Record{
+		AccountID: resp.AccountID,
+		MemoType:  resp.MemoType,
+		Memo:      resp.Memo.Value,
+	}, nil
+}
+
+// LookupReverseRecord implements `ReverseDriver` by performing an "id"
+// federation request against `drv.URL`.
+func (drv *HTTPDriver) LookupReverseRecord(ctx context.Context, accountID string) (*ReverseRecord, error) {
+	query := url.Values{}
+	query.Set("type", "id")
+	query.Set("q", accountID)
+
+	var resp proto.IDResponse
+	found, err := drv.get(ctx, query, &resp)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	name, domain, err := address.Split(resp.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "upstream returned invalid stellar address %q", resp.Address)
+	}
+
+	return &ReverseRecord{
+		Name:   name,
+		Domain: domain,
+	}, nil
+}
+
+// LookupForwardingRecord implements `ForwardDriver` by performing a "forward"
+// federation request with the provided query against `drv.URL`.
+func (drv *HTTPDriver) LookupForwardingRecord(query url.Values) (*Record, error) {
+	var resp proto.NameResponse
+	found, err := drv.get(context.Background(), query, &resp)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &Record{
+		AccountID: resp.AccountID,
+		MemoType:  resp.MemoType,
+		Memo:      resp.Memo.Value,
+	}, nil
+}
+
+var _ Driver = &HTTPDriver{}
+var _ ReverseDriver = &HTTPDriver{}
+var _ ForwardDriver = &HTTPDriver{}
+
+// get performs a federation request with the provided query against
+// `drv.URL` and decodes the response into `dest`, returning false if the
+// upstream service didn't find a record.
+func (drv *HTTPDriver) get(ctx context.Context, query url.Values, dest interface{}) (bool, error) {
+	u, err := url.Parse(drv.URL)
+	if err != nil {
+		return false, errors.Wrap(err, "parsing upstream url")
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, errors.Wrap(err, "building upstream request")
+	}
+
+	client := drv.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	hresp, err := client.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "upstream request errored")
+	}
+	defer hresp.Body.Close()
+
+	body := io.LimitReader(hresp.Body, upstreamResponseMaxSize)
+
+	switch {
+	case hresp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case (hresp.StatusCode >= 400 && hresp.StatusCode < 500) || hresp.StatusCode == http.StatusNotImplemented:
+		// Pass client errors, such as invalid queries, and unsupported query
+		// types on to the end user.
+		errResp := ErrorResponse{StatusCode: hresp.StatusCode}
+		err = json.NewDecoder(body).Decode(&errResp)
+		if err != nil || errResp.Code == "" {
+			return false, errors.Errorf("upstream request failed with (%d) status code", hresp.StatusCode)
+		}
+		return false, errResp
+	case hresp.StatusCode < 200 || hresp.StatusCode >= 300:
+		return false, errors.Errorf("upstream request failed with (%d) status code", hresp.StatusCode)
+	}
+
+	err = json.NewDecoder(body).Decode(dest)
+	if err != nil {
+		return false, errors.Wrap(err, "decoding upstream response")
+	}
+
+	return true, nil
+}
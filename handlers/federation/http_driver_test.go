@@ -0,0 +1,121 @@
+package federation
+
+import (
+	"context"
+	"net/http"
+	stdhttptest "net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/metriqorg/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUpstreamServer(t *testing.T) *stdhttptest.Server {
+	upstream := &Handler{
+		Driver: &StaticDriver{
+			Records: []StaticRecord{
+				{
+					Name:      "scott",
+					Domain:    "stellar.org",
+					AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG",
+				},
+				{
+					ForwardType: "bank_account",
+					Fields:      map[string]string{"acct": "1234"},
+					AccountID:   "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3",
+					MemoType:    "id",
+					Memo:        "1",
+				},
+			},
+		},
+	}
+	server := stdhttptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPDriver(t *testing.T) {
+	upstream := newUpstreamServer(t)
+	driver := &HTTPDriver{URL: upstream.URL + "/federation"}
+	ctx := context.Background()
+
+	rec, err := driver.LookupRecord(ctx, "scott", "stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, &Record{AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG"}, rec)
+
+	rec, err = driver.LookupRecord(ctx, "jed", "stellar.org")
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+
+	reverseRec, err := driver.LookupReverseRecord(ctx, "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+	require.NoError(t, err)
+	assert.Equal(t, &ReverseRecord{Name: "scott", Domain: "stellar.org"}, reverseRec)
+
+	reverseRec, err = driver.LookupReverseRecord(ctx, "GA3R753JKGXU6ETHNY3U6PYIY7D6UUCXXDYBRF4XURNAGXW3CVGQH2ZA")
+	require.NoError(t, err)
+	assert.Nil(t, reverseRec)
+
+	rec, err = driver.LookupForwardingRecord(url.Values{"type": {"forward"}, "forward_type": {"bank_account"}, "acct": {"1234"}})
+	require.NoError(t, err)
+	assert.Equal(t, &Record{AccountID: "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3", MemoType: "id", Memo: "1"}, rec)
+
+	rec, err = driver.LookupForwardingRecord(url.Values{"type": {"forward"}, "forward_type": {"bank_account"}, "acct": {"4321"}})
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+}
+
+func TestHTTPDriver_upstreamErrors(t *testing.T) {
+	upstream := stdhttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("q") {
+		case "bad*stellar.org":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": "invalid_query", "message": "Names can't start with bad"}`))
+		case "broken*stellar.org":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`not json`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer upstream.Close()
+
+	driver := &HTTPDriver{URL: upstream.URL}
+	ctx := context.Background()
+
+	_, err := driver.LookupRecord(ctx, "bad", "stellar.org")
+	assert.Equal(t, ErrorResponse{
+		StatusCode: http.StatusBadRequest,
+		Code:       "invalid_query",
+		Message:    "Names can't start with bad",
+	}, err)
+
+	_, err = driver.LookupRecord(ctx, "broken", "stellar.org")
+	assert.EqualError(t, err, "upstream request failed with (400) status code")
+
+	_, err = driver.LookupRecord(ctx, "scott", "stellar.org")
+	assert.EqualError(t, err, "upstream request failed with (500) status code")
+}
+
+func TestHTTPDriver_handler(t *testing.T) {
+	upstream := stdhttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotImplemented)
+		w.Write([]byte(`{"code": "not_implemented", "message": "id type queries are not supported"}`))
+	}))
+	defer upstream.Close()
+
+	handler := &Handler{&HTTPDriver{URL: upstream.URL}}
+	server := httptest.NewServer(t, handler)
+	defer server.Close()
+
+	// Error responses of the upstream service are passed on
+	server.GET("/federation").
+		WithQuery("type", "id").
+		WithQuery("q", "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG").
+		Expect().
+		Status(http.StatusNotImplemented).
+		JSON().Object().
+		ValueEqual("code", "not_implemented").
+		ValueEqual("message", "id type queries are not supported")
+}
@@ -0,0 +1,150 @@
+package federation
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// ldapTimeout is the timeout of connecting to the directory of an
+// `LDAPDriver` and of each of its requests.
+const ldapTimeout = 10 * time.Second
+
+// LookupRecord implements `Driver` by searching `drv.LookupRecordFilter`
+// under `drv.BaseDN` with the provided name and domain.
+func (drv *LDAPDriver) LookupRecord(ctx context.Context, name, domain string) (*Record, error) {
+	filter := strings.NewReplacer(
+		"{name}", ldap.EscapeFilter(name),
+		"{domain}", ldap.EscapeFilter(domain),
+	).Replace(drv.LookupRecordFilter)
+
+	attributes := []string{drv.AccountIDAttribute}
+	if drv.MemoTypeAttribute != "" {
+		attributes = append(attributes, drv.MemoTypeAttribute)
+	}
+	if drv.MemoAttribute != "" {
+		attributes = append(attributes, drv.MemoAttribute)
+	}
+
+	entry, err := drv.search(filter, attributes)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	record := &Record{
+		AccountID: entry.GetAttributeValue(drv.AccountIDAttribute),
+	}
+	if drv.MemoTypeAttribute != "" {
+		record.MemoType = entry.GetAttributeValue(drv.MemoTypeAttribute)
+	}
+	if drv.MemoAttribute != "" {
+		record.Memo = entry.GetAttributeValue(drv.MemoAttribute)
+	}
+	if record.AccountID == "" {
+		return nil, errors.Errorf("ldap entry %q has no %s attribute", entry.DN, drv.AccountIDAttribute)
+	}
+
+	return record, nil
+}
+
+// LookupReverseRecord implements `ReverseDriver` by searching
+// `drv.LookupReverseRecordFilter` under `drv.BaseDN` with the provided account
+// id.
+func (drv *LDAPDriver) LookupReverseRecord(ctx context.Context, accountID string) (*ReverseRecord, error) {
+	if drv.LookupReverseRecordFilter == "" {
+		return nil, ErrorResponse{
+			StatusCode: http.StatusNotImplemented,
+			Code:       "not_implemented",
+			Message:    "id type queries are not supported",
+		}
+	}
+
+	filter := strings.NewReplacer(
+		"{account_id}", ldap.EscapeFilter(accountID),
+	).Replace(drv.LookupReverseRecordFilter)
+
+	attributes := []string{drv.NameAttribute}
+	if drv.DomainAttribute != "" {
+		attributes = append(attributes, drv.DomainAttribute)
+	}
+
+	entry, err := drv.search(filter, attributes)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	record := &ReverseRecord{
+		Name:   entry.GetAttributeValue(drv.NameAttribute),
+		Domain: drv.Domain,
+	}
+	if drv.DomainAttribute != "" {
+		record.Domain = entry.GetAttributeValue(drv.DomainAttribute)
+	}
+	if record.Name == "" || record.Domain == "" {
+		return nil, errors.Errorf("ldap entry %q has no stellar address", entry.DN)
+	}
+
+	return record, nil
+}
+
+var _ Driver = &LDAPDriver{}
+var _ ReverseDriver = &LDAPDriver{}
+
+// search connects to the directory and returns the entry matching the
+// provided filter under `drv.BaseDN`, or nil if none does.  It errors if more
+// than one entry matches, since the filter then doesn't identify a user.
+func (drv *LDAPDriver) search(filter string, attributes []string) (*ldap.Entry, error) {
+	dial := drv.Dial
+	if dial == nil {
+		dial = dialLDAP
+	}
+	conn, err := dial(drv.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "ldap dial")
+	}
+	defer conn.Close()
+
+	if drv.BindDN != "" {
+		err = conn.Bind(drv.BindDN, drv.BindPassword)
+		if err != nil {
+			return nil, errors.Wrap(err, "ldap bind")
+		}
+	}
+
+	req := ldap.NewSearchRequest(
+		drv.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2, // enough to detect ambiguous filters
+		int(ldapTimeout/time.Second),
+		false,
+		filter,
+		attributes,
+		nil,
+	)
+	result, err := conn.Search(req)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) || (err == nil && len(result.Entries) > 1) {
+		return nil, errors.Errorf("more than one ldap entry matches %q", filter)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "ldap search")
+	}
+
+	if len(result.Entries) == 0 {
+		return nil, nil
+	}
+	return result.Entries[0], nil
+}
+
+func dialLDAP(url string) (LDAPConn, error) {
+	conn, err := ldap.DialURL(url, ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+	return conn, nil
+}
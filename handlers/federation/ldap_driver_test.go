@@ -0,0 +1,171 @@
+package federation
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDirectory is an `LDAPConn` answering searches with the entries stored
+// for their filter.
+type fakeDirectory struct {
+	entries  map[string][]*ldap.Entry
+	bindDN   string
+	password string
+
+	bound    string
+	requests []*ldap.SearchRequest
+	closed   int
+}
+
+func (d *fakeDirectory) Bind(username, password string) error {
+	if username != d.bindDN || password != d.password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	d.bound = username
+	return nil
+}
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.requests = append(d.requests, req)
+	entries := d.entries[req.Filter]
+	if len(entries) > req.SizeLimit {
+		return &ldap.SearchResult{Entries: entries[:req.SizeLimit]}, ldap.NewError(ldap.LDAPResultSizeLimitExceeded, errors.New("size limit exceeded"))
+	}
+	return &ldap.SearchResult{Entries: entries}, nil
+}
+
+func (d *fakeDirectory) Close() error {
+	d.closed++
+	return nil
+}
+
+func newLDAPDriver(d *fakeDirectory) *LDAPDriver {
+	return &LDAPDriver{
+		URL:                       "ldap://ldap.example.com",
+		BindDN:                    "cn=federation,dc=stellar,dc=org",
+		BindPassword:              "secret",
+		BaseDN:                    "ou=people,dc=stellar,dc=org",
+		LookupRecordFilter:        "(&(uid={name})(stellarDomain={domain}))",
+		LookupReverseRecordFilter: "(stellarAccount={account_id})",
+		AccountIDAttribute:        "stellarAccount",
+		MemoTypeAttribute:         "stellarMemoType",
+		MemoAttribute:             "stellarMemo",
+		NameAttribute:             "uid",
+		Domain:                    "stellar.org",
+		Dial: func(url string) (LDAPConn, error) {
+			if url != "ldap://ldap.example.com" {
+				return nil, errors.New("unknown directory")
+			}
+			return d, nil
+		},
+	}
+}
+
+func newFakeDirectory() *fakeDirectory {
+	scott := ldap.NewEntry("uid=scott,ou=people,dc=stellar,dc=org", map[string][]string{
+		"uid":            {"scott"},
+		"stellarAccount": {"GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG"},
+	})
+	bartek := ldap.NewEntry("uid=bartek,ou=people,dc=stellar,dc=org", map[string][]string{
+		"uid":             {"bartek"},
+		"stellarAccount":  {"GD6WU64OEP5C4LRBH6NK3MHYIA2ADN6K6II6EXPNVUR3ERBXT4AN4ACD"},
+		"stellarMemoType": {"text"},
+		"stellarMemo":     {"bartek"},
+	})
+	return &fakeDirectory{
+		bindDN:   "cn=federation,dc=stellar,dc=org",
+		password: "secret",
+		entries: map[string][]*ldap.Entry{
+			"(&(uid=scott)(stellarDomain=stellar.org))":                                 {scott},
+			"(&(uid=bartek)(stellarDomain=stellar.org))":                                {bartek},
+			"(stellarAccount=GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG)": {scott},
+			"(&(uid=\\2a)(stellarDomain=stellar.org))":                                  {scott, bartek},
+		},
+	}
+}
+
+func TestLDAPDriver(t *testing.T) {
+	d := newFakeDirectory()
+	driver := newLDAPDriver(d)
+	ctx := context.Background()
+
+	rec, err := driver.LookupRecord(ctx, "scott", "stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, &Record{AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG"}, rec)
+	assert.Equal(t, "cn=federation,dc=stellar,dc=org", d.bound)
+	assert.Equal(t, "ou=people,dc=stellar,dc=org", d.requests[0].BaseDN)
+	assert.Equal(t, []string{"stellarAccount", "stellarMemoType", "stellarMemo"}, d.requests[0].Attributes)
+
+	rec, err = driver.LookupRecord(ctx, "bartek", "stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, &Record{AccountID: "GD6WU64OEP5C4LRBH6NK3MHYIA2ADN6K6II6EXPNVUR3ERBXT4AN4ACD", MemoType: "text", Memo: "bartek"}, rec)
+
+	rec, err = driver.LookupRecord(ctx, "jed", "stellar.org")
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+
+	reverseRec, err := driver.LookupReverseRecord(ctx, "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+	require.NoError(t, err)
+	assert.Equal(t, &ReverseRecord{Name: "scott", Domain: "stellar.org"}, reverseRec)
+
+	reverseRec, err = driver.LookupReverseRecord(ctx, "GA3R753JKGXU6ETHNY3U6PYIY7D6UUCXXDYBRF4XURNAGXW3CVGQH2ZA")
+	require.NoError(t, err)
+	assert.Nil(t, reverseRec)
+
+	// every lookup closes its connection
+	assert.Equal(t, len(d.requests), d.closed)
+}
+
+func TestLDAPDriver_errors(t *testing.T) {
+	d := newFakeDirectory()
+	driver := newLDAPDriver(d)
+	ctx := context.Background()
+
+	// filter values are escaped, so a wildcard name doesn't match every user
+	_, err := driver.LookupRecord(ctx, "*", "stellar.org")
+	assert.EqualError(t, err, `more than one ldap entry matches "(&(uid=\\2a)(stellarDomain=stellar.org))"`)
+
+	driver.BindPassword = "wrong"
+	_, err = driver.LookupRecord(ctx, "scott", "stellar.org")
+	assert.EqualError(t, err, "ldap bind: LDAP Result Code 49 \"Invalid Credentials\": invalid credentials")
+
+	driver.URL = "ldap://unknown.example.com"
+	_, err = driver.LookupRecord(ctx, "scott", "stellar.org")
+	assert.EqualError(t, err, "ldap dial: unknown directory")
+
+	driver = newLDAPDriver(d)
+	driver.AccountIDAttribute = "stellarAddress"
+	_, err = driver.LookupRecord(ctx, "scott", "stellar.org")
+	assert.EqualError(t, err, `ldap entry "uid=scott,ou=people,dc=stellar,dc=org" has no stellarAddress attribute`)
+}
+
+func TestLDAPDriver_handler(t *testing.T) {
+	driver := newLDAPDriver(newFakeDirectory())
+	driver.LookupReverseRecordFilter = ""
+	server := httptest.NewServer(t, &Handler{driver})
+	defer server.Close()
+
+	server.GET("/federation").
+		WithQuery("type", "name").
+		WithQuery("q", "scott*stellar.org").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().
+		ValueEqual("account_id", "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+
+	// "id" requests aren't supported without a reverse filter
+	server.GET("/federation").
+		WithQuery("type", "id").
+		WithQuery("q", "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG").
+		Expect().
+		Status(http.StatusNotImplemented).
+		JSON().Object().
+		ValueEqual("code", "not_implemented")
+}
@@ -8,14 +8,22 @@
 //
 // A pre-baked implementation of `Driver` and `ReverseDriver` that provides
 // simple access to SQL systems is included. See `SQLDriver` for more details.
+// `StaticDriver` serves a fixed list of records, such as the ones read from a
+// JSON or YAML file, `HTTPDriver` proxies queries to an upstream service
+// implementing the federation protocol, and `LDAPDriver` searches an LDAP
+// directory. Any driver can be wrapped in a `CachingDriver` to cache its
+// results.
 package federation
 
 import (
 	"context"
 	"database/sql"
+	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
+	"github.com/go-ldap/ldap/v3"
 	"github.com/metriqorg/go/support/db"
 )
 
@@ -122,3 +130,147 @@ type SQLDriver struct {
 	init sync.Once
 	db   *db.Session
 }
+
+// StaticDriver provides a `Driver`, `ReverseDriver` and `ForwardDriver`
+// implementation based upon a fixed list of records, intended for
+// organizations with few enough users to keep them in a file. See
+// `ReadStaticRecords` to read the records from a JSON or YAML file.  Note: once
+// a method is called on the struct the records should be considered frozen.
+type StaticDriver struct {
+	// Records are the records federation queries are evaluated against.
+	Records []StaticRecord
+
+	init           sync.Once
+	records        map[string]*Record
+	reverseRecords map[string]*ReverseRecord
+	forwardRecords []StaticRecord
+}
+
+// StaticRecord represents a record of a `StaticDriver`. A record with a name
+// and domain answers "name" federation requests, and "id" federation requests
+// too unless it has a memo, since an account shared by several users doesn't
+// identify any of them.  A record with a forward type answers "forward"
+// federation requests with that forward type and all of its fields.
+type StaticRecord struct {
+	Name        string            `json:"name" yaml:"name"`
+	Domain      string            `json:"domain" yaml:"domain"`
+	ForwardType string            `json:"forward_type" yaml:"forward_type"`
+	Fields      map[string]string `json:"fields" yaml:"fields"`
+	AccountID   string            `json:"account_id" yaml:"account_id"`
+	MemoType    string            `json:"memo_type" yaml:"memo_type"`
+	Memo        string            `json:"memo" yaml:"memo"`
+}
+
+// HTTPDriver provides a `Driver`, `ReverseDriver` and `ForwardDriver`
+// implementation that proxies federation queries to an upstream service, such
+// as an internal user service, that implements the federation protocol.  Not
+// found responses of the upstream service are returned as a nil record, and
+// other error responses are passed to the end user.
+type HTTPDriver struct {
+	// URL is the federation endpoint of the upstream service, such as
+	// "http://users.internal/federation".
+	URL string
+
+	// HTTP is the client used to make requests to the upstream service.  If nil,
+	// `http.DefaultClient` is used.
+	HTTP HTTP
+}
+
+// HTTP represents the http client that an `HTTPDriver` uses to make http
+// requests.
+type HTTP interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// LDAPDriver provides a `Driver` and `ReverseDriver` implementation based upon
+// an LDAP directory, such as the directory of the users of an organization.
+// Every lookup connects to the directory, binds and performs a search, so
+// wrapping the driver in a `CachingDriver` is recommended.  Note: once a method
+// is called on the struct the public fields of this struct should be
+// considered frozen.
+type LDAPDriver struct {
+	// URL is the URL of the directory, such as "ldaps://ldap.example.com".
+	URL string
+
+	// BindDN is the DN to bind as before searching, such as
+	// "cn=federation,dc=example,dc=com".  If empty, searches are anonymous.
+	BindDN string
+
+	// BindPassword is the password of `BindDN`.
+	BindPassword string
+
+	// BaseDN is the DN searches are performed under, such as
+	// "ou=people,dc=example,dc=com".
+	BaseDN string
+
+	// LookupRecordFilter is an LDAP filter used for performing "name" federation
+	// queries, such as "(&(uid={name})(stellarDomain={domain}))".  The "{name}"
+	// and "{domain}" placeholders are replaced with the escaped name and domain
+	// portions of the stellar address.
+	LookupRecordFilter string
+
+	// LookupReverseRecordFilter is an LDAP filter used for performing "reverse"
+	// federation queries, such as "(stellarAccount={account_id})".  The
+	// "{account_id}" placeholder is replaced with the escaped strkey-encoded
+	// account id.  If empty, "id" federation requests are not supported.
+	LookupReverseRecordFilter string
+
+	// AccountIDAttribute is the attribute of the entries holding their stellar
+	// account id.
+	AccountIDAttribute string
+
+	// MemoTypeAttribute and MemoAttribute are the attributes of the entries
+	// holding their memo type and memo, if any.
+	MemoTypeAttribute string
+	MemoAttribute     string
+
+	// NameAttribute is the attribute of the entries holding the name portion of
+	// their stellar address, used by "reverse" federation queries.
+	NameAttribute string
+
+	// DomainAttribute is the attribute of the entries holding the domain portion
+	// of their stellar address, used by "reverse" federation queries.  If empty,
+	// `Domain` is used for all entries.
+	DomainAttribute string
+	Domain          string
+
+	// Dial connects to the directory at `URL`.  If nil, `ldap.DialURL` is used.
+	Dial func(url string) (LDAPConn, error)
+}
+
+// LDAPConn represents the connection to a directory that an `LDAPDriver`
+// performs searches with.
+type LDAPConn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// CachingDriver provides a `Driver`, `ReverseDriver` and `ForwardDriver`
+// implementation that caches the results of another driver in memory, so that
+// repeated lookups of the same record don't reach the backend of that driver.
+// Errors are never cached.  Note: once a method is called on the struct the
+// public fields of this struct should be considered frozen.
+type CachingDriver struct {
+	// Driver is the driver whose results are cached.  "id" and "forward"
+	// federation requests are only supported if it also implements
+	// `ReverseDriver` or `ForwardDriver` respectively.
+	Driver Driver
+
+	// TTL is how long the records found are cached.  If zero, they are not
+	// cached.
+	TTL time.Duration
+
+	// NegativeTTL is how long lookups that found no record are cached.  If zero,
+	// they are not cached.
+	NegativeTTL time.Duration
+
+	// MaxEntries is the maximum number of results cached.  If zero,
+	// `DefaultCacheMaxEntries` is used.
+	MaxEntries int
+
+	init    sync.Once
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
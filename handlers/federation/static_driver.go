@@ -0,0 +1,156 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/support/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ReadStaticRecords reads the list of records of a `StaticDriver` from the
+// JSON or YAML file at `path`, depending on its extension, and validates them.
+func ReadStaticRecords(path string) ([]StaticRecord, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading static records")
+	}
+
+	var records []StaticRecord
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(bs, &records)
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(bs, &records)
+	default:
+		return nil, errors.Errorf("static records file extension %q unrecognized, use .json, .yaml or .yml", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing static records")
+	}
+
+	for i, r := range records {
+		err = r.validate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "validating static record %d", i)
+		}
+	}
+
+	return records, nil
+}
+
+func (r StaticRecord) validate() error {
+	if !strkey.IsValidEd25519PublicKey(r.AccountID) {
+		return errors.Errorf("account_id %q is not a valid Stellar account", r.AccountID)
+	}
+
+	switch r.MemoType {
+	case "":
+		if r.Memo != "" {
+			return errors.New("memo is set but memo_type is not")
+		}
+	case "id", "text", "hash":
+		if r.Memo == "" {
+			return errors.Errorf("memo_type is %q but memo is not set", r.MemoType)
+		}
+	default:
+		return errors.Errorf("memo_type %q unrecognized", r.MemoType)
+	}
+
+	if r.ForwardType == "" && (r.Name == "" || r.Domain == "") {
+		return errors.New("either name and domain or forward_type are required")
+	}
+	if r.ForwardType != "" && (r.Name != "" || r.Domain != "") {
+		return errors.New("forward_type cannot be set with name and domain")
+	}
+	if r.ForwardType == "" && len(r.Fields) > 0 {
+		return errors.New("fields are set but forward_type is not")
+	}
+
+	return nil
+}
+
+// LookupRecord implements `Driver` by looking up the record with the provided
+// name and domain in `drv.Records`.
+func (drv *StaticDriver) LookupRecord(ctx context.Context, name, domain string) (*Record, error) {
+	drv.initRecords()
+	return drv.records[recordKey(name, domain)], nil
+}
+
+// LookupReverseRecord implements `ReverseDriver` by looking up the first
+// record with the provided account id and no memo in `drv.Records`.
+func (drv *StaticDriver) LookupReverseRecord(ctx context.Context, accountID string) (*ReverseRecord, error) {
+	drv.initRecords()
+	return drv.reverseRecords[accountID], nil
+}
+
+// LookupForwardingRecord implements `ForwardDriver` by looking up the first
+// record in `drv.Records` with the forward type of the query whose fields all
+// match the query.
+func (drv *StaticDriver) LookupForwardingRecord(query url.Values) (*Record, error) {
+	drv.initRecords()
+	forwardType := query.Get("forward_type")
+	for _, r := range drv.forwardRecords {
+		if r.ForwardType != forwardType || !r.matches(query) {
+			continue
+		}
+		return r.record(), nil
+	}
+	return nil, nil
+}
+
+var _ Driver = &StaticDriver{}
+var _ ReverseDriver = &StaticDriver{}
+var _ ForwardDriver = &StaticDriver{}
+
+func (drv *StaticDriver) initRecords() {
+	drv.init.Do(func() {
+		drv.records = map[string]*Record{}
+		drv.reverseRecords = map[string]*ReverseRecord{}
+		for _, r := range drv.Records {
+			if r.ForwardType != "" {
+				drv.forwardRecords = append(drv.forwardRecords, r)
+				continue
+			}
+
+			key := recordKey(r.Name, r.Domain)
+			if _, ok := drv.records[key]; !ok {
+				drv.records[key] = r.record()
+			}
+			if _, ok := drv.reverseRecords[r.AccountID]; !ok && r.Memo == "" {
+				drv.reverseRecords[r.AccountID] = &ReverseRecord{
+					Name:   r.Name,
+					Domain: r.Domain,
+				}
+			}
+		}
+	})
+}
+
+func (r StaticRecord) record() *Record {
+	return &Record{
+		AccountID: r.AccountID,
+		MemoType:  r.MemoType,
+		Memo:      r.Memo,
+	}
+}
+
+func (r StaticRecord) matches(query url.Values) bool {
+	for k, v := range r.Fields {
+		if query.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// recordKey returns the key of a record with the provided name and
+// domain, ignoring the case of the domain since domains are case insensitive.
+func recordKey(name, domain string) string {
+	return name + "*" + strings.ToLower(domain)
+}
@@ -0,0 +1,212 @@
+package federation
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/metriqorg/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadStaticRecords(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "records.yaml")
+	err := ioutil.WriteFile(yamlPath, []byte(`
+- name: scott
+  domain: stellar.org
+  account_id: GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG
+- forward_type: bank_account
+  fields:
+    acct: "1234"
+  account_id: GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG
+  memo_type: id
+  memo: "1"
+`), 0600)
+	require.NoError(t, err)
+
+	jsonPath := filepath.Join(dir, "records.json")
+	err = ioutil.WriteFile(jsonPath, []byte(`[
+  {"name": "scott", "domain": "stellar.org", "account_id": "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG"},
+  {"forward_type": "bank_account", "fields": {"acct": "1234"}, "account_id": "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG", "memo_type": "id", "memo": "1"}
+]`), 0600)
+	require.NoError(t, err)
+
+	wantRecords := []StaticRecord{
+		{
+			Name:      "scott",
+			Domain:    "stellar.org",
+			AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG",
+		},
+		{
+			ForwardType: "bank_account",
+			Fields:      map[string]string{"acct": "1234"},
+			AccountID:   "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG",
+			MemoType:    "id",
+			Memo:        "1",
+		},
+	}
+
+	records, err := ReadStaticRecords(yamlPath)
+	require.NoError(t, err)
+	assert.Equal(t, wantRecords, records)
+
+	records, err = ReadStaticRecords(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, wantRecords, records)
+}
+
+func TestReadStaticRecords_invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unrecognized extension",
+			file:    "records.toml",
+			content: ``,
+			wantErr: `static records file extension ".toml" unrecognized, use .json, .yaml or .yml`,
+		},
+		{
+			name:    "unknown field",
+			file:    "records.yaml",
+			content: `[{name: scott, domain: stellar.org, account: GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG}]`,
+			wantErr: "parsing static records: yaml: unmarshal errors:\n  line 1: field account not found in type federation.StaticRecord",
+		},
+		{
+			name:    "invalid account",
+			file:    "records.json",
+			content: `[{"name": "scott", "domain": "stellar.org", "account_id": "GABC"}]`,
+			wantErr: `validating static record 0: account_id "GABC" is not a valid Stellar account`,
+		},
+		{
+			name:    "missing memo",
+			file:    "records.json",
+			content: `[{"name": "scott", "domain": "stellar.org", "account_id": "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG", "memo_type": "text"}]`,
+			wantErr: `validating static record 0: memo_type is "text" but memo is not set`,
+		},
+		{
+			name:    "missing domain",
+			file:    "records.json",
+			content: `[{"name": "scott", "account_id": "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG"}]`,
+			wantErr: "validating static record 0: either name and domain or forward_type are required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.file)
+			err := ioutil.WriteFile(path, []byte(tc.content), 0600)
+			require.NoError(t, err)
+
+			_, err = ReadStaticRecords(path)
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestStaticDriver(t *testing.T) {
+	driver := &StaticDriver{
+		Records: []StaticRecord{
+			{
+				Name:      "scott",
+				Domain:    "stellar.org",
+				AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG",
+			},
+			{
+				Name:      "bartek",
+				Domain:    "stellar.org",
+				AccountID: "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3",
+				MemoType:  "text",
+				Memo:      "bartek",
+			},
+			{
+				ForwardType: "bank_account",
+				Fields:      map[string]string{"acct": "1234"},
+				AccountID:   "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3",
+				MemoType:    "id",
+				Memo:        "1",
+			},
+		},
+	}
+	ctx := context.Background()
+
+	rec, err := driver.LookupRecord(ctx, "scott", "Stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, &Record{AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG"}, rec)
+
+	rec, err = driver.LookupRecord(ctx, "jed", "stellar.org")
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+
+	reverseRec, err := driver.LookupReverseRecord(ctx, "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+	require.NoError(t, err)
+	assert.Equal(t, &ReverseRecord{Name: "scott", Domain: "stellar.org"}, reverseRec)
+
+	// records with a memo share their account so they can't be reverse looked up
+	reverseRec, err = driver.LookupReverseRecord(ctx, "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3")
+	require.NoError(t, err)
+	assert.Nil(t, reverseRec)
+
+	rec, err = driver.LookupForwardingRecord(url.Values{"type": {"forward"}, "forward_type": {"bank_account"}, "acct": {"1234"}})
+	require.NoError(t, err)
+	assert.Equal(t, &Record{AccountID: "GCYMGWPZ6NC2U7SO6SMXOP5ZLXOEC5SYPKITDMVEONLCHFSCCQR2J4S3", MemoType: "id", Memo: "1"}, rec)
+
+	rec, err = driver.LookupForwardingRecord(url.Values{"type": {"forward"}, "forward_type": {"bank_account"}, "acct": {"4321"}})
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+
+	rec, err = driver.LookupForwardingRecord(url.Values{"type": {"forward"}, "forward_type": {"card"}, "acct": {"1234"}})
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+}
+
+func TestStaticDriver_handler(t *testing.T) {
+	driver := &StaticDriver{
+		Records: []StaticRecord{
+			{
+				Name:      "scott",
+				Domain:    "stellar.org",
+				AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG",
+			},
+		},
+	}
+
+	handler := &Handler{driver}
+	server := httptest.NewServer(t, handler)
+	defer server.Close()
+
+	server.GET("/federation").
+		WithQuery("type", "name").
+		WithQuery("q", "scott*stellar.org").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().
+		ValueEqual("account_id", "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG")
+
+	server.GET("/federation").
+		WithQuery("type", "id").
+		WithQuery("q", "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().
+		ValueEqual("stellar_address", "scott*stellar.org")
+
+	server.GET("/federation").
+		WithQuery("type", "forward").
+		WithQuery("forward_type", "bank_account").
+		WithQuery("acct", "1234").
+		Expect().
+		Status(http.StatusNotFound).
+		JSON().Object().
+		ValueEqual("code", "not_found")
+}
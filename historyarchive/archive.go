@@ -45,12 +45,40 @@ type ConnectOptions struct {
 	NetworkPassphrase string
 	S3Region          string
 	S3Endpoint        string
-	UnsignedRequests  bool
+	// S3VirtualHostedStyle addresses S3 buckets as a subdomain of the
+	// endpoint instead of as the first element of the path.
+	S3VirtualHostedStyle bool
+	// S3AccessKeyID, S3SecretAccessKey and S3SessionToken are static S3
+	// credentials. If unset, credentials are looked up in the environment,
+	// shared credentials file and instance role like the AWS CLI does.
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3SessionToken    string
+	// GCSEndpoint overrides the Google Cloud Storage endpoint, e.g. to use an
+	// emulator.
+	GCSEndpoint string
+	// GCSCredentialsFile is a service account key file. If unset, Application
+	// Default Credentials are used.
+	GCSCredentialsFile string
+	// AzureEndpoint overrides the Azure Blob Storage endpoint of the account,
+	// e.g. http://127.0.0.1:10000/devstoreaccount1 to use Azurite.
+	AzureEndpoint string
+	// AzureAccountKey is the base64 encoded account key used to sign Azure
+	// requests. AzureSASToken is used if it is unset, and requests are
+	// anonymous if both are unset.
+	AzureAccountKey  string
+	AzureSASToken    string
+	UnsignedRequests bool
 	// CheckpointFrequency is the number of ledgers between checkpoints
 	// if unset, DefaultCheckpointFrequency will be used
 	CheckpointFrequency uint32
 	// UserAgent is the value of `User-Agent` header. Applicable only for HTTP client.
 	UserAgent string
+	// Cache configures an on-disk cache of the archive files. Files are not
+	// cached if Cache.Path is empty.
+	Cache CacheOptions
+	// Pool configures the ArchivePool returned by NewArchivePool.
+	Pool PoolOptions
 }
 
 type Ledger struct {
@@ -117,6 +145,11 @@ type Archive struct {
 	backend ArchiveBackend
 }
 
+// Backend returns the storage backend the archive reads from and writes to.
+func (arch *Archive) Backend() ArchiveBackend {
+	return arch.backend
+}
+
 func (arch *Archive) GetCheckpointManager() CheckpointManager {
 	return arch.checkpointManager
 }
@@ -416,6 +449,20 @@ func Connect(u string, opts ConnectOptions) (*Archive, error) {
 			pth = pth[1:]
 		}
 		arch.backend, err = makeS3Backend(parsed.Host, pth, opts)
+	} else if parsed.Scheme == "gcs" {
+		arch.backend, err = makeGCSBackend(parsed.Host, strings.TrimPrefix(pth, "/"), opts)
+	} else if parsed.Scheme == "azure" {
+		// azure://account/container/prefix
+		parts := strings.SplitN(strings.TrimPrefix(pth, "/"), "/", 2)
+		if parts[0] == "" {
+			err = errors.New("Azure URL has no container: '" + u + "'")
+		} else {
+			prefix := ""
+			if len(parts) == 2 {
+				prefix = parts[1]
+			}
+			arch.backend, err = makeAzureBackend(parsed.Host, parts[0], prefix, opts)
+		}
 	} else if parsed.Scheme == "file" {
 		pth = path.Join(parsed.Host, pth)
 		arch.backend = makeFsBackend(pth, opts)
@@ -426,6 +473,9 @@ func Connect(u string, opts ConnectOptions) (*Archive, error) {
 	} else {
 		err = errors.New("unknown URL scheme: '" + parsed.Scheme + "'")
 	}
+	if err == nil && opts.Cache.Path != "" {
+		arch.backend, err = makeCachedBackend(u, arch.backend, opts.Cache)
+	}
 	return &arch, err
 }
 
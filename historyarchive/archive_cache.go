@@ -0,0 +1,456 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// DefaultCacheMaxSize is the maximum size of a cache when
+// CacheOptions.MaxSize is zero.
+const DefaultCacheMaxSize = 10 << 30
+
+// DefaultRootHASCacheExpiry is how long the root HAS is cached when
+// CacheOptions.RootHASExpiry is zero.
+const DefaultRootHASCacheExpiry = time.Minute
+
+// cacheTmpDir is the directory inside the cache where files are downloaded
+// before they are verified and moved into place.
+const cacheTmpDir = ".tmp"
+
+var (
+	cacheBucketPathRx     = regexp.MustCompile("^bucket" + hexPrefixPat + "bucket-([0-9a-f]{64})\\.xdr\\.gz$")
+	cacheCheckpointPathRx = regexp.MustCompile("^(history|ledger|transactions|results|scp)" + hexPrefixPat + "[a-z]+-[0-9a-f]{8}\\.(json|xdr\\.gz)$")
+)
+
+// CacheOptions configures the on-disk cache of the files of an archive.
+type CacheOptions struct {
+	// Path is the directory the cached files are stored in. Caching is
+	// disabled when it is empty.
+	Path string
+	// MaxSize is the maximum total size in bytes of the cached files. The
+	// least recently used files are evicted when it is exceeded.
+	// DefaultCacheMaxSize is used when zero.
+	MaxSize int64
+	// RootHASExpiry is how long the root HAS is cached, since it changes
+	// every checkpoint. DefaultRootHASCacheExpiry is used when zero.
+	RootHASExpiry time.Duration
+	// Registry, if set, is used to register hit, miss and eviction counters
+	// labeled with the archive URL.
+	Registry *prometheus.Registry
+	// Namespace is the namespace of the metrics registered in Registry.
+	Namespace string
+}
+
+// CacheStats are the counters of a cache since it was created.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Size is the total size in bytes of the cached files.
+	Size int64
+}
+
+type cacheEntry struct {
+	path     string
+	size     int64
+	cachedAt time.Time
+}
+
+// ArchiveBackendCache is an ArchiveBackend that stores the files it gets from
+// another backend on disk, evicting the least recently used files when the
+// cache exceeds its maximum size. Files are only cached if they never change
+// once published: buckets, checkpoint files and, for a short time, the root
+// HAS. Other files are read from the underlying backend.
+type ArchiveBackendCache struct {
+	backend ArchiveBackend
+	dir     string
+	maxSize int64
+	expiry  time.Duration
+
+	mutex   sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	size    int64
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// MakeArchiveBackendCache returns a cache of backend storing files in
+// opts.Path. Files left in the directory by a previous cache are reused.
+func MakeArchiveBackendCache(backend ArchiveBackend, opts CacheOptions) (*ArchiveBackendCache, error) {
+	if opts.Path == "" {
+		return nil, errors.New("cache path is empty")
+	}
+	c := &ArchiveBackendCache{
+		backend: backend,
+		dir:     opts.Path,
+		maxSize: opts.MaxSize,
+		expiry:  opts.RootHASExpiry,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+	if c.maxSize == 0 {
+		c.maxSize = DefaultCacheMaxSize
+	}
+	if c.expiry == 0 {
+		c.expiry = DefaultRootHASCacheExpiry
+	}
+
+	if err := os.RemoveAll(filepath.Join(c.dir, cacheTmpDir)); err != nil {
+		return nil, errors.Wrap(err, "could not clean cache temporary directory")
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, cacheTmpDir), 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create cache directory")
+	}
+	if err := c.load(); err != nil {
+		return nil, errors.Wrap(err, "could not load cache")
+	}
+	return c, nil
+}
+
+// load adds the files already in the cache directory to the LRU, the most
+// recently modified files being the most recently used.
+func (c *ArchiveBackendCache) load() error {
+	entries := []cacheEntry{}
+	err := filepath.Walk(c.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == cacheTmpDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(c.dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if cacheable(rel) {
+			entries = append(entries, cacheEntry{path: rel, size: info.Size(), cachedAt: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].cachedAt.Before(entries[j].cachedAt)
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, e := range entries {
+		c.add(e)
+	}
+	c.evict()
+	return nil
+}
+
+// makeCachedBackend wraps the backend of the archive at archiveURL in a cache
+// stored in a subdirectory of opts.Path named after the URL, so that archives
+// of a pool can share a cache path.
+func makeCachedBackend(archiveURL string, backend ArchiveBackend, opts CacheOptions) (ArchiveBackend, error) {
+	opts.Path = filepath.Join(opts.Path, cacheDirName(archiveURL))
+	cache, err := MakeArchiveBackendCache(backend, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Registry != nil {
+		err = cache.RegisterMetrics(opts.Registry, opts.Namespace, prometheus.Labels{"archive": archiveURL})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not register cache metrics")
+		}
+	}
+	return cache, nil
+}
+
+var cacheDirNameRx = regexp.MustCompile("[^A-Za-z0-9.-]+")
+
+// cacheDirName returns a directory name for the archive at archiveURL.
+func cacheDirName(archiveURL string) string {
+	return strings.Trim(cacheDirNameRx.ReplaceAllString(archiveURL, "_"), "_")
+}
+
+// CacheStats returns the counters of the cache of the archive, and false if
+// the archive is not cached.
+func (a *Archive) CacheStats() (CacheStats, bool) {
+	if cache, ok := a.backend.(*ArchiveBackendCache); ok {
+		return cache.Stats(), true
+	}
+	return CacheStats{}, false
+}
+
+// cacheable returns true if the file at pth never changes once published, or
+// is the root HAS.
+func cacheable(pth string) bool {
+	return pth == rootHASPath || cacheBucketPathRx.MatchString(pth) || cacheCheckpointPathRx.MatchString(pth)
+}
+
+// Stats returns the counters of the cache.
+func (c *ArchiveBackendCache) Stats() CacheStats {
+	c.mutex.Lock()
+	size := c.size
+	c.mutex.Unlock()
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Size:      size,
+	}
+}
+
+// RegisterMetrics registers the counters of the cache in registry.
+func (c *ArchiveBackendCache) RegisterMetrics(registry *prometheus.Registry, namespace string, labels prometheus.Labels) error {
+	counter := func(name, help string, v *uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "history_archive_cache", Name: name, Help: help,
+			ConstLabels: labels,
+		}, func() float64 { return float64(atomic.LoadUint64(v)) })
+	}
+	collectors := []prometheus.Collector{
+		counter("hits_total", "number of files read from the cache", &c.hits),
+		counter("misses_total", "number of files downloaded into the cache", &c.misses),
+		counter("evictions_total", "number of files evicted from the cache", &c.evictions),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "history_archive_cache", Name: "size_bytes",
+			Help: "total size of the cached files", ConstLabels: labels,
+		}, func() float64 { return float64(c.Stats().Size) }),
+	}
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ArchiveBackendCache) GetFile(pth string) (io.ReadCloser, error) {
+	if !cacheable(pth) {
+		return c.backend.GetFile(pth)
+	}
+
+	if f, ok := c.open(pth); ok {
+		atomic.AddUint64(&c.hits, 1)
+		log.WithField("path", pth).Trace("cache: hit")
+		return f, nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+	log.WithField("path", pth).Trace("cache: miss")
+	return c.fill(pth)
+}
+
+// open opens the cached file at pth and marks it as recently used.
+func (c *ArchiveBackendCache) open(pth string) (io.ReadCloser, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[pth]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(cacheEntry)
+	if pth == rootHASPath && time.Since(entry.cachedAt) > c.expiry {
+		c.remove(elem)
+		return nil, false
+	}
+	f, err := os.Open(c.localPath(pth))
+	if err != nil {
+		// The file was removed from under the cache.
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return f, true
+}
+
+// fill downloads the file at pth, verifies it, adds it to the cache and
+// returns it opened. The returned file stays readable if it is evicted right
+// away, for example because it is larger than the cache.
+func (c *ArchiveBackendCache) fill(pth string) (io.ReadCloser, error) {
+	in, err := c.backend.GetFile(pth)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Join(c.dir, cacheTmpDir), "fill-")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create cache file")
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, in)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not download %s", pth)
+	}
+	if err = verifyArchiveFile(pth, tmp.Name()); err != nil {
+		return nil, errors.Wrapf(err, "could not verify %s", pth)
+	}
+
+	local := c.localPath(pth)
+	if err = os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create cache directory")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err = os.Rename(tmp.Name(), local); err != nil {
+		return nil, errors.Wrap(err, "could not move file into cache")
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open cached file")
+	}
+	if elem, ok := c.entries[pth]; ok {
+		c.size -= elem.Value.(cacheEntry).size
+		c.lru.Remove(elem)
+		delete(c.entries, pth)
+	}
+	c.add(cacheEntry{path: pth, size: size, cachedAt: time.Now()})
+	c.evict()
+	return f, nil
+}
+
+// verifyArchiveFile checks the integrity of a downloaded file: the hash of a
+// bucket must match its name, gzipped files must decompress and JSON files
+// must be a valid HAS.
+func verifyArchiveFile(pth, local string) error {
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(pth, ".json") {
+		var has HistoryArchiveState
+		return json.NewDecoder(f).Decode(&has)
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, gz); err != nil {
+		return err
+	}
+
+	if m := cacheBucketPathRx.FindStringSubmatch(pth); m != nil {
+		expected, err := hex.DecodeString(m[1])
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(h.Sum(nil), expected) {
+			return errors.New("bucket hash does not match")
+		}
+	}
+	return nil
+}
+
+// add adds an entry to the front of the LRU. The caller must hold the mutex.
+func (c *ArchiveBackendCache) add(e cacheEntry) {
+	c.entries[e.path] = c.lru.PushFront(e)
+	c.size += e.size
+}
+
+// evict removes the least recently used files until the cache fits its
+// maximum size. The caller must hold the mutex.
+func (c *ArchiveBackendCache) evict() {
+	for c.size > c.maxSize && c.lru.Len() > 0 {
+		elem := c.lru.Back()
+		log.WithField("path", elem.Value.(cacheEntry).path).Trace("cache: evict")
+		c.remove(elem)
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+// remove removes an entry and its file. The caller must hold the mutex.
+func (c *ArchiveBackendCache) remove(elem *list.Element) {
+	e := elem.Value.(cacheEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, e.path)
+	c.size -= e.size
+	if err := os.Remove(c.localPath(e.path)); err != nil && !os.IsNotExist(err) {
+		log.WithField("path", e.path).WithError(err).Warn("cache: could not remove file")
+	}
+}
+
+func (c *ArchiveBackendCache) localPath(pth string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(path.Clean(pth)))
+}
+
+func (c *ArchiveBackendCache) cached(pth string) (cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[pth]
+	if !ok || pth == rootHASPath {
+		return cacheEntry{}, false
+	}
+	return elem.Value.(cacheEntry), true
+}
+
+func (c *ArchiveBackendCache) Exists(pth string) (bool, error) {
+	if _, ok := c.cached(pth); ok {
+		return true, nil
+	}
+	return c.backend.Exists(pth)
+}
+
+func (c *ArchiveBackendCache) Size(pth string) (int64, error) {
+	if e, ok := c.cached(pth); ok {
+		return e.size, nil
+	}
+	return c.backend.Size(pth)
+}
+
+// PutFile writes the file to the underlying backend and removes any cached
+// copy of it.
+func (c *ArchiveBackendCache) PutFile(pth string, in io.ReadCloser) error {
+	err := c.backend.PutFile(pth, in)
+	c.mutex.Lock()
+	if elem, ok := c.entries[pth]; ok {
+		c.remove(elem)
+	}
+	c.mutex.Unlock()
+	return err
+}
+
+func (c *ArchiveBackendCache) ListFiles(pth string) (chan string, chan error) {
+	return c.backend.ListFiles(pth)
+}
+
+func (c *ArchiveBackendCache) CanListFiles() bool {
+	return c.backend.CanListFiles()
+}
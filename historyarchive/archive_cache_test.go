@@ -0,0 +1,171 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, buf []byte) []byte {
+	var out bytes.Buffer
+	w := gzip.NewWriter(&out)
+	_, err := w.Write(buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return out.Bytes()
+}
+
+// putGzippedBucket puts a random bucket of size bytes in the backend and
+// returns its hash and compressed size.
+func putGzippedBucket(t *testing.T, backend ArchiveBackend, size int) (Hash, int64) {
+	buf := make([]byte, size)
+	_, err := rand.Read(buf)
+	require.NoError(t, err)
+	hash := Hash(sha256.Sum256(buf))
+	gz := gzipBytes(t, buf)
+	require.NoError(t, backend.PutFile(BucketPath(hash), ioutil.NopCloser(bytes.NewReader(gz))))
+	return hash, int64(len(gz))
+}
+
+func putGzippedBucketPath(t *testing.T, backend ArchiveBackend, size int) (string, int64) {
+	hash, sz := putGzippedBucket(t, backend, size)
+	return BucketPath(hash), sz
+}
+
+func readCached(t *testing.T, cache *ArchiveBackendCache, pth string) []byte {
+	f, err := cache.GetFile(pth)
+	require.NoError(t, err)
+	defer f.Close()
+	buf, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	return buf
+}
+
+func TestArchiveCacheHitsAndMisses(t *testing.T) {
+	backend := makeMockBackend(ConnectOptions{})
+	cache, err := MakeArchiveBackendCache(backend, CacheOptions{Path: t.TempDir()})
+	require.NoError(t, err)
+
+	pth, size := putGzippedBucketPath(t, backend, 1024)
+	first := readCached(t, cache, pth)
+	second := readCached(t, cache, pth)
+	assert.Equal(t, first, second)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Size: size}, cache.Stats())
+
+	// Cached files are served even if the backend loses them.
+	backend.(*MockArchiveBackend).files = map[string][]byte{}
+	assert.Equal(t, first, readCached(t, cache, pth))
+	ok, err := cache.Exists(pth)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	sz, err := cache.Size(pth)
+	require.NoError(t, err)
+	assert.Equal(t, size, sz)
+
+	// Files that are not immutable are never cached.
+	require.NoError(t, cache.PutFile("other/file.txt", ioutil.NopCloser(bytes.NewReader([]byte("a")))))
+	assert.Equal(t, []byte("a"), readCached(t, cache, "other/file.txt"))
+	assert.Equal(t, uint64(1), cache.Stats().Misses)
+}
+
+func TestArchiveCacheEviction(t *testing.T) {
+	backend := makeMockBackend(ConnectOptions{})
+	dir := t.TempDir()
+	pth1, size := putGzippedBucketPath(t, backend, 4096)
+	pth2, _ := putGzippedBucketPath(t, backend, 4096)
+	pth3, _ := putGzippedBucketPath(t, backend, 4096)
+
+	cache, err := MakeArchiveBackendCache(backend, CacheOptions{Path: dir, MaxSize: 2*size + size/2})
+	require.NoError(t, err)
+
+	readCached(t, cache, pth1)
+	readCached(t, cache, pth2)
+	readCached(t, cache, pth1)
+	readCached(t, cache, pth3)
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.LessOrEqual(t, stats.Size, 2*size+size/2)
+
+	// pth2 was the least recently used file.
+	readCached(t, cache, pth1)
+	readCached(t, cache, pth3)
+	readCached(t, cache, pth2)
+	stats = cache.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(4), stats.Misses)
+
+	// A new cache reuses the files on disk.
+	cache, err = MakeArchiveBackendCache(backend, CacheOptions{Path: dir, MaxSize: 2*size + size/2})
+	require.NoError(t, err)
+	readCached(t, cache, pth2)
+	assert.Equal(t, CacheStats{Hits: 1, Size: 2 * size}, cache.Stats())
+}
+
+func TestArchiveCacheRejectsCorruptFiles(t *testing.T) {
+	backend := makeMockBackend(ConnectOptions{})
+	cache, err := MakeArchiveBackendCache(backend, CacheOptions{Path: t.TempDir()})
+	require.NoError(t, err)
+
+	pth, _ := putGzippedBucketPath(t, backend, 1024)
+	backend.(*MockArchiveBackend).files[pth] = gzipBytes(t, []byte("tampered"))
+	_, err = cache.GetFile(pth)
+	assert.EqualError(t, err, "could not verify "+pth+": bucket hash does not match")
+
+	ledger := CategoryCheckpointPath("ledger", 63)
+	backend.(*MockArchiveBackend).files[ledger] = []byte("not gzipped")
+	_, err = cache.GetFile(ledger)
+	assert.Error(t, err)
+
+	assert.Equal(t, CacheStats{Misses: 2}, cache.Stats())
+}
+
+func TestArchiveCacheRootHASExpiry(t *testing.T) {
+	backend := makeMockBackend(ConnectOptions{})
+	has, err := json.Marshal(HistoryArchiveState{CurrentLedger: 63})
+	require.NoError(t, err)
+	backend.(*MockArchiveBackend).files[rootHASPath] = has
+
+	cache, err := MakeArchiveBackendCache(backend, CacheOptions{Path: t.TempDir(), RootHASExpiry: time.Hour})
+	require.NoError(t, err)
+	readCached(t, cache, rootHASPath)
+	readCached(t, cache, rootHASPath)
+	assert.Equal(t, uint64(1), cache.Stats().Hits)
+
+	cache, err = MakeArchiveBackendCache(backend, CacheOptions{Path: t.TempDir(), RootHASExpiry: time.Nanosecond})
+	require.NoError(t, err)
+	readCached(t, cache, rootHASPath)
+	time.Sleep(time.Millisecond)
+	readCached(t, cache, rootHASPath)
+	assert.Equal(t, CacheStats{Misses: 2, Size: int64(len(has))}, cache.Stats())
+}
+
+func TestConnectWithCache(t *testing.T) {
+	arch, err := Connect("mock://test", ConnectOptions{
+		CheckpointFrequency: 64,
+		Cache:               CacheOptions{Path: t.TempDir()},
+	})
+	require.NoError(t, err)
+
+	hash, _ := putGzippedBucket(t, arch.backend, 1024)
+	_, err = arch.GetXdrStreamForHash(hash)
+	require.NoError(t, err)
+	_, err = arch.GetXdrStreamForHash(hash)
+	require.NoError(t, err)
+
+	stats, ok := arch.CacheStats()
+	require.True(t, ok)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+}
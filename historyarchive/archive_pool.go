@@ -6,14 +6,89 @@ package historyarchive
 
 import (
 	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/metriqorg/go/support/errors"
 	"github.com/metriqorg/go/xdr"
 )
 
-// A ArchivePool is just a collection of `ArchiveInterface`s so that we can
-// distribute requests fairly throughout the pool.
-type ArchivePool []ArchiveInterface
+const (
+	// DefaultQuarantineDuration is how long an archive that served a corrupt
+	// file is left out of the pool when PoolOptions.QuarantineDuration is
+	// zero.
+	DefaultQuarantineDuration = 10 * time.Minute
+
+	// poolEWMAWeight is the weight of the latest request in the moving
+	// averages of the latency and error rate of an archive.
+	poolEWMAWeight = 0.2
+	// poolErrorPenalty is how much slower than a healthy archive an archive
+	// failing every request is considered to be.
+	poolErrorPenalty = 10
+	// poolMinLatency is the latency of archives that have not served any
+	// request yet, so that they are tried early.
+	poolMinLatency = time.Millisecond
+)
+
+// PoolOptions configures how an ArchivePool picks archives.
+type PoolOptions struct {
+	// MaxAttempts is the number of archives a failed read is attempted on.
+	// All the archives of the pool are attempted when zero.
+	MaxAttempts int
+	// HedgeDelay enables hedged bucket downloads: if an archive has not
+	// started sending a bucket after HedgeDelay the bucket is requested from
+	// another archive as well, and the first response is used. Hedging is
+	// disabled when zero.
+	HedgeDelay time.Duration
+	// QuarantineDuration is how long an archive that served a file failing
+	// hash verification is not used. DefaultQuarantineDuration is used when
+	// zero.
+	QuarantineDuration time.Duration
+}
+
+// ArchiveStats are the health statistics of an archive of a pool.
+type ArchiveStats struct {
+	URL      string
+	Requests uint64
+	Errors   uint64
+	// ErrorRate is the moving average of the share of failed requests.
+	ErrorRate float64
+	// Latency is the moving average of the latency of successful requests.
+	Latency time.Duration
+	// QuarantinedUntil is set if the archive served a corrupt file and is
+	// not used until then.
+	QuarantinedUntil time.Time
+}
+
+// score returns the expected cost of a request to the archive, lower being
+// better.
+func (s ArchiveStats) score() float64 {
+	latency := s.Latency
+	if latency < poolMinLatency {
+		latency = poolMinLatency
+	}
+	return float64(latency) * (1 + poolErrorPenalty*s.ErrorRate)
+}
+
+type pooledArchive struct {
+	archive ArchiveInterface
+	stats   ArchiveStats
+}
+
+// A ArchivePool is a collection of `ArchiveInterface`s that distributes
+// requests throughout the pool. Archives are scored on their error rate and
+// latency so that healthy archives get most of the requests, failed reads
+// are retried on another archive and archives serving corrupt files are
+// quarantined.
+type ArchivePool struct {
+	opts PoolOptions
+
+	mutex    sync.Mutex
+	archives []*pooledArchive
+}
 
 // NewArchivePool tries connecting to each of the provided history archive URLs,
 // returning a pool of valid archives.
@@ -21,7 +96,7 @@ type ArchivePool []ArchiveInterface
 // If none of the archives work, this returns the error message of the last
 // failed archive. Note that the errors for each individual archive are hard to
 // track if there's success overall.
-func NewArchivePool(archiveURLs []string, config ConnectOptions) (ArchivePool, error) {
+func NewArchivePool(archiveURLs []string, config ConnectOptions) (*ArchivePool, error) {
 	if len(archiveURLs) <= 0 {
 		return nil, errors.New("No history archives provided")
 	}
@@ -29,16 +104,10 @@ func NewArchivePool(archiveURLs []string, config ConnectOptions) (ArchivePool, e
 	var lastErr error = nil
 
 	// Try connecting to all of the listed archives, but only store valid ones.
-	var validArchives ArchivePool
+	var validArchives []ArchiveInterface
+	var validURLs []string
 	for _, url := range archiveURLs {
-		archive, err := Connect(
-			url,
-			ConnectOptions{
-				NetworkPassphrase:   config.NetworkPassphrase,
-				CheckpointFrequency: config.CheckpointFrequency,
-				Context:             config.Context,
-			},
-		)
+		archive, err := Connect(url, config)
 
 		if err != nil {
 			lastErr = errors.Wrapf(err, "Error connecting to history archive (%s)", url)
@@ -46,92 +115,398 @@ func NewArchivePool(archiveURLs []string, config ConnectOptions) (ArchivePool, e
 		}
 
 		validArchives = append(validArchives, archive)
+		validURLs = append(validURLs, url)
 	}
 
 	if len(validArchives) == 0 {
 		return nil, lastErr
 	}
 
-	return validArchives, nil
+	return makeArchivePool(validURLs, validArchives, config.Pool), nil
+}
+
+func makeArchivePool(urls []string, archives []ArchiveInterface, opts PoolOptions) *ArchivePool {
+	if opts.QuarantineDuration == 0 {
+		opts.QuarantineDuration = DefaultQuarantineDuration
+	}
+	pa := &ArchivePool{opts: opts}
+	for i, archive := range archives {
+		pa.archives = append(pa.archives, &pooledArchive{
+			archive: archive,
+			stats:   ArchiveStats{URL: urls[i]},
+		})
+	}
+	return pa
 }
 
 // Ensure the pool conforms to the ArchiveInterface
-var _ ArchiveInterface = ArchivePool{}
+var _ ArchiveInterface = &ArchivePool{}
+
+// Stats returns the health statistics of the archives of the pool.
+func (pa *ArchivePool) Stats() []ArchiveStats {
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+	stats := make([]ArchiveStats, 0, len(pa.archives))
+	for _, a := range pa.archives {
+		stats = append(stats, a.stats)
+	}
+	return stats
+}
+
+// candidates returns the archives a request should be attempted on, in
+// order. The first archive is picked at random weighted by the score of the
+// archives, so that requests are spread over the healthy archives, and the
+// other archives follow from best to worst score. Quarantined archives are
+// only returned if every archive is quarantined.
+func (pa *ArchivePool) candidates() []*pooledArchive {
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+
+	now := time.Now()
+	healthy := make([]*pooledArchive, 0, len(pa.archives))
+	for _, a := range pa.archives {
+		if now.After(a.stats.QuarantinedUntil) {
+			healthy = append(healthy, a)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = append(healthy, pa.archives...)
+	}
+
+	sort.SliceStable(healthy, func(i, j int) bool {
+		return healthy[i].stats.score() < healthy[j].stats.score()
+	})
+
+	total := 0.0
+	for _, a := range healthy {
+		total += 1 / a.stats.score()
+	}
+	pick := rand.Float64() * total
+	for i, a := range healthy {
+		pick -= 1 / a.stats.score()
+		if pick <= 0 {
+			healthy[0], healthy[i] = healthy[i], healthy[0]
+			if i > 1 {
+				// Keep the others sorted by score.
+				sort.SliceStable(healthy[1:], func(k, l int) bool {
+					return healthy[1+k].stats.score() < healthy[1+l].stats.score()
+				})
+			}
+			break
+		}
+	}
+
+	if pa.opts.MaxAttempts > 0 && len(healthy) > pa.opts.MaxAttempts {
+		healthy = healthy[:pa.opts.MaxAttempts]
+	}
+	return healthy
+}
+
+// record updates the statistics of the archive with the outcome of a
+// request.
+func (pa *ArchivePool) record(a *pooledArchive, latency time.Duration, err error) {
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+
+	failed := 0.0
+	a.stats.Requests++
+	if err != nil {
+		a.stats.Errors++
+		failed = 1
+	} else if a.stats.Latency == 0 {
+		a.stats.Latency = latency
+	} else {
+		a.stats.Latency = time.Duration((1-poolEWMAWeight)*float64(a.stats.Latency) + poolEWMAWeight*float64(latency))
+	}
+	a.stats.ErrorRate = (1-poolEWMAWeight)*a.stats.ErrorRate + poolEWMAWeight*failed
+}
+
+// quarantine stops using the archive for the quarantine duration.
+func (pa *ArchivePool) quarantine(a *pooledArchive, err error) {
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+	a.stats.QuarantinedUntil = time.Now().Add(pa.opts.QuarantineDuration)
+	log.WithField("archive", a.stats.URL).WithError(err).
+		Warnf("Quarantining history archive until %s", a.stats.QuarantinedUntil.Format(time.RFC3339))
+}
+
+// quarantineArchive quarantines the pooled archive wrapping archive.
+func (pa *ArchivePool) quarantineArchive(archive ArchiveInterface, err error) {
+	for _, a := range pa.archives {
+		if a.archive == archive {
+			pa.quarantine(a, err)
+		}
+	}
+}
+
+// do calls fn on the candidate archives until it succeeds, and returns the
+// last error if every attempt failed.
+func (pa *ArchivePool) do(fn func(ArchiveInterface) error) error {
+	var err error
+	for _, a := range pa.candidates() {
+		start := time.Now()
+		err = fn(a.archive)
+		pa.record(a, time.Since(start), err)
+		if err == nil {
+			return nil
+		}
+		log.WithField("archive", a.stats.URL).WithError(err).Debug("History archive request failed")
+	}
+	return err
+}
+
+// verify calls the verification fn on the candidate archives until one of
+// them has a valid copy of the file. Archives serving a file that does not
+// match its hash are quarantined.
+func (pa *ArchivePool) verify(fn func(archiveVerifier) error) error {
+	return pa.do(func(archive ArchiveInterface) error {
+		v, ok := archive.(archiveVerifier)
+		if !ok {
+			return errors.New("archive does not support verification")
+		}
+		err := fn(v)
+		if _, mismatch := errors.Cause(err).(HashMismatchError); mismatch {
+			pa.quarantineArchive(archive, err)
+		}
+		return err
+	})
+}
+
+// archiveVerifier is implemented by archives that can verify their files.
+type archiveVerifier interface {
+	VerifyBucketHash(h Hash) error
+	VerifyCategoryCheckpoint(cat string, chk uint32) error
+}
+
+// VerifyBucketHash checks that the bucket matches its hash on one of the
+// archives of the pool, quarantining archives serving a corrupt copy.
+func (pa *ArchivePool) VerifyBucketHash(h Hash) error {
+	return pa.verify(func(v archiveVerifier) error {
+		return v.VerifyBucketHash(h)
+	})
+}
+
+// VerifyCategoryCheckpoint checks the checkpoint file on one of the archives
+// of the pool, quarantining archives serving a corrupt copy.
+//
+// Only the ledger headers of the "ledger" category can be checked on their
+// own, against their self-hash. Transaction and result sets are hashed but are
+// only checked against the ledger headers by a full archive verification, so
+// an archive serving corrupt transactions or results is not quarantined.
+func (pa *ArchivePool) VerifyCategoryCheckpoint(cat string, chk uint32) error {
+	return pa.verify(func(v archiveVerifier) error {
+		return v.VerifyCategoryCheckpoint(cat, chk)
+	})
+}
 
 // Below are the ArchiveInterface method implementations.
 
-func (pa ArchivePool) GetAnyArchive() ArchiveInterface {
-	return pa[rand.Intn(len(pa))]
+func (pa *ArchivePool) GetAnyArchive() ArchiveInterface {
+	return pa.candidates()[0].archive
 }
 
-func (pa ArchivePool) GetPathHAS(path string) (HistoryArchiveState, error) {
-	return pa.GetAnyArchive().GetPathHAS(path)
+func (pa *ArchivePool) GetPathHAS(path string) (has HistoryArchiveState, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		has, err = a.GetPathHAS(path)
+		return err
+	})
+	return has, err
 }
 
-func (pa ArchivePool) PutPathHAS(path string, has HistoryArchiveState, opts *CommandOptions) error {
-	return pa.GetAnyArchive().PutPathHAS(path, has, opts)
+func (pa *ArchivePool) PutPathHAS(path string, has HistoryArchiveState, opts *CommandOptions) error {
+	return pa.do(func(a ArchiveInterface) error {
+		return a.PutPathHAS(path, has, opts)
+	})
 }
 
-func (pa ArchivePool) BucketExists(bucket Hash) (bool, error) {
-	return pa.GetAnyArchive().BucketExists(bucket)
+func (pa *ArchivePool) BucketExists(bucket Hash) (ok bool, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		ok, err = a.BucketExists(bucket)
+		return err
+	})
+	return ok, err
 }
 
-func (pa ArchivePool) BucketSize(bucket Hash) (int64, error) {
-	return pa.GetAnyArchive().BucketSize(bucket)
+func (pa *ArchivePool) BucketSize(bucket Hash) (size int64, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		size, err = a.BucketSize(bucket)
+		return err
+	})
+	return size, err
 }
 
-func (pa ArchivePool) CategoryCheckpointExists(cat string, chk uint32) (bool, error) {
-	return pa.GetAnyArchive().CategoryCheckpointExists(cat, chk)
+func (pa *ArchivePool) CategoryCheckpointExists(cat string, chk uint32) (ok bool, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		ok, err = a.CategoryCheckpointExists(cat, chk)
+		return err
+	})
+	return ok, err
 }
 
-func (pa ArchivePool) GetLedgerHeader(chk uint32) (xdr.LedgerHeaderHistoryEntry, error) {
-	return pa.GetAnyArchive().GetLedgerHeader(chk)
+func (pa *ArchivePool) GetLedgerHeader(chk uint32) (header xdr.LedgerHeaderHistoryEntry, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		header, err = a.GetLedgerHeader(chk)
+		return err
+	})
+	return header, err
 }
 
-func (pa ArchivePool) GetRootHAS() (HistoryArchiveState, error) {
-	return pa.GetAnyArchive().GetRootHAS()
+func (pa *ArchivePool) GetRootHAS() (has HistoryArchiveState, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		has, err = a.GetRootHAS()
+		return err
+	})
+	return has, err
 }
 
-func (pa ArchivePool) GetLedgers(start, end uint32) (map[uint32]*Ledger, error) {
-	return pa.GetAnyArchive().GetLedgers(start, end)
+func (pa *ArchivePool) GetLedgers(start, end uint32) (ledgers map[uint32]*Ledger, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		ledgers, err = a.GetLedgers(start, end)
+		return err
+	})
+	return ledgers, err
 }
 
-func (pa ArchivePool) GetCheckpointHAS(chk uint32) (HistoryArchiveState, error) {
-	return pa.GetAnyArchive().GetCheckpointHAS(chk)
+func (pa *ArchivePool) GetCheckpointHAS(chk uint32) (has HistoryArchiveState, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		has, err = a.GetCheckpointHAS(chk)
+		return err
+	})
+	return has, err
 }
 
-func (pa ArchivePool) PutCheckpointHAS(chk uint32, has HistoryArchiveState, opts *CommandOptions) error {
-	return pa.GetAnyArchive().PutCheckpointHAS(chk, has, opts)
+func (pa *ArchivePool) PutCheckpointHAS(chk uint32, has HistoryArchiveState, opts *CommandOptions) error {
+	return pa.do(func(a ArchiveInterface) error {
+		return a.PutCheckpointHAS(chk, has, opts)
+	})
 }
 
-func (pa ArchivePool) PutRootHAS(has HistoryArchiveState, opts *CommandOptions) error {
-	return pa.GetAnyArchive().PutRootHAS(has, opts)
+func (pa *ArchivePool) PutRootHAS(has HistoryArchiveState, opts *CommandOptions) error {
+	return pa.do(func(a ArchiveInterface) error {
+		return a.PutRootHAS(has, opts)
+	})
 }
 
-func (pa ArchivePool) ListBucket(dp DirPrefix) (chan string, chan error) {
+func (pa *ArchivePool) ListBucket(dp DirPrefix) (chan string, chan error) {
 	return pa.GetAnyArchive().ListBucket(dp)
 }
 
-func (pa ArchivePool) ListAllBuckets() (chan string, chan error) {
+func (pa *ArchivePool) ListAllBuckets() (chan string, chan error) {
 	return pa.GetAnyArchive().ListAllBuckets()
 }
 
-func (pa ArchivePool) ListAllBucketHashes() (chan Hash, chan error) {
+func (pa *ArchivePool) ListAllBucketHashes() (chan Hash, chan error) {
 	return pa.GetAnyArchive().ListAllBucketHashes()
 }
 
-func (pa ArchivePool) ListCategoryCheckpoints(cat string, pth string) (chan uint32, chan error) {
+func (pa *ArchivePool) ListCategoryCheckpoints(cat string, pth string) (chan uint32, chan error) {
 	return pa.GetAnyArchive().ListCategoryCheckpoints(cat, pth)
 }
 
-func (pa ArchivePool) GetXdrStreamForHash(hash Hash) (*XdrStream, error) {
-	return pa.GetAnyArchive().GetXdrStreamForHash(hash)
+// GetXdrStreamForHash opens the bucket on the candidate archives until one
+// succeeds. If hedging is enabled, the bucket is requested from the next
+// archive whenever an archive has not responded after the hedge delay.
+//
+// The stream is checked against the hash of the bucket. A corrupt bucket can
+// only be detected once it has been read entirely, so the error is reported
+// by Close as a HashMismatchError rather than retried, and the archive that
+// served it is quarantined so that the bucket is read from another archive
+// when it is opened again.
+func (pa *ArchivePool) GetXdrStreamForHash(hash Hash) (*XdrStream, error) {
+	open := func(a ArchiveInterface) (*XdrStream, error) {
+		stream, err := a.GetXdrStreamForHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		stream.SetExpectedHash(hash)
+		stream.onHashMismatch = func(err error) {
+			pa.quarantineArchive(a, err)
+		}
+		return stream, nil
+	}
+
+	if pa.opts.HedgeDelay <= 0 {
+		var stream *XdrStream
+		err := pa.do(func(a ArchiveInterface) error {
+			var err error
+			stream, err = open(a)
+			return err
+		})
+		return stream, err
+	}
+	return pa.hedge(open)
+}
+
+type hedgeResult struct {
+	stream *XdrStream
+	err    error
+}
+
+// hedge calls fn on the candidate archives, starting the next attempt when
+// the previous one failed or the hedge delay elapsed, and returns the first
+// stream opened. Streams opened by the other attempts are aborted without
+// being read, so that the buckets are not downloaded again from the archives
+// that lost.
+func (pa *ArchivePool) hedge(fn func(ArchiveInterface) (*XdrStream, error)) (*XdrStream, error) {
+	candidates := pa.candidates()
+	results := make(chan hedgeResult, len(candidates))
+	start := func(a *pooledArchive) {
+		go func() {
+			begin := time.Now()
+			stream, err := fn(a.archive)
+			pa.record(a, time.Since(begin), err)
+			results <- hedgeResult{stream, err}
+		}()
+	}
+
+	start(candidates[0])
+	next, pending := 1, 1
+	hedgeTimer := time.After(pa.opts.HedgeDelay)
+	var lastErr error
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// Abort the streams of the attempts still running.
+				go func(n int) {
+					for ; n > 0; n-- {
+						if r := <-results; r.err == nil {
+							r.stream.abort()
+						}
+					}
+				}(pending)
+				return r.stream, nil
+			}
+			lastErr = r.err
+			if next < len(candidates) {
+				start(candidates[next])
+				next++
+				pending++
+				hedgeTimer = time.After(pa.opts.HedgeDelay)
+			} else if pending == 0 {
+				return nil, lastErr
+			}
+		case <-hedgeTimer:
+			if next < len(candidates) {
+				log.WithField("archive", candidates[next-1].stats.URL).Debug("Hedging history archive request")
+				start(candidates[next])
+				next++
+				pending++
+				hedgeTimer = time.After(pa.opts.HedgeDelay)
+			}
+		}
+	}
 }
 
-func (pa ArchivePool) GetXdrStream(pth string) (*XdrStream, error) {
-	return pa.GetAnyArchive().GetXdrStream(pth)
+func (pa *ArchivePool) GetXdrStream(pth string) (stream *XdrStream, err error) {
+	err = pa.do(func(a ArchiveInterface) error {
+		stream, err = a.GetXdrStream(pth)
+		return err
+	})
+	return stream, err
 }
 
-func (pa ArchivePool) GetCheckpointManager() CheckpointManager {
-	return pa.GetAnyArchive().GetCheckpointManager()
+func (pa *ArchivePool) GetCheckpointManager() CheckpointManager {
+	return pa.archives[0].archive.GetCheckpointManager()
 }
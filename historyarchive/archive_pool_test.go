@@ -0,0 +1,216 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPool(t *testing.T, opts PoolOptions, archives ...*Archive) *ArchivePool {
+	urls := make([]string, len(archives))
+	interfaces := make([]ArchiveInterface, len(archives))
+	for i, a := range archives {
+		urls[i] = string(rune('a' + i))
+		interfaces[i] = a
+	}
+	return makeArchivePool(urls, interfaces, opts)
+}
+
+func TestArchivePoolRetriesOnAnotherArchive(t *testing.T) {
+	good := GetTestMockArchive()
+	require.NoError(t, good.AddRandomCheckpoint(63))
+	empty := GetTestMockArchive()
+	pool := testPool(t, PoolOptions{}, empty, good)
+
+	expected, err := good.GetRootHAS()
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		has, err := pool.GetRootHAS()
+		require.NoError(t, err)
+		assert.Equal(t, expected, has)
+	}
+
+	stats := pool.Stats()
+	assert.Equal(t, stats[0].Requests, stats[0].Errors)
+	assert.Equal(t, uint64(20), stats[1].Requests)
+	assert.Zero(t, stats[1].Errors)
+
+	pool = testPool(t, PoolOptions{MaxAttempts: 1}, empty)
+	_, err = pool.GetRootHAS()
+	assert.Error(t, err)
+}
+
+func TestArchivePoolPrefersHealthyArchives(t *testing.T) {
+	pool := testPool(t, PoolOptions{MaxAttempts: 2},
+		GetTestMockArchive(), GetTestMockArchive(), GetTestMockArchive())
+	pool.archives[0].stats.ErrorRate = 1
+	pool.archives[0].stats.Latency = time.Second
+	pool.archives[1].stats.Latency = 10 * time.Millisecond
+	pool.archives[2].stats.QuarantinedUntil = time.Now().Add(time.Hour)
+
+	first := map[string]int{}
+	for i := 0; i < 100; i++ {
+		candidates := pool.candidates()
+		require.Len(t, candidates, 2)
+		for _, c := range candidates {
+			assert.NotEqual(t, "c", c.stats.URL)
+		}
+		first[candidates[0].stats.URL]++
+	}
+	assert.Greater(t, first["b"], 90)
+
+	// Quarantined archives are used when no other archive is left.
+	pool.archives[0].stats.QuarantinedUntil = time.Now().Add(time.Hour)
+	pool.archives[1].stats.QuarantinedUntil = time.Now().Add(time.Hour)
+	assert.Len(t, pool.candidates(), 2)
+}
+
+func TestArchivePoolQuarantinesCorruptArchives(t *testing.T) {
+	good := GetTestMockArchive()
+	corrupt := GetTestMockArchive()
+	hash, _ := putGzippedBucket(t, good.backend, 1024)
+	corrupt.backend.(*MockArchiveBackend).files[BucketPath(hash)] = gzipBytes(t, []byte("corrupt"))
+	pool := testPool(t, PoolOptions{}, corrupt, good)
+
+	for i := 0; i < 100 && pool.Stats()[0].QuarantinedUntil.IsZero(); i++ {
+		require.NoError(t, pool.VerifyBucketHash(hash))
+	}
+	stats := pool.Stats()
+	require.False(t, stats[0].QuarantinedUntil.IsZero())
+	assert.True(t, stats[1].QuarantinedUntil.IsZero())
+
+	for i := 0; i < 10; i++ {
+		candidates := pool.candidates()
+		require.Len(t, candidates, 1)
+		assert.Equal(t, "b", candidates[0].stats.URL)
+	}
+
+	pool = testPool(t, PoolOptions{}, corrupt)
+	err := pool.VerifyBucketHash(hash)
+	assert.IsType(t, HashMismatchError{}, err)
+}
+
+func TestArchivePoolQuarantinesArchivesStreamingCorruptBuckets(t *testing.T) {
+	good := GetTestMockArchive()
+	corrupt := GetTestMockArchive()
+	hash, _ := putGzippedBucket(t, good.backend, 1024)
+	corrupt.backend.(*MockArchiveBackend).files[BucketPath(hash)] = gzipBytes(t, []byte("corrupt"))
+	pool := testPool(t, PoolOptions{}, corrupt)
+
+	// The pool checks the hash even if the caller does not set it.
+	stream, err := pool.GetXdrStreamForHash(hash)
+	require.NoError(t, err)
+	err = stream.Close()
+	assert.IsType(t, HashMismatchError{}, err)
+	assert.False(t, pool.Stats()[0].QuarantinedUntil.IsZero())
+
+	pool = testPool(t, PoolOptions{}, corrupt, good)
+	pool.archives[0].stats.QuarantinedUntil = time.Now().Add(time.Hour)
+	stream, err = pool.GetXdrStreamForHash(hash)
+	require.NoError(t, err)
+	assert.NoError(t, stream.Close())
+	assert.True(t, pool.Stats()[1].QuarantinedUntil.IsZero())
+}
+
+// slowArchive is an archive that blocks bucket downloads until released.
+type slowArchive struct {
+	*Archive
+	release chan struct{}
+}
+
+func (a slowArchive) GetXdrStreamForHash(hash Hash) (*XdrStream, error) {
+	<-a.release
+	return a.Archive.GetXdrStreamForHash(hash)
+}
+
+func TestArchivePoolHedgesBucketDownloads(t *testing.T) {
+	fast := GetTestMockArchive()
+	slow := slowArchive{GetTestMockArchive(), make(chan struct{})}
+	hash, _ := putGzippedBucket(t, fast.backend, 1024)
+	slow.backend.(*MockArchiveBackend).files[BucketPath(hash)] = fast.backend.(*MockArchiveBackend).files[BucketPath(hash)]
+	defer close(slow.release)
+
+	pool := makeArchivePool([]string{"slow", "fast"}, []ArchiveInterface{slow, fast}, PoolOptions{HedgeDelay: 10 * time.Millisecond})
+	for i := 0; i < 5; i++ {
+		stream, err := pool.GetXdrStreamForHash(hash)
+		require.NoError(t, err)
+		require.NoError(t, stream.Close())
+	}
+}
+
+// countingBody is a bucket body that counts the bytes read from it.
+type countingBody struct {
+	io.Reader
+	read   int64
+	closed chan struct{}
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	atomic.AddInt64(&b.read, int64(n))
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	close(b.closed)
+	return nil
+}
+
+// losingArchive is an archive that serves a bucket only when released, after
+// a hedged request to another archive has won.
+type losingArchive struct {
+	*Archive
+	started chan struct{}
+	release chan struct{}
+	body    *countingBody
+}
+
+func (a losingArchive) GetXdrStreamForHash(hash Hash) (*XdrStream, error) {
+	close(a.started)
+	<-a.release
+	return NewXdrStream(a.body), nil
+}
+
+func TestArchivePoolHedgeDoesNotReadLosingStreams(t *testing.T) {
+	fast := GetTestMockArchive()
+	hash, _ := putGzippedBucket(t, fast.backend, 1024)
+
+	for i := 0; i < 100; i++ {
+		losing := losingArchive{
+			Archive: GetTestMockArchive(),
+			started: make(chan struct{}),
+			release: make(chan struct{}),
+			body:    &countingBody{Reader: bytes.NewReader(make([]byte, 1<<20)), closed: make(chan struct{})},
+		}
+		pool := makeArchivePool([]string{"losing", "fast"}, []ArchiveInterface{losing, fast}, PoolOptions{HedgeDelay: time.Millisecond})
+		stream, err := pool.GetXdrStreamForHash(hash)
+		require.NoError(t, err)
+		require.NoError(t, stream.Close())
+
+		select {
+		case <-losing.started:
+		default:
+			// The fast archive was tried first so there was no race.
+			continue
+		}
+
+		close(losing.release)
+		select {
+		case <-losing.body.closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("losing stream was not closed")
+		}
+		assert.Zero(t, atomic.LoadInt64(&losing.body.read))
+		return
+	}
+	t.Fatal("losing archive was never tried first")
+}
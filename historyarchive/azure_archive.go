@@ -0,0 +1,352 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// azureAPIVersion is the version of the Azure Blob Storage REST API used.
+const azureAPIVersion = "2020-10-02"
+
+// azureBlockSize is the size of the blocks files larger than a block are
+// uploaded in, which bounds the memory used by an upload.
+var azureBlockSize = 4 << 20
+
+// AzureArchiveBackend is a backend storing files as block blobs in a
+// container of an Azure storage account. Requests are authorized with the
+// account key (Shared Key) or a SAS token, or are anonymous if neither is
+// set.
+type AzureArchiveBackend struct {
+	ctx        context.Context
+	client     *http.Client
+	account    string
+	accountKey []byte
+	sasToken   url.Values
+	// endpoint is the URL of the container.
+	endpoint  *url.URL
+	prefix    string
+	userAgent string
+}
+
+func (b *AzureArchiveBackend) blobURL(pth string) *url.URL {
+	u := *b.endpoint
+	u.Path = path.Join(u.Path, b.prefix, pth)
+	return &u
+}
+
+func (b *AzureArchiveBackend) do(method string, u *url.URL, body io.Reader, size int64) (*http.Response, error) {
+	if len(b.sasToken) > 0 {
+		query := u.Query()
+		for k, v := range b.sasToken {
+			query[k] = v
+		}
+		u.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(b.ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+		if u.Query().Get("comp") == "" {
+			req.Header.Set("x-ms-blob-type", "BlockBlob")
+		}
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	if b.userAgent != "" {
+		req.Header.Set("User-Agent", b.userAgent)
+	}
+	if b.accountKey != nil {
+		req.Header.Set("Authorization", "SharedKey "+b.account+":"+b.sign(req))
+	}
+
+	logReq(req)
+	resp, err := b.client.Do(req)
+	logResp(resp)
+	return resp, err
+}
+
+// sign returns the Shared Key signature of the request, see
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (b *AzureArchiveBackend) sign(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var headers []string
+	for k := range req.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			headers = append(headers, k)
+		}
+	}
+	sort.Strings(headers)
+	var canonical strings.Builder
+	for _, k := range headers {
+		canonical.WriteString(k + ":" + strings.TrimSpace(req.Header.Get(k)) + "\n")
+	}
+
+	canonical.WriteString("/" + b.account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	var params []string
+	for k := range query {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		values := query[k]
+		sort.Strings(values)
+		canonical.WriteString("\n" + strings.ToLower(k) + ":" + strings.Join(values, ","))
+	}
+
+	toSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonical.String(),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, b.accountKey)
+	mac.Write([]byte(toSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkAzureResp returns an error if the response is not successful.
+func checkAzureResp(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	resp.Body.Close()
+	return errors.Errorf("Bad HTTP response '%s' for %s '%s': %s",
+		resp.Status, resp.Request.Method, resp.Request.URL.Path, strings.TrimSpace(string(body)))
+}
+
+func (b *AzureArchiveBackend) GetFile(pth string) (io.ReadCloser, error) {
+	resp, err := b.do(http.MethodGet, b.blobURL(pth), nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkAzureResp(resp); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (b *AzureArchiveBackend) head(pth string) (*http.Response, error) {
+	resp, err := b.do(http.MethodHead, b.blobURL(pth), nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("Unknown status code=%d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (b *AzureArchiveBackend) Exists(pth string) (bool, error) {
+	resp, err := b.head(pth)
+	if err != nil {
+		return false, err
+	}
+	return resp != nil, nil
+}
+
+func (b *AzureArchiveBackend) Size(pth string) (int64, error) {
+	resp, err := b.head(pth)
+	if err != nil || resp == nil {
+		return 0, err
+	}
+	return resp.ContentLength, nil
+}
+
+// PutFile uploads the file in a single request if it is smaller than a block,
+// and otherwise streams it in blocks that are committed once all are uploaded,
+// see https://learn.microsoft.com/en-us/rest/api/storageservices/put-block-list
+func (b *AzureArchiveBackend) PutFile(pth string, in io.ReadCloser) error {
+	defer in.Close()
+	buf := make([]byte, azureBlockSize)
+	n, err := io.ReadFull(in, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return b.put(b.blobURL(pth), buf[:n])
+	} else if err != nil {
+		return err
+	}
+
+	var blockIDs []string
+	for n > 0 {
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", len(blockIDs))))
+		u := b.blobURL(pth)
+		u.RawQuery = url.Values{"comp": {"block"}, "blockid": {blockID}}.Encode()
+		if err = b.put(u, buf[:n]); err != nil {
+			return errors.Wrapf(err, "uploading block %d", len(blockIDs))
+		}
+		blockIDs = append(blockIDs, blockID)
+
+		n, err = io.ReadFull(in, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+	}
+
+	blockList, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{Latest: blockIDs})
+	if err != nil {
+		return err
+	}
+	u := b.blobURL(pth)
+	u.RawQuery = url.Values{"comp": {"blocklist"}}.Encode()
+	return errors.Wrap(b.put(u, append([]byte(xml.Header), blockList...)), "committing blocks")
+}
+
+func (b *AzureArchiveBackend) put(u *url.URL, body []byte) error {
+	resp, err := b.do(http.MethodPut, u, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	if err = checkAzureResp(resp); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type azureBlobList struct {
+	Blobs []struct {
+		Name string `xml:"Name"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func (b *AzureArchiveBackend) listPage(prefix, marker string) (azureBlobList, error) {
+	var list azureBlobList
+	query := url.Values{
+		"restype": {"container"},
+		"comp":    {"list"},
+		"prefix":  {prefix},
+	}
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	u := *b.endpoint
+	u.RawQuery = query.Encode()
+	resp, err := b.do(http.MethodGet, &u, nil, 0)
+	if err != nil {
+		return list, err
+	}
+	if err = checkAzureResp(resp); err != nil {
+		return list, err
+	}
+	defer resp.Body.Close()
+	err = xml.NewDecoder(resp.Body).Decode(&list)
+	return list, err
+}
+
+func (b *AzureArchiveBackend) ListFiles(pth string) (chan string, chan error) {
+	prefix := path.Join(b.prefix, pth)
+	ch := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(ch)
+		defer close(errs)
+		marker := ""
+		for {
+			list, err := b.listPage(prefix, marker)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, blob := range list.Blobs {
+				log.WithField("key", blob.Name).Trace("azure: ListFiles")
+				ch <- blob.Name
+			}
+			if list.NextMarker == "" {
+				return
+			}
+			marker = list.NextMarker
+		}
+	}()
+	return ch, errs
+}
+
+func (b *AzureArchiveBackend) CanListFiles() bool {
+	return true
+}
+
+func makeAzureBackend(account string, container string, prefix string, opts ConnectOptions) (ArchiveBackend, error) {
+	log.WithFields(log.Fields{"account": account,
+		"container": container,
+		"prefix":    prefix,
+		"endpoint":  opts.AzureEndpoint}).Debug("azure: making backend")
+
+	endpoint := opts.AzureEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Azure endpoint")
+	}
+	u.Path = path.Join("/", u.Path, container)
+
+	backend := AzureArchiveBackend{
+		ctx:       opts.Context,
+		client:    http.DefaultClient,
+		account:   account,
+		endpoint:  u,
+		prefix:    prefix,
+		userAgent: opts.UserAgent,
+	}
+	if opts.AzureAccountKey != "" {
+		backend.accountKey, err = base64.StdEncoding.DecodeString(opts.AzureAccountKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid Azure account key")
+		}
+	} else if opts.AzureSASToken != "" {
+		backend.sasToken, err = url.ParseQuery(strings.TrimPrefix(opts.AzureSASToken, "?"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid Azure SAS token")
+		}
+	}
+	return &backend, nil
+}
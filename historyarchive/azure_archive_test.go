@@ -0,0 +1,207 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAzure is a stand-in for the subset of the Azure Blob Storage REST API
+// used by the Azure backend, addressing containers by path like Azurite.
+// Blob listings return one blob per page.
+type fakeAzure struct {
+	account   string
+	container string
+	mutex     sync.Mutex
+	files     map[string][]byte
+	blocks    map[string][]byte
+	auth      []string
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+
+	containerPath := "/" + f.account + "/" + f.container
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == containerPath && query.Get("comp") == "list":
+		names := []string{}
+		for name := range f.files {
+			if strings.HasPrefix(name, query.Get("prefix")) && name > query.Get("marker") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		type blob struct {
+			Name string `xml:"Name"`
+		}
+		result := struct {
+			XMLName    xml.Name `xml:"EnumerationResults"`
+			Blobs      []blob   `xml:"Blobs>Blob"`
+			NextMarker string   `xml:"NextMarker"`
+		}{}
+		if len(names) > 0 {
+			result.Blobs = []blob{{names[0]}}
+		}
+		if len(names) > 1 {
+			result.NextMarker = names[0]
+		}
+		xml.NewEncoder(w).Encode(result)
+
+	case strings.HasPrefix(r.URL.Path, containerPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, containerPath+"/")
+		switch r.Method {
+		case http.MethodPut:
+			switch query.Get("comp") {
+			case "block":
+				f.blocks[name+"/"+query.Get("blockid")], _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusCreated)
+				return
+			case "blocklist":
+				blockList := struct {
+					Latest []string `xml:"Latest"`
+				}{}
+				if err := xml.NewDecoder(r.Body).Decode(&blockList); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				var body []byte
+				for _, id := range blockList.Latest {
+					block, ok := f.blocks[name+"/"+id]
+					if !ok {
+						http.Error(w, "unknown block", http.StatusBadRequest)
+						return
+					}
+					body = append(body, block...)
+				}
+				f.files[name] = body
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+				http.Error(w, "missing blob type", http.StatusBadRequest)
+				return
+			}
+			f.files[name], _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet, http.MethodHead:
+			body, ok := f.files[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write(body)
+		}
+
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func TestAzureBackend(t *testing.T) {
+	fake := &fakeAzure{account: "devstoreaccount1", container: "archive", files: map[string][]byte{}, blocks: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	arch, err := Connect("azure://devstoreaccount1/archive/prd", ConnectOptions{
+		AzureEndpoint:   server.URL + "/devstoreaccount1",
+		AzureAccountKey: base64.StdEncoding.EncodeToString([]byte("key")),
+	})
+	require.NoError(t, err)
+	backend := arch.backend
+
+	ok, err := backend.Exists("bucket/a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	for _, name := range []string{"bucket/a", "bucket/b", "ledger/c"} {
+		require.NoError(t, backend.PutFile(name, ioutil.NopCloser(strings.NewReader("content "+name))))
+	}
+	assert.Equal(t, []byte("content bucket/a"), fake.files["prd/bucket/a"])
+
+	size, err := backend.Size("bucket/b")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("content bucket/b")), size)
+
+	r, err := backend.GetFile("ledger/c")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "content ledger/c", string(body))
+
+	_, err = backend.GetFile("ledger/d")
+	assert.Error(t, err)
+
+	ch, errs := backend.ListFiles("bucket")
+	names := []string{}
+	for name := range ch {
+		names = append(names, name)
+	}
+	assert.NoError(t, <-errs)
+	assert.Equal(t, []string{"prd/bucket/a", "prd/bucket/b"}, names)
+
+	for _, auth := range fake.auth {
+		assert.True(t, strings.HasPrefix(auth, "SharedKey devstoreaccount1:"), auth)
+	}
+
+	_, err = Connect("azure://devstoreaccount1", ConnectOptions{})
+	assert.EqualError(t, err, "Azure URL has no container: 'azure://devstoreaccount1'")
+}
+
+func TestAzureBackendPutFileInBlocks(t *testing.T) {
+	fake := &fakeAzure{account: "devstoreaccount1", container: "archive", files: map[string][]byte{}, blocks: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	defer func(size int) { azureBlockSize = size }(azureBlockSize)
+	azureBlockSize = 4
+
+	arch, err := Connect("azure://devstoreaccount1/archive", ConnectOptions{
+		AzureEndpoint:   server.URL + "/devstoreaccount1",
+		AzureAccountKey: base64.StdEncoding.EncodeToString([]byte("key")),
+	})
+	require.NoError(t, err)
+
+	for _, content := range []string{"", "abc", "abcd", "abcdefghij", "abcdefgh"} {
+		require.NoError(t, arch.backend.PutFile("bucket/"+content, ioutil.NopCloser(strings.NewReader(content))))
+		assert.Equal(t, content, string(fake.files["bucket/"+content]))
+	}
+	// Files of a block or more are uploaded in blocks of the block size.
+	assert.Len(t, fake.blocks, 1+3+2)
+	for _, block := range fake.blocks {
+		assert.LessOrEqual(t, len(block), 4)
+	}
+}
+
+func TestAzureSharedKeySignature(t *testing.T) {
+	backend, err := makeAzureBackend("account", "container", "", ConnectOptions{
+		AzureAccountKey: base64.StdEncoding.EncodeToString([]byte("secret")),
+	})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, "https://account.blob.core.windows.net/container?restype=container&comp=list&prefix=bucket", nil)
+	require.NoError(t, err)
+	req.Header.Set("x-ms-date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	req.Header.Set("x-ms-version", azureAPIVersion)
+
+	// The string to sign is
+	// "GET\n\n\n\n\n\n\n\n\n\n\n\nx-ms-date:Mon, 02 Jan 2006 15:04:05 GMT\nx-ms-version:2020-10-02\n/account/container\ncomp:list\nprefix:bucket\nrestype:container"
+	assert.Equal(t, "pRs9+iGTs8m2BRqKsewli+XzMcc+ZcAIA1KhrGYroe4=", backend.(*AzureArchiveBackend).sign(req))
+}
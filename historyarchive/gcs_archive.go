@@ -0,0 +1,143 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"context"
+	"io"
+	"path"
+
+	"cloud.google.com/go/storage"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// gcsChunkSize is the size of the chunks files are uploaded in. Most archive
+// files are small, so a smaller chunk than the default 16MiB saves memory
+// when many files are uploaded concurrently.
+const gcsChunkSize = 1 << 20
+
+type GCSArchiveBackend struct {
+	ctx    context.Context
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+func (b *GCSArchiveBackend) GetFile(pth string) (io.ReadCloser, error) {
+	key := path.Join(b.prefix, pth)
+	log.WithField("key", key).Trace("gcs: GetFile")
+	r, err := b.bucket.Object(key).NewReader(b.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (b *GCSArchiveBackend) attrs(pth string) (*storage.ObjectAttrs, error) {
+	key := path.Join(b.prefix, pth)
+	log.WithField("key", key).Trace("gcs: Attrs")
+	attrs, err := b.bucket.Object(key).Attrs(b.ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, nil
+	}
+	return attrs, err
+}
+
+func (b *GCSArchiveBackend) Exists(pth string) (bool, error) {
+	attrs, err := b.attrs(pth)
+	if err != nil {
+		return false, err
+	}
+	return attrs != nil, nil
+}
+
+func (b *GCSArchiveBackend) Size(pth string) (int64, error) {
+	attrs, err := b.attrs(pth)
+	if err != nil || attrs == nil {
+		return 0, err
+	}
+	return attrs.Size, nil
+}
+
+func (b *GCSArchiveBackend) PutFile(pth string, in io.ReadCloser) error {
+	defer in.Close()
+	key := path.Join(b.prefix, pth)
+	log.WithField("key", key).Trace("gcs: PutFile")
+
+	ctx, cancel := context.WithCancel(b.ctx)
+	defer cancel()
+	w := b.bucket.Object(key).NewWriter(ctx)
+	w.ChunkSize = gcsChunkSize
+	if _, err := io.Copy(w, in); err != nil {
+		// Cancelling the context aborts the upload.
+		cancel()
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (b *GCSArchiveBackend) ListFiles(pth string) (chan string, chan error) {
+	prefix := path.Join(b.prefix, pth)
+	ch := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(ch)
+		defer close(errs)
+		it := b.bucket.Objects(b.ctx, &storage.Query{Prefix: prefix})
+		for {
+			attrs, err := it.Next()
+			if err == iterator.Done {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			log.WithField("key", attrs.Name).Trace("gcs: ListFiles")
+			ch <- attrs.Name
+		}
+	}()
+	return ch, errs
+}
+
+func (b *GCSArchiveBackend) CanListFiles() bool {
+	return true
+}
+
+func makeGCSBackend(bucket string, prefix string, opts ConnectOptions) (ArchiveBackend, error) {
+	log.WithFields(log.Fields{"bucket": bucket,
+		"prefix":   prefix,
+		"endpoint": opts.GCSEndpoint}).Debug("gcs: making backend")
+
+	var clientOpts []option.ClientOption
+	if opts.GCSEndpoint != "" {
+		clientOpts = append(clientOpts, option.WithEndpoint(opts.GCSEndpoint))
+	}
+	if opts.GCSCredentialsFile != "" {
+		clientOpts = append(clientOpts, option.WithCredentialsFile(opts.GCSCredentialsFile))
+	} else if opts.UnsignedRequests {
+		clientOpts = append(clientOpts, option.WithoutAuthentication())
+	}
+	if opts.UserAgent != "" {
+		clientOpts = append(clientOpts, option.WithUserAgent(opts.UserAgent))
+	}
+
+	client, err := storage.NewClient(opts.Context, clientOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create GCS client")
+	}
+
+	backend := GCSArchiveBackend{
+		ctx:    opts.Context,
+		bucket: client.Bucket(bucket),
+		prefix: prefix,
+	}
+	return &backend, nil
+}
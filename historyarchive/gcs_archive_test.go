@@ -0,0 +1,143 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGCS is a stand-in for the subset of the Google Cloud Storage JSON and
+// XML APIs used by the GCS backend.
+type fakeGCS struct {
+	bucket string
+	mutex  sync.Mutex
+	files  map[string][]byte
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/"+f.bucket+"/o":
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		var meta struct {
+			Name string `json:"name"`
+		}
+		part, err := mr.NextPart()
+		if err == nil {
+			err = json.NewDecoder(part).Decode(&meta)
+		}
+		if err == nil {
+			part, err = mr.NextPart()
+		}
+		var body []byte
+		if err == nil {
+			body, err = ioutil.ReadAll(part)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.files[meta.Name] = body
+		json.NewEncoder(w).Encode(map[string]string{"bucket": f.bucket, "name": meta.Name, "size": strconv.Itoa(len(body))})
+
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/"+f.bucket+"/o":
+		prefix := r.URL.Query().Get("prefix")
+		names := []string{}
+		for name := range f.files {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		items := []map[string]string{}
+		for _, name := range names {
+			items = append(items, map[string]string{"name": name})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/"+f.bucket+"/o/"):
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/"+f.bucket+"/o/")
+		body, ok := f.files[name]
+		if !ok {
+			http.Error(w, `{"error":{"code":404,"message":"not found"}}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"bucket": f.bucket, "name": name, "size": strconv.Itoa(len(body))})
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/"+f.bucket+"/"):
+		body, ok := f.files[strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+	}
+}
+
+func TestGCSBackend(t *testing.T) {
+	fake := &fakeGCS{bucket: "archive", files: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	arch, err := Connect("gcs://archive/prd/core", ConnectOptions{
+		GCSEndpoint:      server.URL + "/storage/v1/",
+		UnsignedRequests: true,
+	})
+	require.NoError(t, err)
+	backend := arch.backend
+
+	ok, err := backend.Exists("bucket/00/11/22/file")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, backend.PutFile("bucket/00/11/22/file", ioutil.NopCloser(strings.NewReader("content"))))
+	require.NoError(t, backend.PutFile("ledger/00/11/22/file", ioutil.NopCloser(strings.NewReader("other"))))
+	assert.Equal(t, []byte("content"), fake.files["prd/core/bucket/00/11/22/file"])
+
+	ok, err = backend.Exists("bucket/00/11/22/file")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	size, err := backend.Size("bucket/00/11/22/file")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), size)
+
+	r, err := backend.GetFile("bucket/00/11/22/file")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "content", string(body))
+
+	ch, errs := backend.ListFiles("bucket")
+	names := []string{}
+	for name := range ch {
+		names = append(names, name)
+	}
+	assert.NoError(t, <-errs)
+	assert.Equal(t, []string{"prd/core/bucket/00/11/22/file"}, names)
+}
@@ -14,6 +14,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/metriqorg/go/support/errors"
@@ -203,7 +204,11 @@ func makeS3Backend(bucket string, prefix string, opts ConnectOptions) (ArchiveBa
 		Region:   aws.String(opts.S3Region),
 		Endpoint: aws.String(opts.S3Endpoint),
 	}
-	cfg = cfg.WithS3ForcePathStyle(true)
+	cfg = cfg.WithS3ForcePathStyle(!opts.S3VirtualHostedStyle)
+	if opts.S3AccessKeyID != "" {
+		cfg = cfg.WithCredentials(credentials.NewStaticCredentials(
+			opts.S3AccessKeyID, opts.S3SecretAccessKey, opts.S3SessionToken))
+	}
 
 	sess, err := session.NewSession(cfg)
 	if err != nil {
@@ -0,0 +1,381 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+const (
+	// DefaultSyncPollInterval is how often the source root HAS is polled
+	// when SyncOptions.PollInterval is zero.
+	DefaultSyncPollInterval = time.Minute
+	// DefaultSyncConcurrency is the number of checkpoints copied at once
+	// when SyncOptions.Concurrency is zero.
+	DefaultSyncConcurrency = 16
+)
+
+// SyncOptions configures a Syncer.
+type SyncOptions struct {
+	// StatePath is the file the progress of the sync is saved to, so that it
+	// resumes where it stopped after a restart.
+	StatePath string
+	// PollInterval is how often the source root HAS is polled for new
+	// checkpoints. DefaultSyncPollInterval is used when zero.
+	PollInterval time.Duration
+	// Concurrency is the number of checkpoints copied at once.
+	// DefaultSyncConcurrency is used when zero.
+	Concurrency int
+	// Low is the first ledger to copy if there is no saved progress and the
+	// destination archive has no root HAS.
+	Low uint32
+	// SkipOptional skips optional (SCP) checkpoint files.
+	SkipOptional bool
+	// TempDir is where files are downloaded to be verified before they are
+	// copied. The default directory for temporary files is used when empty.
+	TempDir string
+	// Registry, if set, is used to register the metrics of the sync.
+	Registry  *prometheus.Registry
+	Namespace string
+}
+
+// syncState is the progress of a sync saved in SyncOptions.StatePath.
+type syncState struct {
+	// Checkpoint is the last checkpoint that was copied along with every
+	// checkpoint before it.
+	Checkpoint uint32 `json:"checkpoint"`
+}
+
+type syncMetrics struct {
+	sourceLedger prometheus.Gauge
+	syncedLedger prometheus.Gauge
+	lag          prometheus.Gauge
+	files        prometheus.Counter
+	bytes        prometheus.Counter
+	errors       prometheus.Counter
+}
+
+func newSyncMetrics(namespace string) syncMetrics {
+	gauge := func(name, help string) prometheus.Gauge {
+		return prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "history_archive_sync", Name: name, Help: help,
+		})
+	}
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "history_archive_sync", Name: name, Help: help,
+		})
+	}
+	return syncMetrics{
+		sourceLedger: gauge("source_ledger", "current ledger of the source archive"),
+		syncedLedger: gauge("synced_ledger", "last checkpoint ledger copied to the destination archive"),
+		lag:          gauge("lag_checkpoints", "number of checkpoints of the source archive not copied yet"),
+		files:        counter("files_copied_total", "number of files copied"),
+		bytes:        counter("bytes_copied_total", "number of bytes copied"),
+		errors:       counter("errors_total", "number of failed sync passes"),
+	}
+}
+
+func (m syncMetrics) register(registry *prometheus.Registry) error {
+	for _, c := range []prometheus.Collector{m.sourceLedger, m.syncedLedger, m.lag, m.files, m.bytes, m.errors} {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Syncer keeps a destination archive in sync with a source archive. It
+// follows the root HAS of the source and copies new checkpoints and the
+// buckets they reference, verifying every file before it is written, and
+// saves its progress so it does not rescan the destination after a restart.
+type Syncer struct {
+	src     *Archive
+	dst     *Archive
+	opts    SyncOptions
+	metrics syncMetrics
+
+	// checkpoint is the last checkpoint copied, valid if synced is true.
+	checkpoint uint32
+	synced     bool
+
+	bucketsMutex sync.Mutex
+	// buckets are the buckets known to be in the destination archive.
+	buckets map[Hash]bool
+}
+
+// NewSyncer returns a Syncer copying src to dst, resuming from the progress
+// saved in opts.StatePath if any.
+func NewSyncer(src, dst *Archive, opts SyncOptions) (*Syncer, error) {
+	if opts.StatePath == "" {
+		return nil, errors.New("sync state path is empty")
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = DefaultSyncPollInterval
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = DefaultSyncConcurrency
+	}
+	s := &Syncer{
+		src:     src,
+		dst:     dst,
+		opts:    opts,
+		metrics: newSyncMetrics(opts.Namespace),
+		buckets: map[Hash]bool{},
+	}
+	if opts.Registry != nil {
+		if err := s.metrics.register(opts.Registry); err != nil {
+			return nil, errors.Wrap(err, "could not register sync metrics")
+		}
+	}
+
+	buf, err := ioutil.ReadFile(opts.StatePath)
+	if err == nil {
+		var state syncState
+		if err = json.Unmarshal(buf, &state); err != nil {
+			return nil, errors.Wrapf(err, "could not parse sync state %s", opts.StatePath)
+		}
+		s.checkpoint, s.synced = state.Checkpoint, true
+		log.WithField("checkpoint", s.checkpoint).Info("sync: resuming from saved state")
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "could not read sync state %s", opts.StatePath)
+	} else if ok, _ := dst.backend.Exists(rootHASPath); ok {
+		has, err := dst.GetRootHAS()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get destination root HAS")
+		}
+		s.checkpoint, s.synced = has.CurrentLedger, true
+		log.WithField("checkpoint", s.checkpoint).Info("sync: resuming from destination root HAS")
+	}
+	if s.synced {
+		s.metrics.syncedLedger.Set(float64(s.checkpoint))
+	}
+	return s, nil
+}
+
+// Checkpoint returns the last checkpoint copied along with every checkpoint
+// before it, and false if no checkpoint was copied yet.
+func (s *Syncer) Checkpoint() (uint32, bool) {
+	return s.checkpoint, s.synced
+}
+
+// Run syncs the archives every poll interval until the context is done.
+// Failed passes are logged and retried at the next interval.
+func (s *Syncer) Run(ctx context.Context) error {
+	for {
+		if err := s.SyncOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.WithError(err).Error("sync: pass failed")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// SyncOnce copies the checkpoints of the source archive that are not in the
+// destination archive yet, then updates the destination root HAS.
+func (s *Syncer) SyncOnce(ctx context.Context) (err error) {
+	defer func() {
+		if err != nil {
+			s.metrics.errors.Inc()
+		}
+	}()
+
+	has, err := s.src.GetRootHAS()
+	if err != nil {
+		return errors.Wrap(err, "could not get source root HAS")
+	}
+	s.metrics.sourceLedger.Set(float64(has.CurrentLedger))
+
+	manager := s.src.checkpointManager
+	freq := manager.GetCheckpointFrequency()
+	next := manager.GetCheckpoint(s.opts.Low)
+	if s.synced {
+		next = s.checkpoint + freq
+	}
+	s.updateLag(has.CurrentLedger)
+
+	for next <= has.CurrentLedger {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		batch := []uint32{}
+		for chk := next; chk <= has.CurrentLedger && len(batch) < s.opts.Concurrency; chk += freq {
+			batch = append(batch, chk)
+		}
+		if err = s.copyCheckpoints(batch); err != nil {
+			return err
+		}
+		s.checkpoint, s.synced = batch[len(batch)-1], true
+		if err = s.saveState(); err != nil {
+			return err
+		}
+		s.metrics.syncedLedger.Set(float64(s.checkpoint))
+		s.updateLag(has.CurrentLedger)
+		log.WithField("checkpoint", s.checkpoint).Debug("sync: copied checkpoints")
+		next = s.checkpoint + freq
+	}
+
+	if s.synced && s.checkpoint == has.CurrentLedger {
+		if err = s.dst.PutRootHAS(has, &CommandOptions{Force: true}); err != nil {
+			return errors.Wrap(err, "could not update destination root HAS")
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) updateLag(current uint32) {
+	lag := 0.0
+	freq := s.src.checkpointManager.GetCheckpointFrequency()
+	if !s.synced {
+		// The source may not have reached the first checkpoint to copy yet.
+		if low := s.src.checkpointManager.GetCheckpoint(s.opts.Low); current >= low {
+			lag = float64((current-low)/freq + 1)
+		}
+	} else if current > s.checkpoint {
+		lag = float64((current - s.checkpoint) / freq)
+	}
+	s.metrics.lag.Set(lag)
+}
+
+// copyCheckpoints copies the checkpoints concurrently and returns the first
+// error.
+func (s *Syncer) copyCheckpoints(checkpoints []uint32) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(checkpoints))
+	for _, chk := range checkpoints {
+		wg.Add(1)
+		go func(chk uint32) {
+			defer wg.Done()
+			if err := s.copyCheckpoint(chk); err != nil {
+				errs <- errors.Wrapf(err, "could not copy checkpoint 0x%8.8x", chk)
+			}
+		}(chk)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// copyCheckpoint copies the files of the checkpoint and the buckets it
+// references. The checkpoint HAS is copied last so that it is only in the
+// destination archive once the rest of the checkpoint is.
+func (s *Syncer) copyCheckpoint(chk uint32) error {
+	has, err := s.src.GetCheckpointHAS(chk)
+	if err != nil {
+		return err
+	}
+	buckets, err := has.Buckets()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		if err = s.copyBucket(bucket); err != nil {
+			return err
+		}
+	}
+	for _, cat := range Categories() {
+		if cat == "history" || (s.opts.SkipOptional && !categoryRequired(cat)) {
+			continue
+		}
+		err = s.copyFile(CategoryCheckpointPath(cat, chk))
+		if err != nil && categoryRequired(cat) {
+			return err
+		}
+	}
+	return s.copyFile(CategoryCheckpointPath("history", chk))
+}
+
+func (s *Syncer) copyBucket(bucket Hash) error {
+	s.bucketsMutex.Lock()
+	known := s.buckets[bucket]
+	s.bucketsMutex.Unlock()
+	if known {
+		return nil
+	}
+
+	pth := BucketPath(bucket)
+	exists, err := s.dst.backend.Exists(pth)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = s.copyFile(pth); err != nil {
+			return err
+		}
+	}
+
+	s.bucketsMutex.Lock()
+	s.buckets[bucket] = true
+	s.bucketsMutex.Unlock()
+	return nil
+}
+
+// copyFile downloads the file to a temporary file, verifies it and writes it
+// to the destination archive.
+func (s *Syncer) copyFile(pth string) error {
+	rdr, err := s.src.backend.GetFile(pth)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	tmp, err := ioutil.TempFile(s.opts.TempDir, "sync-"+filepath.Base(pth)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, rdr)
+	if err != nil {
+		return errors.Wrapf(err, "could not download %s", pth)
+	}
+	if err = verifyArchiveFile(pth, tmp.Name()); err != nil {
+		return errors.Wrapf(err, "could not verify %s", pth)
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err = s.dst.backend.PutFile(pth, ioutil.NopCloser(tmp)); err != nil {
+		return errors.Wrapf(err, "could not write %s", pth)
+	}
+	s.metrics.files.Inc()
+	s.metrics.bytes.Add(float64(size))
+	return nil
+}
+
+// saveState atomically writes the progress to the state file.
+func (s *Syncer) saveState() error {
+	buf, err := json.Marshal(syncState{Checkpoint: s.checkpoint})
+	if err != nil {
+		return err
+	}
+	tmp := s.opts.StatePath + ".tmp"
+	if err = ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return errors.Wrap(err, "could not write sync state")
+	}
+	if err = os.Rename(tmp, s.opts.StatePath); err != nil {
+		return errors.Wrap(err, "could not write sync state")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"context"
+	"crypto/rand"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addValidCheckpoint adds a checkpoint with gzipped files and a bucket
+// matching its hash to the archive, and makes it the current ledger.
+func addValidCheckpoint(t *testing.T, arch *Archive, chk uint32) Hash {
+	bucket, _ := putGzippedBucket(t, arch.backend, 1024)
+	var has HistoryArchiveState
+	has.CurrentLedger = chk
+	has.CurrentBuckets[0].Curr = bucket.String()
+	for _, cat := range Categories() {
+		if cat == "history" {
+			continue
+		}
+		buf := make([]byte, 128)
+		_, err := rand.Read(buf)
+		require.NoError(t, err)
+		arch.backend.(*MockArchiveBackend).files[CategoryCheckpointPath(cat, chk)] = gzipBytes(t, buf)
+	}
+	opts := &CommandOptions{Force: true}
+	require.NoError(t, arch.PutCheckpointHAS(chk, has, opts))
+	require.NoError(t, arch.PutRootHAS(has, opts))
+	return bucket
+}
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	var m dto.Metric
+	require.NoError(t, g.Write(&m))
+	return m.GetGauge().GetValue()
+}
+
+func TestSync(t *testing.T) {
+	src := GetTestMockArchive()
+	dst := GetTestMockArchive()
+	statePath := filepath.Join(t.TempDir(), "sync.json")
+	for _, chk := range []uint32{63, 127, 191} {
+		addValidCheckpoint(t, src, chk)
+	}
+
+	syncer, err := NewSyncer(src, dst, SyncOptions{StatePath: statePath, Concurrency: 2})
+	require.NoError(t, err)
+	_, ok := syncer.Checkpoint()
+	assert.False(t, ok)
+	require.NoError(t, syncer.SyncOnce(context.Background()))
+
+	assert.Equal(t, src.backend.(*MockArchiveBackend).files, dst.backend.(*MockArchiveBackend).files)
+	state, err := ioutil.ReadFile(statePath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"checkpoint": 191}`, string(state))
+	assert.Equal(t, float64(0), gaugeValue(t, syncer.metrics.lag))
+	assert.Equal(t, float64(191), gaugeValue(t, syncer.metrics.syncedLedger))
+
+	// A new syncer resumes from the saved state and only copies new
+	// checkpoints.
+	addValidCheckpoint(t, src, 255)
+	delete(dst.backend.(*MockArchiveBackend).files, CategoryCheckpointPath("ledger", 63))
+	syncer, err = NewSyncer(src, dst, SyncOptions{StatePath: statePath})
+	require.NoError(t, err)
+	chk, ok := syncer.Checkpoint()
+	require.True(t, ok)
+	assert.Equal(t, uint32(191), chk)
+	require.NoError(t, syncer.SyncOnce(context.Background()))
+
+	exists, err := dst.CategoryCheckpointExists("ledger", 255)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = dst.CategoryCheckpointExists("ledger", 63)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	has, err := dst.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(255), has.CurrentLedger)
+}
+
+func TestSyncResumesFromDestinationRootHAS(t *testing.T) {
+	src := GetTestMockArchive()
+	dst := GetTestMockArchive()
+	addValidCheckpoint(t, src, 63)
+	addValidCheckpoint(t, dst, 63)
+	addValidCheckpoint(t, src, 127)
+
+	syncer, err := NewSyncer(src, dst, SyncOptions{StatePath: filepath.Join(t.TempDir(), "sync.json")})
+	require.NoError(t, err)
+	chk, ok := syncer.Checkpoint()
+	require.True(t, ok)
+	assert.Equal(t, uint32(63), chk)
+	assert.Equal(t, float64(63), gaugeValue(t, syncer.metrics.syncedLedger))
+}
+
+func TestSyncLagBeforeLow(t *testing.T) {
+	src := GetTestMockArchive()
+	dst := GetTestMockArchive()
+	addValidCheckpoint(t, src, 63)
+
+	// The source has not reached the first checkpoint to copy yet.
+	syncer, err := NewSyncer(src, dst, SyncOptions{StatePath: filepath.Join(t.TempDir(), "sync.json"), Low: 1000})
+	require.NoError(t, err)
+	require.NoError(t, syncer.SyncOnce(context.Background()))
+	assert.Equal(t, float64(0), gaugeValue(t, syncer.metrics.lag))
+	_, ok := syncer.Checkpoint()
+	assert.False(t, ok)
+}
+
+func TestSyncRejectsCorruptFiles(t *testing.T) {
+	src := GetTestMockArchive()
+	dst := GetTestMockArchive()
+	statePath := filepath.Join(t.TempDir(), "sync.json")
+	addValidCheckpoint(t, src, 63)
+	bucket := addValidCheckpoint(t, src, 127)
+	src.backend.(*MockArchiveBackend).files[BucketPath(bucket)] = gzipBytes(t, []byte("corrupt"))
+
+	registry := prometheus.NewRegistry()
+	syncer, err := NewSyncer(src, dst, SyncOptions{StatePath: statePath, Concurrency: 1, Registry: registry})
+	require.NoError(t, err)
+	err = syncer.SyncOnce(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bucket hash does not match")
+
+	chk, ok := syncer.Checkpoint()
+	require.True(t, ok)
+	assert.Equal(t, uint32(63), chk)
+	assert.Equal(t, float64(1), gaugeValue(t, syncer.metrics.lag))
+	exists, err := dst.backend.Exists(BucketPath(bucket))
+	require.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = dst.backend.Exists(rootHASPath)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	assert.NotEmpty(t, families)
+}
@@ -78,6 +78,12 @@ func CategoryCheckpointPath(cat string, chk uint32) string {
 	return path.Join(cat, pre, fmt.Sprintf("%s-%8.8x.%s", cat, chk, ext))
 }
 
+// RootHASPath returns the path of the HAS describing the most recent
+// checkpoint in the archive.
+func RootHASPath() string {
+	return rootHASPath
+}
+
 func BucketPath(bucket Hash) string {
 	pre := HashPrefix(bucket)
 	return path.Join("bucket", pre.Path(), fmt.Sprintf("bucket-%s.xdr.gz", bucket))
@@ -18,6 +18,16 @@ import (
 	"github.com/metriqorg/go/xdr"
 )
 
+// HashMismatchError is returned when the content of a file of an archive does
+// not match its expected hash.
+type HashMismatchError struct {
+	msg string
+}
+
+func (e HashMismatchError) Error() string {
+	return e.msg
+}
+
 // Transaction sets are sorted in two different orders: one for hashing and
 // one for applying. Hash order is just the lexicographic order of the
 // hashes of the txs themselves. Apply order is built on top, by xoring
@@ -91,8 +101,8 @@ func (arch *Archive) VerifyLedgerHeaderHistoryEntry(entry *xdr.LedgerHeaderHisto
 		return err
 	}
 	if h != Hash(entry.Hash) {
-		return fmt.Errorf("Ledger %d expected hash %s, got %s",
-			entry.Header.LedgerSeq, Hash(entry.Hash), Hash(h))
+		return HashMismatchError{fmt.Sprintf("Ledger %d expected hash %s, got %s",
+			entry.Header.LedgerSeq, Hash(entry.Hash), Hash(h))}
 	}
 	arch.mutex.Lock()
 	defer arch.mutex.Unlock()
@@ -197,8 +207,8 @@ func checkBucketHash(hasher hash.Hash, expect Hash) error {
 	sum := hasher.Sum([]byte{})
 	copy(actual[:], sum[:])
 	if actual != expect {
-		return fmt.Errorf("Bucket hash mismatch: expected %s, got %s",
-			expect, actual)
+		return HashMismatchError{fmt.Sprintf("Bucket hash mismatch: expected %s, got %s",
+			expect, actual)}
 	}
 	return nil
 }
@@ -29,6 +29,10 @@ type XdrStream struct {
 	validateHash bool
 	expectedHash [sha256.Size]byte
 	xdrDecoder   *xdr.BytesDecoder
+
+	// onHashMismatch is called by Close when the stream does not match the
+	// expected hash.
+	onHashMismatch func(err error)
 }
 
 type countReader struct {
@@ -103,7 +107,8 @@ func (x *XdrStream) ExpectedHash() ([sha256.Size]byte, bool) {
 }
 
 // Close closes all internal readers and checks if the expected hash
-// (if set by SetExpectedHash) matches the actual hash of the stream.
+// (if set by SetExpectedHash) matches the actual hash of the stream,
+// returning a HashMismatchError if it does not.
 func (x *XdrStream) Close() error {
 	if x.validateHash {
 		// Read all remaining data from rdr
@@ -114,18 +119,31 @@ func (x *XdrStream) Close() error {
 			return errors.Wrap(err, "Error reading remaining bytes from rdr")
 		}
 
-		actualHash := x.sha256Hash.Sum([]byte{})
+		var actualHash Hash
+		copy(actualHash[:], x.sha256Hash.Sum([]byte{}))
 
 		if !bytes.Equal(x.expectedHash[:], actualHash[:]) {
 			// close the internal readers to avoid memory leaks
 			x.closeReaders()
-			return errors.New("Stream hash does not match expected hash!")
+			err = HashMismatchError{fmt.Sprintf("Stream hash does not match expected hash: expected %s, got %s",
+				Hash(x.expectedHash), actualHash)}
+			if x.onHashMismatch != nil {
+				x.onHashMismatch(err)
+			}
+			return err
 		}
 	}
 
 	return x.closeReaders()
 }
 
+// abort closes the internal readers without reading the rest of the stream
+// or checking its hash, for streams that are not going to be used.
+func (x *XdrStream) abort() error {
+	x.validateHash = false
+	return x.closeReaders()
+}
+
 func (x *XdrStream) closeReaders() error {
 	var err error
 
@@ -0,0 +1,390 @@
+// Package archivepublisher writes history archives from the ledgers produced
+// by a ledgerbackend.LedgerBackend. It can be used to create archives for
+// private networks and test fixtures without running Gravity's publish
+// commands.
+package archivepublisher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// BucketLevel holds the entries of the curr and snap buckets of a single
+// bucket list level. Entries are written in the given order, so they must
+// already be sorted the way Gravity sorts them (including the leading
+// METAENTRY for protocol 11 and later).
+type BucketLevel struct {
+	Curr []xdr.BucketEntry
+	Snap []xdr.BucketEntry
+}
+
+// BucketList is the state of all levels of a bucket list.
+type BucketList [historyarchive.NumLevels]BucketLevel
+
+// BucketListSource provides the bucket list state at checkpoint ledgers.
+type BucketListSource interface {
+	// BucketList returns the bucket list as of the end of the given
+	// checkpoint ledger.
+	BucketList(ctx context.Context, checkpoint uint32) (BucketList, error)
+}
+
+// Config configures a Publisher.
+type Config struct {
+	// Backend provides the ledgers to publish.
+	Backend ledgerbackend.LedgerBackend
+	// Archive is where the archive files are written.
+	Archive historyarchive.ArchiveBackend
+	// NetworkPassphrase is recorded in the published HAS files.
+	NetworkPassphrase string
+	// CheckpointFrequency is the number of ledgers between checkpoints. If 0,
+	// historyarchive.DefaultCheckpointFrequency is used.
+	CheckpointFrequency uint32
+	// Server is recorded in the server field of the published HAS files.
+	Server string
+	// BucketList is optional. When set, the buckets of every checkpoint are
+	// written to the archive and referenced from its HAS. Otherwise all
+	// buckets in the HAS are empty.
+	BucketList BucketListSource
+}
+
+// Publisher writes checkpoint category files, buckets and HAS files for the
+// ledgers of a LedgerBackend.
+//
+// Ledger backends never return the genesis ledger, so the first checkpoint of
+// a published archive starts at ledger 2.
+type Publisher struct {
+	config            Config
+	checkpointManager historyarchive.CheckpointManager
+}
+
+// New returns a Publisher writing to config.Archive.
+func New(config Config) (*Publisher, error) {
+	if config.Backend == nil {
+		return nil, errors.New("ledger backend is required")
+	}
+	if config.Archive == nil {
+		return nil, errors.New("archive backend is required")
+	}
+	return &Publisher{
+		config:            config,
+		checkpointManager: historyarchive.NewCheckpointManager(config.CheckpointFrequency),
+	}, nil
+}
+
+// PublishCheckpoint publishes the checkpoint ending at the given ledger.
+func (p *Publisher) PublishCheckpoint(ctx context.Context, checkpoint uint32) error {
+	if !p.checkpointManager.IsCheckpoint(checkpoint) {
+		return errors.Errorf("ledger %d is not a checkpoint ledger", checkpoint)
+	}
+	return p.PublishRange(ctx, checkpoint, checkpoint)
+}
+
+// PublishRange publishes every checkpoint containing a ledger in [from, to].
+// The root HAS is updated after each checkpoint, so an interrupted run leaves
+// a consistent archive behind. It is left as is when it already points at a
+// later checkpoint, so older checkpoints can be republished.
+func (p *Publisher) PublishRange(ctx context.Context, from, to uint32) error {
+	if from > to {
+		return errors.Errorf("invalid range: from (%d) > to (%d)", from, to)
+	}
+	first := p.checkpointManager.GetCheckpoint(from)
+	last := p.checkpointManager.GetCheckpoint(to)
+	low := p.checkpointLow(first)
+	if err := p.config.Backend.PrepareRange(ctx, ledgerbackend.BoundedRange(low, last)); err != nil {
+		return errors.Wrapf(err, "error preparing range [%d, %d]", low, last)
+	}
+
+	freq := p.checkpointManager.GetCheckpointFrequency()
+	for chk := first; ; chk += freq {
+		if err := p.publishCheckpoint(ctx, chk); err != nil {
+			return errors.Wrapf(err, "error publishing checkpoint %d", chk)
+		}
+		if chk >= last {
+			return nil
+		}
+	}
+}
+
+func (p *Publisher) checkpointLow(checkpoint uint32) uint32 {
+	low := p.checkpointManager.GetCheckpointRange(checkpoint).Low
+	if low < 2 {
+		low = 2
+	}
+	return low
+}
+
+func (p *Publisher) publishCheckpoint(ctx context.Context, checkpoint uint32) error {
+	var ledgers, transactions, results, scp bytes.Buffer
+	ledgerWriter := newXdrGzWriter(&ledgers)
+	transactionWriter := newXdrGzWriter(&transactions)
+	resultWriter := newXdrGzWriter(&results)
+	scpWriter := newXdrGzWriter(&scp)
+
+	var header xdr.LedgerHeaderHistoryEntry
+	for seq := p.checkpointLow(checkpoint); seq <= checkpoint; seq++ {
+		meta, err := p.config.Backend.GetLedger(ctx, seq)
+		if err != nil {
+			return errors.Wrapf(err, "error getting ledger %d", seq)
+		}
+		header = meta.LedgerHeaderHistoryEntry()
+		if err = ledgerWriter.write(header); err != nil {
+			return err
+		}
+
+		if count := meta.CountTransactions(); count > 0 {
+			entry, err := transactionHistoryEntry(meta)
+			if err != nil {
+				return err
+			}
+			if err = transactionWriter.write(entry); err != nil {
+				return err
+			}
+			resultEntry := xdr.TransactionHistoryResultEntry{LedgerSeq: xdr.Uint32(seq)}
+			for i := 0; i < count; i++ {
+				resultEntry.TxResultSet.Results = append(resultEntry.TxResultSet.Results, meta.TransactionResultPair(i))
+			}
+			if err = resultWriter.write(resultEntry); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range scpInfo(meta) {
+			if err = scpWriter.write(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	has := historyarchive.HistoryArchiveState{
+		Version:           1,
+		Server:            p.config.Server,
+		CurrentLedger:     checkpoint,
+		NetworkPassphrase: p.config.NetworkPassphrase,
+	}
+	var zero historyarchive.Hash
+	for i := range has.CurrentBuckets {
+		has.CurrentBuckets[i].Curr = zero.String()
+		has.CurrentBuckets[i].Snap = zero.String()
+	}
+	if p.config.BucketList != nil {
+		if err := p.publishBuckets(ctx, checkpoint, header.Header.BucketListHash, &has); err != nil {
+			return err
+		}
+	}
+
+	files := []struct {
+		category string
+		writer   *xdrGzWriter
+		buf      *bytes.Buffer
+	}{
+		{"ledger", ledgerWriter, &ledgers},
+		{"transactions", transactionWriter, &transactions},
+		{"results", resultWriter, &results},
+		{"scp", scpWriter, &scp},
+	}
+	for _, file := range files {
+		if err := file.writer.Close(); err != nil {
+			return err
+		}
+		pth := historyarchive.CategoryCheckpointPath(file.category, checkpoint)
+		if err := p.putFile(pth, file.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	// The checkpoint HAS goes last so readers never see a HAS referring to
+	// files that are not there yet.
+	buf, err := json.MarshalIndent(has, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "error encoding HAS")
+	}
+	if err = p.putFile(historyarchive.CategoryCheckpointPath("history", checkpoint), buf); err != nil {
+		return err
+	}
+
+	// Republishing an older checkpoint must not move the root HAS back.
+	current, err := p.rootHASCurrentLedger()
+	if err != nil {
+		return err
+	}
+	if checkpoint < current {
+		return nil
+	}
+	return p.putFile(historyarchive.RootHASPath(), buf)
+}
+
+// rootHASCurrentLedger returns the current ledger of the root HAS of the
+// archive, or 0 if there is none yet.
+func (p *Publisher) rootHASCurrentLedger() (uint32, error) {
+	pth := historyarchive.RootHASPath()
+	exists, err := p.config.Archive.Exists(pth)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error checking if %s exists", pth)
+	}
+	if !exists {
+		return 0, nil
+	}
+	rdr, err := p.config.Archive.GetFile(pth)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error reading %s", pth)
+	}
+	defer rdr.Close()
+	var has historyarchive.HistoryArchiveState
+	if err = json.NewDecoder(rdr).Decode(&has); err != nil {
+		return 0, errors.Wrapf(err, "error decoding %s", pth)
+	}
+	return has.CurrentLedger, nil
+}
+
+// publishBuckets writes the non-empty buckets of the bucket list at the
+// checkpoint and records them in has. Nothing is written unless the bucket
+// list hashes to bucketListHash, the hash in the checkpoint ledger header.
+func (p *Publisher) publishBuckets(ctx context.Context, checkpoint uint32, bucketListHash xdr.Hash, has *historyarchive.HistoryArchiveState) error {
+	bucketList, err := p.config.BucketList.BucketList(ctx, checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "error getting bucket list")
+	}
+
+	buckets := map[historyarchive.Hash][]byte{}
+	listHasher := sha256.New()
+	for i, level := range bucketList {
+		var levelHashes [2]historyarchive.Hash
+		for j, entries := range [][]xdr.BucketEntry{level.Curr, level.Snap} {
+			if len(entries) == 0 {
+				// Empty buckets have the zero hash.
+				continue
+			}
+			hash, content, err := encodeBucket(entries)
+			if err != nil {
+				return err
+			}
+			levelHashes[j] = hash
+			buckets[hash] = content
+		}
+		has.CurrentBuckets[i].Curr = levelHashes[0].String()
+		has.CurrentBuckets[i].Snap = levelHashes[1].String()
+
+		levelHash := sha256.Sum256(append(levelHashes[0][:], levelHashes[1][:]...))
+		listHasher.Write(levelHash[:])
+	}
+
+	var actual historyarchive.Hash
+	copy(actual[:], listHasher.Sum(nil))
+	if actual != historyarchive.Hash(bucketListHash) {
+		return errors.Errorf(
+			"bucket list hash %s does not match ledger header hash %s",
+			actual, historyarchive.Hash(bucketListHash),
+		)
+	}
+
+	for hash, content := range buckets {
+		pth := historyarchive.BucketPath(hash)
+		exists, err := p.config.Archive.Exists(pth)
+		if err != nil {
+			return errors.Wrapf(err, "error checking if %s exists", pth)
+		}
+		if exists {
+			continue
+		}
+		if err = p.putFile(pth, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeBucket returns the hash of a bucket, which is the hash of its
+// uncompressed content, and its gzipped content.
+func encodeBucket(entries []xdr.BucketEntry) (historyarchive.Hash, []byte, error) {
+	var hash historyarchive.Hash
+	var buf bytes.Buffer
+	hasher := sha256.New()
+	writer := newXdrGzWriter(&buf)
+	writer.hasher = hasher
+	for _, entry := range entries {
+		if err := writer.write(entry); err != nil {
+			return hash, nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return hash, nil, err
+	}
+	copy(hash[:], hasher.Sum(nil))
+	return hash, buf.Bytes(), nil
+}
+
+func (p *Publisher) putFile(pth string, content []byte) error {
+	err := p.config.Archive.PutFile(pth, ioutil.NopCloser(bytes.NewReader(content)))
+	return errors.Wrapf(err, "error writing %s", pth)
+}
+
+// transactionHistoryEntry builds the transactions file entry of a ledger the
+// same way Gravity does: generalized transaction sets are stored in the
+// extension, next to an empty legacy set holding the previous ledger hash.
+func transactionHistoryEntry(meta xdr.LedgerCloseMeta) (xdr.TransactionHistoryEntry, error) {
+	entry := xdr.TransactionHistoryEntry{LedgerSeq: xdr.Uint32(meta.LedgerSequence())}
+	var txSet xdr.GeneralizedTransactionSet
+	switch meta.V {
+	case 0:
+		entry.TxSet = meta.MustV0().TxSet
+		return entry, nil
+	case 1:
+		txSet = meta.MustV1().TxSet
+	case 2:
+		txSet = meta.MustV2().TxSet
+	default:
+		return entry, errors.Errorf("unsupported LedgerCloseMeta.V: %d", meta.V)
+	}
+	entry.TxSet = xdr.TransactionSet{
+		PreviousLedgerHash: meta.PreviousLedgerHash(),
+		Txs:                []xdr.TransactionEnvelope{},
+	}
+	entry.Ext = xdr.TransactionHistoryEntryExt{V: 1, GeneralizedTxSet: &txSet}
+	return entry, nil
+}
+
+func scpInfo(meta xdr.LedgerCloseMeta) []xdr.ScpHistoryEntry {
+	switch meta.V {
+	case 0:
+		return meta.MustV0().ScpInfo
+	case 1:
+		return meta.MustV1().ScpInfo
+	case 2:
+		return meta.MustV2().ScpInfo
+	default:
+		return nil
+	}
+}
+
+// xdrGzWriter writes framed XDR values to a gzip stream, optionally hashing
+// the uncompressed stream as it goes.
+type xdrGzWriter struct {
+	gz     *gzip.Writer
+	hasher io.Writer
+}
+
+func newXdrGzWriter(w io.Writer) *xdrGzWriter {
+	return &xdrGzWriter{gz: gzip.NewWriter(w)}
+}
+
+func (w *xdrGzWriter) write(v interface{}) error {
+	var out io.Writer = w.gz
+	if w.hasher != nil {
+		out = io.MultiWriter(w.gz, w.hasher)
+	}
+	return errors.Wrap(xdr.MarshalFramed(out, v), "error encoding XDR")
+}
+
+func (w *xdrGzWriter) Close() error {
+	return w.gz.Close()
+}
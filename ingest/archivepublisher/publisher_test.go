@@ -0,0 +1,287 @@
+package archivepublisher
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/xdr"
+)
+
+type fakeLedgerBackend struct {
+	ledgers  map[uint32]xdr.LedgerCloseMeta
+	prepared ledgerbackend.Range
+}
+
+func (f *fakeLedgerBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	return 0, nil
+}
+
+func (f *fakeLedgerBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	return f.ledgers[sequence], nil
+}
+
+func (f *fakeLedgerBackend) PrepareRange(ctx context.Context, ledgerRange ledgerbackend.Range) error {
+	f.prepared = ledgerRange
+	return nil
+}
+
+func (f *fakeLedgerBackend) IsPrepared(ctx context.Context, ledgerRange ledgerbackend.Range) (bool, error) {
+	return f.prepared == ledgerRange, nil
+}
+
+func (f *fakeLedgerBackend) Close() error {
+	return nil
+}
+
+func testEnvelope() xdr.TransactionEnvelope {
+	return xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				Fee:           100,
+				SourceAccount: xdr.MustMuxedAddress(keypair.MustRandom().Address()),
+			},
+		},
+	}
+}
+
+func testResult(i byte) xdr.TransactionResultMeta {
+	return xdr.TransactionResultMeta{
+		Result: xdr.TransactionResultPair{
+			TransactionHash: xdr.Hash{i},
+			Result: xdr.TransactionResult{
+				FeeCharged: 100,
+				Result: xdr.TransactionResultResult{
+					Code:    xdr.TransactionResultCodeTxSuccess,
+					Results: &[]xdr.OperationResult{},
+				},
+			},
+		},
+	}
+}
+
+// testLedgers returns ledgers [2, 127]. Ledger 10 has a legacy transaction
+// set and ledger 100 a generalized one.
+func testLedgers() map[uint32]xdr.LedgerCloseMeta {
+	ledgers := map[uint32]xdr.LedgerCloseMeta{}
+	for seq := uint32(2); seq <= 127; seq++ {
+		header := xdr.LedgerHeaderHistoryEntry{
+			Hash:   xdr.Hash{byte(seq)},
+			Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(seq), PreviousLedgerHash: xdr.Hash{byte(seq - 1)}},
+		}
+		switch seq {
+		case 10:
+			ledgers[seq] = xdr.LedgerCloseMeta{V: 0, V0: &xdr.LedgerCloseMetaV0{
+				LedgerHeader: header,
+				TxSet:        xdr.TransactionSet{PreviousLedgerHash: xdr.Hash{9}, Txs: []xdr.TransactionEnvelope{testEnvelope()}},
+				TxProcessing: []xdr.TransactionResultMeta{testResult(1)},
+			}}
+		case 100:
+			baseFee := xdr.Int64(100)
+			ledgers[seq] = xdr.LedgerCloseMeta{V: 1, V1: &xdr.LedgerCloseMetaV1{
+				LedgerHeader: header,
+				TxSet: xdr.GeneralizedTransactionSet{
+					V: 1,
+					V1TxSet: &xdr.TransactionSetV1{
+						PreviousLedgerHash: xdr.Hash{99},
+						Phases: []xdr.TransactionPhase{{
+							V0Components: &[]xdr.TxSetComponent{{
+								Type: xdr.TxSetComponentTypeTxsetCompTxsMaybeDiscountedFee,
+								TxsMaybeDiscountedFee: &xdr.TxSetComponentTxsMaybeDiscountedFee{
+									BaseFee: &baseFee,
+									Txs:     []xdr.TransactionEnvelope{testEnvelope(), testEnvelope()},
+								},
+							}},
+						}},
+					},
+				},
+				TxProcessing: []xdr.TransactionResultMeta{testResult(2), testResult(3)},
+			}}
+		default:
+			ledgers[seq] = xdr.LedgerCloseMeta{V: 0, V0: &xdr.LedgerCloseMetaV0{LedgerHeader: header}}
+		}
+	}
+	return ledgers
+}
+
+func TestPublishRange(t *testing.T) {
+	backend := &fakeLedgerBackend{ledgers: testLedgers()}
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	})
+	require.NoError(t, err)
+
+	publisher, err := New(Config{
+		Backend:           backend,
+		Archive:           arch.Backend(),
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Server:            "test",
+	})
+	require.NoError(t, err)
+	require.NoError(t, publisher.PublishRange(context.Background(), 5, 100))
+	assert.Equal(t, ledgerbackend.BoundedRange(2, 127), backend.prepared)
+
+	ledgers, err := arch.GetLedgers(2, 127)
+	require.NoError(t, err)
+	assert.Len(t, ledgers, 126)
+	for seq, ledger := range ledgers {
+		assert.Equal(t, backend.ledgers[seq].LedgerHeaderHistoryEntry(), ledger.Header)
+	}
+
+	legacy := ledgers[10]
+	assert.Equal(t, backend.ledgers[10].MustV0().TxSet, legacy.Transaction.TxSet)
+	assert.Equal(t, int32(0), legacy.Transaction.Ext.V)
+	require.Len(t, legacy.TransactionResult.TxResultSet.Results, 1)
+	assert.Equal(t, xdr.Hash{1}, legacy.TransactionResult.TxResultSet.Results[0].TransactionHash)
+
+	generalized := ledgers[100]
+	assert.Equal(t, xdr.Hash{99}, generalized.Transaction.TxSet.PreviousLedgerHash)
+	assert.Empty(t, generalized.Transaction.TxSet.Txs)
+	require.Equal(t, int32(1), generalized.Transaction.Ext.V)
+	assert.Equal(t, backend.ledgers[100].MustV1().TxSet, *generalized.Transaction.Ext.GeneralizedTxSet)
+	require.Len(t, generalized.TransactionResult.TxResultSet.Results, 2)
+	assert.Equal(t, xdr.Hash{3}, generalized.TransactionResult.TxResultSet.Results[1].TransactionHash)
+
+	assert.Equal(t, xdr.Uint32(0), ledgers[11].Transaction.LedgerSeq)
+
+	has, err := arch.GetCheckpointHAS(63)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(63), has.CurrentLedger)
+	assert.Equal(t, "test", has.Server)
+	assert.Equal(t, network.TestNetworkPassphrase, has.NetworkPassphrase)
+	buckets, err := has.Buckets()
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+
+	root, err := arch.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(127), root.CurrentLedger)
+
+	exists, err := arch.CategoryCheckpointExists("scp", 127)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestPublishRangeKeepsLaterRootHAS(t *testing.T) {
+	backend := &fakeLedgerBackend{ledgers: testLedgers()}
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	})
+	require.NoError(t, err)
+	publisher, err := New(Config{
+		Backend:           backend,
+		Archive:           arch.Backend(),
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, publisher.PublishCheckpoint(context.Background(), 127))
+	root, err := arch.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(127), root.CurrentLedger)
+
+	// Republishing an older checkpoint leaves the root HAS as is...
+	require.NoError(t, publisher.PublishCheckpoint(context.Background(), 63))
+	root, err = arch.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(127), root.CurrentLedger)
+	has, err := arch.GetCheckpointHAS(63)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(63), has.CurrentLedger)
+
+	// ...and republishing the same one rewrites it.
+	require.NoError(t, publisher.PublishCheckpoint(context.Background(), 127))
+	root, err = arch.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(127), root.CurrentLedger)
+}
+
+type fakeBucketListSource BucketList
+
+func (f fakeBucketListSource) BucketList(ctx context.Context, checkpoint uint32) (BucketList, error) {
+	return BucketList(f), nil
+}
+
+func testBucket(t *testing.T, balance xdr.Int64) ([]xdr.BucketEntry, historyarchive.Hash) {
+	entries := []xdr.BucketEntry{{
+		Type: xdr.BucketEntryTypeLiveentry,
+		LiveEntry: &xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{
+					AccountId: xdr.MustAddress(keypair.MustRandom().Address()),
+					Balance:   balance,
+				},
+			},
+		},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, xdr.MarshalFramed(&buf, entries[0]))
+	return entries, sha256.Sum256(buf.Bytes())
+}
+
+func TestPublishBuckets(t *testing.T) {
+	var bucketList BucketList
+	var levelHashes [historyarchive.NumLevels]historyarchive.Hash
+	curr, currHash := testBucket(t, 10)
+	snap, snapHash := testBucket(t, 20)
+	bucketList[0] = BucketLevel{Curr: curr, Snap: snap}
+	levelHashes[0] = sha256.Sum256(append(currHash[:], snapHash[:]...))
+	for i := 1; i < historyarchive.NumLevels; i++ {
+		levelHashes[i] = sha256.Sum256(make([]byte, 64))
+	}
+	var listHash []byte
+	for _, h := range levelHashes {
+		listHash = append(listHash, h[:]...)
+	}
+
+	ledgers := testLedgers()
+	ledgers[63].V0.LedgerHeader.Header.BucketListHash = sha256.Sum256(listHash)
+
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+	publisher, err := New(Config{
+		Backend:    &fakeLedgerBackend{ledgers: ledgers},
+		Archive:    arch.Backend(),
+		BucketList: fakeBucketListSource(bucketList),
+	})
+	require.NoError(t, err)
+	require.NoError(t, publisher.PublishCheckpoint(context.Background(), 63))
+
+	has, err := arch.GetCheckpointHAS(63)
+	require.NoError(t, err)
+	assert.Equal(t, currHash.String(), has.CurrentBuckets[0].Curr)
+	assert.Equal(t, snapHash.String(), has.CurrentBuckets[0].Snap)
+	for _, h := range []historyarchive.Hash{currHash, snapHash} {
+		require.NoError(t, arch.VerifyBucketHash(h))
+	}
+
+	// A bucket list which does not match the ledger header is rejected.
+	bucketList[0].Snap = nil
+	arch, err = historyarchive.Connect("mock://test", historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+	publisher, err = New(Config{
+		Backend:    &fakeLedgerBackend{ledgers: ledgers},
+		Archive:    arch.Backend(),
+		BucketList: fakeBucketListSource(bucketList),
+	})
+	require.NoError(t, err)
+	err = publisher.PublishCheckpoint(context.Background(), 63)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match ledger header hash")
+	exists, err := arch.BucketExists(currHash)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	err = publisher.PublishCheckpoint(context.Background(), 64)
+	assert.EqualError(t, err, "ledger 64 is not a checkpoint ledger")
+}
@@ -0,0 +1,154 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+const (
+	keyIndexMagic      = "MQKI"
+	keyIndexVersion    = 1
+	keyIndexHeaderSize = len(keyIndexMagic) + 1 + 4
+	keyIndexRecordSize = keyDigestSize + 1
+	keyDigestSize      = 16
+)
+
+// keyDigest identifies a ledger key in a CheckpointKeyIndex: it is the
+// beginning of the SHA-256 hash of the key XDR.
+type keyDigest [keyDigestSize]byte
+
+func digestKey(keyXDR string) keyDigest {
+	var d keyDigest
+	hash := sha256.Sum256([]byte(keyXDR))
+	copy(d[:], hash[:])
+	return d
+}
+
+// keyIndexRecord maps a key to the bucket holding its state at a checkpoint.
+// The bucket is identified by its slot in the bucket list: 2*level for the
+// curr bucket of a level and 2*level+1 for its snap bucket.
+type keyIndexRecord struct {
+	digest keyDigest
+	slot   uint8
+}
+
+// CheckpointKeyIndex is an on-disk index of the ledger keys live at
+// checkpoint ledgers. For every key, it records which bucket of the
+// checkpoint holds the key's state, so that the state of a few keys can be
+// read from a few buckets instead of the whole bucket list. Each indexed
+// checkpoint is a file in Dir holding fixed size records sorted by key
+// digest, which are binary searched without loading the file.
+type CheckpointKeyIndex struct {
+	Dir string
+}
+
+func (i CheckpointKeyIndex) path(checkpoint uint32) string {
+	return filepath.Join(i.Dir, fmt.Sprintf("%08x.idx", checkpoint))
+}
+
+// Has returns true if checkpoint is indexed.
+func (i CheckpointKeyIndex) Has(checkpoint uint32) (bool, error) {
+	_, err := os.Stat(i.path(checkpoint))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// lookup returns the bucket slot of each of the given keys, which are XDR
+// encoded ledger keys. Keys which are not live at the checkpoint are not in
+// the returned map.
+func (i CheckpointKeyIndex) lookup(checkpoint uint32, keys []string) (map[string]uint8, error) {
+	file, err := os.Open(i.path(checkpoint))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening index of checkpoint %d", checkpoint)
+	}
+	defer file.Close()
+
+	header := make([]byte, keyIndexHeaderSize)
+	if _, err = io.ReadFull(file, header); err != nil {
+		return nil, errors.Wrapf(err, "error reading index of checkpoint %d", checkpoint)
+	}
+	if string(header[:len(keyIndexMagic)]) != keyIndexMagic || header[len(keyIndexMagic)] != keyIndexVersion {
+		return nil, errors.Errorf("invalid index of checkpoint %d", checkpoint)
+	}
+	count := int(binary.BigEndian.Uint32(header[len(keyIndexMagic)+1:]))
+
+	slots := map[string]uint8{}
+	record := make([]byte, keyIndexRecordSize)
+	for _, key := range keys {
+		digest := digestKey(key)
+		var readErr error
+		n := sort.Search(count, func(j int) bool {
+			if readErr != nil {
+				return true
+			}
+			offset := int64(keyIndexHeaderSize + j*keyIndexRecordSize)
+			if _, readErr = file.ReadAt(record, offset); readErr != nil {
+				return true
+			}
+			return bytes.Compare(record[:keyDigestSize], digest[:]) >= 0
+		})
+		if readErr != nil {
+			return nil, errors.Wrapf(readErr, "error reading index of checkpoint %d", checkpoint)
+		}
+		if n == count {
+			continue
+		}
+		offset := int64(keyIndexHeaderSize + n*keyIndexRecordSize)
+		if _, err = file.ReadAt(record, offset); err != nil {
+			return nil, errors.Wrapf(err, "error reading index of checkpoint %d", checkpoint)
+		}
+		if bytes.Equal(record[:keyDigestSize], digest[:]) {
+			slots[key] = record[keyDigestSize]
+		}
+	}
+	return slots, nil
+}
+
+// write saves the records of a checkpoint. The file is written under a
+// temporary name and renamed, so a checkpoint is either fully indexed or
+// not at all.
+func (i CheckpointKeyIndex) write(checkpoint uint32, records []keyIndexRecord) error {
+	sort.Slice(records, func(a, b int) bool {
+		return bytes.Compare(records[a].digest[:], records[b].digest[:]) < 0
+	})
+
+	if err := os.MkdirAll(i.Dir, 0755); err != nil {
+		return errors.Wrap(err, "error creating index directory")
+	}
+	tmp, err := ioutil.TempFile(i.Dir, filepath.Base(i.path(checkpoint))+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "error creating index file")
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	header := make([]byte, keyIndexHeaderSize)
+	copy(header, keyIndexMagic)
+	header[len(keyIndexMagic)] = keyIndexVersion
+	binary.BigEndian.PutUint32(header[len(keyIndexMagic)+1:], uint32(len(records)))
+	w.Write(header)
+	for _, record := range records {
+		w.Write(record.digest[:])
+		w.WriteByte(record.slot)
+	}
+	if err = w.Flush(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "error writing index file")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "error writing index file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), i.path(checkpoint)), "error writing index file")
+}
@@ -0,0 +1,68 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// CursorStore persists the sequence of the last ledger a ProcessorRunner has
+// processed.
+type CursorStore interface {
+	// GetCursor returns the last processed ledger, or 0 if nothing was
+	// processed yet.
+	GetCursor(ctx context.Context) (uint32, error)
+	// SetCursor saves the last processed ledger.
+	SetCursor(ctx context.Context, sequence uint32) error
+}
+
+// FileCursorStore is a CursorStore keeping the cursor in a JSON file.
+// Processors that write to a database should rather save the cursor in the
+// same transaction as their data, by implementing CursorStore on top of it.
+type FileCursorStore struct {
+	Path string
+}
+
+type fileCursor struct {
+	Ledger uint32 `json:"ledger"`
+}
+
+func (s FileCursorStore) GetCursor(ctx context.Context) (uint32, error) {
+	buf, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.Wrap(err, "error reading cursor file")
+	}
+	var cursor fileCursor
+	if err = json.Unmarshal(buf, &cursor); err != nil {
+		return 0, errors.Wrapf(err, "error decoding cursor file %s", s.Path)
+	}
+	return cursor.Ledger, nil
+}
+
+// SetCursor writes the cursor to a temporary file and renames it over the
+// cursor file, so the file is never left half written.
+func (s FileCursorStore) SetCursor(ctx context.Context, sequence uint32) error {
+	buf, err := json.Marshal(fileCursor{Ledger: sequence})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "error creating cursor file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(buf); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "error writing cursor file")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "error writing cursor file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), s.Path), "error writing cursor file")
+}
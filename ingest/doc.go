@@ -31,9 +31,24 @@ Currently there are three types of readers:
   - LedgerChangeReader reads all changes to ledger entries created as a result of
     transactions (fees and meta) and protocol upgrades in a given ledger.
 
+EntryStateReader combines the two: it returns the state of a few ledger
+entries at any ledger, or their changes between two ledgers, by reading a
+checkpoint's buckets and replaying the ledgers that follow. A
+CheckpointKeyIndex saved on disk lets it read only the buckets holding the
+requested entries.
+
 Warning: Readers stream BOTH successful and failed transactions; check
 transactions status in your application if required.
 
+# Processors
+
+ChangeProcessor and LedgerTransactionProcessor consume the output of readers.
+ProcessorRunner runs them the way OrbitR builds its database: it processes the
+state at a checkpoint, then follows new ledgers from a ledger backend, saving
+a cursor (see CursorStore) after each ledger so it can resume after a restart.
+It can also reingest a range of ledgers. Processors which buffer their output
+implement Committer to flush it once per checkpoint or ledger.
+
 # Tutorial
 
 Refer to the examples below for simple use cases, or check out the README (and
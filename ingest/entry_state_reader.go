@@ -0,0 +1,484 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// EntryStateReaderConfig configures an EntryStateReader.
+type EntryStateReaderConfig struct {
+	// Archive provides the checkpoint buckets.
+	Archive historyarchive.ArchiveInterface
+	// Backend provides the ledgers following the checkpoints.
+	Backend           ledgerbackend.LedgerBackend
+	NetworkPassphrase string
+	// IndexPath is the directory of the CheckpointKeyIndex. When empty, no
+	// index is used and every query scans the buckets of its checkpoint.
+	IndexPath string
+}
+
+// EntryStateReader returns the state of ledger entries at any ledger. The
+// state at a ledger is the state at the preceding checkpoint, read from the
+// checkpoint buckets, followed by the changes of the ledgers after the
+// checkpoint, read from the backend.
+//
+// Without an index, the buckets of a checkpoint are scanned from the newest
+// to the oldest until all the requested keys are found, which can read the
+// whole bucket list. With an index, the first query at a checkpoint scans all
+// its buckets to build the checkpoint's index; later queries only read the
+// buckets holding the requested keys.
+type EntryStateReader struct {
+	archive           historyarchive.ArchiveInterface
+	backend           ledgerbackend.LedgerBackend
+	networkPassphrase string
+	checkpoints       historyarchive.CheckpointManager
+	index             *CheckpointKeyIndex
+	encodingBuffer    *xdr.EncodingBuffer
+}
+
+// NewEntryStateReader constructs a new EntryStateReader.
+func NewEntryStateReader(config EntryStateReaderConfig) (*EntryStateReader, error) {
+	if config.Archive == nil {
+		return nil, errors.New("archive is required")
+	}
+	if config.Backend == nil {
+		return nil, errors.New("backend is required")
+	}
+	if config.NetworkPassphrase == "" {
+		return nil, errors.New("network passphrase is required")
+	}
+	r := &EntryStateReader{
+		archive:           config.Archive,
+		backend:           config.Backend,
+		networkPassphrase: config.NetworkPassphrase,
+		checkpoints:       config.Archive.GetCheckpointManager(),
+		encodingBuffer:    xdr.NewEncodingBuffer(),
+	}
+	if config.IndexPath != "" {
+		r.index = &CheckpointKeyIndex{Dir: config.IndexPath}
+	}
+	return r, nil
+}
+
+// entryState holds the state of the requested keys, indexed by their
+// compressed XDR encoding. Keys without an entry map to nil.
+type entryState map[string]*xdr.LedgerEntry
+
+// GetEntries returns the entries with the given keys at the end of ledger.
+// Keys without an entry at ledger are skipped.
+func (r *EntryStateReader) GetEntries(ctx context.Context, ledger uint32, keys []xdr.LedgerKey) ([]xdr.LedgerEntry, error) {
+	encoded, err := r.encodeKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	state, err := r.stateAt(ctx, ledger, encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []xdr.LedgerEntry{}
+	for _, key := range encoded {
+		if entry := state[key]; entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// Diff returns the changes of the entries with the given keys between the
+// end of ledger from and the end of ledger to. A Change is returned for every
+// key whose entry was created, updated or removed, with Pre being the entry
+// at from and Post the entry at to.
+func (r *EntryStateReader) Diff(ctx context.Context, from, to uint32, keys []xdr.LedgerKey) ([]Change, error) {
+	if from > to {
+		return nil, errors.Errorf("invalid range: %d > %d", from, to)
+	}
+	encoded, err := r.encodeKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	state, err := r.stateAt(ctx, from, encoded)
+	if err != nil {
+		return nil, err
+	}
+	pre := entryState{}
+	for key, entry := range state {
+		pre[key] = entry
+	}
+	if err = r.replay(ctx, from+1, to, state); err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for i, key := range encoded {
+		equal, err := entriesEqual(pre[key], state[key])
+		if err != nil {
+			return nil, err
+		}
+		if equal {
+			continue
+		}
+		changes = append(changes, Change{
+			Type: keys[i].Type,
+			Pre:  pre[key],
+			Post: state[key],
+		})
+	}
+	return changes, nil
+}
+
+// BuildIndex indexes checkpoint, if it is not indexed yet. Queries index the
+// checkpoints they use so calling BuildIndex is only needed to avoid the cost
+// of building an index during a query.
+func (r *EntryStateReader) BuildIndex(ctx context.Context, checkpoint uint32) error {
+	if r.index == nil {
+		return errors.New("index path is not configured")
+	}
+	if !r.checkpoints.IsCheckpoint(checkpoint) {
+		return errors.Errorf("%d is not a checkpoint ledger", checkpoint)
+	}
+	if ok, err := r.index.Has(checkpoint); err != nil || ok {
+		return err
+	}
+	_, err := r.scanCheckpoint(ctx, checkpoint, nil)
+	return err
+}
+
+func (r *EntryStateReader) encodeKeys(keys []xdr.LedgerKey) ([]string, error) {
+	encoded := make([]string, 0, len(keys))
+	for _, key := range keys {
+		keyBytes, err := r.encodingBuffer.LedgerKeyUnsafeMarshalBinaryCompress(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "error marshaling ledger key")
+		}
+		encoded = append(encoded, string(keyBytes))
+	}
+	return encoded, nil
+}
+
+// stateAt returns the state of keys at the end of ledger.
+func (r *EntryStateReader) stateAt(ctx context.Context, ledger uint32, keys []string) (entryState, error) {
+	if ledger == 0 {
+		return nil, errors.New("ledger must be greater than 0")
+	}
+
+	var state entryState
+	var err error
+	base := r.checkpoints.PrevCheckpoint(ledger)
+	if base > ledger {
+		// Ledgers before the first checkpoint are replayed from the genesis
+		// ledger.
+		base = 1
+		state, err = r.genesisState(keys)
+	} else {
+		state, err = r.checkpointState(ctx, base, keys)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.replay(ctx, base+1, ledger, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (r *EntryStateReader) genesisState(keys []string) (entryState, error) {
+	state := entryState{}
+	for _, key := range keys {
+		state[key] = nil
+	}
+	change := GenesisChange(r.networkPassphrase)
+	key, err := r.entryKey(change.Post)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := state[key]; ok {
+		state[key] = change.Post
+	}
+	return state, nil
+}
+
+// checkpointState returns the state of keys at checkpoint, using the index
+// when there is one.
+func (r *EntryStateReader) checkpointState(ctx context.Context, checkpoint uint32, keys []string) (entryState, error) {
+	if r.index == nil {
+		return r.scanCheckpoint(ctx, checkpoint, keys)
+	}
+	indexed, err := r.index.Has(checkpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "error checking index")
+	}
+	if !indexed {
+		return r.scanCheckpoint(ctx, checkpoint, keys)
+	}
+
+	slots, err := r.index.lookup(checkpoint, keys)
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := r.buckets(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	state := entryState{}
+	bySlot := map[uint8]map[string]bool{}
+	for _, key := range keys {
+		state[key] = nil
+		if slot, ok := slots[key]; ok {
+			if bySlot[slot] == nil {
+				bySlot[slot] = map[string]bool{}
+			}
+			bySlot[slot][key] = true
+		}
+	}
+	for slot, wanted := range bySlot {
+		if int(slot) >= len(buckets) || buckets[slot].IsZero() {
+			return nil, errors.Errorf("index of checkpoint %d refers to missing bucket %d", checkpoint, slot)
+		}
+		err = r.readBucket(ctx, buckets[slot], func(key string, entry xdr.BucketEntry) (bool, error) {
+			if !wanted[key] {
+				return true, nil
+			}
+			if entry.Type != xdr.BucketEntryTypeDeadentry {
+				liveEntry := entry.MustLiveEntry()
+				state[key] = &liveEntry
+			}
+			delete(wanted, key)
+			return len(wanted) > 0, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// scanCheckpoint reads the buckets of checkpoint from the newest to the
+// oldest and returns the state of keys. The first entry found for a key is
+// its state at the checkpoint, older entries are shadowed. When the index is
+// enabled all the buckets are read to index checkpoint, otherwise reading
+// stops once all the keys are found.
+func (r *EntryStateReader) scanCheckpoint(ctx context.Context, checkpoint uint32, keys []string) (entryState, error) {
+	buckets, err := r.buckets(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	state := entryState{}
+	for _, key := range keys {
+		state[key] = nil
+	}
+	remaining := len(keys)
+
+	seen := &memoryTempSet{}
+	if err = seen.Open(); err != nil {
+		return nil, errors.Wrap(err, "error opening temp set")
+	}
+	defer seen.Close()
+
+	var records []keyIndexRecord
+	for slot, hash := range buckets {
+		if hash.IsZero() {
+			continue
+		}
+		err = r.readBucket(ctx, hash, func(key string, entry xdr.BucketEntry) (bool, error) {
+			shadowed, err := seen.Exist(key)
+			if err != nil || shadowed {
+				return true, err
+			}
+			if err = seen.Add(key); err != nil {
+				return false, err
+			}
+
+			live := entry.Type != xdr.BucketEntryTypeDeadentry
+			if live && r.index != nil {
+				records = append(records, keyIndexRecord{digest: digestKey(key), slot: uint8(slot)})
+			}
+			if _, ok := state[key]; ok {
+				if live {
+					liveEntry := entry.MustLiveEntry()
+					state[key] = &liveEntry
+				}
+				remaining--
+			}
+			return r.index != nil || remaining > 0, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if r.index == nil && remaining == 0 {
+			break
+		}
+	}
+
+	if r.index != nil {
+		if err = r.index.write(checkpoint, records); err != nil {
+			return nil, errors.Wrapf(err, "error indexing checkpoint %d", checkpoint)
+		}
+	}
+	return state, nil
+}
+
+// buckets returns the bucket hashes of checkpoint, indexed by bucket slot.
+func (r *EntryStateReader) buckets(checkpoint uint32) ([]historyarchive.Hash, error) {
+	has, err := r.archive.GetCheckpointHAS(checkpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get checkpoint HAS at ledger sequence %d", checkpoint)
+	}
+	var buckets []historyarchive.Hash
+	for _, level := range has.CurrentBuckets {
+		for _, hashString := range []string{level.Curr, level.Snap} {
+			hash, err := historyarchive.DecodeHash(hashString)
+			if err != nil {
+				return nil, errors.Wrap(err, "error decoding bucket hash")
+			}
+			buckets = append(buckets, hash)
+		}
+	}
+	return buckets, nil
+}
+
+// readBucket calls f with the key and entry of every live, init and dead
+// entry of a bucket until f returns false. The bucket hash is validated when
+// the stream is closed, which reads the rest of the bucket without decoding
+// it.
+func (r *EntryStateReader) readBucket(ctx context.Context, hash historyarchive.Hash, f func(string, xdr.BucketEntry) (bool, error)) error {
+	stream, err := r.archive.GetXdrStreamForHash(hash)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get xdr stream for hash '%s'", hash.String())
+	}
+	err = r.readBucketEntries(ctx, stream, hash, f)
+	if closeErr := stream.Close(); err == nil && closeErr != nil {
+		err = errors.Wrapf(closeErr, "error closing xdr stream for hash '%s'", hash.String())
+	}
+	return err
+}
+
+func (r *EntryStateReader) readBucketEntries(ctx context.Context, stream *historyarchive.XdrStream, hash historyarchive.Hash, f func(string, xdr.BucketEntry) (bool, error)) error {
+	for n := 0; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var entry xdr.BucketEntry
+		if err := stream.ReadOne(&entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrapf(err, "error on XDR record %d of hash '%s'", n, hash.String())
+		}
+
+		var key xdr.LedgerKey
+		var err error
+		switch entry.Type {
+		case xdr.BucketEntryTypeLiveentry, xdr.BucketEntryTypeInitentry:
+			liveEntry := entry.MustLiveEntry()
+			if key, err = liveEntry.LedgerKey(); err != nil {
+				return errors.Wrapf(err, "error generating ledger key for XDR record %d of hash '%s'", n, hash.String())
+			}
+		case xdr.BucketEntryTypeDeadentry:
+			key = entry.MustDeadEntry()
+		default:
+			continue
+		}
+
+		keyBytes, err := r.encodingBuffer.LedgerKeyUnsafeMarshalBinaryCompress(key)
+		if err != nil {
+			return errors.Wrapf(err, "error marshaling XDR record %d of hash '%s'", n, hash.String())
+		}
+		more, err := f(string(keyBytes), entry)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// replay applies the changes of ledgers from to to to state. Only the keys
+// in state are updated.
+func (r *EntryStateReader) replay(ctx context.Context, from, to uint32, state entryState) error {
+	if from > to {
+		return nil
+	}
+	ledgerRange := ledgerbackend.BoundedRange(from, to)
+	prepared, err := r.backend.IsPrepared(ctx, ledgerRange)
+	if err != nil {
+		return errors.Wrap(err, "error checking prepared range")
+	}
+	if !prepared {
+		if err = r.backend.PrepareRange(ctx, ledgerRange); err != nil {
+			return errors.Wrapf(err, "error preparing range %d-%d", from, to)
+		}
+	}
+
+	for sequence := from; sequence <= to; sequence++ {
+		ledger, err := r.backend.GetLedger(ctx, sequence)
+		if err != nil {
+			return errors.Wrapf(err, "error getting ledger %d", sequence)
+		}
+		reader, err := NewLedgerChangeReaderFromLedgerCloseMeta(r.networkPassphrase, ledger)
+		if err != nil {
+			return errors.Wrapf(err, "error creating change reader for ledger %d", sequence)
+		}
+		for {
+			change, err := reader.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				reader.Close()
+				return errors.Wrapf(err, "error reading changes of ledger %d", sequence)
+			}
+
+			entry := change.Post
+			if entry == nil {
+				entry = change.Pre
+			}
+			key, err := r.entryKey(entry)
+			if err != nil {
+				reader.Close()
+				return err
+			}
+			if _, ok := state[key]; ok {
+				state[key] = change.Post
+			}
+		}
+		reader.Close()
+	}
+	return nil
+}
+
+func (r *EntryStateReader) entryKey(entry *xdr.LedgerEntry) (string, error) {
+	key, err := entry.LedgerKey()
+	if err != nil {
+		return "", errors.Wrap(err, "error generating ledger key")
+	}
+	keyBytes, err := r.encodingBuffer.LedgerKeyUnsafeMarshalBinaryCompress(key)
+	if err != nil {
+		return "", errors.Wrap(err, "error marshaling ledger key")
+	}
+	return string(keyBytes), nil
+}
+
+func entriesEqual(a, b *xdr.LedgerEntry) (bool, error) {
+	if a == nil || b == nil {
+		return a == b, nil
+	}
+	aBytes, err := a.MarshalBinary()
+	if err != nil {
+		return false, errors.Wrap(err, "error marshaling ledger entry")
+	}
+	bBytes, err := b.MarshalBinary()
+	if err != nil {
+		return false, errors.Wrap(err, "error marshaling ledger entry")
+	}
+	return bytes.Equal(aBytes, bBytes), nil
+}
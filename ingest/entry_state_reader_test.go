@@ -0,0 +1,247 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+const stateAccount3 = "GAUJETIZVEP2NRYLUESJ3LS66NVCEGMON4UDCBCSBEVPIID773P2W6AY"
+
+// ledgerMapBackend serves the ledgers of a map.
+type ledgerMapBackend struct {
+	ledgers  map[uint32]xdr.LedgerCloseMeta
+	prepared []ledgerbackend.Range
+}
+
+func (b *ledgerMapBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	return 0, nil
+}
+
+func (b *ledgerMapBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	ledger, ok := b.ledgers[sequence]
+	if !ok {
+		return xdr.LedgerCloseMeta{}, errors.Errorf("ledger %d not found", sequence)
+	}
+	return ledger, nil
+}
+
+func (b *ledgerMapBackend) PrepareRange(ctx context.Context, ledgerRange ledgerbackend.Range) error {
+	b.prepared = append(b.prepared, ledgerRange)
+	return nil
+}
+
+func (b *ledgerMapBackend) IsPrepared(ctx context.Context, ledgerRange ledgerbackend.Range) (bool, error) {
+	return false, nil
+}
+
+func (b *ledgerMapBackend) Close() error {
+	return nil
+}
+
+// countingArchive counts the buckets read from an archive.
+type countingArchive struct {
+	historyarchive.ArchiveInterface
+	reads map[historyarchive.Hash]int
+}
+
+func (a *countingArchive) GetXdrStreamForHash(hash historyarchive.Hash) (*historyarchive.XdrStream, error) {
+	a.reads[hash]++
+	return a.ArchiveInterface.GetXdrStreamForHash(hash)
+}
+
+func putBucket(t *testing.T, arch *historyarchive.Archive, entries ...xdr.BucketEntry) historyarchive.Hash {
+	var raw, gz bytes.Buffer
+	for _, entry := range entries {
+		require.NoError(t, xdr.MarshalFramed(&raw, entry))
+	}
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write(raw.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	hash := historyarchive.Hash(sha256.Sum256(raw.Bytes()))
+	require.NoError(t, arch.Backend().PutFile(historyarchive.BucketPath(hash), ioutil.NopCloser(&gz)))
+	return hash
+}
+
+// testStateArchive returns an archive whose checkpoint 63 holds the first
+// and third accounts. The second account was removed by the newest bucket.
+func testStateArchive(t *testing.T) (*countingArchive, historyarchive.Hash, historyarchive.Hash) {
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+	curr := putBucket(t, arch,
+		metaEntry(11),
+		entryAccount(xdr.BucketEntryTypeLiveentry, runnerAccount1, 200),
+		entryAccount(xdr.BucketEntryTypeDeadentry, runnerAccount2, 0),
+	)
+	snap := putBucket(t, arch,
+		metaEntry(11),
+		entryAccount(xdr.BucketEntryTypeLiveentry, runnerAccount1, 100),
+		entryAccount(xdr.BucketEntryTypeLiveentry, runnerAccount2, 50),
+		entryAccount(xdr.BucketEntryTypeLiveentry, stateAccount3, 30),
+	)
+
+	var has historyarchive.HistoryArchiveState
+	has.CurrentLedger = 63
+	for i := range has.CurrentBuckets {
+		has.CurrentBuckets[i].Curr = historyarchive.Hash{}.String()
+		has.CurrentBuckets[i].Snap = historyarchive.Hash{}.String()
+	}
+	has.CurrentBuckets[0].Curr = curr.String()
+	has.CurrentBuckets[0].Snap = snap.String()
+	require.NoError(t, arch.PutCheckpointHAS(63, has, &historyarchive.CommandOptions{}))
+	require.NoError(t, arch.PutRootHAS(has, &historyarchive.CommandOptions{}))
+	return &countingArchive{ArchiveInterface: arch, reads: map[historyarchive.Hash]int{}}, curr, snap
+}
+
+func accountKey(address string) xdr.LedgerKey {
+	var key xdr.LedgerKey
+	if err := key.SetAccount(xdr.MustAddress(address)); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func assertBalances(t *testing.T, entries []xdr.LedgerEntry, balances map[string]int64) {
+	actual := map[string]int64{}
+	for _, entry := range entries {
+		account := entry.Data.MustAccount()
+		actual[account.AccountId.Address()] = int64(account.Balance)
+	}
+	assert.Equal(t, balances, actual)
+}
+
+func newTestEntryStateReader(t *testing.T, archive historyarchive.ArchiveInterface, indexPath string) (*EntryStateReader, *ledgerMapBackend) {
+	backend := &ledgerMapBackend{ledgers: map[uint32]xdr.LedgerCloseMeta{
+		64: testRunnerLedger(t, 64),
+		65: testRunnerLedger(t, 65),
+	}}
+	reader, err := NewEntryStateReader(EntryStateReaderConfig{
+		Archive:           archive,
+		Backend:           backend,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		IndexPath:         indexPath,
+	})
+	require.NoError(t, err)
+	return reader, backend
+}
+
+func TestEntryStateReaderGetEntries(t *testing.T) {
+	ctx := context.Background()
+	archive, curr, _ := testStateArchive(t)
+	reader, backend := newTestEntryStateReader(t, archive, "")
+	keys := []xdr.LedgerKey{accountKey(runnerAccount1), accountKey(runnerAccount2), accountKey(stateAccount3)}
+
+	entries, err := reader.GetEntries(ctx, 63, keys)
+	require.NoError(t, err)
+	assertBalances(t, entries, map[string]int64{runnerAccount1: 200, stateAccount3: 30})
+	assert.Empty(t, backend.prepared)
+
+	// The second account is created again by every ledger after the
+	// checkpoint.
+	entries, err = reader.GetEntries(ctx, 65, keys)
+	require.NoError(t, err)
+	assertBalances(t, entries, map[string]int64{runnerAccount1: 200, runnerAccount2: 65, stateAccount3: 30})
+	assert.Equal(t, []ledgerbackend.Range{ledgerbackend.BoundedRange(64, 65)}, backend.prepared)
+
+	// Reading stops once all the keys were found.
+	archive.reads = map[historyarchive.Hash]int{}
+	entries, err = reader.GetEntries(ctx, 63, keys[:1])
+	require.NoError(t, err)
+	assertBalances(t, entries, map[string]int64{runnerAccount1: 200})
+	assert.Equal(t, map[historyarchive.Hash]int{curr: 1}, archive.reads)
+}
+
+func TestEntryStateReaderIndex(t *testing.T) {
+	ctx := context.Background()
+	archive, curr, snap := testStateArchive(t)
+	indexPath := t.TempDir()
+	reader, _ := newTestEntryStateReader(t, archive, indexPath)
+	keys := []xdr.LedgerKey{accountKey(runnerAccount1), accountKey(runnerAccount2), accountKey(stateAccount3)}
+
+	// The first query scans all the buckets and indexes the checkpoint.
+	entries, err := reader.GetEntries(ctx, 63, keys[:1])
+	require.NoError(t, err)
+	assertBalances(t, entries, map[string]int64{runnerAccount1: 200})
+	assert.Equal(t, map[historyarchive.Hash]int{curr: 1, snap: 1}, archive.reads)
+	indexed, err := (CheckpointKeyIndex{Dir: indexPath}).Has(63)
+	require.NoError(t, err)
+	assert.True(t, indexed)
+
+	// Later queries only read the buckets holding the keys.
+	archive.reads = map[historyarchive.Hash]int{}
+	entries, err = reader.GetEntries(ctx, 64, keys[2:])
+	require.NoError(t, err)
+	assertBalances(t, entries, map[string]int64{stateAccount3: 30})
+	assert.Equal(t, map[historyarchive.Hash]int{snap: 1}, archive.reads)
+
+	// Removed entries are not indexed, so no bucket is read for them.
+	archive.reads = map[historyarchive.Hash]int{}
+	entries, err = reader.GetEntries(ctx, 63, keys[1:2])
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Empty(t, archive.reads)
+
+	entries, err = reader.GetEntries(ctx, 63, keys)
+	require.NoError(t, err)
+	assertBalances(t, entries, map[string]int64{runnerAccount1: 200, stateAccount3: 30})
+
+	// A new reader uses the existing index.
+	reader, _ = newTestEntryStateReader(t, archive, indexPath)
+	archive.reads = map[historyarchive.Hash]int{}
+	require.NoError(t, reader.BuildIndex(ctx, 63))
+	assert.Empty(t, archive.reads)
+	assert.EqualError(t, reader.BuildIndex(ctx, 64), "64 is not a checkpoint ledger")
+}
+
+func TestEntryStateReaderDiff(t *testing.T) {
+	ctx := context.Background()
+	archive, _, _ := testStateArchive(t)
+	reader, _ := newTestEntryStateReader(t, archive, t.TempDir())
+	keys := []xdr.LedgerKey{accountKey(runnerAccount1), accountKey(runnerAccount2)}
+
+	changes, err := reader.Diff(ctx, 63, 65, keys)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, xdr.LedgerEntryChangeTypeLedgerEntryCreated, changes[0].LedgerEntryChangeType())
+	isBalance(runnerAccount2, 65)(t, 0, changes[0])
+
+	changes, err = reader.Diff(ctx, 64, 65, keys)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, xdr.LedgerEntryChangeTypeLedgerEntryUpdated, changes[0].LedgerEntryChangeType())
+	assert.EqualValues(t, 64, changes[0].Pre.Data.MustAccount().Balance)
+	isBalance(runnerAccount2, 65)(t, 0, changes[0])
+
+	changes, err = reader.Diff(ctx, 65, 65, keys)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = reader.Diff(ctx, 65, 64, keys)
+	assert.EqualError(t, err, "invalid range: 65 > 64")
+}
+
+func TestEntryStateReaderGenesis(t *testing.T) {
+	archive, _, _ := testStateArchive(t)
+	reader, backend := newTestEntryStateReader(t, archive, "")
+	master := keypair.Master(network.TestNetworkPassphrase).Address()
+
+	entries, err := reader.GetEntries(context.Background(), 1, []xdr.LedgerKey{accountKey(master)})
+	require.NoError(t, err)
+	assertBalances(t, entries, map[string]int64{master: 100000000000000000})
+	assert.Empty(t, archive.reads)
+	assert.Empty(t, backend.prepared)
+}
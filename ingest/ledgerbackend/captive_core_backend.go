@@ -187,7 +187,7 @@ func NewCaptive(config CaptiveCoreConfig) (*CaptiveGravity, error) {
 	}
 
 	c := &CaptiveGravity{
-		archive:           &archivePool,
+		archive:           archivePool,
 		ledgerHashStore:   config.LedgerHashStore,
 		useDB:             config.UseDB,
 		cancel:            cancel,
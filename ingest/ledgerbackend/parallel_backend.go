@@ -0,0 +1,484 @@
+package ledgerbackend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/xdr"
+)
+
+// errYield is returned by fetch when a backend stops fetching a sub-range to
+// fetch the sub-range being read.
+var errYield = errors.New("yield")
+
+const (
+	defaultParallelSubRangeSize = 6400
+	defaultParallelBufferSize   = 1000
+	defaultParallelMaxAttempts  = 3
+)
+
+// Ensure ParallelBackend implements LedgerBackend
+var _ LedgerBackend = (*ParallelBackend)(nil)
+
+// ParallelBackendConfig configures a ParallelBackend.
+type ParallelBackendConfig struct {
+	// Backends is the pool of backends ledgers are fetched from. Any backend
+	// can be used (captive core, remote captive core, database), each one is
+	// prepared with the sub-ranges it fetches.
+	Backends []LedgerBackend
+	// SubRangeSize is the number of ledgers fetched by a backend for a single
+	// PrepareRange call. Defaults to 6400 (100 checkpoints).
+	SubRangeSize uint32
+	// BufferSize is the maximum number of ledgers fetched ahead of the
+	// consumer. The backend fetching the ledgers being read is only limited
+	// by its own ledgers, so that ledgers further ahead cannot hold it back.
+	// Defaults to 1000.
+	BufferSize int
+	// MaxAttempts is the number of times a sub-range is attempted before
+	// GetLedger returns an error for its ledgers. A failed sub-range is
+	// attempted again from its first missing ledger, by another backend when
+	// there is one. Defaults to 3.
+	MaxAttempts int
+	// Log is an optional logger. Defaults to the default logger.
+	Log *log.Entry
+}
+
+// ParallelBackend is a LedgerBackend fetching the ledgers of a bounded range
+// from a pool of backends in parallel. The range is split in sub-ranges which
+// are assigned, in order, to the backends as they become available. Fetched
+// ledgers are buffered and returned in order by GetLedger. When the sub-range
+// being read needs a backend after a failure, a backend waiting for room in
+// the buffer gives up its sub-range to fetch it.
+//
+// Unbounded ranges cannot be split: they are prepared and read from the first
+// backend of the pool.
+type ParallelBackend struct {
+	config ParallelBackendConfig
+
+	mutex sync.Mutex
+	// changed is closed, and replaced, whenever the state of the fan-out
+	// changes.
+	changed   chan struct{}
+	fanOut    *fanOut
+	unbounded bool
+	closed    bool
+}
+
+// fanOut is the state of the fetching of a bounded range.
+type fanOut struct {
+	ledgerRange Range
+	subRanges   []*subRange
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	// next is the next ledger returned to the consumer.
+	next uint32
+	// buffered is the number of ledgers fetched, or being fetched, and not
+	// read yet.
+	buffered int
+}
+
+type subRange struct {
+	from, to uint32
+	// ledgers holds the ledgers fetched so far, starting at from. Read
+	// ledgers are reset to free their memory.
+	ledgers  []xdr.LedgerCloseMeta
+	assigned bool
+	attempts int
+	failedOn map[int]bool
+	err      error
+}
+
+func (s *subRange) fetched() uint32 {
+	return s.from + uint32(len(s.ledgers))
+}
+
+func (s *subRange) complete() bool {
+	return s.fetched() > s.to
+}
+
+// NewParallelBackend constructs a new ParallelBackend.
+func NewParallelBackend(config ParallelBackendConfig) (*ParallelBackend, error) {
+	if len(config.Backends) == 0 {
+		return nil, errors.New("at least one backend is required")
+	}
+	if config.SubRangeSize == 0 {
+		config.SubRangeSize = defaultParallelSubRangeSize
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultParallelBufferSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultParallelMaxAttempts
+	}
+	if config.Log == nil {
+		config.Log = log.DefaultLogger
+	}
+	return &ParallelBackend{
+		config:  config,
+		changed: make(chan struct{}),
+	}, nil
+}
+
+// notify wakes up the goroutines waiting for a change. It must be called
+// with the mutex held.
+func (p *ParallelBackend) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// wait waits for a change or for ctx to be done, returning false in the
+// latter case. It must be called with the mutex held.
+func (p *ParallelBackend) wait(ctx context.Context) bool {
+	changed := p.changed
+	p.mutex.Unlock()
+	defer p.mutex.Lock()
+	select {
+	case <-changed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// GetLatestLedgerSequence returns the latest ledger which can be read without
+// blocking. For unbounded ranges, it is the latest ledger of the first
+// backend.
+func (p *ParallelBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	p.mutex.Lock()
+	if p.unbounded {
+		p.mutex.Unlock()
+		return p.config.Backends[0].GetLatestLedgerSequence(ctx)
+	}
+	defer p.mutex.Unlock()
+
+	f := p.fanOut
+	if f == nil {
+		return 0, errors.New("PrepareRange must be called before any other operations")
+	}
+	latest := f.next - 1
+	for _, s := range f.subRanges {
+		if s.to < f.next {
+			continue
+		}
+		if s.fetched() <= latest+1 {
+			break
+		}
+		latest = s.fetched() - 1
+		if !s.complete() {
+			break
+		}
+	}
+	return latest, nil
+}
+
+// PrepareRange prepares the given range. Bounded ranges start being fetched
+// from all the backends and PrepareRange returns once the first ledger is
+// available. Unbounded ranges are prepared by the first backend.
+func (p *ParallelBackend) PrepareRange(ctx context.Context, ledgerRange Range) error {
+	p.stop()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return errors.New("backend is closed")
+	}
+
+	if !ledgerRange.bounded {
+		if err := p.config.Backends[0].PrepareRange(ctx, ledgerRange); err != nil {
+			return err
+		}
+		p.unbounded = true
+		return nil
+	}
+
+	if ledgerRange.from == 0 || ledgerRange.from > ledgerRange.to {
+		return errors.Errorf("invalid range: %s", ledgerRange)
+	}
+	f := &fanOut{ledgerRange: ledgerRange, next: ledgerRange.from}
+	for from := uint64(ledgerRange.from); from <= uint64(ledgerRange.to); from += uint64(p.config.SubRangeSize) {
+		to := from + uint64(p.config.SubRangeSize) - 1
+		if to > uint64(ledgerRange.to) {
+			to = uint64(ledgerRange.to)
+		}
+		f.subRanges = append(f.subRanges, &subRange{
+			from:     uint32(from),
+			to:       uint32(to),
+			failedOn: map[int]bool{},
+		})
+	}
+
+	var workCtx context.Context
+	workCtx, f.cancel = context.WithCancel(context.Background())
+	p.fanOut = f
+	for i := range p.config.Backends {
+		f.wg.Add(1)
+		go p.work(workCtx, f, i)
+	}
+
+	err := p.waitForLedger(ctx, f, ledgerRange.from)
+	if err != nil {
+		p.mutex.Unlock()
+		p.stop()
+		p.mutex.Lock()
+	}
+	return err
+}
+
+// waitForLedger waits until the given ledger is fetched. It must be called
+// with the mutex held.
+func (p *ParallelBackend) waitForLedger(ctx context.Context, f *fanOut, sequence uint32) error {
+	s := f.subRangeOf(sequence)
+	for s.fetched() <= sequence {
+		if s.err != nil {
+			return s.err
+		}
+		if !p.wait(ctx) {
+			return ctx.Err()
+		}
+		if p.fanOut != f {
+			return errors.New("range was prepared again while waiting for ledger")
+		}
+	}
+	return nil
+}
+
+// IsPrepared returns true if the given range is prepared and none of its
+// ledgers was read yet.
+func (p *ParallelBackend) IsPrepared(ctx context.Context, ledgerRange Range) (bool, error) {
+	p.mutex.Lock()
+	if p.unbounded {
+		p.mutex.Unlock()
+		return p.config.Backends[0].IsPrepared(ctx, ledgerRange)
+	}
+	defer p.mutex.Unlock()
+
+	f := p.fanOut
+	if f == nil {
+		return false, nil
+	}
+	return f.ledgerRange.Contains(ledgerRange) && ledgerRange.from >= f.next, nil
+}
+
+// GetLedger returns the given ledger, blocking until it is fetched. Ledgers
+// must be read in increasing order; ledgers which are skipped are discarded.
+func (p *ParallelBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	p.mutex.Lock()
+	if p.unbounded {
+		p.mutex.Unlock()
+		return p.config.Backends[0].GetLedger(ctx, sequence)
+	}
+	defer p.mutex.Unlock()
+
+	f := p.fanOut
+	if f == nil {
+		return xdr.LedgerCloseMeta{}, errors.New("session is not prepared, call PrepareRange first")
+	}
+	if sequence < f.ledgerRange.from || sequence > f.ledgerRange.to {
+		return xdr.LedgerCloseMeta{}, errors.Errorf("requested ledger %d is outside of the prepared range %s", sequence, f.ledgerRange)
+	}
+	if sequence < f.next {
+		return xdr.LedgerCloseMeta{}, errors.Errorf("requested ledger %d was already read, the next ledger is %d", sequence, f.next)
+	}
+	for ; f.next < sequence; f.next++ {
+		f.release(f.next)
+	}
+	p.notify()
+
+	if err := p.waitForLedger(ctx, f, sequence); err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+	s := f.subRangeOf(sequence)
+	ledger := s.ledgers[sequence-s.from]
+	f.release(sequence)
+	f.next = sequence + 1
+	p.notify()
+	return ledger, nil
+}
+
+// Close stops fetching ledgers and closes all the backends.
+func (p *ParallelBackend) Close() error {
+	p.stop()
+
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+
+	var result error
+	for _, backend := range p.config.Backends {
+		if err := backend.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// stop stops the current fan-out and waits for its workers to exit.
+func (p *ParallelBackend) stop() {
+	p.mutex.Lock()
+	f := p.fanOut
+	p.fanOut = nil
+	p.unbounded = false
+	if f != nil {
+		p.notify()
+	}
+	p.mutex.Unlock()
+
+	if f != nil {
+		f.cancel()
+		f.wg.Wait()
+	}
+}
+
+func (f *fanOut) subRangeOf(sequence uint32) *subRange {
+	return f.subRanges[(sequence-f.ledgerRange.from)/(f.subRanges[0].to-f.subRanges[0].from+1)]
+}
+
+// head returns the sub-range being read, or nil once all the ledgers were
+// read.
+func (f *fanOut) head() *subRange {
+	if f.next > f.ledgerRange.to {
+		return nil
+	}
+	return f.subRangeOf(f.next)
+}
+
+// release frees a ledger which will not be read.
+func (f *fanOut) release(sequence uint32) {
+	s := f.subRangeOf(sequence)
+	if sequence < s.fetched() {
+		s.ledgers[sequence-s.from] = xdr.LedgerCloseMeta{}
+		f.buffered--
+	}
+}
+
+// work assigns sub-ranges to the backend at index i until all the sub-ranges
+// are fetched or ctx is done.
+func (p *ParallelBackend) work(ctx context.Context, f *fanOut, i int) {
+	defer f.wg.Done()
+	for {
+		s, ok := p.assign(ctx, f, i)
+		if !ok {
+			return
+		}
+		err := p.fetch(ctx, f, s, i)
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.mutex.Lock()
+		s.assigned = false
+		if err != nil && err != errYield {
+			s.attempts++
+			s.failedOn[i] = true
+			entry := p.config.Log.WithField("from", s.from).WithField("to", s.to).WithField("backend", i)
+			if s.attempts >= p.config.MaxAttempts {
+				s.err = errors.Wrapf(err, "error fetching ledgers %d-%d after %d attempts", s.from, s.to, s.attempts)
+				entry.WithError(err).Error("Error fetching sub-range, giving up")
+			} else {
+				entry.WithError(err).Warn("Error fetching sub-range, retrying")
+			}
+		}
+		p.notify()
+		p.mutex.Unlock()
+	}
+}
+
+// assign returns the first sub-range the backend at index i can fetch,
+// waiting while the remaining sub-ranges are fetched by other backends. A
+// sub-range which failed on a backend is left to the others, unless it failed
+// on all of them.
+func (p *ParallelBackend) assign(ctx context.Context, f *fanOut, i int) (*subRange, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for {
+		pending := false
+		for _, s := range f.subRanges {
+			if s.complete() || s.err != nil || s.to < f.next {
+				continue
+			}
+			pending = true
+			if p.canFetch(s, i) {
+				s.assigned = true
+				return s, true
+			}
+		}
+		if !pending || !p.wait(ctx) {
+			return nil, false
+		}
+	}
+}
+
+// canFetch returns true if s can be assigned to the backend at index i. It
+// must be called with the mutex held.
+func (p *ParallelBackend) canFetch(s *subRange, i int) bool {
+	if s.assigned || s.complete() || s.err != nil {
+		return false
+	}
+	return !s.failedOn[i] || len(s.failedOn) == len(p.config.Backends)
+}
+
+// fetch prepares backend with the missing ledgers of s and fetches them.
+func (p *ParallelBackend) fetch(ctx context.Context, f *fanOut, s *subRange, i int) error {
+	backend := p.config.Backends[i]
+	p.mutex.Lock()
+	from := s.fetched()
+	p.mutex.Unlock()
+
+	ledgerRange := BoundedRange(from, s.to)
+	if err := backend.PrepareRange(ctx, ledgerRange); err != nil {
+		return errors.Wrapf(err, "error preparing range %s", ledgerRange)
+	}
+	for sequence := from; sequence <= s.to; sequence++ {
+		if err := p.reserve(ctx, f, s, i); err != nil {
+			return err
+		}
+		ledger, err := backend.GetLedger(ctx, sequence)
+
+		p.mutex.Lock()
+		if err != nil {
+			f.buffered--
+			p.mutex.Unlock()
+			return errors.Wrapf(err, "error getting ledger %d", sequence)
+		}
+		if sequence < f.next {
+			// The consumer skipped this ledger.
+			ledger = xdr.LedgerCloseMeta{}
+			f.buffered--
+		}
+		s.ledgers = append(s.ledgers, ledger)
+		p.notify()
+		p.mutex.Unlock()
+	}
+	return nil
+}
+
+// reserve waits until there is room in the buffer for the next ledger of s
+// and reserves it. It returns errYield when the sub-range being read needs a
+// backend, so that s is released and the backend fetches it instead.
+func (p *ParallelBackend) reserve(ctx context.Context, f *fanOut, s *subRange, i int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for {
+		head := f.head()
+		if head == s {
+			// s is being read: only its own ledgers count.
+			if f.buffered < p.config.BufferSize || s.fetched()-f.next < uint32(p.config.BufferSize) {
+				f.buffered++
+				return nil
+			}
+		} else {
+			if f.buffered < p.config.BufferSize {
+				f.buffered++
+				return nil
+			}
+			if head != nil && p.canFetch(head, i) {
+				return errYield
+			}
+		}
+		if !p.wait(ctx) {
+			return ctx.Err()
+		}
+	}
+}
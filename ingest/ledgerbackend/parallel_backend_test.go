@@ -0,0 +1,216 @@
+package ledgerbackend
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// rangeBackend serves any ledger of the prepared range, optionally failing
+// at given ledgers.
+type rangeBackend struct {
+	mutex    sync.Mutex
+	prepared []Range
+	failAt   map[uint32]bool
+	fetched  *int64
+	// waitFor delays the first PrepareRange call until waitFor was prepared.
+	waitFor *rangeBackend
+	closed  bool
+}
+
+func (b *rangeBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	return 0, nil
+}
+
+func (b *rangeBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	if b.failAt[sequence] {
+		return xdr.LedgerCloseMeta{}, errors.New("connection lost")
+	}
+	if b.fetched != nil {
+		atomic.AddInt64(b.fetched, 1)
+	}
+	return xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(sequence)},
+			},
+		},
+	}, nil
+}
+
+func (b *rangeBackend) PrepareRange(ctx context.Context, ledgerRange Range) error {
+	for b.waitFor != nil && len(b.waitFor.preparedRanges()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.prepared = append(b.prepared, ledgerRange)
+	return nil
+}
+
+func (b *rangeBackend) IsPrepared(ctx context.Context, ledgerRange Range) (bool, error) {
+	return false, nil
+}
+
+func (b *rangeBackend) Close() error {
+	b.closed = true
+	return nil
+}
+
+func (b *rangeBackend) preparedRanges() []Range {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]Range{}, b.prepared...)
+}
+
+func readLedgers(t *testing.T, backend LedgerBackend, from, to uint32) {
+	for sequence := from; sequence <= to; sequence++ {
+		ledger, err := backend.GetLedger(context.Background(), sequence)
+		require.NoError(t, err)
+		require.Equal(t, sequence, ledger.LedgerSequence())
+	}
+}
+
+func TestParallelBackendFetchesInOrder(t *testing.T) {
+	backends := []*rangeBackend{{}, {}, {}}
+	parallel, err := NewParallelBackend(ParallelBackendConfig{
+		Backends:     []LedgerBackend{backends[0], backends[1], backends[2]},
+		SubRangeSize: 10,
+		BufferSize:   25,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, parallel.PrepareRange(ctx, BoundedRange(2, 100)))
+	prepared, err := parallel.IsPrepared(ctx, BoundedRange(2, 100))
+	require.NoError(t, err)
+	assert.True(t, prepared)
+
+	readLedgers(t, parallel, 2, 100)
+	prepared, err = parallel.IsPrepared(ctx, BoundedRange(2, 100))
+	require.NoError(t, err)
+	assert.False(t, prepared)
+
+	// Every sub-range was prepared exactly once, on one of the backends.
+	var ranges []Range
+	for _, backend := range backends {
+		ranges = append(ranges, backend.preparedRanges()...)
+	}
+	assert.ElementsMatch(t, []Range{
+		BoundedRange(2, 11), BoundedRange(12, 21), BoundedRange(22, 31),
+		BoundedRange(32, 41), BoundedRange(42, 51), BoundedRange(52, 61),
+		BoundedRange(62, 71), BoundedRange(72, 81), BoundedRange(82, 91),
+		BoundedRange(92, 100),
+	}, ranges)
+
+	_, err = parallel.GetLedger(ctx, 50)
+	assert.EqualError(t, err, "requested ledger 50 was already read, the next ledger is 101")
+	_, err = parallel.GetLedger(ctx, 101)
+	assert.EqualError(t, err, "requested ledger 101 is outside of the prepared range [2,100]")
+
+	require.NoError(t, parallel.Close())
+	for _, backend := range backends {
+		assert.True(t, backend.closed)
+	}
+}
+
+func TestParallelBackendRetriesOnAnotherBackend(t *testing.T) {
+	failing := &rangeBackend{failAt: map[uint32]bool{15: true, 25: true}}
+	working := &rangeBackend{waitFor: failing}
+	parallel, err := NewParallelBackend(ParallelBackendConfig{
+		Backends:     []LedgerBackend{failing, working},
+		SubRangeSize: 10,
+		BufferSize:   5,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, parallel.PrepareRange(context.Background(), BoundedRange(10, 29)))
+	readLedgers(t, parallel, 10, 29)
+
+	// The sub-ranges failing on the first backend are resumed by the second
+	// one after their last fetched ledger.
+	failed := failing.preparedRanges()
+	require.NotEmpty(t, failed)
+	for _, r := range failed {
+		assert.Contains(t, []Range{BoundedRange(10, 19), BoundedRange(20, 29)}, r)
+		assert.Contains(t, working.preparedRanges(), BoundedRange(r.from+5, r.to))
+	}
+	require.NoError(t, parallel.Close())
+}
+
+func TestParallelBackendGivesUp(t *testing.T) {
+	parallel, err := NewParallelBackend(ParallelBackendConfig{
+		Backends: []LedgerBackend{
+			&rangeBackend{failAt: map[uint32]bool{15: true}},
+			&rangeBackend{failAt: map[uint32]bool{15: true}},
+		},
+		SubRangeSize: 10,
+		MaxAttempts:  3,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, parallel.PrepareRange(context.Background(), BoundedRange(10, 29)))
+	readLedgers(t, parallel, 10, 14)
+	_, err = parallel.GetLedger(context.Background(), 15)
+	assert.EqualError(t, err, "error fetching ledgers 10-19 after 3 attempts: error getting ledger 15: connection lost")
+	require.NoError(t, parallel.Close())
+}
+
+func TestParallelBackendBoundsBuffer(t *testing.T) {
+	var fetched int64
+	parallel, err := NewParallelBackend(ParallelBackendConfig{
+		Backends: []LedgerBackend{
+			&rangeBackend{fetched: &fetched},
+			&rangeBackend{fetched: &fetched},
+			&rangeBackend{fetched: &fetched},
+		},
+		SubRangeSize: 10,
+		BufferSize:   25,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, parallel.PrepareRange(context.Background(), BoundedRange(1, 100)))
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&fetched) == 25
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, 25, atomic.LoadInt64(&fetched))
+	latest, err := parallel.GetLatestLedgerSequence(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 25, latest)
+
+	// Reading ledgers makes room for the following ones.
+	readLedgers(t, parallel, 1, 10)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&fetched) == 35
+	}, time.Second, time.Millisecond)
+
+	// Skipped ledgers are discarded.
+	ledger, err := parallel.GetLedger(context.Background(), 90)
+	require.NoError(t, err)
+	assert.EqualValues(t, 90, ledger.LedgerSequence())
+	readLedgers(t, parallel, 91, 100)
+	require.NoError(t, parallel.Close())
+}
+
+func TestParallelBackendUnboundedRange(t *testing.T) {
+	first, second := &rangeBackend{}, &rangeBackend{}
+	parallel, err := NewParallelBackend(ParallelBackendConfig{
+		Backends: []LedgerBackend{first, second},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, parallel.PrepareRange(context.Background(), UnboundedRange(10)))
+	readLedgers(t, parallel, 10, 12)
+	assert.Equal(t, []Range{UnboundedRange(10)}, first.preparedRanges())
+	assert.Empty(t, second.preparedRanges())
+	require.NoError(t, parallel.Close())
+}
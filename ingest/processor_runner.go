@@ -0,0 +1,340 @@
+package ingest
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// ChangeProcessor processes ledger entry changes, read either from the
+// buckets of a checkpoint or from the meta of a ledger.
+type ChangeProcessor interface {
+	ProcessChange(ctx context.Context, change Change) error
+}
+
+// LedgerTransactionProcessor processes the transactions of a ledger.
+type LedgerTransactionProcessor interface {
+	ProcessTransaction(ctx context.Context, transaction LedgerTransaction) error
+}
+
+// Committer is implemented by processors which buffer their output. Commit is
+// called once all the changes or transactions of a checkpoint or ledger have
+// been processed, before the cursor is moved past it.
+type Committer interface {
+	Commit(ctx context.Context, sequence uint32) error
+}
+
+// StreamChanges passes every change read from reader to processor.
+func StreamChanges(ctx context.Context, processor ChangeProcessor, reader ChangeReader) error {
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not read change")
+		}
+		if err = processor.ProcessChange(ctx, change); err != nil {
+			return errors.Wrap(err, "could not process change")
+		}
+	}
+}
+
+// StreamLedgerTransactions passes every transaction read from reader to
+// processor.
+func StreamLedgerTransactions(ctx context.Context, processor LedgerTransactionProcessor, reader *LedgerTransactionReader) error {
+	for {
+		tx, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not read transaction")
+		}
+		if err = processor.ProcessTransaction(ctx, tx); err != nil {
+			return errors.Wrapf(err, "could not process transaction %v", tx.Index)
+		}
+	}
+}
+
+// GroupChangeProcessors passes each change to all of its processors in order.
+type GroupChangeProcessors []ChangeProcessor
+
+func (g GroupChangeProcessors) ProcessChange(ctx context.Context, change Change) error {
+	for _, p := range g {
+		if err := p.ProcessChange(ctx, change); err != nil {
+			return errors.Wrapf(err, "error in %T.ProcessChange", p)
+		}
+	}
+	return nil
+}
+
+// Commit commits all processors implementing Committer.
+func (g GroupChangeProcessors) Commit(ctx context.Context, sequence uint32) error {
+	for _, p := range g {
+		if err := commit(ctx, p, sequence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GroupTransactionProcessors passes each transaction to all of its processors
+// in order.
+type GroupTransactionProcessors []LedgerTransactionProcessor
+
+func (g GroupTransactionProcessors) ProcessTransaction(ctx context.Context, tx LedgerTransaction) error {
+	for _, p := range g {
+		if err := p.ProcessTransaction(ctx, tx); err != nil {
+			return errors.Wrapf(err, "error in %T.ProcessTransaction", p)
+		}
+	}
+	return nil
+}
+
+// Commit commits all processors implementing Committer.
+func (g GroupTransactionProcessors) Commit(ctx context.Context, sequence uint32) error {
+	for _, p := range g {
+		if err := commit(ctx, p, sequence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func commit(ctx context.Context, p interface{}, sequence uint32) error {
+	if c, ok := p.(Committer); ok {
+		if err := c.Commit(ctx, sequence); err != nil {
+			return errors.Wrapf(err, "error in %T.Commit", p)
+		}
+	}
+	return nil
+}
+
+// ProcessorRunnerConfig configures a ProcessorRunner.
+type ProcessorRunnerConfig struct {
+	// Backend provides the ledgers to process.
+	Backend ledgerbackend.LedgerBackend
+	// Archive provides the checkpoint state processed when there is no
+	// cursor yet.
+	Archive historyarchive.ArchiveInterface
+	// NetworkPassphrase is the passphrase of the network being ingested.
+	NetworkPassphrase string
+	// Cursor persists the last processed ledger, so that Run resumes where
+	// it stopped.
+	Cursor CursorStore
+	// StartCheckpoint is the checkpoint ledger whose state is processed when
+	// there is no cursor yet. If 0, the latest checkpoint of Archive is used.
+	StartCheckpoint uint32
+	// ChangeProcessors are run on checkpoint state and on ledger changes.
+	ChangeProcessors []ChangeProcessor
+	// TransactionProcessors are run on ledger transactions.
+	TransactionProcessors []LedgerTransactionProcessor
+}
+
+// ProcessorRunner runs change and transaction processors over checkpoint state
+// and ledgers, the way OrbitR builds its own database:
+//
+//   - Run bootstraps state from a checkpoint, then follows new ledgers as they
+//     close, saving a cursor after each one.
+//   - RunRange reingests a bounded range of ledgers with the transaction
+//     processors only, leaving the cursor alone.
+//
+// A ledger is never interrupted half way through: on cancellation the runner
+// finishes and commits the current ledger first. Checkpoint state processing
+// is interrupted and done again on the next Run, as are ledgers being
+// processed when the process is killed, so processors should be idempotent.
+type ProcessorRunner struct {
+	config                ProcessorRunnerConfig
+	changeProcessors      GroupChangeProcessors
+	transactionProcessors GroupTransactionProcessors
+}
+
+// NewProcessorRunner returns a ProcessorRunner using config.
+func NewProcessorRunner(config ProcessorRunnerConfig) (*ProcessorRunner, error) {
+	if config.Backend == nil {
+		return nil, errors.New("ledger backend is required")
+	}
+	if config.NetworkPassphrase == "" {
+		return nil, errors.New("network passphrase is required")
+	}
+	return &ProcessorRunner{
+		config:                config,
+		changeProcessors:      GroupChangeProcessors(config.ChangeProcessors),
+		transactionProcessors: GroupTransactionProcessors(config.TransactionProcessors),
+	}, nil
+}
+
+// Run processes ledgers from the one after the cursor onwards, until ctx is
+// cancelled. If there is no cursor, it first processes the state at
+// StartCheckpoint with the change processors. Run returns nil when stopped by
+// ctx.
+func (r *ProcessorRunner) Run(ctx context.Context) error {
+	if r.config.Cursor == nil {
+		return errors.New("cursor store is required")
+	}
+	cursor, err := r.config.Cursor.GetCursor(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting cursor")
+	}
+
+	if cursor == 0 {
+		checkpoint := r.config.StartCheckpoint
+		if checkpoint == 0 {
+			if r.config.Archive == nil {
+				return errors.New("history archive is required to find the start checkpoint")
+			}
+			has, err := r.config.Archive.GetRootHAS()
+			if err != nil {
+				return errors.Wrap(err, "error getting root HAS")
+			}
+			checkpoint = has.CurrentLedger
+		}
+		if err = r.RunCheckpoint(ctx, checkpoint); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err = r.config.Cursor.SetCursor(detach(ctx), checkpoint); err != nil {
+			return errors.Wrap(err, "error saving cursor")
+		}
+		cursor = checkpoint
+	}
+
+	err = r.config.Backend.PrepareRange(ctx, ledgerbackend.UnboundedRange(cursor+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return errors.Wrapf(err, "error preparing range from %d", cursor+1)
+	}
+
+	for sequence := cursor + 1; ctx.Err() == nil; sequence++ {
+		if err = r.runLedgerSequence(ctx, sequence, true); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err = r.config.Cursor.SetCursor(detach(ctx), sequence); err != nil {
+			return errors.Wrap(err, "error saving cursor")
+		}
+	}
+	return nil
+}
+
+// RunRange processes ledgers [from, to] with the transaction processors,
+// e.g. to rebuild history after a processor changed. Change processors are
+// not run, as state can't be rebuilt from changes alone. It returns ctx.Err()
+// if cancelled before to is processed.
+func (r *ProcessorRunner) RunRange(ctx context.Context, from, to uint32) error {
+	if from > to {
+		return errors.Errorf("invalid range: from (%d) > to (%d)", from, to)
+	}
+	err := r.config.Backend.PrepareRange(ctx, ledgerbackend.BoundedRange(from, to))
+	if err != nil {
+		return errors.Wrapf(err, "error preparing range [%d, %d]", from, to)
+	}
+	for sequence := from; sequence <= to; sequence++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.runLedgerSequence(ctx, sequence, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunCheckpoint processes the state at a checkpoint ledger with the change
+// processors and commits them.
+func (r *ProcessorRunner) RunCheckpoint(ctx context.Context, checkpoint uint32) error {
+	if r.config.Archive == nil {
+		return errors.New("history archive is required to process checkpoint state")
+	}
+	reader, err := NewCheckpointChangeReader(ctx, r.config.Archive, checkpoint)
+	if err != nil {
+		return errors.Wrapf(err, "error creating checkpoint change reader for %d", checkpoint)
+	}
+	defer reader.Close()
+
+	if err = StreamChanges(ctx, r.changeProcessors, reader); err != nil {
+		return errors.Wrapf(err, "error processing checkpoint %d", checkpoint)
+	}
+	return errors.Wrapf(
+		r.changeProcessors.Commit(ctx, checkpoint),
+		"error committing checkpoint %d", checkpoint,
+	)
+}
+
+// RunLedger processes a ledger with both the change and the transaction
+// processors and commits them.
+func (r *ProcessorRunner) RunLedger(ctx context.Context, ledger xdr.LedgerCloseMeta) error {
+	return r.runLedger(ctx, ledger, true)
+}
+
+func (r *ProcessorRunner) runLedgerSequence(ctx context.Context, sequence uint32, changes bool) error {
+	ledger, err := r.config.Backend.GetLedger(ctx, sequence)
+	if err != nil {
+		return errors.Wrapf(err, "error getting ledger %d", sequence)
+	}
+	return r.runLedger(detach(ctx), ledger, changes)
+}
+
+func (r *ProcessorRunner) runLedger(ctx context.Context, ledger xdr.LedgerCloseMeta, changes bool) error {
+	sequence := ledger.LedgerSequence()
+	if changes && len(r.changeProcessors) > 0 {
+		changeReader, err := NewLedgerChangeReaderFromLedgerCloseMeta(r.config.NetworkPassphrase, ledger)
+		if err != nil {
+			return errors.Wrapf(err, "error creating ledger change reader for %d", sequence)
+		}
+		err = StreamChanges(ctx, r.changeProcessors, changeReader)
+		changeReader.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error processing changes of ledger %d", sequence)
+		}
+	}
+
+	if len(r.transactionProcessors) > 0 {
+		txReader, err := NewLedgerTransactionReaderFromLedgerCloseMeta(r.config.NetworkPassphrase, ledger)
+		if err != nil {
+			return errors.Wrapf(err, "error creating ledger transaction reader for %d", sequence)
+		}
+		err = StreamLedgerTransactions(ctx, r.transactionProcessors, txReader)
+		txReader.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error processing transactions of ledger %d", sequence)
+		}
+	}
+
+	if changes {
+		if err := r.changeProcessors.Commit(ctx, sequence); err != nil {
+			return errors.Wrapf(err, "error committing ledger %d", sequence)
+		}
+	}
+	return errors.Wrapf(
+		r.transactionProcessors.Commit(ctx, sequence),
+		"error committing ledger %d", sequence,
+	)
+}
+
+// detachedContext keeps the values of its parent but is never cancelled, so
+// that a checkpoint or ledger being processed is not interrupted on shutdown.
+type detachedContext struct {
+	parent context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
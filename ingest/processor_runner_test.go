@@ -0,0 +1,212 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/xdr"
+)
+
+const (
+	runnerAccount1 = "GACMZD5VJXTRLKVET72CETCYKELPNCOTTBDC6DHFEUPLG5DHEK534JQX"
+	runnerAccount2 = "GA5WBPYA5Y4WAEHXWR2UKO2UO4BUGHUQ74EUPKON2QHV4WRHOIRNKKH2"
+)
+
+type recordingProcessor struct {
+	changes      []Change
+	transactions []LedgerTransaction
+	commits      []uint32
+}
+
+func (p *recordingProcessor) ProcessChange(ctx context.Context, change Change) error {
+	p.changes = append(p.changes, change)
+	return nil
+}
+
+func (p *recordingProcessor) ProcessTransaction(ctx context.Context, tx LedgerTransaction) error {
+	p.transactions = append(p.transactions, tx)
+	return nil
+}
+
+func (p *recordingProcessor) Commit(ctx context.Context, sequence uint32) error {
+	// Commits must not be interrupted by shutdown.
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	p.commits = append(p.commits, sequence)
+	return nil
+}
+
+// testCheckpointArchive returns an archive whose latest checkpoint, 63, holds
+// a single account.
+func testCheckpointArchive(t *testing.T) historyarchive.ArchiveInterface {
+	var raw, gz bytes.Buffer
+	for _, entry := range []xdr.BucketEntry{metaEntry(11), entryAccount(xdr.BucketEntryTypeLiveentry, runnerAccount1, 100)} {
+		require.NoError(t, xdr.MarshalFramed(&raw, entry))
+	}
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write(raw.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	bucket := historyarchive.Hash(sha256.Sum256(raw.Bytes()))
+
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+	require.NoError(t, arch.Backend().PutFile(historyarchive.BucketPath(bucket), ioutil.NopCloser(&gz)))
+	var has historyarchive.HistoryArchiveState
+	has.CurrentLedger = 63
+	for i := range has.CurrentBuckets {
+		has.CurrentBuckets[i].Curr = historyarchive.Hash{}.String()
+		has.CurrentBuckets[i].Snap = historyarchive.Hash{}.String()
+	}
+	has.CurrentBuckets[0].Curr = bucket.String()
+	require.NoError(t, arch.PutCheckpointHAS(63, has, &historyarchive.CommandOptions{}))
+	require.NoError(t, arch.PutRootHAS(has, &historyarchive.CommandOptions{}))
+	return arch
+}
+
+// testRunnerLedger returns a ledger with a single transaction creating an
+// account.
+func testRunnerLedger(t *testing.T, sequence uint32) xdr.LedgerCloseMeta {
+	envelope := xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				Fee:           100,
+				SourceAccount: xdr.MustMuxedAddress(runnerAccount1),
+				SeqNum:        xdr.SequenceNumber(sequence),
+			},
+		},
+	}
+	hash, err := network.HashTransactionInEnvelope(envelope, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	return xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(sequence), LedgerVersion: 10},
+			},
+			TxSet: xdr.TransactionSet{Txs: []xdr.TransactionEnvelope{envelope}},
+			TxProcessing: []xdr.TransactionResultMeta{{
+				Result: xdr.TransactionResultPair{
+					TransactionHash: hash,
+					Result: xdr.TransactionResult{
+						Result: xdr.TransactionResultResult{
+							Code:    xdr.TransactionResultCodeTxSuccess,
+							Results: &[]xdr.OperationResult{},
+						},
+					},
+				},
+				TxApplyProcessing: xdr.TransactionMeta{
+					V: 1,
+					V1: &xdr.TransactionMetaV1{
+						TxChanges: xdr.LedgerEntryChanges{buildChange(runnerAccount2, int64(sequence))},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestProcessorRunnerRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := &ledgerbackend.MockDatabaseBackend{}
+	backend.On("PrepareRange", ctx, ledgerbackend.UnboundedRange(64)).Return(nil).Once()
+	backend.On("GetLedger", ctx, uint32(64)).Return(testRunnerLedger(t, 64), nil).Once()
+	// Shutdown is requested while ledger 65 is being fetched; it is still
+	// processed and committed.
+	backend.On("GetLedger", ctx, uint32(65)).Return(testRunnerLedger(t, 65), nil).Run(func(mock.Arguments) {
+		cancel()
+	}).Once()
+
+	cursor := FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+	changes := &recordingProcessor{}
+	transactions := &recordingProcessor{}
+	runner, err := NewProcessorRunner(ProcessorRunnerConfig{
+		Backend:               backend,
+		Archive:               testCheckpointArchive(t),
+		NetworkPassphrase:     network.TestNetworkPassphrase,
+		Cursor:                cursor,
+		ChangeProcessors:      []ChangeProcessor{changes},
+		TransactionProcessors: []LedgerTransactionProcessor{transactions},
+	})
+	require.NoError(t, err)
+	require.NoError(t, runner.Run(ctx))
+	backend.AssertExpectations(t)
+
+	require.Len(t, changes.changes, 3)
+	isBalance(runnerAccount1, 100)(t, 0, changes.changes[0])
+	isBalance(runnerAccount2, 64)(t, 1, changes.changes[1])
+	isBalance(runnerAccount2, 65)(t, 2, changes.changes[2])
+	assert.Equal(t, []uint32{63, 64, 65}, changes.commits)
+	require.Len(t, transactions.transactions, 2)
+	assert.Equal(t, []uint32{64, 65}, transactions.commits)
+
+	sequence, err := cursor.GetCursor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(65), sequence)
+
+	// A new run resumes after the cursor without processing checkpoint state.
+	ctx, cancel = context.WithCancel(context.Background())
+	backend = &ledgerbackend.MockDatabaseBackend{}
+	backend.On("PrepareRange", ctx, ledgerbackend.UnboundedRange(66)).Return(nil).Once()
+	backend.On("GetLedger", ctx, uint32(66)).Return(xdr.LedgerCloseMeta{}, context.Canceled).Run(func(mock.Arguments) {
+		cancel()
+	}).Once()
+	runner.config.Backend = backend
+	require.NoError(t, runner.Run(ctx))
+	backend.AssertExpectations(t)
+	assert.Equal(t, []uint32{63, 64, 65}, changes.commits)
+}
+
+func TestProcessorRunnerRunRange(t *testing.T) {
+	ctx := context.Background()
+	backend := &ledgerbackend.MockDatabaseBackend{}
+	backend.On("PrepareRange", ctx, ledgerbackend.BoundedRange(10, 11)).Return(nil).Once()
+	backend.On("GetLedger", ctx, uint32(10)).Return(testRunnerLedger(t, 10), nil).Once()
+	backend.On("GetLedger", ctx, uint32(11)).Return(testRunnerLedger(t, 11), nil).Once()
+
+	changes := &recordingProcessor{}
+	transactions := &recordingProcessor{}
+	runner, err := NewProcessorRunner(ProcessorRunnerConfig{
+		Backend:               backend,
+		NetworkPassphrase:     network.TestNetworkPassphrase,
+		ChangeProcessors:      []ChangeProcessor{changes},
+		TransactionProcessors: []LedgerTransactionProcessor{transactions},
+	})
+	require.NoError(t, err)
+	require.NoError(t, runner.RunRange(ctx, 10, 11))
+	backend.AssertExpectations(t)
+
+	assert.Empty(t, changes.changes)
+	assert.Empty(t, changes.commits)
+	require.Len(t, transactions.transactions, 2)
+	assert.Equal(t, []uint32{10, 11}, transactions.commits)
+
+	assert.EqualError(t, runner.RunRange(ctx, 11, 10), "invalid range: from (11) > to (10)")
+}
+
+func TestFileCursorStore(t *testing.T) {
+	ctx := context.Background()
+	store := FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+	sequence, err := store.GetCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), sequence)
+
+	require.NoError(t, store.SetCursor(ctx, 127))
+	sequence, err = store.GetCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(127), sequence)
+}
@@ -0,0 +1,170 @@
+package sink
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/metriqorg/go/support/config"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// FileConfig is the TOML configuration of a sink, e.g.:
+//
+//	format = "json"
+//	cursor-file = "/var/lib/sink/cursor.json"
+//	events = ["transaction", "operation"]
+//
+//	[[outputs]]
+//	type = "kafka"
+//	address = "localhost:9092"
+//	topic = "ledger-events"
+//
+//	[[outputs]]
+//	type = "webhook"
+//	url = "https://example.com/events"
+//	secret = "..."
+//
+// The ledger backend and history archive are configured by the program
+// running the sink.
+type FileConfig struct {
+	// Format is "json" (default) or "protobuf".
+	Format       string   `toml:"format" valid:"optional,matches(^(json|protobuf)$)"`
+	CursorFile   string   `toml:"cursor-file" valid:"required"`
+	StartLedger  uint32   `toml:"start-ledger" valid:"optional"`
+	IncludeState bool     `toml:"include-state" valid:"optional"`
+	Events       []string `toml:"events" valid:"optional"`
+	// Outputs are tables in an array named "outputs".
+	Outputs []OutputConfig `toml:"outputs" valid:"required"`
+}
+
+// OutputConfig configures an output. Which fields are used depends on Type:
+//
+//   - "kafka": Address (comma separated broker "host:port" addresses),
+//     Topic, TransactionalID, ClientID, TLS, Username and Password.
+//   - "nats": Address (e.g. "nats://localhost:4222"), Subject and Token.
+//   - "webhook": URL and Secret.
+//   - "stdout": nothing.
+//
+// Timeout applies to all outputs but stdout and Attempts to nats and webhook
+// outputs.
+type OutputConfig struct {
+	Type            string `toml:"type" valid:"matches(^(kafka|nats|webhook|stdout)$)"`
+	Address         string `toml:"address" valid:"optional"`
+	Topic           string `toml:"topic" valid:"optional"`
+	TransactionalID string `toml:"transactional-id" valid:"optional"`
+	ClientID        string `toml:"client-id" valid:"optional"`
+	TLS             bool   `toml:"tls" valid:"optional"`
+	Username        string `toml:"username" valid:"optional"`
+	Password        string `toml:"password" valid:"optional"`
+	Subject         string `toml:"subject" valid:"optional"`
+	Token           string `toml:"token" valid:"optional"`
+	URL             string `toml:"url" valid:"optional"`
+	Secret          string `toml:"secret" valid:"optional"`
+	Attempts        int    `toml:"attempts" valid:"optional"`
+	// Timeout is a duration such as "10s".
+	Timeout string `toml:"timeout" valid:"optional"`
+}
+
+// ReadConfig reads and validates the configuration file at path.
+func ReadConfig(path string) (*FileConfig, error) {
+	var c FileConfig
+	if err := config.Read(path, &c); err != nil {
+		return nil, err
+	}
+	if _, err := c.EventTypes(); err != nil {
+		return nil, err
+	}
+	if len(c.Outputs) == 0 {
+		return nil, errors.New("at least one output is required")
+	}
+	for i, o := range c.Outputs {
+		if _, err := o.NewOutput(); err != nil {
+			return nil, errors.Wrapf(err, "invalid output %d", i)
+		}
+	}
+	return &c, nil
+}
+
+// EventTypes returns the parsed Events.
+func (c *FileConfig) EventTypes() ([]EventType, error) {
+	var types []EventType
+	for _, name := range c.Events {
+		t, err := ParseEventType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// NewOutputs returns the configured outputs.
+func (c *FileConfig) NewOutputs() ([]Output, error) {
+	var outputs []Output
+	for i, o := range c.Outputs {
+		output, err := o.NewOutput()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid output %d", i)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// NewOutput returns the configured output.
+func (o OutputConfig) NewOutput() (Output, error) {
+	var timeout time.Duration
+	if o.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(o.Timeout); err != nil {
+			return nil, errors.Wrap(err, "invalid timeout")
+		}
+	}
+
+	switch o.Type {
+	case "kafka":
+		if o.Address == "" || o.Topic == "" {
+			return nil, errors.New("kafka output requires an address and a topic")
+		}
+		output := &KafkaOutput{
+			Brokers:         strings.Split(o.Address, ","),
+			Topic:           o.Topic,
+			TransactionalID: o.TransactionalID,
+			ClientID:        o.ClientID,
+			Username:        o.Username,
+			Password:        o.Password,
+			Timeout:         timeout,
+		}
+		if o.TLS {
+			output.TLS = &tls.Config{}
+		}
+		return output, nil
+	case "nats":
+		if o.Address == "" || o.Subject == "" {
+			return nil, errors.New("nats output requires an address and a subject")
+		}
+		return &NATSOutput{
+			URL:      o.Address,
+			Subject:  o.Subject,
+			Token:    o.Token,
+			Attempts: o.Attempts,
+			Timeout:  timeout,
+		}, nil
+	case "webhook":
+		if o.URL == "" {
+			return nil, errors.New("webhook output requires a url")
+		}
+		output := &WebhookOutput{URL: o.URL, Secret: o.Secret, Attempts: o.Attempts}
+		if timeout != 0 {
+			output.Client = &http.Client{Timeout: timeout}
+		}
+		return output, nil
+	case "stdout":
+		return WriterOutput{Writer: os.Stdout}, nil
+	default:
+		return nil, errors.Errorf("unknown output type: %s", o.Type)
+	}
+}
@@ -0,0 +1,146 @@
+package sink
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// Encoder serializes events into message payloads.
+type Encoder interface {
+	Encode(event Event) ([]byte, error)
+	// ContentType is the MIME type of the payloads, sent along messages by
+	// outputs supporting it.
+	ContentType() string
+}
+
+// NewEncoder returns the encoder for format, "json" or "protobuf".
+func NewEncoder(format string) (Encoder, error) {
+	switch format {
+	case "", "json":
+		return JSONEncoder{}, nil
+	case "protobuf":
+		return ProtobufEncoder{}, nil
+	default:
+		return nil, errors.Errorf("unknown format: %s", format)
+	}
+}
+
+// JSONEncoder encodes events as JSON objects.
+type JSONEncoder struct{}
+
+func (JSONEncoder) Encode(event Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (JSONEncoder) ContentType() string {
+	return "application/json"
+}
+
+// ProtobufEncoder encodes events as the Event protobuf message defined in
+// event.proto. Effect details, which have no fixed schema, are embedded as
+// JSON.
+type ProtobufEncoder struct{}
+
+func (ProtobufEncoder) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (ProtobufEncoder) Encode(event Event) ([]byte, error) {
+	var b []byte
+	b = appendString(b, 1, event.ID)
+	b = appendString(b, 2, string(event.Type))
+	b = appendVarint(b, 3, uint64(event.Ledger))
+	b = appendVarint(b, 4, uint64(event.ClosedAt))
+	b = appendString(b, 5, event.TransactionHash)
+
+	switch {
+	case event.Transaction != nil:
+		tx := event.Transaction
+		var m []byte
+		m = appendVarint(m, 1, uint64(tx.Index))
+		m = appendString(m, 2, tx.SourceAccount)
+		m = appendString(m, 3, tx.FeeAccount)
+		m = appendVarint(m, 4, uint64(tx.FeeCharged))
+		m = appendBool(m, 5, tx.Successful)
+		m = appendString(m, 6, tx.ResultCode)
+		m = appendVarint(m, 7, uint64(tx.OperationCount))
+		m = appendBytes(m, 8, tx.EnvelopeXdr)
+		m = appendBytes(m, 9, tx.ResultXdr)
+		m = appendBytes(m, 10, tx.MetaXdr)
+		b = appendMessage(b, 10, m)
+	case event.Operation != nil:
+		op := event.Operation
+		var m []byte
+		m = appendVarint(m, 1, uint64(op.TransactionIndex))
+		m = appendVarint(m, 2, uint64(op.Index))
+		m = appendString(m, 3, op.Type)
+		m = appendString(m, 4, op.SourceAccount)
+		m = appendBytes(m, 5, op.BodyXdr)
+		m = appendBytes(m, 6, op.ResultXdr)
+		b = appendMessage(b, 11, m)
+	case event.Effect != nil:
+		effect := event.Effect
+		var m []byte
+		m = appendVarint(m, 1, uint64(effect.TransactionIndex))
+		m = appendVarint(m, 2, uint64(effect.OperationIndex))
+		m = appendVarint(m, 3, uint64(effect.Order))
+		m = appendString(m, 4, effect.Type)
+		m = appendString(m, 5, effect.Account)
+		if len(effect.Details) > 0 {
+			details, err := json.Marshal(effect.Details)
+			if err != nil {
+				return nil, errors.Wrap(err, "error encoding effect details")
+			}
+			m = appendBytes(m, 6, details)
+		}
+		b = appendMessage(b, 12, m)
+	case event.Change != nil:
+		change := event.Change
+		var m []byte
+		m = appendVarint(m, 1, uint64(change.Index))
+		m = appendString(m, 2, change.ChangeType)
+		m = appendString(m, 3, change.EntryType)
+		m = appendBytes(m, 4, change.KeyXdr)
+		m = appendBytes(m, 5, change.PreXdr)
+		m = appendBytes(m, 6, change.PostXdr)
+		b = appendMessage(b, 13, m)
+	}
+	return b, nil
+}
+
+// The append functions below omit fields with default values, like protobuf
+// 3 encoders do.
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	return appendVarint(b, num, protowire.EncodeBool(v))
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	return appendBytes(b, num, []byte(v))
+}
+
+// appendMessage appends an embedded message, even if empty, as it is a
+// member of a oneof.
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
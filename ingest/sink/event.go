@@ -0,0 +1,313 @@
+package sink
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/metriqorg/go/ingest"
+	"github.com/metriqorg/go/protocols/orbitr/operations"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/toid"
+	"github.com/metriqorg/go/xdr"
+)
+
+// EventType is the kind of an Event.
+type EventType string
+
+const (
+	EventTypeTransaction EventType = "transaction"
+	EventTypeOperation   EventType = "operation"
+	EventTypeEffect      EventType = "effect"
+	EventTypeChange      EventType = "change"
+)
+
+// EventTypes lists all event types, in the order events of a ledger are
+// published: changes first, then each transaction followed by its
+// operations and their effects.
+var EventTypes = []EventType{
+	EventTypeChange,
+	EventTypeTransaction,
+	EventTypeOperation,
+	EventTypeEffect,
+}
+
+// ParseEventType returns the event type named s.
+func ParseEventType(s string) (EventType, error) {
+	for _, t := range EventTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", errors.Errorf("unknown event type: %s", s)
+}
+
+// Event is a normalized description of something that happened in a ledger.
+// Exactly one of Transaction, Operation, Effect and Change is set, depending
+// on Type.
+//
+// ID is derived from the position of the event in the ledger, so an event
+// published again after a restart has the same ID and consumers can
+// deduplicate it.
+type Event struct {
+	ID   string    `json:"id"`
+	Type EventType `json:"type"`
+	// Ledger is the sequence of the ledger the event happened in, or of the
+	// checkpoint for changes read from checkpoint state.
+	Ledger uint32 `json:"ledger"`
+	// ClosedAt is the close time of Ledger as a unix timestamp, or 0 for
+	// changes read from checkpoint state.
+	ClosedAt int64 `json:"closed_at,omitempty"`
+	// TransactionHash is the hash of the transaction the event belongs to.
+	// It is empty for change events.
+	TransactionHash string `json:"transaction_hash,omitempty"`
+
+	Transaction *TransactionEvent `json:"transaction,omitempty"`
+	Operation   *OperationEvent   `json:"operation,omitempty"`
+	Effect      *EffectEvent      `json:"effect,omitempty"`
+	Change      *ChangeEvent      `json:"change,omitempty"`
+}
+
+// TransactionEvent describes an applied transaction. XDR fields are encoded
+// as base64 in JSON and as bytes in protobuf.
+type TransactionEvent struct {
+	Index          uint32 `json:"index"`
+	SourceAccount  string `json:"source_account"`
+	FeeAccount     string `json:"fee_account,omitempty"`
+	FeeCharged     int64  `json:"fee_charged"`
+	Successful     bool   `json:"successful"`
+	ResultCode     string `json:"result_code"`
+	OperationCount uint32 `json:"operation_count"`
+	EnvelopeXdr    []byte `json:"envelope_xdr"`
+	ResultXdr      []byte `json:"result_xdr"`
+	MetaXdr        []byte `json:"meta_xdr"`
+}
+
+// OperationEvent describes an operation of an applied transaction.
+type OperationEvent struct {
+	TransactionIndex uint32 `json:"transaction_index"`
+	Index            uint32 `json:"index"`
+	// Type is the operation type as named by OrbitR, e.g. "payment".
+	Type          string `json:"type"`
+	SourceAccount string `json:"source_account"`
+	BodyXdr       []byte `json:"body_xdr"`
+	// ResultXdr is empty if the transaction failed before applying its
+	// operations.
+	ResultXdr []byte `json:"result_xdr,omitempty"`
+}
+
+// EffectEvent describes an effect of an operation. Effects are computed by
+// the EffectsFunc given to the sink.
+type EffectEvent struct {
+	TransactionIndex uint32 `json:"transaction_index"`
+	OperationIndex   uint32 `json:"operation_index"`
+	// Order is the position of the effect among the effects of the operation,
+	// starting at 1.
+	Order uint32 `json:"order"`
+	// Type is the effect type as named by OrbitR, e.g. "account_credited".
+	Type    string                 `json:"type"`
+	Account string                 `json:"account"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// ChangeEvent describes a ledger entry change. PreXdr is empty for created
+// entries and PostXdr for removed ones.
+type ChangeEvent struct {
+	// Index is the position of the change among the changes of the ledger.
+	Index uint32 `json:"index"`
+	// ChangeType is "created", "updated" or "removed".
+	ChangeType string `json:"change_type"`
+	// EntryType is the ledger entry type, e.g. "account".
+	EntryType string `json:"entry_type"`
+	KeyXdr    []byte `json:"key_xdr"`
+	PreXdr    []byte `json:"pre_xdr,omitempty"`
+	PostXdr   []byte `json:"post_xdr,omitempty"`
+}
+
+// key returns the Message.Key of the event.
+func (e Event) key() string {
+	if e.Change != nil {
+		return base64.StdEncoding.EncodeToString(e.Change.KeyXdr)
+	}
+	return e.TransactionHash
+}
+
+// EffectsFunc returns the effects of the operations of a transaction. The
+// TransactionIndex of the returned effects is set by the caller.
+type EffectsFunc func(transaction ingest.LedgerTransaction, ledger uint32) ([]EffectEvent, error)
+
+// ledgerHeader identifies the ledger events are built for.
+type ledgerHeader struct {
+	sequence uint32
+	closedAt int64
+}
+
+func transactionEvents(header ledgerHeader, tx ingest.LedgerTransaction, effects EffectsFunc) ([]Event, error) {
+	envelopeXdr, err := tx.Envelope.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding envelope")
+	}
+	resultXdr, err := tx.Result.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding result")
+	}
+	metaXdr, err := tx.UnsafeMeta.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding meta")
+	}
+
+	source := tx.Envelope.SourceAccount()
+	ops := tx.Envelope.Operations()
+	txEvent := &TransactionEvent{
+		Index:          tx.Index,
+		SourceAccount:  source.Address(),
+		FeeCharged:     int64(tx.Result.Result.FeeCharged),
+		Successful:     tx.Result.Successful(),
+		ResultCode:     resultCode(tx.Result.Result.Result.Code),
+		OperationCount: uint32(len(ops)),
+		EnvelopeXdr:    envelopeXdr,
+		ResultXdr:      resultXdr,
+		MetaXdr:        metaXdr,
+	}
+	if tx.Envelope.IsFeeBump() {
+		feeAccount := tx.Envelope.FeeBumpAccount()
+		txEvent.FeeAccount = feeAccount.Address()
+	}
+
+	hash := hex.EncodeToString(tx.Result.TransactionHash[:])
+	txID := toid.New(int32(header.sequence), int32(tx.Index), 0).ToInt64()
+	events := []Event{{
+		ID:              fmt.Sprintf("tx-%d", txID),
+		Type:            EventTypeTransaction,
+		Ledger:          header.sequence,
+		ClosedAt:        header.closedAt,
+		TransactionHash: hash,
+		Transaction:     txEvent,
+	}}
+
+	results, _ := tx.Result.OperationResults()
+	for i, op := range ops {
+		opSource := source
+		if op.SourceAccount != nil {
+			opSource = *op.SourceAccount
+		}
+		bodyXdr, err := op.Body.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error encoding operation %d", i)
+		}
+		opEvent := &OperationEvent{
+			TransactionIndex: tx.Index,
+			Index:            uint32(i),
+			Type:             operations.TypeNames[op.Body.Type],
+			SourceAccount:    opSource.Address(),
+			BodyXdr:          bodyXdr,
+		}
+		if i < len(results) {
+			if opEvent.ResultXdr, err = results[i].MarshalBinary(); err != nil {
+				return nil, errors.Wrapf(err, "error encoding operation %d result", i)
+			}
+		}
+		events = append(events, Event{
+			ID:              fmt.Sprintf("op-%d", toid.New(int32(header.sequence), int32(tx.Index), int32(i+1)).ToInt64()),
+			Type:            EventTypeOperation,
+			Ledger:          header.sequence,
+			ClosedAt:        header.closedAt,
+			TransactionHash: hash,
+			Operation:       opEvent,
+		})
+	}
+
+	if effects == nil {
+		return events, nil
+	}
+	effectEvents, err := effects(tx, header.sequence)
+	if err != nil {
+		return nil, errors.Wrap(err, "error computing effects")
+	}
+	for i := range effectEvents {
+		effect := effectEvents[i]
+		effect.TransactionIndex = tx.Index
+		opID := toid.New(int32(header.sequence), int32(tx.Index), int32(effect.OperationIndex+1)).ToInt64()
+		events = append(events, Event{
+			ID:              fmt.Sprintf("effect-%d-%d", opID, effect.Order),
+			Type:            EventTypeEffect,
+			Ledger:          header.sequence,
+			ClosedAt:        header.closedAt,
+			TransactionHash: hash,
+			Effect:          &effect,
+		})
+	}
+	return events, nil
+}
+
+func changeEvent(header ledgerHeader, index uint32, change ingest.Change) (Event, error) {
+	entry := change.Post
+	if entry == nil {
+		entry = change.Pre
+	}
+	key, err := entry.LedgerKey()
+	if err != nil {
+		return Event{}, errors.Wrap(err, "error getting ledger key")
+	}
+	keyXdr, err := key.MarshalBinary()
+	if err != nil {
+		return Event{}, errors.Wrap(err, "error encoding ledger key")
+	}
+	changeEvent := &ChangeEvent{
+		Index:     index,
+		EntryType: entryTypeName(change.Type),
+		KeyXdr:    keyXdr,
+	}
+	switch change.LedgerEntryChangeType() {
+	case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+		changeEvent.ChangeType = "created"
+	case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+		changeEvent.ChangeType = "updated"
+	case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+		changeEvent.ChangeType = "removed"
+	}
+	if change.Pre != nil {
+		if changeEvent.PreXdr, err = change.Pre.MarshalBinary(); err != nil {
+			return Event{}, errors.Wrap(err, "error encoding entry")
+		}
+	}
+	if change.Post != nil {
+		if changeEvent.PostXdr, err = change.Post.MarshalBinary(); err != nil {
+			return Event{}, errors.Wrap(err, "error encoding entry")
+		}
+	}
+	return Event{
+		ID:       fmt.Sprintf("change-%d-%d", header.sequence, index),
+		Type:     EventTypeChange,
+		Ledger:   header.sequence,
+		ClosedAt: header.closedAt,
+		Change:   changeEvent,
+	}, nil
+}
+
+// entryTypeName returns the snake case name of a ledger entry type, e.g.
+// "claimable_balance" for LedgerEntryTypeClaimableBalance.
+func entryTypeName(t xdr.LedgerEntryType) string {
+	return snakeCase(strings.TrimPrefix(t.String(), "LedgerEntryType"))
+}
+
+// resultCode returns the snake case name of a transaction result code, e.g.
+// "tx_bad_seq" for TransactionResultCodeTxBadSeq.
+func resultCode(code xdr.TransactionResultCode) string {
+	return snakeCase(strings.TrimPrefix(code.String(), "TransactionResultCode"))
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
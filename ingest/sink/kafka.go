@@ -0,0 +1,309 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"strconv"
+	"time"
+
+	"github.com/metriqorg/go/support/errors"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+)
+
+// kafkaLedgerHeader is the record header holding the ledger of the event.
+const kafkaLedgerHeader = "ledger"
+
+// KafkaOutput publishes messages to a topic of a cluster speaking the Kafka
+// protocol, e.g. Kafka or Redpanda, with the idempotent, transactional
+// producer of franz-go.
+//
+// The messages of a ledger are published in one transaction, so consumers
+// reading committed records (isolation.level=read_committed) see all the
+// events of a ledger or none of them. Records are keyed by Message.Key and
+// spread over the partitions of the topic by key, so that the events of a
+// transaction or of a ledger entry stay in order. They carry "id", "type",
+// "ledger" and "content-type" headers.
+//
+// KafkaOutput is a LedgerOutput: the last ledger committed is read back from
+// the topic, so a ledger committed before the sink stopped is not published
+// again when it restarts.
+type KafkaOutput struct {
+	// Brokers are the "host:port" addresses of brokers the cluster is
+	// discovered from.
+	Brokers []string
+	Topic   string
+	// TransactionalID identifies the producer across restarts, so that a new
+	// producer aborts the transaction a previous one left open. Only one sink
+	// may publish to Topic with it. It is "sink-" followed by Topic if empty.
+	TransactionalID string
+	// ClientID identifies the producer to the brokers.
+	ClientID string
+	// TLS, if set, is used to connect to the brokers over TLS.
+	TLS *tls.Config
+	// Username and Password, if Username is set, authenticate the producer
+	// with SASL PLAIN.
+	Username string
+	Password string
+	// Timeout bounds publishing a ledger and reading back the last ledger,
+	// 30s if 0.
+	Timeout time.Duration
+
+	client *kgo.Client
+}
+
+func (o *KafkaOutput) timeout() time.Duration {
+	if o.Timeout == 0 {
+		return 30 * time.Second
+	}
+	return o.Timeout
+}
+
+func (o *KafkaOutput) transactionalID() string {
+	if o.TransactionalID == "" {
+		return "sink-" + o.Topic
+	}
+	return o.TransactionalID
+}
+
+// options returns the options connecting a client to the cluster.
+func (o *KafkaOutput) options() []kgo.Opt {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(o.Brokers...),
+		kgo.DialTimeout(o.timeout()),
+	}
+	if o.ClientID != "" {
+		opts = append(opts, kgo.ClientID(o.ClientID))
+	}
+	if o.TLS != nil {
+		opts = append(opts, kgo.DialTLSConfig(o.TLS.Clone()))
+	}
+	if o.Username != "" {
+		opts = append(opts, kgo.SASL(plain.Auth{User: o.Username, Pass: o.Password}.AsMechanism()))
+	}
+	return opts
+}
+
+// producer returns the transactional producer, creating it if needed.
+func (o *KafkaOutput) producer() (*kgo.Client, error) {
+	if o.client != nil {
+		return o.client, nil
+	}
+	client, err := kgo.NewClient(append(o.options(),
+		kgo.TransactionalID(o.transactionalID()),
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),
+	)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating Kafka client")
+	}
+	o.client = client
+	return client, nil
+}
+
+// Publish publishes messages, which are the events of a ledger, in one
+// transaction.
+func (o *KafkaOutput) Publish(ctx context.Context, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	client, err := o.producer()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeout())
+	defer cancel()
+
+	records := make([]*kgo.Record, len(messages))
+	for i, m := range messages {
+		records[i] = o.record(m)
+	}
+	err = client.BeginTransaction()
+	if err == nil {
+		err = client.ProduceSync(ctx, records...).FirstErr()
+	}
+	if err == nil {
+		err = client.EndTransaction(ctx, kgo.TryCommit)
+	}
+	if err != nil {
+		// The transaction is aborted by the coordinator when the next
+		// client initializes the same transactional ID.
+		o.Close()
+		return errors.Wrap(err, "error publishing to Kafka")
+	}
+	return nil
+}
+
+func (o *KafkaOutput) record(m Message) *kgo.Record {
+	return &kgo.Record{
+		Topic: o.Topic,
+		Key:   []byte(m.Key),
+		Value: m.Value,
+		Headers: []kgo.RecordHeader{
+			{Key: "id", Value: []byte(m.ID)},
+			{Key: "type", Value: []byte(m.Type)},
+			{Key: kafkaLedgerHeader, Value: []byte(strconv.FormatUint(uint64(m.Ledger), 10))},
+			{Key: "content-type", Value: []byte(m.ContentType)},
+		},
+	}
+}
+
+// LastLedger returns the ledger of the last committed record of the topic,
+// or 0 if the topic has no committed records. Since ledgers are committed in
+// order, in one transaction each, every ledger up to it was committed.
+func (o *KafkaOutput) LastLedger(ctx context.Context) (uint32, error) {
+	client, err := o.producer()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeout())
+	defer cancel()
+
+	ends, err := o.lastStableOffsets(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+	var last uint32
+	for partition, end := range ends {
+		ledger, err := o.lastLedgerInPartition(ctx, partition, end)
+		if err != nil {
+			return 0, errors.Wrapf(err, "error reading partition %d of Kafka topic %s", partition, o.Topic)
+		}
+		if ledger > last {
+			last = ledger
+		}
+	}
+	return last, nil
+}
+
+// lastStableOffsets returns the offset following the last committed record
+// of each partition of the topic.
+func (o *KafkaOutput) lastStableOffsets(ctx context.Context, client *kgo.Client) (map[int32]int64, error) {
+	metadataRequest := kmsg.NewPtrMetadataRequest()
+	metadataTopic := kmsg.NewMetadataRequestTopic()
+	metadataTopic.Topic = kmsg.StringPtr(o.Topic)
+	metadataRequest.Topics = append(metadataRequest.Topics, metadataTopic)
+	metadata, err := metadataRequest.RequestWith(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting Kafka topic metadata")
+	}
+	if len(metadata.Topics) != 1 {
+		return nil, errors.Errorf("no metadata for Kafka topic %s", o.Topic)
+	}
+	if err = kerr.ErrorForCode(metadata.Topics[0].ErrorCode); err != nil {
+		return nil, errors.Wrapf(err, "error getting metadata of Kafka topic %s", o.Topic)
+	}
+
+	offsetsRequest := kmsg.NewPtrListOffsetsRequest()
+	offsetsRequest.IsolationLevel = 1 // read committed
+	offsetsTopic := kmsg.NewListOffsetsRequestTopic()
+	offsetsTopic.Topic = o.Topic
+	for _, p := range metadata.Topics[0].Partitions {
+		partition := kmsg.NewListOffsetsRequestTopicPartition()
+		partition.Partition = p.Partition
+		partition.Timestamp = -1 // latest
+		offsetsTopic.Partitions = append(offsetsTopic.Partitions, partition)
+	}
+	offsetsRequest.Topics = append(offsetsRequest.Topics, offsetsTopic)
+	offsets, err := offsetsRequest.RequestWith(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing Kafka offsets")
+	}
+
+	ends := map[int32]int64{}
+	for _, t := range offsets.Topics {
+		for _, p := range t.Partitions {
+			if err = kerr.ErrorForCode(p.ErrorCode); err != nil {
+				return nil, errors.Wrapf(err, "error listing offsets of partition %d of Kafka topic %s", p.Partition, o.Topic)
+			}
+			ends[p.Partition] = p.Offset
+		}
+	}
+	return ends, nil
+}
+
+// lastLedgerInPartition returns the ledger of the last committed record of
+// the partition before end, the last stable offset. The last transaction of
+// the partition may have been aborted, so records are read back from end
+// over a window that doubles until a committed record is found.
+func (o *KafkaOutput) lastLedgerInPartition(ctx context.Context, partition int32, end int64) (uint32, error) {
+	for window := int64(2); end > 0; window *= 2 {
+		start := end - window
+		if start < 0 {
+			start = 0
+		}
+		ledger, found, err := o.readLastLedger(ctx, partition, start, end)
+		if err != nil || found || start == 0 {
+			return ledger, err
+		}
+	}
+	return 0, nil
+}
+
+// readLastLedger reads the committed records of the partition from start to
+// end and returns the highest ledger among them.
+func (o *KafkaOutput) readLastLedger(ctx context.Context, partition int32, start, end int64) (uint32, bool, error) {
+	consumer, err := kgo.NewClient(append(o.options(),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{
+			o.Topic: {partition: kgo.NewOffset().At(start)},
+		}),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		// Transaction markers are kept to know when end is reached, since
+		// the record before the last stable offset is usually one.
+		kgo.KeepControlRecords(),
+	)...)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "error creating Kafka client")
+	}
+	defer consumer.Close()
+
+	var ledger uint32
+	var found bool
+	for {
+		fetches := consumer.PollFetches(ctx)
+		if err = fetches.Err(); err != nil {
+			return 0, false, err
+		}
+		for it := fetches.RecordIter(); !it.Done(); {
+			r := it.Next()
+			if r.Offset >= end {
+				return ledger, found, nil
+			}
+			if !r.Attrs.IsControl() {
+				l, err := recordLedger(r)
+				if err != nil {
+					return 0, false, err
+				}
+				found = true
+				if l > ledger {
+					ledger = l
+				}
+			}
+			if r.Offset == end-1 {
+				return ledger, found, nil
+			}
+		}
+	}
+}
+
+func recordLedger(r *kgo.Record) (uint32, error) {
+	for _, h := range r.Headers {
+		if h.Key == kafkaLedgerHeader {
+			ledger, err := strconv.ParseUint(string(h.Value), 10, 32)
+			if err != nil {
+				return 0, errors.Wrapf(err, "invalid ledger header of record at offset %d", r.Offset)
+			}
+			return uint32(ledger), nil
+		}
+	}
+	return 0, errors.Errorf("record at offset %d has no ledger header", r.Offset)
+}
+
+func (o *KafkaOutput) Close() error {
+	if o.client != nil {
+		o.client.Close()
+		o.client = nil
+	}
+	return nil
+}
@@ -0,0 +1,652 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaVersions are the request versions the stand-in broker handles.
+var kafkaVersions = []kmsg.ApiVersionsResponseApiKey{
+	{ApiKey: 0, MinVersion: 3, MaxVersion: 7},  // Produce
+	{ApiKey: 1, MinVersion: 4, MaxVersion: 11}, // Fetch
+	{ApiKey: 2, MinVersion: 2, MaxVersion: 5},  // ListOffsets
+	{ApiKey: 3, MinVersion: 1, MaxVersion: 8},  // Metadata
+	{ApiKey: 10, MinVersion: 0, MaxVersion: 3}, // FindCoordinator
+	{ApiKey: 17, MinVersion: 1, MaxVersion: 1}, // SaslHandshake
+	{ApiKey: 18, MinVersion: 0, MaxVersion: 3}, // ApiVersions
+	{ApiKey: 22, MinVersion: 0, MaxVersion: 4}, // InitProducerID
+	{ApiKey: 24, MinVersion: 0, MaxVersion: 3}, // AddPartitionsToTxn
+	{ApiKey: 26, MinVersion: 0, MaxVersion: 3}, // EndTxn
+	{ApiKey: 36, MinVersion: 0, MaxVersion: 2}, // SaslAuthenticate
+}
+
+type kafkaBatch struct {
+	offset  int64
+	records int64
+	raw     []byte
+}
+
+type kafkaPartition struct {
+	batches []kafkaBatch
+	// end is the high watermark.
+	end     int64
+	aborted []kmsg.FetchResponseTopicPartitionAbortedTransaction
+}
+
+type kafkaTxn struct {
+	producerID int64
+	epoch      int16
+	// partitions maps the partitions of the open transaction to the offset
+	// of their first record in it, -1 if none yet.
+	partitions map[int32]int64
+}
+
+// kafkaBroker is an in-process stand-in for a single node Kafka cluster with
+// one topic. It implements the requests of transactional producers and of
+// consumers reading committed records. If username is set, requests are
+// only served once authenticated with SASL PLAIN.
+type kafkaBroker struct {
+	lock           sync.Mutex
+	addr           string
+	topic          string
+	username       string
+	password       string
+	partitions     []*kafkaPartition
+	txns           map[string]*kafkaTxn
+	nextProducerID int64
+}
+
+func listenKafka(t *testing.T, topic string, partitions int, username, password string) *kafkaBroker {
+	b := &kafkaBroker{topic: topic, username: username, password: password, txns: map[string]*kafkaTxn{}}
+	for i := 0; i < partitions; i++ {
+		b.partitions = append(b.partitions, &kafkaPartition{})
+	}
+	b.addr = listen(t, b.handle)
+	return b
+}
+
+func (b *kafkaBroker) handle(conn net.Conn) {
+	authenticated := b.username == ""
+	for {
+		var size int32
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		reader := kbin.Reader{Src: body}
+		key, version, correlationID := reader.Int16(), reader.Int16(), reader.Int32()
+		reader.NullableString() // client id
+		request := kmsg.RequestForKey(key)
+		if request == nil {
+			return
+		}
+		request.SetVersion(version)
+		if request.IsFlexible() {
+			kmsg.SkipTags(&reader)
+		}
+		if err := request.ReadFrom(reader.Src); err != nil {
+			return
+		}
+
+		var response kmsg.Response
+		switch r := request.(type) {
+		case *kmsg.ApiVersionsRequest:
+			response = b.apiVersions(r)
+		case *kmsg.SASLHandshakeRequest:
+			response = b.saslHandshake(r)
+		case *kmsg.SASLAuthenticateRequest:
+			var resp *kmsg.SASLAuthenticateResponse
+			resp, authenticated = b.saslAuthenticate(r)
+			response = resp
+		default:
+			if !authenticated {
+				return
+			}
+			response = b.serve(request)
+		}
+		if response == nil {
+			return
+		}
+
+		out := make([]byte, 8, 9)
+		binary.BigEndian.PutUint32(out[4:], uint32(correlationID))
+		if response.IsFlexible() && key != 18 {
+			out = append(out, 0)
+		}
+		out = response.AppendTo(out)
+		binary.BigEndian.PutUint32(out, uint32(len(out)-4))
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+func (b *kafkaBroker) apiVersions(r *kmsg.ApiVersionsRequest) kmsg.Response {
+	resp := r.ResponseKind().(*kmsg.ApiVersionsResponse)
+	if r.Version > 3 {
+		resp.Version = 0
+		resp.ErrorCode = 35 // unsupported version
+	}
+	resp.ApiKeys = kafkaVersions
+	return resp
+}
+
+func (b *kafkaBroker) saslHandshake(r *kmsg.SASLHandshakeRequest) kmsg.Response {
+	resp := r.ResponseKind().(*kmsg.SASLHandshakeResponse)
+	resp.SupportedMechanisms = []string{"PLAIN"}
+	if r.Mechanism != "PLAIN" {
+		resp.ErrorCode = 33 // unsupported SASL mechanism
+	}
+	return resp
+}
+
+func (b *kafkaBroker) saslAuthenticate(r *kmsg.SASLAuthenticateRequest) (*kmsg.SASLAuthenticateResponse, bool) {
+	resp := r.ResponseKind().(*kmsg.SASLAuthenticateResponse)
+	if string(r.SASLAuthBytes) != "\x00"+b.username+"\x00"+b.password {
+		resp.ErrorCode = 58 // SASL authentication failed
+		message := "invalid credentials"
+		resp.ErrorMessage = &message
+		return resp, false
+	}
+	return resp, true
+}
+
+func (b *kafkaBroker) serve(request kmsg.Request) kmsg.Response {
+	if fetch, ok := request.(*kmsg.FetchRequest); ok {
+		return b.fetch(fetch)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	host, port, _ := net.SplitHostPort(b.addr)
+	portNumber, _ := strconv.Atoi(port)
+
+	switch r := request.(type) {
+	case *kmsg.MetadataRequest:
+		resp := r.ResponseKind().(*kmsg.MetadataResponse)
+		broker := kmsg.NewMetadataResponseBroker()
+		broker.Host, broker.Port = host, int32(portNumber)
+		resp.Brokers = append(resp.Brokers, broker)
+		topics := []string{b.topic}
+		if r.Topics != nil {
+			topics = nil
+			for _, t := range r.Topics {
+				topics = append(topics, *t.Topic)
+			}
+		}
+		for _, name := range topics {
+			topic := kmsg.NewMetadataResponseTopic()
+			topic.Topic = kmsg.StringPtr(name)
+			if name != b.topic {
+				topic.ErrorCode = 3 // unknown topic or partition
+			}
+			for i := range b.partitions {
+				if name != b.topic {
+					break
+				}
+				partition := kmsg.NewMetadataResponseTopicPartition()
+				partition.Partition = int32(i)
+				partition.Replicas = []int32{0}
+				partition.ISR = []int32{0}
+				topic.Partitions = append(topic.Partitions, partition)
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp
+
+	case *kmsg.FindCoordinatorRequest:
+		resp := r.ResponseKind().(*kmsg.FindCoordinatorResponse)
+		resp.Host, resp.Port = host, int32(portNumber)
+		return resp
+
+	case *kmsg.InitProducerIDRequest:
+		resp := r.ResponseKind().(*kmsg.InitProducerIDResponse)
+		txn := b.txns[*r.TransactionalID]
+		if txn == nil {
+			b.nextProducerID++
+			txn = &kafkaTxn{producerID: b.nextProducerID}
+			b.txns[*r.TransactionalID] = txn
+		} else {
+			// A new producer aborts the transaction left open by the
+			// previous one.
+			b.endTxn(txn, false)
+			txn.epoch++
+		}
+		txn.partitions = map[int32]int64{}
+		resp.ProducerID, resp.ProducerEpoch = txn.producerID, txn.epoch
+		return resp
+
+	case *kmsg.AddPartitionsToTxnRequest:
+		resp := r.ResponseKind().(*kmsg.AddPartitionsToTxnResponse)
+		txn := b.txns[r.TransactionalID]
+		for _, t := range r.Topics {
+			topic := kmsg.NewAddPartitionsToTxnResponseTopic()
+			topic.Topic = t.Topic
+			for _, p := range t.Partitions {
+				partition := kmsg.NewAddPartitionsToTxnResponseTopicPartition()
+				partition.Partition = p
+				if txn == nil || txn.producerID != r.ProducerID || txn.epoch != r.ProducerEpoch {
+					partition.ErrorCode = 47 // invalid producer epoch
+				} else if _, ok := txn.partitions[p]; !ok {
+					txn.partitions[p] = -1
+				}
+				topic.Partitions = append(topic.Partitions, partition)
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp
+
+	case *kmsg.ProduceRequest:
+		resp := r.ResponseKind().(*kmsg.ProduceResponse)
+		var txn *kafkaTxn
+		if r.TransactionID != nil {
+			txn = b.txns[*r.TransactionID]
+		}
+		for _, t := range r.Topics {
+			topic := kmsg.NewProduceResponseTopic()
+			topic.Topic = t.Topic
+			for _, p := range t.Partitions {
+				partition := kmsg.NewProduceResponseTopicPartition()
+				partition.Partition = p.Partition
+				partition.ErrorCode = b.produce(txn, t.Topic, p.Partition, p.Records, &partition.BaseOffset)
+				topic.Partitions = append(topic.Partitions, partition)
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp
+
+	case *kmsg.EndTxnRequest:
+		resp := r.ResponseKind().(*kmsg.EndTxnResponse)
+		txn := b.txns[r.TransactionalID]
+		if txn == nil || txn.producerID != r.ProducerID || txn.epoch != r.ProducerEpoch {
+			resp.ErrorCode = 47 // invalid producer epoch
+			return resp
+		}
+		b.endTxn(txn, r.Commit)
+		return resp
+
+	case *kmsg.ListOffsetsRequest:
+		resp := r.ResponseKind().(*kmsg.ListOffsetsResponse)
+		for _, t := range r.Topics {
+			topic := kmsg.NewListOffsetsResponseTopic()
+			topic.Topic = t.Topic
+			for _, p := range t.Partitions {
+				partition := kmsg.NewListOffsetsResponseTopicPartition()
+				partition.Partition = p.Partition
+				if t.Topic != b.topic || int(p.Partition) >= len(b.partitions) {
+					partition.ErrorCode = 3 // unknown topic or partition
+				} else if p.Timestamp == -1 && r.IsolationLevel == 1 {
+					partition.Offset = b.lastStableOffset(p.Partition)
+				} else if p.Timestamp == -1 {
+					partition.Offset = b.partitions[p.Partition].end
+				}
+				topic.Partitions = append(topic.Partitions, partition)
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp
+	}
+	return nil
+}
+
+// produce appends a record batch to a partition and returns an error code.
+func (b *kafkaBroker) produce(txn *kafkaTxn, topic string, partition int32, raw []byte, baseOffset *int64) int16 {
+	if topic != b.topic || int(partition) >= len(b.partitions) {
+		return 3 // unknown topic or partition
+	}
+	var batch kmsg.RecordBatch
+	if err := batch.ReadFrom(raw); err != nil {
+		return 2 // corrupt message
+	}
+	p := b.partitions[partition]
+	if batch.Attributes&0x10 != 0 {
+		if txn == nil || txn.producerID != batch.ProducerID {
+			return 47 // invalid producer epoch
+		}
+		first, ok := txn.partitions[partition]
+		if !ok {
+			return 48 // invalid transaction state
+		}
+		if first < 0 {
+			txn.partitions[partition] = p.end
+		}
+	}
+	raw = append([]byte(nil), raw...)
+	binary.BigEndian.PutUint64(raw, uint64(p.end))
+	p.batches = append(p.batches, kafkaBatch{offset: p.end, records: int64(batch.LastOffsetDelta) + 1, raw: raw})
+	*baseOffset = p.end
+	p.end += int64(batch.LastOffsetDelta) + 1
+	return 0
+}
+
+// endTxn writes the commit or abort markers of the open transaction.
+func (b *kafkaBroker) endTxn(txn *kafkaTxn, commit bool) {
+	for partition, first := range txn.partitions {
+		p := b.partitions[partition]
+		if !commit && first >= 0 {
+			aborted := kmsg.NewFetchResponseTopicPartitionAbortedTransaction()
+			aborted.ProducerID, aborted.FirstOffset = txn.producerID, first
+			p.aborted = append(p.aborted, aborted)
+		}
+		p.batches = append(p.batches, kafkaBatch{offset: p.end, records: 1, raw: controlBatch(p.end, txn, commit)})
+		p.end++
+	}
+	txn.partitions = map[int32]int64{}
+}
+
+// controlBatch returns a record batch holding the marker ending a
+// transaction.
+func controlBatch(offset int64, txn *kafkaTxn, commit bool) []byte {
+	key := []byte{0, 0, 0, 0}
+	if commit {
+		key[3] = 1
+	}
+	marker := kmsg.EndTxnMarker{}
+	record := kmsg.Record{Key: key, Value: marker.AppendTo(nil)}
+	record.Length = int32(len(record.AppendTo(nil)) - 1)
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	batch := kmsg.RecordBatch{
+		FirstOffset:    offset,
+		Magic:          2,
+		Attributes:     0x30, // transactional control batch
+		FirstTimestamp: now,
+		MaxTimestamp:   now,
+		ProducerID:     txn.producerID,
+		ProducerEpoch:  txn.epoch,
+		FirstSequence:  -1,
+		NumRecords:     1,
+		Records:        record.AppendTo(nil),
+	}
+	raw := batch.AppendTo(nil)
+	binary.BigEndian.PutUint32(raw[8:], uint32(len(raw)-12))
+	binary.BigEndian.PutUint32(raw[17:], crc32.Checksum(raw[21:], castagnoli))
+	return raw
+}
+
+// lastStableOffset returns the first offset of the oldest open transaction
+// of the partition, or its end.
+func (b *kafkaBroker) lastStableOffset(partition int32) int64 {
+	offset := b.partitions[partition].end
+	for _, txn := range b.txns {
+		if first, ok := txn.partitions[partition]; ok && first >= 0 && first < offset {
+			offset = first
+		}
+	}
+	return offset
+}
+
+func (b *kafkaBroker) fetch(r *kmsg.FetchRequest) kmsg.Response {
+	resp := r.ResponseKind().(*kmsg.FetchResponse)
+	empty := true
+	b.lock.Lock()
+	for _, t := range r.Topics {
+		topic := kmsg.NewFetchResponseTopic()
+		topic.Topic = t.Topic
+		for _, rp := range t.Partitions {
+			partition := kmsg.NewFetchResponseTopicPartition()
+			partition.Partition = rp.Partition
+			if t.Topic != b.topic || int(rp.Partition) >= len(b.partitions) {
+				partition.ErrorCode = 3 // unknown topic or partition
+				topic.Partitions = append(topic.Partitions, partition)
+				continue
+			}
+			p := b.partitions[rp.Partition]
+			partition.HighWatermark = p.end
+			partition.LastStableOffset = b.lastStableOffset(rp.Partition)
+			limit := p.end
+			if r.IsolationLevel == 1 {
+				limit = partition.LastStableOffset
+				partition.AbortedTransactions = p.aborted
+			}
+			if rp.FetchOffset > p.end {
+				partition.ErrorCode = 1 // offset out of range
+			}
+			for _, batch := range p.batches {
+				if batch.offset+batch.records > rp.FetchOffset && batch.offset < limit {
+					partition.RecordBatches = append(partition.RecordBatches, batch.raw...)
+					empty = false
+				}
+			}
+			topic.Partitions = append(topic.Partitions, partition)
+		}
+		resp.Topics = append(resp.Topics, topic)
+	}
+	b.lock.Unlock()
+	if empty {
+		// Wait a little for records like a broker would.
+		time.Sleep(10 * time.Millisecond)
+	}
+	return resp
+}
+
+// records returns the records of the committed transactions.
+func (b *kafkaBroker) records(t *testing.T) []*kgo.Record {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	offsets := map[int32]kgo.Offset{}
+	ends := map[int32]int64{}
+	b.lock.Lock()
+	for i := range b.partitions {
+		offsets[int32(i)] = kgo.NewOffset().AtStart()
+		ends[int32(i)] = b.lastStableOffset(int32(i))
+	}
+	b.lock.Unlock()
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(b.addr),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{b.topic: offsets}),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.KeepControlRecords(),
+	}
+	if b.username != "" {
+		opts = append(opts, kgo.SASL(plain.Auth{User: b.username, Pass: b.password}.AsMechanism()))
+	}
+	consumer, err := kgo.NewClient(opts...)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	var records []*kgo.Record
+	for done := 0; done < len(ends); {
+		fetches := consumer.PollFetches(ctx)
+		require.NoError(t, fetches.Err())
+		fetches.EachRecord(func(r *kgo.Record) {
+			if !r.Attrs.IsControl() {
+				records = append(records, r)
+			}
+			if r.Offset == ends[r.Partition]-1 {
+				done++
+			}
+		})
+		for p, end := range ends {
+			if end == 0 {
+				done++
+				delete(ends, p)
+			}
+		}
+	}
+	return records
+}
+
+func recordHeaders(r *kgo.Record) map[string]string {
+	headers := map[string]string{}
+	for _, h := range r.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return headers
+}
+
+// ledgerMessages returns messages of ledger with keys key-0 to key-(n-1).
+func ledgerMessages(ledger uint32, n int) []Message {
+	var messages []Message
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("tx-%d-%d", ledger, i)
+		messages = append(messages, Message{
+			ID:          id,
+			Key:         fmt.Sprintf("key-%d", i),
+			Type:        EventTypeTransaction,
+			Ledger:      ledger,
+			ContentType: "application/json",
+			Value:       []byte(`{"id":"` + id + `"}`),
+		})
+	}
+	return messages
+}
+
+func TestKafkaOutput(t *testing.T) {
+	broker := listenKafka(t, "events", 3, "", "")
+	output := &KafkaOutput{Brokers: []string{broker.addr}, Topic: "events", ClientID: "test", Timeout: 10 * time.Second}
+	defer output.Close()
+	ctx := context.Background()
+
+	ledger, err := output.LastLedger(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), ledger)
+
+	require.NoError(t, output.Publish(ctx, ledgerMessages(5, 20)))
+	require.NoError(t, output.Publish(ctx, ledgerMessages(6, 20)))
+	ledger, err = output.LastLedger(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(6), ledger)
+
+	records := broker.records(t)
+	require.Len(t, records, 40)
+	partitions := map[string]int32{}
+	ids := map[string]bool{}
+	for _, r := range records {
+		headers := recordHeaders(r)
+		assert.Equal(t, `{"id":"`+headers["id"]+`"}`, string(r.Value))
+		assert.Equal(t, "transaction", headers["type"])
+		assert.Equal(t, "application/json", headers["content-type"])
+		assert.True(t, strings.HasPrefix(headers["id"], "tx-"+headers["ledger"]+"-"))
+		ids[headers["id"]] = true
+
+		// Records with the same key are in the same partition.
+		if p, ok := partitions[string(r.Key)]; ok {
+			assert.Equal(t, p, r.Partition)
+		}
+		partitions[string(r.Key)] = r.Partition
+	}
+	assert.Len(t, ids, 40)
+	used := map[int32]bool{}
+	for _, p := range partitions {
+		used[p] = true
+	}
+	assert.Len(t, used, 3)
+}
+
+func TestKafkaOutputLastLedgerIgnoresAbortedTransactions(t *testing.T) {
+	broker := listenKafka(t, "events", 2, "", "")
+	ctx := context.Background()
+	output := &KafkaOutput{Brokers: []string{broker.addr}, Topic: "events", Timeout: 10 * time.Second}
+	require.NoError(t, output.Publish(ctx, ledgerMessages(5, 4)))
+	require.NoError(t, output.Close())
+
+	// A producer aborts ledger 6 and a producer with the transactional ID of
+	// the output stops in the middle of publishing ledger 7, as if the sink
+	// crashed.
+	produce := func(transactionalID string, messages []Message) *kgo.Client {
+		client, err := kgo.NewClient(kgo.SeedBrokers(broker.addr), kgo.TransactionalID(transactionalID))
+		require.NoError(t, err)
+		require.NoError(t, client.BeginTransaction())
+		for _, m := range messages {
+			require.NoError(t, client.ProduceSync(ctx, output.record(m)).FirstErr())
+		}
+		return client
+	}
+	aborted := produce("other", ledgerMessages(6, 4))
+	require.NoError(t, aborted.EndTransaction(ctx, kgo.TryAbort))
+	aborted.Close()
+	crashed := produce("sink-events", ledgerMessages(7, 4))
+	defer crashed.Close()
+
+	output = &KafkaOutput{Brokers: []string{broker.addr}, Topic: "events", Timeout: 10 * time.Second}
+	defer output.Close()
+	ledger, err := output.LastLedger(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), ledger)
+
+	// Publishing ledger 6 aborts the open transaction.
+	require.NoError(t, output.Publish(ctx, ledgerMessages(6, 4)))
+	ledger, err = output.LastLedger(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(6), ledger)
+	ledgers := map[string]int{}
+	for _, r := range broker.records(t) {
+		ledgers[recordHeaders(r)["ledger"]]++
+	}
+	assert.Equal(t, map[string]int{"5": 4, "6": 4}, ledgers)
+}
+
+func TestKafkaOutputSASL(t *testing.T) {
+	broker := listenKafka(t, "events", 1, "sink", "s3cr3t")
+	output := &KafkaOutput{Brokers: []string{broker.addr}, Topic: "events", Username: "sink", Password: "s3cr3t", Timeout: 10 * time.Second}
+	defer output.Close()
+
+	require.NoError(t, output.Publish(context.Background(), ledgerMessages(5, 2)))
+	assert.Len(t, broker.records(t), 2)
+
+	output.Close()
+	output.Password = "wrong"
+	output.Timeout = time.Second
+	err := output.Publish(context.Background(), ledgerMessages(6, 2))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error publishing to Kafka")
+	assert.Len(t, broker.records(t), 2)
+}
+
+// TestKafkaOutputIntegration publishes to the brokers at SINK_KAFKA_BROKER,
+// e.g. "localhost:9092", and is skipped if it is not set. The topic,
+// "sink-integration-test" by default, must exist.
+func TestKafkaOutputIntegration(t *testing.T) {
+	brokers := os.Getenv("SINK_KAFKA_BROKER")
+	if brokers == "" {
+		t.SkipNow()
+		return
+	}
+	topic := os.Getenv("SINK_KAFKA_TOPIC")
+	if topic == "" {
+		topic = "sink-integration-test"
+	}
+
+	output := &KafkaOutput{
+		Brokers:  strings.Split(brokers, ","),
+		Topic:    topic,
+		ClientID: "sink-integration-test",
+		Username: os.Getenv("SINK_KAFKA_USERNAME"),
+		Password: os.Getenv("SINK_KAFKA_PASSWORD"),
+	}
+	if os.Getenv("SINK_KAFKA_TLS") != "" {
+		output.TLS = &tls.Config{}
+	}
+	defer output.Close()
+
+	ctx := context.Background()
+	last, err := output.LastLedger(ctx)
+	require.NoError(t, err)
+	require.NoError(t, output.Publish(ctx, ledgerMessages(last+1, 10)))
+	ledger, err := output.LastLedger(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last+1, ledger)
+}
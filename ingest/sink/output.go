@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Message is an encoded event handed to outputs.
+type Message struct {
+	// ID is the event ID, which outputs pass to their broker or endpoint as
+	// a deduplication key.
+	ID string
+	// Key groups the events which must stay in order, for outputs which
+	// partition by key: the transaction hash for the events of a
+	// transaction and the ledger key for changes.
+	Key         string
+	Type        EventType
+	Ledger      uint32
+	ContentType string
+	Value       []byte
+}
+
+// Output publishes messages to a broker or endpoint.
+type Output interface {
+	// Publish publishes the messages of a ledger and returns once all were
+	// acknowledged, in order. When it fails, some of the messages may have
+	// been published and the ledger is published again later.
+	Publish(ctx context.Context, messages []Message) error
+	Close() error
+}
+
+// LedgerOutput is an Output which publishes the messages of a ledger
+// atomically and keeps track of the ledgers it published, so that the sink
+// publishes each ledger to it exactly once, even when the sink stopped after
+// publishing a ledger but before saving its cursor.
+type LedgerOutput interface {
+	Output
+	// LastLedger returns the last ledger published, 0 if none.
+	LastLedger(ctx context.Context) (uint32, error)
+}
+
+// WriterOutput writes messages to a writer, e.g. os.Stdout. JSON messages
+// are written one per line and protobuf messages are prefixed with their
+// varint encoded length.
+type WriterOutput struct {
+	Writer io.Writer
+}
+
+func (o WriterOutput) Publish(ctx context.Context, messages []Message) error {
+	w := bufio.NewWriter(o.Writer)
+	for _, m := range messages {
+		if m.ContentType == (ProtobufEncoder{}).ContentType() {
+			if _, err := w.Write(protowire.AppendVarint(nil, uint64(len(m.Value)))); err != nil {
+				return err
+			}
+			if _, err := w.Write(m.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := w.Write(m.Value); err != nil {
+			return err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (o WriterOutput) Close() error {
+	return nil
+}
+
+// retry calls f up to attempts times, doubling the wait between attempts
+// from backoff, until it succeeds, returns a permanent error or ctx is done.
+func retry(ctx context.Context, attempts int, backoff time.Duration, f func() error) error {
+	var err error
+	for i := 0; ; i++ {
+		if err = f(); err == nil {
+			return nil
+		}
+		if _, ok := err.(permanentError); ok || i+1 >= attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// permanentError is an error which retrying won't fix.
+type permanentError struct {
+	error
+}
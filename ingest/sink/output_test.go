@@ -0,0 +1,311 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func testMessages() []Message {
+	return []Message{
+		{ID: "tx-1", Type: EventTypeTransaction, Ledger: 5, ContentType: "application/json", Value: []byte(`{"id":"tx-1"}`)},
+		{ID: "op-2", Type: EventTypeOperation, Ledger: 5, ContentType: "application/json", Value: []byte(`{"id":"op-2"}`)},
+	}
+}
+
+// listen starts a TCP server handling each connection with handle.
+func listen(t *testing.T, handle func(net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+type natsMessage struct {
+	subject string
+	headers string
+	payload string
+}
+
+// natsServer is an in-process stand-in for a NATS server.
+type natsServer struct {
+	lock     sync.Mutex
+	connects []string
+	messages []natsMessage
+}
+
+func (s *natsServer) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "INFO {\"headers\":true}\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "CONNECT":
+			s.lock.Lock()
+			s.connects = append(s.connects, fields[1])
+			s.lock.Unlock()
+		case "HPUB":
+			headerLen, _ := strconv.Atoi(fields[2])
+			totalLen, _ := strconv.Atoi(fields[3])
+			buf := make([]byte, totalLen+2)
+			if _, err = io.ReadFull(r, buf); err != nil {
+				return
+			}
+			s.lock.Lock()
+			s.messages = append(s.messages, natsMessage{
+				subject: fields[1],
+				headers: string(buf[:headerLen]),
+				payload: string(buf[headerLen:totalLen]),
+			})
+			s.lock.Unlock()
+		case "PING":
+			// Check that server pings are answered while waiting for a PONG.
+			fmt.Fprint(conn, "PING\r\n")
+			if line, err = r.ReadString('\n'); err != nil || line != "PONG\r\n" {
+				return
+			}
+			fmt.Fprint(conn, "PONG\r\n")
+		}
+	}
+}
+
+func TestNATSOutput(t *testing.T) {
+	server := &natsServer{}
+	output := &NATSOutput{URL: "nats://alice:secret@" + listen(t, server.handle), Subject: "ledger"}
+	defer output.Close()
+
+	require.NoError(t, output.Publish(context.Background(), testMessages()))
+	assert.Equal(t, []natsMessage{
+		{
+			subject: "ledger.transaction",
+			headers: "NATS/1.0\r\nNats-Msg-Id: tx-1\r\nContent-Type: application/json\r\n\r\n",
+			payload: `{"id":"tx-1"}`,
+		},
+		{
+			subject: "ledger.operation",
+			headers: "NATS/1.0\r\nNats-Msg-Id: op-2\r\nContent-Type: application/json\r\n\r\n",
+			payload: `{"id":"op-2"}`,
+		},
+	}, server.messages)
+	require.Len(t, server.connects, 1)
+	assert.Contains(t, server.connects[0], `"user":"alice","pass":"secret"`)
+	assert.Contains(t, server.connects[0], `"headers":true`)
+
+	// Server errors fail the batch and the output reconnects.
+	errorServer := func(conn net.Conn) {
+		fmt.Fprint(conn, "INFO {}\r\n")
+		bufio.NewReader(conn).ReadString('\n')
+		fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+	}
+	output = &NATSOutput{URL: "nats://" + listen(t, errorServer), Subject: "ledger", Attempts: 1}
+	err := output.Publish(context.Background(), testMessages())
+	assert.EqualError(t, err, "NATS error: 'Authorization Violation'")
+	assert.Nil(t, output.conn)
+}
+
+func TestWebhookOutput(t *testing.T) {
+	var lock sync.Mutex
+	var requests []*http.Request
+	var bodies []string
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(IdempotencyKeyHeader) == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	now := time.Unix(1700000000, 0)
+	output := &WebhookOutput{URL: server.URL, Secret: "s3cr3t", Backoff: time.Millisecond, now: func() time.Time { return now }}
+	require.NoError(t, output.Publish(context.Background(), testMessages()))
+	require.Len(t, requests, 2)
+	assert.Equal(t, []string{`{"id":"tx-1"}`, `{"id":"op-2"}`}, bodies)
+	assert.Equal(t, "tx-1", requests[0].Header.Get(IdempotencyKeyHeader))
+	assert.Equal(t, "transaction", requests[0].Header.Get(EventTypeHeader))
+	assert.Equal(t, "5", requests[0].Header.Get(LedgerHeader))
+	assert.Equal(t, "application/json", requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, SignWebhook("s3cr3t", now, []byte(`{"id":"tx-1"}`)), requests[0].Header.Get(SignatureHeader))
+	assert.True(t, strings.HasPrefix(requests[0].Header.Get(SignatureHeader), "t=1700000000,v1="))
+
+	// Client errors are not retried.
+	err := output.Publish(context.Background(), []Message{{ID: "bad"}})
+	assert.EqualError(t, err, "error posting event bad: unexpected status code 400")
+}
+
+func TestWriterOutput(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriterOutput{Writer: &out}.Publish(context.Background(), testMessages()))
+	assert.Equal(t, "{\"id\":\"tx-1\"}\n{\"id\":\"op-2\"}\n", out.String())
+
+	out.Reset()
+	messages := []Message{{ContentType: "application/x-protobuf", Value: []byte{1, 2, 3}}}
+	require.NoError(t, WriterOutput{Writer: &out}.Publish(context.Background(), messages))
+	assert.Equal(t, []byte{3, 1, 2, 3}, out.Bytes())
+}
+
+// protoFields decodes the top level fields of a protobuf message.
+func protoFields(t *testing.T, b []byte) map[protowire.Number]interface{} {
+	fields := map[protowire.Number]interface{}{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			require.True(t, n > 0)
+			fields[num], b = v, b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			require.True(t, n > 0)
+			fields[num], b = v, b[n:]
+		default:
+			t.Fatalf("unexpected wire type %v", typ)
+		}
+	}
+	return fields
+}
+
+func TestProtobufEncoder(t *testing.T) {
+	encoded, err := ProtobufEncoder{}.Encode(Event{
+		ID:              "effect-1-1",
+		Type:            EventTypeEffect,
+		Ledger:          7,
+		ClosedAt:        1700000000,
+		TransactionHash: "ab",
+		Effect: &EffectEvent{
+			OperationIndex: 2,
+			Order:          1,
+			Type:           "account_credited",
+			Details:        map[string]interface{}{"amount": "1.0000000"},
+		},
+	})
+	require.NoError(t, err)
+	fields := protoFields(t, encoded)
+	assert.Equal(t, []byte("effect-1-1"), fields[1])
+	assert.Equal(t, []byte("effect"), fields[2])
+	assert.Equal(t, uint64(7), fields[3])
+	assert.Equal(t, uint64(1700000000), fields[4])
+	assert.Equal(t, []byte("ab"), fields[5])
+	require.Contains(t, fields, protowire.Number(12))
+
+	effect := protoFields(t, fields[12].([]byte))
+	assert.NotContains(t, effect, protowire.Number(1))
+	assert.Equal(t, uint64(2), effect[2])
+	assert.Equal(t, uint64(1), effect[3])
+	assert.Equal(t, []byte("account_credited"), effect[4])
+	assert.Equal(t, []byte(`{"amount":"1.0000000"}`), effect[6])
+}
+
+func TestReadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sink.toml")
+	write := func(content string) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+
+	write(`
+format = "protobuf"
+cursor-file = "cursor.json"
+events = ["transaction", "effect"]
+
+[[outputs]]
+type = "kafka"
+address = "localhost:9092,localhost:9093"
+topic = "events"
+transactional-id = "sink-1"
+timeout = "5s"
+tls = true
+username = "sink"
+password = "s3cr3t"
+
+[[outputs]]
+type = "nats"
+address = "nats://localhost:4222"
+subject = "ledger"
+
+[[outputs]]
+type = "webhook"
+url = "https://example.com/events"
+secret = "s3cr3t"
+
+[[outputs]]
+type = "stdout"
+`)
+	config, err := ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "protobuf", config.Format)
+	events, err := config.EventTypes()
+	require.NoError(t, err)
+	assert.Equal(t, []EventType{EventTypeTransaction, EventTypeEffect}, events)
+	outputs, err := config.NewOutputs()
+	require.NoError(t, err)
+	require.Len(t, outputs, 4)
+	assert.Equal(t, &KafkaOutput{Brokers: []string{"localhost:9092", "localhost:9093"}, Topic: "events", TransactionalID: "sink-1", TLS: &tls.Config{}, Username: "sink", Password: "s3cr3t", Timeout: 5 * time.Second}, outputs[0])
+	assert.IsType(t, &NATSOutput{}, outputs[1])
+	assert.IsType(t, &WebhookOutput{}, outputs[2])
+	assert.IsType(t, WriterOutput{}, outputs[3])
+
+	write(`
+cursor-file = "cursor.json"
+events = ["ledger"]
+
+[[outputs]]
+type = "stdout"
+`)
+	_, err = ReadConfig(path)
+	assert.EqualError(t, err, "unknown event type: ledger")
+
+	write(`
+cursor-file = "cursor.json"
+
+[[outputs]]
+type = "kafka"
+address = "localhost:9092"
+`)
+	_, err = ReadConfig(path)
+	assert.EqualError(t, err, "invalid output 0: kafka output requires an address and a topic")
+}
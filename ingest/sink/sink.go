@@ -0,0 +1,278 @@
+// Package sink publishes the transactions, operations, effects and ledger
+// entry changes of ledgers as events to message brokers and webhooks. Events
+// are encoded as JSON or protobuf (see event.proto) and handed to Outputs;
+// outputs for Kafka-protocol brokers, NATS, webhooks and writers such as
+// stdout are provided. A Sink can be configured from a TOML file, see
+// FileConfig.
+package sink
+
+import (
+	"context"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// Config configures a Sink.
+type Config struct {
+	// Backend provides the ledgers to publish.
+	Backend ledgerbackend.LedgerBackend
+	// Archive provides the latest checkpoint and, if IncludeState is set, its
+	// state.
+	Archive           historyarchive.ArchiveInterface
+	NetworkPassphrase string
+	// Cursor persists the last published ledger.
+	Cursor ingest.CursorStore
+	// StartLedger is the first ledger published when there is no cursor yet.
+	// If 0, publishing starts after the latest checkpoint.
+	StartLedger uint32
+	// IncludeState publishes every ledger entry of a checkpoint as a created
+	// change before publishing ledgers, when there is no cursor yet. The
+	// checkpoint is StartLedger, which must then be a checkpoint ledger, or
+	// the latest one. The state is published like a ledger, at once, so it is
+	// held in memory until it is.
+	IncludeState bool
+
+	Encoder Encoder
+	Outputs []Output
+	// Events are the types of events published, all if empty.
+	Events []EventType
+	// Effects computes effect events. No effect events are published if nil.
+	Effects EffectsFunc
+}
+
+// Sink publishes the events of ledgers to outputs.
+//
+// Ledgers are checkpointed by sequence: the events of a ledger are buffered
+// until the ledger is processed and handed to each output in one Publish
+// call, and the cursor is only moved past the ledger once all outputs
+// acknowledged it. A LedgerOutput, such as KafkaOutput, publishes a ledger
+// atomically and reports the last ledger it published, so each ledger is
+// published to it exactly once, even when the sink stops between publishing
+// a ledger and saving the cursor. Other outputs get such a ledger again, with
+// the same event IDs, which consumers can use to discard the duplicates.
+type Sink struct {
+	config  Config
+	events  map[EventType]bool
+	pending []Message
+	// published is the last ledger published to each output.
+	published []uint32
+	// header is the ledger whose events are being built.
+	header      ledgerHeader
+	changeIndex uint32
+}
+
+// New returns a Sink using config.
+func New(config Config) (*Sink, error) {
+	if config.Backend == nil {
+		return nil, errors.New("ledger backend is required")
+	}
+	if config.Archive == nil {
+		return nil, errors.New("history archive is required")
+	}
+	if config.Cursor == nil {
+		return nil, errors.New("cursor store is required")
+	}
+	if config.Encoder == nil {
+		return nil, errors.New("encoder is required")
+	}
+	if len(config.Outputs) == 0 {
+		return nil, errors.New("at least one output is required")
+	}
+	events := map[EventType]bool{}
+	for _, t := range config.Events {
+		events[t] = true
+	}
+	if len(events) == 0 {
+		for _, t := range EventTypes {
+			events[t] = true
+		}
+	}
+	return &Sink{config: config, events: events, published: make([]uint32, len(config.Outputs))}, nil
+}
+
+// Run publishes ledgers until ctx is cancelled, see ingest.ProcessorRunner.
+func (s *Sink) Run(ctx context.Context) error {
+	cursor, err := s.config.Cursor.GetCursor(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error getting cursor")
+	}
+
+	for i, output := range s.config.Outputs {
+		if output, ok := output.(LedgerOutput); ok {
+			if s.published[i], err = output.LastLedger(ctx); err != nil {
+				return errors.Wrapf(err, "error getting last ledger published to %T", output)
+			}
+		}
+	}
+
+	var checkpoint uint32
+	if cursor == 0 {
+		start := s.config.StartLedger
+		if start == 0 {
+			has, err := s.config.Archive.GetRootHAS()
+			if err != nil {
+				return errors.Wrap(err, "error getting root HAS")
+			}
+			start = has.CurrentLedger
+			if !s.config.IncludeState {
+				start++
+			}
+		}
+		if s.config.IncludeState {
+			if !s.config.Archive.GetCheckpointManager().IsCheckpoint(start) {
+				return errors.Errorf("start ledger %d is not a checkpoint ledger", start)
+			}
+			checkpoint = start
+			s.header = ledgerHeader{sequence: checkpoint}
+		} else if start < 2 {
+			return errors.New("start ledger must be greater than 1")
+		} else if err = s.config.Cursor.SetCursor(ctx, start-1); err != nil {
+			return errors.Wrap(err, "error saving cursor")
+		}
+	}
+
+	runner, err := ingest.NewProcessorRunner(ingest.ProcessorRunnerConfig{
+		Backend:               &headerBackend{LedgerBackend: s.config.Backend, sink: s},
+		Archive:               s.config.Archive,
+		NetworkPassphrase:     s.config.NetworkPassphrase,
+		Cursor:                s.config.Cursor,
+		StartCheckpoint:       checkpoint,
+		ChangeProcessors:      s.changeProcessors(),
+		TransactionProcessors: s.transactionProcessors(),
+	})
+	if err != nil {
+		return err
+	}
+	return runner.Run(ctx)
+}
+
+// Close closes the outputs.
+func (s *Sink) Close() error {
+	var first error
+	for _, output := range s.config.Outputs {
+		if err := output.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// changeProcessors returns the sink if it publishes changes. They are
+// published when the runner commits change processors, before the events of
+// transactions.
+func (s *Sink) changeProcessors() []ingest.ChangeProcessor {
+	if !s.events[EventTypeChange] {
+		return nil
+	}
+	return []ingest.ChangeProcessor{s}
+}
+
+func (s *Sink) transactionProcessors() []ingest.LedgerTransactionProcessor {
+	if !s.events[EventTypeTransaction] && !s.events[EventTypeOperation] && !s.events[EventTypeEffect] {
+		return nil
+	}
+	return []ingest.LedgerTransactionProcessor{s}
+}
+
+// ProcessChange adds a change event to the ledger being published.
+func (s *Sink) ProcessChange(ctx context.Context, change ingest.Change) error {
+	event, err := changeEvent(s.header, s.changeIndex, change)
+	if err != nil {
+		return err
+	}
+	s.changeIndex++
+	return s.add(event)
+}
+
+// ProcessTransaction adds the events of a transaction to the ledger being
+// published.
+func (s *Sink) ProcessTransaction(ctx context.Context, tx ingest.LedgerTransaction) error {
+	effects := s.config.Effects
+	if !s.events[EventTypeEffect] {
+		effects = nil
+	}
+	events, err := transactionEvents(s.header, tx, effects)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err = s.add(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit publishes the pending events of a ledger.
+func (s *Sink) Commit(ctx context.Context, sequence uint32) error {
+	if sequence != s.header.sequence {
+		return errors.Errorf("committing ledger %d while publishing ledger %d", sequence, s.header.sequence)
+	}
+	return s.publish(ctx, sequence)
+}
+
+func (s *Sink) add(event Event) error {
+	if !s.events[event.Type] {
+		return nil
+	}
+	value, err := s.config.Encoder.Encode(event)
+	if err != nil {
+		return errors.Wrapf(err, "error encoding event %s", event.ID)
+	}
+	s.pending = append(s.pending, Message{
+		ID:          event.ID,
+		Key:         event.key(),
+		Type:        event.Type,
+		Ledger:      event.Ledger,
+		ContentType: s.config.Encoder.ContentType(),
+		Value:       value,
+	})
+	return nil
+}
+
+// publish hands the events of ledger to the outputs which did not publish
+// it yet.
+func (s *Sink) publish(ctx context.Context, ledger uint32) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	for i, output := range s.config.Outputs {
+		if s.published[i] >= ledger {
+			continue
+		}
+		if err := output.Publish(ctx, s.pending); err != nil {
+			return errors.Wrapf(err, "error publishing to %T", output)
+		}
+		s.published[i] = ledger
+	}
+	s.pending = s.pending[:0]
+	return nil
+}
+
+// startLedger resets the sink to build the events of ledger.
+func (s *Sink) startLedger(ledger xdr.LedgerCloseMeta) {
+	s.header = ledgerHeader{
+		sequence: ledger.LedgerSequence(),
+		closedAt: int64(ledger.LedgerHeaderHistoryEntry().Header.ScpValue.CloseTime),
+	}
+	s.changeIndex = 0
+	s.pending = s.pending[:0]
+}
+
+// headerBackend tells the sink which ledger the runner is about to process.
+type headerBackend struct {
+	ledgerbackend.LedgerBackend
+	sink *Sink
+}
+
+func (b *headerBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	ledger, err := b.LedgerBackend.GetLedger(ctx, sequence)
+	if err == nil {
+		b.sink.startLedger(ledger)
+	}
+	return ledger, err
+}
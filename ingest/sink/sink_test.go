@@ -0,0 +1,389 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// fakeBackend serves ledgers and cancels the context when asked for a ledger
+// it does not have.
+type fakeBackend struct {
+	ledgers map[uint32]xdr.LedgerCloseMeta
+	cancel  context.CancelFunc
+}
+
+func (f *fakeBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	return 0, nil
+}
+
+func (f *fakeBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	ledger, ok := f.ledgers[sequence]
+	if !ok {
+		f.cancel()
+		return xdr.LedgerCloseMeta{}, context.Canceled
+	}
+	return ledger, nil
+}
+
+func (f *fakeBackend) PrepareRange(ctx context.Context, ledgerRange ledgerbackend.Range) error {
+	return nil
+}
+
+func (f *fakeBackend) IsPrepared(ctx context.Context, ledgerRange ledgerbackend.Range) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeBackend) Close() error {
+	return nil
+}
+
+// recordingOutput records published messages.
+type recordingOutput struct {
+	messages []Message
+}
+
+func (o *recordingOutput) Publish(ctx context.Context, messages []Message) error {
+	o.messages = append(o.messages, messages...)
+	return nil
+}
+
+func (o *recordingOutput) Close() error {
+	return nil
+}
+
+func (o *recordingOutput) ids() []string {
+	ids := []string{}
+	for _, m := range o.messages {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// testLedger returns a ledger closed at 1000+sequence with a transaction
+// making a payment and updating an account.
+func testLedger(t *testing.T, sequence uint32, source, destination *keypair.Full) xdr.LedgerCloseMeta {
+	envelope := xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				Fee:           100,
+				SourceAccount: xdr.MustMuxedAddress(source.Address()),
+				SeqNum:        xdr.SequenceNumber(sequence),
+				Operations: []xdr.Operation{{
+					Body: xdr.OperationBody{
+						Type: xdr.OperationTypePayment,
+						PaymentOp: &xdr.PaymentOp{
+							Destination: xdr.MustMuxedAddress(destination.Address()),
+							Asset:       xdr.MustNewNativeAsset(),
+							Amount:      10,
+						},
+					},
+				}},
+			},
+		},
+	}
+	hash, err := network.HashTransactionInEnvelope(envelope, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+
+	account := func(balance xdr.Int64) xdr.LedgerEntry {
+		return xdr.LedgerEntry{
+			LastModifiedLedgerSeq: xdr.Uint32(sequence),
+			Data: xdr.LedgerEntryData{
+				Type:    xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{AccountId: xdr.MustAddress(destination.Address()), Balance: balance},
+			},
+		}
+	}
+	pre, post := account(100), account(110)
+	return xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq:     xdr.Uint32(sequence),
+					LedgerVersion: 10,
+					ScpValue:      xdr.StellarValue{CloseTime: xdr.TimePoint(1000 + sequence)},
+				},
+			},
+			TxSet: xdr.TransactionSet{Txs: []xdr.TransactionEnvelope{envelope}},
+			TxProcessing: []xdr.TransactionResultMeta{{
+				Result: xdr.TransactionResultPair{
+					TransactionHash: hash,
+					Result: xdr.TransactionResult{
+						FeeCharged: 100,
+						Result: xdr.TransactionResultResult{
+							Code: xdr.TransactionResultCodeTxSuccess,
+							Results: &[]xdr.OperationResult{{
+								Code: xdr.OperationResultCodeOpInner,
+								Tr: &xdr.OperationResultTr{
+									Type:          xdr.OperationTypePayment,
+									PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSuccess},
+								},
+							}},
+						},
+					},
+				},
+				TxApplyProcessing: xdr.TransactionMeta{
+					V: 1,
+					V1: &xdr.TransactionMetaV1{
+						Operations: []xdr.OperationMeta{{
+							Changes: xdr.LedgerEntryChanges{
+								{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &pre},
+								{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &post},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func testArchive(t *testing.T) historyarchive.ArchiveInterface {
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+	var has historyarchive.HistoryArchiveState
+	has.CurrentLedger = 63
+	require.NoError(t, arch.PutRootHAS(has, &historyarchive.CommandOptions{}))
+	return arch
+}
+
+func creditEffects(tx ingest.LedgerTransaction, ledger uint32) ([]EffectEvent, error) {
+	payment := tx.Envelope.Operations()[0].Body.MustPaymentOp()
+	return []EffectEvent{{
+		OperationIndex: 0,
+		Order:          1,
+		Type:           "account_credited",
+		Account:        payment.Destination.Address(),
+		Details:        map[string]interface{}{"amount": "0.0000010"},
+	}}, nil
+}
+
+func TestSinkRun(t *testing.T) {
+	alice, bob := keypair.MustRandom(), keypair.MustRandom()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := &fakeBackend{
+		ledgers: map[uint32]xdr.LedgerCloseMeta{
+			64: testLedger(t, 64, alice, bob),
+			65: testLedger(t, 65, bob, alice),
+		},
+		cancel: cancel,
+	}
+	cursor := ingest.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+	output := &recordingOutput{}
+	sink, err := New(Config{
+		Backend:           backend,
+		Archive:           testArchive(t),
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Cursor:            cursor,
+		Encoder:           JSONEncoder{},
+		Outputs:           []Output{output},
+		Effects:           creditEffects,
+	})
+	require.NoError(t, err)
+	require.NoError(t, sink.Run(ctx))
+
+	// Ledger 64 has ID 64<<32, its first transaction 1<<12 and its first
+	// operation 1.
+	assert.Equal(t, []string{
+		"change-64-0",
+		"tx-274877911040",
+		"op-274877911041",
+		"effect-274877911041-1",
+		"change-65-0",
+		"tx-279172878336",
+		"op-279172878337",
+		"effect-279172878337-1",
+	}, output.ids())
+
+	var event Event
+	require.NoError(t, json.Unmarshal(output.messages[1].Value, &event))
+	assert.Equal(t, EventTypeTransaction, event.Type)
+	assert.Equal(t, uint32(64), event.Ledger)
+	assert.Equal(t, int64(1064), event.ClosedAt)
+	assert.Equal(t, alice.Address(), event.Transaction.SourceAccount)
+	assert.True(t, event.Transaction.Successful)
+	assert.Equal(t, "tx_success", event.Transaction.ResultCode)
+	var envelope xdr.TransactionEnvelope
+	require.NoError(t, xdr.SafeUnmarshal(event.Transaction.EnvelopeXdr, &envelope))
+	assert.Equal(t, backend.ledgers[64].MustV0().TxSet.Txs[0], envelope)
+
+	event = Event{}
+	require.NoError(t, json.Unmarshal(output.messages[2].Value, &event))
+	assert.Equal(t, "payment", event.Operation.Type)
+	assert.Equal(t, alice.Address(), event.Operation.SourceAccount)
+	assert.NotEmpty(t, event.Operation.ResultXdr)
+
+	event = Event{}
+	require.NoError(t, json.Unmarshal(output.messages[3].Value, &event))
+	assert.Equal(t, bob.Address(), event.Effect.Account)
+	assert.Equal(t, "0.0000010", event.Effect.Details["amount"])
+
+	event = Event{}
+	require.NoError(t, json.Unmarshal(output.messages[0].Value, &event))
+	assert.Equal(t, "updated", event.Change.ChangeType)
+	assert.Equal(t, "account", event.Change.EntryType)
+	var entry xdr.LedgerEntry
+	require.NoError(t, xdr.SafeUnmarshal(event.Change.PostXdr, &entry))
+	assert.Equal(t, xdr.Int64(110), entry.Data.MustAccount().Balance)
+
+	sequence, err := cursor.GetCursor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(65), sequence)
+}
+
+func TestSinkRepublishesFailedLedger(t *testing.T) {
+	alice, bob := keypair.MustRandom(), keypair.MustRandom()
+	ledgers := map[uint32]xdr.LedgerCloseMeta{
+		10: testLedger(t, 10, alice, bob),
+		11: testLedger(t, 11, alice, bob),
+	}
+	cursor := ingest.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+	output := &recordingOutput{}
+	config := Config{
+		Archive:           testArchive(t),
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Cursor:            cursor,
+		StartLedger:       10,
+		Encoder:           JSONEncoder{},
+		Outputs:           []Output{output},
+		Events:            []EventType{EventTypeTransaction, EventTypeOperation},
+	}
+
+	// Ledger 10 is published, then publishing ledger 11 fails. None of its
+	// events are published since a ledger is handed to outputs at once.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config.Backend = &fakeBackend{ledgers: ledgers, cancel: cancel}
+	sink, err := New(config)
+	require.NoError(t, err)
+	sink.config.Outputs = []Output{&failingAfter{Output: output, published: 1}}
+	err = sink.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broker unavailable")
+	assert.Equal(t, []string{"tx-42949677056", "op-42949677057"}, output.ids())
+	sequence, err := cursor.GetCursor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), sequence)
+
+	// A new run publishes ledger 11.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	config.Backend = &fakeBackend{ledgers: ledgers, cancel: cancel}
+	sink, err = New(config)
+	require.NoError(t, err)
+	require.NoError(t, sink.Run(ctx))
+	assert.Equal(t, []string{
+		"tx-42949677056",
+		"op-42949677057",
+		"tx-47244644352",
+		"op-47244644353",
+	}, output.ids())
+	sequence, err = cursor.GetCursor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(11), sequence)
+}
+
+// recordingLedgerOutput is a recordingOutput keeping track of the last
+// ledger it published.
+type recordingLedgerOutput struct {
+	recordingOutput
+}
+
+func (o *recordingLedgerOutput) LastLedger(ctx context.Context) (uint32, error) {
+	if len(o.messages) == 0 {
+		return 0, nil
+	}
+	return o.messages[len(o.messages)-1].Ledger, nil
+}
+
+// failingCursor fails to save the cursor once for ledger failAt.
+type failingCursor struct {
+	ingest.CursorStore
+	failAt uint32
+}
+
+func (c *failingCursor) SetCursor(ctx context.Context, sequence uint32) error {
+	if sequence == c.failAt {
+		c.failAt = 0
+		return errors.New("disk full")
+	}
+	return c.CursorStore.SetCursor(ctx, sequence)
+}
+
+func TestSinkPublishesLedgersOnceToLedgerOutputs(t *testing.T) {
+	alice, bob := keypair.MustRandom(), keypair.MustRandom()
+	ledgers := map[uint32]xdr.LedgerCloseMeta{
+		10: testLedger(t, 10, alice, bob),
+		11: testLedger(t, 11, alice, bob),
+	}
+	cursor := &failingCursor{
+		CursorStore: ingest.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")},
+		failAt:      11,
+	}
+	ledgerOutput := &recordingLedgerOutput{}
+	output := &recordingOutput{}
+	config := Config{
+		Archive:           testArchive(t),
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Cursor:            cursor,
+		StartLedger:       10,
+		Encoder:           JSONEncoder{},
+		Outputs:           []Output{ledgerOutput, output},
+		Events:            []EventType{EventTypeTransaction},
+	}
+
+	// Ledger 11 is published but the sink stops before saving the cursor.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config.Backend = &fakeBackend{ledgers: ledgers, cancel: cancel}
+	sink, err := New(config)
+	require.NoError(t, err)
+	err = sink.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+	sequence, err := cursor.GetCursor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), sequence)
+
+	// A new run publishes ledger 11 again, but only to the output which
+	// doesn't keep track of the ledgers it published.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	config.Backend = &fakeBackend{ledgers: ledgers, cancel: cancel}
+	sink, err = New(config)
+	require.NoError(t, err)
+	require.NoError(t, sink.Run(ctx))
+	assert.Equal(t, []string{"tx-42949677056", "tx-47244644352"}, ledgerOutput.ids())
+	assert.Equal(t, []string{"tx-42949677056", "tx-47244644352", "tx-47244644352"}, output.ids())
+	sequence, err = cursor.GetCursor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(11), sequence)
+}
+
+// failingAfter fails once published ledgers were handed to Output.
+type failingAfter struct {
+	Output
+	published int
+}
+
+func (f *failingAfter) Publish(ctx context.Context, messages []Message) error {
+	if f.published == 0 {
+		return errors.New("broker unavailable")
+	}
+	f.published--
+	return f.Output.Publish(ctx, messages)
+}
@@ -0,0 +1,121 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+const (
+	// SignatureHeader holds "t=<unix time>,v1=<hex signature>", where the
+	// signature is the HMAC-SHA256 of "<unix time>.<body>" keyed with the
+	// webhook secret.
+	SignatureHeader = "X-Sink-Signature"
+	// IdempotencyKeyHeader holds the event ID.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// EventTypeHeader holds the event type.
+	EventTypeHeader = "X-Sink-Event-Type"
+	// LedgerHeader holds the ledger sequence of the event.
+	LedgerHeader = "X-Sink-Ledger"
+)
+
+// WebhookOutput POSTs each message to an HTTP endpoint. Requests failing with
+// a network error, a 429 or a 5xx response are retried with an exponential
+// backoff; other non 2xx responses fail the batch.
+type WebhookOutput struct {
+	URL string
+	// Secret, if set, is used to sign requests, see SignatureHeader.
+	Secret string
+	// Attempts is the number of times a request is tried, 5 if 0.
+	Attempts int
+	// Backoff is the wait before the first retry, 1s if 0.
+	Backoff time.Duration
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// now returns the time used in signatures, time.Now if nil.
+	now func() time.Time
+}
+
+func (o *WebhookOutput) Publish(ctx context.Context, messages []Message) error {
+	attempts := o.Attempts
+	if attempts == 0 {
+		attempts = 5
+	}
+	backoff := o.Backoff
+	if backoff == 0 {
+		backoff = time.Second
+	}
+	for _, m := range messages {
+		m := m
+		err := retry(ctx, attempts, backoff, func() error {
+			return o.post(ctx, m)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "error posting event %s", m.ID)
+		}
+	}
+	return nil
+}
+
+func (o *WebhookOutput) post(ctx context.Context, m Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL, bytes.NewReader(m.Value))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", m.ContentType)
+	req.Header.Set(IdempotencyKeyHeader, m.ID)
+	req.Header.Set(EventTypeHeader, string(m.Type))
+	req.Header.Set(LedgerHeader, strconv.FormatUint(uint64(m.Ledger), 10))
+	if o.Secret != "" {
+		now := time.Now
+		if o.now != nil {
+			now = o.now
+		}
+		req.Header.Set(SignatureHeader, SignWebhook(o.Secret, now(), m.Value))
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	default:
+		return permanentError{errors.Errorf("unexpected status code %d", resp.StatusCode)}
+	}
+}
+
+func (o *WebhookOutput) Close() error {
+	return nil
+}
+
+// SignWebhook returns the SignatureHeader value of a request with the given
+// body sent at t.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	stdhttp "net/http"
 	"os"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/spf13/cobra"
@@ -15,18 +17,64 @@ import (
 	"github.com/metriqorg/go/support/log"
 )
 
+// defaultUpstreamTimeout is the timeout of the requests to the upstream
+// service when the upstream config doesn't set one.
+const defaultUpstreamTimeout = 10 * time.Second
+
 // Config represents the configuration of a federation server
 type Config struct {
 	Port     int `valid:"required"`
 	Database struct {
-		Type string `valid:"matches(^sqlite3|postgres$)"`
-		DSN  string `valid:"required"`
-	} `valid:"required"`
+		Type string `valid:"optional"`
+		DSN  string `valid:"optional"`
+	} `valid:"optional"`
 	Queries struct {
-		Federation        string `valid:"required"`
+		Federation        string `valid:"optional"`
 		ReverseFederation string `toml:"reverse-federation" valid:"optional"`
-	} `valid:"required"`
-	TLS *config.TLS `valid:"optional"`
+	} `valid:"optional"`
+	Static   *StaticConfig   `valid:"optional"`
+	Upstream *UpstreamConfig `valid:"optional"`
+	LDAP     *LDAPConfig     `valid:"optional"`
+	Cache    *CacheConfig    `valid:"optional"`
+	TLS      *config.TLS     `valid:"optional"`
+}
+
+// StaticConfig configures the federation server to serve the records of a
+// JSON or YAML file instead of querying a database.
+type StaticConfig struct {
+	File string `valid:"required"`
+}
+
+// UpstreamConfig configures the federation server to proxy queries to an
+// upstream service implementing the federation protocol instead of querying a
+// database.
+type UpstreamConfig struct {
+	URL            string `valid:"required"`
+	TimeoutSeconds int    `toml:"timeout-seconds" valid:"optional"`
+}
+
+// LDAPConfig configures the federation server to search an LDAP directory
+// instead of querying a database.
+type LDAPConfig struct {
+	URL                     string `valid:"required"`
+	BindDN                  string `toml:"bind-dn" valid:"optional"`
+	BindPassword            string `toml:"bind-password" valid:"optional"`
+	BaseDN                  string `toml:"base-dn" valid:"required"`
+	FederationFilter        string `toml:"federation-filter" valid:"required"`
+	ReverseFederationFilter string `toml:"reverse-federation-filter" valid:"optional"`
+	AccountIDAttribute      string `toml:"account-id-attribute" valid:"required"`
+	MemoTypeAttribute       string `toml:"memo-type-attribute" valid:"optional"`
+	MemoAttribute           string `toml:"memo-attribute" valid:"optional"`
+	NameAttribute           string `toml:"name-attribute" valid:"optional"`
+	DomainAttribute         string `toml:"domain-attribute" valid:"optional"`
+	Domain                  string `valid:"optional"`
+}
+
+// CacheConfig configures the caching of the results of federation queries.
+type CacheConfig struct {
+	TTLSeconds         int `toml:"ttl-seconds" valid:"optional"`
+	NegativeTTLSeconds int `toml:"negative-ttl-seconds" valid:"optional"`
+	MaxEntries         int `toml:"max-entries" valid:"optional"`
 }
 
 func main() {
@@ -85,6 +133,101 @@ func run(cmd *cobra.Command, args []string) {
 }
 
 func initDriver(cfg Config) (federation.Driver, error) {
+	configured := 0
+	if cfg.Database.Type != "" || cfg.Database.DSN != "" {
+		configured++
+	}
+	if cfg.Static != nil {
+		configured++
+	}
+	if cfg.Upstream != nil {
+		configured++
+	}
+	if cfg.LDAP != nil {
+		configured++
+	}
+	if configured > 1 {
+		return nil, errors.New("Only one of database, static, upstream and ldap can be configured")
+	}
+
+	var (
+		driver federation.Driver
+		err    error
+	)
+	switch {
+	case cfg.Static != nil:
+		driver, err = initStaticDriver(cfg)
+	case cfg.Upstream != nil:
+		driver, err = initUpstreamDriver(cfg)
+	case cfg.LDAP != nil:
+		driver, err = initLDAPDriver(cfg)
+	default:
+		driver, err = initSQLDriver(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Cache == nil {
+		return driver, nil
+	}
+
+	return &federation.CachingDriver{
+		Driver:      driver,
+		TTL:         time.Duration(cfg.Cache.TTLSeconds) * time.Second,
+		NegativeTTL: time.Duration(cfg.Cache.NegativeTTLSeconds) * time.Second,
+		MaxEntries:  cfg.Cache.MaxEntries,
+	}, nil
+}
+
+func initStaticDriver(cfg Config) (federation.Driver, error) {
+	records, err := federation.ReadStaticRecords(cfg.Static.File)
+	if err != nil {
+		return nil, errors.Wrap(err, "static records read failed")
+	}
+
+	return &federation.StaticDriver{
+		Records: records,
+	}, nil
+}
+
+func initUpstreamDriver(cfg Config) (federation.Driver, error) {
+	timeout := defaultUpstreamTimeout
+	if cfg.Upstream.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.Upstream.TimeoutSeconds) * time.Second
+	}
+
+	return &federation.HTTPDriver{
+		URL:  cfg.Upstream.URL,
+		HTTP: &stdhttp.Client{Timeout: timeout},
+	}, nil
+}
+
+func initLDAPDriver(cfg Config) (federation.Driver, error) {
+	if cfg.LDAP.ReverseFederationFilter != "" && cfg.LDAP.NameAttribute == "" {
+		return nil, errors.New("Missing ldap name-attribute for reverse-federation-filter")
+	}
+	if cfg.LDAP.ReverseFederationFilter != "" && cfg.LDAP.DomainAttribute == "" && cfg.LDAP.Domain == "" {
+		return nil, errors.New("Missing ldap domain-attribute or domain for reverse-federation-filter")
+	}
+
+	return &federation.LDAPDriver{
+		URL:                       cfg.LDAP.URL,
+		BindDN:                    cfg.LDAP.BindDN,
+		BindPassword:              cfg.LDAP.BindPassword,
+		BaseDN:                    cfg.LDAP.BaseDN,
+		LookupRecordFilter:        cfg.LDAP.FederationFilter,
+		LookupReverseRecordFilter: cfg.LDAP.ReverseFederationFilter,
+		AccountIDAttribute:        cfg.LDAP.AccountIDAttribute,
+		MemoTypeAttribute:         cfg.LDAP.MemoTypeAttribute,
+		MemoAttribute:             cfg.LDAP.MemoAttribute,
+		NameAttribute:             cfg.LDAP.NameAttribute,
+		DomainAttribute:           cfg.LDAP.DomainAttribute,
+		Domain:                    cfg.LDAP.Domain,
+	}, nil
+}
+
+func initSQLDriver(cfg Config) (federation.Driver, error) {
 	var dialect string
 
 	switch cfg.Database.Type {
@@ -98,6 +241,10 @@ func initDriver(cfg Config) (federation.Driver, error) {
 		return nil, errors.Errorf("Invalid db type: %s", cfg.Database.Type)
 	}
 
+	if cfg.Queries.Federation == "" {
+		return nil, errors.New("Missing federation query")
+	}
+
 	repo, err := db.Open(dialect, cfg.Database.DSN)
 	if err != nil {
 		return nil, errors.Wrap(err, "db open failed")
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/metriqorg/go/handlers/federation"
+	"github.com/metriqorg/go/support/config"
 	"github.com/metriqorg/go/support/db/dbtest"
 	"github.com/metriqorg/go/support/errors"
 	"github.com/stretchr/testify/assert"
@@ -11,6 +16,7 @@ import (
 
 func TestInitDriver_dialect(t *testing.T) {
 	c := Config{}
+	c.Queries.Federation = "SELECT id FROM people WHERE name = ? AND domain = ?"
 
 	testCases := []struct {
 		dbType  string
@@ -37,3 +43,113 @@ func TestInitDriver_dialect(t *testing.T) {
 		})
 	}
 }
+
+func TestInitDriver_static(t *testing.T) {
+	dir := t.TempDir()
+	recordsPath := filepath.Join(dir, "records.yaml")
+	err := ioutil.WriteFile(recordsPath, []byte(`
+- name: scott
+  domain: stellar.org
+  account_id: GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG
+`), 0600)
+	require.NoError(t, err)
+
+	cfgPath := filepath.Join(dir, "federation.cfg")
+	err = ioutil.WriteFile(cfgPath, []byte(`
+port = 8000
+
+[static]
+file = "`+recordsPath+`"
+
+[cache]
+ttl-seconds = 300
+negative-ttl-seconds = 60
+`), 0600)
+	require.NoError(t, err)
+
+	var c Config
+	err = config.Read(cfgPath, &c)
+	require.NoError(t, err)
+
+	driver, err := initDriver(c)
+	require.NoError(t, err)
+
+	cachingDriver, ok := driver.(*federation.CachingDriver)
+	require.True(t, ok)
+	assert.Equal(t, 5*time.Minute, cachingDriver.TTL)
+	assert.Equal(t, time.Minute, cachingDriver.NegativeTTL)
+	assert.Equal(t, &federation.StaticDriver{
+		Records: []federation.StaticRecord{
+			{
+				Name:      "scott",
+				Domain:    "stellar.org",
+				AccountID: "GD2GJPL3UOK5LX7TWXOACK2ZPWPFSLBNKL3GTGH6BLBNISK4BGWMFBBG",
+			},
+		},
+	}, cachingDriver.Driver)
+}
+
+func TestInitDriver_upstream(t *testing.T) {
+	c := Config{
+		Upstream: &UpstreamConfig{URL: "http://users.internal/federation"},
+	}
+
+	driver, err := initDriver(c)
+	require.NoError(t, err)
+
+	httpDriver, ok := driver.(*federation.HTTPDriver)
+	require.True(t, ok)
+	assert.Equal(t, "http://users.internal/federation", httpDriver.URL)
+}
+
+func TestInitDriver_ldap(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "federation.cfg")
+	err := ioutil.WriteFile(cfgPath, []byte(`
+port = 8000
+
+[ldap]
+url = "ldaps://ldap.stellar.org"
+bind-dn = "cn=federation,dc=stellar,dc=org"
+bind-password = "secret"
+base-dn = "ou=people,dc=stellar,dc=org"
+federation-filter = "(&(uid={name})(stellarDomain={domain}))"
+reverse-federation-filter = "(stellarAccount={account_id})"
+account-id-attribute = "stellarAccount"
+name-attribute = "uid"
+domain = "stellar.org"
+`), 0600)
+	require.NoError(t, err)
+
+	var c Config
+	err = config.Read(cfgPath, &c)
+	require.NoError(t, err)
+
+	driver, err := initDriver(c)
+	require.NoError(t, err)
+	assert.Equal(t, &federation.LDAPDriver{
+		URL:                       "ldaps://ldap.stellar.org",
+		BindDN:                    "cn=federation,dc=stellar,dc=org",
+		BindPassword:              "secret",
+		BaseDN:                    "ou=people,dc=stellar,dc=org",
+		LookupRecordFilter:        "(&(uid={name})(stellarDomain={domain}))",
+		LookupReverseRecordFilter: "(stellarAccount={account_id})",
+		AccountIDAttribute:        "stellarAccount",
+		NameAttribute:             "uid",
+		Domain:                    "stellar.org",
+	}, driver)
+
+	c.LDAP.Domain = ""
+	_, err = initDriver(c)
+	assert.EqualError(t, err, "Missing ldap domain-attribute or domain for reverse-federation-filter")
+}
+
+func TestInitDriver_multipleDrivers(t *testing.T) {
+	c := Config{
+		Static:   &StaticConfig{File: "records.yaml"},
+		Upstream: &UpstreamConfig{URL: "http://users.internal/federation"},
+	}
+
+	_, err := initDriver(c)
+	assert.EqualError(t, err, "Only one of database, static, upstream and ldap can be configured")
+}
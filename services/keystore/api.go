@@ -8,11 +8,13 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rs/cors"
 	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/http/httpdecode"
 	"github.com/metriqorg/go/support/log"
 	"github.com/metriqorg/go/support/render/health"
 	"github.com/metriqorg/go/support/render/httpjson"
@@ -38,6 +40,8 @@ func (s *Service) wrapMiddleware(handler http.Handler) http.Handler {
 func ServeMux(s *Service) http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/keys", s.wrapMiddleware(s.keysHTTPMethodHandler()))
+	mux.Handle("/keys/versions", s.wrapMiddleware(s.keysVersionsHTTPMethodHandler()))
+	mux.Handle("/keys/restore", s.wrapMiddleware(s.restoreKeysHTTPMethodHandler()))
 	mux.Handle("/health", s.wrapMiddleware(health.PassHandler{}))
 	return mux
 }
@@ -46,10 +50,10 @@ func (s *Service) keysHTTPMethodHandler() http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		switch req.Method {
 		case http.MethodGet:
-			jsonHandler(s.getKeys).ServeHTTP(rw, req)
+			s.getKeysHandler(rw, req)
 
 		case http.MethodPut:
-			jsonHandler(s.putKeys).ServeHTTP(rw, req)
+			s.putKeysHandler(rw, req)
 
 		case http.MethodDelete:
 			jsonHandler(s.deleteKeys).ServeHTTP(rw, req)
@@ -60,6 +64,123 @@ func (s *Service) keysHTTPMethodHandler() http.Handler {
 	})
 }
 
+func (s *Service) keysVersionsHTTPMethodHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			jsonHandler(s.getKeysVersions).ServeHTTP(rw, req)
+
+		default:
+			problem.Render(req.Context(), rw, probMethodNotAllowed)
+		}
+	})
+}
+
+func (s *Service) restoreKeysHTTPMethodHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			s.restoreKeysHandler(rw, req)
+
+		default:
+			problem.Render(req.Context(), rw, probMethodNotAllowed)
+		}
+	})
+}
+
+// The handlers of the requests reading or writing the current version of the
+// keys blob can't use jsonHandler as they set the ETag header of the response
+// to its version, and read the version the request is based on from the
+// If-Match header.
+
+func (s *Service) getKeysHandler(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	out, err := s.getKeys(ctx)
+	if err != nil {
+		problem.Render(ctx, rw, err)
+		return
+	}
+
+	etag := keysETag(out.Version)
+	rw.Header().Set("ETag", etag)
+	if req.Header.Get("If-None-Match") == etag {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+	httpjson.Render(rw, out, httpjson.JSON)
+}
+
+func (s *Service) putKeysHandler(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var in putKeysRequest
+	if err := httpdecode.DecodeJSON(req, &in); err != nil {
+		problem.Render(ctx, rw, httpjson.ErrBadRequest)
+		return
+	}
+	ifMatch, err := ifMatchVersion(req)
+	if err != nil {
+		problem.Render(ctx, rw, err)
+		return
+	}
+	in.ifMatch = ifMatch
+
+	out, err := s.putKeys(ctx, in)
+	if err != nil {
+		problem.Render(ctx, rw, err)
+		return
+	}
+	rw.Header().Set("ETag", keysETag(out.Version))
+	httpjson.Render(rw, out, httpjson.JSON)
+}
+
+func (s *Service) restoreKeysHandler(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var in restoreKeysRequest
+	if err := httpdecode.DecodeJSON(req, &in); err != nil {
+		problem.Render(ctx, rw, httpjson.ErrBadRequest)
+		return
+	}
+	ifMatch, err := ifMatchVersion(req)
+	if err != nil {
+		problem.Render(ctx, rw, err)
+		return
+	}
+	in.ifMatch = ifMatch
+
+	out, err := s.restoreKeys(ctx, in)
+	if err != nil {
+		problem.Render(ctx, rw, err)
+		return
+	}
+	rw.Header().Set("ETag", keysETag(out.Version))
+	httpjson.Render(rw, out, httpjson.JSON)
+}
+
+// keysETag returns the entity tag of the given version of a keys blob.
+func keysETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatchVersion returns the version of the keys blob in the If-Match header
+// of req, or nil if the header isn't set or is "*". An If-Match header which
+// isn't an entity tag returned by the keystore can't match any version.
+func ifMatchVersion(req *http.Request) (*int, error) {
+	etag := strings.TrimSpace(req.Header.Get("If-Match"))
+	if etag == "" || etag == "*" {
+		return nil, nil
+	}
+
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(etag, "W/"))
+	if err != nil {
+		return nil, probVersionConflict
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return nil, probVersionConflict
+	}
+	return &version, nil
+}
+
 type authResponse struct {
 	UserID string `json:"userID"`
 }
@@ -186,6 +307,7 @@ func corsHandler(next http.Handler) http.Handler {
 		AllowedOrigins: []string{"*"},
 		AllowedHeaders: []string{"*"},
 		AllowedMethods: []string{"GET", "PUT", "POST", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		ExposedHeaders: []string{"ETag"},
 	})
 	return cors.Handler(next)
 }
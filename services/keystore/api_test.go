@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
@@ -34,6 +35,7 @@ func TestPutKeysAPI(t *testing.T) {
 			URL:     ts.URL,
 			APIType: REST,
 		},
+		keyManager: newTestKeyManager(t),
 	})
 
 	blob := `[{
@@ -87,6 +89,7 @@ func TestGetKeysAPI(t *testing.T) {
 			URL:     ts.URL,
 			APIType: REST,
 		},
+		keyManager: newTestKeyManager(t),
 	}
 	h := ServeMux(s)
 
@@ -157,6 +160,7 @@ func TestDeleteKeysAPI(t *testing.T) {
 			URL:     ts.URL,
 			APIType: REST,
 		},
+		keyManager: newTestKeyManager(t),
 	}
 	h := ServeMux(s)
 
@@ -199,3 +203,112 @@ func TestDeleteKeysAPI(t *testing.T) {
 		t.Errorf("expect the keys blob of the user %s to be deleted", userID(ctx))
 	}
 }
+
+func TestPutKeysAPI_ifMatch(t *testing.T) {
+	db := openKeystoreDB(t)
+	defer db.Close() // drop test db
+
+	conn := db.Open()
+	defer conn.Close() // close db connection
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"userID":"test-user"}`)
+	}))
+	defer ts.Close()
+
+	h := ServeMux(&Service{
+		db: conn.DB,
+		authenticator: &Authenticator{
+			URL:     ts.URL,
+			APIType: REST,
+		},
+		keyManager: newTestKeyManager(t),
+	})
+
+	blob := `[{
+		"id": "test-id",
+		"salt": "test-salt",
+		"encrypterName": "test-encrypter-name",
+		"encryptedBlob": "test-encryptedblob"
+	}]`
+	keysBlob := base64.RawURLEncoding.EncodeToString([]byte(blob))
+	body, err := json.Marshal(putKeysRequest{KeysBlob: keysBlob})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/keys", bytes.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := put("")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT /keys responded with %s, want %s", http.StatusText(rr.Code), http.StatusText(http.StatusOK))
+	}
+	if got := rr.Header().Get("ETag"); got != `"1"` {
+		t.Errorf("got ETag %s, want %s", got, `"1"`)
+	}
+
+	rr = put(`"1"`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT /keys responded with %s, want %s", http.StatusText(rr.Code), http.StatusText(http.StatusOK))
+	}
+	if got := rr.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("got ETag %s, want %s", got, `"2"`)
+	}
+
+	// Another device still based on the first version:
+	rr = put(`"1"`)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PUT /keys responded with %s, want %s", http.StatusText(rr.Code), http.StatusText(http.StatusPreconditionFailed))
+	}
+
+	req := httptest.NewRequest("GET", "/keys", nil)
+	req.Header.Set("If-None-Match", `"2"`)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("GET %s responded with %s, want %s", req.URL, http.StatusText(rr.Code), http.StatusText(http.StatusNotModified))
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	testCases := []struct {
+		ifMatch     string
+		wantVersion *int
+		wantErr     bool
+	}{
+		{ifMatch: ""},
+		{ifMatch: "*"},
+		{ifMatch: `"3"`, wantVersion: intPtr(3)},
+		{ifMatch: `W/"3"`, wantVersion: intPtr(3)},
+		{ifMatch: "3", wantErr: true},
+		{ifMatch: `"three"`, wantErr: true},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("PUT", "/keys", nil)
+		req.Header.Set("If-Match", tc.ifMatch)
+
+		got, err := ifMatchVersion(req)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("If-Match %s: got error %v, want error %t", tc.ifMatch, err, tc.wantErr)
+		}
+		if !reflect.DeepEqual(got, tc.wantVersion) {
+			t.Errorf("If-Match %s: got version %v, want %v", tc.ifMatch, got, tc.wantVersion)
+		}
+	}
+
+	if got := keysETag(3); got != `"3"` {
+		t.Errorf("got ETag %s, want %s", got, `"3"`)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,34 @@
+package keystore
+
+import (
+	"context"
+
+	"github.com/metriqorg/go/support/log"
+)
+
+// Actions recorded in the audit log.
+const (
+	auditActionGet         = "get"
+	auditActionPut         = "put"
+	auditActionDelete      = "delete"
+	auditActionRotate      = "rotate"
+	auditActionGetVersions = "get_versions"
+	auditActionRestore     = "restore"
+)
+
+// auditLog records an access to the keys blob of a user, with its outcome, in
+// the log of ctx. Entries have the "audit" field set so that they can be
+// routed apart from the other logs.
+func auditLog(ctx context.Context, action, userID string, err error) {
+	l := log.Ctx(ctx).WithFields(log.F{
+		"audit":   true,
+		"action":  action,
+		"user_id": userID,
+		"success": err == nil,
+	})
+	if err != nil {
+		l.WithError(err).Warn("keys blob access failed")
+		return
+	}
+	l.Info("keys blob accessed")
+}
@@ -15,5 +15,6 @@ func getConfig() *keystore.Config {
 		MaxOpenDBConns: env.Int("DB_MAX_OPEN_CONNS", 5),
 		AUTHURL:        env.String("KEYSTORE_AUTHFORWARDING_URL", ""),
 		ListenerPort:   env.Int("KEYSTORE_LISTENER_PORT", 8000),
+		MasterKeyFile:  env.String("KEYSTORE_MASTER_KEY_FILE", ""),
 	}
 }
@@ -116,9 +116,10 @@ func main() {
 			}
 		}
 
+		keyManager := loadKeyManager(cfg)
 		server := &http.Server{
 			Addr:        addr,
-			Handler:     keystore.ServeMux(keystore.NewService(ctx, db, authenticator)),
+			Handler:     keystore.ServeMux(keystore.NewService(ctx, db, authenticator, keyManager)),
 			ReadTimeout: 5 * time.Second,
 		}
 
@@ -214,12 +215,56 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "master-key":
+		masterKeyCmd := flag.Arg(1)
+		switch masterKeyCmd {
+		case "generate":
+			if cfg.MasterKeyFile == "" {
+				fmt.Fprintln(os.Stderr, "KEYSTORE_MASTER_KEY_FILE is not set")
+				os.Exit(1)
+			}
+			keyID, err := keystore.GenerateLocalMasterKey(cfg.MasterKeyFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error generating master key: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Fprintf(os.Stdout, "Generated master key %s, run \"keystored master-key rotate\" to re-wrap the stored keys with it.\n", keyID)
+
+		case "rotate":
+			keyManager := loadKeyManager(cfg)
+			n, err := keystore.NewService(ctx, db, nil, keyManager).RotateKeys(ctx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error rotating keys after %d rows: %v\n", n, err)
+				os.Exit(1)
+			}
+
+			fmt.Fprintf(os.Stdout, "Re-wrapped %d rows with master key %s!\n", n, keyManager.CurrentKeyID())
+
+		default:
+			fmt.Fprintf(os.Stderr, "unrecognized master-key command: %q\n", masterKeyCmd)
+			os.Exit(1)
+		}
+
 	default:
 		fmt.Fprintf(os.Stderr, "unrecognized command: %q\n", cmd)
 		os.Exit(1)
 	}
 }
 
+func loadKeyManager(cfg *keystore.Config) keystore.KeyManager {
+	if cfg.MasterKeyFile == "" {
+		fmt.Fprintln(os.Stderr, "KEYSTORE_MASTER_KEY_FILE is not set, run \"keystored master-key generate\" to create it")
+		os.Exit(1)
+	}
+	keyManager, err := keystore.LoadLocalKeyManager(cfg.MasterKeyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading master keys: %v\n", err)
+		os.Exit(1)
+	}
+	return keyManager
+}
+
 // https://github.com/golang/go/blob/c5cf6624076a644906aa7ec5c91c4e01ccd375d3/src/net/http/server.go#L3272-L3288
 type tcpKeepAliveListener struct {
 	*net.TCPListener
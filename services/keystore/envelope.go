@@ -0,0 +1,164 @@
+package keystore
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"io"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// sealedKeysData is a keys blob encrypted with a data key, along with the data
+// key wrapped by a KeyManager.
+type sealedKeysData struct {
+	Data        []byte
+	WrappedKey  []byte
+	MasterKeyID string
+}
+
+// sealKeysData encrypts the keys blob of a user with a new data key, which is
+// wrapped with the current master key of km. The user ID is authenticated
+// along with the blob, so that it can't be moved to another user.
+func sealKeysData(ctx context.Context, km KeyManager, userID string, keysData []byte) (sealedKeysData, error) {
+	dataKey := make([]byte, masterKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return sealedKeysData{}, errors.Wrap(err, "generating data key")
+	}
+
+	data, err := sealAESGCM(dataKey, keysData, []byte(userID))
+	if err != nil {
+		return sealedKeysData{}, errors.Wrap(err, "encrypting keys blob")
+	}
+	keyID, wrappedKey, err := km.WrapKey(ctx, dataKey)
+	if err != nil {
+		return sealedKeysData{}, err
+	}
+	return sealedKeysData{Data: data, WrappedKey: wrappedKey, MasterKeyID: keyID}, nil
+}
+
+// openKeysData decrypts a keys blob sealed by sealKeysData.
+func openKeysData(ctx context.Context, km KeyManager, userID string, sealed sealedKeysData) ([]byte, error) {
+	dataKey, err := km.UnwrapKey(ctx, sealed.MasterKeyID, sealed.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	keysData, err := openAESGCM(dataKey, sealed.Data, []byte(userID))
+	return keysData, errors.Wrap(err, "decrypting keys blob")
+}
+
+// rewrapKeysData re-wraps the data key of a sealed keys blob with the current
+// master key of km. The blob itself isn't decrypted.
+func rewrapKeysData(ctx context.Context, km KeyManager, sealed sealedKeysData) (sealedKeysData, error) {
+	dataKey, err := km.UnwrapKey(ctx, sealed.MasterKeyID, sealed.WrappedKey)
+	if err != nil {
+		return sealedKeysData{}, err
+	}
+	sealed.MasterKeyID, sealed.WrappedKey, err = km.WrapKey(ctx, dataKey)
+	return sealed, err
+}
+
+// RotateKeys re-wraps the data keys of all the keys blobs, including their
+// previous versions, that aren't wrapped with the current master key of the
+// key manager, and encrypts the keys blobs stored before envelope encryption
+// was enabled. It returns the number of rows updated. Each row is updated in
+// its own transaction, so the rotation can be resumed if it is interrupted.
+func (s *Service) RotateKeys(ctx context.Context) (int, error) {
+	type keysRow struct {
+		table   string
+		userID  string
+		version int
+	}
+
+	currentKeyID := s.keyManager.CurrentKeyID()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT 'encrypted_keys', user_id, version
+		FROM encrypted_keys
+		WHERE master_key_id IS DISTINCT FROM $1
+		UNION ALL
+		SELECT 'encrypted_keys_versions', user_id, version
+		FROM encrypted_keys_versions
+		WHERE master_key_id IS DISTINCT FROM $1
+	`, currentKeyID)
+	if err != nil {
+		return 0, errors.Wrap(err, "listing keys blobs to rotate")
+	}
+	var keysRows []keysRow
+	for rows.Next() {
+		var r keysRow
+		if err = rows.Scan(&r.table, &r.userID, &r.version); err != nil {
+			rows.Close()
+			return 0, errors.Wrap(err, "listing keys blobs to rotate")
+		}
+		keysRows = append(keysRows, r)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "listing keys blobs to rotate")
+	}
+
+	n := 0
+	for _, r := range keysRows {
+		rotated, err := s.rotateKeysRow(ctx, r.table, r.userID, r.version)
+		auditLog(ctx, auditActionRotate, r.userID, err)
+		if err != nil {
+			return n, errors.Wrapf(err, "rotating version %d of the keys blob of user %s", r.version, r.userID)
+		}
+		if rotated {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// rotateKeysRow re-wraps or encrypts a version of the keys blob of a user,
+// stored in the given table, unless it was replaced, deleted or rotated in the
+// meantime.
+func (s *Service) rotateKeysRow(ctx context.Context, table, userID string, version int) (rotated bool, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var keys storedKeys
+	err = keys.scan(tx.QueryRowContext(ctx, `
+		SELECT `+storedKeysColumns+`
+		FROM `+table+`
+		WHERE user_id = $1 AND version = $2
+		FOR UPDATE
+	`, userID, version))
+	if err == sql.ErrNoRows {
+		return false, tx.Rollback()
+	}
+	if err != nil {
+		return false, err
+	}
+	if keys.Sealed.MasterKeyID == s.keyManager.CurrentKeyID() {
+		return false, tx.Rollback()
+	}
+
+	sealed := keys.Sealed
+	if sealed.MasterKeyID != "" {
+		sealed, err = rewrapKeysData(ctx, s.keyManager, sealed)
+	} else {
+		sealed, err = sealKeysData(ctx, s.keyManager, userID, keys.Plain)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE `+table+`
+		SET encrypted_keys_data = NULL, sealed_keys_data = $3, wrapped_data_key = $4, master_key_id = $5
+		WHERE user_id = $1 AND version = $2
+	`, userID, version, sealed.Data, sealed.WrappedKey, sealed.MasterKeyID)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
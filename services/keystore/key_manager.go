@@ -0,0 +1,195 @@
+package keystore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// masterKeySize is the size in bytes of the master keys of LocalKeyManager and
+// of the data keys, which are AES-256 keys.
+const masterKeySize = 32
+
+// KeyManager wraps (encrypts) and unwraps the data keys the stored keys blobs
+// are encrypted with. Master keys are versioned: data keys are always wrapped
+// with the current master key, but can be unwrapped with any of the master
+// keys known to the manager, until they are re-wrapped by a key rotation.
+//
+// LocalKeyManager keeps the master keys in a local file. Cloud KMS can be
+// supported by implementing this interface on top of their encrypt and decrypt
+// operations, in which case the master keys never leave the KMS.
+type KeyManager interface {
+	// CurrentKeyID returns the ID of the master key new data keys are
+	// wrapped with.
+	CurrentKeyID() string
+
+	// WrapKey wraps dataKey with the current master key, and returns the ID
+	// of that master key along with the wrapped key.
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrappedKey []byte, err error)
+
+	// UnwrapKey unwraps a data key wrapped with the master key with the
+	// given ID.
+	UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// LocalKeyManager is a KeyManager whose master keys are held in memory,
+// usually loaded from a file with LoadLocalKeyManager. Data keys are wrapped
+// with AES-256-GCM.
+type LocalKeyManager struct {
+	currentKeyID string
+	keys         map[string][]byte
+}
+
+// localKeyFile is the JSON format of the files read by LoadLocalKeyManager.
+type localKeyFile struct {
+	CurrentKeyID string            `json:"currentKeyID"`
+	Keys         map[string]string `json:"keys"` // base64 encoded
+}
+
+// NewLocalKeyManager returns a LocalKeyManager with the given 32 bytes master
+// keys, indexed by ID, which wraps data keys with the key currentKeyID.
+func NewLocalKeyManager(keys map[string][]byte, currentKeyID string) (*LocalKeyManager, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, errors.Errorf("current master key %q not found", currentKeyID)
+	}
+	km := &LocalKeyManager{currentKeyID: currentKeyID, keys: map[string][]byte{}}
+	for id, key := range keys {
+		if len(key) != masterKeySize {
+			return nil, errors.Errorf("master key %q must be %d bytes long", id, masterKeySize)
+		}
+		km.keys[id] = key
+	}
+	return km, nil
+}
+
+// LoadLocalKeyManager returns a LocalKeyManager with the master keys of the
+// given file, a JSON object with the base64 encoded keys indexed by ID and the
+// ID of the current key, e.g.
+//
+//	{"currentKeyID": "2", "keys": {"1": "<base64>", "2": "<base64>"}}
+func LoadLocalKeyManager(path string) (*LocalKeyManager, error) {
+	f, err := readLocalKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string][]byte{}
+	for id, encodedKey := range f.Keys {
+		keys[id], err = base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding master key %q", id)
+		}
+	}
+	return NewLocalKeyManager(keys, f.CurrentKeyID)
+}
+
+// GenerateLocalMasterKey adds a new random master key to the key file at the
+// given path, creating it if it doesn't exist, and makes it the current key.
+// Keys are versioned: the ID of the new key is the number of keys in the file.
+// Rows encrypted with the previous keys can be re-wrapped with the new key by
+// Service.RotateKeys.
+func GenerateLocalMasterKey(path string) (keyID string, err error) {
+	f := localKeyFile{Keys: map[string]string{}}
+	if _, err = os.Stat(path); err == nil {
+		if f, err = readLocalKeyFile(path); err != nil {
+			return "", err
+		}
+	} else if !os.IsNotExist(err) {
+		return "", errors.Wrap(err, "reading master key file")
+	}
+
+	key := make([]byte, masterKeySize)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return "", errors.Wrap(err, "generating master key")
+	}
+	keyID = strconv.Itoa(len(f.Keys) + 1)
+	if _, ok := f.Keys[keyID]; ok {
+		return "", errors.Errorf("master key %q already exists", keyID)
+	}
+	f.Keys[keyID] = base64.StdEncoding.EncodeToString(key)
+	f.CurrentKeyID = keyID
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "encoding master key file")
+	}
+	err = ioutil.WriteFile(path, data, 0600)
+	return keyID, errors.Wrap(err, "writing master key file")
+}
+
+func readLocalKeyFile(path string) (f localKeyFile, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return f, errors.Wrap(err, "reading master key file")
+	}
+	err = json.Unmarshal(data, &f)
+	return f, errors.Wrap(err, "decoding master key file")
+}
+
+// CurrentKeyID implements KeyManager.
+func (km *LocalKeyManager) CurrentKeyID() string {
+	return km.currentKeyID
+}
+
+// WrapKey implements KeyManager.
+func (km *LocalKeyManager) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	wrappedKey, err := sealAESGCM(km.keys[km.currentKeyID], dataKey, []byte(km.currentKeyID))
+	if err != nil {
+		return "", nil, errors.Wrap(err, "wrapping data key")
+	}
+	return km.currentKeyID, wrappedKey, nil
+}
+
+// UnwrapKey implements KeyManager.
+func (km *LocalKeyManager) UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	key, ok := km.keys[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown master key %q", keyID)
+	}
+	dataKey, err := openAESGCM(key, wrappedKey, []byte(keyID))
+	return dataKey, errors.Wrap(err, "unwrapping data key")
+}
+
+// sealAESGCM encrypts and authenticates plaintext and additionalData with the
+// given AES key, and returns the random nonce followed by the ciphertext.
+func sealAESGCM(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// openAESGCM decrypts data sealed by sealAESGCM.
+func openAESGCM(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
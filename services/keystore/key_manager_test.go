@@ -0,0 +1,183 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func newTestMasterKey(t *testing.T) []byte {
+	key := make([]byte, masterKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestKeyManager(t *testing.T) *LocalKeyManager {
+	km, err := NewLocalKeyManager(map[string][]byte{"1": newTestMasterKey(t)}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return km
+}
+
+func TestNewLocalKeyManager_invalidKeys(t *testing.T) {
+	_, err := NewLocalKeyManager(map[string][]byte{"1": newTestMasterKey(t)}, "2")
+	if err == nil || err.Error() != `current master key "2" not found` {
+		t.Errorf("got error %v, want current master key not found", err)
+	}
+
+	_, err = NewLocalKeyManager(map[string][]byte{"1": []byte("too short")}, "1")
+	if err == nil || err.Error() != `master key "1" must be 32 bytes long` {
+		t.Errorf("got error %v, want invalid master key size", err)
+	}
+}
+
+func TestLocalKeyManager_wrapKey(t *testing.T) {
+	ctx := context.Background()
+	km := newTestKeyManager(t)
+	dataKey := newTestMasterKey(t)
+
+	keyID, wrappedKey, err := km.WrapKey(ctx, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "1" {
+		t.Errorf("got key ID %q, want %q", keyID, "1")
+	}
+	if bytes.Contains(wrappedKey, dataKey) {
+		t.Error("wrapped key contains the data key")
+	}
+
+	got, err := km.UnwrapKey(ctx, keyID, wrappedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, dataKey) {
+		t.Errorf("got data key %x, want %x", got, dataKey)
+	}
+
+	_, err = km.UnwrapKey(ctx, "2", wrappedKey)
+	if err == nil {
+		t.Error("expected unwrapping with an unknown master key to fail")
+	}
+
+	wrappedKey[len(wrappedKey)-1] ^= 1
+	_, err = km.UnwrapKey(ctx, keyID, wrappedKey)
+	if err == nil {
+		t.Error("expected unwrapping a tampered data key to fail")
+	}
+}
+
+func TestGenerateLocalMasterKey(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "master-keys.json")
+
+	keyID, err := GenerateLocalMasterKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "1" {
+		t.Errorf("got key ID %q, want %q", keyID, "1")
+	}
+	km1, err := LoadLocalKeyManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, wrappedKey, err := km1.WrapKey(ctx, []byte("data key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Generating a new key keeps the previous ones, so that the data keys
+	// they wrapped can still be unwrapped:
+	keyID, err = GenerateLocalMasterKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "2" {
+		t.Errorf("got key ID %q, want %q", keyID, "2")
+	}
+	km2, err := LoadLocalKeyManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km2.CurrentKeyID() != "2" {
+		t.Errorf("got current key ID %q, want %q", km2.CurrentKeyID(), "2")
+	}
+	got, err := km2.UnwrapKey(ctx, "1", wrappedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "data key" {
+		t.Errorf("got data key %q, want %q", got, "data key")
+	}
+}
+
+func TestSealKeysData(t *testing.T) {
+	ctx := context.Background()
+	key1, key2 := newTestMasterKey(t), newTestMasterKey(t)
+	km1, err := NewLocalKeyManager(map[string][]byte{"1": key1}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keysData := []byte(`[{"id": "test-id"}]`)
+
+	sealed, err := sealKeysData(ctx, km1, "test-user", keysData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.MasterKeyID != "1" {
+		t.Errorf("got master key ID %q, want %q", sealed.MasterKeyID, "1")
+	}
+	got, err := openKeysData(ctx, km1, "test-user", sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, keysData) {
+		t.Errorf("got keys blob %s, want %s", got, keysData)
+	}
+
+	// The keys blob is bound to its user:
+	_, err = openKeysData(ctx, km1, "other-user", sealed)
+	if err == nil {
+		t.Error("expected opening the keys blob of another user to fail")
+	}
+
+	// After a rotation, the data key is wrapped with the new master key but
+	// the keys blob is unchanged:
+	km2, err := NewLocalKeyManager(map[string][]byte{"1": key1, "2": key2}, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrapped, err := rewrapKeysData(ctx, km2, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewrapped.MasterKeyID != "2" {
+		t.Errorf("got master key ID %q, want %q", rewrapped.MasterKeyID, "2")
+	}
+	if !bytes.Equal(rewrapped.Data, sealed.Data) {
+		t.Error("expected the keys blob not to be re-encrypted")
+	}
+
+	onlyKey2, err := NewLocalKeyManager(map[string][]byte{"2": key2}, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = openKeysData(ctx, onlyKey2, "test-user", rewrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, keysData) {
+		t.Errorf("got keys blob %s, want %s", got, keysData)
+	}
+	_, err = openKeysData(ctx, onlyKey2, "test-user", sealed)
+	if err == nil {
+		t.Error("expected opening a keys blob wrapped with a retired master key to fail")
+	}
+}
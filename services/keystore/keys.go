@@ -6,13 +6,13 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/lib/pq"
 	"github.com/metriqorg/go/support/errors"
 	"github.com/metriqorg/go/support/render/problem"
 )
 
 type encryptedKeysData struct {
 	KeysBlob   string     `json:"keysBlob"`
+	Version    int        `json:"version"`
 	CreatedAt  time.Time  `json:"createdAt"`
 	ModifiedAt *time.Time `json:"modifiedAt,omitempty"`
 }
@@ -26,10 +26,16 @@ type encryptedKeyData struct {
 
 type putKeysRequest struct {
 	KeysBlob string `json:"keysBlob"`
+
+	// ifMatch is the version of the keys blob the request is based on, set
+	// from the If-Match header. The keys blob is only stored if it is still
+	// the current version.
+	ifMatch *int
 }
 
-func (s *Service) putKeys(ctx context.Context, in putKeysRequest) (*encryptedKeysData, error) {
+func (s *Service) putKeys(ctx context.Context, in putKeysRequest) (_ *encryptedKeysData, err error) {
 	userID := userID(ctx)
+	defer func() { auditLog(ctx, auditActionPut, userID, err) }()
 	if userID == "" {
 		return nil, probNotAuthorized
 	}
@@ -69,59 +75,46 @@ func (s *Service) putKeys(ctx context.Context, in putKeysRequest) (*encryptedKey
 		}
 	}
 
-	q := `
-		INSERT INTO encrypted_keys (user_id, encrypted_keys_data)
-		VALUES ($1, $2)
-		ON CONFLICT (user_id) DO UPDATE SET encrypted_keys_data = excluded.encrypted_keys_data, modified_at = NOW()
-		RETURNING encrypted_keys_data, created_at, modified_at
-	`
-	var (
-		keysBlob   []byte
-		out        encryptedKeysData
-		modifiedAt pq.NullTime
-	)
-	err = s.db.QueryRowContext(ctx, q, userID, keysData).Scan(&keysBlob, &out.CreatedAt, &modifiedAt)
+	sealed, err := sealKeysData(ctx, s.keyManager, userID, keysData)
 	if err != nil {
-		return nil, errors.Wrap(err, "storing keys blob")
+		return nil, errors.Wrap(err, "encrypting keys blob")
 	}
 
-	out.KeysBlob = base64.RawURLEncoding.EncodeToString(keysBlob)
-	if modifiedAt.Valid {
-		out.ModifiedAt = &modifiedAt.Time
+	out, err := s.storeKeys(ctx, userID, in.ifMatch, sealed)
+	if err != nil {
+		return nil, err
 	}
-	return &out, nil
+	out.KeysBlob = base64.RawURLEncoding.EncodeToString(keysData)
+	return out, nil
 }
 
-func (s *Service) getKeys(ctx context.Context) (*encryptedKeysData, error) {
+func (s *Service) getKeys(ctx context.Context) (_ *encryptedKeysData, err error) {
 	userID := userID(ctx)
+	defer func() { auditLog(ctx, auditActionGet, userID, err) }()
 	if userID == "" {
 		return nil, probNotAuthorized
 	}
 
 	q := `
-		SELECT encrypted_keys_data, created_at, modified_at
+		SELECT ` + storedKeysColumns + `
 		FROM encrypted_keys
 		WHERE user_id = $1
 	`
-	var (
-		keysBlob   []byte
-		out        encryptedKeysData
-		modifiedAt pq.NullTime
-	)
-	err := s.db.QueryRowContext(ctx, q, userID).Scan(&keysBlob, &out.CreatedAt, &modifiedAt)
+	var keys storedKeys
+	err = keys.scan(s.db.QueryRowContext(ctx, q, userID))
 	if err != nil {
 		return nil, errors.Wrap(err, "getting keys blob")
 	}
-
-	out.KeysBlob = base64.RawURLEncoding.EncodeToString(keysBlob)
-	if modifiedAt.Valid {
-		out.ModifiedAt = &modifiedAt.Time
+	keysData, err := s.openStoredKeys(ctx, userID, keys)
+	if err != nil {
+		return nil, err
 	}
-	return &out, nil
+	return keys.encryptedKeysData(keysData), nil
 }
 
-func (s *Service) deleteKeys(ctx context.Context) error {
+func (s *Service) deleteKeys(ctx context.Context) (err error) {
 	userID := userID(ctx)
+	defer func() { auditLog(ctx, auditActionDelete, userID, err) }()
 	if userID == "" {
 		return probNotAuthorized
 	}
@@ -130,6 +123,6 @@ func (s *Service) deleteKeys(ctx context.Context) error {
 		DELETE FROM encrypted_keys
 		WHERE user_id = $1
 	`
-	_, err := s.db.ExecContext(ctx, q, userID)
+	_, err = s.db.ExecContext(ctx, q, userID)
 	return errors.Wrap(err, "deleting keys blob")
 }
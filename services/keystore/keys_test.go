@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/log"
 )
 
 func TestPutKeys(t *testing.T) {
@@ -20,7 +21,7 @@ func TestPutKeys(t *testing.T) {
 	defer conn.Close() // close db connection
 
 	ctx := withUserID(context.Background(), "test-user")
-	s := &Service{conn.DB, nil}
+	s := &Service{db: conn.DB, keyManager: newTestKeyManager(t)}
 
 	blob := `[{
 		"id": "test-id",
@@ -50,7 +51,7 @@ func TestGetKeys(t *testing.T) {
 	defer conn.Close() // close db connection
 
 	ctx := withUserID(context.Background(), "test-user")
-	s := &Service{conn.DB, nil}
+	s := &Service{db: conn.DB, keyManager: newTestKeyManager(t)}
 
 	blob := `[{
 		"id": "test-id",
@@ -85,7 +86,7 @@ func TestDeleteKeys(t *testing.T) {
 	defer conn.Close() // close db connection
 
 	ctx := withUserID(context.Background(), "test-user")
-	s := &Service{conn.DB, nil}
+	s := &Service{db: conn.DB, keyManager: newTestKeyManager(t)}
 
 	blob := `[{
 		"id": "test-id",
@@ -111,6 +112,228 @@ func TestDeleteKeys(t *testing.T) {
 	}
 }
 
+func TestKeysVersions(t *testing.T) {
+	db := openKeystoreDB(t)
+	defer db.Close() // drop test db
+
+	conn := db.Open()
+	defer conn.Close() // close db connection
+
+	ctx := withUserID(context.Background(), "test-user")
+	s := &Service{db: conn.DB, keyManager: newTestKeyManager(t)}
+
+	keysBlob := func(id string) string {
+		blob := `[{
+			"id": "` + id + `",
+			"salt": "test-salt",
+			"encrypterName": "test-encrypter-name",
+			"encryptedBlob": "test-encryptedblob"
+		}]`
+		return base64.RawURLEncoding.EncodeToString([]byte(blob))
+	}
+
+	// Blobs can't be stored based on a version when there is none:
+	version := 1
+	_, err := s.putKeys(ctx, putKeysRequest{KeysBlob: keysBlob("phone"), ifMatch: &version})
+	if !reflect.DeepEqual(err, probVersionConflict) {
+		t.Fatalf("got error %v, want %v", err, probVersionConflict)
+	}
+
+	got, err := s.putKeys(ctx, putKeysRequest{KeysBlob: keysBlob("phone")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 1 {
+		t.Errorf("got version %d, want 1", got.Version)
+	}
+
+	got, err = s.putKeys(ctx, putKeysRequest{KeysBlob: keysBlob("desktop"), ifMatch: &version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 2 {
+		t.Errorf("got version %d, want 2", got.Version)
+	}
+
+	// The phone didn't get the blob stored by the desktop:
+	_, err = s.putKeys(ctx, putKeysRequest{KeysBlob: keysBlob("phone-2"), ifMatch: &version})
+	if !reflect.DeepEqual(err, versionConflict(2)) {
+		t.Fatalf("got error %v, want %v", err, versionConflict(2))
+	}
+
+	versions, err := s.getKeysVersions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 1 {
+		t.Fatalf("got %d versions, want 1", len(versions.Versions))
+	}
+	if versions.Versions[0].Version != 1 {
+		t.Errorf("got version %d, want 1", versions.Versions[0].Version)
+	}
+	verifyKeysBlob(t, versions.Versions[0].KeysBlob, keysBlob("phone"))
+
+	got, err = s.restoreKeys(ctx, restoreKeysRequest{Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 {
+		t.Errorf("got version %d, want 3", got.Version)
+	}
+	verifyKeysBlob(t, got.KeysBlob, keysBlob("phone"))
+
+	got, err = s.getKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 {
+		t.Errorf("got version %d, want 3", got.Version)
+	}
+	verifyKeysBlob(t, got.KeysBlob, keysBlob("phone"))
+
+	_, err = s.restoreKeys(ctx, restoreKeysRequest{Version: 3})
+	if errors.Cause(err) != sql.ErrNoRows {
+		t.Errorf("got error %v, want %v", err, sql.ErrNoRows)
+	}
+
+	// Only the last versions are retained:
+	for i := 0; i < keysVersionsRetained; i++ {
+		_, err = s.putKeys(ctx, putKeysRequest{KeysBlob: keysBlob("desktop")})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err = s.getKeysVersions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != keysVersionsRetained {
+		t.Fatalf("got %d versions, want %d", len(versions.Versions), keysVersionsRetained)
+	}
+	if got := versions.Versions[len(versions.Versions)-1].Version; got != 3 {
+		t.Errorf("got oldest version %d, want 3", got)
+	}
+
+	// The versions are deleted along with the keys blob:
+	err = s.deleteKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err = s.getKeysVersions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 0 {
+		t.Errorf("got %d versions, want 0", len(versions.Versions))
+	}
+}
+
+func TestRotateKeys(t *testing.T) {
+	db := openKeystoreDB(t)
+	defer db.Close() // drop test db
+
+	conn := db.Open()
+	defer conn.Close() // close db connection
+
+	key1, key2 := newTestMasterKey(t), newTestMasterKey(t)
+	km1, err := NewLocalKeyManager(map[string][]byte{"1": key1}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Service{db: conn.DB, keyManager: km1}
+
+	blob := `[{
+		"id": "test-id",
+		"salt": "test-salt",
+		"encrypterName": "test-encrypter-name",
+		"encryptedBlob": "test-encryptedblob"
+	}]`
+	keysBlob := base64.RawURLEncoding.EncodeToString([]byte(blob))
+
+	ctx := withUserID(context.Background(), "test-user")
+	_, err = s.putKeys(ctx, putKeysRequest{KeysBlob: keysBlob})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A row stored before envelope encryption was enabled:
+	legacyCtx := withUserID(context.Background(), "legacy-user")
+	_, err = conn.DB.Exec(`INSERT INTO encrypted_keys (user_id, encrypted_keys_data) VALUES ($1, $2)`, "legacy-user", blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.keyManager, err = NewLocalKeyManager(map[string][]byte{"1": key1, "2": key2}, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.RotateKeys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d rotated keys blobs, want 2", n)
+	}
+
+	// Once rotated, the keys blobs don't need the previous master key:
+	s.keyManager, err = NewLocalKeyManager(map[string][]byte{"2": key2}, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ctx := range []context.Context{ctx, legacyCtx} {
+		got, err := s.getKeys(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verifyKeysBlob(t, got.KeysBlob, keysBlob)
+	}
+
+	n, err = s.RotateKeys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("got %d rotated keys blobs, want 0", n)
+	}
+}
+
+func TestKeysAuditLog(t *testing.T) {
+	logger := log.New()
+	done := logger.StartTest(log.InfoLevel)
+	ctx := log.Set(context.Background(), logger)
+	s := &Service{keyManager: newTestKeyManager(t)}
+
+	_, err := s.putKeys(withUserID(ctx, "test-user"), putKeysRequest{KeysBlob: "not a keys blob"})
+	if !reflect.DeepEqual(err, probInvalidKeysBlob) {
+		t.Fatalf("got error %v, want %v", err, probInvalidKeysBlob)
+	}
+	err = s.deleteKeys(ctx)
+	if !reflect.DeepEqual(err, probNotAuthorized) {
+		t.Fatalf("got error %v, want %v", err, probNotAuthorized)
+	}
+
+	logged := done()
+	if len(logged) != 2 {
+		t.Fatalf("got %d log entries, want 2", len(logged))
+	}
+	wantActions := []string{auditActionPut, auditActionDelete}
+	wantUserIDs := []string{"test-user", ""}
+	for i, entry := range logged {
+		if entry.Data["audit"] != true {
+			t.Errorf("entry %d: got audit=%v, want true", i, entry.Data["audit"])
+		}
+		if entry.Data["action"] != wantActions[i] {
+			t.Errorf("entry %d: got action=%v, want %s", i, entry.Data["action"], wantActions[i])
+		}
+		if entry.Data["user_id"] != wantUserIDs[i] {
+			t.Errorf("entry %d: got user_id=%v, want %q", i, entry.Data["user_id"], wantUserIDs[i])
+		}
+		if entry.Data["success"] != false {
+			t.Errorf("entry %d: got success=%v, want false", i, entry.Data["success"])
+		}
+	}
+}
+
 func verifyKeysBlob(t *testing.T, gotKeysBlob, inKeysBlob string) {
 	var gotEncryptedKeys, inEncryptedKeys []encryptedKeyData
 	gotKeysData, err := base64.RawURLEncoding.DecodeString(gotKeysBlob)
@@ -0,0 +1,257 @@
+package keystore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/render/problem"
+)
+
+// keysVersionsRetained is the number of previous versions of a keys blob kept
+// in encrypted_keys_versions, which can be restored with restoreKeys.
+const keysVersionsRetained = 20
+
+// storedKeysColumns are the columns scanned by storedKeys.scan, which are
+// shared by encrypted_keys and encrypted_keys_versions.
+const storedKeysColumns = "version, encrypted_keys_data, sealed_keys_data, wrapped_data_key, master_key_id, created_at, modified_at"
+
+// storedKeys is a version of a keys blob as stored in the database.
+type storedKeys struct {
+	Version int
+	// Plain is the keys blob of the rows stored before envelope encryption
+	// was enabled, in which case Sealed is empty.
+	Plain      []byte
+	Sealed     sealedKeysData
+	CreatedAt  time.Time
+	ModifiedAt pq.NullTime
+}
+
+func (k *storedKeys) scan(row interface{ Scan(...interface{}) error }) error {
+	var masterKeyID sql.NullString
+	err := row.Scan(&k.Version, &k.Plain, &k.Sealed.Data, &k.Sealed.WrappedKey, &masterKeyID, &k.CreatedAt, &k.ModifiedAt)
+	k.Sealed.MasterKeyID = masterKeyID.String
+	return err
+}
+
+// openStoredKeys decrypts a stored keys blob of the given user.
+func (s *Service) openStoredKeys(ctx context.Context, userID string, keys storedKeys) ([]byte, error) {
+	if keys.Sealed.MasterKeyID == "" {
+		return keys.Plain, nil
+	}
+	keysData, err := openKeysData(ctx, s.keyManager, userID, keys.Sealed)
+	return keysData, errors.Wrap(err, "decrypting keys blob")
+}
+
+func (k storedKeys) encryptedKeysData(keysData []byte) *encryptedKeysData {
+	out := &encryptedKeysData{
+		KeysBlob:  base64.RawURLEncoding.EncodeToString(keysData),
+		Version:   k.Version,
+		CreatedAt: k.CreatedAt,
+	}
+	if k.ModifiedAt.Valid {
+		out.ModifiedAt = &k.ModifiedAt.Time
+	}
+	return out
+}
+
+// storeKeys stores a new version of the keys blob of a user. The current
+// version, if any, is moved to encrypted_keys_versions. If ifMatch is set, the
+// keys blob is only stored if ifMatch is its current version. The returned
+// keys blob is empty, the caller already knows it.
+func (s *Service) storeKeys(ctx context.Context, userID string, ifMatch *int, sealed sealedKeysData) (_ *encryptedKeysData, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "storing keys blob")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var version int
+	err = tx.QueryRowContext(ctx, `
+		SELECT version
+		FROM encrypted_keys
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID).Scan(&version)
+	switch {
+	case err == sql.ErrNoRows:
+		if ifMatch != nil {
+			return nil, probVersionConflict
+		}
+
+		// A concurrent request may have stored the first version of the
+		// keys blob since the query above, in which case nothing is
+		// inserted and this request conflicts with it.
+		q := `
+			INSERT INTO encrypted_keys (user_id, sealed_keys_data, wrapped_data_key, master_key_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id) DO NOTHING
+			RETURNING version, created_at, modified_at
+		`
+		var out *encryptedKeysData
+		out, err = scanKeysVersion(tx.QueryRowContext(ctx, q, userID, sealed.Data, sealed.WrappedKey, sealed.MasterKeyID))
+		if err == sql.ErrNoRows {
+			return nil, probVersionConflict
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "storing keys blob")
+		}
+		return out, errors.Wrap(tx.Commit(), "storing keys blob")
+
+	case err != nil:
+		return nil, errors.Wrap(err, "getting keys blob version")
+	}
+
+	if ifMatch != nil && *ifMatch != version {
+		return nil, versionConflict(version)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO encrypted_keys_versions (user_id, `+storedKeysColumns+`)
+		SELECT user_id, `+storedKeysColumns+`
+		FROM encrypted_keys
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "archiving keys blob")
+	}
+
+	q := `
+		UPDATE encrypted_keys SET
+			version = version + 1,
+			encrypted_keys_data = NULL,
+			sealed_keys_data = $2,
+			wrapped_data_key = $3,
+			master_key_id = $4,
+			modified_at = NOW()
+		WHERE user_id = $1
+		RETURNING version, created_at, modified_at
+	`
+	out, err := scanKeysVersion(tx.QueryRowContext(ctx, q, userID, sealed.Data, sealed.WrappedKey, sealed.MasterKeyID))
+	if err != nil {
+		return nil, errors.Wrap(err, "storing keys blob")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM encrypted_keys_versions
+		WHERE user_id = $1 AND version < $2
+	`, userID, out.Version-keysVersionsRetained)
+	if err != nil {
+		return nil, errors.Wrap(err, "pruning keys blob versions")
+	}
+
+	return out, errors.Wrap(tx.Commit(), "storing keys blob")
+}
+
+func scanKeysVersion(row *sql.Row) (*encryptedKeysData, error) {
+	var (
+		out        encryptedKeysData
+		modifiedAt pq.NullTime
+	)
+	err := row.Scan(&out.Version, &out.CreatedAt, &modifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	if modifiedAt.Valid {
+		out.ModifiedAt = &modifiedAt.Time
+	}
+	return &out, nil
+}
+
+type keysVersionsResponse struct {
+	Versions []encryptedKeysData `json:"versions"`
+}
+
+// getKeysVersions returns the previous versions of the keys blob of the user,
+// newest first.
+func (s *Service) getKeysVersions(ctx context.Context) (_ *keysVersionsResponse, err error) {
+	userID := userID(ctx)
+	defer func() { auditLog(ctx, auditActionGetVersions, userID, err) }()
+	if userID == "" {
+		return nil, probNotAuthorized
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+storedKeysColumns+`
+		FROM encrypted_keys_versions
+		WHERE user_id = $1
+		ORDER BY version DESC
+	`, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting keys blob versions")
+	}
+	defer rows.Close()
+
+	out := &keysVersionsResponse{Versions: []encryptedKeysData{}}
+	for rows.Next() {
+		var keys storedKeys
+		if err = keys.scan(rows); err != nil {
+			return nil, errors.Wrap(err, "getting keys blob versions")
+		}
+		var keysData []byte
+		keysData, err = s.openStoredKeys(ctx, userID, keys)
+		if err != nil {
+			return nil, err
+		}
+		out.Versions = append(out.Versions, *keys.encryptedKeysData(keysData))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "getting keys blob versions")
+	}
+	return out, nil
+}
+
+type restoreKeysRequest struct {
+	Version int `json:"version"`
+
+	// ifMatch is the version of the keys blob the request is based on, set
+	// from the If-Match header.
+	ifMatch *int
+}
+
+// restoreKeys stores a previous version of the keys blob of the user as its
+// new version.
+func (s *Service) restoreKeys(ctx context.Context, in restoreKeysRequest) (_ *encryptedKeysData, err error) {
+	userID := userID(ctx)
+	defer func() { auditLog(ctx, auditActionRestore, userID, err) }()
+	if userID == "" {
+		return nil, probNotAuthorized
+	}
+
+	if in.Version == 0 {
+		return nil, problem.MakeInvalidFieldProblem("version", errRequiredField)
+	}
+
+	q := `
+		SELECT ` + storedKeysColumns + `
+		FROM encrypted_keys_versions
+		WHERE user_id = $1 AND version = $2
+	`
+	var keys storedKeys
+	err = keys.scan(s.db.QueryRowContext(ctx, q, userID, in.Version))
+	if err != nil {
+		return nil, errors.Wrap(err, "getting keys blob version")
+	}
+	keysData, err := s.openStoredKeys(ctx, userID, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := sealKeysData(ctx, s.keyManager, userID, keysData)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting keys blob")
+	}
+	out, err := s.storeKeys(ctx, userID, in.ifMatch, sealed)
+	if err != nil {
+		return nil, err
+	}
+	out.KeysBlob = base64.RawURLEncoding.EncodeToString(keysData)
+	return out, nil
+}
@@ -0,0 +1,39 @@
+-- +migrate Up
+
+-- Keys blobs are now stored in sealed_keys_data, encrypted with a data key
+-- which is itself wrapped with the master key master_key_id. Rows stored
+-- before keep their keys blob in encrypted_keys_data until they are
+-- encrypted by the key rotation command.
+ALTER TABLE public.encrypted_keys
+	ALTER COLUMN encrypted_keys_data DROP NOT NULL,
+	ADD COLUMN sealed_keys_data bytea,
+	ADD COLUMN wrapped_data_key bytea,
+	ADD COLUMN master_key_id text;
+
+CREATE INDEX encrypted_keys_master_key_id_idx ON public.encrypted_keys (master_key_id);
+
+-- +migrate Down
+
+-- The master keys are not available to the database, so the keys blobs that
+-- were sealed can't be decrypted back here. Reverting is refused while any
+-- keys blob is sealed rather than losing them.
+-- +migrate StatementBegin
+DO $$
+DECLARE
+	sealed integer;
+BEGIN
+	SELECT count(*) INTO sealed FROM public.encrypted_keys WHERE sealed_keys_data IS NOT NULL;
+	IF sealed > 0 THEN
+		RAISE EXCEPTION 'cannot revert envelope encryption: % keys blobs are sealed with a master key', sealed;
+	END IF;
+END
+$$;
+-- +migrate StatementEnd
+
+DROP INDEX public.encrypted_keys_master_key_id_idx;
+
+ALTER TABLE public.encrypted_keys
+	DROP COLUMN master_key_id,
+	DROP COLUMN wrapped_data_key,
+	DROP COLUMN sealed_keys_data,
+	ALTER COLUMN encrypted_keys_data SET NOT NULL;
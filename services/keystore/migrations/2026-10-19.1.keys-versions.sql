@@ -0,0 +1,27 @@
+-- +migrate Up
+
+-- version is incremented every time the keys blob of a user is stored, the
+-- previous versions being moved to encrypted_keys_versions.
+ALTER TABLE public.encrypted_keys
+	ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+CREATE TABLE public.encrypted_keys_versions (
+    user_id text NOT NULL REFERENCES public.encrypted_keys (user_id) ON DELETE CASCADE,
+    version integer NOT NULL,
+    encrypted_keys_data jsonb,
+    sealed_keys_data bytea,
+    wrapped_data_key bytea,
+    master_key_id text,
+    created_at timestamp with time zone NOT NULL,
+    modified_at timestamp with time zone,
+    PRIMARY KEY (user_id, version)
+);
+
+CREATE INDEX encrypted_keys_versions_master_key_id_idx ON public.encrypted_keys_versions (master_key_id);
+
+-- +migrate Down
+
+DROP TABLE public.encrypted_keys_versions;
+
+ALTER TABLE public.encrypted_keys
+	DROP COLUMN version;
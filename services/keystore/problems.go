@@ -22,7 +22,8 @@ var (
 		Title:  "Method Not Allowed",
 		Status: http.StatusMethodNotAllowed,
 		Detail: "This endpoint does not support the request method you used. " +
-			"The server supports HTTP GET/PUT/DELETE for the /keys endpoint.",
+			"The server supports HTTP GET/PUT/DELETE for the /keys endpoint, " +
+			"HTTP GET for the /keys/versions endpoint and HTTP POST for the /keys/restore endpoint.",
 	}
 
 	probInvalidKeysBlob = problem.P{
@@ -41,4 +42,20 @@ var (
 		Status: 401,
 		Detail: "Your request is not authorized.",
 	}
+
+	probVersionConflict = problem.P{
+		Type:   "version_conflict",
+		Title:  "Version Conflict",
+		Status: http.StatusPreconditionFailed,
+		Detail: "The keys blob was modified since the version your request is based on. " +
+			"Please get the current version of the keys blob, merge your changes into it and try again.",
+	}
 )
+
+// versionConflict returns probVersionConflict with the current version of the
+// keys blob.
+func versionConflict(currentVersion int) problem.P {
+	p := probVersionConflict
+	p.Extras = map[string]interface{}{"version": currentVersion}
+	return p
+}
@@ -18,6 +18,10 @@ type Config struct {
 	AUTHURL string
 
 	ListenerPort int
+
+	// MasterKeyFile is the path of the file holding the master keys used
+	// by LocalKeyManager.
+	MasterKeyFile string
 }
 
 type Authenticator struct {
@@ -29,8 +33,11 @@ type Authenticator struct {
 type Service struct {
 	db            *sql.DB
 	authenticator *Authenticator
+	keyManager    KeyManager
 }
 
-func NewService(ctx context.Context, db *sql.DB, authenticator *Authenticator) *Service {
-	return &Service{db: db, authenticator: authenticator}
+// NewService returns a Service storing the keys blobs in db, encrypted with
+// data keys wrapped by keyManager.
+func NewService(ctx context.Context, db *sql.DB, authenticator *Authenticator, keyManager KeyManager) *Service {
+	return &Service{db: db, authenticator: authenticator, keyManager: keyManager}
 }
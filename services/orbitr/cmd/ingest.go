@@ -6,13 +6,22 @@ import (
 	"go/types"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/metriqorg/go/historyarchive"
+	publicingest "github.com/metriqorg/go/ingest"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/ingest/sink"
 	orbitr "github.com/metriqorg/go/services/orbitr/internal"
 	"github.com/metriqorg/go/services/orbitr/internal/db2/history"
 	"github.com/metriqorg/go/services/orbitr/internal/ingest"
+	"github.com/metriqorg/go/services/orbitr/internal/ingest/processors"
+	apkg "github.com/metriqorg/go/support/app"
 	support "github.com/metriqorg/go/support/config"
 	"github.com/metriqorg/go/support/db"
 	"github.com/metriqorg/go/support/log"
@@ -435,7 +444,122 @@ var ingestBuildStateCmd = &cobra.Command{
 	},
 }
 
+var ingestSinkConfigPath string
+
+var ingestSinkCmdOpts = []*support.ConfigOption{
+	{
+		Name:        "sink-config",
+		ConfigKey:   &ingestSinkConfigPath,
+		OptType:     types.String,
+		Required:    true,
+		FlagDefault: "",
+		Usage:       "path to the TOML file configuring event formats and outputs",
+	},
+}
+
+var ingestSinkCmd = &cobra.Command{
+	Use:   "sink",
+	Short: "publishes transaction, operation, effect and ledger entry change events to Kafka, NATS, webhooks or stdout",
+	Long:  "follows the network with Captive Core and publishes the events of each ledger to the outputs configured in --sink-config. It does not write to OrbitR's DB.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, co := range ingestSinkCmdOpts {
+			if err := co.RequireE(); err != nil {
+				return err
+			}
+			co.SetValue()
+		}
+
+		if err := orbitr.ApplyFlags(config, flags, orbitr.ApplyOptions{RequireCaptiveCoreConfig: false, AlwaysIngest: true}); err != nil {
+			return err
+		}
+		if !config.EnableCaptiveCoreIngestion {
+			return fmt.Errorf("sink requires Captive Core ingestion")
+		}
+
+		sinkConfig, err := sink.ReadConfig(ingestSinkConfigPath)
+		if err != nil {
+			return fmt.Errorf("cannot read sink config: %v", err)
+		}
+		encoder, err := sink.NewEncoder(sinkConfig.Format)
+		if err != nil {
+			return err
+		}
+		events, err := sinkConfig.EventTypes()
+		if err != nil {
+			return err
+		}
+		outputs, err := sinkConfig.NewOutputs()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		archive, err := historyarchive.NewArchivePool(
+			config.HistoryArchiveURLs,
+			historyarchive.ConnectOptions{
+				Context:             ctx,
+				NetworkPassphrase:   config.NetworkPassphrase,
+				CheckpointFrequency: config.CheckpointFrequency,
+				UserAgent:           fmt.Sprintf("orbitr/%s golang/%s", apkg.Version(), runtime.Version()),
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("cannot create history archive: %v", err)
+		}
+
+		backend, err := ledgerbackend.NewCaptive(ledgerbackend.CaptiveCoreConfig{
+			BinaryPath:          config.CaptiveCoreBinaryPath,
+			StoragePath:         config.CaptiveCoreStoragePath,
+			UseDB:               config.CaptiveCoreConfigUseDB,
+			Toml:                config.CaptiveCoreToml,
+			NetworkPassphrase:   config.NetworkPassphrase,
+			HistoryArchiveURLs:  config.HistoryArchiveURLs,
+			CheckpointFrequency: config.CheckpointFrequency,
+			Log:                 log.WithField("subservice", "gravity"),
+			Context:             ctx,
+			UserAgent:           fmt.Sprintf("captivecore orbitr/%s golang/%s", apkg.Version(), runtime.Version()),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot create captive core backend: %v", err)
+		}
+		defer backend.Close()
+
+		eventSink, err := sink.New(sink.Config{
+			Backend:           backend,
+			Archive:           archive,
+			NetworkPassphrase: config.NetworkPassphrase,
+			Cursor:            publicingest.FileCursorStore{Path: sinkConfig.CursorFile},
+			StartLedger:       sinkConfig.StartLedger,
+			IncludeState:      sinkConfig.IncludeState,
+			Encoder:           encoder,
+			Outputs:           outputs,
+			Events:            events,
+			Effects:           processors.SinkEffects(config.NetworkPassphrase),
+		})
+		if err != nil {
+			return err
+		}
+		defer eventSink.Close()
+
+		log.Info("Starting event sink")
+		if err = eventSink.Run(ctx); err != nil {
+			return err
+		}
+		log.Info("Event sink stopped")
+		return nil
+	},
+}
+
 func init() {
+	for _, co := range ingestSinkCmdOpts {
+		err := co.Init(ingestSinkCmd)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+
 	for _, co := range ingestVerifyRangeCmdOpts {
 		err := co.Init(ingestVerifyRangeCmd)
 		if err != nil {
@@ -466,5 +590,6 @@ func init() {
 		ingestTriggerStateRebuildCmd,
 		ingestInitGenesisStateCmd,
 		ingestBuildStateCmd,
+		ingestSinkCmd,
 	)
 }
@@ -0,0 +1,35 @@
+package processors
+
+import (
+	"github.com/metriqorg/go/ingest"
+	"github.com/metriqorg/go/ingest/sink"
+	"github.com/metriqorg/go/protocols/orbitr/effects"
+	"github.com/metriqorg/go/toid"
+)
+
+// SinkEffects returns a sink.EffectsFunc computing the same effects as
+// EffectProcessor, so that event sinks publish OrbitR's effects.
+func SinkEffects(networkPassphrase string) sink.EffectsFunc {
+	return func(transaction ingest.LedgerTransaction, sequence uint32) ([]sink.EffectEvent, error) {
+		txEffects, err := operationsEffects(transaction, sequence, networkPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		events := make([]sink.EffectEvent, 0, len(txEffects))
+		for _, e := range txEffects {
+			account := e.address
+			if e.addressMuxed.Valid {
+				account = e.addressMuxed.String
+			}
+			events = append(events, sink.EffectEvent{
+				// Operation orders in TOIDs start at 1.
+				OperationIndex: uint32(toid.Parse(e.operationID).OperationOrder - 1),
+				Order:          e.order,
+				Type:           effects.EffectTypeNames[effects.EffectType(e.effectType)],
+				Account:        account,
+				Details:        e.details,
+			})
+		}
+		return events, nil
+	}
+}
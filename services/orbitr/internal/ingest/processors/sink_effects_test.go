@@ -0,0 +1,37 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/metriqorg/go/ingest/sink"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/xdr"
+)
+
+func TestSinkEffects(t *testing.T) {
+	source := "GAUJETIZVEP2NRYLUESJ3LS66NVCEGMON4UDCBCSBEVPIID773P2W6AY"
+	destination := "GACMZD5VJXTRLKVET72CETCYKELPNCOTTBDC6DHFEUPLG5DHEK534JQX"
+	tx := createTransaction(true, 2)
+	tx.Index = 1
+	tx.Envelope.V1.Tx.Operations[1] = xdr.Operation{
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypePayment,
+			PaymentOp: &xdr.PaymentOp{
+				Destination: xdr.MustMuxedAddress(destination),
+				Asset:       xdr.MustNewNativeAsset(),
+				Amount:      10000000,
+			},
+		},
+	}
+
+	events, err := SinkEffects(network.TestNetworkPassphrase)(tx, 20)
+	require.NoError(t, err)
+	details := map[string]interface{}{"amount": "10.000000", "asset_type": "native"}
+	assert.Equal(t, []sink.EffectEvent{
+		{OperationIndex: 1, Order: 1, Type: "account_credited", Account: destination, Details: details},
+		{OperationIndex: 1, Order: 2, Type: "account_debited", Account: source, Details: details},
+	}, events)
+}
@@ -84,6 +84,12 @@ func (c *ServeCommand) Command() *cobra.Command {
 			FlagDefault: "500",
 			Required:    true,
 		},
+		{
+			Name:      "rules-file",
+			Usage:     "JSON file configuring the compliance rules of the regulated asset, replacing the KYC required payment amount threshold rule",
+			OptType:   types.String,
+			ConfigKey: &opts.RulesFile,
+		},
 	}
 	cmd := &cobra.Command{
 		Use:   "serve",
@@ -0,0 +1,149 @@
+// Package compliance checks the payments of regulated assets against the
+// compliance rules configured by their issuer.
+package compliance
+
+import (
+	"context"
+	"time"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// Payment is a payment of a regulated asset submitted for approval.
+type Payment struct {
+	// TxSourceAccount and TxSequence identify the transaction of the payment.
+	// At most one transaction can ever be executed for a source account and
+	// sequence number.
+	TxSourceAccount string
+	TxSequence      int64
+	Source          string
+	Destination     string
+	AssetCode       string
+	AssetIssuer     string
+	Amount          int64
+}
+
+// Status is the SEP-8 status of a payment that doesn't comply with a rule.
+type Status string
+
+const (
+	StatusRejected       Status = "rejected"
+	StatusActionRequired Status = "action_required"
+	StatusPending        Status = "pending"
+)
+
+// Result describes why a payment doesn't comply with a rule.
+type Result struct {
+	// Rule is the name of the rule the payment doesn't comply with.
+	Rule         string
+	Status       Status
+	Message      string
+	ActionURL    string
+	ActionFields []string
+}
+
+// Rule is a compliance rule payments of an asset must comply with.
+type Rule interface {
+	// Name identifies the rule in the results it returns.
+	Name() string
+	// Check returns a result if the payment doesn't comply with the rule, or
+	// nil if it does.
+	Check(ctx context.Context, p Payment) (*Result, error)
+}
+
+// Engine checks payments against the rules configured for their asset.
+type Engine struct {
+	// Rules are the rules of each asset keyed by asset code, checked in order.
+	Rules map[string][]Rule
+}
+
+// Check returns the result of the first rule of the payment's asset that the
+// payment doesn't comply with, or nil if it complies with all of them.
+func (e Engine) Check(ctx context.Context, p Payment) (*Result, error) {
+	for _, r := range e.Rules[p.AssetCode] {
+		result, err := r.Check(ctx, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking rule %s", r.Name())
+		}
+		if result != nil {
+			result.Rule = r.Name()
+			return result, nil
+		}
+	}
+	return nil, nil
+}
+
+// KYCStatus is the status of the KYC of an account.
+type KYCStatus string
+
+const (
+	// KYCStatusRequested is the status of accounts that were asked to submit
+	// their KYC and haven't yet.
+	KYCStatusRequested KYCStatus = "requested"
+	KYCStatusApproved  KYCStatus = "approved"
+	KYCStatusRejected  KYCStatus = "rejected"
+	KYCStatusPending   KYCStatus = "pending"
+)
+
+// KYC is the KYC data of an account.
+type KYC struct {
+	Status KYCStatus
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country of the
+	// account, if submitted.
+	CountryCode string
+	// ActionURL is the URL the KYC data of the account is submitted to.
+	ActionURL string
+}
+
+// KYCStore provides the KYC data of accounts.
+type KYCStore interface {
+	// AccountKYC returns the KYC of the account, or nil if the account was
+	// never asked to submit its KYC.
+	AccountKYC(ctx context.Context, address string) (*KYC, error)
+	// RequestKYC returns the KYC of the account, asking the account to submit
+	// its KYC if it never was.
+	RequestKYC(ctx context.Context, address string) (*KYC, error)
+}
+
+// Approve records the payment as approved, unless it exceeds one of the
+// velocity limits of its asset, in which case the result of that limit is
+// returned and the payment isn't recorded. The limits are checked again when
+// the payment is recorded, since payments of the same source approved
+// concurrently may exceed them together even if each passed Check.
+func (e Engine) Approve(ctx context.Context, p Payment, payments PaymentStore) (*Result, error) {
+	var limits []VelocityLimit
+	for _, r := range e.Rules[p.AssetCode] {
+		if limit, ok := r.(VelocityLimit); ok {
+			limits = append(limits, limit)
+		}
+	}
+
+	exceeded, err := payments.RecordApprovedPayment(ctx, p, limits)
+	if err != nil {
+		return nil, errors.Wrap(err, "recording approved payment")
+	}
+	if exceeded == nil {
+		return nil, nil
+	}
+	result, err := exceeded.result(p)
+	if err != nil {
+		return nil, err
+	}
+	result.Rule = exceeded.Name()
+	return result, nil
+}
+
+// PaymentStore provides the payments approved previously.
+type PaymentStore interface {
+	// ApprovedVolume returns the total amount of the payments of the
+	// payment's asset by the payment's source approved since the given time,
+	// excluding the payment approved for the same transaction as the payment.
+	ApprovedVolume(ctx context.Context, p Payment, since time.Time) (int64, error)
+	// RecordApprovedPayment records the payment as approved, replacing the
+	// payment approved for the same transaction, unless the approved volume
+	// of its source including it would exceed one of the limits. It returns
+	// the limit exceeded, or nil if the payment was recorded. Checking the
+	// limits and recording the payment is atomic for the payments of a
+	// source.
+	RecordApprovedPayment(ctx context.Context, p Payment, limits []VelocityLimit) (*VelocityLimit, error)
+}
@@ -0,0 +1,146 @@
+package compliance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/support/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKYCStore is a KYCStore keeping the KYC of accounts in memory.
+type testKYCStore struct {
+	KYCs map[string]*KYC
+}
+
+func (s *testKYCStore) AccountKYC(ctx context.Context, address string) (*KYC, error) {
+	return s.KYCs[address], nil
+}
+
+func (s *testKYCStore) RequestKYC(ctx context.Context, address string) (*KYC, error) {
+	if s.KYCs[address] == nil {
+		s.KYCs[address] = &KYC{
+			Status:    KYCStatusRequested,
+			ActionURL: "https://example.com/kyc-status/" + address,
+		}
+	}
+	return s.KYCs[address], nil
+}
+
+// testPaymentStore is a PaymentStore with a fixed approved volume, keeping
+// the payments approved in memory.
+type testPaymentStore struct {
+	Volume   int64
+	Since    time.Time
+	Approved []Payment
+}
+
+func (s *testPaymentStore) ApprovedVolume(ctx context.Context, p Payment, since time.Time) (int64, error) {
+	s.Since = since
+	return s.Volume, nil
+}
+
+func (s *testPaymentStore) RecordApprovedPayment(ctx context.Context, p Payment, limits []VelocityLimit) (*VelocityLimit, error) {
+	for i, limit := range limits {
+		if limit.Exceeded(s.Volume, p) {
+			return &limits[i], nil
+		}
+	}
+	s.Approved = append(s.Approved, p)
+	return nil, nil
+}
+
+type testRule struct {
+	name   string
+	result *Result
+	err    error
+}
+
+func (r testRule) Name() string {
+	return r.name
+}
+
+func (r testRule) Check(ctx context.Context, p Payment) (*Result, error) {
+	return r.result, r.err
+}
+
+func TestEngineCheck(t *testing.T) {
+	ctx := context.Background()
+	e := Engine{
+		Rules: map[string][]Rule{
+			"GOAT": {
+				testRule{name: "first"},
+				testRule{name: "second", result: &Result{Status: StatusRejected, Message: "Second."}},
+				testRule{name: "third", result: &Result{Status: StatusPending, Message: "Third."}},
+			},
+			"FOO": {
+				testRule{name: "first"},
+			},
+		},
+	}
+
+	result, err := e.Check(ctx, Payment{AssetCode: "GOAT"})
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Rule: "second", Status: StatusRejected, Message: "Second."}, result)
+
+	result, err = e.Check(ctx, Payment{AssetCode: "FOO"})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	result, err = e.Check(ctx, Payment{AssetCode: "BAR"})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestEngineCheck_error(t *testing.T) {
+	e := Engine{
+		Rules: map[string][]Rule{
+			"GOAT": {
+				testRule{name: "first", err: errors.New("store unavailable")},
+				testRule{name: "second", result: &Result{Status: StatusRejected}},
+			},
+		},
+	}
+
+	_, err := e.Check(context.Background(), Payment{AssetCode: "GOAT"})
+	assert.EqualError(t, err, "checking rule first: store unavailable")
+}
+
+func TestEngineApprove(t *testing.T) {
+	ctx := context.Background()
+	paymentStore := &testPaymentStore{Volume: 600_000000}
+	e := Engine{
+		Rules: map[string][]Rule{
+			"GOAT": {
+				testRule{name: "first"},
+				VelocityLimit{MaxAmount: 10000_000000, Period: 24 * time.Hour, Payments: paymentStore},
+				VelocityLimit{MaxAmount: 1000_000000, Period: time.Hour, Payments: paymentStore},
+			},
+		},
+	}
+
+	// Payments within the velocity limits are recorded:
+	p := Payment{AssetCode: "GOAT", Amount: 400_000000}
+	result, err := e.Approve(ctx, p, paymentStore)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, []Payment{p}, paymentStore.Approved)
+
+	// Assets without velocity limits too:
+	result, err = e.Approve(ctx, Payment{AssetCode: "FOO", Amount: 1}, paymentStore)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Len(t, paymentStore.Approved, 2)
+
+	// Payments exceeding a limit aren't:
+	result, err = e.Approve(ctx, Payment{AssetCode: "GOAT", Amount: 400_000001}, paymentStore)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Rule:    "velocity_limit",
+		Status:  StatusRejected,
+		Message: "Your payments can't exceed 1000.00 GOAT every hour.",
+	}, result)
+	assert.Len(t, paymentStore.Approved, 2)
+}
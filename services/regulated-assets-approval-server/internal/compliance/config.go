@@ -0,0 +1,129 @@
+package compliance
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/metriqorg/go/amount"
+	"github.com/metriqorg/go/strkey"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// defaultVelocityLimitPeriod is the period of velocity limits configured
+// without one.
+const defaultVelocityLimitPeriod = 24 * time.Hour
+
+var rxCountryCode = regexp.MustCompile("^[A-Z]{2}$")
+
+// RuleConfig configures a rule in the rules file.
+type RuleConfig struct {
+	// Type is the name of the rule: kyc_threshold, velocity_limit,
+	// sanctioned_accounts, jurisdiction or allowed_counterparties.
+	Type string `json:"type"`
+	// Amount is the threshold of kyc_threshold and the max amount of
+	// velocity_limit.
+	Amount string `json:"amount"`
+	// PeriodSeconds is the period of velocity_limit, one day by default.
+	PeriodSeconds int64 `json:"period_seconds"`
+	// Accounts are the accounts of sanctioned_accounts and
+	// allowed_counterparties.
+	Accounts []string `json:"accounts"`
+	// BlockedCountryCodes are the ISO 3166-1 alpha-2 codes of the countries
+	// blocked by jurisdiction.
+	BlockedCountryCodes []string `json:"blocked_country_codes"`
+}
+
+// ParseRules parses the rules file, a JSON object with the list of rules of
+// each asset keyed by asset code, and returns the rules it configures.
+func ParseRules(rulesJSON []byte, kycStore KYCStore, paymentStore PaymentStore) (map[string][]Rule, error) {
+	configs := map[string][]RuleConfig{}
+	err := json.Unmarshal(rulesJSON, &configs)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing rules")
+	}
+
+	rules := make(map[string][]Rule, len(configs))
+	for assetCode, assetConfigs := range configs {
+		for i, c := range assetConfigs {
+			r, err := c.rule(kycStore, paymentStore)
+			if err != nil {
+				return nil, errors.Wrapf(err, "configuring rule %d of asset %s", i, assetCode)
+			}
+			rules[assetCode] = append(rules[assetCode], r)
+		}
+	}
+	return rules, nil
+}
+
+func (c RuleConfig) rule(kycStore KYCStore, paymentStore PaymentStore) (Rule, error) {
+	switch c.Type {
+	case "kyc_threshold":
+		threshold, err := c.amount()
+		if err != nil {
+			return nil, err
+		}
+		return KYCThreshold{Threshold: threshold, KYC: kycStore}, nil
+	case "velocity_limit":
+		maxAmount, err := c.amount()
+		if err != nil {
+			return nil, err
+		}
+		if c.PeriodSeconds < 0 {
+			return nil, errors.New("period cannot be negative")
+		}
+		period := time.Duration(c.PeriodSeconds) * time.Second
+		if period == 0 {
+			period = defaultVelocityLimitPeriod
+		}
+		return VelocityLimit{MaxAmount: maxAmount, Period: period, Payments: paymentStore}, nil
+	case "sanctioned_accounts":
+		err := c.validateAccounts()
+		if err != nil {
+			return nil, err
+		}
+		return SanctionedAccounts{Accounts: c.Accounts}, nil
+	case "jurisdiction":
+		countryCodes := make([]string, 0, len(c.BlockedCountryCodes))
+		for _, cc := range c.BlockedCountryCodes {
+			cc = strings.ToUpper(cc)
+			if !rxCountryCode.MatchString(cc) {
+				return nil, errors.Errorf("%q is not a valid ISO 3166-1 alpha-2 country code", cc)
+			}
+			countryCodes = append(countryCodes, cc)
+		}
+		return Jurisdictions{BlockedCountryCodes: countryCodes, KYC: kycStore}, nil
+	case "allowed_counterparties":
+		err := c.validateAccounts()
+		if err != nil {
+			return nil, err
+		}
+		return AllowedCounterparties{Accounts: c.Accounts}, nil
+	default:
+		return nil, errors.Errorf("rule type %q unrecognized", c.Type)
+	}
+}
+
+func (c RuleConfig) amount() (int64, error) {
+	a, err := amount.ParseInt64(c.Amount)
+	if err != nil {
+		return 0, errors.Wrapf(err, "%q cannot be parsed as a Stellar amount", c.Amount)
+	}
+	if a <= 0 {
+		return 0, errors.New("amount must be greater than zero")
+	}
+	return a, nil
+}
+
+func (c RuleConfig) validateAccounts() error {
+	if len(c.Accounts) == 0 {
+		return errors.New("no accounts configured but at least one is required")
+	}
+	for _, a := range c.Accounts {
+		if !strkey.IsValidEd25519PublicKey(a) {
+			return errors.Errorf("%q is not a valid Stellar account", a)
+		}
+	}
+	return nil
+}
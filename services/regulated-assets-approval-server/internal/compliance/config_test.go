@@ -0,0 +1,66 @@
+package compliance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	kycStore := &testKYCStore{}
+	paymentStore := &testPaymentStore{}
+	sanctioned := keypair.MustRandom().Address()
+	counterparty := keypair.MustRandom().Address()
+
+	rules, err := ParseRules([]byte(`{
+		"GOAT": [
+			{"type": "sanctioned_accounts", "accounts": ["`+sanctioned+`"]},
+			{"type": "jurisdiction", "blocked_country_codes": ["kp", "IR"]},
+			{"type": "velocity_limit", "amount": "10000"},
+			{"type": "velocity_limit", "amount": "1000", "period_seconds": 3600},
+			{"type": "kyc_threshold", "amount": "500"}
+		],
+		"FOO": [
+			{"type": "allowed_counterparties", "accounts": ["`+counterparty+`"]}
+		]
+	}`), kycStore, paymentStore)
+	require.NoError(t, err)
+
+	wantRules := map[string][]Rule{
+		"GOAT": {
+			SanctionedAccounts{Accounts: []string{sanctioned}},
+			Jurisdictions{BlockedCountryCodes: []string{"KP", "IR"}, KYC: kycStore},
+			VelocityLimit{MaxAmount: 10000_000000, Period: 24 * time.Hour, Payments: paymentStore},
+			VelocityLimit{MaxAmount: 1000_000000, Period: time.Hour, Payments: paymentStore},
+			KYCThreshold{Threshold: 500_000000, KYC: kycStore},
+		},
+		"FOO": {
+			AllowedCounterparties{Accounts: []string{counterparty}},
+		},
+	}
+	assert.Equal(t, wantRules, rules)
+}
+
+func TestParseRules_invalid(t *testing.T) {
+	testCases := []struct {
+		rulesJSON string
+		wantErr   string
+	}{
+		{`[]`, "parsing rules: json: cannot unmarshal array into Go value of type map[string][]compliance.RuleConfig"},
+		{`{"GOAT": [{"type": "unknown"}]}`, `configuring rule 0 of asset GOAT: rule type "unknown" unrecognized`},
+		{`{"GOAT": [{"type": "kyc_threshold", "amount": "0"}]}`, "configuring rule 0 of asset GOAT: amount must be greater than zero"},
+		{`{"GOAT": [{"type": "velocity_limit", "amount": "1", "period_seconds": -1}]}`, "configuring rule 0 of asset GOAT: period cannot be negative"},
+		{`{"GOAT": [{"type": "sanctioned_accounts"}]}`, "configuring rule 0 of asset GOAT: no accounts configured but at least one is required"},
+		{`{"GOAT": [{"type": "allowed_counterparties", "accounts": ["GABC"]}]}`, `configuring rule 0 of asset GOAT: "GABC" is not a valid Stellar account`},
+		{`{"GOAT": [{"type": "jurisdiction", "blocked_country_codes": ["FRA"]}]}`, `configuring rule 0 of asset GOAT: "FRA" is not a valid ISO 3166-1 alpha-2 country code`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.wantErr, func(t *testing.T) {
+			_, err := ParseRules([]byte(tc.rulesJSON), &testKYCStore{}, &testPaymentStore{})
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/metriqorg/go/amount"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// KYCThreshold requires the source account of payments exceeding the
+// threshold to have its KYC approved.
+type KYCThreshold struct {
+	Threshold int64
+	KYC       KYCStore
+}
+
+func (r KYCThreshold) Name() string {
+	return "kyc_threshold"
+}
+
+func (r KYCThreshold) Check(ctx context.Context, p Payment) (*Result, error) {
+	if p.Amount <= r.Threshold {
+		return nil, nil
+	}
+
+	kyc, err := r.KYC.RequestKYC(ctx, p.Source)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting source account KYC")
+	}
+
+	threshold, err := readableAmount(r.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kyc.Status {
+	case KYCStatusApproved:
+		return nil, nil
+	case KYCStatusRejected:
+		return &Result{
+			Status:  StatusRejected,
+			Message: fmt.Sprintf("Your KYC was rejected and you're not authorized for operations above %s %s.", threshold, p.AssetCode),
+		}, nil
+	case KYCStatusPending:
+		return &Result{
+			Status:  StatusPending,
+			Message: fmt.Sprintf("Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above %s %s.", threshold, p.AssetCode),
+		}, nil
+	default:
+		return &Result{
+			Status:       StatusActionRequired,
+			Message:      fmt.Sprintf("Payments exceeding %s %s require KYC approval. Please provide an email address.", threshold, p.AssetCode),
+			ActionURL:    kyc.ActionURL,
+			ActionFields: []string{"email_address"},
+		}, nil
+	}
+}
+
+// VelocityLimit limits the total amount of the payments approved for a source
+// account over a period.
+type VelocityLimit struct {
+	MaxAmount int64
+	Period    time.Duration
+	Payments  PaymentStore
+}
+
+func (r VelocityLimit) Name() string {
+	return "velocity_limit"
+}
+
+func (r VelocityLimit) Check(ctx context.Context, p Payment) (*Result, error) {
+	volume, err := r.Payments.ApprovedVolume(ctx, p, time.Now().Add(-r.Period))
+	if err != nil {
+		return nil, errors.Wrap(err, "getting source account approved payments volume")
+	}
+	if !r.Exceeded(volume, p) {
+		return nil, nil
+	}
+	return r.result(p)
+}
+
+// Exceeded reports whether approving the payment on top of the given approved
+// volume exceeds the limit.
+func (r VelocityLimit) Exceeded(volume int64, p Payment) bool {
+	return volume+p.Amount > r.MaxAmount
+}
+
+func (r VelocityLimit) result(p Payment) (*Result, error) {
+	maxAmount, err := readableAmount(r.MaxAmount)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Status:  StatusRejected,
+		Message: fmt.Sprintf("Your payments can't exceed %s %s every %s.", maxAmount, p.AssetCode, readablePeriod(r.Period)),
+	}, nil
+}
+
+// SanctionedAccounts rejects payments involving any of the accounts.
+type SanctionedAccounts struct {
+	Accounts []string
+}
+
+func (r SanctionedAccounts) Name() string {
+	return "sanctioned_accounts"
+}
+
+func (r SanctionedAccounts) Check(ctx context.Context, p Payment) (*Result, error) {
+	if contains(r.Accounts, p.TxSourceAccount) || contains(r.Accounts, p.Source) {
+		return &Result{
+			Status:  StatusRejected,
+			Message: fmt.Sprintf("Your account is not allowed to transact %s.", p.AssetCode),
+		}, nil
+	}
+	if contains(r.Accounts, p.Destination) {
+		return &Result{
+			Status:  StatusRejected,
+			Message: fmt.Sprintf("The destination account is not allowed to receive %s.", p.AssetCode),
+		}, nil
+	}
+	return nil, nil
+}
+
+// Jurisdictions rejects payments from or to accounts whose KYC country is one
+// of the blocked countries. The source account must submit its country before
+// its payments are approved.
+type Jurisdictions struct {
+	BlockedCountryCodes []string
+	KYC                 KYCStore
+}
+
+func (r Jurisdictions) Name() string {
+	return "jurisdiction"
+}
+
+func (r Jurisdictions) Check(ctx context.Context, p Payment) (*Result, error) {
+	sourceKYC, err := r.KYC.RequestKYC(ctx, p.Source)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting source account KYC")
+	}
+	if sourceKYC.CountryCode == "" {
+		return &Result{
+			Status:       StatusActionRequired,
+			Message:      fmt.Sprintf("Payments of %s require the country of your account. Please provide an email address and a country code.", p.AssetCode),
+			ActionURL:    sourceKYC.ActionURL,
+			ActionFields: []string{"email_address", "country_code"},
+		}, nil
+	}
+	if contains(r.BlockedCountryCodes, sourceKYC.CountryCode) {
+		return &Result{
+			Status:  StatusRejected,
+			Message: fmt.Sprintf("Accounts from %s are not allowed to transact %s.", sourceKYC.CountryCode, p.AssetCode),
+		}, nil
+	}
+
+	// The destination account can't be asked to submit its KYC when the
+	// source account submits a payment, so only the destination accounts
+	// that submitted their country are checked.
+	destinationKYC, err := r.KYC.AccountKYC(ctx, p.Destination)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting destination account KYC")
+	}
+	if destinationKYC != nil && contains(r.BlockedCountryCodes, destinationKYC.CountryCode) {
+		return &Result{
+			Status:  StatusRejected,
+			Message: fmt.Sprintf("Accounts from %s are not allowed to receive %s.", destinationKYC.CountryCode, p.AssetCode),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// AllowedCounterparties only approves payments to one of the accounts.
+type AllowedCounterparties struct {
+	Accounts []string
+}
+
+func (r AllowedCounterparties) Name() string {
+	return "allowed_counterparties"
+}
+
+func (r AllowedCounterparties) Check(ctx context.Context, p Payment) (*Result, error) {
+	if contains(r.Accounts, p.Destination) {
+		return nil, nil
+	}
+	return &Result{
+		Status:  StatusRejected,
+		Message: fmt.Sprintf("The destination account is not an allowed counterparty for %s.", p.AssetCode),
+	}, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// readableAmount converts an amount to a human readable string with two
+// decimals, from 5000000000 to 500.00.
+func readableAmount(a int64) (string, error) {
+	amountFloat, err := strconv.ParseFloat(amount.StringFromInt64(a), 64)
+	if err != nil {
+		return "", errors.Wrap(err, "converting amount from string to float")
+	}
+	return fmt.Sprintf("%.2f", amountFloat), nil
+}
+
+// readablePeriod converts a period to a human readable string in the largest
+// unit of days, hours, minutes or seconds it is a multiple of.
+func readablePeriod(d time.Duration) string {
+	units := []struct {
+		duration time.Duration
+		name     string
+	}{
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+	}
+	for _, u := range units {
+		if d%u.duration == 0 {
+			return pluralize(int64(d/u.duration), u.name)
+		}
+	}
+	return pluralize(int64(d/time.Second), "second")
+}
+
+func pluralize(n int64, unit string) string {
+	if n == 1 {
+		return unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,176 @@
+package compliance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKYCThreshold(t *testing.T) {
+	ctx := context.Background()
+	source := keypair.MustRandom().Address()
+	kycStore := &testKYCStore{KYCs: map[string]*KYC{}}
+	r := KYCThreshold{Threshold: 500_000000, KYC: kycStore}
+
+	// payments up to the threshold comply without KYC
+	result, err := r.Check(ctx, Payment{Source: source, AssetCode: "GOAT", Amount: 500_000000})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Empty(t, kycStore.KYCs)
+
+	// payments above the threshold require KYC
+	p := Payment{Source: source, AssetCode: "GOAT", Amount: 500_000001}
+	result, err = r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:       StatusActionRequired,
+		Message:      "Payments exceeding 500.00 GOAT require KYC approval. Please provide an email address.",
+		ActionURL:    "https://example.com/kyc-status/" + source,
+		ActionFields: []string{"email_address"},
+	}, result)
+
+	kycStore.KYCs[source].Status = KYCStatusRejected
+	result, err = r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:  StatusRejected,
+		Message: "Your KYC was rejected and you're not authorized for operations above 500.00 GOAT.",
+	}, result)
+
+	kycStore.KYCs[source].Status = KYCStatusPending
+	result, err = r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:  StatusPending,
+		Message: "Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above 500.00 GOAT.",
+	}, result)
+
+	kycStore.KYCs[source].Status = KYCStatusApproved
+	result, err = r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestVelocityLimit(t *testing.T) {
+	ctx := context.Background()
+	paymentStore := &testPaymentStore{Volume: 600_000000}
+	r := VelocityLimit{MaxAmount: 1000_000000, Period: 24 * time.Hour, Payments: paymentStore}
+
+	result, err := r.Check(ctx, Payment{AssetCode: "GOAT", Amount: 400_000000})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), paymentStore.Since, time.Minute)
+
+	result, err = r.Check(ctx, Payment{AssetCode: "GOAT", Amount: 400_000001})
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:  StatusRejected,
+		Message: "Your payments can't exceed 1000.00 GOAT every day.",
+	}, result)
+
+	r.Period = 6 * time.Hour
+	result, err = r.Check(ctx, Payment{AssetCode: "GOAT", Amount: 400_000001})
+	require.NoError(t, err)
+	assert.Equal(t, "Your payments can't exceed 1000.00 GOAT every 6 hours.", result.Message)
+}
+
+func TestSanctionedAccounts(t *testing.T) {
+	ctx := context.Background()
+	sanctioned := keypair.MustRandom().Address()
+	other := keypair.MustRandom().Address()
+	r := SanctionedAccounts{Accounts: []string{sanctioned}}
+
+	result, err := r.Check(ctx, Payment{TxSourceAccount: other, Source: other, Destination: other, AssetCode: "GOAT"})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	wantSourceResult := &Result{
+		Status:  StatusRejected,
+		Message: "Your account is not allowed to transact GOAT.",
+	}
+	result, err = r.Check(ctx, Payment{TxSourceAccount: sanctioned, Source: other, Destination: other, AssetCode: "GOAT"})
+	require.NoError(t, err)
+	assert.Equal(t, wantSourceResult, result)
+
+	result, err = r.Check(ctx, Payment{TxSourceAccount: other, Source: sanctioned, Destination: other, AssetCode: "GOAT"})
+	require.NoError(t, err)
+	assert.Equal(t, wantSourceResult, result)
+
+	result, err = r.Check(ctx, Payment{TxSourceAccount: other, Source: other, Destination: sanctioned, AssetCode: "GOAT"})
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:  StatusRejected,
+		Message: "The destination account is not allowed to receive GOAT.",
+	}, result)
+}
+
+func TestJurisdictions(t *testing.T) {
+	ctx := context.Background()
+	source := keypair.MustRandom().Address()
+	destination := keypair.MustRandom().Address()
+	kycStore := &testKYCStore{KYCs: map[string]*KYC{}}
+	r := Jurisdictions{BlockedCountryCodes: []string{"KP"}, KYC: kycStore}
+
+	// the source account must submit its country
+	p := Payment{Source: source, Destination: destination, AssetCode: "GOAT", Amount: 1}
+	result, err := r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:       StatusActionRequired,
+		Message:      "Payments of GOAT require the country of your account. Please provide an email address and a country code.",
+		ActionURL:    "https://example.com/kyc-status/" + source,
+		ActionFields: []string{"email_address", "country_code"},
+	}, result)
+
+	// destination accounts that didn't submit their country are allowed
+	kycStore.KYCs[source].CountryCode = "FR"
+	result, err = r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.NotContains(t, kycStore.KYCs, destination)
+
+	kycStore.KYCs[destination] = &KYC{Status: KYCStatusApproved, CountryCode: "KP"}
+	result, err = r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:  StatusRejected,
+		Message: "Accounts from KP are not allowed to receive GOAT.",
+	}, result)
+
+	kycStore.KYCs[source].CountryCode = "KP"
+	result, err = r.Check(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:  StatusRejected,
+		Message: "Accounts from KP are not allowed to transact GOAT.",
+	}, result)
+}
+
+func TestAllowedCounterparties(t *testing.T) {
+	ctx := context.Background()
+	counterparty := keypair.MustRandom().Address()
+	r := AllowedCounterparties{Accounts: []string{counterparty}}
+
+	result, err := r.Check(ctx, Payment{Destination: counterparty, AssetCode: "GOAT"})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	result, err = r.Check(ctx, Payment{Destination: keypair.MustRandom().Address(), AssetCode: "GOAT"})
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Status:  StatusRejected,
+		Message: "The destination account is not an allowed counterparty for GOAT.",
+	}, result)
+}
+
+func TestReadablePeriod(t *testing.T) {
+	assert.Equal(t, "day", readablePeriod(24*time.Hour))
+	assert.Equal(t, "7 days", readablePeriod(7*24*time.Hour))
+	assert.Equal(t, "hour", readablePeriod(time.Hour))
+	assert.Equal(t, "90 minutes", readablePeriod(90*time.Minute))
+	assert.Equal(t, "30 seconds", readablePeriod(30*time.Second))
+}
@@ -3,6 +3,7 @@
 // migrations/2021-05-05.0.initial.sql (162B)
 // migrations/2021-05-18.0.accounts-kyc-status.sql (414B)
 // migrations/2021-06-08.0.pending-kyc-status.sql (193B)
+// migrations/2026-10-19.0.compliance-rules.sql (705B)
 
 package dbmigrate
 
@@ -131,6 +132,26 @@ func migrations202106080PendingKycStatusSql() (*asset, error) {
 	return a, nil
 }
 
+var _migrations202610190ComplianceRulesSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x92\x41\x6e\xf2\x30\x10\x85\xf7\x3e\xc5\x2c\x41\x3f\xfc\x17\x60\x95\x12\x57\x42\x0d\x0e\x8a\x12\xb5\xac\x2c\x63\x46\xd4\x6a\x63\xbb\xf1\xb8\x40\x4f\x5f\xd5\xb4\x80\x68\x04\xea\xce\xd6\x3c\x7f\xe3\x79\xf3\xc6\x63\xf8\xd7\x9a\x4d\xa7\x08\xa1\xf1\x8c\x65\x45\xcd\x2b\xa8\xb3\xbb\x82\x83\x8f\xab\x57\xa3\xff\x2b\xad\x5d\xb4\x14\xe4\xcb\x5e\xcb\x40\x8a\x62\x60\x00\x00\x59\x9e\xc3\xb4\x2c\x9a\xb9\x80\x24\xe8\xf6\x52\xbb\x35\x02\xe1\x8e\x26\x8c\x4d\x2b\x9e\xd5\xfc\x02\xe5\x7d\xe7\xde\x71\x2d\xbd\xda\xb7\x68\x29\xc0\x20\xa1\x68\x27\x83\x8b\x9d\x46\xf9\xdd\x2c\x41\x40\x94\x35\x88\xa6\x28\x46\x47\x11\xbe\x45\xb4\x1a\x61\x65\x36\xc6\x5e\x0a\x6e\x23\xd6\x18\xc8\x58\x45\xc6\xd9\x6b\x32\x15\x02\xd2\x69\x98\xde\xaa\x09\x21\x62\xd7\x5b\x6f\x13\xb7\xf7\x8b\x47\x03\x14\x01\x99\x16\x03\xa9\xd6\xc3\xd6\xd0\x73\xba\xc2\x87\xb3\x78\x7c\x02\x39\xbf\xcf\x9a\xa2\x06\x51\x3e\x0e\x86\x07\xf8\xa2\x9a\xcd\xb3\x6a\x09\x0f\x7c\x09\x83\x5f\xb6\x8d\xce\x4d\x1a\xb2\xe1\x69\x0d\x33\x91\xf3\x27\x28\xc5\x95\x4d\x5c\xa2\x4e\x2e\xfc\x9c\x0f\x33\x8f\xce\xa7\xf8\x6a\x71\x9e\xa1\xdc\x6d\x2d\x63\x79\x55\x2e\x6e\x6c\x7e\xf2\xa7\xac\x25\x60\x4f\xd8\x26\xec\x13\x00\x00\xff\xff\x03\x00\xe6\xd6\xf2\xea\xc1\x02\x00\x00")
+
+func migrations202610190ComplianceRulesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations202610190ComplianceRulesSql,
+		"migrations/2026-10-19.0.compliance-rules.sql",
+	)
+}
+
+func migrations202610190ComplianceRulesSql() (*asset, error) {
+	bytes, err := migrations202610190ComplianceRulesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/2026-10-19.0.compliance-rules.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x77, 0x2e, 0xde, 0x20, 0x34, 0xc5, 0xc0, 0x40, 0xa6, 0xff, 0xa, 0xe1, 0x9d, 0x85, 0xfd, 0x0, 0x4b, 0x23, 0xdc, 0xc7, 0x6f, 0xef, 0x65, 0x2a, 0x28, 0xb3, 0xe8, 0x24, 0x9c, 0x3a, 0x5, 0xb2}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -225,6 +246,7 @@ var _bindata = map[string]func() (*asset, error){
 	"migrations/2021-05-05.0.initial.sql":             migrations202105050InitialSql,
 	"migrations/2021-05-18.0.accounts-kyc-status.sql": migrations202105180AccountsKycStatusSql,
 	"migrations/2021-06-08.0.pending-kyc-status.sql":  migrations202106080PendingKycStatusSql,
+	"migrations/2026-10-19.0.compliance-rules.sql":    migrations202610190ComplianceRulesSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -274,6 +296,7 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"2021-05-05.0.initial.sql":             {migrations202105050InitialSql, map[string]*bintree{}},
 		"2021-05-18.0.accounts-kyc-status.sql": {migrations202105180AccountsKycStatusSql, map[string]*bintree{}},
 		"2021-06-08.0.pending-kyc-status.sql":  {migrations202106080PendingKycStatusSql, map[string]*bintree{}},
+		"2026-10-19.0.compliance-rules.sql":    {migrations202610190ComplianceRulesSql, map[string]*bintree{}},
 	}},
 }}
 
@@ -0,0 +1,25 @@
+-- +migrate Up
+
+ALTER TABLE public.accounts_kyc_status
+    ADD COLUMN country_code text;
+
+CREATE TABLE public.approved_payments (
+    tx_source_account text NOT NULL,
+    tx_sequence bigint NOT NULL,
+    source_account text NOT NULL,
+    destination_account text NOT NULL,
+    asset_code text NOT NULL,
+    asset_issuer text NOT NULL,
+    amount bigint NOT NULL,
+    approved_at timestamp with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tx_source_account, tx_sequence)
+);
+
+CREATE INDEX ON public.approved_payments (source_account, asset_code, asset_issuer, approved_at);
+
+-- +migrate Down
+
+DROP TABLE public.approved_payments;
+
+ALTER TABLE public.accounts_kyc_status
+    DROP COLUMN country_code;
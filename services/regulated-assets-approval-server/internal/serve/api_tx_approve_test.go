@@ -266,6 +266,7 @@ func TestAPI_txAprove_actionRequired(t *testing.T) {
 		ActionURL:    "https://example.com/kyc-status/" + callbackID,
 		ActionMethod: "POST",
 		ActionFields: []string{"email_address"},
+		Rule:         "kyc_threshold",
 	}
 	assert.Equal(t, wantTxApprovalResponse, gotTxApprovalResponse)
 }
@@ -359,6 +360,7 @@ func TestAPI_txAprove_actionRequiredFlow(t *testing.T) {
 		ActionURL:    "https://example.com/kyc-status/" + callbackID,
 		ActionMethod: "POST",
 		ActionFields: []string{"email_address"},
+		Rule:         "kyc_threshold",
 	}
 	assert.Equal(t, wantTxApprovalResponse, gotTxApprovalResponse)
 
@@ -425,7 +427,8 @@ func TestAPI_txAprove_actionRequiredFlow(t *testing.T) {
 	require.NoError(t, err)
 	wantBody = `{
 		"status": "rejected",
-		"error": "Your KYC was rejected and you're not authorized for operations above 500.00 GOAT."
+		"error": "Your KYC was rejected and you're not authorized for operations above 500.00 GOAT.",
+		"rule": "kyc_threshold"
 	}`
 	require.JSONEq(t, wantBody, string(body))
 
@@ -458,7 +461,8 @@ func TestAPI_txAprove_actionRequiredFlow(t *testing.T) {
 	wantBody = `{
 		"status": "pending",
 		"message": "Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above 500.00 GOAT.",
-		"timeout": 0
+		"timeout": 0,
+		"rule": "kyc_threshold"
 	}`
 	require.JSONEq(t, wantBody, string(body))
 }
@@ -0,0 +1,160 @@
+package serve
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/compliance"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// complianceStore keeps the KYC of accounts and the approved payments the
+// compliance rules are checked against in the database.
+type complianceStore struct {
+	db      *sqlx.DB
+	baseURL string
+}
+
+func (s complianceStore) AccountKYC(ctx context.Context, address string) (*compliance.KYC, error) {
+	const q = `
+		SELECT callback_id, approved_at, rejected_at, pending_at, country_code
+		FROM accounts_kyc_status
+		WHERE stellar_address = $1
+	`
+	kyc, err := s.scanKYC(s.db.QueryRowContext(ctx, q, address))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "querying accounts_kyc_status table")
+	}
+	return kyc, nil
+}
+
+func (s complianceStore) RequestKYC(ctx context.Context, address string) (*compliance.KYC, error) {
+	intendedCallbackID := uuid.New().String()
+	const q = `
+		WITH new_row AS (
+			INSERT INTO accounts_kyc_status (stellar_address, callback_id)
+			VALUES ($1, $2)
+			ON CONFLICT(stellar_address) DO NOTHING
+			RETURNING *
+		)
+		SELECT callback_id, approved_at, rejected_at, pending_at, country_code FROM new_row
+		UNION
+		SELECT callback_id, approved_at, rejected_at, pending_at, country_code
+		FROM accounts_kyc_status
+		WHERE stellar_address = $1
+	`
+	kyc, err := s.scanKYC(s.db.QueryRowContext(ctx, q, address, intendedCallbackID))
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting new row into accounts_kyc_status table")
+	}
+	return kyc, nil
+}
+
+func (s complianceStore) scanKYC(row *sql.Row) (*compliance.KYC, error) {
+	var (
+		callbackID                        string
+		approvedAt, rejectedAt, pendingAt sql.NullTime
+		countryCode                       sql.NullString
+	)
+	err := row.Scan(&callbackID, &approvedAt, &rejectedAt, &pendingAt, &countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	kyc := &compliance.KYC{
+		Status:      compliance.KYCStatusRequested,
+		CountryCode: countryCode.String,
+		ActionURL:   fmt.Sprintf("%s/kyc-status/%s", s.baseURL, callbackID),
+	}
+	switch {
+	case approvedAt.Valid:
+		kyc.Status = compliance.KYCStatusApproved
+	case rejectedAt.Valid:
+		kyc.Status = compliance.KYCStatusRejected
+	case pendingAt.Valid:
+		kyc.Status = compliance.KYCStatusPending
+	}
+	return kyc, nil
+}
+
+func (s complianceStore) ApprovedVolume(ctx context.Context, p compliance.Payment, since time.Time) (int64, error) {
+	return approvedVolume(ctx, s.db, p, since)
+}
+
+func approvedVolume(ctx context.Context, q sqlx.QueryerContext, p compliance.Payment, since time.Time) (int64, error) {
+	const query = `
+		SELECT COALESCE(SUM(amount), 0)::bigint
+		FROM approved_payments
+		WHERE source_account = $1
+			AND asset_code = $2
+			AND asset_issuer = $3
+			AND approved_at >= $4
+			AND NOT (tx_source_account = $5 AND tx_sequence = $6)
+	`
+	var volume int64
+	err := q.QueryRowxContext(ctx, query, p.Source, p.AssetCode, p.AssetIssuer, since, p.TxSourceAccount, p.TxSequence).Scan(&volume)
+	if err != nil {
+		return 0, errors.Wrap(err, "querying approved_payments table")
+	}
+	return volume, nil
+}
+
+// RecordApprovedPayment records a payment as approved if it doesn't exceed
+// the limits. A payment approved previously for the same transaction source
+// account and sequence number is replaced, since only one of them can be
+// executed.
+//
+// The payments of a source account are approved one at a time: the
+// transaction holds an advisory lock on the source account until it commits,
+// so the volume checked includes the payments approved concurrently.
+func (s complianceStore) RecordApprovedPayment(ctx context.Context, p compliance.Payment, limits []compliance.VelocityLimit) (*compliance.VelocityLimit, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "beginning transaction")
+	}
+	defer tx.Rollback()
+
+	if len(limits) > 0 {
+		_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", p.Source)
+		if err != nil {
+			return nil, errors.Wrap(err, "locking source account")
+		}
+	}
+	now := time.Now()
+	for i, limit := range limits {
+		volume, err := approvedVolume(ctx, tx, p, now.Add(-limit.Period))
+		if err != nil {
+			return nil, err
+		}
+		if limit.Exceeded(volume, p) {
+			return &limits[i], nil
+		}
+	}
+
+	const q = `
+		INSERT INTO approved_payments (tx_source_account, tx_sequence, source_account, destination_account, asset_code, asset_issuer, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tx_source_account, tx_sequence) DO UPDATE SET
+			source_account = EXCLUDED.source_account,
+			destination_account = EXCLUDED.destination_account,
+			asset_code = EXCLUDED.asset_code,
+			asset_issuer = EXCLUDED.asset_issuer,
+			amount = EXCLUDED.amount,
+			approved_at = NOW()
+	`
+	_, err = tx.ExecContext(ctx, q, p.TxSourceAccount, p.TxSequence, p.Source, p.Destination, p.AssetCode, p.AssetIssuer, p.Amount)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting into approved_payments table")
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing transaction")
+	}
+	return nil, nil
+}
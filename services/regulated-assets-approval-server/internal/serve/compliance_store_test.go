@@ -0,0 +1,111 @@
+package serve
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/compliance"
+	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/db/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplianceStore_RecordApprovedPayment(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+	defer db.Close()
+	conn := db.Open()
+	defer conn.Close()
+	s := complianceStore{db: conn, baseURL: "https://example.com"}
+
+	source := keypair.MustRandom().Address()
+	p := compliance.Payment{
+		TxSourceAccount: source,
+		TxSequence:      1,
+		Source:          source,
+		Destination:     keypair.MustRandom().Address(),
+		AssetCode:       "FOO",
+		AssetIssuer:     keypair.MustRandom().Address(),
+		Amount:          600,
+	}
+	limits := []compliance.VelocityLimit{
+		{MaxAmount: 10000, Period: 24 * time.Hour},
+		{MaxAmount: 1000, Period: time.Hour},
+	}
+
+	exceeded, err := s.RecordApprovedPayment(ctx, p, limits)
+	require.NoError(t, err)
+	assert.Nil(t, exceeded)
+
+	// A payment for the same transaction replaces the previous one, so it
+	// isn't counted in the volume:
+	exceeded, err = s.RecordApprovedPayment(ctx, p, limits)
+	require.NoError(t, err)
+	assert.Nil(t, exceeded)
+
+	// Another payment exceeding a limit isn't recorded:
+	p.TxSequence = 2
+	exceeded, err = s.RecordApprovedPayment(ctx, p, limits)
+	require.NoError(t, err)
+	assert.Equal(t, &limits[1], exceeded)
+
+	volume, err := s.ApprovedVolume(ctx, compliance.Payment{Source: source, AssetCode: p.AssetCode, AssetIssuer: p.AssetIssuer}, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), volume)
+
+	// Payments are recorded without limits:
+	exceeded, err = s.RecordApprovedPayment(ctx, p, nil)
+	require.NoError(t, err)
+	assert.Nil(t, exceeded)
+	volume, err = s.ApprovedVolume(ctx, compliance.Payment{Source: source, AssetCode: p.AssetCode, AssetIssuer: p.AssetIssuer}, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1200), volume)
+}
+
+func TestComplianceStore_RecordApprovedPaymentConcurrent(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+	defer db.Close()
+	conn := db.Open()
+	defer conn.Close()
+	s := complianceStore{db: conn, baseURL: "https://example.com"}
+
+	source := keypair.MustRandom().Address()
+	destination := keypair.MustRandom().Address()
+	issuer := keypair.MustRandom().Address()
+	limits := []compliance.VelocityLimit{{MaxAmount: 500, Period: time.Hour}}
+
+	// Only 5 of the payments fit in the limit, even if they are all approved
+	// at the same time.
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(sequence int64) {
+			defer wg.Done()
+			_, err := s.RecordApprovedPayment(ctx, compliance.Payment{
+				TxSourceAccount: source,
+				TxSequence:      sequence,
+				Source:          source,
+				Destination:     destination,
+				AssetCode:       "FOO",
+				AssetIssuer:     issuer,
+				Amount:          100,
+			}, limits)
+			errs <- err
+		}(int64(i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var count int
+	err := conn.GetContext(ctx, &count, "SELECT COUNT(*) FROM approved_payments WHERE source_account = $1", source)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
@@ -18,6 +18,7 @@ type kycGetResponse struct {
 	StellarAddress string     `json:"stellar_address"`
 	CallbackID     string     `json:"callback_id"`
 	EmailAddress   string     `json:"email_address,omitempty"`
+	CountryCode    string     `json:"country_code,omitempty"`
 	CreatedAt      *time.Time `json:"created_at"`
 	KYCSubmittedAt *time.Time `json:"kyc_submitted_at,omitempty"`
 	ApprovedAt     *time.Time `json:"approved_at,omitempty"`
@@ -83,16 +84,16 @@ func (h GetDetailHandler) handle(ctx context.Context, in getDetailRequest) (*kyc
 	// Prepare SELECT query return values.
 	var (
 		stellarAddress, callbackID                        string
-		emailAddress                                      sql.NullString
+		emailAddress, countryCode                         sql.NullString
 		createdAt                                         time.Time
 		kycSubmittedAt, approvedAt, rejectedAt, pendingAt sql.NullTime
 	)
 	const q = `
-		SELECT stellar_address, email_address, created_at, kyc_submitted_at, approved_at, rejected_at, pending_at, callback_id
+		SELECT stellar_address, email_address, country_code, created_at, kyc_submitted_at, approved_at, rejected_at, pending_at, callback_id
 		FROM accounts_kyc_status
 		WHERE stellar_address = $1 OR callback_id = $1
 	`
-	err := h.DB.QueryRowContext(ctx, q, in.StellarAddressOrCallbackID).Scan(&stellarAddress, &emailAddress, &createdAt, &kycSubmittedAt, &approvedAt, &rejectedAt, &pendingAt, &callbackID)
+	err := h.DB.QueryRowContext(ctx, q, in.StellarAddressOrCallbackID).Scan(&stellarAddress, &emailAddress, &countryCode, &createdAt, &kycSubmittedAt, &approvedAt, &rejectedAt, &pendingAt, &callbackID)
 	if err == sql.ErrNoRows {
 		return nil, httperror.NewHTTPError(http.StatusNotFound, "Not found.")
 	}
@@ -104,6 +105,7 @@ func (h GetDetailHandler) handle(ctx context.Context, in getDetailRequest) (*kyc
 		StellarAddress: stellarAddress,
 		CallbackID:     callbackID,
 		EmailAddress:   emailAddress.String,
+		CountryCode:    countryCode.String,
 		CreatedAt:      &createdAt,
 		KYCSubmittedAt: timePointerIfValid(kycSubmittedAt),
 		ApprovedAt:     timePointerIfValid(approvedAt),
@@ -18,6 +18,9 @@ import (
 type kycPostRequest struct {
 	CallbackID   string `path:"callback_id"`
 	EmailAddress string `json:"email_address"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country of the
+	// account, required by the jurisdiction compliance rule.
+	CountryCode string `json:"country_code"`
 }
 
 type kycPostResponse struct {
@@ -90,6 +93,10 @@ func (h PostHandler) handle(ctx context.Context, in kycPostRequest) (*kycPostRes
 	if !RxEmail.MatchString(in.EmailAddress) {
 		return nil, httperror.NewHTTPError(http.StatusBadRequest, "The provided email_address is invalid.")
 	}
+	in.CountryCode = strings.ToUpper(in.CountryCode)
+	if in.CountryCode != "" && !RxCountryCode.MatchString(in.CountryCode) {
+		return nil, httperror.NewHTTPError(http.StatusBadRequest, "The provided country_code is invalid.")
+	}
 
 	var exists bool
 	query, args := in.buildUpdateKYCQuery()
@@ -130,6 +137,11 @@ func (in kycPostRequest) buildUpdateKYCQuery() (string, []interface{}) {
 	args = append(args, in.EmailAddress)
 	query.WriteString(fmt.Sprintf("email_address = $%d, ", len(args)))
 
+	if in.CountryCode != "" {
+		args = append(args, in.CountryCode)
+		query.WriteString(fmt.Sprintf("country_code = $%d, ", len(args)))
+	}
+
 	// update KYC status to rejected, pending or approved
 	if in.isKYCRejected() {
 		query.WriteString("rejected_at = NOW(), pending_at = NULL, approved_at = NULL ")
@@ -156,3 +168,6 @@ func (in kycPostRequest) buildUpdateKYCQuery() (string, []interface{}) {
 // RxEmail is a regex used to validate e-mail addresses, according with the reference https://www.alexedwards.net/blog/validation-snippets-for-go#email-validation.
 // It's free to use under the [MIT License](https://opensource.org/licenses/MIT)
 var RxEmail = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+
+// RxCountryCode is a regex used to validate ISO 3166-1 alpha-2 country codes.
+var RxCountryCode = regexp.MustCompile("^[A-Z]{2}$")
@@ -93,6 +93,18 @@ func TestBuildUpdateKYCQuery(t *testing.T) {
 	expectedArgs = []interface{}{in.EmailAddress, in.CallbackID}
 	require.Equal(t, expectedQuery, query)
 	require.Equal(t, expectedArgs, args)
+
+	// test approved query with country code
+	in = kycPostRequest{
+		CallbackID:   "1234567890-12345",
+		EmailAddress: "test@email.com",
+		CountryCode:  "US",
+	}
+	query, args = in.buildUpdateKYCQuery()
+	expectedQuery = "WITH updated_row AS (UPDATE accounts_kyc_status SET kyc_submitted_at = NOW(), email_address = $1, country_code = $2, rejected_at = NULL, pending_at = NULL, approved_at = NOW() WHERE callback_id = $3 RETURNING * )\n\t\tSELECT EXISTS(\n\t\t\tSELECT * FROM updated_row\n\t\t)\n\t"
+	expectedArgs = []interface{}{in.EmailAddress, in.CountryCode, in.CallbackID}
+	require.Equal(t, expectedQuery, query)
+	require.Equal(t, expectedArgs, args)
 }
 
 func TestPostHandler_handle_error(t *testing.T) {
@@ -127,6 +139,16 @@ func TestPostHandler_handle_error(t *testing.T) {
 	require.Nil(t, kycPostResp)
 	require.Equal(t, httperror.NewHTTPError(http.StatusBadRequest, "The provided email_address is invalid."), err)
 
+	// invalid country_code
+	in = kycPostRequest{
+		CallbackID:   "random-callback-id",
+		EmailAddress: "email@test.com",
+		CountryCode:  "USA",
+	}
+	kycPostResp, err = handler.handle(ctx, in)
+	require.Nil(t, kycPostResp)
+	require.Equal(t, httperror.NewHTTPError(http.StatusBadRequest, "The provided country_code is invalid."), err)
+
 	// no entry found for the given callbackID
 	in = kycPostRequest{
 		CallbackID:   "random-callback-id",
@@ -228,3 +250,17 @@ func TestRxEmail(t *testing.T) {
 	// Test correct email.
 	assert.Regexp(t, RxEmail, "t@email.com")
 }
+
+func TestRxCountryCode(t *testing.T) {
+	// Test empty country code string.
+	assert.NotRegexp(t, RxCountryCode, "")
+
+	// Test alpha-3 country code.
+	assert.NotRegexp(t, RxCountryCode, "USA")
+
+	// Test lowercase country code.
+	assert.NotRegexp(t, RxCountryCode, "us")
+
+	// Test correct country code.
+	assert.Regexp(t, RxCountryCode, "US")
+}
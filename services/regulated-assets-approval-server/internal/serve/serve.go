@@ -2,6 +2,7 @@ package serve
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
@@ -9,9 +10,11 @@ import (
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/jmoiron/sqlx"
 	"github.com/metriqorg/go/amount"
 	"github.com/metriqorg/go/clients/orbitrclient"
 	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/compliance"
 	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/db"
 	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/serve/kycstatus"
 	"github.com/metriqorg/go/support/errors"
@@ -30,6 +33,10 @@ type Options struct {
 	KYCRequiredPaymentAmountThreshold string
 	NetworkPassphrase                 string
 	Port                              int
+	// RulesFile is a JSON file configuring the compliance rules of the asset.
+	// If not set payments exceeding the KYCRequiredPaymentAmountThreshold
+	// require KYC approval.
+	RulesFile string
 }
 
 func Serve(opts Options) {
@@ -71,6 +78,11 @@ func handleHTTP(opts Options) http.Handler {
 	if err != nil {
 		log.Warn("Error pinging to Database: ", err)
 	}
+	complianceRules, err := opts.complianceRules(db)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "configuring compliance rules"))
+	}
+
 	mux := chi.NewMux()
 
 	mux.Use(middleware.RequestID)
@@ -102,6 +114,7 @@ func handleHTTP(opts Options) http.Handler {
 		db:                db,
 		kycThreshold:      parsedKYCRequiredPaymentThreshold,
 		baseURL:           opts.BaseURL,
+		compliance:        compliance.Engine{Rules: complianceRules},
 	}.ServeHTTP)
 	mux.Route("/kyc-status", func(mux chi.Router) {
 		mux.Post("/{callback_id}", kycstatus.PostHandler{
@@ -125,6 +138,29 @@ func (opts Options) orbitrClient() orbitrclient.ClientInterface {
 	}
 }
 
+// complianceRules returns the compliance rules configured in the rules file,
+// or nil if no rules file is configured.
+func (opts Options) complianceRules(db *sqlx.DB) (map[string][]compliance.Rule, error) {
+	if opts.RulesFile == "" {
+		return nil, nil
+	}
+	rulesJSON, err := ioutil.ReadFile(opts.RulesFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading rules file")
+	}
+	store := complianceStore{db: db, baseURL: opts.BaseURL}
+	rules, err := compliance.ParseRules(rulesJSON, store, store)
+	if err != nil {
+		return nil, err
+	}
+	for assetCode := range rules {
+		if assetCode != opts.AssetCode {
+			return nil, errors.Errorf("rules configured for asset %s but the server only approves %s", assetCode, opts.AssetCode)
+		}
+	}
+	return rules, nil
+}
+
 func buildURLString(baseURL, endpoint string) string {
 	URL, err := url.Parse(baseURL)
 	if err != nil {
@@ -2,16 +2,15 @@ package serve
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"net/http"
 	"strconv"
 
-	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/metriqorg/go/amount"
 	"github.com/metriqorg/go/clients/orbitrclient"
 	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/compliance"
 	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/serve/httperror"
 	"github.com/metriqorg/go/support/errors"
 	"github.com/metriqorg/go/support/http/httpdecode"
@@ -27,6 +26,10 @@ type txApproveHandler struct {
 	db                *sqlx.DB
 	kycThreshold      int64
 	baseURL           string
+	// compliance checks the payments against the compliance rules of the
+	// asset. If no rules are configured payments exceeding the kycThreshold
+	// require KYC approval.
+	compliance compliance.Engine
 }
 
 type txApproveRequest struct {
@@ -186,9 +189,15 @@ func (h txApproveHandler) txApprove(ctx context.Context, in txApproveRequest) (r
 		return NewRejectedTxApprovalResponse("Invalid transaction sequence number."), nil
 	}
 
-	actionRequiredResponse, err := h.handleActionRequiredResponseIfNeeded(ctx, paymentSource, paymentOp)
+	// the revised transaction has the payment source account as its source
+	// account and the same sequence number
+	payment, err := h.compliancePayment(paymentSource, tx.SourceAccount().Sequence, paymentSource, paymentOp)
 	if err != nil {
-		return nil, errors.Wrap(err, "handling KYC required payment")
+		return nil, err
+	}
+	actionRequiredResponse, err := h.handleActionRequiredResponseIfNeeded(ctx, payment)
+	if err != nil {
+		return nil, errors.Wrap(err, "handling non compliant payment")
 	}
 	if actionRequiredResponse != nil {
 		return actionRequiredResponse, nil
@@ -242,65 +251,88 @@ func (h txApproveHandler) txApprove(ctx context.Context, in txApproveRequest) (r
 		return nil, errors.Wrap(err, "encoding revised transaction")
 	}
 
-	return NewRevisedTxApprovalResponse(txe), nil
-}
-
-// handleActionRequiredResponseIfNeeded validates and returns an action_required
-// response if the payment requires KYC.
-func (h txApproveHandler) handleActionRequiredResponseIfNeeded(ctx context.Context, stellarAddress string, paymentOp *txnbuild.Payment) (*txApprovalResponse, error) {
-	paymentAmount, err := amount.ParseInt64(paymentOp.Amount)
+	result, err := h.complianceEngine().Approve(ctx, payment, h.complianceStore())
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing payment amount from string to Int64")
+		return nil, errors.Wrap(err, "approving payment")
 	}
-	if paymentAmount <= h.kycThreshold {
-		return nil, nil
+	if result != nil {
+		return complianceResultResponse(ctx, result)
 	}
 
-	intendedCallbackID := uuid.New().String()
-	const q = `
-		WITH new_row AS (
-			INSERT INTO accounts_kyc_status (stellar_address, callback_id)
-			VALUES ($1, $2)
-			ON CONFLICT(stellar_address) DO NOTHING
-			RETURNING *
-		)
-		SELECT callback_id, approved_at, rejected_at, pending_at FROM new_row
-		UNION
-		SELECT callback_id, approved_at, rejected_at, pending_at
-		FROM accounts_kyc_status
-		WHERE stellar_address = $1
-	`
-	var (
-		callbackID                        string
-		approvedAt, rejectedAt, pendingAt sql.NullTime
-	)
-	err = h.db.QueryRowContext(ctx, q, stellarAddress, intendedCallbackID).Scan(&callbackID, &approvedAt, &rejectedAt, &pendingAt)
+	return NewRevisedTxApprovalResponse(txe), nil
+}
+
+// handleActionRequiredResponseIfNeeded checks the payment against the
+// compliance rules and returns a rejected, pending or action_required response
+// reporting the rule the payment doesn't comply with, if any.
+func (h txApproveHandler) handleActionRequiredResponseIfNeeded(ctx context.Context, payment compliance.Payment) (*txApprovalResponse, error) {
+	result, err := h.complianceEngine().Check(ctx, payment)
 	if err != nil {
-		return nil, errors.Wrap(err, "inserting new row into accounts_kyc_status table")
+		return nil, errors.Wrap(err, "checking compliance rules")
 	}
-
-	if approvedAt.Valid {
+	if result == nil {
 		return nil, nil
 	}
+	return complianceResultResponse(ctx, result)
+}
 
-	kycThreshold, err := convertAmountToReadableString(h.kycThreshold)
-	if err != nil {
-		return nil, errors.Wrap(err, "converting kycThreshold to human readable string")
-	}
+// complianceResultResponse returns the response reporting the rule a payment
+// doesn't comply with.
+func complianceResultResponse(ctx context.Context, result *compliance.Result) (*txApprovalResponse, error) {
+	log.Ctx(ctx).Infof("payment does not comply with rule %s, responding with status %s", result.Rule, result.Status)
+
+	var resp *txApprovalResponse
+	switch result.Status {
+	case compliance.StatusRejected:
+		resp = NewRejectedTxApprovalResponse(result.Message)
+	case compliance.StatusPending:
+		resp = NewPendingTxApprovalResponse(result.Message)
+	case compliance.StatusActionRequired:
+		resp = NewActionRequiredTxApprovalResponse(result.Message, result.ActionURL, result.ActionFields)
+	default:
+		return nil, errors.Errorf("rule %s returned unsupported status %q", result.Rule, result.Status)
+	}
+	resp.Rule = result.Rule
+	return resp, nil
+}
 
-	if rejectedAt.Valid {
-		return NewRejectedTxApprovalResponse(fmt.Sprintf("Your KYC was rejected and you're not authorized for operations above %s %s.", kycThreshold, h.assetCode)), nil
+// complianceEngine returns the engine checking payments against the compliance
+// rules, which only requires KYC approval for the payments exceeding the
+// kycThreshold if no rules are configured.
+func (h txApproveHandler) complianceEngine() compliance.Engine {
+	if h.compliance.Rules != nil {
+		return h.compliance
+	}
+	return compliance.Engine{
+		Rules: map[string][]compliance.Rule{
+			h.assetCode: {
+				compliance.KYCThreshold{Threshold: h.kycThreshold, KYC: h.complianceStore()},
+			},
+		},
 	}
+}
 
-	if pendingAt.Valid {
-		return NewPendingTxApprovalResponse(fmt.Sprintf("Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above %s %s.", kycThreshold, h.assetCode)), nil
-	}
+func (h txApproveHandler) complianceStore() complianceStore {
+	return complianceStore{db: h.db, baseURL: h.baseURL}
+}
 
-	return NewActionRequiredTxApprovalResponse(
-		fmt.Sprintf(`Payments exceeding %s %s require KYC approval. Please provide an email address.`, kycThreshold, h.assetCode),
-		fmt.Sprintf("%s/kyc-status/%s", h.baseURL, callbackID),
-		[]string{"email_address"},
-	), nil
+// compliancePayment returns the payment checked against the compliance rules
+// for a payment operation executed by the transaction with the given source
+// account and sequence number.
+func (h txApproveHandler) compliancePayment(txSourceAccount string, txSequence int64, paymentSource string, paymentOp *txnbuild.Payment) (compliance.Payment, error) {
+	paymentAmount, err := amount.ParseInt64(paymentOp.Amount)
+	if err != nil {
+		return compliance.Payment{}, errors.Wrap(err, "parsing payment amount from string to Int64")
+	}
+	return compliance.Payment{
+		TxSourceAccount: txSourceAccount,
+		TxSequence:      txSequence,
+		Source:          paymentSource,
+		Destination:     paymentOp.Destination,
+		AssetCode:       h.assetCode,
+		AssetIssuer:     h.issuerKP.Address(),
+		Amount:          paymentAmount,
+	}, nil
 }
 
 // handleSuccessResponseIfNeeded inspects the incoming transaction and returns a
@@ -329,9 +361,13 @@ func (h txApproveHandler) handleSuccessResponseIfNeeded(ctx context.Context, tx
 		return NewRejectedTxApprovalResponse("Invalid transaction sequence number."), nil
 	}
 
-	kycRequiredResponse, err := h.handleActionRequiredResponseIfNeeded(ctx, paymentSource, paymentOp)
+	payment, err := h.compliancePayment(tx.SourceAccount().AccountID, tx.SourceAccount().Sequence, paymentSource, paymentOp)
 	if err != nil {
-		return nil, errors.Wrap(err, "handling KYC required payment")
+		return nil, err
+	}
+	kycRequiredResponse, err := h.handleActionRequiredResponseIfNeeded(ctx, payment)
+	if err != nil {
+		return nil, errors.Wrap(err, "handling non compliant payment")
 	}
 	if kycRequiredResponse != nil {
 		return kycRequiredResponse, nil
@@ -347,6 +383,14 @@ func (h txApproveHandler) handleSuccessResponseIfNeeded(ctx context.Context, tx
 		return nil, errors.Wrap(err, "encoding revised transaction")
 	}
 
+	result, err := h.complianceEngine().Approve(ctx, payment, h.complianceStore())
+	if err != nil {
+		return nil, errors.Wrap(err, "approving payment")
+	}
+	if result != nil {
+		return complianceResultResponse(ctx, result)
+	}
+
 	return NewSuccessTxApprovalResponse(txe, "Transaction is compliant and signed by the issuer."), nil
 }
 
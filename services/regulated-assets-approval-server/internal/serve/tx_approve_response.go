@@ -16,6 +16,9 @@ type txApprovalResponse struct {
 	ActionMethod string     `json:"action_method,omitempty"`
 	ActionFields []string   `json:"action_fields,omitempty"`
 	Timeout      *int64     `json:"timeout,omitempty"`
+	// Rule is the compliance rule a rejected, pending or action_required
+	// response was triggered by.
+	Rule string `json:"rule,omitempty"`
 }
 
 func (t *txApprovalResponse) Render(w http.ResponseWriter) {
@@ -10,6 +10,7 @@ import (
 	"github.com/metriqorg/go/keypair"
 	"github.com/metriqorg/go/network"
 	"github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/compliance"
 	"github.com/metriqorg/go/services/regulated-assets-approval-server/internal/db/dbtest"
 	"github.com/metriqorg/go/txnbuild"
 	"github.com/stretchr/testify/assert"
@@ -235,18 +236,18 @@ func TestTxApproveHandler_handleActionRequiredResponseIfNeeded(t *testing.T) {
 
 	// payments up to the the threshold won't trigger "action_required"
 	clientKP := keypair.MustRandom()
-	paymentOp := &txnbuild.Payment{
-		Amount: amount.StringFromInt64(kycThreshold),
+	payment := compliance.Payment{
+		Source:    clientKP.Address(),
+		AssetCode: "FOO",
+		Amount:    kycThreshold,
 	}
-	txApprovalResp, err := h.handleActionRequiredResponseIfNeeded(ctx, clientKP.Address(), paymentOp)
+	txApprovalResp, err := h.handleActionRequiredResponseIfNeeded(ctx, payment)
 	require.NoError(t, err)
 	require.Nil(t, txApprovalResp)
 
 	// payments greater than the threshold will trigger "action_required"
-	paymentOp = &txnbuild.Payment{
-		Amount: amount.StringFromInt64(kycThreshold + 1),
-	}
-	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, clientKP.Address(), paymentOp)
+	payment.Amount = kycThreshold + 1
+	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, payment)
 	require.NoError(t, err)
 
 	var callbackID string
@@ -261,6 +262,7 @@ func TestTxApproveHandler_handleActionRequiredResponseIfNeeded(t *testing.T) {
 		StatusCode:   http.StatusOK,
 		ActionURL:    "https://example.com/kyc-status/" + callbackID,
 		ActionFields: []string{"email_address"},
+		Rule:         "kyc_threshold",
 	}
 	require.Equal(t, wantResp, txApprovalResp)
 
@@ -275,7 +277,7 @@ func TestTxApproveHandler_handleActionRequiredResponseIfNeeded(t *testing.T) {
 	`
 	_, err = conn.ExecContext(ctx, q, clientKP.Address())
 	require.NoError(t, err)
-	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, clientKP.Address(), paymentOp)
+	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, payment)
 	require.NoError(t, err)
 	require.Nil(t, txApprovalResp)
 
@@ -290,9 +292,11 @@ func TestTxApproveHandler_handleActionRequiredResponseIfNeeded(t *testing.T) {
 	`
 	_, err = conn.ExecContext(ctx, q, clientKP.Address())
 	require.NoError(t, err)
-	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, clientKP.Address(), paymentOp)
+	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, payment)
 	require.NoError(t, err)
-	require.Equal(t, NewRejectedTxApprovalResponse("Your KYC was rejected and you're not authorized for operations above 500.00 FOO."), txApprovalResp)
+	wantResp = NewRejectedTxApprovalResponse("Your KYC was rejected and you're not authorized for operations above 500.00 FOO.")
+	wantResp.Rule = "kyc_threshold"
+	require.Equal(t, wantResp, txApprovalResp)
 
 	// if KYC was previously marked as pending, handleActionRequiredResponseIfNeeded will return a "pending" response
 	q = `
@@ -305,9 +309,11 @@ func TestTxApproveHandler_handleActionRequiredResponseIfNeeded(t *testing.T) {
 	`
 	_, err = conn.ExecContext(ctx, q, clientKP.Address())
 	require.NoError(t, err)
-	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, clientKP.Address(), paymentOp)
+	txApprovalResp, err = h.handleActionRequiredResponseIfNeeded(ctx, payment)
 	require.NoError(t, err)
-	require.Equal(t, NewPendingTxApprovalResponse("Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above 500.00 FOO."), txApprovalResp)
+	wantResp = NewPendingTxApprovalResponse("Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above 500.00 FOO.")
+	wantResp.Rule = "kyc_threshold"
+	require.Equal(t, wantResp, txApprovalResp)
 }
 
 func TestTxApproveHandler_txApprove_rejected(t *testing.T) {
@@ -653,6 +659,7 @@ func TestTxApproveHandler_txApprove_actionRequired(t *testing.T) {
 		StatusCode:   http.StatusOK,
 		ActionURL:    "https://example.com/kyc-status/" + callbackID,
 		ActionFields: []string{"email_address"},
+		Rule:         "kyc_threshold",
 	}
 	require.Equal(t, wantResp, txApprovalResp)
 }
@@ -1264,6 +1271,7 @@ func TestTxApproveHandler_handleSuccessResponseIfNeeded_actionRequired(t *testin
 		"https://example.com/kyc-status/"+callbackID,
 		[]string{"email_address"},
 	)
+	wantTxApprovalResponse.Rule = "kyc_threshold"
 	assert.Equal(t, wantTxApprovalResponse, txApprovalResponse)
 
 	// compliant operations with a payment above threshold will return "rejected" if the user's KYC was rejected
@@ -1279,7 +1287,9 @@ func TestTxApproveHandler_handleSuccessResponseIfNeeded_actionRequired(t *testin
 	require.NoError(t, err)
 	txApprovalResponse, err = handler.handleSuccessResponseIfNeeded(ctx, tx)
 	require.NoError(t, err)
-	assert.Equal(t, NewRejectedTxApprovalResponse("Your KYC was rejected and you're not authorized for operations above 500.00 GOAT."), txApprovalResponse)
+	wantTxApprovalResponse = NewRejectedTxApprovalResponse("Your KYC was rejected and you're not authorized for operations above 500.00 GOAT.")
+	wantTxApprovalResponse.Rule = "kyc_threshold"
+	assert.Equal(t, wantTxApprovalResponse, txApprovalResponse)
 
 	// compliant operations with a payment above threshold will return "pending" if the user's KYC was marked as pending
 	query = `
@@ -1294,7 +1304,9 @@ func TestTxApproveHandler_handleSuccessResponseIfNeeded_actionRequired(t *testin
 	require.NoError(t, err)
 	txApprovalResponse, err = handler.handleSuccessResponseIfNeeded(ctx, tx)
 	require.NoError(t, err)
-	assert.Equal(t, NewPendingTxApprovalResponse("Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above 500.00 GOAT."), txApprovalResponse)
+	wantTxApprovalResponse = NewPendingTxApprovalResponse("Your account could not be verified as approved nor rejected and was marked as pending. You will need staff authorization for operations above 500.00 GOAT.")
+	wantTxApprovalResponse.Rule = "kyc_threshold"
+	assert.Equal(t, wantTxApprovalResponse, txApprovalResponse)
 
 	// compliant operations with a payment above threshold will return "success" if the user's KYC was approved
 	query = `
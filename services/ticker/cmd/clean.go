@@ -50,5 +50,10 @@ var cmdCleanTrades = &cobra.Command{
 		if err != nil {
 			Logger.Fatal("could not delete trade entries:", err)
 		}
+
+		err = session.DeleteOldMarketAggregates(context.Background(), minDate)
+		if err != nil {
+			Logger.Fatal("could not delete market aggregates:", err)
+		}
 	},
 }
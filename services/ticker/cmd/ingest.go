@@ -17,6 +17,7 @@ func init() {
 	cmdIngest.AddCommand(cmdIngestAssets)
 	cmdIngest.AddCommand(cmdIngestTrades)
 	cmdIngest.AddCommand(cmdIngestOrderbooks)
+	cmdIngest.AddCommand(cmdIngestStream)
 
 	cmdIngestTrades.Flags().BoolVar(
 		&ShouldStream,
@@ -31,6 +32,13 @@ func init() {
 		7*24,
 		"Number of past hours to backfill trade data",
 	)
+
+	cmdIngestStream.Flags().IntVar(
+		&BackfillHours,
+		"num-hours",
+		7*24,
+		"Number of past hours to backfill trade data when no trade cursor is stored yet",
+	)
 }
 
 var cmdIngest = &cobra.Command{
@@ -121,3 +129,36 @@ var cmdIngestOrderbooks = &cobra.Command{
 		}
 	},
 }
+
+var cmdIngestStream = &cobra.Command{
+	Use:   "stream",
+	Short: "Continuously ingests trades and orderbooks from the OrbitR Stream API as a daemon.",
+	Long: `Continuously ingests trades and orderbooks from the OrbitR Stream API as a daemon.
+
+The cursor of the trade stream is stored in the database, so that restarting the
+command resumes from the last ingested trade and backfills the trades closed in
+the meantime. Market aggregates are updated as trades are ingested.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dbInfo, err := pq.ParseURL(DatabaseURL)
+		if err != nil {
+			Logger.Fatal("could not parse db-url:", err)
+		}
+
+		session, err := tickerdb.CreateSession("postgres", dbInfo)
+		if err != nil {
+			Logger.Fatal("could not connect to db:", err)
+		}
+		defer session.DB.Close()
+
+		Logger.Info("Streaming trades and orderbooks (this is a continuous process)")
+		err = ticker.StreamMarketData(context.Background(), ticker.StreamConfig{
+			Session:       &session,
+			Client:        Client,
+			Logger:        Logger,
+			BackfillHours: BackfillHours,
+		})
+		if err != nil {
+			Logger.Fatal("could not stream market data:", err)
+		}
+	},
+}
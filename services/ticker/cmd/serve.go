@@ -22,7 +22,7 @@ func init() {
 
 var cmdServe = &cobra.Command{
 	Use:   "serve",
-	Short: "Runs a GraphQL interface to get Ticker data",
+	Short: "Runs a GraphQL interface and market data endpoints to get Ticker data",
 	Run: func(cmd *cobra.Command, args []string) {
 		Logger.Info("Starting GraphQL Server")
 		dbInfo, err := pq.ParseURL(DatabaseURL)
@@ -36,6 +36,6 @@ var cmdServe = &cobra.Command{
 		}
 		defer session.DB.Close()
 
-		ticker.StartGraphQLServer(&session, Logger, ServerAddr)
+		ticker.StartGraphQLServer(&session, Client, Logger, ServerAddr)
 	},
 }
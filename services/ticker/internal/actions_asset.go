@@ -15,6 +15,8 @@ import (
 )
 
 // RefreshAssets scrapes the most recent asset list and ingests then into the db.
+// The changes of the TOML files of the issuers are recorded, and the trust
+// scores of the assets are recomputed.
 func RefreshAssets(ctx context.Context, s *tickerdb.TickerSession, c *orbitrclient.Client, l *hlog.Entry) (err error) {
 	sc := scraper.ScraperConfig{
 		Client: c,
@@ -29,6 +31,7 @@ func RefreshAssets(ctx context.Context, s *tickerdb.TickerSession, c *orbitrclie
 	wg.Add(1)
 	go func() {
 		count := 0
+		recordedTOMLs := map[string]bool{}
 		defer wg.Done()
 		for finalAsset := range assetQueue {
 			dbIssuer := tomlIssuerToDBIssuer(finalAsset.IssuerDetails)
@@ -41,8 +44,21 @@ func RefreshAssets(ctx context.Context, s *tickerdb.TickerSession, c *orbitrclie
 				continue
 			}
 
+			tomlURL := finalAsset.IssuerDetails.TOMLURL
+			if finalAsset.IssuerDetails.TOMLData != "" && !recordedTOMLs[tomlURL] {
+				recordedTOMLs[tomlURL] = true
+				err = recordTOMLSnapshot(ctx, s, l, tomlURL, finalAsset.IssuerDetails.TOMLData)
+				if err != nil {
+					l.Error("Error recording TOML:", tomlURL, err)
+				}
+				err = recordDomainRegistration(ctx, s, tomlURL)
+				if err != nil {
+					l.Error("Error recording domain registration:", tomlURL, err)
+				}
+			}
+
 			dbAsset := finalAssetToDBAsset(finalAsset, issuerID)
-			err = s.InsertOrUpdateAsset(ctx, &dbAsset, []string{"code", "issuer_account", "issuer_id"})
+			err = s.InsertOrUpdateAsset(ctx, &dbAsset, []string{"code", "issuer_account", "issuer_id", "trust_score"})
 			if err != nil {
 				l.Error("Error inserting asset:", dbAsset, err)
 				continue
@@ -52,6 +68,8 @@ func RefreshAssets(ctx context.Context, s *tickerdb.TickerSession, c *orbitrclie
 		}
 	}()
 	wg.Wait()
+
+	err = updateAssetTrustScores(ctx, s, l)
 	return
 }
 
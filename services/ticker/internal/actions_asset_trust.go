@@ -0,0 +1,152 @@
+package ticker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"time"
+
+	"github.com/metriqorg/go/services/ticker/internal/scraper"
+	"github.com/metriqorg/go/services/ticker/internal/tickerdb"
+	"github.com/metriqorg/go/support/errors"
+	hlog "github.com/metriqorg/go/support/log"
+)
+
+// Each factor adds up to 25 points to the trust score of an asset, which is
+// halved when the TOML of the asset had a suspicious change recently. Domain
+// age, trustlines and liquidity reach their maximum at the values below.
+const (
+	trustScoreFactorWeight     = 25.0
+	trustScoreMaxDomainAgeDays = 365
+	trustScoreMaxNumAccounts   = 10000
+	trustScoreMaxTradeCount    = 1000
+)
+
+// domainRegistrationCheckInterval is how often the registration of the domain
+// of a TOML is looked up again, in case the domain expired and was registered
+// by someone else.
+const domainRegistrationCheckInterval = 30 * 24 * time.Hour
+
+// recordTOMLSnapshot stores the content of the TOML at tomlURL if it changed
+// since its latest snapshot, along with the changes from that snapshot. The
+// changes are checked against all the previous snapshots, see
+// scraper.DiffTOML.
+func recordTOMLSnapshot(ctx context.Context, s *tickerdb.TickerSession, l *hlog.Entry, tomlURL, tomlData string) error {
+	hash := sha256.Sum256([]byte(tomlData))
+	snapshot := tickerdb.TOMLSnapshot{
+		TOMLURL:     tomlURL,
+		ContentHash: hex.EncodeToString(hash[:]),
+		Content:     tomlData,
+		CreatedAt:   time.Now(),
+	}
+
+	snapshots, err := s.RetrieveTOMLSnapshots(ctx, tomlURL)
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve TOML snapshots")
+	}
+	if len(snapshots) > 0 && snapshots[0].ContentHash == snapshot.ContentHash {
+		return nil
+	}
+
+	var dbChanges []tickerdb.TOMLChange
+	if len(snapshots) > 0 {
+		history := make([]string, len(snapshots))
+		for i, previous := range snapshots {
+			history[i] = previous.Content
+		}
+		changes, err := scraper.DiffTOML(history, tomlData)
+		if err != nil {
+			return errors.Wrap(err, "could not diff TOML snapshots")
+		}
+		for _, c := range changes {
+			if c.IsSuspicious {
+				l.Warnf("Suspicious change of %s in %s: %q -> %q\n", c.Field, tomlURL, c.OldValue, c.NewValue)
+			}
+			dbChanges = append(dbChanges, tickerdb.TOMLChange{
+				Field:        c.Field,
+				OldValue:     c.OldValue,
+				NewValue:     c.NewValue,
+				IsSuspicious: c.IsSuspicious,
+			})
+		}
+	}
+
+	return errors.Wrap(
+		s.InsertTOMLSnapshot(ctx, snapshot, dbChanges),
+		"could not insert TOML snapshot",
+	)
+}
+
+// recordDomainRegistration looks up when the domain of the TOML at tomlURL was
+// registered and stores it, unless it was looked up recently.
+func recordDomainRegistration(ctx context.Context, s *tickerdb.TickerSession, tomlURL string) error {
+	found, registration, err := s.GetDomainRegistration(ctx, tomlURL)
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve domain registration")
+	}
+	if found && time.Since(registration.CheckedAt) < domainRegistrationCheckInterval {
+		return nil
+	}
+
+	domain, registeredAt, err := scraper.FetchDomainRegistration(tomlURL)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch domain registration")
+	}
+	return errors.Wrap(
+		s.InsertOrUpdateDomainRegistration(ctx, tickerdb.DomainRegistration{
+			TOMLURL:      tomlURL,
+			Domain:       domain,
+			RegisteredAt: registeredAt,
+			CheckedAt:    time.Now(),
+		}),
+		"could not store domain registration",
+	)
+}
+
+// updateAssetTrustScores recomputes the trust scores of all assets.
+func updateAssetTrustScores(ctx context.Context, s *tickerdb.TickerSession, l *hlog.Entry) error {
+	factors, err := s.RetrieveAssetTrustFactors(ctx, time.Now().Add(-tickerdb.SuspiciousTOMLChangesWindow))
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve asset trust factors")
+	}
+
+	for _, f := range factors {
+		score := computeTrustScore(f)
+		if err = s.UpdateAssetTrustScore(ctx, f.AssetID, score); err != nil {
+			return errors.Wrapf(err, "could not update trust score of asset %d", f.AssetID)
+		}
+	}
+	l.Infof("Updated the trust score of %d assets\n", len(factors))
+	return nil
+}
+
+// computeTrustScore computes the trust score of an asset, from 0 to 100, from
+// the validity of its TOML, the age of the domain serving it, its number of
+// trustlines and its liquidity (i.e. its number of trades in the past 7
+// days).
+func computeTrustScore(f tickerdb.AssetTrustFactors) float64 {
+	var score float64
+	switch {
+	case f.IsValid && f.AssetControlledByDomain:
+		score += trustScoreFactorWeight
+	case f.IsValid:
+		score += trustScoreFactorWeight / 2
+	}
+	score += trustScoreFactorWeight * math.Min(f.DomainAgeDays, trustScoreMaxDomainAgeDays) / trustScoreMaxDomainAgeDays
+	score += trustScoreFactorWeight * logRatio(float64(f.NumAccounts), trustScoreMaxNumAccounts)
+	score += trustScoreFactorWeight * logRatio(float64(f.TradeCount7d), trustScoreMaxTradeCount)
+
+	if f.HasRecentSuspiciousChange {
+		score /= 2
+	}
+	return math.Round(score*100) / 100
+}
+
+// logRatio returns log(1+value) / log(1+max), capped to [0, 1].
+func logRatio(value, max float64) float64 {
+	if value <= 0 {
+		return 0
+	}
+	return math.Min(math.Log1p(value)/math.Log1p(max), 1)
+}
@@ -0,0 +1,36 @@
+package ticker
+
+import (
+	"testing"
+
+	"github.com/metriqorg/go/services/ticker/internal/tickerdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeTrustScore(t *testing.T) {
+	// An invalid asset with an unknown domain, no trustlines and no trades:
+	assert.Equal(t, 0.0, computeTrustScore(tickerdb.AssetTrustFactors{}))
+
+	// An established asset gets the maximum score:
+	established := tickerdb.AssetTrustFactors{
+		IsValid:                 true,
+		AssetControlledByDomain: true,
+		NumAccounts:             50000,
+		DomainAgeDays:           400,
+		TradeCount7d:            5000,
+	}
+	assert.Equal(t, 100.0, computeTrustScore(established))
+
+	// ...which is halved after a suspicious TOML change:
+	established.HasRecentSuspiciousChange = true
+	assert.Equal(t, 50.0, computeTrustScore(established))
+
+	// A valid TOML that doesn't list the asset only gets half of the
+	// validity points, and the other factors are proportional to the
+	// logarithm of their values:
+	assert.Equal(t, 12.5+12.5+12.5+0, computeTrustScore(tickerdb.AssetTrustFactors{
+		IsValid:       true,
+		NumAccounts:   99,
+		DomainAgeDays: 182.5,
+	}))
+}
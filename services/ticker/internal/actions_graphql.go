@@ -1,13 +1,15 @@
 package ticker
 
 import (
+	orbitrclient "github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/services/ticker/internal/api"
 	"github.com/metriqorg/go/services/ticker/internal/gql"
 	"github.com/metriqorg/go/services/ticker/internal/tickerdb"
 	hlog "github.com/metriqorg/go/support/log"
 )
 
-func StartGraphQLServer(s *tickerdb.TickerSession, l *hlog.Entry, port string) {
+func StartGraphQLServer(s *tickerdb.TickerSession, c *orbitrclient.Client, l *hlog.Entry, port string) {
 	graphql := gql.New(s, l)
 
-	graphql.Serve(port)
+	graphql.Serve(port, api.New(s, c, l))
 }
@@ -0,0 +1,291 @@
+package ticker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	orbitrclient "github.com/metriqorg/go/clients/orbitrclient"
+	hProtocol "github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/services/ticker/internal/scraper"
+	"github.com/metriqorg/go/services/ticker/internal/tickerdb"
+	"github.com/metriqorg/go/support/errors"
+	hlog "github.com/metriqorg/go/support/log"
+)
+
+// tradesCursorName is the name of the ingestion cursor of the trade stream.
+const tradesCursorName = "trades"
+
+// StreamConfig configures the long-running ingestion performed by StreamMarketData.
+type StreamConfig struct {
+	Session *tickerdb.TickerSession
+	Client  orbitrclient.ClientInterface
+	Logger  *hlog.Entry
+	// BackfillHours is the number of past hours of trades ingested before
+	// streaming when no trade cursor is stored yet.
+	BackfillHours int
+	// MarketsRefreshInterval is how often the markets whose orderbooks are
+	// streamed are refreshed. Defaults to 10 minutes.
+	MarketsRefreshInterval time.Duration
+	// RetryInterval is how long to wait before restarting a failed stream.
+	// Defaults to 5 seconds.
+	RetryInterval time.Duration
+}
+
+// StreamMarketData continuously ingests new trades and orderbook updates from the
+// OrbitR streaming APIs until ctx is done.
+//
+// The paging token of the last ingested trade is stored in the database, so that
+// restarting the stream replays the trades closed in the meantime. Every ingested
+// trade is also added to the aggregates of its market at every resolution.
+// Orderbooks are streamed for all the markets that were active in the past 7 days.
+func StreamMarketData(ctx context.Context, config StreamConfig) error {
+	if config.MarketsRefreshInterval == 0 {
+		config.MarketsRefreshInterval = 10 * time.Minute
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = 5 * time.Second
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			err := streamTradesFromCursor(ctx, config)
+			if ctx.Err() != nil {
+				return
+			}
+			config.Logger.Error(errors.Wrap(err, "trade stream stopped"))
+			if !sleepWithContext(ctx, config.RetryInterval) {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		streamRelevantOrderbooks(ctx, config)
+	}()
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// streamTradesFromCursor streams the trades following the stored cursor. When no
+// cursor is stored yet, the trades of the past config.BackfillHours are ingested
+// first. It returns when the stream stops or a trade can't be ingested.
+func streamTradesFromCursor(ctx context.Context, config StreamConfig) error {
+	s := config.Session
+	cursor, err := s.GetIngestionCursor(ctx, tradesCursorName)
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve trade cursor")
+	}
+	if cursor == "" {
+		cursor, err = backfillStreamTrades(ctx, config)
+		if err != nil {
+			return errors.Wrap(err, "could not backfill trades")
+		}
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sc := scraper.ScraperConfig{
+		Client: config.Client,
+		Logger: config.Logger,
+		Ctx:    &streamCtx,
+	}
+
+	var ingestErr error
+	err = sc.StreamNewTrades(cursor, func(trade hProtocol.Trade) {
+		if ingestErr != nil {
+			return
+		}
+		config.Logger.Debugf("New trade arrived. ID: %v; Close Time: %v\n", trade.ID, trade.LedgerCloseTime)
+		if ingestErr = ingestStreamedTrade(ctx, config, trade); ingestErr != nil {
+			cancel()
+		}
+	})
+	if ingestErr != nil {
+		return ingestErr
+	}
+	if err == nil {
+		err = errors.New("stream closed")
+	}
+	return err
+}
+
+// ingestStreamedTrade inserts a trade and updates its market aggregates, then
+// moves the trade cursor after it. Trades between unknown assets are skipped.
+func ingestStreamedTrade(ctx context.Context, config StreamConfig, trade hProtocol.Trade) error {
+	s := config.Session
+	scraper.NormalizeTradeAssets(&trade)
+	bID, cID, err := findBaseAndCounter(ctx, s, trade)
+	switch {
+	case err == errBaseOrCounterNotFound:
+		config.Logger.Debugf("Skipping trade %s between unknown assets\n", trade.ID)
+	case err != nil:
+		return errors.Wrap(err, "could not retrieve trade assets")
+	default:
+		dbTrade, err := hProtocolTradeToDBTrade(trade, bID, cID)
+		if err != nil {
+			config.Logger.Error(errors.Wrapf(err, "could not convert trade %s", trade.ID))
+			break
+		}
+		if _, err = s.InsertTradeWithAggregate(ctx, dbTrade); err != nil {
+			return errors.Wrapf(err, "could not insert trade %s", trade.ID)
+		}
+	}
+
+	return errors.Wrap(
+		s.SetIngestionCursor(ctx, tradesCursorName, trade.PT),
+		"could not store trade cursor",
+	)
+}
+
+// backfillStreamTrades ingests the trades of the past config.BackfillHours,
+// recomputes their market aggregates and returns the paging token of the newest
+// one, which the stream starts from.
+func backfillStreamTrades(ctx context.Context, config StreamConfig) (cursor string, err error) {
+	s := config.Session
+	sc := scraper.ScraperConfig{
+		Client: config.Client,
+		Logger: config.Logger,
+	}
+	since := time.Now().Add(time.Hour * -time.Duration(config.BackfillHours))
+	trades, err := sc.FetchAllTrades(since, 0)
+	if err != nil {
+		return
+	}
+
+	var dbTrades []tickerdb.Trade
+	for _, trade := range trades {
+		bID, cID, findErr := findBaseAndCounter(ctx, s, trade)
+		if findErr != nil {
+			continue
+		}
+
+		dbTrade, convErr := hProtocolTradeToDBTrade(trade, bID, cID)
+		if convErr != nil {
+			config.Logger.Error("Could not convert entry to DB Trade: ", convErr)
+			continue
+		}
+		dbTrades = append(dbTrades, dbTrade)
+	}
+
+	config.Logger.Infof("Inserting %d entries in the database.\n", len(dbTrades))
+	if err = s.BulkInsertTrades(ctx, dbTrades); err != nil {
+		return
+	}
+	if err = s.RefreshMarketAggregates(ctx, since); err != nil {
+		return
+	}
+
+	// Trades are fetched in descending order. Without any trade the stream
+	// starts from now, and the cursor is stored with the first streamed one.
+	if len(trades) == 0 {
+		return
+	}
+	cursor = trades[0].PT
+	err = s.SetIngestionCursor(ctx, tradesCursorName, cursor)
+	return
+}
+
+// streamRelevantOrderbooks streams the orderbooks of the markets that were active
+// in the past 7 days, refreshing the list of markets every
+// config.MarketsRefreshInterval, until ctx is done.
+func streamRelevantOrderbooks(ctx context.Context, config StreamConfig) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	streams := map[string]context.CancelFunc{}
+	ticker := time.NewTicker(config.MarketsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		mkts, err := config.Session.Retrieve7DRelevantMarkets(ctx)
+		if err != nil {
+			config.Logger.Error(errors.Wrap(err, "could not retrieve partial markets"))
+		} else {
+			relevant := map[string]bool{}
+			for _, mkt := range mkts {
+				key := fmt.Sprintf("%d_%d", mkt.BaseAssetID, mkt.CounterAssetID)
+				relevant[key] = true
+				if streams[key] != nil {
+					continue
+				}
+
+				mktCtx, cancel := context.WithCancel(ctx)
+				streams[key] = cancel
+				wg.Add(1)
+				go func(mkt tickerdb.PartialMarket) {
+					defer wg.Done()
+					streamMarketOrderbook(mktCtx, config, mkt)
+				}(mkt)
+			}
+
+			for key, cancel := range streams {
+				if !relevant[key] {
+					cancel()
+					delete(streams, key)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamMarketOrderbook streams the orderbook of a market into the database,
+// restarting the stream whenever it fails, until ctx is done.
+func streamMarketOrderbook(ctx context.Context, config StreamConfig, mkt tickerdb.PartialMarket) {
+	sc := scraper.ScraperConfig{
+		Client: config.Client,
+		Logger: config.Logger,
+	}
+	handler := func(ob scraper.OrderbookStats) {
+		dbOS := orderbookStatsToDBOrderbookStats(ob, mkt.BaseAssetID, mkt.CounterAssetID)
+		err := config.Session.InsertOrUpdateOrderbookStats(ctx, &dbOS, []string{"base_asset_id", "counter_asset_id"})
+		if err != nil {
+			config.Logger.Error(errors.Wrap(err, "could not insert orderbook stats into db"))
+		}
+	}
+
+	for {
+		err := sc.StreamOrderbookForAssets(
+			ctx,
+			mkt.BaseAssetType,
+			mkt.BaseAssetCode,
+			mkt.BaseAssetIssuer,
+			mkt.CounterAssetType,
+			mkt.CounterAssetCode,
+			mkt.CounterAssetIssuer,
+			handler,
+		)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			config.Logger.Error(errors.Wrap(err, "could not stream orderbook for assets"))
+		}
+		if !sleepWithContext(ctx, config.RetryInterval) {
+			return
+		}
+	}
+}
+
+// sleepWithContext waits for d and returns true, or returns false as soon as ctx
+// is done.
+func sleepWithContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
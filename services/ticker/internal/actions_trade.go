@@ -105,6 +105,10 @@ func BackfillTrades(
 	return nil
 }
 
+// errBaseOrCounterNotFound is returned by findBaseAndCounter when the assets of
+// a trade are not in the database.
+var errBaseOrCounterNotFound = errors.New("base or counter asset no found")
+
 // findBaseAndCounter tries to find the Base and Counter assets IDs in the database,
 // and returns an error if it doesn't find any.
 func findBaseAndCounter(ctx context.Context, s *tickerdb.TickerSession, trade hProtocol.Trade) (bID int32, cID int32, err error) {
@@ -127,7 +131,7 @@ func findBaseAndCounter(ctx context.Context, s *tickerdb.TickerSession, trade hP
 	}
 
 	if !bFound || !cFound {
-		err = errors.New("base or counter asset no found")
+		err = errBaseOrCounterNotFound
 		return
 	}
 
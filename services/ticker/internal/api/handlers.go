@@ -0,0 +1,232 @@
+package api
+
+import (
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/metriqorg/go/services/ticker/internal/scraper"
+	"github.com/metriqorg/go/services/ticker/internal/utils"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+// Pairs serves the /pairs endpoint, listing the markets that were active in
+// the past 7 days.
+func (s *server) Pairs(w http.ResponseWriter, r *http.Request) {
+	mkts, err := s.db.Retrieve7DRelevantMarkets(r.Context())
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "could not retrieve markets"))
+		renderError(w, http.StatusInternalServerError, "could not retrieve the requested data")
+		return
+	}
+
+	pairs := []pair{}
+	for _, mkt := range mkts {
+		base := assetID(mkt.BaseAssetType, mkt.BaseAssetCode, mkt.BaseAssetIssuer)
+		target := assetID(mkt.CounterAssetType, mkt.CounterAssetCode, mkt.CounterAssetIssuer)
+		pairs = append(pairs, pair{
+			TickerID: base + "_" + target,
+			Base:     base,
+			Target:   target,
+		})
+	}
+	httpjson.Render(w, pairs, httpjson.JSON)
+}
+
+// Tickers serves the /tickers endpoint, with the market data of the last 24
+// hours.
+func (s *server) Tickers(w http.ResponseWriter, r *http.Request) {
+	mkts, err := s.db.RetrievePartialMarkets(r.Context(), nil, nil, nil, nil, 24)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "could not retrieve markets"))
+		renderError(w, http.StatusInternalServerError, "could not retrieve the requested data")
+		return
+	}
+
+	tickers := []ticker{}
+	for _, mkt := range mkts {
+		base := assetID(mkt.BaseAssetType, mkt.BaseAssetCode, mkt.BaseAssetIssuer)
+		target := assetID(mkt.CounterAssetType, mkt.CounterAssetCode, mkt.CounterAssetIssuer)
+		tickers = append(tickers, ticker{
+			TickerID:       base + "_" + target,
+			BaseCurrency:   base,
+			TargetCurrency: target,
+			LastPrice:      mkt.Close,
+			BaseVolume:     mkt.BaseVolume,
+			TargetVolume:   mkt.CounterVolume,
+			Bid:            mkt.HighestBid,
+			Ask:            mkt.LowestAsk,
+			High:           mkt.High,
+			Low:            mkt.Low,
+		})
+	}
+	httpjson.Render(w, tickers, httpjson.JSON)
+}
+
+// Orderbook serves the /orderbook endpoint. The depth parameter is the total
+// number of levels (half of them on each side), 0 meaning full depth.
+func (s *server) Orderbook(w http.ResponseWriter, r *http.Request) {
+	tickerID := r.URL.Query().Get("ticker_id")
+	base, target, err := parseTickerID(tickerID)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	depth, err := intParam(r, "depth", 0)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sc := scraper.ScraperConfig{
+		Client: s.client,
+		Logger: s.logger,
+	}
+	summary, err := sc.FetchOrderbookSummary(
+		base.Type, base.Code, base.Issuer,
+		target.Type, target.Code, target.Issuer,
+		depth/2,
+	)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "could not fetch orderbook summary"))
+		renderError(w, http.StatusBadGateway, "could not retrieve the requested data")
+		return
+	}
+
+	ob := orderbook{
+		TickerID:  tickerID,
+		Timestamp: utils.TimeToUnixEpoch(time.Now()),
+		Bids:      [][2]string{},
+		Asks:      [][2]string{},
+	}
+	for _, bid := range summary.Bids {
+		// Bid amounts are in units of counter, see calcOrderbookStats.
+		amount, ok := new(big.Rat).SetString(bid.Amount)
+		if !ok || bid.PriceR.N == 0 {
+			continue
+		}
+		amount.Mul(amount, big.NewRat(int64(bid.PriceR.D), int64(bid.PriceR.N)))
+		ob.Bids = append(ob.Bids, [2]string{bid.Price, amount.FloatString(7)})
+	}
+	for _, ask := range summary.Asks {
+		ob.Asks = append(ob.Asks, [2]string{ask.Price, ask.Amount})
+	}
+	httpjson.Render(w, ob, httpjson.JSON)
+}
+
+// maxHistoricalTradesWindow is the longest period, in seconds, trades can be
+// requested for from the /historical_trades endpoint.
+const maxHistoricalTradesWindow = 7 * 24 * 60 * 60
+
+// HistoricalTrades serves the /historical_trades endpoint. Trades are filtered
+// by type (buy or sell), start_time and end_time (Unix timestamps, defaulting
+// to the last 24 hours, at most 7 days apart) and limit (at most 1000, 500 by
+// default).
+func (s *server) HistoricalTrades(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	base, target, err := parseTickerID(q.Get("ticker_id"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tradeType := q.Get("type")
+	if tradeType != "" && tradeType != "buy" && tradeType != "sell" {
+		renderError(w, http.StatusBadRequest, "type must be buy or sell")
+		return
+	}
+	limit, err := intParam(r, "limit", 500)
+	if err != nil || limit < 1 || limit > 1000 {
+		renderError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+		return
+	}
+	now := time.Now().Unix()
+	endTime, err := intParam(r, "end_time", int(now))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	startTime, err := intParam(r, "start_time", endTime-24*60*60)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if startTime > endTime || endTime-startTime > maxHistoricalTradesWindow {
+		renderError(w, http.StatusBadRequest, "start_time must be before end_time and at most 7 days before it")
+		return
+	}
+
+	ctx := r.Context()
+	bFound, bID, err := s.db.GetAssetByCodeAndIssuerAccount(ctx, base.Code, base.Issuer)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "could not retrieve base asset"))
+		renderError(w, http.StatusInternalServerError, "could not retrieve the requested data")
+		return
+	}
+	cFound, cID, err := s.db.GetAssetByCodeAndIssuerAccount(ctx, target.Code, target.Issuer)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "could not retrieve target asset"))
+		renderError(w, http.StatusInternalServerError, "could not retrieve the requested data")
+		return
+	}
+	if !bFound || !cFound {
+		renderError(w, http.StatusNotFound, "ticker_id not found")
+		return
+	}
+
+	trades := historicalTrades{
+		Buy:  []historicalTrade{},
+		Sell: []historicalTrade{},
+	}
+	for _, side := range []struct {
+		tradeType    string
+		baseIsSeller bool
+		trades       *[]historicalTrade
+	}{
+		// The base party made the offer when it is the seller, so the
+		// trade is a buy from the point of view of the taker.
+		{"buy", true, &trades.Buy},
+		{"sell", false, &trades.Sell},
+	} {
+		if tradeType != "" && tradeType != side.tradeType {
+			continue
+		}
+		dbTrades, err := s.db.RetrieveMarketTrades(ctx, bID, cID,
+			time.Unix(int64(startTime), 0),
+			time.Unix(int64(endTime), 0),
+			&side.baseIsSeller,
+			limit,
+		)
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "could not retrieve trades"))
+			renderError(w, http.StatusInternalServerError, "could not retrieve the requested data")
+			return
+		}
+		for _, dbTrade := range dbTrades {
+			*side.trades = append(*side.trades, historicalTrade{
+				TradeID:        dbTrade.ID,
+				Price:          dbTrade.Price,
+				BaseVolume:     dbTrade.BaseAmount,
+				TargetVolume:   dbTrade.CounterAmount,
+				TradeTimestamp: utils.TimeToUnixEpoch(dbTrade.LedgerCloseTime),
+				Type:           side.tradeType,
+			})
+		}
+	}
+	httpjson.Render(w, trades, httpjson.JSON)
+}
+
+// intParam parses the integer query parameter with the given name, returning
+// defaultValue if it is missing.
+func intParam(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid %s", name)
+	}
+	return n, nil
+}
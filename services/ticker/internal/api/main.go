@@ -0,0 +1,140 @@
+// Package api serves the ticker market data in the formats expected by
+// exchange listing sites (CoinGecko and CoinMarketCap): pairs, tickers,
+// orderbook and historical trades.
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	orbitrclient "github.com/metriqorg/go/clients/orbitrclient"
+	"github.com/metriqorg/go/services/ticker/internal/tickerdb"
+	"github.com/metriqorg/go/support/errors"
+	hlog "github.com/metriqorg/go/support/log"
+	"github.com/metriqorg/go/support/render/httpjson"
+)
+
+// nativeAssetCode is the code used for the native asset in ticker IDs.
+const nativeAssetCode = "MTRQ"
+
+// pair represents an entry of the /pairs endpoint.
+type pair struct {
+	TickerID string `json:"ticker_id"`
+	Base     string `json:"base"`
+	Target   string `json:"target"`
+}
+
+// ticker represents an entry of the /tickers endpoint, with the market
+// data of the last 24 hours.
+type ticker struct {
+	TickerID       string  `json:"ticker_id"`
+	BaseCurrency   string  `json:"base_currency"`
+	TargetCurrency string  `json:"target_currency"`
+	LastPrice      float64 `json:"last_price"`
+	BaseVolume     float64 `json:"base_volume"`
+	TargetVolume   float64 `json:"target_volume"`
+	Bid            float64 `json:"bid"`
+	Ask            float64 `json:"ask"`
+	High           float64 `json:"high"`
+	Low            float64 `json:"low"`
+}
+
+// orderbook represents the response of the /orderbook endpoint. Each level is
+// a [price, base amount] pair.
+type orderbook struct {
+	TickerID  string      `json:"ticker_id"`
+	Timestamp int64       `json:"timestamp"`
+	Bids      [][2]string `json:"bids"`
+	Asks      [][2]string `json:"asks"`
+}
+
+// historicalTrade represents a trade of the /historical_trades endpoint.
+type historicalTrade struct {
+	TradeID        int64   `json:"trade_id"`
+	Price          float64 `json:"price"`
+	BaseVolume     float64 `json:"base_volume"`
+	TargetVolume   float64 `json:"target_volume"`
+	TradeTimestamp int64   `json:"trade_timestamp"`
+	Type           string  `json:"type"`
+}
+
+// historicalTrades represents the response of the /historical_trades endpoint,
+// with the trades of each type.
+type historicalTrades struct {
+	Buy  []historicalTrade `json:"buy"`
+	Sell []historicalTrade `json:"sell"`
+}
+
+// tickerAsset is an asset of a ticker ID.
+type tickerAsset struct {
+	Type   string
+	Code   string
+	Issuer string
+}
+
+type server struct {
+	db     *tickerdb.TickerSession
+	client orbitrclient.ClientInterface
+	logger *hlog.Entry
+}
+
+// New returns an http.Handler serving the market endpoints under /api/v1/.
+// The orderbook endpoint queries OrbitR, the other ones the database.
+func New(s *tickerdb.TickerSession, c orbitrclient.ClientInterface, l *hlog.Entry) http.Handler {
+	srv := &server{db: s, client: c, logger: l}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/pairs", srv.Pairs)
+	mux.HandleFunc("/api/v1/tickers", srv.Tickers)
+	mux.HandleFunc("/api/v1/orderbook", srv.Orderbook)
+	mux.HandleFunc("/api/v1/historical_trades", srv.HistoricalTrades)
+	return mux
+}
+
+// renderError writes an error response with the given status code.
+func renderError(w http.ResponseWriter, statusCode int, message string) {
+	httpjson.RenderStatus(w, statusCode, map[string]string{"error": message}, httpjson.JSON)
+}
+
+// assetID returns the identifier of an asset in ticker IDs: MTRQ for the
+// native asset, and CODE:ISSUER for the other ones.
+func assetID(assetType, code, issuer string) string {
+	if assetType == string(orbitrclient.AssetTypeNative) {
+		return nativeAssetCode
+	}
+	return code + ":" + issuer
+}
+
+// parseTickerID parses a ticker ID in the BASE_TARGET format, where each asset
+// is formatted as returned by assetID, e.g. "MTRQ_BTC:GABC...".
+func parseTickerID(tickerID string) (base, target tickerAsset, err error) {
+	assets := strings.Split(tickerID, "_")
+	if len(assets) != 2 {
+		err = errors.New("invalid ticker_id")
+		return
+	}
+	if base, err = parseAssetID(assets[0]); err != nil {
+		return
+	}
+	target, err = parseAssetID(assets[1])
+	return
+}
+
+// parseAssetID parses an asset identifier formatted as returned by assetID.
+// The native asset is returned with the code and issuer it has in the
+// database.
+func parseAssetID(id string) (asset tickerAsset, err error) {
+	if id == nativeAssetCode {
+		return tickerAsset{Type: string(orbitrclient.AssetTypeNative), Code: nativeAssetCode, Issuer: "native"}, nil
+	}
+
+	parts := strings.Split(id, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || len(parts[0]) > 12 {
+		err = errors.New("invalid ticker_id")
+		return
+	}
+	asset = tickerAsset{Type: string(orbitrclient.AssetType4), Code: parts[0], Issuer: parts[1]}
+	if len(asset.Code) > 4 {
+		asset.Type = string(orbitrclient.AssetType12)
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	orbitrclient "github.com/metriqorg/go/clients/orbitrclient"
+	hProtocol "github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIssuer = "GCF3TQXKZJNFJK7HCMNE2O2CUNKCJH2Y2ROISTBPLC7C5EIA5NNG2XZB"
+
+func TestParseTickerID(t *testing.T) {
+	base, target, err := parseTickerID("MTRQ_BTC:" + testIssuer)
+	require.NoError(t, err)
+	assert.Equal(t, tickerAsset{Type: "native", Code: "MTRQ", Issuer: "native"}, base)
+	assert.Equal(t, tickerAsset{Type: "credit_alphanum4", Code: "BTC", Issuer: testIssuer}, target)
+
+	base, target, err = parseTickerID("LONGCODE:" + testIssuer + "_MTRQ")
+	require.NoError(t, err)
+	assert.Equal(t, tickerAsset{Type: "credit_alphanum12", Code: "LONGCODE", Issuer: testIssuer}, base)
+	assert.Equal(t, tickerAsset{Type: "native", Code: "MTRQ", Issuer: "native"}, target)
+
+	for _, tickerID := range []string{
+		"",
+		"MTRQ",
+		"MTRQ_BTC",
+		"MTRQ_BTC:",
+		"MTRQ_:" + testIssuer,
+		"MTRQ_TOOLONGASSETCODE:" + testIssuer,
+		"MTRQ_BTC:" + testIssuer + "_ETH:" + testIssuer,
+	} {
+		_, _, err = parseTickerID(tickerID)
+		assert.EqualError(t, err, "invalid ticker_id", tickerID)
+	}
+}
+
+func TestAssetID(t *testing.T) {
+	assert.Equal(t, "MTRQ", assetID("native", "MTRQ", "native"))
+	assert.Equal(t, "BTC:"+testIssuer, assetID("credit_alphanum4", "BTC", testIssuer))
+}
+
+func TestOrderbook(t *testing.T) {
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.
+		On("OrderBook", orbitrclient.OrderBookRequest{
+			SellingAssetType:  orbitrclient.AssetTypeNative,
+			BuyingAssetType:   orbitrclient.AssetType4,
+			BuyingAssetCode:   "BTC",
+			BuyingAssetIssuer: testIssuer,
+			Limit:             5,
+		}).
+		Return(hProtocol.OrderBookSummary{
+			Bids: []hProtocol.PriceLevel{
+				{PriceR: hProtocol.Price{N: 1, D: 2}, Price: "0.5000000", Amount: "10.0000000"},
+			},
+			Asks: []hProtocol.PriceLevel{
+				{PriceR: hProtocol.Price{N: 1, D: 1}, Price: "1.0000000", Amount: "3.0000000"},
+			},
+		}, nil)
+
+	h := New(nil, orbitrClient, log.DefaultLogger)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/v1/orderbook?ticker_id=MTRQ_BTC:"+testIssuer+"&depth=10", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var ob orderbook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ob))
+	assert.Equal(t, "MTRQ_BTC:"+testIssuer, ob.TickerID)
+	assert.NotZero(t, ob.Timestamp)
+	// Bid amounts are converted from counter to base units.
+	assert.Equal(t, [][2]string{{"0.5000000", "20.0000000"}}, ob.Bids)
+	assert.Equal(t, [][2]string{{"1.0000000", "3.0000000"}}, ob.Asks)
+	orbitrClient.AssertExpectations(t)
+}
+
+func TestOrderbook_errors(t *testing.T) {
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.
+		On("OrderBook", orbitrclient.OrderBookRequest{
+			SellingAssetType:  orbitrclient.AssetTypeNative,
+			BuyingAssetType:   orbitrclient.AssetType4,
+			BuyingAssetCode:   "BTC",
+			BuyingAssetIssuer: testIssuer,
+			Limit:             200,
+		}).
+		Return(hProtocol.OrderBookSummary{}, errors.New("something went wrong"))
+	h := New(nil, orbitrClient, log.DefaultLogger)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orderbook?ticker_id=MTRQ", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error": "invalid ticker_id"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orderbook?ticker_id=MTRQ_BTC:"+testIssuer+"&depth=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error": "invalid depth"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orderbook?ticker_id=MTRQ_BTC:"+testIssuer, nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	orbitrClient.AssertExpectations(t)
+}
+
+func TestHistoricalTrades_invalidParameters(t *testing.T) {
+	h := New(nil, &orbitrclient.MockClient{}, log.DefaultLogger)
+	tickerID := "MTRQ_BTC:" + testIssuer
+
+	for query, expected := range map[string]string{
+		"ticker_id=MTRQ":                                           "invalid ticker_id",
+		"ticker_id=" + tickerID + "&type=x":                        "type must be buy or sell",
+		"ticker_id=" + tickerID + "&limit=0":                       "limit must be between 1 and 1000",
+		"ticker_id=" + tickerID + "&limit=1001":                    "limit must be between 1 and 1000",
+		"ticker_id=" + tickerID + "&start_time=abc":                "invalid start_time",
+		"ticker_id=" + tickerID + "&end_time=-1":                   "invalid end_time",
+		"ticker_id=" + tickerID + "&start_time=200&end_time=100":   "start_time must be before end_time and at most 7 days before it",
+		"ticker_id=" + tickerID + "&start_time=0&end_time=1000000": "start_time must be before end_time and at most 7 days before it",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/historical_trades?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.JSONEq(t, `{"error": "`+expected+`"}`, w.Body.String(), query)
+	}
+}
@@ -39,9 +39,30 @@ type asset struct {
 	Countries                   string
 	Status                      string
 	IssuerID                    int32
+	TrustScore                  float64
+	SuspiciousTOMLChanges       []*tomlChange
 	OrderbookStats              orderbookStats
 }
 
+// tomlSnapshot represents a version of the TOML file of an issuer
+type tomlSnapshot struct {
+	TOMLURL     string
+	ContentHash string
+	Content     string
+	CreatedAt   graphql.Time
+	Changes     []*tomlChange
+}
+
+// tomlChange represents a field of a TOML file that changed
+// between two versions of the file
+type tomlChange struct {
+	Field        string
+	OldValue     string
+	NewValue     string
+	IsSuspicious bool
+	CreatedAt    graphql.Time
+}
+
 // partialMarket represents the aggregated market data for a
 // specific pair of assets since <Since>
 type partialMarket struct {
@@ -64,6 +85,22 @@ type partialMarket struct {
 	OrderbookStats       orderbookStats
 }
 
+// candle represents the OHLCV data of a market during an interval
+// of the given resolution
+type candle struct {
+	Resolution           string
+	IntervalStart        graphql.Time
+	Open                 float64
+	High                 float64
+	Low                  float64
+	Close                float64
+	BaseVolume           float64
+	CounterVolume        float64
+	TradeCount           int32
+	FirstLedgerCloseTime graphql.Time
+	LastLedgerCloseTime  graphql.Time
+}
+
 // orderbookStats represents the orderbook stats for a
 // specific pair of assets (aggregated or not)
 type orderbookStats struct {
@@ -90,8 +127,9 @@ func New(s *tickerdb.TickerSession, l *hlog.Entry) *resolver {
 	return &resolver{db: s, logger: l}
 }
 
-// Serve creates a GraphQL interface on <address>/graphql and a GraphiQL explorer on /graphiql
-func (r *resolver) Serve(address string) {
+// Serve creates a GraphQL interface on <address>/graphql and a GraphiQL explorer on /graphiql.
+// If apiHandler is not nil, it serves the requests on <address>/api/.
+func (r *resolver) Serve(address string, apiHandler http.Handler) {
 	relayHandler := r.NewRelayHandler()
 	mux := http.NewServeMux()
 	mux.Handle("/graphql", http.HandlerFunc(func(wr http.ResponseWriter, re *http.Request) {
@@ -99,6 +137,12 @@ func (r *resolver) Serve(address string) {
 		relayHandler.ServeHTTP(wr, re)
 	}))
 	mux.Handle("/graphiql", GraphiQL{})
+	if apiHandler != nil {
+		mux.Handle("/api/", http.HandlerFunc(func(wr http.ResponseWriter, re *http.Request) {
+			r.logger.Infof("%s %s %s\n", re.RemoteAddr, re.Method, re.URL)
+			apiHandler.ServeHTTP(wr, re)
+		}))
+	}
 
 	server := &http.Server{
 		Addr:        address,
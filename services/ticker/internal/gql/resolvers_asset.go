@@ -3,7 +3,9 @@ package gql
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/graph-gophers/graphql-go"
 	"github.com/metriqorg/go/services/ticker/internal/tickerdb"
 )
 
@@ -17,12 +19,126 @@ func (r *resolver) Assets(ctx context.Context) (assets []*asset, err error) {
 		return
 	}
 
+	suspiciousChanges, err := r.suspiciousTOMLChangesByIssuerID(ctx)
+	if err != nil {
+		err = errors.New("could not retrieve the requested data")
+		return
+	}
+
 	for _, dbAsset := range dbAssets {
-		assets = append(assets, dbAssetToAsset(dbAsset))
+		a := dbAssetToAsset(dbAsset)
+		a.SuspiciousTOMLChanges = suspiciousChanges[dbAsset.IssuerID]
+		if a.SuspiciousTOMLChanges == nil {
+			a.SuspiciousTOMLChanges = []*tomlChange{}
+		}
+		assets = append(assets, a)
 	}
 	return
 }
 
+// TOMLHistory resolves the tomlHistory() GraphQL query.
+func (r *resolver) TOMLHistory(ctx context.Context, args struct {
+	AssetCode   string
+	AssetIssuer string
+	Limit       *int32
+}) (snapshots []*tomlSnapshot, err error) {
+	limit, err := validateTOMLHistoryLimit(args.Limit)
+	if err != nil {
+		return
+	}
+
+	dbSnapshots, err := r.db.RetrieveAssetTOMLSnapshots(ctx, args.AssetCode, args.AssetIssuer, limit)
+	if err != nil {
+		err = errors.New("could not retrieve the requested data")
+		return
+	}
+	if len(dbSnapshots) == 0 {
+		return []*tomlSnapshot{}, nil
+	}
+
+	// Snapshots are ordered newest first, so the changes of all of them
+	// were detected since the last one was created.
+	oldest := dbSnapshots[len(dbSnapshots)-1]
+	dbChanges, err := r.db.RetrieveTOMLChanges(ctx, oldest.TOMLURL, oldest.CreatedAt)
+	if err != nil {
+		err = errors.New("could not retrieve the requested data")
+		return
+	}
+	changes := map[int32][]*tomlChange{}
+	for _, dbChange := range dbChanges {
+		changes[dbChange.SnapshotID] = append(changes[dbChange.SnapshotID], dbTOMLChangeToTOMLChange(dbChange))
+	}
+
+	for _, dbSnapshot := range dbSnapshots {
+		snapshot := &tomlSnapshot{
+			TOMLURL:     dbSnapshot.TOMLURL,
+			ContentHash: dbSnapshot.ContentHash,
+			Content:     dbSnapshot.Content,
+			CreatedAt:   graphql.Time{Time: dbSnapshot.CreatedAt},
+			Changes:     changes[dbSnapshot.ID],
+		}
+		if snapshot.Changes == nil {
+			snapshot.Changes = []*tomlChange{}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return
+}
+
+// suspiciousTOMLChangesByIssuerID retrieves the suspicious TOML changes of the
+// past tickerdb.SuspiciousTOMLChangesWindow, grouped by the ID of the issuers
+// the TOML files belong to.
+func (r *resolver) suspiciousTOMLChangesByIssuerID(ctx context.Context) (map[int32][]*tomlChange, error) {
+	since := time.Now().Add(-tickerdb.SuspiciousTOMLChangesWindow)
+	dbChanges, err := r.db.RetrieveSuspiciousTOMLChanges(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbChanges) == 0 {
+		return nil, nil
+	}
+
+	dbIssuers, err := r.db.GetAllIssuers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	issuerIDs := map[string][]int32{}
+	for _, dbIssuer := range dbIssuers {
+		issuerIDs[dbIssuer.TOMLURL] = append(issuerIDs[dbIssuer.TOMLURL], dbIssuer.ID)
+	}
+
+	changes := map[int32][]*tomlChange{}
+	for _, dbChange := range dbChanges {
+		for _, id := range issuerIDs[dbChange.TOMLURL] {
+			changes[id] = append(changes[id], dbTOMLChangeToTOMLChange(dbChange))
+		}
+	}
+	return changes, nil
+}
+
+// validateTOMLHistoryLimit validates the limit of the tomlHistory() GraphQL
+// query, returning 10 if it isn't provided.
+func validateTOMLHistoryLimit(limit *int32) (int, error) {
+	if limit == nil {
+		return 10, nil
+	}
+	if *limit < 1 || *limit > 100 {
+		return 0, errors.New("limit must be between 1 and 100")
+	}
+	return int(*limit), nil
+}
+
+// dbTOMLChangeToTOMLChange converts a tickerdb.TOMLChange to a *tomlChange
+func dbTOMLChangeToTOMLChange(dbChange tickerdb.TOMLChange) *tomlChange {
+	return &tomlChange{
+		Field:        dbChange.Field,
+		OldValue:     dbChange.OldValue,
+		NewValue:     dbChange.NewValue,
+		IsSuspicious: dbChange.IsSuspicious,
+		CreatedAt:    graphql.Time{Time: dbChange.CreatedAt},
+	}
+}
+
 // dbAssetToAsset converts a tickerdb.Asset to an *asset
 func dbAssetToAsset(dbAsset tickerdb.Asset) *asset {
 	return &asset{
@@ -51,5 +167,6 @@ func dbAssetToAsset(dbAsset tickerdb.Asset) *asset {
 		Countries:                   dbAsset.Countries,
 		Status:                      dbAsset.Status,
 		IssuerID:                    dbAsset.IssuerID,
+		TrustScore:                  dbAsset.TrustScore,
 	}
 }
@@ -3,6 +3,7 @@ package gql
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/metriqorg/go/services/ticker/internal/tickerdb"
@@ -70,6 +71,85 @@ func (r *resolver) Ticker(ctx context.Context,
 
 }
 
+// candleResolutions maps the resolutions accepted by the candles() GraphQL
+// query to their length in seconds.
+var candleResolutions = map[string]int32{
+	"1m":  tickerdb.Resolution1m,
+	"5m":  tickerdb.Resolution5m,
+	"15m": tickerdb.Resolution15m,
+	"1h":  tickerdb.Resolution1h,
+	"4h":  tickerdb.Resolution4h,
+	"1d":  tickerdb.Resolution1d,
+	"1w":  tickerdb.Resolution1w,
+}
+
+// Candles resolves the candles() GraphQL query.
+func (r *resolver) Candles(ctx context.Context, args struct {
+	BaseAssetCode      string
+	BaseAssetIssuer    string
+	CounterAssetCode   string
+	CounterAssetIssuer string
+	Resolution         string
+	Since              *graphql.Time
+	Until              *graphql.Time
+	Limit              *int32
+}) (candles []*candle, err error) {
+	resolution, ok := candleResolutions[args.Resolution]
+	if !ok {
+		err = errors.New("resolution must be one of 1m, 5m, 15m, 1h, 4h, 1d or 1w")
+		return
+	}
+	limit, err := validateCandleLimit(args.Limit)
+	if err != nil {
+		return
+	}
+
+	var since time.Time
+	if args.Since != nil {
+		since = args.Since.Time
+	}
+	until := time.Now()
+	if args.Until != nil {
+		until = args.Until.Time
+	}
+
+	dbCandles, err := r.db.RetrieveCandles(ctx,
+		args.BaseAssetCode,
+		args.BaseAssetIssuer,
+		args.CounterAssetCode,
+		args.CounterAssetIssuer,
+		resolution,
+		since,
+		until,
+		limit,
+	)
+	if err != nil {
+		// obfuscating sql errors to avoid exposing underlying
+		// implementation
+		err = errors.New("could not retrieve the requested data")
+		return
+	}
+
+	for _, dbCandle := range dbCandles {
+		candles = append(candles, dbAggregateToCandle(dbCandle, args.Resolution))
+	}
+	return
+}
+
+// validateCandleLimit validates if the limit parameter of the candles() query
+// is within an acceptable range (at most 1000 candles)
+func validateCandleLimit(n *int32) (int, error) {
+	if n == nil {
+		return 100, nil // default limit = 100
+	}
+
+	if *n > 0 && *n <= 1000 {
+		return int(*n), nil
+	}
+
+	return 0, errors.New("limit must be between 1 and 1000")
+}
+
 // validateNumHoursAgo validates if the numHoursAgo parameter is within an acceptable
 // time range (at most 168 hours ago = 7 days)
 func validateNumHoursAgo(n *int32) (int, error) {
@@ -118,3 +198,20 @@ func dbMarketToPartialMarket(dbMarket tickerdb.PartialMarket) *partialMarket {
 		OrderbookStats:       os,
 	}
 }
+
+// dbAggregateToCandle converts a tickerdb.MarketAggregate to a *candle
+func dbAggregateToCandle(dbAggregate tickerdb.MarketAggregate, resolution string) *candle {
+	return &candle{
+		Resolution:           resolution,
+		IntervalStart:        graphql.Time{Time: dbAggregate.IntervalStart},
+		Open:                 dbAggregate.Open,
+		High:                 dbAggregate.High,
+		Low:                  dbAggregate.Low,
+		Close:                dbAggregate.Close,
+		BaseVolume:           dbAggregate.BaseVolume,
+		CounterVolume:        dbAggregate.CounterVolume,
+		TradeCount:           dbAggregate.TradeCount,
+		FirstLedgerCloseTime: graphql.Time{Time: dbAggregate.FirstLedgerCloseTime},
+		LastLedgerCloseTime:  graphql.Time{Time: dbAggregate.LastLedgerCloseTime},
+	}
+}
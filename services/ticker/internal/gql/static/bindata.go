@@ -1,7 +1,7 @@
 // Code generated by go-bindata. DO NOT EDIT.
 // sources:
 // graphiql.html (1.182kB)
-// schema.gql (2.42kB)
+// schema.gql (4.066kB)
 
 package static
 
@@ -90,7 +90,7 @@ func graphiqlHtml() (*asset, error) {
 	return a, nil
 }
 
-var _schemaGql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xe4\x54\x51\x6f\xe3\x36\x0c\x7e\xb6\x7f\x05\xdb\xbd\xb4\x2f\x79\x18\xf6\x64\x6c\x03\xd2\x76\xc3\x8a\x35\xb7\xdb\xa5\x37\x0c\x28\x86\x81\xb1\x18\x87\x88\x2c\xf9\x28\x29\x6d\x70\xe8\x7f\x1f\x24\x27\xa9\x6c\xb7\xdd\x0f\xb8\x27\x5b\x24\x3f\x8a\xfc\xf4\x91\xae\xde\x50\x8b\xf0\xb5\x2c\xbe\x04\x92\x7d\x05\xc5\x9f\xf1\x5b\x3e\x97\xa5\xdf\x77\x04\xe9\x14\xdd\xdf\x81\x90\x17\xa6\x1d\x01\x6a\x0d\x3b\xd4\xac\xd0\x93\x02\x74\x8e\xbc\x03\x6b\xc0\x6f\x08\x96\x9e\xb4\x46\x01\x43\xfe\xd1\xca\x76\x56\x16\xbd\xbf\x82\x87\x79\xfc\x39\xfb\xe7\xac\x7c\x27\x19\x3b\x17\x48\xde\xc9\x76\x08\xa8\xe0\xe1\x36\xfd\x4d\xf2\x79\x41\x45\xe0\x3c\x7a\x07\x6b\xb1\x6d\xca\xa3\xd1\x79\xf8\xd1\x84\xf6\x37\x1b\xc4\xcd\x1b\xfb\x33\x6c\xe2\x5f\x44\x5e\x28\x5a\x63\xd0\x1e\x7e\x82\xef\x7f\xe8\xcd\x97\x33\xb0\x9d\x67\x6b\x50\xeb\x3d\x74\x62\x77\xac\x08\x6a\x1b\x8c\x27\x01\x34\x2a\xe2\x56\xe8\xa8\x6f\x1e\xd8\xac\x2d\xac\xad\xc0\x9a\xb5\x27\x61\xd3\xcc\xca\xa2\x45\xd9\x92\x77\x17\x65\x51\xc4\xd0\xd4\xfd\xb5\x55\x54\xc1\xd2\xc7\x90\xdc\xde\xf7\x92\x79\x0e\x77\xbd\x06\xca\x5d\x13\x5c\xd6\x62\x05\xb7\xc6\x97\xc5\x65\x05\x0f\x8b\x54\xca\x84\xf9\xa6\x11\x6a\x12\xed\x03\xd2\xac\xbc\xc1\x59\x44\x27\x7e\x5e\xa5\x07\xa1\x43\x96\x0f\xd8\x12\x5c\xd0\xac\x99\xc1\xf9\xdf\x77\x8b\x7f\xaf\xee\xaf\xcf\xc1\x0a\x20\x44\xb4\x63\xd3\x68\x82\x3a\x88\x90\xa9\xf7\x59\xe0\xf9\xe5\x90\x40\x10\x72\x41\x7b\x37\x2b\x0b\xcf\xf5\x96\x24\xf2\x78\xbc\xe0\x7f\x1b\x9e\x9f\x5a\x3b\xb5\xfe\x5c\x96\xae\xc6\x28\xa6\x2b\x6e\x62\xe0\xe1\x74\xcf\x2d\x1d\xb4\x9e\x28\x8d\x5a\xaf\x33\xc6\xcf\x8e\x9a\x9b\xd7\x89\xf9\xcc\x1e\x41\xd9\xd1\x84\xf6\x10\xe3\x52\x29\x67\x65\x81\xc1\x6f\x3e\xd1\x97\xc0\x42\xaa\x82\x2b\x6b\x35\xa1\x39\xd9\x77\xb6\xc6\x95\xa6\x81\xa3\xed\xef\xf8\x55\x5b\x4c\x09\x7a\x01\x18\x2f\x56\x6b\x52\x57\xfb\x1b\xdb\x22\x9b\x01\xc4\xd4\x1b\x3b\x55\xca\xd0\x73\x3f\x2c\x95\x5d\xb2\xce\x53\xc0\xb0\x34\xc5\xae\xd3\xb8\xbf\xa1\x9a\x5b\xd4\xae\x3a\xd0\x15\xfb\xcb\x98\x8f\x81\xe4\xea\xec\x58\x5b\xa3\x38\x8a\xc2\x65\xc6\x35\x3f\x91\xfa\x10\xda\x55\x14\xe9\x29\x51\x8b\x4f\x13\x1b\xbb\xcf\x46\x73\xcb\x7e\x58\x8d\x90\xa2\x36\x69\xed\xd6\x38\x2f\xa1\x1e\xdf\x50\x5b\xad\xd1\x93\xa0\x9e\x2b\x25\xe4\x1c\xbd\xeb\x5d\x72\x63\xd0\x07\x19\x45\x05\x13\x67\x22\xb7\xc5\x59\x08\x6e\x22\x82\xdb\x9b\xc3\xd3\x1e\xf7\x63\xaf\xaf\x28\x9a\x34\x43\x1f\x91\x25\x03\xbd\x3a\xf8\xb9\x7d\x38\xc0\xc7\x5a\x5e\x19\xfc\x91\x6b\x82\x8b\x19\xff\xb2\x3a\xc4\x27\x3a\x8a\xe7\x00\x18\x9b\x53\xa1\xd7\xbd\xce\x7a\xf2\x6d\x47\xe6\xc5\xaf\xed\xe3\xcb\x61\xc3\xcd\x26\xcb\xb8\x41\xd3\xe4\x37\x68\xeb\xb2\x23\xc7\xeb\x76\xa8\x97\x1e\xc5\x57\x69\xb4\x92\x08\xc4\xf9\x3b\x52\x0d\xc9\x75\x8c\x8f\xe6\x93\x33\x6e\x99\xb7\x7c\x56\x14\xc9\xca\xda\xed\x32\x2e\xa6\x0a\xfe\x18\x9c\x5f\xde\x60\x3c\xed\xef\xbd\xc6\xb7\xca\xd1\xd0\x0e\x5f\x4b\x28\x56\xac\x0e\x1d\x9e\xa6\x70\xc5\x6a\xcc\xc4\x8a\xd5\x02\x9f\xf2\x8d\xb4\x1d\xa3\xd0\x6d\xc7\x28\x74\xdb\x05\x67\x7c\xb9\x4e\x08\xd5\xf8\xbc\x60\xf5\xd1\x72\xb6\xef\x8e\xd5\xf6\xf2\x8e\xef\xd8\x85\x95\xe6\xfa\x77\xda\xe7\x8b\x76\xb8\x88\x82\xe8\x7c\x29\xdb\x56\x7f\xfe\x74\x97\x2f\x21\x52\x24\x18\x17\xc7\x92\x64\x37\x98\x9a\xb8\x87\x27\x46\x2f\x68\xdc\x9a\x64\xe2\x78\xa4\xd5\x3c\xf8\xcd\x2f\x46\x75\x7d\xd5\xd9\x2e\xec\xac\x63\x3f\x41\x58\x69\xee\x1f\xd9\xfb\xdc\xf8\x5c\xfe\x17\x00\x00\xff\xff\x20\x26\x65\x82\x74\x09\x00\x00")
+var _schemaGql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xe4\x56\xc9\x6e\xdb\x48\x13\x3e\x93\x4f\x51\x4a\x2e\x36\x20\x08\xd6\xff\x27\x17\xcd\x02\xc8\x4a\x26\x36\x62\x3b\x8b\x9c\x00\x03\x63\x10\x94\xd8\x25\xb2\xe1\x66\x37\xd3\x8b\x14\x21\xc8\xbb\x0f\xaa\x49\x49\xad\xcd\x01\x66\x8e\x73\x92\xaa\xaa\x6b\xfb\x6a\xa3\x2b\x2a\xaa\x11\xbe\xe7\xd9\xd7\x40\x76\x35\x82\xec\x03\xff\xe6\x3f\xf2\xdc\xaf\x1a\x82\x48\xb1\xf8\x39\x58\xf2\x56\xd2\x82\x00\x95\x82\x05\x2a\x29\xd0\x93\x00\x74\x8e\xbc\x03\xa3\xc1\x57\x04\x37\xa8\x3d\x56\x70\x47\x7e\x69\xec\xe3\x20\xcf\x5a\xf1\x08\x1e\xc6\xfc\xa7\xf7\x57\x2f\x7f\xc2\x96\x74\x2e\x90\x3d\x6d\xac\x93\x8f\xe0\xe1\x3a\xfe\x3b\x30\xe7\x2d\x0a\x02\xe7\xd1\x3b\x98\x5b\x53\x47\x33\x0a\x9d\x87\x5f\x75\xa8\xaf\x4c\xb0\x6e\x5c\x9a\xdf\xa1\xe2\x7f\xac\x79\x26\x68\x8e\x41\x79\xf8\x0d\xfe\xf7\xa2\x65\x9f\x0f\xc0\x34\x5e\x1a\x8d\x4a\xad\xa0\xb1\x66\x21\x05\x41\x61\x82\xf6\x64\x01\xb5\x60\xbd\x19\x3a\x6a\x53\x07\xa9\xe7\x06\xe6\xc6\xc2\x5c\x2a\x4f\x56\xea\x72\x90\x67\x35\xda\x47\xf2\xee\x2c\xcf\x32\x7e\x1a\x93\x9f\x18\x41\x23\x98\x7a\x7e\x92\xf2\xdb\x5c\x12\x49\xe7\xeb\x98\x52\x2a\x3a\xd0\x4b\x52\x1c\xc1\xb5\xf6\x79\x76\x3e\x82\x87\xdb\x18\xca\x01\xf0\x65\x69\xa9\x8c\xa8\xef\x80\x66\xec\x09\xcc\x38\xeb\x88\xcf\x51\x78\x10\x1a\x94\xf6\x0e\x6b\x82\x33\x1a\x94\x03\x78\x76\x7b\xff\xf1\xc3\x97\xcb\xfb\xc9\x33\x30\x16\x10\x58\xdd\x49\x5d\x2a\x82\x22\x58\x4b\xba\x58\xa5\x2f\x9f\x9d\xef\x42\x08\x96\x5c\x50\xde\x0d\xf2\xcc\xcb\xe2\x91\x2c\x23\xb9\x76\xf1\xd3\x94\xc7\x9b\xe4\x8e\x27\xcf\x19\xbe\xbb\xba\x99\x7c\x86\x02\xb5\x50\xe4\xc0\xcc\x63\xda\x6d\xd9\x60\x46\x7e\x49\xd4\x76\x34\x97\x8f\x83\x47\x2d\xb6\x4d\xc0\xf0\x3b\x40\x1f\x5f\x94\x72\x41\x9a\x03\x36\x2a\x30\x30\x70\x36\xac\xfb\xf0\xb2\xee\xc3\xf0\x65\xdd\x67\xdd\x61\xd5\x87\x17\x55\x1f\x86\x82\xc1\x18\x2e\xcf\xfb\x60\xac\x20\x4b\x02\x66\x2b\xf0\xb2\xa6\xe3\xa0\xb2\xee\x83\x93\xba\xa0\x3e\x04\xed\xa5\x3a\x8f\x8f\xc1\xa2\x2e\x89\x5b\x11\x10\x94\xac\xa5\x4f\xfb\x78\x78\x71\xd1\x07\xf4\xac\x5b\x1b\xe7\x61\x78\x71\x71\x71\xfe\x4b\x8c\x34\xd2\x96\x0a\xd2\x7e\x93\x39\x5a\x62\x60\x82\xd5\x24\x06\x79\xd6\xb1\x4f\x36\x6e\xef\x74\xe7\xf6\x9e\x68\xdd\xde\x93\xbd\xcb\xd2\x2d\x7e\x29\x37\x26\x3f\x82\x7b\x59\x53\x9e\x65\x11\x83\x0d\x15\x33\x4f\xaa\x3e\x89\xa1\x1f\xee\x84\x8a\xa0\x92\xce\x1b\xbb\x5a\x97\xf9\xfe\xdd\xed\x0d\xf7\x1a\xad\x19\xed\x62\xe9\x28\xd6\x6e\x6b\x1a\x07\xbc\x0f\x9a\x96\xe4\x3c\xcc\xa5\x75\xbe\x0f\x4b\xe9\xab\x68\xb4\xa8\xb8\x0c\xdb\x45\xc3\x7a\x8d\xa5\x85\x34\xc1\xc1\x82\xac\xe3\x5e\xe8\x1c\xb0\xb3\x13\x83\x13\xd3\xd8\xdb\x45\xc3\x58\xc2\x4d\xfd\xce\x79\x0c\x4c\xad\xae\xda\x34\xb8\x38\x78\x0c\x61\x3c\x0e\xed\x3e\x50\x9c\xfe\x54\x63\xe3\x2a\xe3\x19\xae\x1f\x79\xee\x0a\x54\x68\xe1\x52\x96\x0c\x67\x47\x45\x9c\xdb\x3b\x10\xfb\x80\xef\x40\xb1\xeb\xb2\x05\x6e\x5c\xc4\xda\x26\x7c\x56\x4a\x48\x1d\xea\xee\x8d\x8b\x05\xeb\xe5\x19\x06\x5f\x7d\xa4\xaf\x41\x5a\x12\x23\xb8\x34\x46\x11\xea\x0d\x7f\x61\x0a\x9c\x29\xda\x11\xd4\xad\x8f\x3f\x94\x41\xdf\xeb\x2e\xcb\xc4\x68\x6f\x8d\x52\x24\x2e\x57\xaf\x4c\x8d\x52\xef\xa8\xe8\xa2\x32\x47\x9b\x31\x91\xdc\xef\x86\x2a\x5d\x7c\x3f\x8e\x0f\x76\x43\x13\xd2\x35\x0a\x57\xaf\xa8\x90\x35\x2a\x37\xea\xe0\xe2\xfc\x92\xad\xd4\xcb\x33\x41\xae\x48\xc8\xc2\x68\x21\xb9\xf2\x2e\x61\xce\xe5\x37\x12\x77\xa1\x9e\x91\x4d\x0c\xd5\xf8\xed\x80\x27\xdd\x27\x1d\x4b\xb8\x1b\x8d\x25\x41\x75\x3c\x54\xd7\xda\x79\x1b\x8a\x7d\x0f\x85\x51\x0a\x3d\x59\x54\x63\x21\x2c\x39\x47\x4f\x4a\xa7\xb2\xd4\xe8\x83\xdd\x7b\x15\x34\xcf\x51\xca\xe3\x4b\x11\x52\x46\xdb\x04\xd7\xaf\xd6\xa5\x7d\x0e\xae\x30\x96\xda\xc1\xb8\x00\x6f\x78\x07\x41\x61\xea\x26\xf0\xbd\xd9\x1c\x66\xee\xc3\xf6\xfa\x4b\xbf\xea\x83\x88\xf5\x63\x75\x2c\xa9\x0f\x3a\xe2\x10\x67\xd2\x06\xe7\x95\xd4\xbc\xad\xb4\x00\x25\xbf\x86\xa8\xb2\x1e\xae\x6e\x4c\x2b\x54\x0b\x8a\xd7\x19\xe7\xf1\x54\x83\x0b\xae\x91\x45\x9c\xc7\x76\x58\x59\x43\x7a\xd7\x7a\x96\xed\x82\x6f\xf8\xd4\xfd\xff\x02\x04\xae\xe2\xb9\x61\x67\x53\x8e\x7f\xdb\x6a\xcf\x0f\x2d\x6d\x4e\x46\x34\x95\x06\xb2\x67\x97\xb5\xd9\x74\x1f\x5c\x28\x2a\x40\x07\xc8\x0b\x05\xa6\xd7\x6f\xee\xae\xef\xde\x7c\x79\xfb\xfa\x4f\x3e\x0a\xd8\xd9\x15\xdb\xf3\xd8\xc2\x3a\xc8\xb3\xad\x73\x76\x36\x89\xef\x5c\x37\xc7\x2d\xd5\x4d\x31\x4f\x1d\xb4\x27\x8f\x67\x35\x1e\xf6\xf7\x28\xd3\x65\x70\x6a\xa9\x9f\xde\xe9\x4f\xac\xf4\x27\x37\x3a\x5b\xfc\x6c\x54\xa8\x13\x20\x3b\x85\x7d\x76\x0c\x74\xc2\xb2\x75\x0b\x99\x86\xf4\x56\xae\xcc\x72\x4b\x54\xb2\xac\xb6\x54\x8b\x5a\x42\x2b\xe3\x12\x52\xf2\xbd\x5e\xa0\x9a\x7a\xb4\xbe\xbd\x1c\x71\xf6\xac\xf3\x37\x24\x4a\xb2\x13\x7e\xcf\xec\x8d\x50\xe1\x69\x59\xbc\xda\x33\x63\x1e\xa7\xfc\x89\x39\x82\x77\x3b\xf4\xb6\x06\xfb\x1f\x20\x4f\x55\xe3\xbf\x8a\x51\x7b\xae\x19\x99\xa3\xa7\xff\x78\x54\xbb\x39\xef\xa6\xb9\x83\xc0\x5e\x8e\xff\x0a\xe8\x7f\x0a\xc5\x3a\xd3\x5d\x04\xe0\x7b\x0e\xd9\x4c\x8a\xce\xc5\x66\xcd\xcf\xa4\xd8\x0f\x65\x26\xc5\x2d\x7e\xdb\xd2\xe8\x1e\xf7\xb5\xd0\x3d\xee\x6b\xa1\x7b\xbc\x95\x09\x4a\xae\xb1\x84\x62\x9f\xbe\x95\xe2\xbd\x91\xc9\x41\x5d\x47\xdb\x0e\x32\xd7\xa5\x09\x33\x25\x8b\xb7\xb4\x4a\xca\xb2\x77\xe9\x82\x55\x09\xe5\x4d\xad\x3e\x7d\xbc\x49\x38\x73\x12\x64\x91\x4b\x3b\x25\xbb\xd8\xd9\x0f\xfc\x01\x70\xc0\xf4\x16\xb5\x9b\x93\x3d\x10\x2c\x69\x36\x0e\xbe\x7a\xad\x45\xd3\x46\xbd\x91\x08\x6a\x8c\x93\xfe\x40\xc3\xd8\xf2\x7e\x29\xbd\x4f\x99\xeb\x1c\xd3\x2f\x20\xf8\x7e\x2c\xf0\xc2\x68\x4f\xda\x5f\xa1\xab\x0e\xb9\x29\xc7\x12\x8f\xf9\x78\xdb\xa2\xc5\x4f\xf6\xf3\x96\xcb\x9e\xe7\x92\x94\x48\xec\x19\x25\x3e\xa3\x0a\x29\xc6\x9a\x96\xfb\x2c\xe9\xa6\x9b\x9b\x90\x7e\x13\x1c\x44\xf3\x23\xff\x1b\x00\x00\xff\xff\x03\x00\xc5\xd4\x81\xe8\xe2\x0f\x00\x00")
 
 func schemaGqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -106,7 +106,7 @@ func schemaGql() (*asset, error) {
 	}
 
 	info := bindataFileInfo{name: "schema.gql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf7, 0x3c, 0xa2, 0xb0, 0x7, 0x29, 0xef, 0xb6, 0x66, 0x0, 0x23, 0x1e, 0xf8, 0xf4, 0x6a, 0x79, 0x4f, 0xa5, 0xd8, 0xec, 0x24, 0x85, 0xfe, 0xa, 0xfd, 0xe9, 0xc6, 0x27, 0x6c, 0xf1, 0xd8, 0x46}}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x71, 0xb7, 0x53, 0x1d, 0x44, 0x74, 0x5d, 0x2a, 0xaf, 0xec, 0xf6, 0xac, 0x34, 0x18, 0xc7, 0x86, 0x2e, 0x1d, 0xf5, 0xea, 0xc9, 0x40, 0x37, 0x7c, 0xe8, 0x1, 0x50, 0xe4, 0xd2, 0xf8, 0x79, 0x4e}}
 	return a, nil
 }
 
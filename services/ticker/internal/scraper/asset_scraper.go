@@ -237,6 +237,8 @@ func processAsset(logger *hlog.Entry, asset hProtocol.AssetStat, tomlCache *TOML
 			issuer, err = decodeTOMLIssuer(tomlData)
 			if err != nil {
 				errors = append(errors, err)
+			} else {
+				issuer.TOMLData = tomlData
 			}
 
 			tomlCache.Set(tomlURL, issuer)
@@ -167,7 +167,10 @@ func TestProcessAsset_notCached(t *testing.T) {
 	assert.Equal(t, "not cached signing key", finalAsset.IssuerDetails.SigningKey)
 	cachedTOML, ok := tomlCache.Get(server.URL)
 	assert.True(t, ok)
-	assert.Equal(t, TOMLIssuer{SigningKey: "not cached signing key"}, cachedTOML)
+	assert.Equal(t, TOMLIssuer{
+		SigningKey: "not cached signing key",
+		TOMLData:   `SIGNING_KEY="not cached signing key"`,
+	}, cachedTOML)
 }
 
 func TestProcessAsset_cached(t *testing.T) {
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// rdapURL is the RDAP bootstrap service the registration of domains is
+// looked up with. It redirects to the RDAP server of the registry of the
+// domain.
+var rdapURL = "https://rdap.org/domain/"
+
+// rdapDomain is the part of an RDAP domain response holding its events.
+// See: https://www.rfc-editor.org/rfc/rfc9083#section-4.5
+type rdapDomain struct {
+	Events []struct {
+		EventAction string    `json:"eventAction"`
+		EventDate   time.Time `json:"eventDate"`
+	} `json:"events"`
+}
+
+// FetchDomainRegistration looks up with RDAP when the registrable domain of
+// the given TOML URL, e.g. "example.com" for
+// "https://www.example.com/.well-known/stellar.toml", was registered.
+func FetchDomainRegistration(tomlURL string) (domain string, registeredAt time.Time, err error) {
+	parsedURL, err := url.Parse(tomlURL)
+	if err != nil {
+		err = errors.Wrap(err, "invalid TOML URL")
+		return
+	}
+	domain, err = publicsuffix.EffectiveTLDPlusOne(parsedURL.Hostname())
+	if err != nil {
+		err = errors.Wrapf(err, "could not find the registrable domain of %s", parsedURL.Hostname())
+		return
+	}
+
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+	req, err := http.NewRequest("GET", rdapURL+domain, nil)
+	if err != nil {
+		err = errors.Wrap(err, "invalid URL or request")
+		return
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	req.Header.Set("User-Agent", "Stellar Ticker v1.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = errors.Errorf("RDAP lookup of %s returned status %d", domain, resp.StatusCode)
+		return
+	}
+
+	var rdap rdapDomain
+	if err = json.NewDecoder(resp.Body).Decode(&rdap); err != nil {
+		err = errors.Wrapf(err, "could not decode RDAP response for %s", domain)
+		return
+	}
+	for _, event := range rdap.Events {
+		if event.EventAction == "registration" {
+			registeredAt = event.EventDate
+			return
+		}
+	}
+	err = errors.Errorf("RDAP response for %s has no registration event", domain)
+	return
+}
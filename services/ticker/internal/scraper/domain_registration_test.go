@@ -0,0 +1,50 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchDomainRegistration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/domain/example.co.uk":
+			w.Header().Set("Content-Type", "application/rdap+json")
+			w.Write([]byte(`{
+				"objectClassName": "domain",
+				"ldhName": "example.co.uk",
+				"events": [
+					{"eventAction": "last changed", "eventDate": "2024-01-02T00:00:00Z"},
+					{"eventAction": "registration", "eventDate": "1999-03-04T05:06:07Z"}
+				]
+			}`))
+		case "/domain/unregistered.com":
+			w.WriteHeader(http.StatusNotFound)
+		case "/domain/noevents.com":
+			w.Write([]byte(`{"objectClassName": "domain", "events": []}`))
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { rdapURL = url }(rdapURL)
+	rdapURL = server.URL + "/domain/"
+
+	// Subdomains are looked up by their registrable domain:
+	domain, registeredAt, err := FetchDomainRegistration("https://www.example.co.uk/.well-known/stellar.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "example.co.uk", domain)
+	assert.True(t, time.Date(1999, 3, 4, 5, 6, 7, 0, time.UTC).Equal(registeredAt))
+
+	_, _, err = FetchDomainRegistration("https://unregistered.com/.well-known/stellar.toml")
+	assert.EqualError(t, err, "RDAP lookup of unregistered.com returned status 404")
+
+	_, _, err = FetchDomainRegistration("https://noevents.com/.well-known/stellar.toml")
+	assert.EqualError(t, err, "RDAP response for noevents.com has no registration event")
+
+	_, _, err = FetchDomainRegistration("https://com/.well-known/stellar.toml")
+	assert.Error(t, err)
+}
@@ -57,6 +57,7 @@ type TOMLIssuer struct {
 	Documentation    TOMLDoc        `toml:"DOCUMENTATION"`
 	Currencies       []TOMLCurrency `toml:"CURRENCIES"`
 	TOMLURL          string         `toml:"-"`
+	TOMLData         string         `toml:"-"` // raw content, only set if it could be decoded
 }
 
 // FinalAsset is the interface to represent the aggregated Asset data.
@@ -155,6 +156,26 @@ func (c *ScraperConfig) FetchOrderbookForAssets(bType, bCode, bIssuer, cType, cC
 	return c.fetchOrderbook(bType, bCode, bIssuer, cType, cCode, cIssuer)
 }
 
+// FetchOrderbookSummary fetches the orderbook summary for the base and counter assets
+// provided in the parameters, with at most limit levels on each side (0 meaning OrbitR's
+// maximum). Unlike FetchOrderbookForAssets, it doesn't retry when rate-limited, so that it
+// can be used to serve requests.
+func (c *ScraperConfig) FetchOrderbookSummary(bType, bCode, bIssuer, cType, cCode, cIssuer string, limit int) (hProtocol.OrderBookSummary, error) {
+	r := createOrderbookRequest(bType, bCode, bIssuer, cType, cCode, cIssuer)
+	if limit > 0 && limit < int(r.Limit) {
+		r.Limit = uint(limit)
+	}
+	return c.Client.OrderBook(r)
+}
+
+// StreamOrderbookForAssets streams the orderbook of the base and counter assets provided in
+// the parameters and calls the handler function with the updated stats whenever it changes.
+// It returns when ctx is done or the stream fails.
+func (c *ScraperConfig) StreamOrderbookForAssets(ctx context.Context, bType, bCode, bIssuer, cType, cCode, cIssuer string, h func(OrderbookStats)) error {
+	c.Logger.Infof("Streaming orderbook info for %s:%s / %s:%s\n", bCode, bIssuer, cCode, cIssuer)
+	return c.streamOrderbook(ctx, bType, bCode, bIssuer, cType, cCode, cIssuer, h)
+}
+
 // NormalizeTradeAssets enforces the following rules:
 // 1. native asset type refers to a "MTRQ" code and a "native" issuer
 // 2. native is always the base asset (and if not, base and counter are swapped)
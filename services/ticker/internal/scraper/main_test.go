@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/metriqorg/go/support/errors"
 	"github.com/metriqorg/go/support/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func Test_ScraperConfig_FetchAllTrades_doesntCrashWhenReceivesAnError(t *testing.T) {
@@ -42,3 +44,67 @@ func Test_ScraperConfig_FetchAllTrades_doesntCrashWhenReceivesEmptyList(t *testi
 	assert.NoError(t, err)
 	assert.Empty(t, trades)
 }
+
+func Test_ScraperConfig_StreamOrderbookForAssets(t *testing.T) {
+	issuer := "GCF3TQXKZJNFJK7HCMNE2O2CUNKCJH2Y2ROISTBPLC7C5EIA5NNG2XZB"
+	orbitrClient := &orbitrclient.MockClient{}
+	orbitrClient.
+		On("StreamOrderBooks", mock.Anything, orbitrclient.OrderBookRequest{
+			SellingAssetType:  orbitrclient.AssetTypeNative,
+			BuyingAssetType:   orbitrclient.AssetType4,
+			BuyingAssetCode:   "BTC",
+			BuyingAssetIssuer: issuer,
+			Limit:             200,
+		}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			handler := args.Get(2).(orbitrclient.OrderBookHandler)
+			handler(hProtocol.OrderBookSummary{
+				Bids: []hProtocol.PriceLevel{
+					{PriceR: hProtocol.Price{N: 1, D: 4}, Amount: "10.0"},
+					{PriceR: hProtocol.Price{N: 1, D: 2}, Amount: "20.0"},
+				},
+				Asks: []hProtocol.PriceLevel{
+					{PriceR: hProtocol.Price{N: 1, D: 1}, Amount: "5.0"},
+				},
+			})
+			handler(hProtocol.OrderBookSummary{})
+		}).
+		Return(nil)
+
+	sc := ScraperConfig{
+		Logger: log.DefaultLogger,
+		Client: orbitrClient,
+	}
+
+	var stats []OrderbookStats
+	err := sc.StreamOrderbookForAssets(context.Background(), "native", "MTRQ", "native", "credit_alphanum4", "BTC", issuer, func(obStats OrderbookStats) {
+		stats = append(stats, obStats)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []OrderbookStats{
+		{
+			BaseAssetCode:      "MTRQ",
+			BaseAssetType:      "native",
+			BaseAssetIssuer:    "native",
+			CounterAssetCode:   "BTC",
+			CounterAssetType:   "credit_alphanum4",
+			CounterAssetIssuer: issuer,
+			NumBids:            2,
+			BidVolume:          30,
+			HighestBid:         0.5,
+			NumAsks:            1,
+			AskVolume:          5,
+			LowestAsk:          1,
+			Spread:             0.5,
+			SpreadMidPoint:     0.75,
+		},
+		{
+			BaseAssetCode:      "MTRQ",
+			BaseAssetType:      "native",
+			BaseAssetIssuer:    "native",
+			CounterAssetCode:   "BTC",
+			CounterAssetType:   "credit_alphanum4",
+			CounterAssetIssuer: issuer,
+		},
+	}, stats)
+}
@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"context"
 	"math"
 	"strconv"
 	"time"
@@ -18,16 +19,7 @@ func (c *ScraperConfig) fetchOrderbook(bType, bCode, bIssuer, cType, cCode, cIss
 		summary hProtocol.OrderBookSummary
 	)
 
-	obStats := OrderbookStats{
-		BaseAssetCode:      bType,
-		BaseAssetType:      bCode,
-		BaseAssetIssuer:    bIssuer,
-		CounterAssetCode:   cType,
-		CounterAssetType:   cCode,
-		CounterAssetIssuer: cIssuer,
-		HighestBid:         math.Inf(-1), // start with -Inf to make sure we catch the correct max bid
-		LowestAsk:          math.Inf(1),  // start with +Inf to make sure we catch the correct min ask
-	}
+	obStats := newOrderbookStats(bType, bCode, bIssuer, cType, cCode, cIssuer)
 	r := createOrderbookRequest(bType, bCode, bIssuer, cType, cCode, cIssuer)
 
 	err = utils.Retry(5, 5*time.Second, c.Logger, func() error {
@@ -48,6 +40,35 @@ func (c *ScraperConfig) fetchOrderbook(bType, bCode, bIssuer, cType, cCode, cIss
 	return obStats, nil
 }
 
+// streamOrderbook streams the orderbook of the base and counter assets provided in the
+// parameters and calls the handler function with the stats of every update.
+func (c *ScraperConfig) streamOrderbook(ctx context.Context, bType, bCode, bIssuer, cType, cCode, cIssuer string, h func(OrderbookStats)) error {
+	r := createOrderbookRequest(bType, bCode, bIssuer, cType, cCode, cIssuer)
+	return c.Client.StreamOrderBooks(ctx, r, func(summary hProtocol.OrderBookSummary) {
+		obStats := newOrderbookStats(bType, bCode, bIssuer, cType, cCode, cIssuer)
+		if err := calcOrderbookStats(&obStats, summary); err != nil {
+			c.Logger.Error(errors.Wrap(err, "could not calculate orderbook stats"))
+			return
+		}
+		h(obStats)
+	})
+}
+
+// newOrderbookStats returns an empty OrderbookStats instance for the base and counter assets
+// provided in the parameters, ready to be filled by calcOrderbookStats
+func newOrderbookStats(bType, bCode, bIssuer, cType, cCode, cIssuer string) OrderbookStats {
+	return OrderbookStats{
+		BaseAssetCode:      bCode,
+		BaseAssetType:      bType,
+		BaseAssetIssuer:    bIssuer,
+		CounterAssetCode:   cCode,
+		CounterAssetType:   cType,
+		CounterAssetIssuer: cIssuer,
+		HighestBid:         math.Inf(-1), // start with -Inf to make sure we catch the correct max bid
+		LowestAsk:          math.Inf(1),  // start with +Inf to make sure we catch the correct min ask
+	}
+}
+
 // calcOrderbookStats calculates the NumBids, BidVolume, BidMax, NumAsks, AskVolume and AskMin
 // statistics for a given OrdebookStats instance
 func calcOrderbookStats(obStats *OrderbookStats, summary hProtocol.OrderBookSummary) error {
@@ -0,0 +1,168 @@
+package scraper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/metriqorg/go/support/errors"
+)
+
+// TOMLChange represents a field of a TOML file whose value changed. Fields
+// are named after their TOML keys, e.g. "SIGNING_KEY", "DOCUMENTATION.ORG_URL",
+// "CURRENCIES[USD].issuer" or "CURRENCIES[USD:GABC...].status". Added fields
+// have an empty OldValue and removed fields an empty NewValue.
+type TOMLChange struct {
+	Field        string
+	OldValue     string
+	NewValue     string
+	IsSuspicious bool
+}
+
+// DiffTOML decodes the given TOML files and returns the changes from the
+// previous version of the file to newData, ordered by field. History holds
+// the previous versions of the file, newest first, and must not be empty.
+//
+// Changes that may indicate a compromised domain are flagged as suspicious:
+// a SIGNING_KEY that differs from the last one the file had, and a currency
+// code issued by an account that didn't issue it the last time the code was
+// listed, including a SIGNING_KEY or a currency code appearing for the first
+// time. Fields are compared with their last non-empty value in history, so
+// removing a field and adding it back with another value is flagged too.
+func DiffTOML(history []string, newData string) (changes []TOMLChange, err error) {
+	if len(history) == 0 {
+		err = errors.New("no previous TOML to diff with")
+		return
+	}
+
+	lastFields := map[string]string{}
+	var oldFields map[string]string
+	for i, data := range history {
+		var issuer TOMLIssuer
+		issuer, err = decodeTOMLIssuer(data)
+		if err != nil {
+			err = errors.Wrapf(err, "could not decode TOML %d versions ago", i+1)
+			return
+		}
+		fields := flattenTOMLIssuer(issuer)
+		if i == 0 {
+			oldFields = fields
+		}
+		for name, value := range fields {
+			if _, ok := lastFields[name]; !ok {
+				lastFields[name] = value
+			}
+		}
+	}
+	newIssuer, err := decodeTOMLIssuer(newData)
+	if err != nil {
+		err = errors.Wrap(err, "could not decode new TOML")
+		return
+	}
+	newFields := flattenTOMLIssuer(newIssuer)
+
+	var names []string
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	for name := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldValue, newValue := oldFields[name], newFields[name]
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, TOMLChange{
+			Field:        name,
+			OldValue:     oldValue,
+			NewValue:     newValue,
+			IsSuspicious: isSuspiciousTOMLChange(name, lastFields[name], newValue),
+		})
+	}
+	return
+}
+
+// isSuspiciousTOMLChange reports whether setting a field to newValue is
+// suspicious given lastValue, the last non-empty value of the field or an
+// empty string if it never had one, see DiffTOML.
+func isSuspiciousTOMLChange(field, lastValue, newValue string) bool {
+	if newValue == "" {
+		return false
+	}
+
+	switch {
+	case field == "SIGNING_KEY":
+		return newValue != lastValue
+	case strings.HasPrefix(field, "CURRENCIES[") && strings.HasSuffix(field, "].issuer"):
+		lastIssuers := map[string]bool{}
+		if lastValue != "" {
+			for _, issuer := range strings.Split(lastValue, ",") {
+				lastIssuers[issuer] = true
+			}
+		}
+		for _, issuer := range strings.Split(newValue, ",") {
+			if !lastIssuers[issuer] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flattenTOMLIssuer maps the fields of a TOML file with a non-zero value to
+// their value. The issuers of each currency code are listed in the
+// "CURRENCIES[<code>].issuer" field, and the other currency fields are keyed
+// by code and issuer.
+func flattenTOMLIssuer(issuer TOMLIssuer) map[string]string {
+	fields := map[string]string{}
+	flattenTOMLFields("", reflect.ValueOf(issuer), fields)
+
+	issuers := map[string][]string{}
+	for _, currency := range issuer.Currencies {
+		issuers[currency.Code] = append(issuers[currency.Code], currency.Issuer)
+		prefix := fmt.Sprintf("CURRENCIES[%s:%s].", currency.Code, currency.Issuer)
+		flattenTOMLFields(prefix, reflect.ValueOf(currency), fields)
+		delete(fields, prefix+"code")
+		delete(fields, prefix+"issuer")
+	}
+	for code, codeIssuers := range issuers {
+		sort.Strings(codeIssuers)
+		fields[fmt.Sprintf("CURRENCIES[%s].issuer", code)] = strings.Join(codeIssuers, ",")
+	}
+	return fields
+}
+
+// flattenTOMLFields adds the fields of the struct v with a toml tag to fields,
+// prefixing their name with prefix. Nested structs are flattened with their
+// name as prefix, and slices of structs are skipped.
+func flattenTOMLFields(prefix string, v reflect.Value, fields map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("toml")
+		if name == "" || name == "-" {
+			continue
+		}
+		f := v.Field(i)
+		if f.IsZero() {
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.Struct:
+			flattenTOMLFields(prefix+name+".", f, fields)
+		case reflect.Slice:
+			if f.Type().Elem().Kind() != reflect.String {
+				continue
+			}
+			fields[prefix+name] = strings.Join(f.Interface().([]string), ",")
+		default:
+			fields[prefix+name] = fmt.Sprint(f.Interface())
+		}
+	}
+}
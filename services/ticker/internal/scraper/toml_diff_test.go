@@ -0,0 +1,140 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTOML(t *testing.T) {
+	oldData := `
+SIGNING_KEY="GOLDSIGNINGKEY"
+TRANSFER_SERVER="https://api.example.com"
+
+[DOCUMENTATION]
+ORG_NAME="Example"
+
+[[CURRENCIES]]
+code="USD"
+issuer="GUSDISSUER"
+status="live"
+
+[[CURRENCIES]]
+code="EUR"
+issuer="GEURISSUER"
+`
+	newData := `
+SIGNING_KEY="GNEWSIGNINGKEY"
+TRANSFER_SERVER="https://api.example.com"
+WEB_AUTH_ENDPOINT="https://auth.example.com"
+
+[DOCUMENTATION]
+ORG_NAME="Example Inc"
+
+[[CURRENCIES]]
+code="USD"
+issuer="GUSDISSUER"
+status="dead"
+
+[[CURRENCIES]]
+code="EUR"
+issuer="GOTHERISSUER"
+
+[[CURRENCIES]]
+code="BTC"
+issuer="GBTCISSUER"
+collateral_addresses=["a", "b"]
+`
+	changes, err := DiffTOML([]string{oldData}, newData)
+	require.NoError(t, err)
+	assert.Equal(t, []TOMLChange{
+		{Field: "CURRENCIES[BTC:GBTCISSUER].collateral_addresses", NewValue: "a,b"},
+		{Field: "CURRENCIES[BTC].issuer", NewValue: "GBTCISSUER", IsSuspicious: true},
+		{Field: "CURRENCIES[EUR].issuer", OldValue: "GEURISSUER", NewValue: "GOTHERISSUER", IsSuspicious: true},
+		{Field: "CURRENCIES[USD:GUSDISSUER].status", OldValue: "live", NewValue: "dead"},
+		{Field: "DOCUMENTATION.ORG_NAME", OldValue: "Example", NewValue: "Example Inc"},
+		{Field: "SIGNING_KEY", OldValue: "GOLDSIGNINGKEY", NewValue: "GNEWSIGNINGKEY", IsSuspicious: true},
+		{Field: "WEB_AUTH_ENDPOINT", NewValue: "https://auth.example.com"},
+	}, changes)
+
+	// Identical files have no changes:
+	changes, err = DiffTOML([]string{oldData}, oldData)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = DiffTOML([]string{oldData}, "SIGNING_KEY=")
+	assert.Error(t, err)
+	_, err = DiffTOML([]string{"SIGNING_KEY="}, oldData)
+	assert.Error(t, err)
+	_, err = DiffTOML(nil, oldData)
+	assert.Error(t, err)
+}
+
+func TestDiffTOMLHistory(t *testing.T) {
+	history := []string{
+		// The signing key and the EUR currency were removed...
+		`
+[[CURRENCIES]]
+code="USD"
+issuer="GUSDISSUER"
+`,
+		`
+SIGNING_KEY="GOLDSIGNINGKEY"
+
+[[CURRENCIES]]
+code="USD"
+issuer="GUSDISSUER"
+
+[[CURRENCIES]]
+code="EUR"
+issuer="GEURISSUER"
+`,
+	}
+
+	// ...so adding them back as they were isn't suspicious:
+	changes, err := DiffTOML(history, history[1])
+	require.NoError(t, err)
+	assert.Equal(t, []TOMLChange{
+		{Field: "CURRENCIES[EUR].issuer", NewValue: "GEURISSUER"},
+		{Field: "SIGNING_KEY", NewValue: "GOLDSIGNINGKEY"},
+	}, changes)
+
+	// But adding them back with other values is:
+	changes, err = DiffTOML(history, `
+SIGNING_KEY="GNEWSIGNINGKEY"
+
+[[CURRENCIES]]
+code="USD"
+issuer="GUSDISSUER"
+
+[[CURRENCIES]]
+code="EUR"
+issuer="GOTHERISSUER"
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []TOMLChange{
+		{Field: "CURRENCIES[EUR].issuer", NewValue: "GOTHERISSUER", IsSuspicious: true},
+		{Field: "SIGNING_KEY", NewValue: "GNEWSIGNINGKEY", IsSuspicious: true},
+	}, changes)
+}
+
+func TestIsSuspiciousTOMLChange(t *testing.T) {
+	// Removing a signing key isn't suspicious, adding one where none existed
+	// or replacing it is:
+	assert.False(t, isSuspiciousTOMLChange("SIGNING_KEY", "GOLD", ""))
+	assert.False(t, isSuspiciousTOMLChange("SIGNING_KEY", "GOLD", "GOLD"))
+	assert.True(t, isSuspiciousTOMLChange("SIGNING_KEY", "", "GNEW"))
+	assert.True(t, isSuspiciousTOMLChange("SIGNING_KEY", "GOLD", "GNEW"))
+
+	// A currency code issued by a new account is suspicious, including a
+	// new code:
+	assert.False(t, isSuspiciousTOMLChange("CURRENCIES[USD].issuer", "GA", ""))
+	assert.False(t, isSuspiciousTOMLChange("CURRENCIES[USD].issuer", "GA,GB", "GA"))
+	assert.True(t, isSuspiciousTOMLChange("CURRENCIES[USD].issuer", "", "GA"))
+	assert.True(t, isSuspiciousTOMLChange("CURRENCIES[USD].issuer", "GA", "GA,GB"))
+	assert.True(t, isSuspiciousTOMLChange("CURRENCIES[USD].issuer", "GA", "GB"))
+
+	assert.False(t, isSuspiciousTOMLChange("TRANSFER_SERVER", "https://a.com", "https://b.com"))
+	assert.False(t, isSuspiciousTOMLChange("TRANSFER_SERVER", "", "https://b.com"))
+}
@@ -47,6 +47,7 @@ type Asset struct {
 	Countries                   string    `db:"countries"`
 	Status                      string    `db:"status"`
 	IssuerID                    int32     `db:"issuer_id"`
+	TrustScore                  float64   `db:"trust_score"`
 	Issuer                      Issuer    `db:"-"`
 }
 
@@ -99,6 +100,79 @@ type OrderbookStats struct {
 	UpdatedAt      time.Time `db:"updated_at"`
 }
 
+// IngestionCursor represents an entry on the ingestion_cursors table. It
+// stores the position of a long-running ingestion stream, so that it can be
+// resumed after a restart.
+type IngestionCursor struct {
+	Name      string    `db:"name"`
+	Cursor    string    `db:"cursor"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// MarketAggregate represents an entry on the market_aggregates table, which
+// holds the trade data of a market aggregated in OHLCV candles of Resolution
+// seconds.
+type MarketAggregate struct {
+	BaseAssetID          int32     `db:"base_asset_id"`
+	CounterAssetID       int32     `db:"counter_asset_id"`
+	Resolution           int32     `db:"resolution"`
+	IntervalStart        time.Time `db:"interval_start"`
+	BaseVolume           float64   `db:"base_volume"`
+	CounterVolume        float64   `db:"counter_volume"`
+	TradeCount           int32     `db:"trade_count"`
+	Open                 float64   `db:"open_price"`
+	High                 float64   `db:"highest_price"`
+	Low                  float64   `db:"lowest_price"`
+	Close                float64   `db:"close_price"`
+	FirstLedgerCloseTime time.Time `db:"first_ledger_close_time"`
+	LastLedgerCloseTime  time.Time `db:"last_ledger_close_time"`
+}
+
+// TOMLSnapshot represents an entry on the toml_snapshots table. A snapshot is
+// stored each time the content of a TOML file changes.
+type TOMLSnapshot struct {
+	ID          int32     `db:"id"`
+	TOMLURL     string    `db:"toml_url"`
+	ContentHash string    `db:"content_hash"`
+	Content     string    `db:"content"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// TOMLChange represents an entry on the toml_changes table, i.e. a field of
+// a TOML file that changed between two snapshots.
+type TOMLChange struct {
+	ID           int32     `db:"id"`
+	SnapshotID   int32     `db:"snapshot_id"`
+	TOMLURL      string    `db:"toml_url"`
+	Field        string    `db:"field"`
+	OldValue     string    `db:"old_value"`
+	NewValue     string    `db:"new_value"`
+	IsSuspicious bool      `db:"is_suspicious"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// DomainRegistration represents an entry on the domain_registrations table,
+// i.e. when the domain serving a TOML file was registered.
+type DomainRegistration struct {
+	TOMLURL      string    `db:"toml_url"`
+	Domain       string    `db:"domain"`
+	RegisteredAt time.Time `db:"registered_at"`
+	CheckedAt    time.Time `db:"checked_at"`
+}
+
+// AssetTrustFactors represents the data the trust score of an asset is
+// computed from.
+// Note: this struct does *not* directly map to a db entity.
+type AssetTrustFactors struct {
+	AssetID                   int32   `db:"asset_id"`
+	IsValid                   bool    `db:"is_valid"`
+	AssetControlledByDomain   bool    `db:"asset_controlled_by_domain"`
+	NumAccounts               int32   `db:"num_accounts"`
+	DomainAgeDays             float64 `db:"domain_age_days"`
+	TradeCount7d              int64   `db:"trade_count_7d"`
+	HasRecentSuspiciousChange bool    `db:"has_recent_suspicious_change"`
+}
+
 // Market represent the aggregated market data retrieved from the database.
 // Note: this struct does *not* directly map to a db entity.
 type Market struct {
@@ -0,0 +1,32 @@
+
+-- +migrate Up
+CREATE TABLE ingestion_cursors (
+    name text NOT NULL PRIMARY KEY,
+    cursor text NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+
+CREATE TABLE market_aggregates (
+    base_asset_id integer REFERENCES assets (id) NOT NULL,
+    counter_asset_id integer REFERENCES assets (id) NOT NULL,
+    interval_start timestamptz NOT NULL,
+
+    base_volume double precision NOT NULL,
+    counter_volume double precision NOT NULL,
+    trade_count integer NOT NULL,
+
+    open_price double precision NOT NULL,
+    highest_price double precision NOT NULL,
+    lowest_price double precision NOT NULL,
+    close_price double precision NOT NULL,
+
+    first_ledger_close_time timestamptz NOT NULL,
+    last_ledger_close_time timestamptz NOT NULL
+);
+ALTER TABLE ONLY public.market_aggregates
+    ADD CONSTRAINT market_aggregates_base_counter_interval_key PRIMARY KEY (base_asset_id, counter_asset_id, interval_start);
+CREATE INDEX market_aggregates_interval_start_idx ON market_aggregates (interval_start);
+
+-- +migrate Down
+DROP TABLE market_aggregates;
+DROP TABLE ingestion_cursors;
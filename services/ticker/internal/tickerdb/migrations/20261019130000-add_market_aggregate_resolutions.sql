@@ -0,0 +1,62 @@
+
+-- +migrate Up
+-- Market aggregates become OHLCV candles at multiple resolutions (in seconds).
+-- Weekly candles start on Mondays, the other ones are aligned on the Unix epoch.
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION market_aggregate_interval_start(t timestamptz, resolution integer)
+  RETURNS timestamptz AS $$
+  BEGIN
+    IF resolution = 604800 THEN
+      RETURN date_trunc('week', t AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    END IF;
+    RETURN to_timestamp(floor(extract(epoch from t) / resolution) * resolution);
+  END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +migrate StatementEnd
+
+ALTER TABLE market_aggregates ADD COLUMN resolution integer NOT NULL DEFAULT 3600;
+ALTER TABLE market_aggregates ALTER COLUMN resolution DROP DEFAULT;
+ALTER TABLE market_aggregates DROP CONSTRAINT market_aggregates_base_counter_interval_key;
+ALTER TABLE ONLY public.market_aggregates
+    ADD CONSTRAINT market_aggregates_base_counter_resolution_interval_key PRIMARY KEY (base_asset_id, counter_asset_id, resolution, interval_start);
+DROP INDEX market_aggregates_interval_start_idx;
+CREATE INDEX market_aggregates_resolution_interval_start_idx ON market_aggregates (resolution, interval_start);
+
+-- Hourly aggregates already exist, the other resolutions are computed from
+-- the trades in the database.
+INSERT INTO market_aggregates (
+    base_asset_id, counter_asset_id, resolution, interval_start,
+    base_volume, counter_volume, trade_count,
+    open_price, highest_price, lowest_price, close_price,
+    first_ledger_close_time, last_ledger_close_time
+)
+SELECT
+    t.base_asset_id,
+    t.counter_asset_id,
+    r.resolution,
+    market_aggregate_interval_start(t.ledger_close_time, r.resolution) AS interval_start,
+    sum(t.base_amount),
+    sum(t.counter_amount),
+    count(t.base_amount),
+    (array_agg(t.price ORDER BY t.ledger_close_time ASC))[1],
+    max(t.price),
+    min(t.price),
+    (array_agg(t.price ORDER BY t.ledger_close_time DESC))[1],
+    min(t.ledger_close_time),
+    max(t.ledger_close_time)
+FROM trades AS t
+    CROSS JOIN (VALUES (60), (300), (900), (14400), (86400), (604800)) AS r (resolution)
+WHERE t.base_asset_id IS NOT NULL
+    AND t.counter_asset_id IS NOT NULL
+GROUP BY t.base_asset_id, t.counter_asset_id, r.resolution, interval_start;
+
+-- +migrate Down
+DELETE FROM market_aggregates WHERE resolution <> 3600;
+DROP INDEX market_aggregates_resolution_interval_start_idx;
+CREATE INDEX market_aggregates_interval_start_idx ON market_aggregates (interval_start);
+ALTER TABLE market_aggregates DROP CONSTRAINT market_aggregates_base_counter_resolution_interval_key;
+ALTER TABLE ONLY public.market_aggregates
+    ADD CONSTRAINT market_aggregates_base_counter_interval_key PRIMARY KEY (base_asset_id, counter_asset_id, interval_start);
+ALTER TABLE market_aggregates DROP COLUMN resolution;
+DROP FUNCTION market_aggregate_interval_start(timestamptz, integer);
@@ -0,0 +1,31 @@
+
+-- +migrate Up
+-- TOML snapshots are keyed by URL rather than by issuer, since issuers are
+-- identified by the SIGNING_KEY of their TOML, which may change.
+CREATE TABLE toml_snapshots (
+    id serial NOT NULL PRIMARY KEY,
+    toml_url text NOT NULL,
+    content_hash text NOT NULL,
+    content text NOT NULL,
+    created_at timestamptz NOT NULL
+);
+CREATE INDEX toml_snapshots_toml_url_created_at_idx ON toml_snapshots (toml_url, created_at);
+
+CREATE TABLE toml_changes (
+    id serial NOT NULL PRIMARY KEY,
+    snapshot_id integer REFERENCES toml_snapshots (id) ON DELETE CASCADE NOT NULL,
+    toml_url text NOT NULL,
+    field text NOT NULL,
+    old_value text NOT NULL,
+    new_value text NOT NULL,
+    is_suspicious boolean NOT NULL,
+    created_at timestamptz NOT NULL
+);
+CREATE INDEX toml_changes_toml_url_created_at_idx ON toml_changes (toml_url, created_at);
+
+ALTER TABLE assets ADD COLUMN trust_score double precision NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE assets DROP COLUMN trust_score;
+DROP TABLE toml_changes;
+DROP TABLE toml_snapshots;
@@ -0,0 +1,13 @@
+
+-- +migrate Up
+-- Domain registrations are keyed by TOML URL, like TOML snapshots, and hold
+-- when the registrable domain of the URL was registered according to RDAP.
+CREATE TABLE domain_registrations (
+    toml_url text NOT NULL PRIMARY KEY,
+    domain text NOT NULL,
+    registered_at timestamptz NOT NULL,
+    checked_at timestamptz NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE domain_registrations;
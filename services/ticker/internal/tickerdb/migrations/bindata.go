@@ -11,6 +11,10 @@
 // migrations/20190425110313-add_orderbook_stats.sql (749B)
 // migrations/20190426092321-add_aggregated_orderbook_view.sql (831B)
 // migrations/20220909100700-trades_pk_to_bigint.sql (220B)
+// migrations/20261019120000-add_ingestion_cursors_and_market_aggregates.sql (1.082kB)
+// migrations/20261019130000-add_market_aggregate_resolutions.sql (2.884kB)
+// migrations/20261019140000-add_toml_history_and_trust_score.sql (1.062kB)
+// migrations/20261019140500-add_domain_registrations.sql (401B)
 
 package bdata
 
@@ -299,6 +303,86 @@ func migrations20220909100700Trades_pk_to_bigintSql() (*asset, error) {
 	return a, nil
 }
 
+var _migrations20261019120000Add_ingestion_cursors_and_market_aggregatesSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x94\xc1\x6e\xdb\x30\x0c\x86\xef\x7e\x0a\x1e\x13\x2c\xdd\x0b\xe4\xe4\xc5\x1a\x10\xcc\x93\x0b\xd5\x05\x96\x93\xa0\x58\x9c\x2a\x54\x96\x0c\x89\x6e\xbb\x3d\xfd\x10\x67\x49\xeb\xba\x41\x8c\x9e\xf9\x91\xfe\x25\x7f\x54\x76\x73\x03\x5f\x5a\x6b\xa2\x22\x84\xfb\x2e\xdb\x08\x96\xd7\x0c\xea\xfc\x5b\xc9\xc0\x7a\x83\x89\x6c\xf0\xb2\xe9\x63\x0a\x31\xc1\x22\x03\x00\xf0\xaa\x45\x20\x7c\x21\xe0\x55\x0d\xfc\xbe\x2c\xe1\x56\x6c\x7f\xe6\x62\x07\x3f\xd8\x6e\x35\x30\xc7\x8e\x31\x75\xac\xf4\x9d\x56\x84\x5a\x2a\x02\xb2\x2d\x26\x52\x6d\x47\x7f\xcf\x50\xb6\x5c\x67\xe3\x18\xad\x8a\x8f\x48\x52\x19\x13\xd1\x28\xc2\x53\x8c\xbd\x4a\x28\x55\x4a\x48\xd2\x6a\xb0\x9e\xd0\x60\x04\xc1\xbe\x33\xc1\xf8\x86\xdd\xc1\x50\x4b\xb0\xb0\x7a\xf9\x2e\x43\x13\x7a\x4f\x18\x3f\xd9\x7d\x80\xe3\x93\x72\x32\x91\x8a\x1f\x9f\x62\x95\xbd\x46\x7c\x0a\xae\x6f\x11\x74\xe8\xf7\x0e\xa1\x8b\xd8\xd8\x64\x83\xbf\x10\x69\x1e\x4d\x51\x69\x94\xc3\x31\xce\xd9\x5f\x89\x61\x60\xe8\xd0\xcb\x2e\xda\xe6\xea\xb0\x07\x6b\x1e\x30\xd1\x3c\xd8\x85\xe7\xd9\x6c\xe3\x42\xc2\xeb\xe8\xc0\xfe\xb6\x31\x91\x74\xa8\x0d\x46\x79\x6c\x3c\x5c\xec\x85\xdb\x3d\x74\x38\x35\xbb\xe1\x20\x55\x5e\xd6\x4c\xfc\x57\xaa\xe2\xe5\x0e\xba\x7e\xef\x6c\xf3\x75\xa2\xd7\x10\x27\x2f\x0a\xd8\x54\xfc\xae\x16\xf9\x96\xd7\x53\x07\xe5\x60\xdf\xe9\xa7\x9d\x8d\x78\xc4\x3f\x6f\x77\x01\x16\x23\x49\x57\x13\xf1\x56\xef\x64\x5a\xae\x4f\xee\x6f\x79\xc1\x7e\x7d\xf0\xdd\x31\x2f\xad\x7e\x81\x8a\x4f\x39\x58\x4c\x06\x8f\x76\xbd\x08\xcf\x3e\x2b\x44\x75\x7b\x69\xc9\xd6\x6f\xab\x93\x97\x60\x9d\xfd\x03\x00\x00\xff\xff\x03\x00\x4b\xd9\xa4\x34\x3a\x04\x00\x00")
+
+func migrations20261019120000Add_ingestion_cursors_and_market_aggregatesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations20261019120000Add_ingestion_cursors_and_market_aggregatesSql,
+		"migrations/20261019120000-add_ingestion_cursors_and_market_aggregates.sql",
+	)
+}
+
+func migrations20261019120000Add_ingestion_cursors_and_market_aggregatesSql() (*asset, error) {
+	bytes, err := migrations20261019120000Add_ingestion_cursors_and_market_aggregatesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/20261019120000-add_ingestion_cursors_and_market_aggregates.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1a, 0x21, 0x59, 0xbb, 0xed, 0x15, 0xa8, 0xaf, 0xc9, 0x66, 0x23, 0x6d, 0x9f, 0x14, 0xde, 0x25, 0x77, 0x8a, 0x66, 0xdc, 0x53, 0x5e, 0xc3, 0x26, 0xd7, 0x1f, 0xad, 0x75, 0x6, 0x13, 0xdb, 0x0}}
+	return a, nil
+}
+
+var _migrations20261019130000Add_market_aggregate_resolutionsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x56\xef\x73\xa3\x36\x10\xfd\xce\x5f\xf1\x3e\x64\x26\xd0\xfa\x5c\xdf\xdc\x4d\xe6\x3a\x6e\x3b\x43\x8c\x92\xd0\x62\xc8\xf0\xe3\xae\x69\xa7\xc3\x28\xa0\x38\x4c\x00\xb9\x92\x7c\xb1\xfb\xd7\x77\x10\xc6\xc6\x31\x97\xa4\xbd\xf6\x13\x68\xf5\xf6\xe9\xed\x6a\xb5\x92\xf1\xe6\x0d\xbe\xad\x8a\x85\xa0\x8a\x21\x59\x36\xc3\x39\x15\x0f\x4c\x81\x2e\x16\x82\x2d\xa8\x62\x12\xb7\x2c\xe3\x15\x43\x70\xe5\xcd\x3e\x22\xa3\x75\x5e\x32\x09\xaa\x50\xad\x4a\x55\x2c\x4b\x06\xc1\x24\x2f\x57\xaa\xe0\xb5\x84\x59\xd4\x90\x2c\xe3\x75\x2e\xad\x71\xc3\xf7\x89\xb1\x87\x72\xb3\xf3\x93\x8a\x0a\x05\x5e\x63\xce\xeb\x9c\x6e\xe4\x08\xea\x9e\x81\xab\x7b\x26\xc0\xeb\x86\x58\x30\xd0\xb2\x58\xd4\x2c\x6f\x60\xcd\x6c\x52\x17\x6b\xb0\x25\xcf\xee\xc7\xc6\x81\xe2\x48\x51\xc5\x2a\x56\xab\x73\xb6\x28\x6a\x63\x16\x12\x3b\x26\x08\x42\x84\xe4\xda\xb3\x67\x04\x17\x89\x3f\x8b\xdd\xc0\x47\xa5\xa3\x4a\x77\x51\xa5\x45\xad\x98\xf8\x4c\xcb\x54\x0b\x32\x15\x54\x51\x31\xa9\x68\xb5\x54\x7f\x8d\x7a\x11\xa1\x01\x2e\x98\xb0\x0c\x20\x24\x71\x12\xfa\x51\x1f\x0a\x3b\xc2\xc9\x89\x01\x9c\x93\x4b\xd7\x37\x00\xc0\xbd\xe8\xbb\xff\x88\xb3\xc9\xfb\x0f\x93\x09\xe2\x2b\xd2\xce\x77\x3c\xc8\x1b\x1d\x4a\xac\xea\xcc\x3c\x7d\x64\xec\xe1\x74\x04\x05\x3b\x46\xec\xce\x09\x7e\x0b\x7c\x82\xd3\x24\x9e\x9d\x5a\x03\xb6\xa9\x66\x22\xbe\x03\xf7\x62\x6a\xf4\x38\x15\x4f\x77\xea\xcc\xbb\x92\x73\x61\xb2\xb5\x12\x34\x53\xa6\x4e\x20\xee\x04\xaf\xa0\x2c\x7c\xd7\x13\x69\xe1\x9b\xfe\xa8\x21\x24\xbe\x33\x35\x4e\x4e\xe0\xd9\xfe\x65\x62\x5f\x12\x2c\xcb\xe5\x42\xfe\x59\xc2\x9d\xcf\x93\xd8\x3e\xf7\xc8\x74\x78\x27\x48\x9d\x1b\x86\xed\xc5\x24\x84\x86\x1d\x65\x5e\xc2\x76\x1c\xcc\x02\x2f\x99\xfb\x03\x79\x86\x1f\xc4\xf0\x13\xcf\x83\x43\x2e\xec\xc4\x8b\xf1\xee\x6c\x32\x99\xbe\x44\xa9\x67\x8f\x49\x9d\x30\xb8\xee\x88\x5e\xe2\xd0\xd8\x59\xe0\x47\x71\x68\xbb\x7e\x7c\x8c\x48\x6f\xa9\x64\x69\xc6\x57\x4d\xe9\xec\x0b\xe8\x81\x6d\x0e\xa9\x03\xdf\xbb\xc1\x72\x75\x5b\x16\xd9\xf8\x88\x44\x6f\x56\x9b\x81\xd7\xae\xb4\x8f\xe7\x60\x51\x5c\x87\xee\xdc\x0e\x6f\xf0\x0b\xb9\x81\xa9\xb5\x51\x29\x99\x4a\x8b\x7c\x84\xce\x77\x6f\xd9\xb3\x8c\xb0\xa3\xd1\xc5\x6f\x4d\x0d\x1d\xbb\xeb\x3b\xe4\xd7\x01\x31\x87\xe8\xb4\xc8\xd7\xd3\xee\xa8\x7d\xc9\x65\x48\xf2\xce\x1b\x03\xe7\x51\xc2\x7c\x56\x60\x53\x6c\x57\x7c\x25\xca\x4d\xbf\x33\xd1\x52\x30\x9a\x6f\xc0\xd6\x85\x54\xfd\x4e\xb2\xe7\x6a\x1b\x4a\xc6\xab\xe5\x4a\xb1\x5c\x97\x7f\xc3\xd5\x40\x95\xa0\x39\x93\x28\xda\x26\x93\x53\x45\x9b\x24\x8e\x0d\xd7\x8f\x48\x18\xc3\xf5\xe3\x60\x48\xa7\xde\xc1\xaf\x48\xf7\x68\x4f\xf0\x99\x97\xab\x8a\xed\xdd\xbb\xb1\x56\xd6\x56\x5a\x8b\xe6\x4b\x56\xa7\x4b\x51\x64\x6c\x84\xfb\x62\x71\xcf\xa4\xea\x86\x25\x7f\xec\x8d\xb2\x92\x4b\xb6\x1d\x68\xcf\xbb\x42\x48\x95\x96\x2c\x5f\x30\x91\xb6\xb3\x4d\x83\x18\xa1\xa4\x43\x76\xc3\x32\x22\xe2\x91\x59\xac\x9d\xd5\xf8\x30\xce\xad\xf1\x28\x5a\x6d\x17\xe3\x5e\xcc\xda\xf2\x34\x79\x4f\x6a\xc1\x54\xe3\x01\x5d\x7d\x1a\xab\x69\xaf\x43\xd9\x93\xab\xca\xec\xc4\x55\x8d\x1a\xab\x6f\xdf\xe9\xeb\x4f\x69\xe3\xa0\x93\x49\x85\xa0\x9b\x46\xa6\xa9\xc6\x3a\x75\x08\x42\x87\x84\x38\xbf\xc1\x80\x44\xd8\xd1\xcc\xb2\x7e\x7f\xfb\x47\x17\xe4\xba\x73\xdb\xf2\x55\x45\xfd\xc4\xf2\x4f\x57\x70\xc8\xe1\x12\x9a\xf0\x08\xd6\x2d\x47\xd7\x83\xb3\xc6\x45\x18\xcc\xbb\x22\xb7\x23\x28\x8d\x9e\x85\x41\x14\xe1\xe7\xc0\xf5\x61\x7e\xb4\xbd\x84\x44\x30\xcf\x26\xd6\x08\xe6\xbb\x89\xfe\x7c\xdf\x7e\xde\xbe\x7f\xdf\xfe\x7c\x38\xdb\xfe\xb4\xf7\x98\xa5\xf7\x44\xf4\x8f\xab\x65\x7c\xba\x22\x21\x79\x5a\x2d\x70\xa3\x5d\x33\xd7\x4b\xdb\xbe\x33\x50\x3c\x07\xb0\xcb\x30\x48\xae\xdb\xac\x1c\x56\xde\x50\xd5\x41\x8c\xbf\x7c\xca\xa6\x87\x2f\x05\x87\x3f\xd6\x86\x43\x3c\x12\x13\xe8\xbc\x1c\x1f\xec\x36\x88\x3d\x23\x7e\xf8\x69\x7b\xf7\x3c\xdb\x1d\x9f\x6d\x75\x2f\x36\xca\x57\x77\xc7\x43\xa0\xf5\x1f\x5f\x66\x43\x41\xfc\xef\xf7\xda\x57\x5c\x66\xff\x2e\x1b\x4f\x5e\x07\xdb\x8d\x7d\xfd\x1b\x71\xff\xec\x1b\xed\x9e\x85\x53\xe3\x6f\x00\x00\x00\xff\xff\x03\x00\x34\xa4\xe7\x79\x44\x0b\x00\x00")
+
+func migrations20261019130000Add_market_aggregate_resolutionsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations20261019130000Add_market_aggregate_resolutionsSql,
+		"migrations/20261019130000-add_market_aggregate_resolutions.sql",
+	)
+}
+
+func migrations20261019130000Add_market_aggregate_resolutionsSql() (*asset, error) {
+	bytes, err := migrations20261019130000Add_market_aggregate_resolutionsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/20261019130000-add_market_aggregate_resolutions.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x32, 0x3d, 0x12, 0xf2, 0x32, 0xe8, 0x23, 0x51, 0x8, 0x30, 0xb8, 0x53, 0x6, 0x34, 0xce, 0x3a, 0xdf, 0x7d, 0xcc, 0xa1, 0x7d, 0x23, 0xf0, 0xb9, 0x82, 0xfd, 0x31, 0x2b, 0xfd, 0x3, 0xec, 0x69}}
+	return a, nil
+}
+
+var _migrations20261019140000Add_toml_history_and_trust_scoreSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x53\xcd\x6e\xdb\x3c\x10\xbc\xeb\x29\xf6\xf8\x19\x9f\x53\xf4\xee\x93\x6a\x31\x81\x11\x59\x0e\x64\x19\xa8\x4f\x04\x2d\xae\xcd\x45\x65\x52\xe0\xae\xea\xb8\x4f\x5f\xf8\xbf\x8d\x95\x06\xed\x91\x9a\xd9\x9d\xe1\x8c\x98\x3c\x3c\xc0\xff\x5b\xda\x44\x23\x08\x8b\xf6\x70\xac\x66\xd3\x1c\xd8\x9b\x96\x5d\x10\x06\x13\x11\xbe\xe1\x1e\x2d\xac\xf6\xb0\x28\x73\x88\x46\x1c\x46\x10\x67\xfc\xe1\x13\x31\x77\x18\x87\xc0\xe4\x6b\x3c\x9f\x8e\x53\x87\x5d\x64\xd1\x0b\xad\xe9\x34\x2d\x0e\x61\x3e\x79\x2a\x26\xc5\x93\x7e\x56\x4b\x08\x6b\x10\x87\x14\x8f\x92\x43\xd8\x39\xaa\x1d\x6c\xcd\x1e\x6a\x67\xfc\x06\x3f\x25\xe3\x52\xa5\x95\x82\x2a\xfd\x92\x2b\x90\xb0\x6d\xf4\xcd\xd7\x7f\x09\x00\x00\x59\x60\x8c\x64\x1a\x28\x66\x15\x14\x8b\x3c\x87\x97\x72\x32\x4d\xcb\x25\x3c\xab\xe5\xf0\xc8\x39\x0e\x76\xb1\x01\xc1\x57\xb9\xf2\x4e\x58\x1d\xbc\xa0\x17\xed\x0c\xbb\x3f\xe0\xbd\x50\x44\x23\x68\xb5\x11\x10\xda\x22\x8b\xd9\xb6\xf2\xe3\x4a\x4a\x06\xa3\x8b\xff\x49\x91\xa9\xaf\x6f\xfc\xeb\x8b\x2b\x7d\xdb\xa3\xc9\xbe\xc2\xac\xb8\xbb\xe9\x85\x3a\xfc\x45\x73\x30\x4a\x7a\xe2\x39\x05\xf7\x37\xe1\x5c\x64\x34\x59\x20\x2f\xb8\xc1\x08\xa5\x7a\x54\xa5\x2a\xc6\x6a\x7e\x67\x85\xec\xe0\xe0\x30\x53\xb9\xaa\x14\x8c\xd3\xf9\x38\xcd\xd4\x75\xfd\xc7\x79\xaf\x09\x1b\xdb\x07\x84\xc6\xea\xef\xa6\xe9\xb0\x0f\xf4\xb8\x7b\x1f\x24\xd6\xdc\x71\x4b\x35\x85\x8e\x61\x15\x42\x83\xc6\xbf\xe1\xfc\x53\x57\xe7\x30\x3f\x6c\xea\x1a\xfa\x7b\x3d\xa5\x79\xa5\xca\x73\x4d\x86\x19\x85\x21\xcd\x32\x18\xcf\xf2\xc5\xb4\x00\x89\x1d\x8b\xe6\x3a\x44\x04\x1b\xba\x55\x83\xd0\x46\xac\x89\x29\xdc\xae\x01\x99\x7a\x4c\x17\x79\x05\x9f\x47\xc9\x6f\x6f\x36\x0b\x3b\xdf\x27\x90\x95\xb3\x97\x1e\x85\x51\x72\x04\xee\x7f\x99\x7b\x80\xbd\x69\xd9\x05\xe1\x51\xf2\x13\x00\x00\xff\xff\x03\x00\x02\xdb\x48\x89\x26\x04\x00\x00")
+
+func migrations20261019140000Add_toml_history_and_trust_scoreSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations20261019140000Add_toml_history_and_trust_scoreSql,
+		"migrations/20261019140000-add_toml_history_and_trust_score.sql",
+	)
+}
+
+func migrations20261019140000Add_toml_history_and_trust_scoreSql() (*asset, error) {
+	bytes, err := migrations20261019140000Add_toml_history_and_trust_scoreSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/20261019140000-add_toml_history_and_trust_score.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8d, 0x8d, 0x70, 0x4f, 0xce, 0x47, 0x94, 0xfb, 0x1b, 0x17, 0x83, 0x26, 0x4d, 0x1b, 0xef, 0xd, 0xac, 0xaa, 0xc2, 0x31, 0xc8, 0x97, 0xf6, 0xe5, 0x17, 0xcb, 0x33, 0x8f, 0x7a, 0x69, 0xfa, 0xd}}
+	return a, nil
+}
+
+var _migrations20261019140500Add_domain_registrationsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\xc1\x4e\x84\x30\x14\x45\xf7\xfd\x8a\xbb\xd4\x38\xe3\x0f\xcc\x0a\x85\x85\xb1\x33\x43\x1a\x58\xcc\x8a\x74\xe8\x13\x1a\xa0\x25\xed\x33\x38\x7e\xbd\x99\x81\x68\x30\xd1\xe5\xcd\x3d\xb9\x3d\x7d\x62\xbb\xc5\xc3\x60\x9b\xa0\x99\x50\x8e\xd7\x98\xfa\x41\x5b\x87\x40\x8d\x8d\x1c\x34\x5b\xef\x22\x74\x20\x74\x74\x21\x83\xf3\x05\xc5\x71\x2f\x51\x2a\xb9\x41\x6f\x3b\x9a\x63\x74\x7a\x8c\xad\xe7\xb8\x81\x76\x06\xad\xef\xcd\x75\x6c\x6a\xc9\x81\x5b\xfa\x9e\x3b\xf7\x04\x33\xbf\xe0\xdf\x6e\x4d\xa9\x24\x26\x1d\x17\x82\x02\x19\xe8\xba\xf6\xc1\x58\xd7\x80\x3d\x54\x9a\xe4\x8f\xe2\x59\x65\x49\x91\xa1\x48\x9e\x64\xb6\x0c\x54\x6b\xc5\x3b\x01\x00\xec\x87\xbe\x7a\x0f\x3d\x98\x3e\x18\x87\x63\x81\x43\x29\x25\x72\xf5\xb2\x4f\xd4\x09\xaf\xd9\x69\x73\xe3\x16\x87\x15\x35\x37\x3f\x1e\x95\x66\xb0\x1d\x28\xb2\x1e\x46\xfe\xfc\xc5\xd5\x2d\xd5\xdd\xdf\x90\xb8\xdf\x89\xd5\x75\x53\x3f\x39\x91\xaa\x63\xfe\xcf\x27\x76\xe2\x0b\x00\x00\xff\xff\x03\x00\x5c\x51\x68\x04\x91\x01\x00\x00")
+
+func migrations20261019140500Add_domain_registrationsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations20261019140500Add_domain_registrationsSql,
+		"migrations/20261019140500-add_domain_registrations.sql",
+	)
+}
+
+func migrations20261019140500Add_domain_registrationsSql() (*asset, error) {
+	bytes, err := migrations20261019140500Add_domain_registrationsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/20261019140500-add_domain_registrations.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x16, 0xd0, 0x28, 0x3d, 0xa0, 0x13, 0x68, 0x94, 0xee, 0xf0, 0xe6, 0xf6, 0xb1, 0x8b, 0x40, 0xcb, 0x14, 0xbf, 0xdc, 0xd9, 0xf6, 0x76, 0xb3, 0xad, 0xe0, 0x56, 0xba, 0xe, 0x5a, 0xbb, 0x3a, 0x77}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -390,17 +474,21 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"migrations/20190404184050-initial.sql":                         migrations20190404184050InitialSql,
-	"migrations/20190405112544-increase_asset_code_size.sql":        migrations20190405112544Increase_asset_code_sizeSql,
-	"migrations/20190408115724-add_new_asset_fields.sql":            migrations20190408115724Add_new_asset_fieldsSql,
-	"migrations/20190408155841-add_issuers_table.sql":               migrations20190408155841Add_issuers_tableSql,
-	"migrations/20190409152216-add_trades_table.sql":                migrations20190409152216Add_trades_tableSql,
-	"migrations/20190409172610-rename_assets_desc_description.sql":  migrations20190409172610Rename_assets_desc_descriptionSql,
-	"migrations/20190410094830-add_assets_issuer_account_field.sql": migrations20190410094830Add_assets_issuer_account_fieldSql,
-	"migrations/20190411165735-data_seed_and_indices.sql":           migrations20190411165735Data_seed_and_indicesSql,
-	"migrations/20190425110313-add_orderbook_stats.sql":             migrations20190425110313Add_orderbook_statsSql,
-	"migrations/20190426092321-add_aggregated_orderbook_view.sql":   migrations20190426092321Add_aggregated_orderbook_viewSql,
-	"migrations/20220909100700-trades_pk_to_bigint.sql":             migrations20220909100700Trades_pk_to_bigintSql,
+	"migrations/20190404184050-initial.sql":                                     migrations20190404184050InitialSql,
+	"migrations/20190405112544-increase_asset_code_size.sql":                    migrations20190405112544Increase_asset_code_sizeSql,
+	"migrations/20190408115724-add_new_asset_fields.sql":                        migrations20190408115724Add_new_asset_fieldsSql,
+	"migrations/20190408155841-add_issuers_table.sql":                           migrations20190408155841Add_issuers_tableSql,
+	"migrations/20190409152216-add_trades_table.sql":                            migrations20190409152216Add_trades_tableSql,
+	"migrations/20190409172610-rename_assets_desc_description.sql":              migrations20190409172610Rename_assets_desc_descriptionSql,
+	"migrations/20190410094830-add_assets_issuer_account_field.sql":             migrations20190410094830Add_assets_issuer_account_fieldSql,
+	"migrations/20190411165735-data_seed_and_indices.sql":                       migrations20190411165735Data_seed_and_indicesSql,
+	"migrations/20190425110313-add_orderbook_stats.sql":                         migrations20190425110313Add_orderbook_statsSql,
+	"migrations/20190426092321-add_aggregated_orderbook_view.sql":               migrations20190426092321Add_aggregated_orderbook_viewSql,
+	"migrations/20220909100700-trades_pk_to_bigint.sql":                         migrations20220909100700Trades_pk_to_bigintSql,
+	"migrations/20261019120000-add_ingestion_cursors_and_market_aggregates.sql": migrations20261019120000Add_ingestion_cursors_and_market_aggregatesSql,
+	"migrations/20261019130000-add_market_aggregate_resolutions.sql":            migrations20261019130000Add_market_aggregate_resolutionsSql,
+	"migrations/20261019140000-add_toml_history_and_trust_score.sql":            migrations20261019140000Add_toml_history_and_trust_scoreSql,
+	"migrations/20261019140500-add_domain_registrations.sql":                    migrations20261019140500Add_domain_registrationsSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -447,17 +535,21 @@ type bintree struct {
 
 var _bintree = &bintree{nil, map[string]*bintree{
 	"migrations": {nil, map[string]*bintree{
-		"20190404184050-initial.sql":                         {migrations20190404184050InitialSql, map[string]*bintree{}},
-		"20190405112544-increase_asset_code_size.sql":        {migrations20190405112544Increase_asset_code_sizeSql, map[string]*bintree{}},
-		"20190408115724-add_new_asset_fields.sql":            {migrations20190408115724Add_new_asset_fieldsSql, map[string]*bintree{}},
-		"20190408155841-add_issuers_table.sql":               {migrations20190408155841Add_issuers_tableSql, map[string]*bintree{}},
-		"20190409152216-add_trades_table.sql":                {migrations20190409152216Add_trades_tableSql, map[string]*bintree{}},
-		"20190409172610-rename_assets_desc_description.sql":  {migrations20190409172610Rename_assets_desc_descriptionSql, map[string]*bintree{}},
-		"20190410094830-add_assets_issuer_account_field.sql": {migrations20190410094830Add_assets_issuer_account_fieldSql, map[string]*bintree{}},
-		"20190411165735-data_seed_and_indices.sql":           {migrations20190411165735Data_seed_and_indicesSql, map[string]*bintree{}},
-		"20190425110313-add_orderbook_stats.sql":             {migrations20190425110313Add_orderbook_statsSql, map[string]*bintree{}},
-		"20190426092321-add_aggregated_orderbook_view.sql":   {migrations20190426092321Add_aggregated_orderbook_viewSql, map[string]*bintree{}},
-		"20220909100700-trades_pk_to_bigint.sql":             {migrations20220909100700Trades_pk_to_bigintSql, map[string]*bintree{}},
+		"20190404184050-initial.sql":                                     {migrations20190404184050InitialSql, map[string]*bintree{}},
+		"20190405112544-increase_asset_code_size.sql":                    {migrations20190405112544Increase_asset_code_sizeSql, map[string]*bintree{}},
+		"20190408115724-add_new_asset_fields.sql":                        {migrations20190408115724Add_new_asset_fieldsSql, map[string]*bintree{}},
+		"20190408155841-add_issuers_table.sql":                           {migrations20190408155841Add_issuers_tableSql, map[string]*bintree{}},
+		"20190409152216-add_trades_table.sql":                            {migrations20190409152216Add_trades_tableSql, map[string]*bintree{}},
+		"20190409172610-rename_assets_desc_description.sql":              {migrations20190409172610Rename_assets_desc_descriptionSql, map[string]*bintree{}},
+		"20190410094830-add_assets_issuer_account_field.sql":             {migrations20190410094830Add_assets_issuer_account_fieldSql, map[string]*bintree{}},
+		"20190411165735-data_seed_and_indices.sql":                       {migrations20190411165735Data_seed_and_indicesSql, map[string]*bintree{}},
+		"20190425110313-add_orderbook_stats.sql":                         {migrations20190425110313Add_orderbook_statsSql, map[string]*bintree{}},
+		"20190426092321-add_aggregated_orderbook_view.sql":               {migrations20190426092321Add_aggregated_orderbook_viewSql, map[string]*bintree{}},
+		"20220909100700-trades_pk_to_bigint.sql":                         {migrations20220909100700Trades_pk_to_bigintSql, map[string]*bintree{}},
+		"20261019120000-add_ingestion_cursors_and_market_aggregates.sql": {migrations20261019120000Add_ingestion_cursors_and_market_aggregatesSql, map[string]*bintree{}},
+		"20261019130000-add_market_aggregate_resolutions.sql":            {migrations20261019130000Add_market_aggregate_resolutionsSql, map[string]*bintree{}},
+		"20261019140000-add_toml_history_and_trust_score.sql":            {migrations20261019140000Add_toml_history_and_trust_scoreSql, map[string]*bintree{}},
+		"20261019140500-add_domain_registrations.sql":                    {migrations20261019140500Add_domain_registrationsSql, map[string]*bintree{}},
 	}},
 }}
 
@@ -2,6 +2,7 @@ package tickerdb
 
 import (
 	"context"
+	"time"
 )
 
 // InsertOrUpdateAsset inserts an Asset on the database (if new),
@@ -96,3 +97,45 @@ func (s *TickerSession) GetAssetsWithNestedIssuer(ctx context.Context) (assets [
 
 	return
 }
+
+// RetrieveAssetTrustFactors retrieves the data the trust scores of all assets
+// are computed from. The domain age of an asset is 0 if the registration of
+// the domain of its TOML file is unknown, and its suspicious changes are only
+// considered if they were detected since suspiciousSince.
+func (s *TickerSession) RetrieveAssetTrustFactors(ctx context.Context, suspiciousSince time.Time) (factors []AssetTrustFactors, err error) {
+	err = s.SelectRaw(ctx, &factors, `
+		SELECT
+			a.id AS asset_id,
+			a.is_valid,
+			a.asset_controlled_by_domain,
+			a.num_accounts,
+			COALESCE(extract(epoch FROM now() - dr.registered_at) / 86400, 0)::double precision AS domain_age_days,
+			COALESCE(t.trade_count, 0) AS trade_count_7d,
+			EXISTS(
+				SELECT 1 FROM toml_changes AS tc
+				WHERE tc.toml_url = i.toml_url AND tc.is_suspicious = TRUE AND tc.created_at >= ?
+			) AS has_recent_suspicious_change
+		FROM assets AS a
+			JOIN issuers AS i ON a.issuer_id = i.id
+			LEFT JOIN domain_registrations AS dr ON dr.toml_url = i.toml_url
+			LEFT JOIN (
+				SELECT asset_id, count(*) AS trade_count
+				FROM (
+					SELECT base_asset_id AS asset_id FROM trades
+					WHERE ledger_close_time > now() - interval '7 days'
+					UNION ALL
+					SELECT counter_asset_id AS asset_id FROM trades
+					WHERE ledger_close_time > now() - interval '7 days'
+				) AS asset_trades
+				GROUP BY asset_id
+			) AS t ON t.asset_id = a.id`,
+		suspiciousSince,
+	)
+	return
+}
+
+// UpdateAssetTrustScore sets the trust score of the asset with the given ID.
+func (s *TickerSession) UpdateAssetTrustScore(ctx context.Context, id int32, score float64) error {
+	_, err := s.ExecRaw(ctx, "UPDATE assets SET trust_score = ? WHERE id = ?", score, id)
+	return err
+}
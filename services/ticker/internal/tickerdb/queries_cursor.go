@@ -0,0 +1,27 @@
+package tickerdb
+
+import (
+	"context"
+	"time"
+)
+
+// GetIngestionCursor returns the cursor stored for the ingestion stream with
+// the given name, or an empty string if the stream was never started.
+func (s *TickerSession) GetIngestionCursor(ctx context.Context, name string) (cursor string, err error) {
+	err = s.GetRaw(ctx, &cursor, "SELECT cursor FROM ingestion_cursors WHERE name = ?", name)
+	if s.NoRows(err) {
+		err = nil
+	}
+	return
+}
+
+// SetIngestionCursor stores the cursor of the ingestion stream with the given
+// name, replacing the previous one.
+func (s *TickerSession) SetIngestionCursor(ctx context.Context, name string, cursor string) error {
+	c := IngestionCursor{
+		Name:      name,
+		Cursor:    cursor,
+		UpdatedAt: time.Now(),
+	}
+	return s.performUpsertQuery(ctx, c, "ingestion_cursors", "ingestion_cursors_pkey", []string{"name"})
+}
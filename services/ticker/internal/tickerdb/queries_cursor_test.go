@@ -0,0 +1,49 @@
+package tickerdb
+
+import (
+	"context"
+	"testing"
+
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestionCursor(t *testing.T) {
+	db := OpenTestDBConnection(t)
+	defer db.Close()
+
+	var session TickerSession
+	session.DB = db.Open()
+	ctx := context.Background()
+	defer session.DB.Close()
+
+	// Run migrations to make sure the tests are run
+	// on the most updated schema version
+	migrations := &migrate.FileMigrationSource{
+		Dir: "./migrations",
+	}
+	_, err := migrate.Exec(session.DB.DB, "postgres", migrations, migrate.Up)
+	require.NoError(t, err)
+
+	// Streams that never started have no cursor:
+	cursor, err := session.GetIngestionCursor(ctx, "trades")
+	require.NoError(t, err)
+	assert.Equal(t, "", cursor)
+
+	require.NoError(t, session.SetIngestionCursor(ctx, "trades", "123-1"))
+	require.NoError(t, session.SetIngestionCursor(ctx, "other", "1"))
+	cursor, err = session.GetIngestionCursor(ctx, "trades")
+	require.NoError(t, err)
+	assert.Equal(t, "123-1", cursor)
+
+	// Setting the cursor again replaces it:
+	require.NoError(t, session.SetIngestionCursor(ctx, "trades", "124-2"))
+	cursor, err = session.GetIngestionCursor(ctx, "trades")
+	require.NoError(t, err)
+	assert.Equal(t, "124-2", cursor)
+
+	cursor, err = session.GetIngestionCursor(ctx, "other")
+	require.NoError(t, err)
+	assert.Equal(t, "1", cursor)
+}
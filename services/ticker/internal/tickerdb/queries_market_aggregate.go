@@ -0,0 +1,208 @@
+package tickerdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Resolutions of the market aggregates, in seconds.
+const (
+	Resolution1m  int32 = 60
+	Resolution5m  int32 = 300
+	Resolution15m int32 = 900
+	Resolution1h  int32 = 3600
+	Resolution4h  int32 = 14400
+	Resolution1d  int32 = 86400
+	Resolution1w  int32 = 604800
+)
+
+// MarketAggregateResolutions lists the resolutions the market aggregates are
+// maintained at. Weekly aggregates start on Mondays, the other ones are
+// aligned on the Unix epoch.
+var MarketAggregateResolutions = []int32{
+	Resolution1m,
+	Resolution5m,
+	Resolution15m,
+	Resolution1h,
+	Resolution4h,
+	Resolution1d,
+	Resolution1w,
+}
+
+// InsertTradeWithAggregate inserts a trade in the database and adds it to the
+// aggregates of its market at every resolution, in a single statement. Trades
+// that are already in the database (i.e. orbitr_id already exists) are ignored
+// and inserted is false.
+func (s *TickerSession) InsertTradeWithAggregate(ctx context.Context, trade Trade) (inserted bool, err error) {
+	dbFields := getDBFieldTags(trade, true)
+	dbValues := getDBFieldValues(trade, true)
+
+	qs := "WITH inserted AS ("
+	qs += " INSERT INTO trades (" + strings.Join(dbFields, ", ") + ")"
+	qs += " VALUES (" + generatePlaceholders(dbValues) + ")"
+	qs += " ON CONFLICT ON CONSTRAINT trades_orbitr_id_key DO NOTHING"
+	qs += " RETURNING base_asset_id, counter_asset_id, ledger_close_time, base_amount, counter_amount, price"
+	qs += ")" + withResolutions(upsertMarketAggregateQuery)
+
+	res, err := s.ExecRaw(ctx, qs, dbValues...)
+	if err != nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	inserted = n > 0
+	return
+}
+
+// RefreshMarketAggregates recomputes the market aggregates from the trades in
+// the database, starting with the aggregates that include the given time. It
+// is used after trades are inserted in bulk, e.g. when backfilling.
+func (s *TickerSession) RefreshMarketAggregates(ctx context.Context, since time.Time) error {
+	_, err := s.ExecRaw(ctx, withResolutions(refreshMarketAggregatesQuery), since)
+	return err
+}
+
+// RetrieveMarketAggregates retrieves the market aggregates of the given
+// resolution starting at or after the given time, ordered by market and
+// interval.
+func (s *TickerSession) RetrieveMarketAggregates(ctx context.Context, resolution int32, since time.Time) (aggs []MarketAggregate, err error) {
+	err = s.SelectRaw(ctx, &aggs, `
+		SELECT *
+		FROM market_aggregates
+		WHERE resolution = ? AND interval_start >= ?
+		ORDER BY base_asset_id, counter_asset_id, interval_start`,
+		resolution,
+		since,
+	)
+	return
+}
+
+// RetrieveCandles retrieves the market aggregates of the given resolution for
+// the market between the base and counter assets provided in the parameters,
+// starting in [since, until). If there are more than limit aggregates, the
+// most recent ones are returned. Aggregates are ordered by interval.
+func (s *TickerSession) RetrieveCandles(ctx context.Context,
+	baseAssetCode string,
+	baseAssetIssuer string,
+	counterAssetCode string,
+	counterAssetIssuer string,
+	resolution int32,
+	since time.Time,
+	until time.Time,
+	limit int,
+) (aggs []MarketAggregate, err error) {
+	err = s.SelectRaw(ctx, &aggs, `
+		SELECT * FROM (
+			SELECT ma.*
+			FROM market_aggregates AS ma
+				JOIN assets AS bAsset ON ma.base_asset_id = bAsset.id
+				JOIN assets AS cAsset ON ma.counter_asset_id = cAsset.id
+			WHERE bAsset.code = ? AND bAsset.issuer_account = ?
+				AND cAsset.code = ? AND cAsset.issuer_account = ?
+				AND ma.resolution = ?
+				AND ma.interval_start >= ? AND ma.interval_start < ?
+			ORDER BY ma.interval_start DESC
+			LIMIT ?
+		) AS candles
+		ORDER BY interval_start ASC`,
+		baseAssetCode,
+		baseAssetIssuer,
+		counterAssetCode,
+		counterAssetIssuer,
+		resolution,
+		since,
+		until,
+		limit,
+	)
+	return
+}
+
+// DeleteOldMarketAggregates deletes the market aggregates in the database
+// for intervals starting before minDate.
+func (s *TickerSession) DeleteOldMarketAggregates(ctx context.Context, minDate time.Time) error {
+	_, err := s.ExecRaw(ctx, "DELETE FROM market_aggregates WHERE interval_start < ?", minDate)
+	return err
+}
+
+// withResolutions replaces the __RESOLUTIONS__ placeholder of a query with the
+// values of MarketAggregateResolutions, e.g. "(60), (300)".
+func withResolutions(query string) string {
+	var values []string
+	for _, resolution := range MarketAggregateResolutions {
+		values = append(values, fmt.Sprintf("(%d)", resolution))
+	}
+	return strings.Replace(query, "__RESOLUTIONS__", strings.Join(values, ", "), -1)
+}
+
+// upsertMarketAggregateQuery adds the rows of the "inserted" trades to the
+// aggregates of their market at every resolution.
+var upsertMarketAggregateQuery = `
+INSERT INTO market_aggregates (
+	base_asset_id, counter_asset_id, resolution, interval_start,
+	base_volume, counter_volume, trade_count,
+	open_price, highest_price, lowest_price, close_price,
+	first_ledger_close_time, last_ledger_close_time
+)
+SELECT
+	base_asset_id, counter_asset_id, r.resolution,
+	market_aggregate_interval_start(ledger_close_time, r.resolution),
+	base_amount, counter_amount, 1,
+	price, price, price, price,
+	ledger_close_time, ledger_close_time
+FROM inserted
+	CROSS JOIN (VALUES __RESOLUTIONS__) AS r (resolution)
+ON CONFLICT ON CONSTRAINT market_aggregates_base_counter_resolution_interval_key DO UPDATE SET
+	base_volume = market_aggregates.base_volume + EXCLUDED.base_volume,
+	counter_volume = market_aggregates.counter_volume + EXCLUDED.counter_volume,
+	trade_count = market_aggregates.trade_count + EXCLUDED.trade_count,
+	open_price = CASE
+		WHEN EXCLUDED.first_ledger_close_time < market_aggregates.first_ledger_close_time
+		THEN EXCLUDED.open_price ELSE market_aggregates.open_price END,
+	highest_price = GREATEST(market_aggregates.highest_price, EXCLUDED.highest_price),
+	lowest_price = LEAST(market_aggregates.lowest_price, EXCLUDED.lowest_price),
+	close_price = CASE
+		WHEN EXCLUDED.last_ledger_close_time >= market_aggregates.last_ledger_close_time
+		THEN EXCLUDED.close_price ELSE market_aggregates.close_price END,
+	first_ledger_close_time = LEAST(market_aggregates.first_ledger_close_time, EXCLUDED.first_ledger_close_time),
+	last_ledger_close_time = GREATEST(market_aggregates.last_ledger_close_time, EXCLUDED.last_ledger_close_time);
+`
+
+var refreshMarketAggregatesQuery = `
+INSERT INTO market_aggregates (
+	base_asset_id, counter_asset_id, resolution, interval_start,
+	base_volume, counter_volume, trade_count,
+	open_price, highest_price, lowest_price, close_price,
+	first_ledger_close_time, last_ledger_close_time
+)
+SELECT
+	t.base_asset_id,
+	t.counter_asset_id,
+	r.resolution,
+	market_aggregate_interval_start(t.ledger_close_time, r.resolution) AS interval_start,
+	sum(t.base_amount),
+	sum(t.counter_amount),
+	count(t.base_amount),
+	(array_agg(t.price ORDER BY t.ledger_close_time ASC))[1],
+	max(t.price),
+	min(t.price),
+	(array_agg(t.price ORDER BY t.ledger_close_time DESC))[1],
+	min(t.ledger_close_time),
+	max(t.ledger_close_time)
+FROM trades AS t
+	CROSS JOIN (VALUES __RESOLUTIONS__) AS r (resolution)
+WHERE t.ledger_close_time >= market_aggregate_interval_start(?, r.resolution)
+	AND t.base_asset_id IS NOT NULL
+	AND t.counter_asset_id IS NOT NULL
+GROUP BY t.base_asset_id, t.counter_asset_id, r.resolution, interval_start
+ON CONFLICT ON CONSTRAINT market_aggregates_base_counter_resolution_interval_key DO UPDATE SET
+	base_volume = EXCLUDED.base_volume,
+	counter_volume = EXCLUDED.counter_volume,
+	trade_count = EXCLUDED.trade_count,
+	open_price = EXCLUDED.open_price,
+	highest_price = EXCLUDED.highest_price,
+	lowest_price = EXCLUDED.lowest_price,
+	close_price = EXCLUDED.close_price,
+	first_ledger_close_time = EXCLUDED.first_ledger_close_time,
+	last_ledger_close_time = EXCLUDED.last_ledger_close_time;
+`
@@ -0,0 +1,165 @@
+package tickerdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarketAggregates(t *testing.T) {
+	db := OpenTestDBConnection(t)
+	defer db.Close()
+
+	var session TickerSession
+	session.DB = db.Open()
+	ctx := context.Background()
+	defer session.DB.Close()
+
+	// Run migrations to make sure the tests are run
+	// on the most updated schema version
+	migrations := &migrate.FileMigrationSource{
+		Dir: "./migrations",
+	}
+	_, err := migrate.Exec(session.DB.DB, "postgres", migrations, migrate.Up)
+	require.NoError(t, err)
+
+	// Adding a seed issuer to be used later:
+	tbl := session.GetTable("issuers")
+	_, err = tbl.Insert(Issuer{
+		PublicKey: "GCF3TQXKZJNFJK7HCMNE2O2CUNKCJH2Y2ROISTBPLC7C5EIA5NNG2XZB",
+		Name:      "FOO BAR",
+	}).IgnoreCols("id").Exec(ctx)
+	require.NoError(t, err)
+	var issuer Issuer
+	err = session.GetRaw(ctx, &issuer, `
+		SELECT *
+		FROM issuers
+		ORDER BY id DESC
+		LIMIT 1`,
+	)
+	require.NoError(t, err)
+
+	// Adding the base and counter assets:
+	var assets []Asset
+	for _, code := range []string{"MTRQ", "BTC"} {
+		err = session.InsertOrUpdateAsset(ctx, &Asset{
+			Code:     code,
+			IssuerID: issuer.ID,
+		}, []string{"code", "issuer_id"})
+		require.NoError(t, err)
+		var asset Asset
+		err = session.GetRaw(ctx, &asset, `
+			SELECT *
+			FROM assets
+			ORDER BY id DESC
+			LIMIT 1`,
+		)
+		require.NoError(t, err)
+		assets = append(assets, asset)
+	}
+
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	trade := func(id string, offset time.Duration, amount, price float64) Trade {
+		return Trade{
+			OrbitRID:        id,
+			BaseAssetID:     assets[0].ID,
+			CounterAssetID:  assets[1].ID,
+			LedgerCloseTime: hour.Add(offset),
+			BaseAmount:      amount,
+			CounterAmount:   amount * price,
+			Price:           price,
+		}
+	}
+
+	// Trades are streamed out of order within the first hour:
+	trades := []Trade{
+		trade("hrzid1", 10*time.Minute, 10, 2),
+		trade("hrzid2", 20*time.Minute, 5, 4),
+		trade("hrzid3", 5*time.Minute, 1, 1),
+		trade("hrzid4", 70*time.Minute, 2, 3),
+	}
+	for _, tr := range trades {
+		var inserted bool
+		inserted, err = session.InsertTradeWithAggregate(ctx, tr)
+		require.NoError(t, err)
+		assert.True(t, inserted)
+	}
+
+	// Trades already ingested are not aggregated twice:
+	inserted, err := session.InsertTradeWithAggregate(ctx, trades[0])
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	aggs, err := session.RetrieveMarketAggregates(ctx, Resolution1h, hour)
+	require.NoError(t, err)
+	require.Len(t, aggs, 2)
+
+	first := aggs[0]
+	assert.Equal(t, assets[0].ID, first.BaseAssetID)
+	assert.Equal(t, assets[1].ID, first.CounterAssetID)
+	assert.True(t, hour.Equal(first.IntervalStart))
+	assert.Equal(t, 16.0, first.BaseVolume)
+	assert.Equal(t, 41.0, first.CounterVolume)
+	assert.Equal(t, int32(3), first.TradeCount)
+	assert.Equal(t, 1.0, first.Open)
+	assert.Equal(t, 4.0, first.High)
+	assert.Equal(t, 1.0, first.Low)
+	assert.Equal(t, 4.0, first.Close)
+	assert.WithinDuration(t, hour.Add(5*time.Minute), first.FirstLedgerCloseTime, time.Millisecond)
+	assert.WithinDuration(t, hour.Add(20*time.Minute), first.LastLedgerCloseTime, time.Millisecond)
+
+	second := aggs[1]
+	assert.True(t, hour.Add(time.Hour).Equal(second.IntervalStart))
+	assert.Equal(t, int32(1), second.TradeCount)
+	assert.Equal(t, 3.0, second.Open)
+	assert.Equal(t, 3.0, second.Close)
+
+	// Refreshing the aggregates from the trades gives the same result:
+	_, err = session.ExecRaw(ctx, "DELETE FROM market_aggregates")
+	require.NoError(t, err)
+	err = session.BulkInsertTrades(ctx, []Trade{trade("hrzid5", 30*time.Minute, 4, 0.5)})
+	require.NoError(t, err)
+	err = session.RefreshMarketAggregates(ctx, hour.Add(30*time.Minute))
+	require.NoError(t, err)
+
+	aggs, err = session.RetrieveMarketAggregates(ctx, Resolution1h, hour)
+	require.NoError(t, err)
+	require.Len(t, aggs, 2)
+	assert.Equal(t, 20.0, aggs[0].BaseVolume)
+	assert.Equal(t, int32(4), aggs[0].TradeCount)
+	assert.Equal(t, 1.0, aggs[0].Open)
+	assert.Equal(t, 0.5, aggs[0].Low)
+	assert.Equal(t, 4.0, aggs[0].Close)
+	assert.Equal(t, int32(1), aggs[1].TradeCount)
+
+	// Each trade has its own 1-minute candle, and the daily candle holds
+	// them all unless the day changed in between:
+	candles, err := session.RetrieveCandles(ctx, "MTRQ", "", "BTC", "", Resolution1m, hour, hour.Add(2*time.Hour), 3)
+	require.NoError(t, err)
+	require.Len(t, candles, 3)
+	assert.True(t, hour.Add(20*time.Minute).Equal(candles[0].IntervalStart))
+	assert.True(t, hour.Add(30*time.Minute).Equal(candles[1].IntervalStart))
+	assert.True(t, hour.Add(70*time.Minute).Equal(candles[2].IntervalStart))
+	assert.Equal(t, Resolution1m, candles[2].Resolution)
+	assert.Equal(t, 3.0, candles[2].Close)
+
+	candles, err = session.RetrieveCandles(ctx, "MTRQ", "", "BTC", "", Resolution1d, hour.AddDate(0, 0, -1), hour.Add(2*time.Hour), 10)
+	require.NoError(t, err)
+	var dailyTrades int32
+	for _, candle := range candles {
+		dailyTrades += candle.TradeCount
+	}
+	assert.Equal(t, int32(5), dailyTrades)
+
+	// Old aggregates can be deleted:
+	err = session.DeleteOldMarketAggregates(ctx, hour.Add(time.Hour))
+	require.NoError(t, err)
+	aggs, err = session.RetrieveMarketAggregates(ctx, Resolution1h, hour)
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	assert.True(t, hour.Add(time.Hour).Equal(aggs[0].IntervalStart))
+}
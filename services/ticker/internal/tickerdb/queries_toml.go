@@ -0,0 +1,121 @@
+package tickerdb
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// SuspiciousTOMLChangesWindow is how long a suspicious TOML change is reported
+// for, and affects the trust score of the assets of the TOML.
+const SuspiciousTOMLChangesWindow = 30 * 24 * time.Hour
+
+// RetrieveTOMLSnapshots retrieves the snapshots stored for the TOML file at
+// the given URL, newest first. It returns none if the TOML was never stored.
+func (s *TickerSession) RetrieveTOMLSnapshots(ctx context.Context, tomlURL string) (snapshots []TOMLSnapshot, err error) {
+	err = s.SelectRaw(ctx, &snapshots, `
+		SELECT *
+		FROM toml_snapshots
+		WHERE toml_url = ?
+		ORDER BY created_at DESC, id DESC`,
+		tomlURL,
+	)
+	return
+}
+
+// InsertTOMLSnapshot inserts a snapshot of a TOML file along with the changes
+// from the previous snapshot, in a single statement. The SnapshotID, TOMLURL
+// and CreatedAt fields of the changes are set from the snapshot.
+func (s *TickerSession) InsertTOMLSnapshot(ctx context.Context, snapshot TOMLSnapshot, changes []TOMLChange) error {
+	dbFields := getDBFieldTags(snapshot, true)
+	dbValues := getDBFieldValues(snapshot, true)
+
+	qs := "INSERT INTO toml_snapshots (" + strings.Join(dbFields, ", ") + ")"
+	qs += " VALUES (" + generatePlaceholders(dbValues) + ")"
+	if len(changes) == 0 {
+		_, err := s.ExecRaw(ctx, qs, dbValues...)
+		return err
+	}
+
+	var values []string
+	for _, c := range changes {
+		values = append(values, "(?, ?, ?, ?::boolean)")
+		dbValues = append(dbValues, c.Field, c.OldValue, c.NewValue, c.IsSuspicious)
+	}
+	qs = "WITH snapshot AS (" + qs + " RETURNING id, toml_url, created_at)"
+	qs += " INSERT INTO toml_changes (snapshot_id, toml_url, field, old_value, new_value, is_suspicious, created_at)"
+	qs += " SELECT snapshot.id, snapshot.toml_url, c.field, c.old_value, c.new_value, c.is_suspicious, snapshot.created_at"
+	qs += " FROM snapshot CROSS JOIN (VALUES " + strings.Join(values, ", ") + ")"
+	qs += " AS c (field, old_value, new_value, is_suspicious);"
+
+	_, err := s.ExecRaw(ctx, qs, dbValues...)
+	return err
+}
+
+// RetrieveAssetTOMLSnapshots retrieves the most recent snapshots of the TOML
+// file of the issuer of the given asset, newest first. At most limit
+// snapshots are returned.
+func (s *TickerSession) RetrieveAssetTOMLSnapshots(ctx context.Context,
+	code string,
+	issuerAccount string,
+	limit int,
+) (snapshots []TOMLSnapshot, err error) {
+	err = s.SelectRaw(ctx, &snapshots, `
+		SELECT ts.*
+		FROM toml_snapshots AS ts
+			JOIN issuers AS i ON ts.toml_url = i.toml_url
+			JOIN assets AS a ON a.issuer_id = i.id
+		WHERE a.code = ? AND a.issuer_account = ?
+		ORDER BY ts.created_at DESC, ts.id DESC
+		LIMIT ?`,
+		code,
+		issuerAccount,
+		limit,
+	)
+	return
+}
+
+// RetrieveTOMLChanges retrieves the changes of the TOML file at the given URL
+// detected since the given time, ordered by detection time.
+func (s *TickerSession) RetrieveTOMLChanges(ctx context.Context, tomlURL string, since time.Time) (changes []TOMLChange, err error) {
+	err = s.SelectRaw(ctx, &changes, `
+		SELECT *
+		FROM toml_changes
+		WHERE toml_url = ? AND created_at >= ?
+		ORDER BY created_at, id`,
+		tomlURL,
+		since,
+	)
+	return
+}
+
+// RetrieveSuspiciousTOMLChanges retrieves the suspicious changes of all TOML
+// files detected since the given time, ordered by detection time.
+func (s *TickerSession) RetrieveSuspiciousTOMLChanges(ctx context.Context, since time.Time) (changes []TOMLChange, err error) {
+	err = s.SelectRaw(ctx, &changes, `
+		SELECT *
+		FROM toml_changes
+		WHERE is_suspicious = TRUE AND created_at >= ?
+		ORDER BY created_at, id`,
+		since,
+	)
+	return
+}
+
+// GetDomainRegistration returns the registration of the domain of the TOML
+// file at the given URL. Found is false if it was never looked up.
+func (s *TickerSession) GetDomainRegistration(ctx context.Context, tomlURL string) (found bool, registration DomainRegistration, err error) {
+	err = s.GetRaw(ctx, &registration, "SELECT * FROM domain_registrations WHERE toml_url = ?", tomlURL)
+	if s.NoRows(err) {
+		err = nil
+		return
+	}
+	found = err == nil
+	return
+}
+
+// InsertOrUpdateDomainRegistration stores the registration of the domain of
+// a TOML file, replacing the previous one.
+func (s *TickerSession) InsertOrUpdateDomainRegistration(ctx context.Context, registration DomainRegistration) error {
+	return s.performUpsertQuery(ctx, registration, "domain_registrations", "domain_registrations_pkey", []string{"toml_url"})
+}
@@ -0,0 +1,158 @@
+package tickerdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOMLSnapshots(t *testing.T) {
+	db := OpenTestDBConnection(t)
+	defer db.Close()
+
+	var session TickerSession
+	session.DB = db.Open()
+	ctx := context.Background()
+	defer session.DB.Close()
+
+	// Run migrations to make sure the tests are run
+	// on the most updated schema version
+	migrations := &migrate.FileMigrationSource{
+		Dir: "./migrations",
+	}
+	_, err := migrate.Exec(session.DB.DB, "postgres", migrations, migrate.Up)
+	require.NoError(t, err)
+
+	tomlURL := "https://example.com/.well-known/stellar.toml"
+	issuerAccount := "GCF3TQXKZJNFJK7HCMNE2O2CUNKCJH2Y2ROISTBPLC7C5EIA5NNG2XZB"
+
+	// Adding a seed issuer and asset to be used later:
+	issuerID, err := session.InsertOrUpdateIssuer(ctx, &Issuer{
+		PublicKey: issuerAccount,
+		TOMLURL:   tomlURL,
+	}, []string{"public_key"})
+	require.NoError(t, err)
+	err = session.InsertOrUpdateAsset(ctx, &Asset{
+		Code:          "USD",
+		IssuerAccount: issuerAccount,
+		IssuerID:      issuerID,
+		IsValid:       true,
+		NumAccounts:   10,
+	}, []string{"code", "issuer_account", "issuer_id"})
+	require.NoError(t, err)
+
+	// TOMLs that were never stored have no snapshot:
+	snapshots, err := session.RetrieveTOMLSnapshots(ctx, tomlURL)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	firstTime := time.Now().Add(-time.Hour)
+	err = session.InsertTOMLSnapshot(ctx, TOMLSnapshot{
+		TOMLURL:     tomlURL,
+		ContentHash: "hash1",
+		Content:     `SIGNING_KEY="GA"`,
+		CreatedAt:   firstTime,
+	}, nil)
+	require.NoError(t, err)
+
+	secondTime := time.Now()
+	err = session.InsertTOMLSnapshot(ctx, TOMLSnapshot{
+		TOMLURL:     tomlURL,
+		ContentHash: "hash2",
+		Content:     `SIGNING_KEY="GB"`,
+		CreatedAt:   secondTime,
+	}, []TOMLChange{
+		{Field: "SIGNING_KEY", OldValue: "GA", NewValue: "GB", IsSuspicious: true},
+		{Field: "TRANSFER_SERVER", NewValue: "https://example.com"},
+	})
+	require.NoError(t, err)
+
+	snapshots, err = session.RetrieveTOMLSnapshots(ctx, tomlURL)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	latest := snapshots[0]
+	assert.Equal(t, "hash2", latest.ContentHash)
+	assert.Equal(t, `SIGNING_KEY="GB"`, latest.Content)
+	assert.Equal(t, "hash1", snapshots[1].ContentHash)
+
+	snapshots, err = session.RetrieveAssetTOMLSnapshots(ctx, "USD", issuerAccount, 10)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "hash2", snapshots[0].ContentHash)
+	assert.Equal(t, "hash1", snapshots[1].ContentHash)
+
+	snapshots, err = session.RetrieveAssetTOMLSnapshots(ctx, "USD", issuerAccount, 1)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "hash2", snapshots[0].ContentHash)
+
+	changes, err := session.RetrieveTOMLChanges(ctx, tomlURL, firstTime)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	for _, c := range changes {
+		assert.Equal(t, latest.ID, c.SnapshotID)
+		assert.Equal(t, tomlURL, c.TOMLURL)
+		assert.Equal(
+			t,
+			secondTime.Local().Round(time.Millisecond),
+			c.CreatedAt.Local().Round(time.Millisecond),
+		)
+	}
+
+	changes, err = session.RetrieveSuspiciousTOMLChanges(ctx, firstTime)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "SIGNING_KEY", changes[0].Field)
+	assert.Equal(t, "GA", changes[0].OldValue)
+	assert.Equal(t, "GB", changes[0].NewValue)
+
+	changes, err = session.RetrieveSuspiciousTOMLChanges(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// The TOML history and the domain registration are used to compute the
+	// trust factors of the asset:
+	factors, err := session.RetrieveAssetTrustFactors(ctx, firstTime)
+	require.NoError(t, err)
+	require.Len(t, factors, 1)
+	assert.Equal(t, 0.0, factors[0].DomainAgeDays)
+
+	found, _, err := session.GetDomainRegistration(ctx, tomlURL)
+	require.NoError(t, err)
+	assert.False(t, found)
+	registration := DomainRegistration{
+		TOMLURL:      tomlURL,
+		Domain:       "example.com",
+		RegisteredAt: time.Now().Add(-240 * time.Hour),
+		CheckedAt:    time.Now(),
+	}
+	err = session.InsertOrUpdateDomainRegistration(ctx, registration)
+	require.NoError(t, err)
+	registration.RegisteredAt = time.Now().Add(-48 * time.Hour)
+	err = session.InsertOrUpdateDomainRegistration(ctx, registration)
+	require.NoError(t, err)
+	found, dbRegistration, err := session.GetDomainRegistration(ctx, tomlURL)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "example.com", dbRegistration.Domain)
+
+	factors, err = session.RetrieveAssetTrustFactors(ctx, firstTime)
+	require.NoError(t, err)
+	require.Len(t, factors, 1)
+	assert.True(t, factors[0].IsValid)
+	assert.Equal(t, int32(10), factors[0].NumAccounts)
+	assert.InDelta(t, 2.0, factors[0].DomainAgeDays, 0.01)
+	assert.Equal(t, int64(0), factors[0].TradeCount7d)
+	assert.True(t, factors[0].HasRecentSuspiciousChange)
+
+	err = session.UpdateAssetTrustScore(ctx, factors[0].AssetID, 42.5)
+	require.NoError(t, err)
+	assets, err := session.GetAllValidAssets(ctx)
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	assert.Equal(t, 42.5, assets[0].TrustScore)
+}
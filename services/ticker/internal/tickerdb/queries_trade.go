@@ -32,6 +32,37 @@ func (s *TickerSession) GetLastTrade(ctx context.Context) (trade Trade, err erro
 	return
 }
 
+// RetrieveMarketTrades retrieves the trades between the given base and counter
+// assets closed in [since, until), newest first. If baseIsSeller is not nil,
+// only the trades whose base party is the seller, or the buyer, are returned.
+// If limit > 0, at most limit trades are returned.
+func (s *TickerSession) RetrieveMarketTrades(ctx context.Context,
+	baseAssetID int32,
+	counterAssetID int32,
+	since time.Time,
+	until time.Time,
+	baseIsSeller *bool,
+	limit int,
+) (trades []Trade, err error) {
+	qs := `
+		SELECT *
+		FROM trades
+		WHERE base_asset_id = ? AND counter_asset_id = ?
+			AND ledger_close_time >= ? AND ledger_close_time < ?`
+	args := []interface{}{baseAssetID, counterAssetID, since, until}
+	if baseIsSeller != nil {
+		qs += " AND base_is_seller = ?"
+		args = append(args, *baseIsSeller)
+	}
+	qs += " ORDER BY ledger_close_time DESC, id DESC"
+	if limit > 0 {
+		qs += " LIMIT ?"
+		args = append(args, limit)
+	}
+	err = s.SelectRaw(ctx, &trades, qs, args...)
+	return
+}
+
 // DeleteOldTrades deletes trades in the database older than minDate.
 func (s *TickerSession) DeleteOldTrades(ctx context.Context, minDate time.Time) error {
 	_, err := s.ExecRaw(ctx, "DELETE FROM trades WHERE ledger_close_time < ?", minDate)
@@ -350,3 +350,113 @@ func TestDeleteOldTrades(t *testing.T) {
 	assert.WithinDuration(t, now.Local(), trade1.LedgerCloseTime.Local(), 10*time.Millisecond)
 	assert.WithinDuration(t, oneDayAgo.Local(), trade2.LedgerCloseTime.Local(), 10*time.Millisecond)
 }
+
+func TestRetrieveMarketTrades(t *testing.T) {
+	db := OpenTestDBConnection(t)
+	defer db.Close()
+
+	var session TickerSession
+	session.DB = db.Open()
+	ctx := context.Background()
+	defer session.DB.Close()
+
+	// Run migrations to make sure the tests are run
+	// on the most updated schema version
+	migrations := &migrate.FileMigrationSource{
+		Dir: "./migrations",
+	}
+	_, err := migrate.Exec(session.DB.DB, "postgres", migrations, migrate.Up)
+	require.NoError(t, err)
+
+	// Adding a seed issuer to be used later:
+	tbl := session.GetTable("issuers")
+	_, err = tbl.Insert(Issuer{
+		PublicKey: "GCF3TQXKZJNFJK7HCMNE2O2CUNKCJH2Y2ROISTBPLC7C5EIA5NNG2XZB",
+		Name:      "FOO BAR",
+	}).IgnoreCols("id").Exec(ctx)
+	require.NoError(t, err)
+	var issuer Issuer
+	err = session.GetRaw(ctx, &issuer, `
+		SELECT *
+		FROM issuers
+		ORDER BY id DESC
+		LIMIT 1`,
+	)
+	require.NoError(t, err)
+
+	// Adding the base and counter assets:
+	var assets []Asset
+	for _, code := range []string{"MTRQ", "BTC"} {
+		err = session.InsertOrUpdateAsset(ctx, &Asset{
+			Code:     code,
+			IssuerID: issuer.ID,
+		}, []string{"code", "issuer_id"})
+		require.NoError(t, err)
+		var asset Asset
+		err = session.GetRaw(ctx, &asset, `
+			SELECT *
+			FROM assets
+			ORDER BY id DESC
+			LIMIT 1`,
+		)
+		require.NoError(t, err)
+		assets = append(assets, asset)
+	}
+
+	now := time.Now()
+	trades := []Trade{
+		{
+			OrbitRID:        "hrzid1",
+			BaseAssetID:     assets[0].ID,
+			CounterAssetID:  assets[1].ID,
+			LedgerCloseTime: now.Add(-3 * time.Hour),
+		},
+		{
+			OrbitRID:        "hrzid2",
+			BaseAssetID:     assets[0].ID,
+			CounterAssetID:  assets[1].ID,
+			BaseIsSeller:    true,
+			LedgerCloseTime: now.Add(-2 * time.Hour),
+		},
+		{
+			OrbitRID:        "hrzid3",
+			BaseAssetID:     assets[0].ID,
+			CounterAssetID:  assets[1].ID,
+			LedgerCloseTime: now.Add(-1 * time.Hour),
+		},
+		{
+			OrbitRID:        "hrzid4",
+			BaseAssetID:     assets[1].ID,
+			CounterAssetID:  assets[0].ID,
+			LedgerCloseTime: now.Add(-1 * time.Hour),
+		},
+	}
+	err = session.BulkInsertTrades(ctx, trades)
+	require.NoError(t, err)
+
+	// Trades of the market are returned newest first:
+	dbTrades, err := session.RetrieveMarketTrades(ctx, assets[0].ID, assets[1].ID, now.Add(-24*time.Hour), now, nil, 0)
+	require.NoError(t, err)
+	require.Len(t, dbTrades, 3)
+	assert.Equal(t, "hrzid3", dbTrades[0].OrbitRID)
+	assert.Equal(t, "hrzid2", dbTrades[1].OrbitRID)
+	assert.Equal(t, "hrzid1", dbTrades[2].OrbitRID)
+
+	// Filtering by time and limit:
+	dbTrades, err = session.RetrieveMarketTrades(ctx, assets[0].ID, assets[1].ID, now.Add(-150*time.Minute), now.Add(-30*time.Minute), nil, 1)
+	require.NoError(t, err)
+	require.Len(t, dbTrades, 1)
+	assert.Equal(t, "hrzid3", dbTrades[0].OrbitRID)
+
+	// Filtering by side:
+	baseIsSeller := true
+	dbTrades, err = session.RetrieveMarketTrades(ctx, assets[0].ID, assets[1].ID, now.Add(-24*time.Hour), now, &baseIsSeller, 0)
+	require.NoError(t, err)
+	require.Len(t, dbTrades, 1)
+	assert.Equal(t, "hrzid2", dbTrades[0].OrbitRID)
+	baseIsSeller = false
+	dbTrades, err = session.RetrieveMarketTrades(ctx, assets[0].ID, assets[1].ID, now.Add(-24*time.Hour), now, &baseIsSeller, 1)
+	require.NoError(t, err)
+	require.Len(t, dbTrades, 1)
+	assert.Equal(t, "hrzid3", dbTrades[0].OrbitRID)
+}
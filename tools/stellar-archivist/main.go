@@ -5,16 +5,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/metriqorg/go/historyarchive"
 	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
 )
 
 const checkpointFrequency = uint32(64)
@@ -48,6 +53,17 @@ type Options struct {
 	Last        int
 	Recent      bool
 	Profile     bool
+	// SyncStatePath, SyncInterval and MetricsAddr configure the sync
+	// command.
+	SyncStatePath string
+	SyncInterval  time.Duration
+	MetricsAddr   string
+	// Query, QueryCheckpoint, QueryKey and QueryAccount configure the query
+	// commands.
+	Query           TxQuery
+	QueryCheckpoint uint32
+	QueryKey        string
+	QueryAccount    string
 	Debug       bool
 	Trace       bool
 	CommandOpts historyarchive.CommandOptions
@@ -164,10 +180,97 @@ func repair(src string, dst string, opts *Options) {
 	}
 }
 
+func syncArchives(src string, dst string, opts *Options) {
+	srcArch := historyarchive.MustConnect(src, opts.ConnectOpts)
+	dstArch := historyarchive.MustConnect(dst, opts.ConnectOpts)
+
+	registry := prometheus.NewRegistry()
+	syncer, err := historyarchive.NewSyncer(srcArch, dstArch, historyarchive.SyncOptions{
+		StatePath:    opts.SyncStatePath,
+		PollInterval: opts.SyncInterval,
+		Concurrency:  opts.CommandOpts.Concurrency,
+		Low:          uint32(opts.Low),
+		SkipOptional: opts.CommandOpts.SkipOptional,
+		Registry:     registry,
+		Namespace:    "stellar_archivist",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if opts.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		go func() {
+			log.Fatal(http.ListenAndServe(opts.MetricsAddr, mux))
+		}()
+	}
+
+	log.Printf("syncing %v -> %v\n", src, dst)
+	if err = syncer.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func queryTxs(a string, opts *Options) {
+	arch := historyarchive.MustConnect(a, opts.ConnectOpts)
+	state, err := arch.GetRootHAS()
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Error getting HAS"))
+	}
+	passphrase := opts.ConnectOpts.NetworkPassphrase
+	if passphrase == "" {
+		passphrase = state.NetworkPassphrase
+	}
+	high := opts.High
+	if high > state.CurrentLedger {
+		high = state.CurrentLedger
+	}
+	low := uint32(opts.Low)
+	if low == 0 {
+		low = 1
+	}
+	err = queryTransactions(arch, passphrase, low, high, opts.Query, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func queryEntry(a string, opts *Options) {
+	arch := historyarchive.MustConnect(a, opts.ConnectOpts)
+	var key xdr.LedgerKey
+	if opts.QueryAccount != "" {
+		accountID, err := xdr.AddressToAccountId(opts.QueryAccount)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Invalid account"))
+		}
+		if err = key.SetAccount(accountID); err != nil {
+			log.Fatal(err)
+		}
+	} else if err := xdr.SafeUnmarshalBase64(opts.QueryKey, &key); err != nil {
+		log.Fatal(errors.Wrap(err, "Invalid ledger key"))
+	}
+	checkpoint := opts.QueryCheckpoint
+	if checkpoint == 0 {
+		state, err := arch.GetRootHAS()
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Error getting HAS"))
+		}
+		checkpoint = state.CurrentLedger
+	}
+	if err := queryLedgerEntry(context.Background(), arch, checkpoint, key, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func main() {
 
 	var opts Options
 	opts.ConnectOpts.CheckpointFrequency = checkpointFrequency
+	// Azure credentials are read from the environment, like the Azure CLI
+	// does, rather than from flags that would show up in process listings.
+	opts.ConnectOpts.AzureAccountKey = os.Getenv("AZURE_STORAGE_KEY")
+	opts.ConnectOpts.AzureSASToken = os.Getenv("AZURE_STORAGE_SAS_TOKEN")
 
 	rootCmd := &cobra.Command{
 		Use:   "stellar-archivist",
@@ -229,6 +332,27 @@ func main() {
 		"S3 endpoint to use",
 	)
 
+	rootCmd.PersistentFlags().BoolVar(
+		&opts.ConnectOpts.S3VirtualHostedStyle,
+		"s3virtualhostedstyle",
+		false,
+		"address S3 buckets as subdomains of the endpoint instead of path-style",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&opts.ConnectOpts.GCSEndpoint,
+		"gcsendpoint",
+		"",
+		"Google Cloud Storage endpoint to use",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&opts.ConnectOpts.AzureEndpoint,
+		"azureendpoint",
+		"",
+		"Azure Blob Storage endpoint to use (default https://<account>.blob.core.windows.net)",
+	)
+
 	rootCmd.PersistentFlags().BoolVarP(
 		&opts.CommandOpts.DryRun,
 		"dryrun",
@@ -333,6 +457,107 @@ func main() {
 		},
 	})
 
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "continuously copy new checkpoints of the source archive to the destination archive",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.SetupLogging()
+			opts.MaybeProfile()
+			src, dst := srcDst(args)
+			syncArchives(src, dst, &opts)
+		},
+	}
+	syncCmd.Flags().StringVar(
+		&opts.SyncStatePath,
+		"state-file",
+		"stellar-archivist-sync.json",
+		"file the sync progress is saved to, to resume after a restart",
+	)
+	syncCmd.Flags().DurationVar(
+		&opts.SyncInterval,
+		"interval",
+		historyarchive.DefaultSyncPollInterval,
+		"how often to poll the source archive for new checkpoints",
+	)
+	syncCmd.Flags().StringVar(
+		&opts.MetricsAddr,
+		"metrics-addr",
+		"",
+		"address to serve Prometheus metrics on at /metrics, e.g. :9100",
+	)
+	rootCmd.AddCommand(syncCmd)
+
+	queryCmd := &cobra.Command{
+		Use:   "query",
+		Short: "search archive contents, printing results as JSON lines",
+	}
+	queryCmd.PersistentFlags().StringVar(
+		&opts.ConnectOpts.NetworkPassphrase,
+		"network-passphrase",
+		"",
+		"network passphrase (default the one in the archive HAS)",
+	)
+	queryTxsCmd := &cobra.Command{
+		Use:   "transactions",
+		Short: "print transactions in the --low to --high ledger range matching all given filters",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.SetupLogging()
+			queryTxs(firstArg(args), &opts)
+		},
+	}
+	queryTxsCmd.Flags().StringVar(
+		&opts.Query.SourceAccount,
+		"source-account",
+		"",
+		"transaction, fee bump or operation source account",
+	)
+	queryTxsCmd.Flags().StringVar(
+		&opts.Query.OperationType,
+		"operation-type",
+		"",
+		"operation type, e.g. payment or manage_sell_offer",
+	)
+	queryTxsCmd.Flags().StringVar(
+		&opts.Query.Asset,
+		"asset",
+		"",
+		"asset involved in an operation, native or CODE:ISSUER",
+	)
+	queryTxsCmd.Flags().StringVar(
+		&opts.Query.Memo,
+		"memo",
+		"",
+		"memo text, id or hex hash",
+	)
+	queryEntryCmd := &cobra.Command{
+		Use:   "entry",
+		Short: "print the value of a ledger entry at a checkpoint",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.SetupLogging()
+			queryEntry(firstArg(args), &opts)
+		},
+	}
+	queryEntryCmd.Flags().Uint32Var(
+		&opts.QueryCheckpoint,
+		"checkpoint",
+		0,
+		"checkpoint ledger to read the entry at (default the latest)",
+	)
+	queryEntryCmd.Flags().StringVar(
+		&opts.QueryKey,
+		"key",
+		"",
+		"base64-encoded LedgerKey XDR of the entry",
+	)
+	queryEntryCmd.Flags().StringVar(
+		&opts.QueryAccount,
+		"account",
+		"",
+		"account to read the account entry of, instead of --key",
+	)
+	queryCmd.AddCommand(queryTxsCmd, queryEntryCmd)
+	rootCmd.AddCommand(queryCmd)
+
 	rootCmd.AddCommand(&cobra.Command{
 		Use: "dumpxdr",
 		Run: func(cmd *cobra.Command, args []string) {
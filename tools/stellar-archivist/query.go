@@ -0,0 +1,348 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/protocols/orbitr/operations"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// TxQuery selects transactions by their content. Empty fields match any
+// transaction.
+type TxQuery struct {
+	// SourceAccount matches the transaction, fee bump or operation source
+	// account.
+	SourceAccount string
+	// OperationType is an operation type as named by orbitr, e.g. "payment".
+	OperationType string
+	// Asset is "native" or "CODE:ISSUER" and matches operations sending,
+	// receiving, trading or changing trust in the asset.
+	Asset string
+	// Memo matches text memos, the decimal value of id memos and the hex
+	// value of hash and return memos.
+	Memo string
+}
+
+// txMatcher is a parsed TxQuery.
+type txMatcher struct {
+	sourceAccount string
+	operationType *xdr.OperationType
+	asset         *xdr.Asset
+	memo          string
+}
+
+type txRecord struct {
+	Ledger        uint32 `json:"ledger"`
+	CloseTime     int64  `json:"close_time"`
+	Hash          string `json:"hash"`
+	Successful    bool   `json:"successful"`
+	SourceAccount string `json:"source_account"`
+	EnvelopeXdr   string `json:"envelope_xdr"`
+	ResultXdr     string `json:"result_xdr"`
+}
+
+type entryRecord struct {
+	Checkpoint uint32  `json:"checkpoint"`
+	Key        string  `json:"key"`
+	Found      bool    `json:"found"`
+	EntryXdr   *string `json:"entry_xdr,omitempty"`
+}
+
+func newTxMatcher(q TxQuery) (*txMatcher, error) {
+	m := &txMatcher{sourceAccount: q.SourceAccount, memo: q.Memo}
+	if q.OperationType != "" {
+		for opType, name := range operations.TypeNames {
+			if name == q.OperationType {
+				opType := opType
+				m.operationType = &opType
+				break
+			}
+		}
+		if m.operationType == nil {
+			return nil, errors.Errorf("unknown operation type: %s", q.OperationType)
+		}
+	}
+	if q.Asset != "" {
+		assets, err := xdr.BuildAssets(q.Asset)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid asset")
+		}
+		if len(assets) != 1 {
+			return nil, errors.Errorf("expected a single asset: %s", q.Asset)
+		}
+		m.asset = &assets[0]
+	}
+	return m, nil
+}
+
+func (m *txMatcher) match(envelope xdr.TransactionEnvelope) bool {
+	ops := envelope.Operations()
+
+	if m.sourceAccount != "" {
+		found := muxedAddress(envelope.SourceAccount()) == m.sourceAccount
+		if envelope.IsFeeBump() && muxedAddress(envelope.FeeBumpAccount()) == m.sourceAccount {
+			found = true
+		}
+		for _, op := range ops {
+			if op.SourceAccount != nil && muxedAddress(*op.SourceAccount) == m.sourceAccount {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if m.memo != "" && memoString(envelope.Memo()) != m.memo {
+		return false
+	}
+
+	if m.operationType != nil || m.asset != nil {
+		found := false
+		for _, op := range ops {
+			if m.operationType != nil && op.Body.Type != *m.operationType {
+				continue
+			}
+			if m.asset != nil && !containsAsset(operationAssets(envelope, op), *m.asset) {
+				continue
+			}
+			found = true
+			break
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func muxedAddress(account xdr.MuxedAccount) string {
+	id := account.ToAccountId()
+	return id.Address()
+}
+
+func memoString(memo xdr.Memo) string {
+	switch memo.Type {
+	case xdr.MemoTypeMemoText:
+		return memo.MustText()
+	case xdr.MemoTypeMemoId:
+		return strconv.FormatUint(uint64(memo.MustId()), 10)
+	case xdr.MemoTypeMemoHash:
+		hash := memo.MustHash()
+		return hex.EncodeToString(hash[:])
+	case xdr.MemoTypeMemoReturn:
+		hash := memo.MustRetHash()
+		return hex.EncodeToString(hash[:])
+	default:
+		return ""
+	}
+}
+
+func containsAsset(assets []xdr.Asset, asset xdr.Asset) bool {
+	for _, a := range assets {
+		if a.Equals(asset) {
+			return true
+		}
+	}
+	return false
+}
+
+// operationAssets returns the assets an operation sends, receives, trades or
+// changes trust in.
+func operationAssets(envelope xdr.TransactionEnvelope, op xdr.Operation) []xdr.Asset {
+	body := op.Body
+	switch body.Type {
+	case xdr.OperationTypePayment:
+		return []xdr.Asset{body.MustPaymentOp().Asset}
+	case xdr.OperationTypePathPaymentStrictReceive:
+		p := body.MustPathPaymentStrictReceiveOp()
+		return append([]xdr.Asset{p.SendAsset, p.DestAsset}, p.Path...)
+	case xdr.OperationTypePathPaymentStrictSend:
+		p := body.MustPathPaymentStrictSendOp()
+		return append([]xdr.Asset{p.SendAsset, p.DestAsset}, p.Path...)
+	case xdr.OperationTypeManageSellOffer:
+		o := body.MustManageSellOfferOp()
+		return []xdr.Asset{o.Selling, o.Buying}
+	case xdr.OperationTypeManageBuyOffer:
+		o := body.MustManageBuyOfferOp()
+		return []xdr.Asset{o.Selling, o.Buying}
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		o := body.MustCreatePassiveSellOfferOp()
+		return []xdr.Asset{o.Selling, o.Buying}
+	case xdr.OperationTypeChangeTrust:
+		line := body.MustChangeTrustOp().Line
+		if line.Type == xdr.AssetTypeAssetTypePoolShare {
+			params := line.MustLiquidityPool().MustConstantProduct()
+			return []xdr.Asset{params.AssetA, params.AssetB}
+		}
+		return []xdr.Asset{line.ToAsset()}
+	case xdr.OperationTypeAllowTrust:
+		// The issuer of the asset is the operation source account.
+		source := envelope.SourceAccount()
+		if op.SourceAccount != nil {
+			source = *op.SourceAccount
+		}
+		return []xdr.Asset{body.MustAllowTrustOp().Asset.ToAsset(source.ToAccountId())}
+	case xdr.OperationTypeSetTrustLineFlags:
+		return []xdr.Asset{body.MustSetTrustLineFlagsOp().Asset}
+	case xdr.OperationTypeClawback:
+		return []xdr.Asset{body.MustClawbackOp().Asset}
+	case xdr.OperationTypeCreateClaimableBalance:
+		return []xdr.Asset{body.MustCreateClaimableBalanceOp().Asset}
+	default:
+		return nil
+	}
+}
+
+// ledgerEnvelopes returns the transaction envelopes of an archived ledger,
+// from either its legacy or its generalized transaction set.
+func ledgerEnvelopes(entry xdr.TransactionHistoryEntry) []xdr.TransactionEnvelope {
+	if entry.Ext.V != 1 {
+		return entry.TxSet.Txs
+	}
+	var envelopes []xdr.TransactionEnvelope
+	for _, phase := range entry.Ext.MustGeneralizedTxSet().MustV1TxSet().Phases {
+		for _, component := range *phase.V0Components {
+			envelopes = append(envelopes, component.MustTxsMaybeDiscountedFee().Txs...)
+		}
+	}
+	return envelopes
+}
+
+// queryTransactions writes the transactions in ledgers [low, high] matching
+// the query to out, as JSON lines in the order they were applied. It reads
+// one checkpoint at a time.
+func queryTransactions(arch historyarchive.ArchiveInterface, passphrase string, low, high uint32, q TxQuery, out io.Writer) error {
+	if low > high {
+		return errors.Errorf("invalid range: low (%d) > high (%d)", low, high)
+	}
+	if passphrase == "" {
+		return errors.New("network passphrase is required to match transactions with their results")
+	}
+	matcher, err := newTxMatcher(q)
+	if err != nil {
+		return err
+	}
+
+	manager := arch.GetCheckpointManager()
+	enc := json.NewEncoder(out)
+	for chk := manager.GetCheckpoint(low); ; chk += manager.GetCheckpointFrequency() {
+		ledgers, err := arch.GetLedgers(manager.GetCheckpointRange(chk).Low, chk)
+		if err != nil {
+			return errors.Wrapf(err, "error reading checkpoint %d", chk)
+		}
+		sequences := make([]uint32, 0, len(ledgers))
+		for seq := range ledgers {
+			if seq >= low && seq <= high {
+				sequences = append(sequences, seq)
+			}
+		}
+		sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+		for _, seq := range sequences {
+			if err := writeLedgerTransactions(ledgers[seq], seq, passphrase, matcher, enc); err != nil {
+				return errors.Wrapf(err, "error querying ledger %d", seq)
+			}
+		}
+		if chk >= manager.GetCheckpoint(high) {
+			return nil
+		}
+	}
+}
+
+func writeLedgerTransactions(ledger *historyarchive.Ledger, seq uint32, passphrase string, matcher *txMatcher, enc *json.Encoder) error {
+	envelopes := map[xdr.Hash]xdr.TransactionEnvelope{}
+	for _, envelope := range ledgerEnvelopes(ledger.Transaction) {
+		hash, err := network.HashTransactionInEnvelope(envelope, passphrase)
+		if err != nil {
+			return errors.Wrap(err, "error hashing transaction")
+		}
+		envelopes[hash] = envelope
+	}
+
+	for _, result := range ledger.TransactionResult.TxResultSet.Results {
+		envelope, ok := envelopes[result.TransactionHash]
+		if !ok {
+			return errors.Errorf(
+				"no transaction with hash %s, is the network passphrase correct?",
+				hex.EncodeToString(result.TransactionHash[:]),
+			)
+		}
+		if !matcher.match(envelope) {
+			continue
+		}
+
+		envelopeXdr, err := xdr.MarshalBase64(envelope)
+		if err != nil {
+			return err
+		}
+		resultXdr, err := xdr.MarshalBase64(result.Result)
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(txRecord{
+			Ledger:        seq,
+			CloseTime:     int64(ledger.Header.Header.ScpValue.CloseTime),
+			Hash:          hex.EncodeToString(result.TransactionHash[:]),
+			Successful:    result.Result.Successful(),
+			SourceAccount: muxedAddress(envelope.SourceAccount()),
+			EnvelopeXdr:   envelopeXdr,
+			ResultXdr:     resultXdr,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryLedgerEntry scans the buckets of a checkpoint for the entry with the
+// given key and writes its value to out as a JSON line.
+func queryLedgerEntry(ctx context.Context, arch historyarchive.ArchiveInterface, checkpoint uint32, key xdr.LedgerKey, out io.Writer) error {
+	keyXdr, err := key.MarshalBinaryBase64()
+	if err != nil {
+		return errors.Wrap(err, "invalid ledger key")
+	}
+	reader, err := ingest.NewCheckpointChangeReader(ctx, arch, checkpoint)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	record := entryRecord{Checkpoint: checkpoint, Key: keyXdr}
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, "error reading buckets")
+		}
+		changeKey, err := change.Post.LedgerKey()
+		if err != nil {
+			return err
+		}
+		if changeKey.Equals(key) {
+			entryXdr, err := xdr.MarshalBase64(change.Post)
+			if err != nil {
+				return err
+			}
+			record.Found = true
+			record.EntryXdr = &entryXdr
+			break
+		}
+	}
+	return json.NewEncoder(out).Encode(record)
+}
@@ -0,0 +1,215 @@
+// Copyright 2023 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/metriqorg/go/historyarchive"
+	"github.com/metriqorg/go/ingest/archivepublisher"
+	"github.com/metriqorg/go/ingest/ledgerbackend"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapLedgerBackend map[uint32]xdr.LedgerCloseMeta
+
+func (m mapLedgerBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	return 0, nil
+}
+
+func (m mapLedgerBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	return m[sequence], nil
+}
+
+func (m mapLedgerBackend) PrepareRange(ctx context.Context, ledgerRange ledgerbackend.Range) error {
+	return nil
+}
+
+func (m mapLedgerBackend) IsPrepared(ctx context.Context, ledgerRange ledgerbackend.Range) (bool, error) {
+	return true, nil
+}
+
+func (m mapLedgerBackend) Close() error {
+	return nil
+}
+
+func payment(t *testing.T, source, destination *keypair.Full, asset xdr.Asset, memo xdr.Memo) (xdr.TransactionEnvelope, xdr.TransactionResultMeta) {
+	envelope := xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				Fee:           100,
+				SourceAccount: xdr.MustMuxedAddress(source.Address()),
+				Memo:          memo,
+				Operations: []xdr.Operation{{
+					Body: xdr.OperationBody{
+						Type: xdr.OperationTypePayment,
+						PaymentOp: &xdr.PaymentOp{
+							Destination: xdr.MustMuxedAddress(destination.Address()),
+							Asset:       asset,
+							Amount:      10,
+						},
+					},
+				}},
+			},
+		},
+	}
+	hash, err := network.HashTransactionInEnvelope(envelope, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	result := xdr.TransactionResultMeta{
+		Result: xdr.TransactionResultPair{
+			TransactionHash: hash,
+			Result: xdr.TransactionResult{
+				Result: xdr.TransactionResultResult{
+					Code:    xdr.TransactionResultCodeTxSuccess,
+					Results: &[]xdr.OperationResult{},
+				},
+			},
+		},
+	}
+	return envelope, result
+}
+
+func TestQueryTransactions(t *testing.T) {
+	alice, bob := keypair.MustRandom(), keypair.MustRandom()
+	usd := xdr.MustNewCreditAsset("USD", bob.Address())
+	native := xdr.MustNewNativeAsset()
+	memo, err := xdr.NewMemo(xdr.MemoTypeMemoText, "invoice 7")
+	require.NoError(t, err)
+
+	ledgers := mapLedgerBackend{}
+	for seq := uint32(2); seq <= 127; seq++ {
+		ledgers[seq] = xdr.LedgerCloseMeta{V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(seq)}},
+		}}
+	}
+	addTx := func(seq uint32, source, destination *keypair.Full, asset xdr.Asset, memo xdr.Memo) {
+		envelope, result := payment(t, source, destination, asset, memo)
+		meta := ledgers[seq].MustV0()
+		meta.TxSet.Txs = append(meta.TxSet.Txs, envelope)
+		meta.TxProcessing = append(meta.TxProcessing, result)
+		ledgers[seq] = xdr.LedgerCloseMeta{V0: &meta}
+	}
+	addTx(10, alice, bob, usd, memo)
+	addTx(10, bob, alice, native, xdr.Memo{})
+	addTx(70, alice, bob, native, xdr.Memo{})
+	addTx(100, alice, bob, usd, xdr.Memo{})
+
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+	publisher, err := archivepublisher.New(archivepublisher.Config{Backend: ledgers, Archive: arch.Backend()})
+	require.NoError(t, err)
+	require.NoError(t, publisher.PublishRange(context.Background(), 2, 127))
+
+	query := func(low, high uint32, q TxQuery) []txRecord {
+		var out bytes.Buffer
+		require.NoError(t, queryTransactions(arch, network.TestNetworkPassphrase, low, high, q, &out))
+		records := []txRecord{}
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record txRecord
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		return records
+	}
+	ledgerSeqs := func(records []txRecord) []uint32 {
+		seqs := []uint32{}
+		for _, record := range records {
+			seqs = append(seqs, record.Ledger)
+		}
+		return seqs
+	}
+
+	assert.Equal(t, []uint32{10, 10, 70, 100}, ledgerSeqs(query(1, 127, TxQuery{})))
+	assert.Equal(t, []uint32{70}, ledgerSeqs(query(11, 99, TxQuery{})))
+	assert.Equal(t, []uint32{10, 70, 100}, ledgerSeqs(query(1, 127, TxQuery{SourceAccount: alice.Address()})))
+	assert.Equal(t, []uint32{10, 100}, ledgerSeqs(query(1, 127, TxQuery{Asset: "USD:" + bob.Address()})))
+	assert.Equal(t, []uint32{10, 70}, ledgerSeqs(query(1, 127, TxQuery{Asset: "native"})))
+	assert.Empty(t, query(1, 127, TxQuery{OperationType: "create_account"}))
+
+	records := query(1, 127, TxQuery{Memo: "invoice 7", OperationType: "payment"})
+	require.Len(t, records, 1)
+	assert.Equal(t, alice.Address(), records[0].SourceAccount)
+	assert.True(t, records[0].Successful)
+	var envelope xdr.TransactionEnvelope
+	require.NoError(t, xdr.SafeUnmarshalBase64(records[0].EnvelopeXdr, &envelope))
+	assert.Equal(t, memo, envelope.Memo())
+
+	err = queryTransactions(arch, network.PublicNetworkPassphrase, 1, 127, TxQuery{}, ioutil.Discard)
+	assert.Contains(t, err.Error(), "is the network passphrase correct?")
+	err = queryTransactions(arch, network.TestNetworkPassphrase, 1, 127, TxQuery{OperationType: "pay"}, ioutil.Discard)
+	assert.EqualError(t, err, "unknown operation type: pay")
+}
+
+func TestQueryLedgerEntry(t *testing.T) {
+	alice, bob := keypair.MustRandom(), keypair.MustRandom()
+	account := func(kp *keypair.Full, balance xdr.Int64) xdr.BucketEntry {
+		return xdr.BucketEntry{
+			Type: xdr.BucketEntryTypeLiveentry,
+			LiveEntry: &xdr.LedgerEntry{
+				Data: xdr.LedgerEntryData{
+					Type:    xdr.LedgerEntryTypeAccount,
+					Account: &xdr.AccountEntry{AccountId: xdr.MustAddress(kp.Address()), Balance: balance},
+				},
+			},
+		}
+	}
+
+	var raw, gz bytes.Buffer
+	for _, entry := range []xdr.BucketEntry{account(alice, 10), account(bob, 20)} {
+		require.NoError(t, xdr.MarshalFramed(&raw, entry))
+	}
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write(raw.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	bucket := historyarchive.Hash(sha256.Sum256(raw.Bytes()))
+
+	arch, err := historyarchive.Connect("mock://test", historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+	require.NoError(t, arch.Backend().PutFile(historyarchive.BucketPath(bucket), ioutil.NopCloser(&gz)))
+	var has historyarchive.HistoryArchiveState
+	has.CurrentLedger = 63
+	for i := range has.CurrentBuckets {
+		has.CurrentBuckets[i].Curr = historyarchive.Hash{}.String()
+		has.CurrentBuckets[i].Snap = historyarchive.Hash{}.String()
+	}
+	has.CurrentBuckets[0].Curr = bucket.String()
+	require.NoError(t, arch.PutCheckpointHAS(63, has, &historyarchive.CommandOptions{}))
+
+	var key xdr.LedgerKey
+	require.NoError(t, key.SetAccount(xdr.MustAddress(bob.Address())))
+	var out bytes.Buffer
+	require.NoError(t, queryLedgerEntry(context.Background(), arch, 63, key, &out))
+	var record entryRecord
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, uint32(63), record.Checkpoint)
+	require.True(t, record.Found)
+	var entry xdr.LedgerEntry
+	require.NoError(t, xdr.SafeUnmarshalBase64(*record.EntryXdr, &entry))
+	assert.Equal(t, xdr.Int64(20), entry.Data.MustAccount().Balance)
+
+	out.Reset()
+	require.NoError(t, key.SetAccount(xdr.MustAddress(keypair.MustRandom().Address())))
+	require.NoError(t, queryLedgerEntry(context.Background(), arch, 63, key, &out))
+	record = entryRecord{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.False(t, record.Found)
+	assert.Nil(t, record.EntryXdr)
+}
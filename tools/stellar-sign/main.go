@@ -6,7 +6,6 @@ package main
 
 import (
 	"bufio"
-	b64 "encoding/base64"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -118,6 +117,9 @@ var in *bufio.Reader
 var infile = flag.String("infile", "", "transaction envelope")
 var verify = flag.Bool("verify", false, "Verify the transaction instead of signing")
 var testnet = flag.Bool("testnet", false, "Sign or verify the transaction using Testnet passphrase instead of Public")
+var describeOnly = flag.Bool("describe", false, "Only print the transaction description, without signing or verifying")
+var describeJSON = flag.Bool("json", false, "Print the transaction description as JSON")
+var signers = flag.String("signers", "", "Comma separated addresses of other signers to identify in the transaction description")
 
 func main() {
 	flag.Parse()
@@ -151,41 +153,40 @@ func main() {
 		env = string(raw)
 	}
 
-	// parse the envelope
-	var txe xdr.TransactionEnvelope
-	err = xdr.SafeUnmarshalBase64(env, &txe)
+	passPhrase := network.PublicNetworkPassphrase
+	if *testnet {
+		passPhrase = network.TestNetworkPassphrase
+	}
+
+	parsed, err := txnbuild.TransactionFromXDR(strings.TrimSpace(env))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	isFeeBump := txe.IsFeeBump()
+	desc, err := txnbuild.DescribeGenericTransaction(parsed, txnbuild.DescribeOptions{
+		NetworkPassphrase: passPhrase,
+		Signers:           knownSigners(parsed, *signers),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Println("")
-	fmt.Println("Transaction Summary:")
-	sourceAccount := txe.SourceAccount().ToAccountId()
-	fmt.Printf("  type: %s\n", txe.Type.String())
-	if isFeeBump {
-		fmt.Printf("  fee bump source: %s\n", txe.FeeBumpAccount().ToAccountId().Address())
-	}
-	fmt.Printf("  source: %s\n", sourceAccount.Address())
-	fmt.Printf("  ops: %d\n", len(txe.Operations()))
-	fmt.Printf("  sigs: %d\n", len(txe.Signatures()))
-	for _, signature := range txe.Signatures() {
-		fmt.Printf("    %s\n", b64.StdEncoding.EncodeToString(signature.Signature))
-	}
-	if isFeeBump {
-		fmt.Printf("  fee bump sigs: %d\n", len(txe.FeeBumpSignatures()))
-		for _, feeBumpSignature := range txe.FeeBumpSignatures() {
-			fmt.Printf("    %s\n", b64.StdEncoding.EncodeToString(feeBumpSignature.Signature))
+	if *describeJSON {
+		var raw []byte
+		raw, err = desc.JSON()
+		if err != nil {
+			log.Fatal(err)
 		}
+		fmt.Println(string(raw))
+	} else {
+		fmt.Println("Transaction Summary:")
+		fmt.Print(desc.String())
 	}
 	fmt.Println("")
 
-	// TODO: add operation details
-
-	passPhrase := network.PublicNetworkPassphrase
-	if *testnet {
-		passPhrase = network.TestNetworkPassphrase
+	if *describeOnly {
+		return
 	}
 
 	// read seed/public key
@@ -197,11 +198,6 @@ func main() {
 	flowRouter := &SignOrVerify{verify: *verify, networkPassphrase: passPhrase}
 	flowRouter.setKey(key)
 
-	parsed, err := txnbuild.TransactionFromXDR(env)
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	if *verify {
 		err := flowRouter.doVerify(parsed)
 		if err != nil {
@@ -219,6 +215,49 @@ func main() {
 
 }
 
+// knownSigners returns the addresses whose signatures are identified in the
+// transaction description: the source accounts of the transaction and its
+// operations, the fee account of a fee bump and the addresses of -signers.
+func knownSigners(gtx *txnbuild.GenericTransaction, extra string) []string {
+	var addresses []string
+	if feeBump, ok := gtx.FeeBump(); ok {
+		addresses = append(addresses, feeBump.FeeAccount())
+		tx := feeBump.InnerTransaction()
+		addresses = append(addresses, tx.SourceAccount().AccountID)
+		for _, op := range tx.Operations() {
+			addresses = append(addresses, op.GetSourceAccount())
+		}
+	}
+	if tx, ok := gtx.Transaction(); ok {
+		addresses = append(addresses, tx.SourceAccount().AccountID)
+		for _, op := range tx.Operations() {
+			addresses = append(addresses, op.GetSourceAccount())
+		}
+	}
+	for _, address := range strings.Split(extra, ",") {
+		addresses = append(addresses, strings.TrimSpace(address))
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, address := range addresses {
+		if address == "" {
+			continue
+		}
+		// Signatures are made by the account behind a muxed account.
+		if muxed, err := xdr.AddressToMuxedAccount(address); err == nil {
+			accountID := muxed.ToAccountId()
+			address = accountID.Address()
+		}
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		result = append(result, address)
+	}
+	return result
+}
+
 func readLine(prompt string, private bool) (string, error) {
 	fmt.Println(prompt)
 	var line string
@@ -0,0 +1,1107 @@
+package txnbuild
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metriqorg/go/amount"
+	"github.com/metriqorg/go/keypair"
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/protocols/orbitr/operations"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/metriqorg/go/xdr"
+)
+
+// DescribeOptions configures how transactions are described.
+type DescribeOptions struct {
+	// NetworkPassphrase is used to compute the transaction hash and to verify
+	// signatures. When empty, hashes are omitted and signatures are not
+	// matched against Signers.
+	NetworkPassphrase string
+	// Signers is a list of addresses whose signatures should be identified in
+	// the description, for example the signers of the source account.
+	Signers []string
+}
+
+// TransactionDescription is a structured, human-readable representation of a
+// transaction. It can be marshaled to JSON or rendered as text with String.
+type TransactionDescription struct {
+	Hash           string                   `json:"hash,omitempty"`
+	SourceAccount  string                   `json:"source_account"`
+	SequenceNumber int64                    `json:"sequence_number"`
+	BaseFee        int64                    `json:"base_fee"`
+	MaxFee         int64                    `json:"max_fee"`
+	Memo           *MemoDescription         `json:"memo,omitempty"`
+	Preconditions  PreconditionsDescription `json:"preconditions"`
+	Operations     []OperationDescription   `json:"operations"`
+	Signatures     []SignatureDescription   `json:"signatures"`
+	FeeBump        *FeeBumpDescription      `json:"fee_bump,omitempty"`
+}
+
+// FeeBumpDescription describes the fee-bump envelope wrapping a transaction.
+type FeeBumpDescription struct {
+	Hash       string                 `json:"hash,omitempty"`
+	FeeAccount string                 `json:"fee_account"`
+	BaseFee    int64                  `json:"base_fee"`
+	MaxFee     int64                  `json:"max_fee"`
+	Signatures []SignatureDescription `json:"signatures"`
+}
+
+// MemoDescription describes a transaction memo.
+type MemoDescription struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// PreconditionsDescription describes the preconditions of a transaction.
+type PreconditionsDescription struct {
+	MinTime                    *time.Time `json:"min_time,omitempty"`
+	MaxTime                    *time.Time `json:"max_time,omitempty"`
+	MinLedger                  uint32     `json:"min_ledger,omitempty"`
+	MaxLedger                  uint32     `json:"max_ledger,omitempty"`
+	MinSequenceNumber          *int64     `json:"min_sequence_number,omitempty"`
+	MinSequenceNumberAge       uint64     `json:"min_sequence_number_age,omitempty"`
+	MinSequenceNumberLedgerGap uint32     `json:"min_sequence_number_ledger_gap,omitempty"`
+	ExtraSigners               []string   `json:"extra_signers,omitempty"`
+}
+
+// OperationDescription describes a single operation. Summary is a one line
+// sentence such as "Pay 10 USD:GA.. to GB.." and Details holds the operation
+// fields keyed by their orbitr JSON names.
+type OperationDescription struct {
+	Type          string                 `json:"type"`
+	SourceAccount string                 `json:"source_account,omitempty"`
+	Summary       string                 `json:"summary"`
+	Details       map[string]interface{} `json:"details"`
+}
+
+// SignatureDescription describes a decorated signature. Signer is only set
+// when the signature could be verified against one of the
+// DescribeOptions.Signers.
+type SignatureDescription struct {
+	Hint      string `json:"hint"`
+	Signature string `json:"signature"`
+	Signer    string `json:"signer,omitempty"`
+}
+
+// DescribeTransactionXDR decodes a base64 transaction envelope and describes
+// it.
+func DescribeTransactionXDR(txeB64 string, opts DescribeOptions) (TransactionDescription, error) {
+	gtx, err := TransactionFromXDR(txeB64)
+	if err != nil {
+		return TransactionDescription{}, errors.Wrap(err, "could not parse transaction envelope")
+	}
+	return DescribeGenericTransaction(gtx, opts)
+}
+
+// DescribeGenericTransaction describes a transaction or fee-bump
+// transaction.
+func DescribeGenericTransaction(gtx *GenericTransaction, opts DescribeOptions) (TransactionDescription, error) {
+	if tx, ok := gtx.Transaction(); ok {
+		return DescribeTransaction(tx, opts)
+	}
+	if feeBump, ok := gtx.FeeBump(); ok {
+		return DescribeFeeBumpTransaction(feeBump, opts)
+	}
+	return TransactionDescription{}, errors.New("transaction is empty")
+}
+
+// DescribeFeeBumpTransaction describes the inner transaction of a fee-bump
+// transaction together with the fee-bump envelope.
+func DescribeFeeBumpTransaction(tx *FeeBumpTransaction, opts DescribeOptions) (TransactionDescription, error) {
+	desc, err := DescribeTransaction(tx.InnerTransaction(), opts)
+	if err != nil {
+		return TransactionDescription{}, err
+	}
+
+	desc.FeeBump = &FeeBumpDescription{
+		FeeAccount: tx.FeeAccount(),
+		BaseFee:    tx.BaseFee(),
+		MaxFee:     tx.MaxFee(),
+	}
+	var hash [32]byte
+	if opts.NetworkPassphrase != "" {
+		hash, err = tx.Hash(opts.NetworkPassphrase)
+		if err != nil {
+			return TransactionDescription{}, errors.Wrap(err, "could not hash fee bump transaction")
+		}
+		desc.FeeBump.Hash = hex.EncodeToString(hash[:])
+	}
+	desc.FeeBump.Signatures = describeSignatures(tx.Signatures(), hash, opts)
+	return desc, nil
+}
+
+// DescribeTransaction describes a transaction.
+func DescribeTransaction(tx *Transaction, opts DescribeOptions) (TransactionDescription, error) {
+	desc := TransactionDescription{
+		SourceAccount:  tx.sourceAccount.AccountID,
+		SequenceNumber: tx.sourceAccount.Sequence,
+		BaseFee:        tx.baseFee,
+		MaxFee:         tx.maxFee,
+		Preconditions:  describePreconditions(tx.preconditions),
+		Operations:     make([]OperationDescription, 0, len(tx.operations)),
+	}
+
+	var err error
+	if tx.memo != nil {
+		desc.Memo, err = describeMemo(tx.memo)
+		if err != nil {
+			return TransactionDescription{}, err
+		}
+	}
+
+	for i, op := range tx.operations {
+		opDesc, err := DescribeOperation(op)
+		if err != nil {
+			return TransactionDescription{}, errors.Wrapf(err, "could not describe operation %d", i)
+		}
+		desc.Operations = append(desc.Operations, opDesc)
+	}
+
+	var hash [32]byte
+	if opts.NetworkPassphrase != "" {
+		hash, err = network.HashTransactionInEnvelope(tx.envelope, opts.NetworkPassphrase)
+		if err != nil {
+			return TransactionDescription{}, errors.Wrap(err, "could not hash transaction")
+		}
+		desc.Hash = hex.EncodeToString(hash[:])
+	}
+	desc.Signatures = describeSignatures(tx.Signatures(), hash, opts)
+	return desc, nil
+}
+
+// JSON returns the indented JSON representation of the description.
+func (d TransactionDescription) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// String renders the description as multi-line text.
+func (d TransactionDescription) String() string {
+	var b strings.Builder
+	if d.FeeBump != nil {
+		fmt.Fprintf(&b, "Fee bump transaction %s\n", d.FeeBump.Hash)
+		fmt.Fprintf(&b, "  Fee account: %s\n", d.FeeBump.FeeAccount)
+		fmt.Fprintf(&b, "  Fee: %d per operation (max %d)\n", d.FeeBump.BaseFee, d.FeeBump.MaxFee)
+		writeSignatures(&b, "  Fee bump signatures", d.FeeBump.Signatures)
+		b.WriteString("Inner ")
+	}
+
+	fmt.Fprintf(&b, "Transaction %s\n", d.Hash)
+	fmt.Fprintf(&b, "  Source: %s\n", d.SourceAccount)
+	fmt.Fprintf(&b, "  Sequence: %d\n", d.SequenceNumber)
+	fmt.Fprintf(&b, "  Fee: %d per operation (max %d)\n", d.BaseFee, d.MaxFee)
+	if d.Memo != nil {
+		fmt.Fprintf(&b, "  Memo: %s %q\n", d.Memo.Type, d.Memo.Value)
+	}
+
+	p := d.Preconditions
+	if p.MinTime != nil || p.MaxTime != nil {
+		fmt.Fprintf(&b, "  Valid from %s until %s\n", formatTimeBound(p.MinTime), formatTimeBound(p.MaxTime))
+	}
+	if p.MinLedger != 0 || p.MaxLedger != 0 {
+		fmt.Fprintf(&b, "  Valid from ledger %d until ledger %d\n", p.MinLedger, p.MaxLedger)
+	}
+	if p.MinSequenceNumber != nil {
+		fmt.Fprintf(&b, "  Min sequence number: %d\n", *p.MinSequenceNumber)
+	}
+	if p.MinSequenceNumberAge != 0 {
+		fmt.Fprintf(&b, "  Min sequence number age: %ds\n", p.MinSequenceNumberAge)
+	}
+	if p.MinSequenceNumberLedgerGap != 0 {
+		fmt.Fprintf(&b, "  Min sequence number ledger gap: %d\n", p.MinSequenceNumberLedgerGap)
+	}
+	for _, signer := range p.ExtraSigners {
+		fmt.Fprintf(&b, "  Extra signer: %s\n", signer)
+	}
+
+	fmt.Fprintf(&b, "  Operations (%d):\n", len(d.Operations))
+	for i, op := range d.Operations {
+		fmt.Fprintf(&b, "    %d. %s\n", i+1, op.Summary)
+		if op.SourceAccount != "" {
+			fmt.Fprintf(&b, "       source: %s\n", op.SourceAccount)
+		}
+	}
+	writeSignatures(&b, "  Signatures", d.Signatures)
+	return b.String()
+}
+
+func writeSignatures(b *strings.Builder, title string, signatures []SignatureDescription) {
+	fmt.Fprintf(b, "%s (%d):\n", title, len(signatures))
+	for _, sig := range signatures {
+		signer := sig.Signer
+		if signer == "" {
+			signer = "unknown signer"
+		}
+		fmt.Fprintf(b, "    %s %s\n", sig.Hint, signer)
+	}
+}
+
+func formatTimeBound(t *time.Time) string {
+	if t == nil {
+		return "any time"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func describeSignatures(signatures []xdr.DecoratedSignature, hash [32]byte, opts DescribeOptions) []SignatureDescription {
+	result := make([]SignatureDescription, 0, len(signatures))
+	for _, sig := range signatures {
+		desc := SignatureDescription{
+			Hint:      hex.EncodeToString(sig.Hint[:]),
+			Signature: base64.StdEncoding.EncodeToString(sig.Signature),
+		}
+		if opts.NetworkPassphrase != "" {
+			for _, signer := range opts.Signers {
+				kp, err := keypair.ParseAddress(signer)
+				if err != nil || kp.Hint() != sig.Hint {
+					continue
+				}
+				if kp.Verify(hash[:], sig.Signature) == nil {
+					desc.Signer = signer
+					break
+				}
+			}
+		}
+		result = append(result, desc)
+	}
+	return result
+}
+
+func describeMemo(memo Memo) (*MemoDescription, error) {
+	switch m := memo.(type) {
+	case MemoText:
+		return &MemoDescription{Type: "text", Value: string(m)}, nil
+	case MemoID:
+		return &MemoDescription{Type: "id", Value: strconv.FormatUint(uint64(m), 10)}, nil
+	case MemoHash:
+		return &MemoDescription{Type: "hash", Value: hex.EncodeToString(m[:])}, nil
+	case MemoReturn:
+		return &MemoDescription{Type: "return", Value: hex.EncodeToString(m[:])}, nil
+	default:
+		return nil, errors.Errorf("unknown memo type %T", memo)
+	}
+}
+
+func describePreconditions(p Preconditions) PreconditionsDescription {
+	desc := PreconditionsDescription{
+		MinSequenceNumber:          p.MinSequenceNumber,
+		MinSequenceNumberAge:       p.MinSequenceNumberAge,
+		MinSequenceNumberLedgerGap: p.MinSequenceNumberLedgerGap,
+		ExtraSigners:               p.ExtraSigners,
+	}
+	if p.TimeBounds.MinTime > 0 {
+		t := time.Unix(p.TimeBounds.MinTime, 0).UTC()
+		desc.MinTime = &t
+	}
+	if p.TimeBounds.MaxTime > 0 {
+		t := time.Unix(p.TimeBounds.MaxTime, 0).UTC()
+		desc.MaxTime = &t
+	}
+	if p.LedgerBounds != nil {
+		desc.MinLedger = p.LedgerBounds.MinLedger
+		desc.MaxLedger = p.LedgerBounds.MaxLedger
+	}
+	return desc
+}
+
+// DescribeOperation returns the description of a single operation.
+func DescribeOperation(op Operation) (OperationDescription, error) {
+	var (
+		opType  xdr.OperationType
+		summary string
+		details = map[string]interface{}{}
+	)
+
+	switch o := op.(type) {
+	case *CreateAccount:
+		opType = xdr.OperationTypeCreateAccount
+		summary = fmt.Sprintf("Create account %s with %s MTRQ", o.Destination, o.Amount)
+		details["account"] = o.Destination
+		details["starting_balance"] = o.Amount
+	case *Payment:
+		opType = xdr.OperationTypePayment
+		summary = fmt.Sprintf("Pay %s %s to %s", o.Amount, assetName(o.Asset), o.Destination)
+		details["to"] = o.Destination
+		details["amount"] = o.Amount
+		details["asset"] = assetString(o.Asset)
+	case *PathPaymentStrictReceive:
+		opType = xdr.OperationTypePathPaymentStrictReceive
+		summary = fmt.Sprintf(
+			"Pay %s %s to %s, sending at most %s %s",
+			o.DestAmount, assetName(o.DestAsset), o.Destination, o.SendMax, assetName(o.SendAsset),
+		)
+		details["to"] = o.Destination
+		details["amount"] = o.DestAmount
+		details["asset"] = assetString(o.DestAsset)
+		details["source_max"] = o.SendMax
+		details["source_asset"] = assetString(o.SendAsset)
+		details["path"] = assetStrings(o.Path)
+	case *PathPaymentStrictSend:
+		opType = xdr.OperationTypePathPaymentStrictSend
+		summary = fmt.Sprintf(
+			"Send %s %s to %s, delivering at least %s %s",
+			o.SendAmount, assetName(o.SendAsset), o.Destination, o.DestMin, assetName(o.DestAsset),
+		)
+		details["to"] = o.Destination
+		details["source_amount"] = o.SendAmount
+		details["source_asset"] = assetString(o.SendAsset)
+		details["destination_min"] = o.DestMin
+		details["asset"] = assetString(o.DestAsset)
+		details["path"] = assetStrings(o.Path)
+	case *ManageSellOffer:
+		opType = xdr.OperationTypeManageSellOffer
+		summary = describeOffer("Sell", o.Amount, o.Selling, o.Buying, o.Price, o.OfferID)
+		describeOfferDetails(details, o.Amount, o.Selling, o.Buying, o.Price)
+		details["offer_id"] = o.OfferID
+	case *ManageBuyOffer:
+		opType = xdr.OperationTypeManageBuyOffer
+		summary = describeOffer("Buy", o.Amount, o.Buying, o.Selling, o.Price, o.OfferID)
+		describeOfferDetails(details, o.Amount, o.Selling, o.Buying, o.Price)
+		details["offer_id"] = o.OfferID
+	case *CreatePassiveSellOffer:
+		opType = xdr.OperationTypeCreatePassiveSellOffer
+		summary = "Passively " + strings.ToLower(describeOffer("Sell", o.Amount, o.Selling, o.Buying, o.Price, 0))
+		describeOfferDetails(details, o.Amount, o.Selling, o.Buying, o.Price)
+	case *SetOptions:
+		opType = xdr.OperationTypeSetOptions
+		summary = describeSetOptions(o, details)
+	case *ChangeTrust:
+		opType = xdr.OperationTypeChangeTrust
+		line := changeTrustAssetString(o.Line)
+		details["asset"] = line
+		details["limit"] = o.Limit
+		if isZeroAmount(o.Limit) {
+			summary = fmt.Sprintf("Remove trustline to %s", line)
+		} else if o.Limit == "" || o.Limit == MaxTrustlineLimit {
+			summary = fmt.Sprintf("Trust %s", line)
+		} else {
+			summary = fmt.Sprintf("Trust %s up to %s", line, o.Limit)
+		}
+	case *AllowTrust:
+		opType = xdr.OperationTypeAllowTrust
+		code := ""
+		if o.Type != nil {
+			code = o.Type.GetCode()
+		}
+		details["trustor"] = o.Trustor
+		details["asset_code"] = code
+		details["authorize"] = o.Authorize
+		details["authorize_to_maintain_liabilities"] = o.AuthorizeToMaintainLiabilities
+		switch {
+		case o.Authorize:
+			summary = fmt.Sprintf("Authorize %s to hold %s", o.Trustor, code)
+		case o.AuthorizeToMaintainLiabilities:
+			summary = fmt.Sprintf("Authorize %s to maintain liabilities in %s", o.Trustor, code)
+		default:
+			summary = fmt.Sprintf("Revoke authorization of %s to hold %s", o.Trustor, code)
+		}
+	case *AccountMerge:
+		opType = xdr.OperationTypeAccountMerge
+		summary = fmt.Sprintf("Merge account into %s", o.Destination)
+		details["into"] = o.Destination
+	case *Inflation:
+		opType = xdr.OperationTypeInflation
+		summary = "Run inflation"
+	case *ManageData:
+		opType = xdr.OperationTypeManageData
+		details["name"] = o.Name
+		if o.Value == nil {
+			summary = fmt.Sprintf("Delete data entry %q", o.Name)
+		} else {
+			details["value"] = base64.StdEncoding.EncodeToString(o.Value)
+			summary = fmt.Sprintf("Set data entry %q to %s", o.Name, dataValueString(o.Value))
+		}
+	case *BumpSequence:
+		opType = xdr.OperationTypeBumpSequence
+		summary = fmt.Sprintf("Bump sequence number to %d", o.BumpTo)
+		details["bump_to"] = strconv.FormatInt(o.BumpTo, 10)
+	case *BeginSponsoringFutureReserves:
+		opType = xdr.OperationTypeBeginSponsoringFutureReserves
+		summary = fmt.Sprintf("Begin sponsoring future reserves of %s", o.SponsoredID)
+		details["sponsored_id"] = o.SponsoredID
+	case *EndSponsoringFutureReserves:
+		opType = xdr.OperationTypeEndSponsoringFutureReserves
+		summary = "End sponsoring future reserves"
+	case *CreateClaimableBalance:
+		opType = xdr.OperationTypeCreateClaimableBalance
+		claimants := make([]map[string]interface{}, 0, len(o.Destinations))
+		names := make([]string, 0, len(o.Destinations))
+		for _, c := range o.Destinations {
+			claimants = append(claimants, map[string]interface{}{
+				"destination": c.Destination,
+				"predicate":   predicateString(c.Predicate),
+			})
+			names = append(names, c.Destination)
+		}
+		summary = fmt.Sprintf(
+			"Create claimable balance of %s %s for %s",
+			o.Amount, assetName(o.Asset), strings.Join(names, ", "),
+		)
+		details["asset"] = assetString(o.Asset)
+		details["amount"] = o.Amount
+		details["claimants"] = claimants
+	case *ClaimClaimableBalance:
+		opType = xdr.OperationTypeClaimClaimableBalance
+		summary = fmt.Sprintf("Claim claimable balance %s", o.BalanceID)
+		details["balance_id"] = o.BalanceID
+	case *RevokeSponsorship:
+		opType = xdr.OperationTypeRevokeSponsorship
+		summary = describeRevokeSponsorship(o, details)
+	case *Clawback:
+		opType = xdr.OperationTypeClawback
+		summary = fmt.Sprintf("Claw back %s %s from %s", o.Amount, assetName(o.Asset), o.From)
+		details["from"] = o.From
+		details["amount"] = o.Amount
+		details["asset"] = assetString(o.Asset)
+	case *ClawbackClaimableBalance:
+		opType = xdr.OperationTypeClawbackClaimableBalance
+		summary = fmt.Sprintf("Claw back claimable balance %s", o.BalanceID)
+		details["balance_id"] = o.BalanceID
+	case *SetTrustLineFlags:
+		opType = xdr.OperationTypeSetTrustLineFlags
+		setFlags := trustLineFlagNames(o.SetFlags)
+		clearFlags := trustLineFlagNames(o.ClearFlags)
+		summary = fmt.Sprintf(
+			"Set trustline flags of %s for %s (set: %s; clear: %s)",
+			o.Trustor, assetName(o.Asset), joinOrNone(setFlags), joinOrNone(clearFlags),
+		)
+		details["trustor"] = o.Trustor
+		details["asset"] = assetString(o.Asset)
+		details["set_flags"] = setFlags
+		details["clear_flags"] = clearFlags
+	case *LiquidityPoolDeposit:
+		opType = xdr.OperationTypeLiquidityPoolDeposit
+		poolID := hex.EncodeToString(o.LiquidityPoolID[:])
+		summary = fmt.Sprintf(
+			"Deposit at most %s and %s into liquidity pool %s",
+			o.MaxAmountA, o.MaxAmountB, poolID,
+		)
+		details["liquidity_pool_id"] = poolID
+		details["reserves_max"] = []string{o.MaxAmountA, o.MaxAmountB}
+		details["min_price"] = o.MinPrice.String()
+		details["max_price"] = o.MaxPrice.String()
+	case *LiquidityPoolWithdraw:
+		opType = xdr.OperationTypeLiquidityPoolWithdraw
+		poolID := hex.EncodeToString(o.LiquidityPoolID[:])
+		summary = fmt.Sprintf(
+			"Withdraw %s pool shares from liquidity pool %s, receiving at least %s and %s",
+			o.Amount, poolID, o.MinAmountA, o.MinAmountB,
+		)
+		details["liquidity_pool_id"] = poolID
+		details["shares"] = o.Amount
+		details["reserves_min"] = []string{o.MinAmountA, o.MinAmountB}
+	case *InvokeHostFunction:
+		opType = xdr.OperationTypeInvokeHostFunction
+		var err error
+		summary, err = describeHostFunction(o.HostFunction, details)
+		if err != nil {
+			return OperationDescription{}, err
+		}
+		auth := make([]map[string]interface{}, 0, len(o.Auth))
+		for _, entry := range o.Auth {
+			auth = append(auth, describeAuthorizationEntry(entry))
+		}
+		details["auth"] = auth
+	case *BumpFootprintExpiration:
+		opType = xdr.OperationTypeBumpFootprintExpiration
+		summary = fmt.Sprintf("Extend expiration of footprint entries by %d ledgers", o.LedgersToExpire)
+		details["ledgers_to_expire"] = o.LedgersToExpire
+	case *RestoreFootprint:
+		opType = xdr.OperationTypeRestoreFootprint
+		summary = "Restore expired footprint entries"
+	default:
+		return OperationDescription{}, errors.Errorf("unknown operation type %T", op)
+	}
+
+	return OperationDescription{
+		Type:          operations.TypeNames[opType],
+		SourceAccount: op.GetSourceAccount(),
+		Summary:       summary,
+		Details:       details,
+	}, nil
+}
+
+// assetName returns the short name of an asset used in summaries.
+func assetName(asset BasicAsset) string {
+	if asset == nil {
+		return "<missing asset>"
+	}
+	if asset.IsNative() {
+		return "MTRQ"
+	}
+	return asset.GetCode() + ":" + asset.GetIssuer()
+}
+
+// assetString returns the canonical representation of an asset used in
+// details.
+func assetString(asset BasicAsset) string {
+	if asset == nil {
+		return ""
+	}
+	if asset.IsNative() {
+		return "native"
+	}
+	return asset.GetCode() + ":" + asset.GetIssuer()
+}
+
+func assetStrings(assets []Asset) []string {
+	result := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		result = append(result, assetString(asset))
+	}
+	return result
+}
+
+func changeTrustAssetString(asset ChangeTrustAsset) string {
+	if asset == nil {
+		return ""
+	}
+	if params, ok := asset.GetLiquidityPoolParameters(); ok {
+		return fmt.Sprintf(
+			"liquidity pool shares of %s/%s",
+			assetName(params.AssetA), assetName(params.AssetB),
+		)
+	}
+	return assetName(asset)
+}
+
+func describeOffer(verb, offerAmount string, selling, buying Asset, price xdr.Price, offerID int64) string {
+	if offerID != 0 && isZeroAmount(offerAmount) {
+		return fmt.Sprintf("Delete offer %d", offerID)
+	}
+	var s string
+	if verb == "Sell" {
+		s = fmt.Sprintf("Sell %s %s for %s at %s", offerAmount, assetName(selling), assetName(buying), price.String())
+	} else {
+		s = fmt.Sprintf("Buy %s %s with %s at %s", offerAmount, assetName(selling), assetName(buying), price.String())
+	}
+	if offerID != 0 {
+		s += fmt.Sprintf(" (update offer %d)", offerID)
+	}
+	return s
+}
+
+func describeOfferDetails(details map[string]interface{}, offerAmount string, selling, buying Asset, price xdr.Price) {
+	details["amount"] = offerAmount
+	details["selling_asset"] = assetString(selling)
+	details["buying_asset"] = assetString(buying)
+	details["price"] = price.String()
+}
+
+func describeSetOptions(o *SetOptions, details map[string]interface{}) string {
+	var changes []string
+	if o.InflationDestination != nil {
+		changes = append(changes, "inflation destination to "+*o.InflationDestination)
+		details["inflation_dest"] = *o.InflationDestination
+	}
+	if len(o.SetFlags) > 0 {
+		names := accountFlagNames(o.SetFlags)
+		changes = append(changes, "set flags "+strings.Join(names, ", "))
+		details["set_flags"] = names
+	}
+	if len(o.ClearFlags) > 0 {
+		names := accountFlagNames(o.ClearFlags)
+		changes = append(changes, "clear flags "+strings.Join(names, ", "))
+		details["clear_flags"] = names
+	}
+	if o.MasterWeight != nil {
+		changes = append(changes, fmt.Sprintf("master weight to %d", *o.MasterWeight))
+		details["master_key_weight"] = *o.MasterWeight
+	}
+	if o.LowThreshold != nil {
+		changes = append(changes, fmt.Sprintf("low threshold to %d", *o.LowThreshold))
+		details["low_threshold"] = *o.LowThreshold
+	}
+	if o.MediumThreshold != nil {
+		changes = append(changes, fmt.Sprintf("medium threshold to %d", *o.MediumThreshold))
+		details["med_threshold"] = *o.MediumThreshold
+	}
+	if o.HighThreshold != nil {
+		changes = append(changes, fmt.Sprintf("high threshold to %d", *o.HighThreshold))
+		details["high_threshold"] = *o.HighThreshold
+	}
+	if o.HomeDomain != nil {
+		changes = append(changes, fmt.Sprintf("home domain to %q", *o.HomeDomain))
+		details["home_domain"] = *o.HomeDomain
+	}
+	if o.Signer != nil {
+		if o.Signer.Weight == 0 {
+			changes = append(changes, "remove signer "+o.Signer.Address)
+		} else {
+			changes = append(changes, fmt.Sprintf("add signer %s with weight %d", o.Signer.Address, o.Signer.Weight))
+		}
+		details["signer_key"] = o.Signer.Address
+		details["signer_weight"] = o.Signer.Weight
+	}
+	if len(changes) == 0 {
+		return "Set options (no changes)"
+	}
+	return "Set " + strings.Join(changes, "; ")
+}
+
+func describeRevokeSponsorship(o *RevokeSponsorship, details map[string]interface{}) string {
+	switch o.SponsorshipType {
+	case RevokeSponsorshipTypeAccount:
+		if o.Account != nil {
+			details["account_id"] = *o.Account
+			return fmt.Sprintf("Revoke sponsorship of account %s", *o.Account)
+		}
+	case RevokeSponsorshipTypeTrustLine:
+		if o.TrustLine != nil {
+			asset := ""
+			if o.TrustLine.Asset != nil {
+				if poolID, ok := o.TrustLine.Asset.GetLiquidityPoolID(); ok {
+					asset = "liquidity pool " + hex.EncodeToString(poolID[:])
+				} else {
+					asset = assetString(o.TrustLine.Asset)
+				}
+			}
+			details["trustline_account_id"] = o.TrustLine.Account
+			details["trustline_asset"] = asset
+			return fmt.Sprintf("Revoke sponsorship of trustline %s of %s", asset, o.TrustLine.Account)
+		}
+	case RevokeSponsorshipTypeOffer:
+		if o.Offer != nil {
+			details["offer_id"] = o.Offer.OfferID
+			details["seller"] = o.Offer.SellerAccountAddress
+			return fmt.Sprintf("Revoke sponsorship of offer %d of %s", o.Offer.OfferID, o.Offer.SellerAccountAddress)
+		}
+	case RevokeSponsorshipTypeData:
+		if o.Data != nil {
+			details["data_account_id"] = o.Data.Account
+			details["data_name"] = o.Data.DataName
+			return fmt.Sprintf("Revoke sponsorship of data entry %q of %s", o.Data.DataName, o.Data.Account)
+		}
+	case RevokeSponsorshipTypeClaimableBalance:
+		if o.ClaimableBalance != nil {
+			details["claimable_balance_id"] = *o.ClaimableBalance
+			return fmt.Sprintf("Revoke sponsorship of claimable balance %s", *o.ClaimableBalance)
+		}
+	case RevokeSponsorshipTypeSigner:
+		if o.Signer != nil {
+			details["signer_account_id"] = o.Signer.AccountID
+			details["signer_key"] = o.Signer.SignerAddress
+			return fmt.Sprintf("Revoke sponsorship of signer %s of %s", o.Signer.SignerAddress, o.Signer.AccountID)
+		}
+	}
+	return "Revoke sponsorship"
+}
+
+var accountFlagDescriptions = []struct {
+	flag AccountFlag
+	name string
+}{
+	{AuthRequired, "auth_required"},
+	{AuthRevocable, "auth_revocable"},
+	{AuthImmutable, "auth_immutable"},
+	{AuthClawbackEnabled, "auth_clawback_enabled"},
+}
+
+func accountFlagNames(flags []AccountFlag) []string {
+	names := []string{}
+	for _, flag := range flags {
+		name := fmt.Sprintf("unknown(%d)", flag)
+		for _, d := range accountFlagDescriptions {
+			if d.flag == flag {
+				name = d.name
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+var trustLineFlagDescriptions = []struct {
+	flag TrustLineFlag
+	name string
+}{
+	{TrustLineAuthorized, "authorized"},
+	{TrustLineAuthorizedToMaintainLiabilities, "authorized_to_maintain_liabilities"},
+	{TrustLineClawbackEnabled, "clawback_enabled"},
+}
+
+func trustLineFlagNames(flags []TrustLineFlag) []string {
+	names := []string{}
+	for _, flag := range flags {
+		name := fmt.Sprintf("unknown(%d)", flag)
+		for _, d := range trustLineFlagDescriptions {
+			if d.flag == flag {
+				name = d.name
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func joinOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func isZeroAmount(value string) bool {
+	parsed, err := amount.ParseInt64(value)
+	return err == nil && parsed == 0
+}
+
+// dataValueString renders a data entry value as text when it is printable
+// and as base64 otherwise.
+func dataValueString(value []byte) string {
+	for _, r := range string(value) {
+		if !strconv.IsPrint(r) {
+			return "base64:" + base64.StdEncoding.EncodeToString(value)
+		}
+	}
+	return strconv.Quote(string(value))
+}
+
+func predicateString(p xdr.ClaimPredicate) string {
+	switch p.Type {
+	case xdr.ClaimPredicateTypeClaimPredicateUnconditional:
+		return "unconditional"
+	case xdr.ClaimPredicateTypeClaimPredicateAnd:
+		return "and(" + predicateListString(p.AndPredicates) + ")"
+	case xdr.ClaimPredicateTypeClaimPredicateOr:
+		return "or(" + predicateListString(p.OrPredicates) + ")"
+	case xdr.ClaimPredicateTypeClaimPredicateNot:
+		if p.NotPredicate == nil || *p.NotPredicate == nil {
+			return "not()"
+		}
+		return "not(" + predicateString(**p.NotPredicate) + ")"
+	case xdr.ClaimPredicateTypeClaimPredicateBeforeAbsoluteTime:
+		if p.AbsBefore == nil {
+			return "before_absolute_time()"
+		}
+		return "before " + time.Unix(int64(*p.AbsBefore), 0).UTC().Format(time.RFC3339)
+	case xdr.ClaimPredicateTypeClaimPredicateBeforeRelativeTime:
+		if p.RelBefore == nil {
+			return "before_relative_time()"
+		}
+		return fmt.Sprintf("within %ds", int64(*p.RelBefore))
+	default:
+		return fmt.Sprintf("unknown(%d)", p.Type)
+	}
+}
+
+func predicateListString(predicates *[]xdr.ClaimPredicate) string {
+	if predicates == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*predicates))
+	for _, p := range *predicates {
+		parts = append(parts, predicateString(p))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeHostFunction(hf xdr.HostFunction, details map[string]interface{}) (string, error) {
+	switch hf.Type {
+	case xdr.HostFunctionTypeHostFunctionTypeInvokeContract:
+		invoke := hf.MustInvokeContract()
+		contract, call, err := describeContractCall(invoke)
+		if err != nil {
+			return "", err
+		}
+		args := make([]string, 0, len(invoke.Args))
+		for _, arg := range invoke.Args {
+			args = append(args, ScValString(arg))
+		}
+		details["function"] = "invoke_contract"
+		details["contract"] = contract
+		details["contract_function"] = string(invoke.FunctionName)
+		details["args"] = args
+		return fmt.Sprintf("Invoke contract %s: %s", contract, call), nil
+	case xdr.HostFunctionTypeHostFunctionTypeCreateContract:
+		create := hf.MustCreateContract()
+		summary, err := describeCreateContract(create, details)
+		if err != nil {
+			return "", err
+		}
+		details["function"] = "create_contract"
+		return summary, nil
+	case xdr.HostFunctionTypeHostFunctionTypeUploadContractWasm:
+		wasm := hf.MustWasm()
+		hash := sha256.Sum256(wasm)
+		details["function"] = "upload_contract_wasm"
+		details["wasm_hash"] = hex.EncodeToString(hash[:])
+		details["wasm_size"] = len(wasm)
+		return fmt.Sprintf("Upload contract wasm %s (%d bytes)", hex.EncodeToString(hash[:]), len(wasm)), nil
+	default:
+		return "", errors.Errorf("unknown host function type %s", hf.Type)
+	}
+}
+
+func describeContractCall(invoke xdr.InvokeContractArgs) (string, string, error) {
+	contract, err := invoke.ContractAddress.String()
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid contract address")
+	}
+	args := make([]string, 0, len(invoke.Args))
+	for _, arg := range invoke.Args {
+		args = append(args, ScValString(arg))
+	}
+	return contract, fmt.Sprintf("%s(%s)", invoke.FunctionName, strings.Join(args, ", ")), nil
+}
+
+func describeCreateContract(create xdr.CreateContractArgs, details map[string]interface{}) (string, error) {
+	var source string
+	preimage := create.ContractIdPreimage
+	switch preimage.Type {
+	case xdr.ContractIdPreimageTypeContractIdPreimageFromAddress:
+		fromAddress := preimage.MustFromAddress()
+		address, err := fromAddress.Address.String()
+		if err != nil {
+			return "", errors.Wrap(err, "invalid deployer address")
+		}
+		details["deployer"] = address
+		details["salt"] = hex.EncodeToString(fromAddress.Salt[:])
+		source = "deployed by " + address
+	case xdr.ContractIdPreimageTypeContractIdPreimageFromAsset:
+		asset := preimage.MustFromAsset()
+		details["asset"] = asset.StringCanonical()
+		source = "for asset " + asset.StringCanonical()
+	default:
+		return "", errors.Errorf("unknown contract id preimage type %s", preimage.Type)
+	}
+
+	switch create.Executable.Type {
+	case xdr.ContractExecutableTypeContractExecutableWasm:
+		hash := create.Executable.MustWasmHash()
+		details["executable"] = "wasm:" + hex.EncodeToString(hash[:])
+	case xdr.ContractExecutableTypeContractExecutableToken:
+		details["executable"] = "token"
+	default:
+		return "", errors.Errorf("unknown contract executable type %s", create.Executable.Type)
+	}
+	return fmt.Sprintf("Create %s contract %s", details["executable"], source), nil
+}
+
+func describeAuthorizationEntry(entry xdr.SorobanAuthorizationEntry) map[string]interface{} {
+	result := map[string]interface{}{}
+	switch entry.Credentials.Type {
+	case xdr.SorobanCredentialsTypeSorobanCredentialsSourceAccount:
+		result["credentials"] = "source_account"
+	case xdr.SorobanCredentialsTypeSorobanCredentialsAddress:
+		creds := entry.Credentials.MustAddress()
+		address, err := creds.Address.String()
+		if err != nil {
+			address = "invalid address"
+		}
+		result["credentials"] = address
+		result["nonce"] = int64(creds.Nonce)
+		result["signature_expiration_ledger"] = uint32(creds.SignatureExpirationLedger)
+	}
+	result["invocations"] = describeAuthorizedInvocation(entry.RootInvocation, nil)
+	return result
+}
+
+func describeAuthorizedInvocation(invocation xdr.SorobanAuthorizedInvocation, result []string) []string {
+	fn := invocation.Function
+	switch fn.Type {
+	case xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeContractFn:
+		if invoke, ok := fn.GetContractFn(); ok {
+			if contract, call, err := describeContractCall(invoke); err == nil {
+				result = append(result, contract+": "+call)
+				break
+			}
+		}
+		result = append(result, "invalid contract invocation")
+	case xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeCreateContractHostFn:
+		if create, ok := fn.GetCreateContractHostFn(); ok {
+			if summary, err := describeCreateContract(create, map[string]interface{}{}); err == nil {
+				result = append(result, summary)
+				break
+			}
+		}
+		result = append(result, "invalid create contract")
+	}
+	for _, sub := range invocation.SubInvocations {
+		result = describeAuthorizedInvocation(sub, result)
+	}
+	return result
+}
+
+// ScValString renders a smart contract value in a compact, human-readable
+// form, for example `sym:transfer`, `i128:1000` or `vec[u32:1, u32:2]`.
+func ScValString(v xdr.ScVal) string {
+	switch v.Type {
+	case xdr.ScValTypeScvBool:
+		return strconv.FormatBool(v.MustB())
+	case xdr.ScValTypeScvVoid:
+		return "void"
+	case xdr.ScValTypeScvError:
+		e := v.MustError()
+		if e.Type == xdr.ScErrorTypeSceContract {
+			return fmt.Sprintf("error(contract:%d)", e.MustContractCode())
+		}
+		return fmt.Sprintf("error(%s:%s)", e.Type, e.MustCode())
+	case xdr.ScValTypeScvU32:
+		return fmt.Sprintf("u32:%d", v.MustU32())
+	case xdr.ScValTypeScvI32:
+		return fmt.Sprintf("i32:%d", v.MustI32())
+	case xdr.ScValTypeScvU64:
+		return fmt.Sprintf("u64:%d", v.MustU64())
+	case xdr.ScValTypeScvI64:
+		return fmt.Sprintf("i64:%d", v.MustI64())
+	case xdr.ScValTypeScvTimepoint:
+		return "timepoint:" + time.Unix(int64(v.MustTimepoint()), 0).UTC().Format(time.RFC3339)
+	case xdr.ScValTypeScvDuration:
+		return fmt.Sprintf("duration:%ds", v.MustDuration())
+	case xdr.ScValTypeScvU128:
+		parts := v.MustU128()
+		return "u128:" + joinWords(false, uint64(parts.Hi), uint64(parts.Lo)).String()
+	case xdr.ScValTypeScvI128:
+		parts := v.MustI128()
+		return "i128:" + joinWords(true, uint64(parts.Hi), uint64(parts.Lo)).String()
+	case xdr.ScValTypeScvU256:
+		parts := v.MustU256()
+		return "u256:" + joinWords(false, uint64(parts.HiHi), uint64(parts.HiLo), uint64(parts.LoHi), uint64(parts.LoLo)).String()
+	case xdr.ScValTypeScvI256:
+		parts := v.MustI256()
+		return "i256:" + joinWords(true, uint64(parts.HiHi), uint64(parts.HiLo), uint64(parts.LoHi), uint64(parts.LoLo)).String()
+	case xdr.ScValTypeScvBytes:
+		return "bytes:" + hex.EncodeToString(v.MustBytes())
+	case xdr.ScValTypeScvString:
+		return strconv.Quote(string(v.MustStr()))
+	case xdr.ScValTypeScvSymbol:
+		return "sym:" + string(v.MustSym())
+	case xdr.ScValTypeScvVec:
+		vec := v.MustVec()
+		if vec == nil {
+			return "vec[]"
+		}
+		parts := make([]string, 0, len(*vec))
+		for _, item := range *vec {
+			parts = append(parts, ScValString(item))
+		}
+		return "vec[" + strings.Join(parts, ", ") + "]"
+	case xdr.ScValTypeScvMap:
+		m := v.MustMap()
+		if m == nil {
+			return "map{}"
+		}
+		parts := make([]string, 0, len(*m))
+		for _, entry := range *m {
+			parts = append(parts, ScValString(entry.Key)+": "+ScValString(entry.Val))
+		}
+		return "map{" + strings.Join(parts, ", ") + "}"
+	case xdr.ScValTypeScvAddress:
+		address, err := v.MustAddress().String()
+		if err != nil {
+			return "address:invalid"
+		}
+		return "address:" + address
+	case xdr.ScValTypeScvContractInstance:
+		executable := v.MustInstance().Executable
+		if executable.Type == xdr.ContractExecutableTypeContractExecutableWasm {
+			hash := executable.MustWasmHash()
+			return "instance(wasm:" + hex.EncodeToString(hash[:]) + ")"
+		}
+		return "instance(token)"
+	case xdr.ScValTypeScvLedgerKeyContractInstance:
+		return "ledger_key_contract_instance"
+	case xdr.ScValTypeScvLedgerKeyNonce:
+		return fmt.Sprintf("nonce:%d", v.MustNonceKey().Nonce)
+	default:
+		return fmt.Sprintf("unknown(%d)", v.Type)
+	}
+}
+
+// joinWords builds an integer from big-endian 64 bit words, interpreting it
+// as two's complement when signed is true.
+func joinWords(signed bool, words ...uint64) *big.Int {
+	result := new(big.Int)
+	for _, w := range words {
+		result.Lsh(result, 64)
+		result.Or(result, new(big.Int).SetUint64(w))
+	}
+	if signed && len(words) > 0 && int64(words[0]) < 0 {
+		result.Sub(result, new(big.Int).Lsh(big.NewInt(1), uint(64*len(words))))
+	}
+	return result
+}
+
+// DescriptionDiff is a single difference between two transaction
+// descriptions. Path is the JSON path of the field which differs and Old or
+// New are empty when the field is missing on that side.
+type DescriptionDiff struct {
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// DiffTransactionDescriptions compares two transaction descriptions field by
+// field and returns the differences sorted by path. It can be used to show
+// what changed between a proposed transaction and the one being signed.
+func DiffTransactionDescriptions(before, after TransactionDescription) ([]DescriptionDiff, error) {
+	oldFields, err := flattenDescription(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenDescription(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []DescriptionDiff
+	for path, oldValue := range oldFields {
+		if newValue, ok := newFields[path]; !ok || newValue != oldValue {
+			diffs = append(diffs, DescriptionDiff{Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			diffs = append(diffs, DescriptionDiff{Path: path, New: newValue})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs, nil
+}
+
+func flattenDescription(d TransactionDescription) (map[string]string, error) {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal description")
+	}
+	var value interface{}
+	if err = json.Unmarshal(raw, &value); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal description")
+	}
+	fields := map[string]string{}
+	flattenValue("", value, fields)
+	return fields, nil
+}
+
+func flattenValue(path string, value interface{}, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if path == "" {
+				flattenValue(key, item, fields)
+			} else {
+				flattenValue(path+"."+key, item, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(fmt.Sprintf("%s[%d]", path, i), item, fields)
+		}
+	case nil:
+	case string:
+		fields[path] = v
+	default:
+		fields[path] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,248 @@
+package txnbuild
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/metriqorg/go/network"
+	"github.com/metriqorg/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDescribeTestTransaction(t *testing.T, destination string, paymentAmount string) *Transaction {
+	kp0 := newKeypair0()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 9605939170639897)
+	usd := CreditAsset{Code: "USD", Issuer: newKeypair2().Address()}
+	weight := Threshold(2)
+
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations: []Operation{
+				&Payment{Destination: destination, Amount: paymentAmount, Asset: usd},
+				&SetOptions{Signer: &Signer{Address: newKeypair1().Address(), Weight: weight}},
+				&ManageData{Name: "config", Value: []byte("enabled")},
+			},
+			BaseFee:       MinBaseFee,
+			Memo:          MemoText("invoice 42"),
+			Preconditions: Preconditions{TimeBounds: NewTimebounds(0, 1700000000)},
+		},
+	)
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, kp0)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestDescribeTransaction(t *testing.T) {
+	kp0 := newKeypair0()
+	tx := newDescribeTestTransaction(t, newKeypair1().Address(), "10")
+
+	desc, err := DescribeTransaction(tx, DescribeOptions{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Signers:           []string{newKeypair1().Address(), kp0.Address()},
+	})
+	require.NoError(t, err)
+
+	hash, err := tx.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, hash, desc.Hash)
+	assert.Equal(t, kp0.Address(), desc.SourceAccount)
+	assert.Equal(t, int64(300), desc.MaxFee)
+	assert.Equal(t, &MemoDescription{Type: "text", Value: "invoice 42"}, desc.Memo)
+	assert.Nil(t, desc.Preconditions.MinTime)
+	require.NotNil(t, desc.Preconditions.MaxTime)
+	assert.Equal(t, int64(1700000000), desc.Preconditions.MaxTime.Unix())
+
+	require.Len(t, desc.Operations, 3)
+	assert.Equal(t, "payment", desc.Operations[0].Type)
+	assert.Equal(t,
+		"Pay 10 USD:"+newKeypair2().Address()+" to "+newKeypair1().Address(),
+		desc.Operations[0].Summary,
+	)
+	assert.Equal(t, "set_options", desc.Operations[1].Type)
+	assert.Equal(t, "Set add signer "+newKeypair1().Address()+" with weight 2", desc.Operations[1].Summary)
+	assert.Equal(t, `Set data entry "config" to "enabled"`, desc.Operations[2].Summary)
+
+	require.Len(t, desc.Signatures, 1)
+	assert.Equal(t, kp0.Address(), desc.Signatures[0].Signer)
+
+	text := desc.String()
+	assert.Contains(t, text, "Transaction "+hash)
+	assert.Contains(t, text, "1. Pay 10 USD:")
+	assert.Contains(t, text, "Valid from any time until 2023-11-14T22:13:20Z")
+
+	raw, err := desc.JSON()
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, hash, decoded["hash"])
+}
+
+func TestDescribeTransactionXDRFeeBump(t *testing.T) {
+	inner := newDescribeTestTransaction(t, newKeypair1().Address(), "10")
+	feeBump, err := NewFeeBumpTransaction(FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: newKeypair1().Address(),
+		BaseFee:    2 * MinBaseFee,
+	})
+	require.NoError(t, err)
+	envelope, err := feeBump.Base64()
+	require.NoError(t, err)
+
+	desc, err := DescribeTransactionXDR(envelope, DescribeOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, desc.FeeBump)
+	assert.Equal(t, newKeypair1().Address(), desc.FeeBump.FeeAccount)
+	assert.Equal(t, int64(800), desc.FeeBump.MaxFee)
+	assert.Empty(t, desc.Hash)
+	assert.Len(t, desc.Operations, 3)
+	assert.True(t, strings.HasPrefix(desc.String(), "Fee bump transaction"))
+}
+
+func TestDescribeInvokeHostFunction(t *testing.T) {
+	amount := xdr.Int128Parts{Hi: -1, Lo: 0xffffffffffffff9c}
+	from := xdr.MustAddress(newKeypair0().Address())
+	op := &InvokeHostFunction{
+		HostFunction: xdr.HostFunction{
+			Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+			InvokeContract: &xdr.InvokeContractArgs{
+				ContractAddress: xdr.ScAddress{
+					Type:       xdr.ScAddressTypeScAddressTypeContract,
+					ContractId: &xdr.Hash{0x1, 0x2},
+				},
+				FunctionName: "transfer",
+				Args: xdr.ScVec{
+					{Type: xdr.ScValTypeScvAddress, Address: &xdr.ScAddress{
+						Type:      xdr.ScAddressTypeScAddressTypeAccount,
+						AccountId: &from,
+					}},
+					{Type: xdr.ScValTypeScvI128, I128: &amount},
+				},
+			},
+		},
+		Auth: []xdr.SorobanAuthorizationEntry{
+			{
+				Credentials: xdr.SorobanCredentials{
+					Type: xdr.SorobanCredentialsTypeSorobanCredentialsSourceAccount,
+				},
+				RootInvocation: xdr.SorobanAuthorizedInvocation{
+					Function: xdr.SorobanAuthorizedFunction{
+						Type: xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeContractFn,
+						ContractFn: &xdr.InvokeContractArgs{
+							ContractAddress: xdr.ScAddress{
+								Type:       xdr.ScAddressTypeScAddressTypeContract,
+								ContractId: &xdr.Hash{0x1, 0x2},
+							},
+							FunctionName: "transfer",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	desc, err := DescribeOperation(op)
+	require.NoError(t, err)
+	assert.Equal(t, "invoke_host_function", desc.Type)
+	contract, err := op.HostFunction.InvokeContract.ContractAddress.String()
+	require.NoError(t, err)
+	assert.Equal(t,
+		"Invoke contract "+contract+": transfer(address:"+newKeypair0().Address()+", i128:-100)",
+		desc.Summary,
+	)
+	assert.Equal(t, []string{"address:" + newKeypair0().Address(), "i128:-100"}, desc.Details["args"])
+	auth := desc.Details["auth"].([]map[string]interface{})
+	require.Len(t, auth, 1)
+	assert.Equal(t, "source_account", auth[0]["credentials"])
+	assert.Equal(t, []string{contract + ": transfer()"}, auth[0]["invocations"])
+}
+
+func TestScValString(t *testing.T) {
+	sym := xdr.ScSymbol("name")
+	str := xdr.ScString("hello")
+	u32 := xdr.Uint32(7)
+	u128 := xdr.UInt128Parts{Hi: 1, Lo: 0}
+	vec := &xdr.ScVec{{Type: xdr.ScValTypeScvU32, U32: &u32}, {Type: xdr.ScValTypeScvVoid}}
+	m := &xdr.ScMap{{
+		Key: xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym},
+		Val: xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &str},
+	}}
+
+	assert.Equal(t, "u128:18446744073709551616", ScValString(xdr.ScVal{Type: xdr.ScValTypeScvU128, U128: &u128}))
+	assert.Equal(t, "vec[u32:7, void]", ScValString(xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &vec}))
+	assert.Equal(t, `map{sym:name: "hello"}`, ScValString(xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &m}))
+}
+
+func TestDiffTransactionDescriptions(t *testing.T) {
+	before, err := DescribeTransaction(newDescribeTestTransaction(t, newKeypair1().Address(), "10"), DescribeOptions{})
+	require.NoError(t, err)
+	after, err := DescribeTransaction(newDescribeTestTransaction(t, newKeypair2().Address(), "10"), DescribeOptions{})
+	require.NoError(t, err)
+
+	diffs, err := DiffTransactionDescriptions(before, before)
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	diffs, err = DiffTransactionDescriptions(before, after)
+	require.NoError(t, err)
+	paths := []string{}
+	for _, d := range diffs {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{
+		"operations[0].details.to",
+		"operations[0].summary",
+		"signatures[0].signature",
+	}, paths)
+	assert.Equal(t, newKeypair1().Address(), diffs[0].Old)
+	assert.Equal(t, newKeypair2().Address(), diffs[0].New)
+}
+
+func TestDescribeOperationZeroAmounts(t *testing.T) {
+	usd := CreditAsset{Code: "USD", Issuer: newKeypair2().Address()}
+	line, err := usd.ToChangeTrustAsset()
+	require.NoError(t, err)
+
+	for _, limit := range []string{"0", "0.0", "0.0000000", "00.000"} {
+		desc, err := DescribeOperation(&ChangeTrust{Line: line, Limit: limit})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(desc.Summary, "Remove trustline to USD"), limit)
+
+		desc, err = DescribeOperation(&ManageSellOffer{
+			Selling: usd,
+			Buying:  NativeAsset{},
+			Amount:  limit,
+			Price:   xdr.Price{N: 1, D: 1},
+			OfferID: 42,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Delete offer 42", desc.Summary, limit)
+	}
+
+	desc, err := DescribeOperation(&ChangeTrust{Line: line, Limit: "0.0000001"})
+	require.NoError(t, err)
+	assert.False(t, strings.HasPrefix(desc.Summary, "Remove trustline"))
+
+	desc, err = DescribeOperation(&ManageSellOffer{
+		Selling: usd,
+		Buying:  NativeAsset{},
+		Amount:  "0.000001",
+		Price:   xdr.Price{N: 1, D: 1},
+		OfferID: 42,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, "Delete offer 42", desc.Summary)
+}
+
+func TestIsZeroAmount(t *testing.T) {
+	assert.True(t, isZeroAmount("0"))
+	assert.True(t, isZeroAmount("0.0000000"))
+	assert.True(t, isZeroAmount("000.00"))
+	assert.False(t, isZeroAmount("0.000001"))
+	assert.False(t, isZeroAmount("1"))
+	assert.False(t, isZeroAmount(""))
+	assert.False(t, isZeroAmount("abc"))
+}
@@ -0,0 +1,288 @@
+package txnbuild
+
+import (
+	"github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/support/errors"
+)
+
+// feeBumpReplaceMultiplier is the factor by which the fee rate of a fee-bump
+// transaction must exceed the fee rate of a pending transaction for gravity to
+// replace the pending transaction in its queue.
+const feeBumpReplaceMultiplier = 10
+
+// surgeCapacityUsage is the ledger capacity usage above which the network is
+// considered to be in surge pricing.
+const surgeCapacityUsage = 0.9
+
+// FeeStatsProvider is the interface used by FeeEstimator to obtain fee
+// statistics. It is implemented by orbitrclient.Client.
+type FeeStatsProvider interface {
+	FeeStats() (orbitr.FeeStats, error)
+}
+
+// FeeStrategy describes how a base fee is picked from the fee statistics
+// reported by OrbitR.
+type FeeStrategy struct {
+	// Percentile of the max fee distribution of recent ledgers to bid. Must be
+	// one of 10, 20, 30, 40, 50, 60, 70, 80, 90, 95 or 99.
+	Percentile int
+	// MaxBaseFee caps the base fee (per operation) the estimator is allowed to
+	// pick. Zero means there is no cap.
+	MaxBaseFee int64
+}
+
+var (
+	// FeeStrategyEconomy bids the median of recent max fees.
+	FeeStrategyEconomy = FeeStrategy{Percentile: 50}
+	// FeeStrategyNormal bids the 70th percentile of recent max fees.
+	FeeStrategyNormal = FeeStrategy{Percentile: 70}
+	// FeeStrategyPriority bids the 95th percentile of recent max fees.
+	FeeStrategyPriority = FeeStrategy{Percentile: 95}
+)
+
+// Validate returns an error if the strategy cannot be used to estimate fees.
+func (s FeeStrategy) Validate() error {
+	if _, err := percentileFee(orbitr.FeeDistribution{}, s.Percentile); err != nil {
+		return err
+	}
+	if s.MaxBaseFee < 0 {
+		return errors.New("max base fee cannot be negative")
+	}
+	if s.MaxBaseFee != 0 && s.MaxBaseFee < MinBaseFee {
+		return errors.Errorf("max base fee cannot be lower than network minimum of %d", MinBaseFee)
+	}
+	return nil
+}
+
+// FeeEstimate is the result of estimating the fee of a transaction.
+type FeeEstimate struct {
+	// BaseFee is the fee per operation which should be used as
+	// TransactionParams.BaseFee.
+	BaseFee int64
+	// MaxFee is the maximum fee the transaction will be charged, that is
+	// BaseFee multiplied by the number of operations.
+	MaxFee int64
+	// Surge is true when the network was in surge pricing according to the
+	// fee statistics.
+	Surge bool
+	// Capped is true when the base fee was limited by FeeStrategy.MaxBaseFee.
+	Capped bool
+}
+
+// FeeBumpPlan describes the fee-bump transaction needed to get a pending
+// transaction included in a ledger.
+type FeeBumpPlan struct {
+	// Needed is false when the inner transaction already bids at least the
+	// estimated base fee and no fee-bump is required.
+	Needed bool
+	// BaseFee is the base fee to use in FeeBumpTransactionParams.
+	BaseFee int64
+	// MaxFee is the maximum fee the fee account will be charged. It includes
+	// the additional operation counted for the fee-bump itself.
+	MaxFee int64
+	// Surge is true when the network was in surge pricing according to the
+	// fee statistics.
+	Surge bool
+}
+
+// EstimateFee picks a base fee for a transaction with numOps operations
+// using the fee statistics and strategy provided.
+func EstimateFee(stats orbitr.FeeStats, strategy FeeStrategy, numOps int) (FeeEstimate, error) {
+	if numOps <= 0 {
+		return FeeEstimate{}, errors.New("number of operations must be positive")
+	}
+	if err := strategy.Validate(); err != nil {
+		return FeeEstimate{}, errors.Wrap(err, "invalid fee strategy")
+	}
+
+	baseFee, err := percentileFee(stats.MaxFee, strategy.Percentile)
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+	if baseFee < stats.LastLedgerBaseFee {
+		baseFee = stats.LastLedgerBaseFee
+	}
+	if baseFee < MinBaseFee {
+		baseFee = MinBaseFee
+	}
+
+	estimate := FeeEstimate{Surge: isSurge(stats)}
+	if strategy.MaxBaseFee != 0 && baseFee > strategy.MaxBaseFee {
+		baseFee = strategy.MaxBaseFee
+		estimate.Capped = true
+	}
+
+	estimate.BaseFee = baseFee
+	estimate.MaxFee, err = mulFee(baseFee, numOps)
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+	return estimate, nil
+}
+
+// PlanFeeBump computes the fee-bump needed for a pending inner transaction.
+// Gravity only replaces a transaction in its queue when the fee-bump bids a
+// fee rate at least ten times higher than the pending transaction, so the
+// plan never proposes less than that. An error is returned if the strategy's
+// MaxBaseFee does not allow a replacement.
+func PlanFeeBump(stats orbitr.FeeStats, strategy FeeStrategy, inner *Transaction) (FeeBumpPlan, error) {
+	if inner == nil {
+		return FeeBumpPlan{}, errors.New("inner transaction is missing")
+	}
+	numOps := len(inner.operations)
+	estimate, err := EstimateFee(stats, strategy, numOps)
+	if err != nil {
+		return FeeBumpPlan{}, err
+	}
+
+	plan := FeeBumpPlan{Surge: estimate.Surge}
+	if inner.baseFee >= estimate.BaseFee {
+		return plan, nil
+	}
+
+	replaceFee, err := mulFee(inner.baseFee, feeBumpReplaceMultiplier)
+	if err != nil {
+		return FeeBumpPlan{}, err
+	}
+	baseFee := estimate.BaseFee
+	if baseFee < replaceFee {
+		baseFee = replaceFee
+	}
+	if baseFee < MinBaseFee {
+		baseFee = MinBaseFee
+	}
+	if strategy.MaxBaseFee != 0 && baseFee > strategy.MaxBaseFee {
+		return FeeBumpPlan{}, errors.Errorf(
+			"base fee of %d required to replace pending transaction exceeds max base fee of %d",
+			baseFee, strategy.MaxBaseFee,
+		)
+	}
+
+	plan.Needed = true
+	plan.BaseFee = baseFee
+	plan.MaxFee, err = mulFee(baseFee, numOps+1)
+	if err != nil {
+		return FeeBumpPlan{}, err
+	}
+	return plan, nil
+}
+
+// FeeEstimator estimates transaction fees using the fee statistics of an
+// OrbitR instance.
+type FeeEstimator struct {
+	Client   FeeStatsProvider
+	Strategy FeeStrategy
+}
+
+// NewFeeEstimator returns a FeeEstimator using the given client and strategy.
+func NewFeeEstimator(client FeeStatsProvider, strategy FeeStrategy) *FeeEstimator {
+	return &FeeEstimator{Client: client, Strategy: strategy}
+}
+
+func (e *FeeEstimator) feeStats() (orbitr.FeeStats, error) {
+	if e.Client == nil {
+		return orbitr.FeeStats{}, errors.New("fee estimator has no client")
+	}
+	stats, err := e.Client.FeeStats()
+	if err != nil {
+		return orbitr.FeeStats{}, errors.Wrap(err, "could not obtain fee stats")
+	}
+	return stats, nil
+}
+
+// Estimate returns the fee estimate for a transaction with numOps operations.
+func (e *FeeEstimator) Estimate(numOps int) (FeeEstimate, error) {
+	stats, err := e.feeStats()
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+	return EstimateFee(stats, e.Strategy, numOps)
+}
+
+// BaseFee returns the base fee to use for a transaction with numOps
+// operations. It is a shortcut for Estimate(numOps).BaseFee.
+func (e *FeeEstimator) BaseFee(numOps int) (int64, error) {
+	estimate, err := e.Estimate(numOps)
+	if err != nil {
+		return 0, err
+	}
+	return estimate.BaseFee, nil
+}
+
+// PlanFeeBump returns the fee-bump plan for a pending inner transaction.
+func (e *FeeEstimator) PlanFeeBump(inner *Transaction) (FeeBumpPlan, error) {
+	stats, err := e.feeStats()
+	if err != nil {
+		return FeeBumpPlan{}, err
+	}
+	return PlanFeeBump(stats, e.Strategy, inner)
+}
+
+// FeeBump wraps a pending inner transaction in a FeeBumpTransaction paid by
+// feeAccount with the base fee from PlanFeeBump. The returned transaction is
+// unsigned. If the inner transaction already bids enough, nil is returned
+// together with a plan whose Needed field is false.
+func (e *FeeEstimator) FeeBump(inner *Transaction, feeAccount string) (*FeeBumpTransaction, FeeBumpPlan, error) {
+	plan, err := e.PlanFeeBump(inner)
+	if err != nil {
+		return nil, FeeBumpPlan{}, err
+	}
+	if !plan.Needed {
+		return nil, plan, nil
+	}
+
+	tx, err := NewFeeBumpTransaction(FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: feeAccount,
+		BaseFee:    plan.BaseFee,
+	})
+	if err != nil {
+		return nil, FeeBumpPlan{}, errors.Wrap(err, "could not build fee bump transaction")
+	}
+	return tx, plan, nil
+}
+
+func isSurge(stats orbitr.FeeStats) bool {
+	return stats.LedgerCapacityUsage >= surgeCapacityUsage ||
+		stats.FeeCharged.Min > stats.LastLedgerBaseFee
+}
+
+func percentileFee(dist orbitr.FeeDistribution, percentile int) (int64, error) {
+	switch percentile {
+	case 10:
+		return dist.P10, nil
+	case 20:
+		return dist.P20, nil
+	case 30:
+		return dist.P30, nil
+	case 40:
+		return dist.P40, nil
+	case 50:
+		return dist.P50, nil
+	case 60:
+		return dist.P60, nil
+	case 70:
+		return dist.P70, nil
+	case 80:
+		return dist.P80, nil
+	case 90:
+		return dist.P90, nil
+	case 95:
+		return dist.P95, nil
+	case 99:
+		return dist.P99, nil
+	default:
+		return 0, errors.Errorf("unsupported fee percentile %d", percentile)
+	}
+}
+
+func mulFee(fee int64, n int) (int64, error) {
+	if fee == 0 || n == 0 {
+		return 0, nil
+	}
+	total := fee * int64(n)
+	if total/int64(n) != fee {
+		return 0, errors.Errorf("base fee %d results in an overflow of max fee", fee)
+	}
+	return total, nil
+}
@@ -0,0 +1,153 @@
+package txnbuild
+
+import (
+	"testing"
+
+	"github.com/metriqorg/go/protocols/orbitr"
+	"github.com/metriqorg/go/support/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFeeStatsProvider struct {
+	stats orbitr.FeeStats
+	err   error
+}
+
+func (f fakeFeeStatsProvider) FeeStats() (orbitr.FeeStats, error) {
+	return f.stats, f.err
+}
+
+func surgeFeeStats() orbitr.FeeStats {
+	return orbitr.FeeStats{
+		LastLedger:          100,
+		LastLedgerBaseFee:   100,
+		LedgerCapacityUsage: 0.97,
+		FeeCharged: orbitr.FeeDistribution{
+			Min: 250,
+		},
+		MaxFee: orbitr.FeeDistribution{
+			P10: 100,
+			P50: 300,
+			P70: 800,
+			P95: 5000,
+			P99: 20000,
+		},
+	}
+}
+
+func TestEstimateFeeStrategies(t *testing.T) {
+	stats := surgeFeeStats()
+	for _, tc := range []struct {
+		strategy FeeStrategy
+		baseFee  int64
+	}{
+		{FeeStrategyEconomy, 300},
+		{FeeStrategyNormal, 800},
+		{FeeStrategyPriority, 5000},
+	} {
+		estimate, err := EstimateFee(stats, tc.strategy, 3)
+		require.NoError(t, err)
+		assert.Equal(t, tc.baseFee, estimate.BaseFee)
+		assert.Equal(t, 3*tc.baseFee, estimate.MaxFee)
+		assert.True(t, estimate.Surge)
+		assert.False(t, estimate.Capped)
+	}
+}
+
+func TestEstimateFeeMinimum(t *testing.T) {
+	estimate, err := EstimateFee(orbitr.FeeStats{}, FeeStrategy{Percentile: 10}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(MinBaseFee), estimate.BaseFee)
+	assert.False(t, estimate.Surge)
+}
+
+func TestEstimateFeeCapped(t *testing.T) {
+	estimate, err := EstimateFee(surgeFeeStats(), FeeStrategy{Percentile: 99, MaxBaseFee: 1000}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), estimate.BaseFee)
+	assert.Equal(t, int64(2000), estimate.MaxFee)
+	assert.True(t, estimate.Capped)
+}
+
+func TestEstimateFeeInvalid(t *testing.T) {
+	_, err := EstimateFee(surgeFeeStats(), FeeStrategy{Percentile: 75}, 1)
+	assert.EqualError(t, err, "invalid fee strategy: unsupported fee percentile 75")
+
+	_, err = EstimateFee(surgeFeeStats(), FeeStrategy{Percentile: 50, MaxBaseFee: 10}, 1)
+	assert.EqualError(t, err, "invalid fee strategy: max base fee cannot be lower than network minimum of 100")
+
+	_, err = EstimateFee(surgeFeeStats(), FeeStrategyNormal, 0)
+	assert.EqualError(t, err, "number of operations must be positive")
+}
+
+func newPendingTransaction(t *testing.T, baseFee int64) *Transaction {
+	sourceAccount := NewSimpleAccount(newKeypair0().Address(), 1)
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []Operation{&Inflation{}, &Inflation{}},
+			BaseFee:       baseFee,
+			Preconditions: Preconditions{TimeBounds: NewInfiniteTimeout()},
+		},
+	)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestPlanFeeBump(t *testing.T) {
+	inner := newPendingTransaction(t, MinBaseFee)
+
+	plan, err := PlanFeeBump(surgeFeeStats(), FeeStrategyPriority, inner)
+	require.NoError(t, err)
+	assert.True(t, plan.Needed)
+	assert.Equal(t, int64(5000), plan.BaseFee)
+	assert.Equal(t, int64(15000), plan.MaxFee)
+
+	// the economy estimate is below the replacement fee of the pending
+	// transaction
+	plan, err = PlanFeeBump(surgeFeeStats(), FeeStrategyEconomy, inner)
+	require.NoError(t, err)
+	assert.True(t, plan.Needed)
+	assert.Equal(t, int64(10*MinBaseFee), plan.BaseFee)
+
+	_, err = PlanFeeBump(surgeFeeStats(), FeeStrategy{Percentile: 50, MaxBaseFee: 500}, inner)
+	assert.EqualError(t, err, "base fee of 1000 required to replace pending transaction exceeds max base fee of 500")
+}
+
+func TestPlanFeeBumpNotNeeded(t *testing.T) {
+	inner := newPendingTransaction(t, 1000)
+
+	plan, err := PlanFeeBump(surgeFeeStats(), FeeStrategyNormal, inner)
+	require.NoError(t, err)
+	assert.False(t, plan.Needed)
+	assert.True(t, plan.Surge)
+}
+
+func TestFeeEstimatorFeeBump(t *testing.T) {
+	estimator := NewFeeEstimator(fakeFeeStatsProvider{stats: surgeFeeStats()}, FeeStrategyPriority)
+	inner := newPendingTransaction(t, MinBaseFee)
+
+	baseFee, err := estimator.BaseFee(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), baseFee)
+
+	feeBump, plan, err := estimator.FeeBump(inner, newKeypair1().Address())
+	require.NoError(t, err)
+	assert.True(t, plan.Needed)
+	assert.Equal(t, plan.BaseFee, feeBump.BaseFee())
+	assert.Equal(t, plan.MaxFee, feeBump.MaxFee())
+	assert.Equal(t, newKeypair1().Address(), feeBump.FeeAccount())
+
+	feeBump, plan, err = NewFeeEstimator(fakeFeeStatsProvider{stats: surgeFeeStats()}, FeeStrategyEconomy).
+		FeeBump(newPendingTransaction(t, 1000), newKeypair1().Address())
+	require.NoError(t, err)
+	assert.False(t, plan.Needed)
+	assert.Nil(t, feeBump)
+}
+
+func TestFeeEstimatorClientError(t *testing.T) {
+	estimator := NewFeeEstimator(fakeFeeStatsProvider{err: errors.New("boom")}, FeeStrategyNormal)
+	_, err := estimator.Estimate(1)
+	assert.EqualError(t, err, "could not obtain fee stats: boom")
+}
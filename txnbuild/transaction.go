@@ -1024,6 +1024,17 @@ func NewFeeBumpTransaction(params FeeBumpTransactionParams) (*FeeBumpTransaction
 // Muxed accounts or ID memos can be provided to identity a user of a shared Stellar account.
 // More details on SEP 10: https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0010.md
 func BuildChallengeTx(serverSignerSecret, clientAccountID, webAuthDomain, homeDomain, network string, timebound time.Duration, memo *MemoID) (*Transaction, error) {
+	return BuildChallengeTxWithClientDomain(serverSignerSecret, clientAccountID, webAuthDomain, homeDomain, "", "", network, timebound, memo)
+}
+
+// BuildChallengeTxWithClientDomain is like BuildChallengeTx but also attributes
+// the challenge to the client domain, the domain of the client application the
+// user authenticates with, by adding a client_domain Manage Data operation with
+// the clientSigningKey as its source account. The clientSigningKey must be the
+// SIGNING_KEY of the stellar.toml hosted at the clientDomain, and the
+// challenge must be signed by it to be verified. No client_domain operation is
+// added if the clientDomain is empty.
+func BuildChallengeTxWithClientDomain(serverSignerSecret, clientAccountID, webAuthDomain, homeDomain, clientDomain, clientSigningKey, network string, timebound time.Duration, memo *MemoID) (*Transaction, error) {
 	if timebound < time.Second {
 		return nil, errors.New("provided timebound must be at least 1s (300s is recommended)")
 	}
@@ -1052,6 +1063,10 @@ func BuildChallengeTx(serverSignerSecret, clientAccountID, webAuthDomain, homeDo
 		}
 	}
 
+	if clientDomain != "" && !strkey.IsValidEd25519PublicKey(clientSigningKey) {
+		return nil, errors.Errorf("%s is not a valid client signing key", clientSigningKey)
+	}
+
 	// represent server signing account as SimpleAccount
 	sa := SimpleAccount{
 		AccountID: serverKP.Address(),
@@ -1083,6 +1098,13 @@ func BuildChallengeTx(serverSignerSecret, clientAccountID, webAuthDomain, homeDo
 			TimeBounds: NewTimebounds(currentTime.Unix(), maxTime.Unix()),
 		},
 	}
+	if clientDomain != "" {
+		txParams.Operations = append(txParams.Operations, &ManageData{
+			SourceAccount: clientSigningKey,
+			Name:          "client_domain",
+			Value:         []byte(clientDomain),
+		})
+	}
 	// Do not replace this if-then-assign block by assigning `memo` within the `TransactionParams`
 	// struct above. Doing so will cause errors as described here: https://go.dev/doc/faq#nil_error
 	if memo != nil {
@@ -1131,6 +1153,10 @@ func generateRandomNonce(n int) ([]byte, error) {
 // web_auth_domain the value will be checked to match the webAuthDomain
 // provided. If it does not match the function will return an error.
 //
+// The challenge may contain a subsequent Manage Data operation with key
+// client_domain, whose source account is the client domain's signing key.
+// Use ChallengeTxClientDomain to get the client domain.
+//
 // It does not verify that the transaction has been signed by the client or
 // that any signatures other than the servers on the transaction are valid. Use
 // one of the following functions to completely verify the transaction:
@@ -1235,6 +1261,7 @@ func ReadChallengeTx(challengeTx, serverAccountID, network, webAuthDomain string
 	}
 
 	// verify subsequent operations are manage data ops and known, or unknown with source account set to server account
+	clientDomainOpFound := false
 	for _, op := range operations[1:] {
 		op, ok := op.(*ManageData)
 		if !ok {
@@ -1251,6 +1278,17 @@ func ReadChallengeTx(challengeTx, serverAccountID, network, webAuthDomain string
 			if !bytes.Equal(op.Value, []byte(webAuthDomain)) {
 				return tx, clientAccountID, matchedHomeDomain, memo, errors.Errorf("web auth domain operation value is %q but expect %q", string(op.Value), webAuthDomain)
 			}
+		case "client_domain":
+			if clientDomainOpFound {
+				return tx, clientAccountID, matchedHomeDomain, memo, errors.New("challenge has more than one client domain operation")
+			}
+			clientDomainOpFound = true
+			if !strkey.IsValidEd25519PublicKey(op.SourceAccount) {
+				return tx, clientAccountID, matchedHomeDomain, memo, errors.New("client domain operation source account must be a Stellar account")
+			}
+			if len(op.Value) == 0 {
+				return tx, clientAccountID, matchedHomeDomain, memo, errors.New("client domain operation has no value")
+			}
 		default:
 			// verify unknown subsequent operations are manage data ops with source account set to server account
 			if op.SourceAccount != serverAccountID {
@@ -1267,6 +1305,20 @@ func ReadChallengeTx(challengeTx, serverAccountID, network, webAuthDomain string
 	return tx, clientAccountID, matchedHomeDomain, memo, nil
 }
 
+// ChallengeTxClientDomain returns the client domain a SEP 10 challenge
+// transaction is attributed to and the client domain's signing key, which are
+// empty if the challenge has no client_domain Manage Data operation. It
+// doesn't validate the challenge, use ReadChallengeTx first.
+func ChallengeTxClientDomain(tx *Transaction) (clientDomain string, clientSigningKey string) {
+	for _, op := range tx.Operations() {
+		op, ok := op.(*ManageData)
+		if ok && op.Name == "client_domain" {
+			return string(op.Value), op.SourceAccount
+		}
+	}
+	return "", ""
+}
+
 // VerifyChallengeTxThreshold verifies that for a SEP 10 challenge transaction
 // all signatures on the transaction are accounted for and that the signatures
 // meet a threshold on an account. A transaction is verified if it is signed by
@@ -1381,24 +1433,41 @@ func VerifyChallengeTxSigners(challengeTx, serverAccountID, network, webAuthDoma
 		return nil, errors.New("no verifiable signers provided, at least one G... address must be provided")
 	}
 
-	// Verify all the transaction's signers (server and client) in one
-	// hit. We do this in one hit here even though the server signature was
-	// checked in the ReadChallengeTx to ensure that every signature and signer
-	// are consumed only once on the transaction.
+	// A challenge attributed to a client domain must also be signed by the
+	// client domain's signing key, which isn't a client signer unless it was
+	// provided as one.
+	_, clientDomainSigner := ChallengeTxClientDomain(tx)
+	clientDomainSignerIsClientSigner := clientSignersSeen.Contains(clientDomainSigner)
+
+	// Verify all the transaction's signers (server, client and client domain)
+	// in one hit. We do this in one hit here even though the server signature
+	// was checked in the ReadChallengeTx to ensure that every signature and
+	// signer are consumed only once on the transaction.
 	allSigners := append([]string{serverKP.Address()}, clientSigners...)
+	if clientDomainSigner != "" && !clientDomainSignerIsClientSigner && clientDomainSigner != serverKP.Address() {
+		allSigners = append(allSigners, clientDomainSigner)
+	}
 	allSignersFound, err := verifyTxSignatures(tx, network, allSigners...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Confirm the server is in the list of signers found and remove it.
+	// Confirm the server and client domain signers are in the list of signers
+	// found and remove them.
 	serverSignerFound := false
+	clientDomainSignerFound := false
 	signersFound := make([]string, 0, len(allSignersFound)-1)
 	for _, signer := range allSignersFound {
 		if signer == serverKP.Address() {
 			serverSignerFound = true
 			continue
 		}
+		if signer == clientDomainSigner {
+			clientDomainSignerFound = true
+			if !clientDomainSignerIsClientSigner {
+				continue
+			}
+		}
 		signersFound = append(signersFound, signer)
 	}
 
@@ -1407,6 +1476,11 @@ func VerifyChallengeTxSigners(challengeTx, serverAccountID, network, webAuthDoma
 		return nil, errors.Errorf("transaction not signed by %s", serverKP.Address())
 	}
 
+	// Confirm we matched a signature to the client domain signer.
+	if clientDomainSigner != "" && !clientDomainSignerFound {
+		return nil, errors.Errorf("transaction not signed by client domain signer %s", clientDomainSigner)
+	}
+
 	// Confirm we matched signatures to the client signers.
 	if len(signersFound) == 0 {
 		return nil, errors.Errorf("transaction not signed by %s", strings.Join(clientSigners, ", "))
@@ -1156,6 +1156,120 @@ func TestBuildChallengeTx(t *testing.T) {
 	}
 }
 
+func TestBuildChallengeTxWithClientDomain(t *testing.T) {
+	serverKP := newKeypair0()
+	clientKP := newKeypair1()
+	clientDomainKP := newKeypair2()
+
+	tx, err := BuildChallengeTxWithClientDomain(serverKP.Seed(), clientKP.Address(), "testwebauth.stellar.org", "testanchor.stellar.org", "testwallet.stellar.org", clientDomainKP.Address(), network.TestNetworkPassphrase, time.Minute, nil)
+	require.NoError(t, err)
+	require.Len(t, tx.Operations(), 3)
+	clientDomainOp, ok := tx.Operations()[2].(*ManageData)
+	require.True(t, ok)
+	assert.Equal(t, clientDomainKP.Address(), clientDomainOp.SourceAccount)
+	assert.Equal(t, "client_domain", clientDomainOp.Name)
+	assert.Equal(t, []byte("testwallet.stellar.org"), clientDomainOp.Value)
+
+	clientDomain, clientSigningKey := ChallengeTxClientDomain(tx)
+	assert.Equal(t, "testwallet.stellar.org", clientDomain)
+	assert.Equal(t, clientDomainKP.Address(), clientSigningKey)
+
+	// No client_domain operation is added without a client domain.
+	tx, err = BuildChallengeTxWithClientDomain(serverKP.Seed(), clientKP.Address(), "testwebauth.stellar.org", "testanchor.stellar.org", "", "", network.TestNetworkPassphrase, time.Minute, nil)
+	require.NoError(t, err)
+	assert.Len(t, tx.Operations(), 2)
+	clientDomain, clientSigningKey = ChallengeTxClientDomain(tx)
+	assert.Empty(t, clientDomain)
+	assert.Empty(t, clientSigningKey)
+
+	_, err = BuildChallengeTxWithClientDomain(serverKP.Seed(), clientKP.Address(), "testwebauth.stellar.org", "testanchor.stellar.org", "testwallet.stellar.org", clientDomainKP.Seed(), network.TestNetworkPassphrase, time.Minute, nil)
+	assert.EqualError(t, err, clientDomainKP.Seed()+" is not a valid client signing key")
+}
+
+func TestVerifyChallengeTxSigners_clientDomain(t *testing.T) {
+	serverKP := newKeypair0()
+	clientKP := newKeypair1()
+	clientDomainKP := newKeypair2()
+
+	tx, err := BuildChallengeTxWithClientDomain(serverKP.Seed(), clientKP.Address(), "testwebauth.stellar.org", "testanchor.stellar.org", "testwallet.stellar.org", clientDomainKP.Address(), network.TestNetworkPassphrase, time.Minute, nil)
+	require.NoError(t, err)
+
+	// The client domain signing key is required to sign the challenge.
+	txClientOnly, err := tx.Sign(network.TestNetworkPassphrase, clientKP)
+	require.NoError(t, err)
+	tx64, err := txClientOnly.Base64()
+	require.NoError(t, err)
+	_, _, _, _, err = ReadChallengeTx(tx64, serverKP.Address(), network.TestNetworkPassphrase, "testwebauth.stellar.org", []string{"testanchor.stellar.org"})
+	require.NoError(t, err)
+	_, err = VerifyChallengeTxSigners(tx64, serverKP.Address(), network.TestNetworkPassphrase, "testwebauth.stellar.org", []string{"testanchor.stellar.org"}, clientKP.Address())
+	assert.EqualError(t, err, "transaction not signed by client domain signer "+clientDomainKP.Address())
+
+	// The client domain signing key isn't returned as a client signer.
+	txSigned, err := tx.Sign(network.TestNetworkPassphrase, clientKP, clientDomainKP)
+	require.NoError(t, err)
+	tx64, err = txSigned.Base64()
+	require.NoError(t, err)
+	signersFound, err := VerifyChallengeTxSigners(tx64, serverKP.Address(), network.TestNetworkPassphrase, "testwebauth.stellar.org", []string{"testanchor.stellar.org"}, clientKP.Address())
+	require.NoError(t, err)
+	assert.Equal(t, []string{clientKP.Address()}, signersFound)
+
+	signersFound, err = VerifyChallengeTxThreshold(tx64, serverKP.Address(), network.TestNetworkPassphrase, "testwebauth.stellar.org", []string{"testanchor.stellar.org"}, Threshold(1), SignerSummary{clientKP.Address(): 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{clientKP.Address()}, signersFound)
+
+	// Unless it is also provided as a client signer.
+	signersFound, err = VerifyChallengeTxSigners(tx64, serverKP.Address(), network.TestNetworkPassphrase, "testwebauth.stellar.org", []string{"testanchor.stellar.org"}, clientKP.Address(), clientDomainKP.Address())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{clientKP.Address(), clientDomainKP.Address()}, signersFound)
+}
+
+func TestReadChallengeTx_invalidClientDomainOps(t *testing.T) {
+	serverKP := newKeypair0()
+	clientKP := newKeypair1()
+	txSource := NewSimpleAccount(serverKP.Address(), -1)
+	op := ManageData{
+		SourceAccount: clientKP.Address(),
+		Name:          "testanchor.stellar.org auth",
+		Value:         []byte(base64.StdEncoding.EncodeToString(make([]byte, 48))),
+	}
+	clientDomainOp := ManageData{
+		SourceAccount: clientKP.Address(),
+		Name:          "client_domain",
+		Value:         []byte("testwallet.stellar.org"),
+	}
+	tx64, err := newSignedTransaction(
+		TransactionParams{
+			SourceAccount:        &txSource,
+			IncrementSequenceNum: true,
+			Operations:           []Operation{&op, &clientDomainOp, &clientDomainOp},
+			BaseFee:              MinBaseFee,
+			Preconditions:        Preconditions{TimeBounds: NewTimeout(1000)},
+		},
+		network.TestNetworkPassphrase,
+		serverKP,
+	)
+	require.NoError(t, err)
+	_, _, _, _, err = ReadChallengeTx(tx64, serverKP.Address(), network.TestNetworkPassphrase, "testwebauth.stellar.org", []string{"testanchor.stellar.org"})
+	assert.EqualError(t, err, "challenge has more than one client domain operation")
+
+	clientDomainOp.Value = nil
+	txSource = NewSimpleAccount(serverKP.Address(), -1)
+	tx64, err = newSignedTransaction(
+		TransactionParams{
+			SourceAccount:        &txSource,
+			IncrementSequenceNum: true,
+			Operations:           []Operation{&op, &clientDomainOp},
+			BaseFee:              MinBaseFee,
+			Preconditions:        Preconditions{TimeBounds: NewTimeout(1000)},
+		},
+		network.TestNetworkPassphrase,
+		serverKP,
+	)
+	require.NoError(t, err)
+	_, _, _, _, err = ReadChallengeTx(tx64, serverKP.Address(), network.TestNetworkPassphrase, "testwebauth.stellar.org", []string{"testanchor.stellar.org"})
+	assert.EqualError(t, err, "client domain operation has no value")
+}
+
 func TestHashHex(t *testing.T) {
 	kp0 := newKeypair0()
 	sourceAccount := NewSimpleAccount(kp0.Address(), int64(9605939170639897))